@@ -0,0 +1,278 @@
+// Copyright 2021 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package abort undoes a partially executed release.
+package abort
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+
+	gh "github.com/google/go-github/v50/github"
+	flag "github.com/spf13/pflag"
+
+	"github.com/cilium/release/pkg/confirm"
+	"github.com/cilium/release/pkg/github"
+	"github.com/cilium/release/pkg/persistence"
+	"github.com/cilium/release/pkg/types"
+)
+
+const usage = "usage: abort <version> [flags]"
+
+// aborter undoes the steps of a release, asking for confirmation before
+// each destructive action.
+type aborter struct {
+	ghClient    *gh.Client
+	owner, repo string
+	dryRun      bool
+	// gate reports the actions on the tracking issue. Confirming the step
+	// with --confirm doesn't confirm its actions, each of them is still
+	// prompted for.
+	gate *confirm.Gate
+	// kept is set once an action is declined, or can't be undone, in which
+	// case the state of the steps is kept.
+	kept bool
+
+	in  *bufio.Reader
+	out io.Writer
+}
+
+// confirm asks whether the given action should be performed. It's never
+// performed with dryRun.
+func (a *aborter) confirm(action string) (bool, error) {
+	if a.dryRun {
+		fmt.Fprintf(a.out, "Would %s\n", action)
+		return false, nil
+	}
+	fmt.Fprintf(a.out, "%s? [y/N] ", strings.ToUpper(action[:1])+action[1:])
+	answer, err := a.in.ReadString('\n')
+	if err != nil && err != io.EOF {
+		return false, err
+	}
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	ok := answer == "y" || answer == "yes"
+	if !ok {
+		a.kept = true
+	}
+	return ok, nil
+}
+
+// executed prints an executed action and records it to be reported on the
+// tracking issue.
+func (a *aborter) executed(format string, args ...interface{}) {
+	fmt.Fprintf(a.out, format+"\n", args...)
+	a.gate.Executed(format, args...)
+}
+
+// Command implements the 'abort' subcommand, which undoes a partially
+// executed release: it deletes the draft release and the tag, if the release
+// isn't published, closes the version bump PRs opened by 'downstream bump'
+// and, once all of them are undone, removes the state files of the steps.
+func Command(ctx context.Context, ghClient *gh.Client, args []string) (err error) {
+	var (
+		repoName       string
+		stateFile      string
+		notesStateFile string
+		confirmURL     string
+		environment    string
+		dryRun         bool
+	)
+	fs := flag.NewFlagSet("abort", flag.ContinueOnError)
+	fs.StringVar(&repoName, "repo", "cilium/cilium", "GitHub organization and repository names separated by a slash")
+	fs.StringVar(&stateFile, "state-file", persistence.DownstreamStateFile, "State file of 'downstream bump' containing the version bump PRs")
+	fs.StringVar(&notesStateFile, "notes-state-file", "release-state.json", "State file of the release notes of the release, removed so that they are generated from scratch on the next attempt. Kept if empty")
+	confirm.AddFlags(fs, &confirmURL, &environment)
+	fs.BoolVar(&dryRun, "dry-run", false, "Only print what would be undone")
+	if err := types.ParseFlags(fs, "abort", args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return fmt.Errorf(usage)
+	}
+	tag := "v" + strings.TrimPrefix(fs.Arg(0), "v")
+	owner, repo, err := types.SplitRepoName(repoName)
+	if err != nil {
+		return err
+	}
+
+	a := &aborter{
+		ghClient: ghClient,
+		owner:    owner,
+		repo:     repo,
+		dryRun:   dryRun,
+		in:       bufio.NewReader(os.Stdin),
+		out:      os.Stdout,
+	}
+	if !dryRun {
+		a.gate, err = confirm.Open(ctx, ghClient, "abort "+tag, confirmURL, environment, a.in, a.out)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			if rerr := a.gate.Report(ctx); rerr != nil && err == nil {
+				err = rerr
+			}
+		}()
+	}
+	if err := a.abortRelease(ctx, tag); err != nil {
+		return err
+	}
+	if err := a.abortDownstream(ctx, stateFile, strings.TrimPrefix(tag, "v")); err != nil {
+		return err
+	}
+	return a.resetState(notesStateFile, stateFile)
+}
+
+// abortRelease deletes the draft release of tag and the tag itself. It
+// fails if the release is already published as it can't be undone.
+func (a *aborter) abortRelease(ctx context.Context, tag string) error {
+	releases, err := github.ListReleases(ctx, a.ghClient, a.owner, a.repo)
+	if err != nil {
+		return fmt.Errorf("unable to list releases: %w", err)
+	}
+	for _, release := range releases {
+		if release.GetTagName() != tag {
+			continue
+		}
+		if !release.GetDraft() {
+			return fmt.Errorf("release %s is already published, it can't be aborted", tag)
+		}
+		ok, err := a.confirm(fmt.Sprintf("delete the draft release %s of %s/%s", tag, a.owner, a.repo))
+		if err != nil {
+			return err
+		}
+		if ok {
+			if _, err := a.ghClient.Repositories.DeleteRelease(ctx, a.owner, a.repo, release.GetID()); err != nil {
+				return fmt.Errorf("unable to delete the draft release %s: %w", tag, err)
+			}
+			a.executed("Deleted the draft release %s", tag)
+		}
+	}
+
+	_, resp, err := a.ghClient.Git.GetRef(ctx, a.owner, a.repo, "tags/"+tag)
+	if resp != nil && resp.StatusCode == http.StatusNotFound {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("unable to get tag %s: %w", tag, err)
+	}
+	ok, err := a.confirm(fmt.Sprintf("delete the tag %s of %s/%s", tag, a.owner, a.repo))
+	if err != nil || !ok {
+		return err
+	}
+	if _, err := a.ghClient.Git.DeleteRef(ctx, a.owner, a.repo, "tags/"+tag); err != nil {
+		return fmt.Errorf("unable to delete tag %s: %w", tag, err)
+	}
+	a.executed("Deleted the tag %s", tag)
+	return nil
+}
+
+// abortDownstream closes the open version bump PRs of the state file to the
+// given version, e.g. '1.14.3', and removes the closed ones from it, so that 'downstream bump' opens them
+// again. The merged PRs are kept and warned about as they need a revert.
+func (a *aborter) abortDownstream(ctx context.Context, stateFile, ver string) error {
+	if _, err := os.Stat(stateFile); err != nil {
+		return nil
+	}
+	state, err := persistence.Load(stateFile)
+	if err != nil {
+		return fmt.Errorf("unable to read state file: %w", err)
+	}
+	var kept []persistence.DownstreamPR
+	for _, dpr := range state.DownstreamPRs {
+		if len(dpr.Version) != 0 && dpr.Version != ver {
+			kept = append(kept, dpr)
+			continue
+		}
+		owner, repo, err := types.SplitRepoName(dpr.Repo)
+		if err != nil {
+			return err
+		}
+		pr, _, err := a.ghClient.PullRequests.Get(ctx, owner, repo, dpr.Number)
+		if err != nil {
+			return fmt.Errorf("unable to get %s: %w", dpr.URL, err)
+		}
+		switch {
+		case pr.GetMerged():
+			fmt.Fprintf(a.out, "WARNING: %s is already merged, it needs to be reverted\n", dpr.URL)
+			a.kept = true
+			kept = append(kept, dpr)
+			continue
+		case pr.GetState() == "open":
+			ok, err := a.confirm("close " + dpr.URL)
+			if err != nil {
+				return err
+			}
+			if !ok {
+				kept = append(kept, dpr)
+				continue
+			}
+			if _, _, err := a.ghClient.PullRequests.Edit(ctx, owner, repo, dpr.Number, &gh.PullRequest{State: gh.String("closed")}); err != nil {
+				return fmt.Errorf("unable to close %s: %w", dpr.URL, err)
+			}
+			a.executed("Closed %s", dpr.URL)
+		}
+	}
+	if a.dryRun || len(kept) == len(state.DownstreamPRs) {
+		return nil
+	}
+	state.DownstreamPRs = kept
+	if err := persistence.Store(stateFile, state); err != nil {
+		return fmt.Errorf("unable to store state: %w", err)
+	}
+	return nil
+}
+
+// resetState removes the state file of the release notes and the one of
+// 'downstream bump', if it has no version bump PRs left, so that the next
+// attempt starts from scratch. They are kept if any action was declined or
+// can't be undone.
+func (a *aborter) resetState(notesStateFile, stateFile string) error {
+	if a.kept && !a.dryRun {
+		fmt.Fprintf(a.out, "Keeping the state files as the release isn't fully undone\n")
+		return nil
+	}
+	var files []string
+	if _, err := os.Stat(notesStateFile); len(notesStateFile) != 0 && err == nil {
+		files = append(files, notesStateFile)
+	}
+	if _, err := os.Stat(stateFile); err == nil {
+		state, err := persistence.Load(stateFile)
+		if err != nil {
+			return fmt.Errorf("unable to read state file: %w", err)
+		}
+		if len(state.DownstreamPRs) == 0 {
+			files = append(files, stateFile)
+		}
+	}
+	for _, file := range files {
+		ok, err := a.confirm("remove the state file " + file)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			continue
+		}
+		if err := os.Remove(file); err != nil {
+			return fmt.Errorf("unable to remove state file: %w", err)
+		}
+		a.executed("Removed the state file %s", file)
+	}
+	return nil
+}
@@ -0,0 +1,191 @@
+// Copyright 2021 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package abort
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	gh "github.com/google/go-github/v50/github"
+
+	"github.com/cilium/release/pkg/confirm"
+	"github.com/cilium/release/pkg/persistence"
+)
+
+func TestAbort(t *testing.T) {
+	var deleted []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method + " " + r.URL.Path {
+		case "GET /repos/cilium/cilium/issues/1":
+			fmt.Fprint(w, `{"number": 1, "state": "open"}`)
+		case "GET /repos/cilium/cilium/releases":
+			fmt.Fprint(w, `[{"id": 2, "tag_name": "v1.14.3", "draft": true}, {"id": 1, "tag_name": "v1.14.2"}]`)
+		case "GET /repos/cilium/cilium/git/ref/tags/v1.14.3":
+			fmt.Fprint(w, `{"ref": "refs/tags/v1.14.3"}`)
+		case "GET /repos/cilium/cilium-cli/pulls/10":
+			fmt.Fprint(w, `{"number": 10, "state": "open"}`)
+		case "GET /repos/cilium/charts/pulls/20":
+			fmt.Fprint(w, `{"number": 20, "state": "closed", "merged": true}`)
+		case "DELETE /repos/cilium/cilium/releases/2", "DELETE /repos/cilium/cilium/git/refs/tags/v1.14.3", "PATCH /repos/cilium/cilium-cli/pulls/10":
+			deleted = append(deleted, r.Method+" "+r.URL.Path)
+			fmt.Fprint(w, `{}`)
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL)
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+	ghClient := gh.NewClient(nil)
+	ghClient.BaseURL, _ = url.Parse(srv.URL + "/")
+
+	stateFile := filepath.Join(t.TempDir(), "state.json")
+	err := persistence.Store(stateFile, &persistence.State{
+		DownstreamPRs: []persistence.DownstreamPR{
+			{Repo: "cilium/cilium-cli", Version: "1.14.3", Number: 10, URL: "https://github.com/cilium/cilium-cli/pull/10"},
+			{Repo: "cilium/charts", Version: "1.14.3", Number: 20, URL: "https://github.com/cilium/charts/pull/20"},
+			// The PRs of other versions are left alone.
+			{Repo: "cilium/cilium-cli", Version: "1.13.8", Number: 9, URL: "https://github.com/cilium/cilium-cli/pull/9"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The URL of the tracking issue and the answers are read from the same
+	// input. The tag is kept.
+	var out bytes.Buffer
+	a := &aborter{
+		ghClient: ghClient,
+		owner:    "cilium",
+		repo:     "cilium",
+		in:       bufio.NewReader(strings.NewReader("https://github.com/cilium/cilium/issues/1\ny\nn\ny\n")),
+		out:      &out,
+	}
+	a.gate, err = confirm.Open(context.Background(), ghClient, "abort v1.14.3", "", "", a.in, a.out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := a.abortRelease(context.Background(), "v1.14.3"); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.abortDownstream(context.Background(), stateFile, "1.14.3"); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"DELETE /repos/cilium/cilium/releases/2", "PATCH /repos/cilium/cilium-cli/pulls/10"}
+	if !reflect.DeepEqual(deleted, want) {
+		t.Errorf("got requests %v, want %v", deleted, want)
+	}
+	state, err := persistence.Load(stateFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(state.DownstreamPRs) != 2 || state.DownstreamPRs[0].Number != 20 || state.DownstreamPRs[1].Number != 9 {
+		t.Errorf("got downstream PRs %+v, want only the merged one and the one of 1.13.8", state.DownstreamPRs)
+	}
+	if !strings.Contains(out.String(), "WARNING: https://github.com/cilium/charts/pull/20 is already merged") {
+		t.Errorf("merged PR not warned about:\n%s", out.String())
+	}
+
+	// The tag was kept, so is the state.
+	notesStateFile := filepath.Join(t.TempDir(), "release-state.json")
+	if err := persistence.Store(notesStateFile, &persistence.State{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.resetState(notesStateFile, stateFile); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(notesStateFile); err != nil {
+		t.Errorf("state file removed although the release isn't fully undone: %v", err)
+	}
+}
+
+func TestAbortResetState(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method + " " + r.URL.Path {
+		case "GET /repos/cilium/cilium-cli/pulls/10":
+			fmt.Fprint(w, `{"number": 10, "state": "open"}`)
+		case "PATCH /repos/cilium/cilium-cli/pulls/10":
+			fmt.Fprint(w, `{}`)
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL)
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+	ghClient := gh.NewClient(nil)
+	ghClient.BaseURL, _ = url.Parse(srv.URL + "/")
+
+	dir := t.TempDir()
+	stateFile := filepath.Join(dir, "downstream-state.json")
+	err := persistence.Store(stateFile, &persistence.State{
+		DownstreamPRs: []persistence.DownstreamPR{
+			{Repo: "cilium/cilium-cli", Version: "1.14.3", Number: 10, URL: "https://github.com/cilium/cilium-cli/pull/10"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	notesStateFile := filepath.Join(dir, "release-state.json")
+	if err := persistence.Store(notesStateFile, &persistence.State{}); err != nil {
+		t.Fatal(err)
+	}
+
+	// The removal of the state of the release notes is declined.
+	var out bytes.Buffer
+	a := &aborter{
+		ghClient: ghClient,
+		in:       bufio.NewReader(strings.NewReader("y\nn\ny\n")),
+		out:      &out,
+	}
+	if err := a.abortDownstream(context.Background(), stateFile, "1.14.3"); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.resetState(notesStateFile, stateFile); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(notesStateFile); err != nil {
+		t.Errorf("declined removal of %s: %v", notesStateFile, err)
+	}
+	if _, err := os.Stat(stateFile); !os.IsNotExist(err) {
+		t.Errorf("state file %s not removed: %v", stateFile, err)
+	}
+	if !strings.Contains(out.String(), "Remove the state file "+notesStateFile+"? [y/N]") {
+		t.Errorf("removal not confirmed:\n%s", out.String())
+	}
+}
+
+func TestAbortPublished(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[{"id": 2, "tag_name": "v1.14.3"}]`)
+	}))
+	defer srv.Close()
+	ghClient := gh.NewClient(nil)
+	ghClient.BaseURL, _ = url.Parse(srv.URL + "/")
+
+	a := &aborter{ghClient: ghClient, owner: "cilium", repo: "cilium"}
+	if err := a.abortRelease(context.Background(), "v1.14.3"); err == nil {
+		t.Error("published release aborted")
+	}
+}
@@ -0,0 +1,137 @@
+// Copyright 2021 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backport
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	gh "github.com/google/go-github/v50/github"
+	flag "github.com/spf13/pflag"
+
+	"github.com/cilium/release/pkg/github"
+	"github.com/cilium/release/pkg/profile"
+	"github.com/cilium/release/pkg/types"
+)
+
+func auditCommand(ctx context.Context, ghClient *gh.Client, args []string) error {
+	var (
+		repoName    string
+		branch      string
+		profileName string
+	)
+	fs := flag.NewFlagSet("backport audit", flag.ContinueOnError)
+	fs.StringVar(&repoName, "repo", "cilium/cilium", "GitHub organization and repository names separated by a slash")
+	fs.StringVar(&branch, "branch", "", "Stable branch to audit (e.g.: 'v1.14')")
+	addProfileFlag(fs, &profileName)
+	if err := types.ParseFlags(fs, "backport audit", args); err != nil {
+		return err
+	}
+	p, err := profile.Get(profileName)
+	if err != nil {
+		return err
+	}
+	if len(branch) == 0 {
+		return fmt.Errorf("--branch must be set")
+	}
+	owner, repo, err := types.SplitRepoName(repoName)
+	if err != nil {
+		return err
+	}
+
+	problems, err := audit(ctx, ghClient, p, owner, repo, branch)
+	if err != nil {
+		return err
+	}
+	if len(problems) == 0 {
+		fmt.Fprintf(os.Stdout, "Backport labels are consistent with the PRs merged into %s\n", branch)
+		return nil
+	}
+	for _, problem := range problems {
+		fmt.Fprintf(os.Stdout, "%s\n", problem)
+	}
+	return fmt.Errorf("found %d inconsistencies in the backport labels", len(problems))
+}
+
+// audit compares the upstream PRs referenced by the backport PRs merged into
+// the given branch with the upstream PRs labeled as backported to it, and
+// returns the inconsistencies found.
+func audit(ctx context.Context, ghClient *gh.Client, p profile.Profile, owner, repo, branch string) ([]string, error) {
+	ver := p.StableVersion(branch)
+
+	merged, err := github.SearchIssues(ctx, ghClient, fmt.Sprintf("repo:%s/%s is:pr is:merged base:%s", owner, repo, branch))
+	if err != nil {
+		return nil, fmt.Errorf("unable to search PRs merged into %s: %w", branch, err)
+	}
+	// backportedBy maps an upstream PR to the backport PR that merged it.
+	backportedBy := map[int]int{}
+	for _, pr := range merged {
+		for _, upstreamPR := range github.UpstreamPRs(pr.GetBody()) {
+			backportedBy[upstreamPR] = pr.GetNumber()
+		}
+	}
+
+	labeled, err := github.SearchIssues(ctx, ghClient, fmt.Sprintf("repo:%s/%s is:pr label:%s%s", owner, repo, p.DoneBackportPrefix, ver))
+	if err != nil {
+		return nil, fmt.Errorf("unable to search PRs labeled %s%s: %w", p.DoneBackportPrefix, ver, err)
+	}
+	labeledDone := map[int]bool{}
+	for _, pr := range labeled {
+		labeledDone[pr.GetNumber()] = true
+	}
+
+	var upstreamPRs []int
+	for upstreamPR := range backportedBy {
+		upstreamPRs = append(upstreamPRs, upstreamPR)
+	}
+	sort.Ints(upstreamPRs)
+
+	var problems []string
+	for _, upstreamPR := range upstreamPRs {
+		if labeledDone[upstreamPR] {
+			continue
+		}
+		pr, _, err := ghClient.PullRequests.Get(ctx, owner, repo, upstreamPR)
+		if err != nil {
+			return nil, fmt.Errorf("unable to get upstream PR %d: %w", upstreamPR, err)
+		}
+		var stale []string
+		for _, lbl := range pr.Labels {
+			if lbl.GetName() == p.NeedsBackportPrefix+ver || lbl.GetName() == p.PendingBackportPrefix+ver {
+				stale = append(stale, lbl.GetName())
+			}
+		}
+		problem := fmt.Sprintf("upstream PR #%d was backported by #%d but is not labeled %s%s", upstreamPR, backportedBy[upstreamPR], p.DoneBackportPrefix, ver)
+		if len(stale) != 0 {
+			problem += fmt.Sprintf(" (still labeled %s)", strings.Join(stale, ", "))
+		}
+		problems = append(problems, problem)
+	}
+
+	var notBackported []int
+	for upstreamPR := range labeledDone {
+		if _, ok := backportedBy[upstreamPR]; !ok {
+			notBackported = append(notBackported, upstreamPR)
+		}
+	}
+	sort.Ints(notBackported)
+	for _, upstreamPR := range notBackported {
+		problems = append(problems, fmt.Sprintf("upstream PR #%d is labeled %s%s but no backport PR merged into %s references it", upstreamPR, p.DoneBackportPrefix, ver, branch))
+	}
+	return problems, nil
+}
@@ -0,0 +1,63 @@
+// Copyright 2021 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backport
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	gh "github.com/google/go-github/v50/github"
+	flag "github.com/spf13/pflag"
+
+	"github.com/cilium/release/pkg/profile"
+)
+
+var subcommands = map[string]func(ctx context.Context, ghClient *gh.Client, args []string) error{
+	"audit":     auditCommand,
+	"create":    createCommand,
+	"describe":  describeCommand,
+	"digest":    digestCommand,
+	"labels":    labelsCommand,
+	"list":      listCommand,
+	"preflight": preflightCommand,
+	"reviewers": reviewersCommand,
+	"suggest":   suggestCommand,
+	"validate":  validateCommand,
+}
+
+// addProfileFlag adds to fs the --profile flag selecting the label scheme of
+// the repository, which defines the backport labels.
+func addProfileFlag(fs *flag.FlagSet, name *string) {
+	fs.StringVar(name, "profile", profile.Default, fmt.Sprintf("Label scheme of the repository defining the backport labels, one of %s", strings.Join(profile.Names(), ", ")))
+}
+
+// Command implements the 'backport' subcommand.
+func Command(ctx context.Context, ghClient *gh.Client, args []string) error {
+	var names []string
+	for name := range subcommands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	if len(args) == 0 {
+		return fmt.Errorf("usage: backport {%s} [flags]", strings.Join(names, "|"))
+	}
+	subcommand, ok := subcommands[args[0]]
+	if !ok {
+		return fmt.Errorf("unknown subcommand %q, usage: backport {%s} [flags]", args[0], strings.Join(names, "|"))
+	}
+	return subcommand(ctx, ghClient, args[1:])
+}
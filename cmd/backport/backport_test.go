@@ -0,0 +1,105 @@
+// Copyright 2021 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backport
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	gh "github.com/google/go-github/v50/github"
+
+	"github.com/cilium/release/pkg/git"
+)
+
+// newTestClient returns a client of a fake GitHub server answering the
+// requests, given as 'METHOD /path', with the given JSON bodies, and the
+// other requests modifying the repository with no content. The requests
+// modifying the repository are recorded into the returned slice as
+// 'METHOD /path body', in the order they are received.
+func newTestClient(t *testing.T, responses map[string]string) (*gh.Client, *[]string) {
+	t.Helper()
+	var requests []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Method + " " + r.URL.Path
+		if r.Method != http.MethodGet {
+			body, _ := io.ReadAll(r.Body)
+			requests = append(requests, strings.TrimSpace(key+" "+strings.TrimSpace(string(body))))
+		}
+		body, ok := responses[key]
+		switch {
+		case ok:
+			io.WriteString(w, body)
+		case r.Method == http.MethodGet:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL)
+			http.NotFound(w, r)
+		default:
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	ghClient := gh.NewClient(nil)
+	ghClient.BaseURL, _ = url.Parse(srv.URL + "/")
+	return ghClient, &requests
+}
+
+// newTestRepo creates a git repository, with a 'main' branch, as the current
+// working directory of the test and returns a function running git in it.
+func newTestRepo(t *testing.T) func(args ...string) string {
+	t.Helper()
+	dir := t.TempDir()
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+	for _, env := range []string{"GIT_AUTHOR_NAME", "GIT_COMMITTER_NAME"} {
+		t.Setenv(env, "Test")
+	}
+	for _, env := range []string{"GIT_AUTHOR_EMAIL", "GIT_COMMITTER_EMAIL"} {
+		t.Setenv(env, "test@example.com")
+	}
+	t.Setenv("GIT_CONFIG_GLOBAL", filepath.Join(dir, ".gitconfig"))
+	run := func(args ...string) string {
+		t.Helper()
+		out, err := git.Run("", args...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return out
+	}
+	run("init", "--quiet", "--initial-branch=main")
+	return run
+}
+
+// commitFile writes content into file and commits it with the given subject,
+// returning the SHA of the commit.
+func commitFile(t *testing.T, run func(args ...string) string, file, content, subject string) string {
+	t.Helper()
+	if err := os.WriteFile(file, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	run("add", file)
+	run("commit", "--quiet", "-m", subject)
+	return run("rev-parse", "HEAD")
+}
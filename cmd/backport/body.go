@@ -0,0 +1,49 @@
+// Copyright 2021 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backport
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	gh "github.com/google/go-github/v50/github"
+
+	"github.com/cilium/release/pkg/profile"
+)
+
+// prTitle returns the title of a backport PR for the given branch.
+func prTitle(branch string, now time.Time) string {
+	return fmt.Sprintf("%s backports %s", branch, now.Format("2006-01-02"))
+}
+
+// prBody renders the body of a backport PR containing the given upstream
+// PRs. The "upstream-prs" block is the one parsed when generating the
+// release notes.
+func prBody(p profile.Profile, branch string, upstreamPRs []*gh.PullRequest) string {
+	var sb strings.Builder
+	numbers := make([]string, 0, len(upstreamPRs))
+	for _, pr := range upstreamPRs {
+		fmt.Fprintf(&sb, " * #%d -- %s (@%s)\n", pr.GetNumber(), pr.GetTitle(), pr.GetUser().GetLogin())
+		numbers = append(numbers, strconv.Itoa(pr.GetNumber()))
+	}
+	sb.WriteString("\nOnce this PR is merged, you can update the PR labels via:\n")
+	sb.WriteString("```upstream-prs\n")
+	fmt.Fprintf(&sb, "$ for pr in %s; do contrib/backporting/set-labels.py $pr done %s; done\n",
+		strings.Join(numbers, " "), p.StableVersion(branch))
+	sb.WriteString("```\n")
+	return sb.String()
+}
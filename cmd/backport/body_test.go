@@ -0,0 +1,94 @@
+// Copyright 2021 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backport
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	gh "github.com/google/go-github/v50/github"
+
+	"github.com/cilium/release/pkg/profile"
+)
+
+func Test_prBody(t *testing.T) {
+	prs := []*gh.PullRequest{
+		{
+			Number: gh.Int(9959),
+			Title:  gh.String("Fix foo"),
+			User:   &gh.User{Login: gh.String("alice")},
+		},
+		{
+			Number: gh.Int(9982),
+			Title:  gh.String("Fix bar"),
+			User:   &gh.User{Login: gh.String("bob")},
+		},
+	}
+	want := " * #9959 -- Fix foo (@alice)\n" +
+		" * #9982 -- Fix bar (@bob)\n" +
+		"\n" +
+		"Once this PR is merged, you can update the PR labels via:\n" +
+		"```upstream-prs\n" +
+		"$ for pr in 9959 9982; do contrib/backporting/set-labels.py $pr done 1.14; done\n" +
+		"```\n"
+	p, _ := profile.Get("cilium")
+	if got := prBody(p, "v1.14", prs); got != want {
+		t.Errorf("prBody() = %q, want %q", got, want)
+	}
+}
+
+func Test_describe(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/repos/cilium/cilium/pulls/9959":
+			fmt.Fprint(w, `{"number": 9959, "title": "Fix foo", "merged": true, "user": {"login": "alice"}}`)
+		case "/repos/cilium/cilium/pulls/9982":
+			fmt.Fprint(w, `{"number": 9982, "title": "Fix bar", "merged": true, "user": {"login": "alice"}}`)
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL)
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+	ghClient := gh.NewClient(nil)
+	ghClient.BaseURL, _ = url.Parse(srv.URL + "/")
+
+	var buf bytes.Buffer
+	now := time.Date(2023, 7, 12, 0, 0, 0, 0, time.UTC)
+	p, _ := profile.Get("cilium")
+	if err := describe(context.Background(), ghClient, p, &buf, "cilium", "cilium", "v1.14", []int{9959, 9982}, now); err != nil {
+		t.Fatal(err)
+	}
+	want := "Title: v1.14 backports 2023-07-12\n" +
+		"Labels: kind/backports, backport/1.14\n" +
+		"Cc: @alice\n" +
+		"\n" +
+		" * #9959 -- Fix foo (@alice)\n" +
+		" * #9982 -- Fix bar (@alice)\n" +
+		"\n" +
+		"Once this PR is merged, you can update the PR labels via:\n" +
+		"```upstream-prs\n" +
+		"$ for pr in 9959 9982; do contrib/backporting/set-labels.py $pr done 1.14; done\n" +
+		"```\n"
+	if buf.String() != want {
+		t.Errorf("got:\n%s\nwant:\n%s", buf.String(), want)
+	}
+}
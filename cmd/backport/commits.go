@@ -0,0 +1,98 @@
+// Copyright 2021 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backport
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	gh "github.com/google/go-github/v50/github"
+
+	"github.com/cilium/release/pkg/git"
+)
+
+// listCommits returns all commits of the given PR.
+func listCommits(ctx context.Context, ghClient *gh.Client, owner, repo string, prNumber int) ([]*gh.RepositoryCommit, error) {
+	var commits []*gh.RepositoryCommit
+	opts := &gh.ListOptions{PerPage: 100}
+	for {
+		c, resp, err := ghClient.PullRequests.ListCommits(ctx, owner, repo, prNumber, opts)
+		if err != nil {
+			return nil, err
+		}
+		commits = append(commits, c...)
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+	return commits, nil
+}
+
+// upstreamCommits returns the SHAs of the commits merged into the main
+// branch by the given upstream PR, ordered from the oldest to the newest.
+// The commits must be present in the local clone.
+func upstreamCommits(ctx context.Context, ghClient *gh.Client, owner, repo string, pr *gh.PullRequest) ([]string, error) {
+	if !pr.GetMerged() {
+		return nil, fmt.Errorf("PR %d is not merged", pr.GetNumber())
+	}
+	prCommits, err := listCommits(ctx, ghClient, owner, repo, pr.GetNumber())
+	if err != nil {
+		return nil, err
+	}
+
+	mergeSHA := pr.GetMergeCommitSHA()
+	out, err := git.Run("", "rev-list", "--reverse", fmt.Sprintf("--max-count=%d", len(prCommits)), mergeSHA)
+	if err != nil {
+		return nil, err
+	}
+	shas := strings.Fields(out)
+	// If the PR was rebased the last len(prCommits) commits, ending with the
+	// merge commit, are the commits of the PR. Otherwise it was squashed and
+	// only the merge commit needs to be picked.
+	if len(shas) != len(prCommits) {
+		return []string{mergeSHA}, nil
+	}
+	for i, sha := range shas {
+		subject, err := git.Run("", "log", "-1", "--format=%s", sha)
+		if err != nil {
+			return nil, err
+		}
+		prSubject := strings.SplitN(prCommits[i].GetCommit().GetMessage(), "\n", 2)[0]
+		if subject != strings.TrimSpace(prSubject) {
+			return []string{mergeSHA}, nil
+		}
+	}
+	return shas, nil
+}
+
+// cherryPick cherry-picks the given commit, with a reference to it, onto the
+// HEAD of the worktree in dir. A merge commit, e.g. the one of a PR merged
+// with a merge commit, is picked relative to its first parent, i.e. the
+// branch it was merged into.
+func cherryPick(dir, sha string) error {
+	args := []string{"cherry-pick", "-x"}
+	parents, err := git.Run(dir, "rev-list", "--parents", "--max-count=1", sha)
+	if err != nil {
+		return err
+	}
+	// The commit itself is listed before its parents.
+	if len(strings.Fields(parents)) > 2 {
+		args = append(args, "-m", "1")
+	}
+	_, err = git.Run(dir, append(args, sha)...)
+	return err
+}
@@ -0,0 +1,179 @@
+// Copyright 2021 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backport
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	gh "github.com/google/go-github/v50/github"
+	flag "github.com/spf13/pflag"
+
+	"github.com/cilium/release/pkg/config"
+	"github.com/cilium/release/pkg/confirm"
+	"github.com/cilium/release/pkg/git"
+	"github.com/cilium/release/pkg/profile"
+	"github.com/cilium/release/pkg/types"
+)
+
+func createCommand(ctx context.Context, ghClient *gh.Client, args []string) (err error) {
+	var (
+		repoName       string
+		branch         string
+		prNumbers      []int
+		upstreamRemote string
+		forkRemote     string
+		mainBranch     string
+		reviews        bool
+		cfgFile        string
+		force          bool
+		confirmURL     string
+		environment    string
+		profileName    string
+	)
+	fs := flag.NewFlagSet("backport create", flag.ContinueOnError)
+	fs.StringVar(&repoName, "repo", "cilium/cilium", "GitHub organization and repository names separated by a slash")
+	fs.StringVar(&branch, "branch", "", "Stable branch to backport the PRs to (e.g.: 'v1.14')")
+	fs.IntSliceVar(&prNumbers, "pr", nil, "Upstream PRs to backport")
+	fs.StringVar(&upstreamRemote, "upstream-remote", "origin", "Git remote of the upstream repository")
+	fs.StringVar(&forkRemote, "fork-remote", "", "Git remote of the fork the backport branch is pushed to, defaults to the GitHub login of the token's owner. The PR is opened from the owner of the fork")
+	fs.StringVar(&mainBranch, "main-branch", "main", "Branch the upstream PRs were merged into")
+	fs.BoolVar(&reviews, "request-reviews", true, "Request reviews from the code owners of the changed files and the upstream PRs authors")
+	fs.StringVar(&cfgFile, "config", "", "Configuration file containing the end of life dates of the branches")
+	fs.BoolVar(&force, "force", false, "Backport the PRs even if the branch reached its end of life according to --config")
+	confirm.AddFlags(fs, &confirmURL, &environment)
+	addProfileFlag(fs, &profileName)
+	if err := types.ParseFlags(fs, "backport create", args); err != nil {
+		return err
+	}
+	p, err := profile.Get(profileName)
+	if err != nil {
+		return err
+	}
+	if len(branch) == 0 || len(prNumbers) == 0 {
+		return fmt.Errorf("--branch and --pr must be set")
+	}
+	owner, repo, err := types.SplitRepoName(repoName)
+	if err != nil {
+		return err
+	}
+	if len(cfgFile) != 0 {
+		cfg, err := config.Load(cfgFile)
+		if err != nil {
+			return fmt.Errorf("unable to load configuration: %w", err)
+		}
+		if err := cfg.Schedule.CheckVersionsEOL(time.Now(), force, branch); err != nil {
+			return err
+		}
+	}
+
+	gate, err := confirm.Open(ctx, ghClient, "backport create "+branch, confirmURL, environment, bufio.NewReader(os.Stdin), os.Stdout)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if rerr := gate.Report(ctx); rerr != nil && err == nil {
+			err = rerr
+		}
+	}()
+
+	if len(forkRemote) == 0 {
+		user, _, err := ghClient.Users.Get(ctx, "")
+		if err != nil {
+			return fmt.Errorf("unable to get authenticated user: %w", err)
+		}
+		forkRemote = user.GetLogin()
+	}
+	forkName, err := git.RemoteRepoName("", forkRemote)
+	if err != nil {
+		return fmt.Errorf("unable to find the fork of remote %s: %w", forkRemote, err)
+	}
+	forkOwner, _, err := types.SplitRepoName(forkName)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(os.Stderr, "Fetching %s/%s and %s/%s\n", upstreamRemote, branch, upstreamRemote, mainBranch)
+	if _, err := git.Run("", "fetch", upstreamRemote, branch, mainBranch); err != nil {
+		return err
+	}
+
+	// The commits are picked in a temporary worktree so that the checkout
+	// of the user, and its work in progress, is left untouched.
+	now := time.Now()
+	backportBranch := fmt.Sprintf("pr/%s-backport-%s", branch, now.Format("2006-01-02-15-04"))
+	dir, err := os.MkdirTemp("", "backport-create-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+	if _, err := git.Run("", "worktree", "add", "-b", backportBranch, dir, upstreamRemote+"/"+branch); err != nil {
+		return err
+	}
+	defer git.Run("", "worktree", "remove", "--force", dir)
+
+	var upstreamPRs []*gh.PullRequest
+	for _, prNumber := range prNumbers {
+		pr, _, err := ghClient.PullRequests.Get(ctx, owner, repo, prNumber)
+		if err != nil {
+			return fmt.Errorf("unable to get PR %d: %w", prNumber, err)
+		}
+		shas, err := upstreamCommits(ctx, ghClient, owner, repo, pr)
+		if err != nil {
+			return err
+		}
+		for _, sha := range shas {
+			fmt.Fprintf(os.Stderr, "Cherry-picking %s from PR %d\n", sha, prNumber)
+			if err := cherryPick(dir, sha); err != nil {
+				git.Run(dir, "cherry-pick", "--abort")
+				return fmt.Errorf("unable to cherry-pick PR %d, please backport it manually on top of branch %s: %w", prNumber, backportBranch, err)
+			}
+		}
+		upstreamPRs = append(upstreamPRs, pr)
+	}
+
+	if _, err := git.Run(dir, "push", forkRemote, backportBranch); err != nil {
+		return err
+	}
+
+	title := prTitle(branch, now)
+	body := prBody(p, branch, upstreamPRs)
+	head := forkOwner + ":" + backportBranch
+	pr, _, err := ghClient.PullRequests.Create(ctx, owner, repo, &gh.NewPullRequest{
+		Title: &title,
+		Head:  &head,
+		Base:  &branch,
+		Body:  &body,
+	})
+	if err != nil {
+		return fmt.Errorf("unable to create backport PR: %w", err)
+	}
+	_, _, err = ghClient.Issues.AddLabelsToIssue(ctx, owner, repo, pr.GetNumber(), p.BackportPRLabels(branch))
+	if err != nil {
+		return fmt.Errorf("unable to set labels in backport PR %d: %w", pr.GetNumber(), err)
+	}
+	fmt.Fprintf(os.Stdout, "Backport PR created: %s\n", pr.GetHTMLURL())
+	gate.Executed("Opened backport PR %s", pr.GetHTMLURL())
+	if reviews {
+		err = requestReviews(ctx, ghClient, owner, repo, pr, false)
+		if err != nil {
+			return err
+		}
+	}
+	return transitionLabels(ctx, ghClient, p, owner, repo, pr, false)
+}
@@ -0,0 +1,92 @@
+// Copyright 2021 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backport
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func Test_createCommand(t *testing.T) {
+	run := newTestRepo(t)
+	commitFile(t, run, "a.txt", "base\n", "Initial commit")
+	run("branch", "v1.14")
+	run("checkout", "--quiet", "-b", "feature")
+	commitFile(t, run, "b.txt", "foo\n", "Fix foo")
+	run("checkout", "--quiet", "main")
+	commitFile(t, run, "c.txt", "bar\n", "Fix bar")
+	// PR 10 is merged with a merge commit.
+	run("merge", "--quiet", "--no-ff", "-m", "Merge pull request #10 from alice/feature", "feature")
+	merge := run("rev-parse", "HEAD")
+
+	upstream := filepath.Join(t.TempDir(), "upstream.git")
+	fork := filepath.Join(t.TempDir(), "fork.git")
+	run("init", "--quiet", "--bare", upstream)
+	run("init", "--quiet", "--bare", fork)
+	run("remote", "add", "origin", upstream)
+	run("push", "--quiet", "origin", "main", "v1.14")
+	// The fork isn't named after the login of the user.
+	run("remote", "add", "fork", "https://github.com/bob/cilium")
+	run("remote", "set-url", "--push", "fork", fork)
+	// Work in progress in the checkout of the user.
+	if err := os.WriteFile("a.txt", []byte("wip\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	body := "```upstream-prs\n$ for pr in 10; do contrib/backporting/set-labels.py $pr done 1.14; done\n```\n"
+	ghClient, requests := newTestClient(t, map[string]string{
+		"GET /repos/cilium/cilium/issues/1":          `{"number": 1, "state": "open"}`,
+		"GET /repos/cilium/cilium/pulls/10":          `{"number": 10, "title": "Fix foo", "merged": true, "merge_commit_sha": "` + merge + `", "user": {"login": "alice"}, "labels": [{"name": "needs-backport/1.14"}]}`,
+		"GET /repos/cilium/cilium/pulls/10/commits":  `[{"commit": {"message": "Fix foo"}}]`,
+		"POST /repos/cilium/cilium/pulls":            `{"number": 40, "state": "open", "html_url": "https://github.com/cilium/cilium/pull/40", "base": {"ref": "v1.14"}, "body": ` + strconv.Quote(body) + `}`,
+		"POST /repos/cilium/cilium/issues/40/labels": `[]`,
+		"POST /repos/cilium/cilium/issues/10/labels": `[]`,
+	})
+	err := createCommand(context.Background(), ghClient, []string{
+		"--branch", "v1.14", "--pr", "10", "--fork-remote", "fork", "--request-reviews=false",
+		"--confirm", "https://github.com/cilium/cilium/issues/1",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(*requests) == 0 || !strings.HasPrefix((*requests)[0], `POST /repos/cilium/cilium/pulls {"title":"v1.14 backports `) ||
+		!strings.Contains((*requests)[0], `"head":"bob:pr/v1.14-backport-`) {
+		t.Fatalf("got requests %q, want the backport PR opened from the fork of bob", *requests)
+	}
+	branches := run("--git-dir", fork, "branch", "--format=%(refname:short)")
+	if !strings.HasPrefix(branches, "pr/v1.14-backport-") {
+		t.Fatalf("got branches %q in the fork, want the backport branch", branches)
+	}
+	if got := run("--git-dir", fork, "show", branches+":b.txt"); got != "foo" {
+		t.Errorf("got b.txt %q in the backport branch, want the change of PR 10", got)
+	}
+	if msg := run("--git-dir", fork, "log", "-1", "--format=%B", branches); !strings.Contains(msg, "(cherry picked from commit "+merge+")") {
+		t.Errorf("got commit message %q, want a reference to the upstream commit", msg)
+	}
+	if got := run("rev-parse", "--abbrev-ref", "HEAD"); got != "main" {
+		t.Errorf("got checkout of %s, want the one of the user left on main", got)
+	}
+	if b, _ := os.ReadFile("a.txt"); string(b) != "wip\n" {
+		t.Errorf("got a.txt %q, want the work in progress of the user left untouched", b)
+	}
+	if out := run("worktree", "list"); strings.Contains(out, "\n") {
+		t.Errorf("the temporary worktree was not removed:\n%s", out)
+	}
+}
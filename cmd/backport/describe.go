@@ -0,0 +1,90 @@
+// Copyright 2021 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backport
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	gh "github.com/google/go-github/v50/github"
+	flag "github.com/spf13/pflag"
+
+	"github.com/cilium/release/pkg/profile"
+	"github.com/cilium/release/pkg/types"
+)
+
+func describeCommand(ctx context.Context, ghClient *gh.Client, args []string) error {
+	var (
+		repoName    string
+		branch      string
+		prNumbers   []int
+		profileName string
+	)
+	fs := flag.NewFlagSet("backport describe", flag.ContinueOnError)
+	fs.StringVar(&repoName, "repo", "cilium/cilium", "GitHub organization and repository names separated by a slash")
+	fs.StringVar(&branch, "branch", "", "Stable branch the PRs are backported to (e.g.: 'v1.14')")
+	fs.IntSliceVar(&prNumbers, "prs", nil, "Upstream PRs of the backport PR")
+	addProfileFlag(fs, &profileName)
+	if err := types.ParseFlags(fs, "backport describe", args); err != nil {
+		return err
+	}
+	p, err := profile.Get(profileName)
+	if err != nil {
+		return err
+	}
+	if len(branch) == 0 || len(prNumbers) == 0 {
+		return fmt.Errorf("--branch and --prs must be set")
+	}
+	owner, repo, err := types.SplitRepoName(repoName)
+	if err != nil {
+		return err
+	}
+	return describe(ctx, ghClient, p, os.Stdout, owner, repo, branch, prNumbers, time.Now())
+}
+
+// describe writes into w the title, labels, authors to cc and body of a
+// backport PR of the given upstream PRs, for the backport PRs created
+// manually to follow the format parsed when generating the release notes.
+func describe(ctx context.Context, ghClient *gh.Client, p profile.Profile, w io.Writer, owner, repo, branch string, prNumbers []int, now time.Time) error {
+	var (
+		upstreamPRs []*gh.PullRequest
+		authors     []string
+		seen        = map[string]bool{}
+	)
+	for _, prNumber := range prNumbers {
+		pr, _, err := ghClient.PullRequests.Get(ctx, owner, repo, prNumber)
+		if err != nil {
+			return fmt.Errorf("unable to get PR %d: %w", prNumber, err)
+		}
+		if !pr.GetMerged() {
+			fmt.Fprintf(os.Stderr, "WARNING: PR %d is not merged\n", prNumber)
+		}
+		upstreamPRs = append(upstreamPRs, pr)
+		if login := pr.GetUser().GetLogin(); !seen[login] {
+			seen[login] = true
+			authors = append(authors, "@"+login)
+		}
+	}
+
+	fmt.Fprintf(w, "Title: %s\n", prTitle(branch, now))
+	fmt.Fprintf(w, "Labels: %s\n", strings.Join(p.BackportPRLabels(branch), ", "))
+	fmt.Fprintf(w, "Cc: %s\n", strings.Join(authors, " "))
+	fmt.Fprintf(w, "\n%s", prBody(p, branch, upstreamPRs))
+	return nil
+}
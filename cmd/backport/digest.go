@@ -0,0 +1,148 @@
+// Copyright 2021 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backport
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	gh "github.com/google/go-github/v50/github"
+	flag "github.com/spf13/pflag"
+
+	"github.com/cilium/release/pkg/git"
+	"github.com/cilium/release/pkg/profile"
+	"github.com/cilium/release/pkg/types"
+)
+
+func digestCommand(ctx context.Context, ghClient *gh.Client, args []string) error {
+	var (
+		repoName       string
+		branch         string
+		withPreflight  bool
+		upstreamRemote string
+		mainBranch     string
+		issue          int
+		createIssue    bool
+		profileName    string
+	)
+	fs := flag.NewFlagSet("backport digest", flag.ContinueOnError)
+	fs.StringVar(&repoName, "repo", "cilium/cilium", "GitHub organization and repository names separated by a slash")
+	fs.StringVar(&branch, "branch", "", "Stable branch of the pending backports (e.g.: 'v1.14')")
+	fs.BoolVar(&withPreflight, "preflight", false, "Test-apply the pending backports in the local clone to report the conflicting ones")
+	fs.StringVar(&upstreamRemote, "upstream-remote", "origin", "Git remote of the upstream repository, used with --preflight")
+	fs.StringVar(&mainBranch, "main-branch", "main", "Branch the upstream PRs were merged into, used with --preflight")
+	fs.IntVar(&issue, "issue", 0, "Post the digest as a comment in the given issue")
+	fs.BoolVar(&createIssue, "create-issue", false, "Post the digest as a new issue")
+	addProfileFlag(fs, &profileName)
+	if err := types.ParseFlags(fs, "backport digest", args); err != nil {
+		return err
+	}
+	p, err := profile.Get(profileName)
+	if err != nil {
+		return err
+	}
+	if len(branch) == 0 {
+		return fmt.Errorf("--branch must be set")
+	}
+	owner, repo, err := types.SplitRepoName(repoName)
+	if err != nil {
+		return err
+	}
+
+	pending, err := listPending(ctx, ghClient, p, owner, repo, branch)
+	if err != nil {
+		return err
+	}
+
+	conflicts := map[int]PreflightResult{}
+	if withPreflight && len(pending) != 0 {
+		if _, err := git.Run("", "fetch", upstreamRemote, branch, mainBranch); err != nil {
+			return err
+		}
+		var prs []*gh.PullRequest
+		for _, pb := range pending {
+			pr, _, err := ghClient.PullRequests.Get(ctx, owner, repo, pb.PR)
+			if err != nil {
+				return fmt.Errorf("unable to get PR %d: %w", pb.PR, err)
+			}
+			prs = append(prs, pr)
+		}
+		results, err := preflight(ctx, ghClient, owner, repo, upstreamRemote+"/"+branch, prs)
+		if err != nil {
+			return err
+		}
+		for _, r := range results {
+			if r.Conflicts() {
+				conflicts[r.PR] = r
+			}
+		}
+	}
+
+	digest := renderDigest(branch, pending, conflicts)
+	switch {
+	case issue != 0:
+		comment, _, err := ghClient.Issues.CreateComment(ctx, owner, repo, issue, &gh.IssueComment{Body: &digest})
+		if err != nil {
+			return fmt.Errorf("unable to comment on issue %d: %w", issue, err)
+		}
+		fmt.Fprintf(os.Stdout, "Digest posted: %s\n", comment.GetHTMLURL())
+	case createIssue:
+		title := fmt.Sprintf("Backport digest for %s (%s)", branch, time.Now().Format("2006-01-02"))
+		i, _, err := ghClient.Issues.Create(ctx, owner, repo, &gh.IssueRequest{Title: &title, Body: &digest})
+		if err != nil {
+			return fmt.Errorf("unable to create issue: %w", err)
+		}
+		fmt.Fprintf(os.Stdout, "Digest posted: %s\n", i.GetHTMLURL())
+	default:
+		fmt.Fprint(os.Stdout, digest)
+	}
+	return nil
+}
+
+// renderDigest renders, in Markdown, the pending backports grouped by the
+// person responsible for them, flagging the ones that conflict.
+func renderDigest(branch string, pending []PendingBackport, conflicts map[int]PreflightResult) string {
+	byPerson := map[string][]PendingBackport{}
+	for _, p := range pending {
+		byPerson[p.Responsible()] = append(byPerson[p.Responsible()], p)
+	}
+	people := make([]string, 0, len(byPerson))
+	for person := range byPerson {
+		people = append(people, person)
+	}
+	sort.Strings(people)
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "# Pending backports to %s\n\n", branch)
+	fmt.Fprintf(&sb, "%d PRs are pending a backport, %d of them conflict with %s.\n", len(pending), len(conflicts), branch)
+	for _, person := range people {
+		fmt.Fprintf(&sb, "\n## @%s\n\n", person)
+		for _, p := range byPerson[person] {
+			fmt.Fprintf(&sb, "- [ ] #%d %s", p.PR, p.Title)
+			if c, ok := conflicts[p.PR]; ok {
+				fmt.Fprintf(&sb, " — **conflicts**")
+				if len(c.ConflictingFiles) != 0 {
+					fmt.Fprintf(&sb, " in `%s`", strings.Join(c.ConflictingFiles, "`, `"))
+				}
+			}
+			sb.WriteString("\n")
+		}
+	}
+	return sb.String()
+}
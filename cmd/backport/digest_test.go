@@ -0,0 +1,118 @@
+// Copyright 2021 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backport
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestPendingBackport_Responsible(t *testing.T) {
+	tests := []struct {
+		p    PendingBackport
+		want string
+	}{
+		{PendingBackport{Author: "alice"}, "alice"},
+		{PendingBackport{Author: "alice", Assignees: []string{"bob", "carol"}}, "bob"},
+	}
+	for _, tt := range tests {
+		if got := tt.p.Responsible(); got != tt.want {
+			t.Errorf("Responsible(%+v) = %s, want %s", tt.p, got, tt.want)
+		}
+	}
+}
+
+func Test_renderDigest(t *testing.T) {
+	pending := []PendingBackport{
+		{PR: 10, Title: "Fix foo", Author: "alice"},
+		{PR: 20, Title: "Fix bar", Author: "bob", Assignees: []string{"alice"}},
+		{PR: 30, Title: "Fix baz", Author: "bob"},
+	}
+	tests := []struct {
+		name      string
+		conflicts map[int]PreflightResult
+		want      string
+	}{
+		{
+			name: "without preflight",
+			want: "# Pending backports to v1.14\n\n" +
+				"3 PRs are pending a backport, 0 of them conflict with v1.14.\n" +
+				"\n## @alice\n\n" +
+				"- [ ] #10 Fix foo\n" +
+				"- [ ] #20 Fix bar\n" +
+				"\n## @bob\n\n" +
+				"- [ ] #30 Fix baz\n",
+		},
+		{
+			name: "with conflicts",
+			conflicts: map[int]PreflightResult{
+				20: {PR: 20, ConflictingCommit: "abc", ConflictingFiles: []string{"a.go", "b.go"}},
+				30: {PR: 30, ConflictingCommit: "def"},
+			},
+			want: "# Pending backports to v1.14\n\n" +
+				"3 PRs are pending a backport, 2 of them conflict with v1.14.\n" +
+				"\n## @alice\n\n" +
+				"- [ ] #10 Fix foo\n" +
+				"- [ ] #20 Fix bar — **conflicts** in `a.go`, `b.go`\n" +
+				"\n## @bob\n\n" +
+				"- [ ] #30 Fix baz — **conflicts**\n",
+		},
+	}
+	for _, tt := range tests {
+		if got := renderDigest("v1.14", pending, tt.conflicts); got != tt.want {
+			t.Errorf("%s: got:\n%s\nwant:\n%s", tt.name, got, tt.want)
+		}
+	}
+}
+
+func Test_digestCommand(t *testing.T) {
+	search := `{"total_count": 1, "items": [{"number": 10, "title": "Fix foo", "user": {"login": "alice"}}]}`
+	want := "# Pending backports to v1.14\n\n" +
+		"1 PRs are pending a backport, 0 of them conflict with v1.14.\n" +
+		"\n## @alice\n\n" +
+		"- [ ] #10 Fix foo\n"
+	tests := []struct {
+		name string
+		args []string
+		path string
+	}{
+		{"comment", []string{"--branch", "v1.14", "--issue", "5"}, "POST /repos/cilium/cilium/issues/5/comments"},
+		{"issue", []string{"--branch", "v1.14", "--create-issue"}, "POST /repos/cilium/cilium/issues"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ghClient, requests := newTestClient(t, map[string]string{
+				"GET /search/issues": search,
+				tt.path:              `{"html_url": "https://github.com/cilium/cilium/issues/5"}`,
+			})
+			if err := digestCommand(context.Background(), ghClient, tt.args); err != nil {
+				t.Fatal(err)
+			}
+			if len(*requests) != 1 || !strings.HasPrefix((*requests)[0], tt.path+" ") {
+				t.Fatalf("got requests %q, want %s", *requests, tt.path)
+			}
+			var posted map[string]interface{}
+			if err := json.Unmarshal([]byte(strings.TrimPrefix((*requests)[0], tt.path+" ")), &posted); err != nil {
+				t.Fatal(err)
+			}
+			if got := posted["body"]; !reflect.DeepEqual(got, want) {
+				t.Errorf("got digest:\n%s\nwant:\n%s", got, want)
+			}
+		})
+	}
+}
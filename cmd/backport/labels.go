@@ -0,0 +1,127 @@
+// Copyright 2021 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backport
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	gh "github.com/google/go-github/v50/github"
+	flag "github.com/spf13/pflag"
+
+	"github.com/cilium/release/pkg/github"
+	"github.com/cilium/release/pkg/profile"
+	"github.com/cilium/release/pkg/types"
+)
+
+func labelsCommand(ctx context.Context, ghClient *gh.Client, args []string) error {
+	var (
+		repoName    string
+		prNumber    int
+		dryRun      bool
+		profileName string
+	)
+	fs := flag.NewFlagSet("backport labels", flag.ContinueOnError)
+	fs.StringVar(&repoName, "repo", "cilium/cilium", "GitHub organization and repository names separated by a slash")
+	fs.IntVar(&prNumber, "pr", 0, "Backport PR whose upstream PRs labels are updated")
+	fs.BoolVar(&dryRun, "dry-run", false, "Only print the label changes")
+	addProfileFlag(fs, &profileName)
+	if err := types.ParseFlags(fs, "backport labels", args); err != nil {
+		return err
+	}
+	p, err := profile.Get(profileName)
+	if err != nil {
+		return err
+	}
+	if prNumber == 0 {
+		return fmt.Errorf("--pr must be set")
+	}
+	owner, repo, err := types.SplitRepoName(repoName)
+	if err != nil {
+		return err
+	}
+
+	pr, _, err := ghClient.PullRequests.Get(ctx, owner, repo, prNumber)
+	if err != nil {
+		return fmt.Errorf("unable to get PR %d: %w", prNumber, err)
+	}
+	return transitionLabels(ctx, ghClient, p, owner, repo, pr, dryRun)
+}
+
+// backportLabel returns the backport label that the upstream PRs of the
+// given backport PR should have, according to the state of the backport PR.
+func backportLabel(p profile.Profile, backportPR *gh.PullRequest) string {
+	ver := p.StableVersion(backportPR.GetBase().GetRef())
+	switch {
+	case backportPR.GetMerged():
+		return p.DoneBackportPrefix + ver
+	case backportPR.GetState() == "open":
+		return p.PendingBackportPrefix + ver
+	default:
+		// The backport PR was closed without being merged.
+		return p.NeedsBackportPrefix + ver
+	}
+}
+
+// transitionLabels sets, in each upstream PR referenced by the given
+// backport PR, the backport label of p matching the state of the backport
+// PR, e.g. needs-backport/X.Y, backport-pending/X.Y or backport-done/X.Y.
+func transitionLabels(ctx context.Context, ghClient *gh.Client, p profile.Profile, owner, repo string, backportPR *gh.PullRequest, dryRun bool) error {
+	upstreamPRs := github.UpstreamPRs(backportPR.GetBody())
+	if len(upstreamPRs) == 0 {
+		return fmt.Errorf("no upstream PRs found in backport PR %d", backportPR.GetNumber())
+	}
+
+	ver := p.StableVersion(backportPR.GetBase().GetRef())
+	target := backportLabel(p, backportPR)
+	for _, prNumber := range upstreamPRs {
+		pr, _, err := ghClient.PullRequests.Get(ctx, owner, repo, prNumber)
+		if err != nil {
+			return fmt.Errorf("unable to get upstream PR %d: %w", prNumber, err)
+		}
+		hasTarget := false
+		for _, lbl := range pr.Labels {
+			name := lbl.GetName()
+			if name == target {
+				hasTarget = true
+				continue
+			}
+			if name != p.NeedsBackportPrefix+ver && name != p.PendingBackportPrefix+ver && name != p.DoneBackportPrefix+ver {
+				continue
+			}
+			fmt.Fprintf(os.Stdout, "PR %d: removing label %q\n", prNumber, name)
+			if dryRun {
+				continue
+			}
+			_, err := ghClient.Issues.RemoveLabelForIssue(ctx, owner, repo, prNumber, name)
+			if err != nil {
+				return fmt.Errorf("unable to remove label %q from PR %d: %w", name, prNumber, err)
+			}
+		}
+		if hasTarget {
+			continue
+		}
+		fmt.Fprintf(os.Stdout, "PR %d: adding label %q\n", prNumber, target)
+		if dryRun {
+			continue
+		}
+		_, _, err = ghClient.Issues.AddLabelsToIssue(ctx, owner, repo, prNumber, []string{target})
+		if err != nil {
+			return fmt.Errorf("unable to add label %q to PR %d: %w", target, prNumber, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,118 @@
+// Copyright 2021 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backport
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	gh "github.com/google/go-github/v50/github"
+
+	"github.com/cilium/release/pkg/profile"
+)
+
+func Test_backportLabel(t *testing.T) {
+	tests := []struct {
+		profile string
+		pr      *gh.PullRequest
+		want    string
+	}{
+		{"cilium", &gh.PullRequest{State: gh.String("open"), Base: &gh.PullRequestBranch{Ref: gh.String("v1.14")}}, "backport-pending/1.14"},
+		{"cilium", &gh.PullRequest{State: gh.String("closed"), Merged: gh.Bool(true), Base: &gh.PullRequestBranch{Ref: gh.String("v1.14")}}, "backport-done/1.14"},
+		{"cilium", &gh.PullRequest{State: gh.String("closed"), Base: &gh.PullRequestBranch{Ref: gh.String("v1.14")}}, "needs-backport/1.14"},
+		{"kubernetes", &gh.PullRequest{State: gh.String("closed"), Merged: gh.Bool(true), Base: &gh.PullRequestBranch{Ref: gh.String("release-1.28")}}, "cherry-picked/1.28"},
+	}
+	for _, tt := range tests {
+		p, _ := profile.Get(tt.profile)
+		if got := backportLabel(p, tt.pr); got != tt.want {
+			t.Errorf("%s: backportLabel(%s) = %s, want %s", tt.profile, tt.pr.GetState(), got, tt.want)
+		}
+	}
+}
+
+func Test_transitionLabels(t *testing.T) {
+	p, _ := profile.Get("cilium")
+	upstreamPRs := []*gh.PullRequest{
+		{Number: gh.Int(10), Title: gh.String("Fix foo"), User: &gh.User{Login: gh.String("alice")}},
+		{Number: gh.Int(20), Title: gh.String("Fix bar"), User: &gh.User{Login: gh.String("bob")}},
+	}
+	body := prBody(p, "v1.14", upstreamPRs)
+	tests := []struct {
+		name     string
+		state    string
+		merged   bool
+		dryRun   bool
+		requests []string
+	}{
+		{
+			name:  "opened",
+			state: "open",
+			requests: []string{
+				`DELETE /repos/cilium/cilium/issues/10/labels/needs-backport/1.14`,
+				`POST /repos/cilium/cilium/issues/10/labels ["backport-pending/1.14"]`,
+				`DELETE /repos/cilium/cilium/issues/20/labels/needs-backport/1.14`,
+			},
+		},
+		{
+			name:   "merged",
+			state:  "closed",
+			merged: true,
+			requests: []string{
+				`DELETE /repos/cilium/cilium/issues/10/labels/needs-backport/1.14`,
+				`POST /repos/cilium/cilium/issues/10/labels ["backport-done/1.14"]`,
+				`DELETE /repos/cilium/cilium/issues/20/labels/needs-backport/1.14`,
+				`DELETE /repos/cilium/cilium/issues/20/labels/backport-pending/1.14`,
+				`POST /repos/cilium/cilium/issues/20/labels ["backport-done/1.14"]`,
+			},
+		},
+		{
+			name:   "dry run",
+			state:  "open",
+			dryRun: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ghClient, requests := newTestClient(t, map[string]string{
+				// Labels of other branches are left untouched.
+				"GET /repos/cilium/cilium/pulls/10": `{"number": 10, "labels": [{"name": "needs-backport/1.14"}, {"name": "needs-backport/1.13"}]}`,
+				"GET /repos/cilium/cilium/pulls/20": `{"number": 20, "labels": [{"name": "needs-backport/1.14"}, {"name": "backport-pending/1.14"}]}`,
+			})
+			backportPR := &gh.PullRequest{
+				Number: gh.Int(30),
+				State:  gh.String(tt.state),
+				Merged: gh.Bool(tt.merged),
+				Body:   gh.String(body),
+				Base:   &gh.PullRequestBranch{Ref: gh.String("v1.14")},
+			}
+			if err := transitionLabels(context.Background(), ghClient, p, "cilium", "cilium", backportPR, tt.dryRun); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(*requests, tt.requests) {
+				t.Errorf("got requests:\n%q\nwant:\n%q", *requests, tt.requests)
+			}
+		})
+	}
+}
+
+func Test_transitionLabelsNoUpstreamPRs(t *testing.T) {
+	p, _ := profile.Get("cilium")
+	ghClient, _ := newTestClient(t, nil)
+	backportPR := &gh.PullRequest{Number: gh.Int(30), Body: gh.String("Manual backport")}
+	if err := transitionLabels(context.Background(), ghClient, p, "cilium", "cilium", backportPR, false); err == nil {
+		t.Error("expected an error for a backport PR without upstream PRs")
+	}
+}
@@ -0,0 +1,173 @@
+// Copyright 2021 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backport
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	gh "github.com/google/go-github/v50/github"
+	flag "github.com/spf13/pflag"
+
+	"github.com/cilium/release/pkg/github"
+	"github.com/cilium/release/pkg/profile"
+	"github.com/cilium/release/pkg/types"
+)
+
+// PendingBackport is an upstream PR that still needs to be backported.
+type PendingBackport struct {
+	PR     int      `json:"pr"`
+	Title  string   `json:"title"`
+	Author string   `json:"author"`
+	URL    string   `json:"url"`
+	Areas  []string `json:"areas,omitempty"`
+	// Assignees are the people assigned to the upstream PR, usually to
+	// take care of its backport.
+	Assignees []string `json:"assignees,omitempty"`
+}
+
+// Responsible returns the person responsible for the backport: the first
+// assignee of the upstream PR or, if there are none, its author.
+func (p PendingBackport) Responsible() string {
+	if len(p.Assignees) != 0 {
+		return p.Assignees[0]
+	}
+	return p.Author
+}
+
+func listCommand(ctx context.Context, ghClient *gh.Client, args []string) error {
+	var (
+		repoName    string
+		branch      string
+		groupBy     string
+		output      string
+		profileName string
+	)
+	fs := flag.NewFlagSet("backport list", flag.ContinueOnError)
+	fs.StringVar(&repoName, "repo", "cilium/cilium", "GitHub organization and repository names separated by a slash")
+	fs.StringVar(&branch, "branch", "", "Stable branch of the pending backports (e.g.: 'v1.14')")
+	fs.StringVar(&groupBy, "group-by", "author", "Group the PRs by 'author' or 'area'")
+	fs.StringVar(&output, "output", "markdown", "Output format, one of 'markdown' or 'json'")
+	addProfileFlag(fs, &profileName)
+	if err := types.ParseFlags(fs, "backport list", args); err != nil {
+		return err
+	}
+	p, err := profile.Get(profileName)
+	if err != nil {
+		return err
+	}
+	if len(branch) == 0 {
+		return fmt.Errorf("--branch must be set")
+	}
+	if groupBy != "author" && groupBy != "area" {
+		return fmt.Errorf("unknown group %q", groupBy)
+	}
+	owner, repo, err := types.SplitRepoName(repoName)
+	if err != nil {
+		return err
+	}
+
+	pending, err := listPending(ctx, ghClient, p, owner, repo, branch)
+	if err != nil {
+		return err
+	}
+	return writePending(os.Stdout, output, branch, groupPending(pending, groupBy))
+}
+
+// listPending returns all merged PRs that need to be backported to the
+// given branch and were not backported yet, according to the labels of p.
+func listPending(ctx context.Context, ghClient *gh.Client, p profile.Profile, owner, repo, branch string) ([]PendingBackport, error) {
+	ver := p.StableVersion(branch)
+	query := fmt.Sprintf("repo:%s/%s is:pr is:merged label:%s%s -label:%s%s",
+		owner, repo, p.NeedsBackportPrefix, ver, p.DoneBackportPrefix, ver)
+	// The search is split by merge date if more PRs are pending than a
+	// single search returns, e.g. for a branch whose backports lag behind.
+	issues, err := github.SearchIssuesMergedBetween(ctx, ghClient, query, time.Unix(0, 0), time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("unable to search pending backports: %w", err)
+	}
+	pending := make([]PendingBackport, 0, len(issues))
+	for _, issue := range issues {
+		pb := PendingBackport{
+			PR:     issue.GetNumber(),
+			Title:  issue.GetTitle(),
+			Author: issue.GetUser().GetLogin(),
+			URL:    issue.GetHTMLURL(),
+		}
+		for _, assignee := range issue.Assignees {
+			pb.Assignees = append(pb.Assignees, assignee.GetLogin())
+		}
+		for _, lbl := range issue.Labels {
+			if strings.HasPrefix(lbl.GetName(), p.AreaPrefix) {
+				pb.Areas = append(pb.Areas, lbl.GetName())
+			}
+		}
+		pending = append(pending, pb)
+	}
+	sort.Slice(pending, func(i, j int) bool {
+		return pending[i].PR < pending[j].PR
+	})
+	return pending, nil
+}
+
+// groupPending groups the pending backports by author or by area. A PR with
+// several areas is part of each of their groups.
+func groupPending(pending []PendingBackport, groupBy string) map[string][]PendingBackport {
+	groups := map[string][]PendingBackport{}
+	for _, p := range pending {
+		keys := []string{p.Author}
+		if groupBy == "area" {
+			keys = p.Areas
+			if len(keys) == 0 {
+				keys = []string{"(no area)"}
+			}
+		}
+		for _, key := range keys {
+			groups[key] = append(groups[key], p)
+		}
+	}
+	return groups
+}
+
+func writePending(w io.Writer, output, branch string, groups map[string][]PendingBackport) error {
+	switch output {
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(groups)
+	case "markdown":
+		keys := make([]string, 0, len(groups))
+		for key := range groups {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		fmt.Fprintf(w, "# Pending backports to %s\n", branch)
+		for _, key := range keys {
+			fmt.Fprintf(w, "\n## %s\n\n", key)
+			for _, p := range groups[key] {
+				fmt.Fprintf(w, "- [ ] #%d %s (@%s)\n", p.PR, p.Title, p.Author)
+			}
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown output format %q", output)
+	}
+}
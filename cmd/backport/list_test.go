@@ -0,0 +1,177 @@
+// Copyright 2021 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backport
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+
+	gh "github.com/google/go-github/v50/github"
+
+	"github.com/cilium/release/pkg/profile"
+)
+
+func Test_listPending(t *testing.T) {
+	tests := []struct {
+		profile string
+		branch  string
+		query   string
+	}{
+		{"cilium", "v1.14", `repo:cilium/cilium is:pr is:merged label:needs-backport/1.14 -label:backport-done/1.14`},
+		{"kubernetes", "release-1.28", `repo:cilium/cilium is:pr is:merged label:needs-cherry-pick/1.28 -label:cherry-picked/1.28`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.profile, func(t *testing.T) {
+			var queries []string
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/search/issues" {
+					t.Errorf("unexpected request %s %s", r.Method, r.URL)
+					http.NotFound(w, r)
+					return
+				}
+				queries = append(queries, r.URL.Query().Get("q"))
+				switch len(queries) {
+				case 1:
+					// More PRs are pending than a single search returns.
+					fmt.Fprint(w, `{"total_count": 1001, "items": []}`)
+				case 2:
+					fmt.Fprint(w, `{"total_count": 1, "items": [
+						{"number": 30, "title": "Fix bar", "html_url": "https://github.com/cilium/cilium/pull/30", "user": {"login": "bob"},
+						 "labels": [{"name": "area/datapath"}, {"name": "sig/network"}, {"name": "release-note/bug"}]}
+					]}`)
+				default:
+					fmt.Fprint(w, `{"total_count": 1, "items": [
+						{"number": 10, "title": "Fix foo", "html_url": "https://github.com/cilium/cilium/pull/10", "user": {"login": "alice"},
+						 "assignees": [{"login": "carol"}]}
+					]}`)
+				}
+			}))
+			defer srv.Close()
+			ghClient := gh.NewClient(nil)
+			ghClient.BaseURL, _ = url.Parse(srv.URL + "/")
+
+			p, _ := profile.Get(tt.profile)
+			got, err := listPending(context.Background(), ghClient, p, "cilium", "cilium", tt.branch)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(queries) != 3 {
+				t.Fatalf("got queries %q, want the search split in two", queries)
+			}
+			for _, q := range queries {
+				if !strings.HasPrefix(q, tt.query+" merged:") {
+					t.Errorf("got query %q, want %q", q, tt.query)
+				}
+			}
+			area := map[string]string{"cilium": "area/datapath", "kubernetes": "sig/network"}[tt.profile]
+			want := []PendingBackport{
+				{PR: 10, Title: "Fix foo", Author: "alice", URL: "https://github.com/cilium/cilium/pull/10", Assignees: []string{"carol"}},
+				{PR: 30, Title: "Fix bar", Author: "bob", URL: "https://github.com/cilium/cilium/pull/30", Areas: []string{area}},
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("got %+v, want %+v", got, want)
+			}
+		})
+	}
+}
+
+func Test_groupPending(t *testing.T) {
+	foo := PendingBackport{PR: 10, Title: "Fix foo", Author: "alice", Areas: []string{"area/datapath", "area/cli"}}
+	bar := PendingBackport{PR: 20, Title: "Fix bar", Author: "bob", Assignees: []string{"alice"}}
+	baz := PendingBackport{PR: 30, Title: "Fix baz", Author: "alice", Areas: []string{"area/cli"}}
+	tests := []struct {
+		groupBy string
+		want    map[string][]PendingBackport
+	}{
+		{"author", map[string][]PendingBackport{
+			"alice": {foo, baz},
+			"bob":   {bar},
+		}},
+		{"area", map[string][]PendingBackport{
+			"area/datapath": {foo},
+			"area/cli":      {foo, baz},
+			"(no area)":     {bar},
+		}},
+	}
+	for _, tt := range tests {
+		if got := groupPending([]PendingBackport{foo, bar, baz}, tt.groupBy); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("groupPending(%s) = %+v, want %+v", tt.groupBy, got, tt.want)
+		}
+	}
+}
+
+func Test_writePending(t *testing.T) {
+	groups := map[string][]PendingBackport{
+		"bob":   {{PR: 20, Title: "Fix bar", Author: "bob"}},
+		"alice": {{PR: 10, Title: "Fix foo", Author: "alice", Areas: []string{"area/cli"}}},
+	}
+	tests := []struct {
+		output  string
+		want    string
+		wantErr bool
+	}{
+		{
+			output: "markdown",
+			want: "# Pending backports to v1.14\n" +
+				"\n## alice\n\n" +
+				"- [ ] #10 Fix foo (@alice)\n" +
+				"\n## bob\n\n" +
+				"- [ ] #20 Fix bar (@bob)\n",
+		},
+		{
+			output: "json",
+			want: `{
+  "alice": [
+    {
+      "pr": 10,
+      "title": "Fix foo",
+      "author": "alice",
+      "url": "",
+      "areas": [
+        "area/cli"
+      ]
+    }
+  ],
+  "bob": [
+    {
+      "pr": 20,
+      "title": "Fix bar",
+      "author": "bob",
+      "url": ""
+    }
+  ]
+}
+`,
+		},
+		{output: "csv", wantErr: true},
+	}
+	for _, tt := range tests {
+		var buf bytes.Buffer
+		err := writePending(&buf, tt.output, "v1.14", groups)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: got error %v", tt.output, err)
+		}
+		if got := buf.String(); got != tt.want {
+			t.Errorf("%s: got:\n%s\nwant:\n%s", tt.output, got, tt.want)
+		}
+	}
+}
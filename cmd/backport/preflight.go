@@ -0,0 +1,174 @@
+// Copyright 2021 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backport
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	gh "github.com/google/go-github/v50/github"
+	flag "github.com/spf13/pflag"
+
+	"github.com/cilium/release/pkg/git"
+	"github.com/cilium/release/pkg/types"
+)
+
+// PreflightResult is the result of test-applying an upstream PR onto a
+// stable branch.
+type PreflightResult struct {
+	PR     int    `json:"pr"`
+	Title  string `json:"title"`
+	Author string `json:"author"`
+	// ConflictingCommit is the upstream commit that failed to be picked.
+	ConflictingCommit string `json:"conflictingCommit,omitempty"`
+	// ConflictingFiles are the files with conflicts.
+	ConflictingFiles []string `json:"conflictingFiles,omitempty"`
+}
+
+// Conflicts returns true if the PR can't be cherry-picked cleanly.
+func (r PreflightResult) Conflicts() bool {
+	return len(r.ConflictingCommit) != 0
+}
+
+func preflightCommand(ctx context.Context, ghClient *gh.Client, args []string) error {
+	var (
+		repoName       string
+		branch         string
+		prNumbers      []int
+		upstreamRemote string
+		mainBranch     string
+		output         string
+	)
+	fs := flag.NewFlagSet("backport preflight", flag.ContinueOnError)
+	fs.StringVar(&repoName, "repo", "cilium/cilium", "GitHub organization and repository names separated by a slash")
+	fs.StringVar(&branch, "branch", "", "Stable branch to test the backports against (e.g.: 'v1.14')")
+	fs.IntSliceVar(&prNumbers, "pr", nil, "Upstream PRs to test, in the order they would be backported")
+	fs.StringVar(&upstreamRemote, "upstream-remote", "origin", "Git remote of the upstream repository")
+	fs.StringVar(&mainBranch, "main-branch", "main", "Branch the upstream PRs were merged into")
+	fs.StringVar(&output, "output", "text", "Output format, one of 'text' or 'json'")
+	if err := types.ParseFlags(fs, "backport preflight", args); err != nil {
+		return err
+	}
+	if len(branch) == 0 || len(prNumbers) == 0 {
+		return fmt.Errorf("--branch and --pr must be set")
+	}
+	owner, repo, err := types.SplitRepoName(repoName)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(os.Stderr, "Fetching %s/%s and %s/%s\n", upstreamRemote, branch, upstreamRemote, mainBranch)
+	if _, err := git.Run("", "fetch", upstreamRemote, branch, mainBranch); err != nil {
+		return err
+	}
+
+	var prs []*gh.PullRequest
+	for _, prNumber := range prNumbers {
+		pr, _, err := ghClient.PullRequests.Get(ctx, owner, repo, prNumber)
+		if err != nil {
+			return fmt.Errorf("unable to get PR %d: %w", prNumber, err)
+		}
+		prs = append(prs, pr)
+	}
+
+	results, err := preflight(ctx, ghClient, owner, repo, upstreamRemote+"/"+branch, prs)
+	if err != nil {
+		return err
+	}
+	return writePreflight(os.Stdout, output, branch, results)
+}
+
+// preflight cherry-picks the commits of the given PRs, one PR after the
+// other, on top of ref in a temporary worktree. PRs that conflict are
+// skipped so that the following PRs are tested as if they were backported
+// without them.
+func preflight(ctx context.Context, ghClient *gh.Client, owner, repo, ref string, prs []*gh.PullRequest) ([]PreflightResult, error) {
+	dir, err := os.MkdirTemp("", "backport-preflight-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+	if _, err := git.Run("", "worktree", "add", "--detach", dir, ref); err != nil {
+		return nil, err
+	}
+	defer git.Run("", "worktree", "remove", "--force", dir)
+
+	var results []PreflightResult
+	for _, pr := range prs {
+		result := PreflightResult{
+			PR:     pr.GetNumber(),
+			Title:  pr.GetTitle(),
+			Author: pr.GetUser().GetLogin(),
+		}
+		shas, err := upstreamCommits(ctx, ghClient, owner, repo, pr)
+		if err != nil {
+			return nil, err
+		}
+		before, err := git.Run(dir, "rev-parse", "HEAD")
+		if err != nil {
+			return nil, err
+		}
+		for _, sha := range shas {
+			if err := cherryPick(dir, sha); err == nil {
+				continue
+			}
+			result.ConflictingCommit = sha
+			files, err := git.Run(dir, "diff", "--name-only", "--diff-filter=U")
+			if err == nil && len(files) != 0 {
+				result.ConflictingFiles = strings.Split(files, "\n")
+			}
+			git.Run(dir, "cherry-pick", "--abort")
+			if _, err := git.Run(dir, "reset", "--hard", before); err != nil {
+				return nil, err
+			}
+			break
+		}
+		fmt.Fprintf(os.Stderr, ".")
+		results = append(results, result)
+	}
+	fmt.Fprintln(os.Stderr)
+	return results, nil
+}
+
+func writePreflight(w io.Writer, output, branch string, results []PreflightResult) error {
+	switch output {
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(results)
+	case "text":
+		var conflicts int
+		for _, r := range results {
+			if !r.Conflicts() {
+				fmt.Fprintf(w, "OK       #%d %s (@%s)\n", r.PR, r.Title, r.Author)
+				continue
+			}
+			conflicts++
+			fmt.Fprintf(w, "CONFLICT #%d %s (@%s)\n", r.PR, r.Title, r.Author)
+			fmt.Fprintf(w, "         commit %s\n", r.ConflictingCommit)
+			for _, f := range r.ConflictingFiles {
+				fmt.Fprintf(w, "         - %s\n", f)
+			}
+		}
+		fmt.Fprintf(w, "\n%d of %d PRs conflict with %s\n", conflicts, len(results), branch)
+		return nil
+	default:
+		return fmt.Errorf("unknown output format %q", output)
+	}
+}
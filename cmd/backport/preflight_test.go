@@ -0,0 +1,152 @@
+// Copyright 2021 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backport
+
+import (
+	"bytes"
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	gh "github.com/google/go-github/v50/github"
+)
+
+func Test_preflight(t *testing.T) {
+	run := newTestRepo(t)
+	commitFile(t, run, "a.txt", "base\n", "Initial commit")
+	run("branch", "v1.14")
+	foo := commitFile(t, run, "b.txt", "foo\n", "Fix foo")
+	bar := commitFile(t, run, "a.txt", "main\n", "Fix bar")
+	commitFile(t, run, "c.txt", "baz\n", "Add baz")
+	baz2 := commitFile(t, run, "c.txt", "baz\nqux\n", "Fix baz")
+	run("checkout", "--quiet", "v1.14")
+	commitFile(t, run, "a.txt", "stable\n", "Diverge from main")
+	run("checkout", "--quiet", "main")
+
+	ghClient, _ := newTestClient(t, map[string]string{
+		"GET /repos/cilium/cilium/pulls/1/commits": `[{"commit": {"message": "Fix foo"}}]`,
+		"GET /repos/cilium/cilium/pulls/2/commits": `[{"commit": {"message": "Fix bar"}}]`,
+		"GET /repos/cilium/cilium/pulls/3/commits": `[{"commit": {"message": "Add baz"}}, {"commit": {"message": "Fix baz\n\nDetails"}}]`,
+	})
+	prs := map[int]*gh.PullRequest{
+		1: {Number: gh.Int(1), Title: gh.String("Fix foo"), Merged: gh.Bool(true), MergeCommitSHA: gh.String(foo), User: &gh.User{Login: gh.String("alice")}},
+		2: {Number: gh.Int(2), Title: gh.String("Fix bar"), Merged: gh.Bool(true), MergeCommitSHA: gh.String(bar), User: &gh.User{Login: gh.String("bob")}},
+		3: {Number: gh.Int(3), Title: gh.String("Fix baz"), Merged: gh.Bool(true), MergeCommitSHA: gh.String(baz2), User: &gh.User{Login: gh.String("alice")}},
+	}
+
+	tests := []struct {
+		name string
+		prs  []int
+		want []PreflightResult
+	}{
+		{
+			name: "clean",
+			prs:  []int{1, 3},
+			want: []PreflightResult{
+				{PR: 1, Title: "Fix foo", Author: "alice"},
+				{PR: 3, Title: "Fix baz", Author: "alice"},
+			},
+		},
+		{
+			name: "conflict",
+			prs:  []int{2},
+			want: []PreflightResult{
+				{PR: 2, Title: "Fix bar", Author: "bob", ConflictingCommit: bar, ConflictingFiles: []string{"a.txt"}},
+			},
+		},
+		{
+			name: "conflicting PR skipped",
+			prs:  []int{2, 1, 3},
+			want: []PreflightResult{
+				{PR: 2, Title: "Fix bar", Author: "bob", ConflictingCommit: bar, ConflictingFiles: []string{"a.txt"}},
+				{PR: 1, Title: "Fix foo", Author: "alice"},
+				{PR: 3, Title: "Fix baz", Author: "alice"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var list []*gh.PullRequest
+			for _, number := range tt.prs {
+				list = append(list, prs[number])
+			}
+			got, err := preflight(context.Background(), ghClient, "cilium", "cilium", "v1.14", list)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+	if out := run("worktree", "list"); strings.Count(out, "\n") != 0 {
+		t.Errorf("the temporary worktrees were not removed:\n%s", out)
+	}
+}
+
+func Test_writePreflight(t *testing.T) {
+	results := []PreflightResult{
+		{PR: 1, Title: "Fix foo", Author: "alice"},
+		{PR: 2, Title: "Fix bar", Author: "bob", ConflictingCommit: "abc", ConflictingFiles: []string{"a.txt", "b.txt"}},
+	}
+	tests := []struct {
+		output  string
+		want    string
+		wantErr bool
+	}{
+		{
+			output: "text",
+			want: "OK       #1 Fix foo (@alice)\n" +
+				"CONFLICT #2 Fix bar (@bob)\n" +
+				"         commit abc\n" +
+				"         - a.txt\n" +
+				"         - b.txt\n" +
+				"\n1 of 2 PRs conflict with v1.14\n",
+		},
+		{
+			output: "json",
+			want: `[
+  {
+    "pr": 1,
+    "title": "Fix foo",
+    "author": "alice"
+  },
+  {
+    "pr": 2,
+    "title": "Fix bar",
+    "author": "bob",
+    "conflictingCommit": "abc",
+    "conflictingFiles": [
+      "a.txt",
+      "b.txt"
+    ]
+  }
+]
+`,
+		},
+		{output: "yaml", wantErr: true},
+	}
+	for _, tt := range tests {
+		var buf bytes.Buffer
+		err := writePreflight(&buf, tt.output, "v1.14", results)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: got error %v", tt.output, err)
+		}
+		if got := buf.String(); got != tt.want {
+			t.Errorf("%s: got:\n%s\nwant:\n%s", tt.output, got, tt.want)
+		}
+	}
+}
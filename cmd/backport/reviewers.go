@@ -0,0 +1,145 @@
+// Copyright 2021 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backport
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	gh "github.com/google/go-github/v50/github"
+	flag "github.com/spf13/pflag"
+
+	"github.com/cilium/release/pkg/codeowners"
+	"github.com/cilium/release/pkg/github"
+	"github.com/cilium/release/pkg/types"
+)
+
+func reviewersCommand(ctx context.Context, ghClient *gh.Client, args []string) error {
+	var (
+		repoName string
+		prNumber int
+		dryRun   bool
+	)
+	fs := flag.NewFlagSet("backport reviewers", flag.ContinueOnError)
+	fs.StringVar(&repoName, "repo", "cilium/cilium", "GitHub organization and repository names separated by a slash")
+	fs.IntVar(&prNumber, "pr", 0, "Backport PR to request reviews for")
+	fs.BoolVar(&dryRun, "dry-run", false, "Only print the reviewers")
+	if err := types.ParseFlags(fs, "backport reviewers", args); err != nil {
+		return err
+	}
+	if prNumber == 0 {
+		return fmt.Errorf("--pr must be set")
+	}
+	owner, repo, err := types.SplitRepoName(repoName)
+	if err != nil {
+		return err
+	}
+	pr, _, err := ghClient.PullRequests.Get(ctx, owner, repo, prNumber)
+	if err != nil {
+		return fmt.Errorf("unable to get PR %d: %w", prNumber, err)
+	}
+	return requestReviews(ctx, ghClient, owner, repo, pr, dryRun)
+}
+
+// getCodeOwners returns the CODEOWNERS of the repository at the given ref.
+func getCodeOwners(ctx context.Context, ghClient *gh.Client, owner, repo, ref string) (*codeowners.CodeOwners, error) {
+	for _, path := range codeowners.Paths {
+		file, _, resp, err := ghClient.Repositories.GetContents(ctx, owner, repo, path, &gh.RepositoryContentGetOptions{Ref: ref})
+		if err != nil {
+			if resp != nil && resp.StatusCode == 404 {
+				continue
+			}
+			return nil, err
+		}
+		content, err := file.GetContent()
+		if err != nil {
+			return nil, err
+		}
+		return codeowners.Parse(content), nil
+	}
+	return codeowners.Parse(""), nil
+}
+
+// requestReviews requests reviews on the backport PR from the code owners of
+// the files it changes, according to the CODEOWNERS of the stable branch,
+// and from the authors of its upstream PRs.
+func requestReviews(ctx context.Context, ghClient *gh.Client, owner, repo string, backportPR *gh.PullRequest, dryRun bool) error {
+	co, err := getCodeOwners(ctx, ghClient, owner, repo, backportPR.GetBase().GetRef())
+	if err != nil {
+		return fmt.Errorf("unable to get CODEOWNERS: %w", err)
+	}
+	files, err := github.ListFiles(ctx, ghClient, owner, repo, backportPR.GetNumber())
+	if err != nil {
+		return fmt.Errorf("unable to list files of PR %d: %w", backportPR.GetNumber(), err)
+	}
+
+	users := map[string]struct{}{}
+	teams := map[string]struct{}{}
+	for _, file := range files {
+		for _, o := range co.Owners(file.GetFilename()) {
+			o = strings.TrimPrefix(o, "@")
+			if org, team, ok := strings.Cut(o, "/"); ok {
+				// Only teams of the repository's organization can review.
+				if strings.EqualFold(org, owner) {
+					teams[team] = struct{}{}
+				}
+				continue
+			}
+			// Owners can also be emails which can't be requested.
+			if !strings.Contains(o, "@") {
+				users[o] = struct{}{}
+			}
+		}
+	}
+	for _, prNumber := range github.UpstreamPRs(backportPR.GetBody()) {
+		pr, _, err := ghClient.PullRequests.Get(ctx, owner, repo, prNumber)
+		if err != nil {
+			return fmt.Errorf("unable to get upstream PR %d: %w", prNumber, err)
+		}
+		users[pr.GetUser().GetLogin()] = struct{}{}
+	}
+	// GitHub does not allow requesting a review from the PR author.
+	delete(users, backportPR.GetUser().GetLogin())
+
+	req := gh.ReviewersRequest{
+		Reviewers:     sortedKeys(users),
+		TeamReviewers: sortedKeys(teams),
+	}
+	if len(req.Reviewers) == 0 && len(req.TeamReviewers) == 0 {
+		return nil
+	}
+	fmt.Fprintf(os.Stdout, "Requesting reviews on PR %d from users %v and teams %v\n",
+		backportPR.GetNumber(), req.Reviewers, req.TeamReviewers)
+	if dryRun {
+		return nil
+	}
+	_, _, err = ghClient.PullRequests.RequestReviewers(ctx, owner, repo, backportPR.GetNumber(), req)
+	if err != nil {
+		return fmt.Errorf("unable to request reviews on PR %d: %w", backportPR.GetNumber(), err)
+	}
+	return nil
+}
+
+func sortedKeys(m map[string]struct{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,190 @@
+// Copyright 2021 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backport
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	gh "github.com/google/go-github/v50/github"
+	flag "github.com/spf13/pflag"
+
+	"github.com/cilium/release/pkg/config"
+	"github.com/cilium/release/pkg/github"
+	"github.com/cilium/release/pkg/profile"
+	"github.com/cilium/release/pkg/types"
+)
+
+// Suggestion is an upstream PR that is likely to need a backport but isn't
+// labeled for it.
+type Suggestion struct {
+	PR     int    `json:"pr"`
+	Title  string `json:"title"`
+	Author string `json:"author"`
+	// Reasons are why the PR is suggested, e.g. its release note label or
+	// the critical paths it touches.
+	Reasons []string `json:"reasons"`
+}
+
+func suggestCommand(ctx context.Context, ghClient *gh.Client, args []string) error {
+	var (
+		repoName    string
+		branch      string
+		mainBranch  string
+		cfgFile     string
+		output      string
+		force       bool
+		profileName string
+	)
+	fs := flag.NewFlagSet("backport suggest", flag.ContinueOnError)
+	fs.StringVar(&repoName, "repo", "cilium/cilium", "GitHub organization and repository names separated by a slash")
+	fs.StringVar(&branch, "branch", "", "Stable branch to suggest backports for (e.g.: 'v1.14')")
+	fs.StringVar(&mainBranch, "main-branch", "main", "Branch the upstream PRs were merged into")
+	fs.StringVar(&cfgFile, "config", "", "Configuration file containing the critical paths whose changes are suggested for backport")
+	fs.StringVar(&output, "output", "text", "Output format, one of 'text' or 'json'")
+	fs.BoolVar(&force, "force", false, "Suggest backports even if the branch reached its end of life according to --config")
+	addProfileFlag(fs, &profileName)
+	if err := types.ParseFlags(fs, "backport suggest", args); err != nil {
+		return err
+	}
+	p, err := profile.Get(profileName)
+	if err != nil {
+		return err
+	}
+	if len(branch) == 0 {
+		return fmt.Errorf("--branch must be set")
+	}
+	owner, repo, err := types.SplitRepoName(repoName)
+	if err != nil {
+		return err
+	}
+	var criticalPaths []string
+	if len(cfgFile) != 0 {
+		cfg, err := config.Load(cfgFile)
+		if err != nil {
+			return fmt.Errorf("unable to load configuration: %w", err)
+		}
+		criticalPaths = cfg.Backports.CriticalPaths
+		if err := cfg.Schedule.CheckVersionsEOL(time.Now(), force, branch); err != nil {
+			return err
+		}
+	}
+
+	suggestions, err := suggest(ctx, ghClient, p, owner, repo, mainBranch, branch, criticalPaths)
+	if err != nil {
+		return err
+	}
+	return writeSuggestions(os.Stdout, output, branch, suggestions)
+}
+
+// suggest returns the PRs merged into mainBranch since branch was created
+// that are labeled with the bug label of p, or touch one of the critical
+// paths, but are not labeled for a backport to branch.
+func suggest(ctx context.Context, ghClient *gh.Client, p profile.Profile, owner, repo, mainBranch, branch string, criticalPaths []string) ([]Suggestion, error) {
+	ver := p.StableVersion(branch)
+
+	cc, _, err := ghClient.Repositories.CompareCommits(ctx, owner, repo, mainBranch, branch, &gh.ListOptions{PerPage: 1})
+	if err != nil {
+		return nil, fmt.Errorf("unable to find the branch point of %s: %w", branch, err)
+	}
+	branchPoint := cc.GetMergeBaseCommit().GetCommit().GetCommitter().GetDate()
+	fmt.Fprintf(os.Stderr, "%s branched off %s on %s\n", branch, mainBranch, branchPoint.Format("2006-01-02"))
+
+	// More PRs are usually merged into the main branch since the branch
+	// point than a single search returns.
+	merged, err := github.SearchIssuesMergedBetween(ctx, ghClient, fmt.Sprintf("repo:%s/%s is:pr is:merged base:%s",
+		owner, repo, mainBranch), branchPoint.Time, time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("unable to search PRs merged into %s: %w", mainBranch, err)
+	}
+
+	var suggestions []Suggestion
+	for _, pr := range merged {
+		var lbls []string
+		for _, lbl := range pr.Labels {
+			lbls = append(lbls, lbl.GetName())
+		}
+		if backportLabeled(p, lbls, ver) {
+			continue
+		}
+		var reasons []string
+		for _, lbl := range lbls {
+			if lbl == p.BugLabel {
+				reasons = append(reasons, "labeled "+p.BugLabel)
+			}
+		}
+		if len(criticalPaths) != 0 {
+			files, err := github.ListFiles(ctx, ghClient, owner, repo, pr.GetNumber())
+			if err != nil {
+				return nil, fmt.Errorf("unable to list files of PR %d: %w", pr.GetNumber(), err)
+			}
+			for _, pattern := range criticalPaths {
+				for _, file := range files {
+					if config.MatchPath(pattern, file.GetFilename()) {
+						reasons = append(reasons, "touches "+pattern)
+						break
+					}
+				}
+			}
+		}
+		if len(reasons) == 0 {
+			continue
+		}
+		suggestions = append(suggestions, Suggestion{
+			PR:      pr.GetNumber(),
+			Title:   pr.GetTitle(),
+			Author:  pr.GetUser().GetLogin(),
+			Reasons: reasons,
+		})
+	}
+	return suggestions, nil
+}
+
+// backportLabeled returns true if one of the labels tracks the backport of
+// the PR to the given version, whether it is needed, pending or done.
+func backportLabeled(p profile.Profile, lbls []string, ver string) bool {
+	for _, lbl := range lbls {
+		switch lbl {
+		case p.NeedsBackportPrefix + ver, p.PendingBackportPrefix + ver, p.DoneBackportPrefix + ver:
+			return true
+		}
+	}
+	return false
+}
+
+func writeSuggestions(w io.Writer, output, branch string, suggestions []Suggestion) error {
+	switch output {
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(suggestions)
+	case "text":
+		if len(suggestions) == 0 {
+			fmt.Fprintf(w, "No backport candidate found for %s\n", branch)
+			return nil
+		}
+		fmt.Fprintf(w, "PRs that might need a backport to %s:\n", branch)
+		for _, s := range suggestions {
+			fmt.Fprintf(w, " * #%d -- %s (@%s): %s\n", s.PR, s.Title, s.Author, strings.Join(s.Reasons, ", "))
+		}
+		return nil
+	}
+	return fmt.Errorf("unknown output format %q", output)
+}
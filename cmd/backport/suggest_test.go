@@ -0,0 +1,43 @@
+// Copyright 2021 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backport
+
+import (
+	"testing"
+
+	"github.com/cilium/release/pkg/profile"
+)
+
+func Test_backportLabeled(t *testing.T) {
+	tests := []struct {
+		profile string
+		lbls    []string
+		want    bool
+	}{
+		{"cilium", []string{"release-note/bug"}, false},
+		{"cilium", []string{"release-note/bug", "needs-backport/1.13"}, false},
+		{"cilium", []string{"needs-backport/1.14"}, true},
+		{"cilium", []string{"backport-pending/1.14"}, true},
+		{"cilium", []string{"backport-done/1.14"}, true},
+		{"kubernetes", []string{"needs-backport/1.14"}, false},
+		{"kubernetes", []string{"cherry-pick-pending/1.14"}, true},
+	}
+	for _, tt := range tests {
+		p, _ := profile.Get(tt.profile)
+		if got := backportLabeled(p, tt.lbls, "1.14"); got != tt.want {
+			t.Errorf("%s: backportLabeled(%v) = %v, want %v", tt.profile, tt.lbls, got, tt.want)
+		}
+	}
+}
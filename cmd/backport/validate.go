@@ -0,0 +1,168 @@
+// Copyright 2021 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backport
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	gh "github.com/google/go-github/v50/github"
+	flag "github.com/spf13/pflag"
+
+	"github.com/cilium/release/pkg/github"
+	"github.com/cilium/release/pkg/types"
+)
+
+// upstreamCommitRes match the references to the upstream commit added when
+// cherry-picking a commit, either by 'git cherry-pick -x' or by the
+// contrib/backporting scripts.
+var upstreamCommitRes = []*regexp.Regexp{
+	regexp.MustCompile(`\(cherry picked from commit ([0-9a-f]{7,40})\)`),
+	regexp.MustCompile(`\[ upstream commit ([0-9a-f]{7,40}) \]`),
+}
+
+// upstreamCommit returns the upstream commit referenced in the given commit
+// message or an empty string if there is none.
+func upstreamCommit(msg string) string {
+	for _, re := range upstreamCommitRes {
+		if m := re.FindStringSubmatch(msg); m != nil {
+			return m[1]
+		}
+	}
+	return ""
+}
+
+func validateCommand(ctx context.Context, ghClient *gh.Client, args []string) error {
+	var (
+		repoName   string
+		prNumber   int
+		mainBranch string
+		reviews    bool
+	)
+	fs := flag.NewFlagSet("backport validate", flag.ContinueOnError)
+	fs.StringVar(&repoName, "repo", "cilium/cilium", "GitHub organization and repository names separated by a slash")
+	fs.IntVar(&prNumber, "pr", 0, "Backport PR to validate")
+	fs.StringVar(&mainBranch, "main-branch", "main", "Branch the upstream PRs were merged into")
+	fs.BoolVar(&reviews, "request-reviews", false, "Request reviews from the code owners of the changed files and the upstream PRs authors")
+	if err := types.ParseFlags(fs, "backport validate", args); err != nil {
+		return err
+	}
+	if prNumber == 0 && fs.NArg() == 1 {
+		n, err := strconv.Atoi(fs.Arg(0))
+		if err != nil {
+			return fmt.Errorf("invalid PR number %q", fs.Arg(0))
+		}
+		prNumber = n
+	}
+	if prNumber == 0 {
+		return fmt.Errorf("usage: backport validate <pr>")
+	}
+	owner, repo, err := types.SplitRepoName(repoName)
+	if err != nil {
+		return err
+	}
+
+	problems, err := validate(ctx, ghClient, owner, repo, mainBranch, prNumber)
+	if err != nil {
+		return err
+	}
+	if reviews {
+		pr, _, err := ghClient.PullRequests.Get(ctx, owner, repo, prNumber)
+		if err != nil {
+			return fmt.Errorf("unable to get PR %d: %w", prNumber, err)
+		}
+		err = requestReviews(ctx, ghClient, owner, repo, pr, false)
+		if err != nil {
+			return err
+		}
+	}
+	if len(problems) == 0 {
+		fmt.Fprintf(os.Stdout, "Backport PR %d is consistent with its upstream PRs\n", prNumber)
+		return nil
+	}
+	for _, problem := range problems {
+		fmt.Fprintf(os.Stdout, "%s\n", problem)
+	}
+	return fmt.Errorf("backport PR %d has %d inconsistencies", prNumber, len(problems))
+}
+
+// validate checks that every commit of the backport PR references an
+// upstream commit, merged by one of the upstream PRs listed in the backport
+// PR body, and that every listed upstream PR has at least one commit in the
+// backport PR. It returns the list of problems found.
+func validate(ctx context.Context, ghClient *gh.Client, owner, repo, mainBranch string, prNumber int) ([]string, error) {
+	pr, _, err := ghClient.PullRequests.Get(ctx, owner, repo, prNumber)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get PR %d: %w", prNumber, err)
+	}
+	listed := map[int]bool{}
+	for _, upstreamPR := range github.UpstreamPRs(pr.GetBody()) {
+		listed[upstreamPR] = false
+	}
+
+	commits, err := listCommits(ctx, ghClient, owner, repo, prNumber)
+	if err != nil {
+		return nil, fmt.Errorf("unable to list commits of PR %d: %w", prNumber, err)
+	}
+
+	var problems []string
+	for _, commit := range commits {
+		sha := commit.GetSHA()
+		msg := commit.GetCommit().GetMessage()
+		subject := strings.SplitN(msg, "\n", 2)[0]
+		upstreamSHA := upstreamCommit(msg)
+		if len(upstreamSHA) == 0 {
+			problems = append(problems, fmt.Sprintf("- commit %.12s %q does not reference an upstream commit", sha, subject))
+			continue
+		}
+		prs, _, err := ghClient.PullRequests.ListPullRequestsWithCommit(ctx, owner, repo, upstreamSHA, &gh.PullRequestListOptions{State: "closed"})
+		if err != nil {
+			return nil, fmt.Errorf("unable to find PR of upstream commit %s: %w", upstreamSHA, err)
+		}
+		var upstreamPR int
+		for _, p := range prs {
+			if p.GetBase().GetRef() == mainBranch && !p.GetMergedAt().IsZero() {
+				upstreamPR = p.GetNumber()
+				break
+			}
+		}
+		if upstreamPR == 0 {
+			problems = append(problems, fmt.Sprintf("- commit %.12s %q: upstream commit %.12s was not merged by any PR into %s", sha, subject, upstreamSHA, mainBranch))
+			continue
+		}
+		if _, ok := listed[upstreamPR]; !ok {
+			problems = append(problems, fmt.Sprintf("+ commit %.12s %q belongs to upstream PR #%d which is not listed in the PR body", sha, subject, upstreamPR))
+			continue
+		}
+		listed[upstreamPR] = true
+	}
+
+	var missing []int
+	for upstreamPR, found := range listed {
+		if !found {
+			missing = append(missing, upstreamPR)
+		}
+	}
+	sort.Ints(missing)
+	for _, upstreamPR := range missing {
+		problems = append(problems, fmt.Sprintf("- upstream PR #%d is listed in the PR body but none of its commits were backported", upstreamPR))
+	}
+	return problems, nil
+}
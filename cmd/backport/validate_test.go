@@ -0,0 +1,93 @@
+// Copyright 2021 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backport
+
+import (
+	"context"
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+func Test_upstreamCommit(t *testing.T) {
+	tests := []struct {
+		msg  string
+		want string
+	}{
+		{"Fix foo\n\n(cherry picked from commit 0123456789abcdef0123456789abcdef01234567)", "0123456789abcdef0123456789abcdef01234567"},
+		{"Fix foo\n\n[ upstream commit 0123456789ab ]\n\nSigned-off-by: Alice", "0123456789ab"},
+		{"Fix foo\n\nSigned-off-by: Alice", ""},
+		{"Fix foo\n\n(cherry picked from commit xyz)", ""},
+	}
+	for _, tt := range tests {
+		if got := upstreamCommit(tt.msg); got != tt.want {
+			t.Errorf("upstreamCommit(%q) = %q, want %q", tt.msg, got, tt.want)
+		}
+	}
+}
+
+func Test_validate(t *testing.T) {
+	const (
+		body    = "```upstream-prs\n$ for pr in 10 20; do contrib/backporting/set-labels.py $pr done 1.14; done\n```\n"
+		fooPick = `{"sha": "b1b1b1b1b1b1b1", "commit": {"message": "Fix foo\n\n(cherry picked from commit a1a1a1a1a1a1a1)"}}`
+		barPick = `{"sha": "b2b2b2b2b2b2b2", "commit": {"message": "Fix bar\n\n[ upstream commit a2a2a2a2a2a2a2 ]"}}`
+		bazPick = `{"sha": "b3b3b3b3b3b3b3", "commit": {"message": "Fix baz\n\n[ upstream commit a3a3a3a3a3a3a3 ]"}}`
+		manual  = `{"sha": "b4b4b4b4b4b4b4", "commit": {"message": "Fix qux"}}`
+		stable  = `{"sha": "b5b5b5b5b5b5b5", "commit": {"message": "Fix quux\n\n[ upstream commit a5a5a5a5a5a5a5 ]"}}`
+	)
+	tests := []struct {
+		name    string
+		commits string
+		want    []string
+	}{
+		{
+			name:    "consistent",
+			commits: "[" + fooPick + "," + barPick + "]",
+		},
+		{
+			name:    "inconsistent",
+			commits: "[" + fooPick + "," + bazPick + "," + manual + "," + stable + "]",
+			want: []string{
+				`+ commit b3b3b3b3b3b3 "Fix baz" belongs to upstream PR #30 which is not listed in the PR body`,
+				`- commit b4b4b4b4b4b4 "Fix qux" does not reference an upstream commit`,
+				`- commit b5b5b5b5b5b5 "Fix quux": upstream commit a5a5a5a5a5a5 was not merged by any PR into main`,
+				`- upstream PR #20 is listed in the PR body but none of its commits were backported`,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ghClient, _ := newTestClient(t, map[string]string{
+				"GET /repos/cilium/cilium/pulls/40":         `{"number": 40, "body": ` + strconv.Quote(body) + `}`,
+				"GET /repos/cilium/cilium/pulls/40/commits": tt.commits,
+				"GET /repos/cilium/cilium/commits/a1a1a1a1a1a1a1/pulls": `[
+					{"number": 41, "base": {"ref": "v1.14"}, "merged_at": "2023-07-12T09:30:00Z"},
+					{"number": 10, "base": {"ref": "main"}, "merged_at": "2023-07-10T09:30:00Z"}
+				]`,
+				"GET /repos/cilium/cilium/commits/a2a2a2a2a2a2a2/pulls": `[{"number": 20, "base": {"ref": "main"}, "merged_at": "2023-07-10T09:30:00Z"}]`,
+				"GET /repos/cilium/cilium/commits/a3a3a3a3a3a3a3/pulls": `[{"number": 30, "base": {"ref": "main"}, "merged_at": "2023-07-10T09:30:00Z"}]`,
+				// The upstream commit was only merged into the stable branch.
+				"GET /repos/cilium/cilium/commits/a5a5a5a5a5a5a5/pulls": `[{"number": 50, "base": {"ref": "v1.14"}, "merged_at": "2023-07-10T09:30:00Z"}]`,
+			})
+			got, err := validate(context.Background(), ghClient, "cilium", "cilium", "main", 40)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got problems:\n%q\nwant:\n%q", got, tt.want)
+			}
+		})
+	}
+}
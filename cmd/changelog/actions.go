@@ -0,0 +1,68 @@
+// Copyright 2021 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package changelog
+
+import (
+	"path/filepath"
+	"strconv"
+
+	"github.com/cilium/release/pkg/actions"
+	"github.com/cilium/release/pkg/version"
+)
+
+// reportGitHubActions writes the release notes into the summary of the
+// GitHub Actions step and sets the outputs of the step.
+func (cl *ChangeLog) reportGitHubActions(notes []byte) error {
+	if err := actions.AppendStepSummary(string(notes)); err != nil {
+		return err
+	}
+
+	changes := 0
+	for _, section := range cl.Sections() {
+		changes += len(section.Entries)
+	}
+	outputs := [][2]string{
+		{"changes", strconv.Itoa(changes)},
+		{"prs", strconv.Itoa(len(cl.listOfPrs))},
+		{"backport-prs", strconv.Itoa(len(cl.prsWithUpstream))},
+		{"version", cl.detectVersion()},
+	}
+	if len(cl.Output) != 0 {
+		path, err := filepath.Abs(cl.Output)
+		if err != nil {
+			return err
+		}
+		outputs = append(outputs, [2]string{"changelog-path", path})
+	}
+	if len(cl.gistURL) != 0 {
+		outputs = append(outputs, [2]string{"gist-url", cl.gistURL})
+	}
+	for _, output := range outputs {
+		if err := actions.SetOutput(output[0], output[1]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// detectVersion returns the version being released, e.g. '1.14.3', if the
+// head of the release notes is a version tag, or an empty string otherwise.
+func (cl *ChangeLog) detectVersion() string {
+	ver, err := version.Parse(cl.Head)
+	if err != nil {
+		return ""
+	}
+	return ver.String()
+}
@@ -0,0 +1,99 @@
+// Copyright 2021 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package changelog
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	gh "github.com/google/go-github/v50/github"
+
+	"github.com/cilium/release/pkg/types"
+	"github.com/cilium/release/pkg/usage"
+)
+
+// BranchConfig returns the configuration generating the release notes of
+// the given branch, e.g. '1.14', since its latest release. The files written
+// by the run are suffixed with the branch so that the runs of several
+// branches don't overwrite each other.
+func BranchConfig(cfg types.Config, branch string) types.Config {
+	cfg.Branches = nil
+	cfg.SinceLatestRelease = branch
+	cfg.Head = "v" + branch
+	cfg.StateFile = branchFile(cfg.StateFile, branch)
+	if len(cfg.Output) == 0 {
+		cfg.Output = "release-notes.md"
+	}
+	cfg.Output = branchFile(cfg.Output, branch)
+	if len(cfg.StreamFile) != 0 {
+		cfg.StreamFile = branchFile(cfg.StreamFile, branch)
+	}
+	if len(cfg.CIChangesFile) != 0 {
+		cfg.CIChangesFile = branchFile(cfg.CIChangesFile, branch)
+	}
+	if len(cfg.ExcludedFile) != 0 {
+		cfg.ExcludedFile = branchFile(cfg.ExcludedFile, branch)
+	}
+	if len(cfg.ExcludedReport) != 0 {
+		cfg.ExcludedReport = branchFile(cfg.ExcludedReport, branch)
+	}
+	if len(cfg.UpgradeNotesFile) != 0 {
+		cfg.UpgradeNotesFile = branchFile(cfg.UpgradeNotesFile, branch)
+	}
+	if len(cfg.OrphansReport) != 0 {
+		cfg.OrphansReport = branchFile(cfg.OrphansReport, branch)
+	}
+	if len(cfg.ProvenanceFile) != 0 {
+		cfg.ProvenanceFile = branchFile(cfg.ProvenanceFile, branch)
+	}
+	return cfg
+}
+
+// branchFile returns the given file name with the branch inserted before
+// its extension, e.g. 'release-notes-1.14.md'.
+func branchFile(file, branch string) string {
+	ext := filepath.Ext(file)
+	return strings.TrimSuffix(file, ext) + "-" + branch + ext
+}
+
+// GenerateBranchesReleaseNotes generates in parallel the release notes of
+// each of cfg.Branches, sharing the client and the cache. The changelogs are
+// returned in the order of cfg.Branches, with nil for the branches that
+// failed.
+func GenerateBranchesReleaseNotes(ctx context.Context, ghClient *gh.Client, cfg types.Config, tracker *usage.Tracker) ([]*ChangeLog, error) {
+	var (
+		wg   sync.WaitGroup
+		cls  = make([]*ChangeLog, len(cfg.Branches))
+		errs = make([]error, len(cfg.Branches))
+	)
+	for i, branch := range cfg.Branches {
+		wg.Add(1)
+		go func(i int, branch string) {
+			defer wg.Done()
+			cl, err := GenerateReleaseNotes(ctx, ghClient, BranchConfig(cfg, branch), tracker)
+			if err != nil {
+				errs[i] = fmt.Errorf("%s: %w", branch, err)
+				return
+			}
+			cls[i] = cl
+		}(i, branch)
+	}
+	wg.Wait()
+	return cls, errors.Join(errs...)
+}
@@ -0,0 +1,43 @@
+// Copyright 2021 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package changelog
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/cilium/release/pkg/types"
+)
+
+func TestBranchConfig(t *testing.T) {
+	cfg := types.Config{
+		Branches:   []string{"1.13", "1.14"},
+		StateFile:  "release-state.json",
+		StreamFile: "out/stream.jsonl",
+		RepoName:   "cilium/cilium",
+	}
+	got := BranchConfig(cfg, "1.14")
+	want := types.Config{
+		SinceLatestRelease: "1.14",
+		Head:               "v1.14",
+		StateFile:          "release-state-1.14.json",
+		StreamFile:         "out/stream-1.14.jsonl",
+		Output:             "release-notes-1.14.md",
+		RepoName:           "cilium/cilium",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("BranchConfig() = %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,86 @@
+// Copyright 2021 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package changelog
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	gh "github.com/google/go-github/v50/github"
+
+	"github.com/cilium/release/pkg/cache"
+)
+
+// callsPerCommit is the rough number of API calls made to resolve a commit
+// that is not cached: one to list its PRs and, for backport PRs, the ones to
+// get their upstream PRs.
+const callsPerCommit = 2
+
+// estimateCalls returns the rough number of API calls needed to resolve the
+// PRs of the given commits.
+func estimateCalls(prCache *cache.Cache, owner, repo string, shas []string) int {
+	calls := 0
+	for _, sha := range shas {
+		if _, ok := prCache.CommitPRs(owner, repo, sha); !ok {
+			calls += callsPerCommit
+		}
+	}
+	return calls
+}
+
+// checkBudget returns an error if the remaining API rate limit doesn't
+// allow the given number of calls, unless wait is set in which case it
+// waits for the rate limit to be reset. It warns if the calls would use most
+// of the remaining rate limit.
+func checkBudget(ctx context.Context, ghClient *gh.Client, calls int, wait bool) error {
+	limits, _, err := ghClient.RateLimits(ctx)
+	if err != nil {
+		return fmt.Errorf("unable to get rate limit: %w", err)
+	}
+	core := limits.GetCore()
+	if core == nil {
+		return nil
+	}
+	remaining, reset := core.Remaining, core.Reset.Time
+	fmt.Fprintf(os.Stderr, "About %d API calls needed, %d/%d remaining until %s\n", calls, remaining, core.Limit, reset.Format(time.Kitchen))
+	if calls <= remaining {
+		if calls > remaining*8/10 {
+			fmt.Fprintf(os.Stderr, "WARNING: the run will use most of the remaining rate limit\n")
+		}
+		return nil
+	}
+	if calls > core.Limit {
+		fmt.Fprintf(os.Stderr, "WARNING: the run needs more calls than the rate limit allows, it will have to be resumed from the state file\n")
+		return nil
+	}
+	if !wait {
+		return fmt.Errorf("about %d API calls are needed but only %d remain until %s, use --wait-for-reset to wait for the rate limit to be reset", calls, remaining, reset.Format(time.Kitchen))
+	}
+	return waitForReset(ctx, reset)
+}
+
+// waitForReset waits for the rate limit to be reset at the given time.
+func waitForReset(ctx context.Context, reset time.Time) error {
+	d := time.Until(reset) + time.Minute
+	fmt.Fprintf(os.Stderr, "Waiting %s for the rate limit to be reset\n", d.Round(time.Second))
+	select {
+	case <-time.After(d):
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,41 @@
+// Copyright 2021 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package changelog
+
+import (
+	"testing"
+
+	gh "github.com/google/go-github/v50/github"
+
+	"github.com/cilium/release/pkg/cache"
+)
+
+func TestEstimateCalls(t *testing.T) {
+	shas := []string{"a", "b", "c"}
+	if got, want := estimateCalls(nil, "cilium", "cilium", shas), 3*callsPerCommit; got != want {
+		t.Errorf("without cache: got %d, want %d", got, want)
+	}
+
+	prCache, err := cache.New(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := prCache.StoreCommitPRs("cilium", "cilium", "b", []*gh.PullRequest{{Number: gh.Int(1)}}); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := estimateCalls(prCache, "cilium", "cilium", shas), 2*callsPerCommit; got != want {
+		t.Errorf("with cache: got %d, want %d", got, want)
+	}
+}
@@ -0,0 +1,574 @@
+// Copyright 2020-2021 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package changelog
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"text/template"
+	"time"
+
+	gh "github.com/google/go-github/v50/github"
+
+	"github.com/cilium/release/pkg/actions"
+	"github.com/cilium/release/pkg/cache"
+	"github.com/cilium/release/pkg/config"
+	"github.com/cilium/release/pkg/confirm"
+	"github.com/cilium/release/pkg/github"
+	"github.com/cilium/release/pkg/persistence"
+	"github.com/cilium/release/pkg/tracing"
+	"github.com/cilium/release/pkg/types"
+	"github.com/cilium/release/pkg/usage"
+)
+
+// ChangeLog contains all the PRs found between two commits.
+type ChangeLog struct {
+	types.Config
+
+	ghClient        *gh.Client
+	prsWithUpstream types.BackportPRs
+	listOfPrs       types.PullRequests
+	authors         config.Authors
+	docs            config.Docs
+	// groups are the groups owning the changes, used as the sections of
+	// the release notes with GroupByOwner.
+	groups []config.Group
+	// criticalPaths are the paths, e.g. 'pkg/datapath/', whose changes
+	// make the PRs of high risk with AnnotateRisk.
+	criticalPaths []string
+	// components are the components the changed files are mapped to with
+	// ComponentsSummary.
+	components []config.Component
+	// newContributors maps the login of the PR authors to whether the
+	// changelog contains their first merged PR.
+	newContributors map[string]bool
+	// removed maps the PRs removed from the changelog, e.g. by the
+	// overrides or ExcludePublished, to why they were removed.
+	removed map[int]string
+	// orphans are the commits and backport PRs that couldn't be resolved,
+	// only recorded if OrphansReport is set.
+	orphans []types.Orphan
+	// headSHA is the commit Head pointed to when the commits were
+	// compared, if known.
+	headSHA string
+	// knownIssues are the open issues listed in the Known Issues section,
+	// see KnownIssues.
+	knownIssues []KnownIssue
+	// reverts are the changes reverted within the release notes, see
+	// FoldReverts.
+	reverts []Revert
+	// dependencyChanges are the changes of go.mod, see DependencyChanges.
+	dependencyChanges []DependencyChange
+	// gistURL is the URL of the gist the release notes were uploaded to
+	// by the gist sink, if any.
+	gistURL string
+	// templates are the templates the release notes are rendered with,
+	// loaded from TemplateDir on first use.
+	templates *template.Template
+
+	// Gate is the confirmation of the publishing into a release sink, on
+	// whose tracking issue the releases edited are reported.
+	Gate *confirm.Gate
+}
+
+// New returns the changelog of the given PRs, e.g. restored from a state
+// file, without fetching anything.
+func New(ghClient *gh.Client, cfg types.Config, backportPRs types.BackportPRs, prs types.PullRequests) *ChangeLog {
+	return &ChangeLog{
+		Config:          cfg,
+		ghClient:        ghClient,
+		prsWithUpstream: backportPRs,
+		listOfPrs:       prs,
+	}
+}
+
+// GenerateReleaseNotes fetches all PRs between cfg.Base and cfg.Head,
+// resuming from cfg.StateFile if it exists. The state is always stored in
+// cfg.StateFile so that an interrupted run can be continued. The time spent
+// in each phase is recorded in tracker, if not nil.
+func GenerateReleaseNotes(ctx context.Context, ghClient *gh.Client, cfg types.Config, tracker *usage.Tracker) (*ChangeLog, error) {
+	var (
+		backportPRs     = types.BackportPRs{}
+		listOfPRs       = types.PullRequests{}
+		shas            []string
+		newContributors = map[string]bool{}
+		orphans         []types.Orphan
+		trailerNotes    = map[string]string{}
+		commitPRs       = map[string][]int{}
+		// headSHA is the commit cfg.Head pointed to when the commits
+		// were compared.
+		headSHA string
+	)
+
+	if cfg.PreviewPR != 0 {
+		if err := previewRange(ctx, ghClient, &cfg); err != nil {
+			return nil, err
+		}
+	}
+
+	switch cfg.Mode {
+	case ModeCommits:
+		cl, err := generateCommitNotes(ctx, ghClient, cfg, tracker)
+		if err != nil {
+			return nil, err
+		}
+		return cl, cl.findAppendices(ctx, tracker)
+	case ModeSearch:
+		cl, err := generateSearchNotes(ctx, ghClient, cfg, tracker)
+		if err != nil {
+			return nil, err
+		}
+		return cl, cl.findAppendices(ctx, tracker)
+	}
+
+	// With a security fork, the PRs are resolved in the fork while the
+	// release notes are generated for cfg.Repo.
+	src := resolutionConfig(cfg)
+
+	if _, err := os.Stat(cfg.StateFile); err == nil {
+		fmt.Fprintf(os.Stderr, "Found state file, resuming from stored state\n")
+		state, err := persistence.Load(cfg.StateFile)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read persistence file: %w", err)
+		}
+		if err := checkLastStable(ctx, ghClient, src); err != nil {
+			return nil, err
+		}
+		backportPRs, listOfPRs, shas = state.BackportPRs, state.PullRequests, state.SHAs
+		if state.NewContributors != nil {
+			newContributors = state.NewContributors
+		}
+		orphans = state.Orphans
+		if state.TrailerNotes != nil {
+			trailerNotes = state.TrailerNotes
+		}
+		if state.CommitPRs != nil {
+			commitPRs = state.CommitPRs
+		}
+		if len(state.Base) != 0 {
+			if len(cfg.Base) != 0 && cfg.Base != state.Base {
+				return nil, fmt.Errorf("state file %s was stored for base %s, not %s", cfg.StateFile, state.Base, cfg.Base)
+			}
+			cfg.Base = state.Base
+		}
+		headSHA = state.HeadSHA
+		if len(headSHA) != 0 {
+			phaseCtx, endPhase := tracing.Phase(ctx, tracker, "compare")
+			commits, err := compareRepositoryCommits(phaseCtx, ghClient, src.Owner, src.Repo, headSHA, cfg.Head, cfg.AllowTruncated)
+			endPhase()
+			if err != nil {
+				return nil, fmt.Errorf("unable to find the commits merged since the state was stored: %w", err)
+			}
+			if len(commits) != 0 {
+				fmt.Fprintf(os.Stderr, "%s advanced by %d commits since the state was stored\n", cfg.Head, len(commits))
+				headSHA = commits[0].GetSHA()
+			}
+			shas = addCommits(shas, trailerNotes, commits)
+		}
+	} else if _, err := os.Stat(cfg.ShasFile); len(cfg.ShasFile) != 0 && err == nil {
+		if err := resolveBase(ctx, ghClient, &cfg); err != nil {
+			return nil, err
+		}
+		fmt.Fprintf(os.Stderr, "Found SHAs file, reading the commits from %s\n", cfg.ShasFile)
+		fileSHAs, err := readSHAs(cfg.ShasFile)
+		if err != nil {
+			return nil, err
+		}
+		phaseCtx, endPhase := tracing.Phase(ctx, tracker, "compare")
+		commits, err := commitsOfSHAs(phaseCtx, ghClient, src.Owner, src.Repo, cfg.Base, cfg.Head, fileSHAs)
+		endPhase()
+		if err != nil {
+			return nil, err
+		}
+		if len(commits) != 0 {
+			headSHA = commits[0].GetSHA()
+		}
+		shas = addCommits(shas, trailerNotes, commits)
+	} else {
+		if err := resolveBase(ctx, ghClient, &cfg); err != nil {
+			return nil, err
+		}
+		phaseCtx, endPhase := tracing.Phase(ctx, tracker, "compare")
+		commits, err := compareRepositoryCommits(phaseCtx, ghClient, src.Owner, src.Repo, cfg.Base, cfg.Head, cfg.AllowTruncated)
+		endPhase()
+		if err != nil {
+			return nil, err
+		}
+		if len(commits) != 0 {
+			headSHA = commits[0].GetSHA()
+		}
+		shas = addCommits(shas, trailerNotes, commits)
+		if len(cfg.ShasFile) != 0 {
+			if err := writeSHAs(cfg.ShasFile, shas); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	fmt.Fprintf(os.Stderr, "Found %d commits!\n", len(shas))
+
+	printer := func(msg string) {
+		if cfg.GitHubActions && strings.HasPrefix(msg, "WARNING: ") {
+			msg = actions.Warning(msg) + "\n"
+		}
+		fmt.Fprint(os.Stderr, msg)
+	}
+
+	prCache, err := openCache(ctx, ghClient, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := checkBudget(ctx, ghClient, estimateCalls(prCache, src.Owner, src.Repo, shas), cfg.WaitForReset); err != nil {
+		return nil, err
+	}
+
+	var authors config.Authors
+	if len(cfg.AuthorsFile) != 0 {
+		authors, err = config.LoadAuthors(cfg.AuthorsFile)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read authors file: %w", err)
+		}
+	}
+
+	overrides, err := loadOverrides(cfg)
+	if err != nil {
+		return nil, err
+	}
+	disclosures, err := loadDisclosures(cfg)
+	if err != nil {
+		return nil, err
+	}
+	docs, err := loadDocs(cfg)
+	if err != nil {
+		return nil, err
+	}
+	groups, err := loadGroups(cfg)
+	if err != nil {
+		return nil, err
+	}
+	criticalPaths, err := loadCriticalPaths(cfg)
+	if err != nil {
+		return nil, err
+	}
+	components, err := loadComponents(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	stream, err := newStreamer(cfg, authors, backportPRs, listOfPRs)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create stream file: %w", err)
+	}
+	var streamFn func(backportPR, prNumber int, pr types.PullRequest)
+	if stream != nil {
+		streamFn = stream.write
+	}
+
+	var orphanFn func(types.Orphan) error
+	if len(cfg.OrphansReport) != 0 || cfg.NoPRCommits == NoPRCommitsRender || cfg.NoPRCommits == NoPRCommitsFail {
+		orphanFn = func(o types.Orphan) error {
+			switch {
+			case o.Reason == types.OrphanNoPR:
+				if err := noPRCommit(ctx, ghClient, src, listOfPRs, o.SHA); err != nil {
+					return err
+				}
+			case o.Reason == types.OrphanUnresolvedBackport && len(cfg.OrphansReport) == 0:
+				return &github.UnresolvedUpstreamError{BackportPR: o.PR, UpstreamPR: o.UpstreamPR}
+			}
+			if len(cfg.OrphansReport) != 0 {
+				orphans = append(orphans, o)
+			}
+			return nil
+		}
+	}
+
+	phaseCtx, endPhase := tracing.Phase(ctx, tracker, "PR resolution")
+	opts := github.PatchReleaseOptions{
+		Owner:         src.Owner,
+		Repo:          src.Repo,
+		UpstreamOwner: src.UpstreamOwner,
+		UpstreamRepo:  src.UpstreamRepo,
+		Printer:       printer,
+		Cache:         prCache,
+		Stream:        streamFn,
+		Orphan:        orphanFn,
+		CommitPRs:     commitPRs,
+	}
+	prsWithUpstream, listOfPrs, leftShas, err := resolvePRs(phaseCtx, ghClient, opts, cfg.WaitForReset, backportPRs, listOfPRs, shas)
+	endPhase()
+	if stream != nil {
+		if err := stream.close(); err != nil {
+			fmt.Fprintf(os.Stderr, "Unable to write stream file %s: %s\n", cfg.StreamFile, err)
+		}
+	}
+	fmt.Println()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Unable to retrieve PRs for commits: %s\n", err)
+		if reset, ok := github.RateLimitReset(err); ok {
+			fmt.Fprintf(os.Stderr, "The rate limit is reset at %s, use --wait-for-reset to wait for it instead of failing\n", reset.Format(time.Kitchen))
+		}
+		fmt.Fprintf(os.Stderr, "Storing state in %s before existing!\n", cfg.StateFile)
+	}
+	// storeState stores the PRs resolved so far, and the commits left, in
+	// cfg.StateFile.
+	storeState := func() error {
+		return persistence.Store(cfg.StateFile, &persistence.State{
+			BackportPRs:     prsWithUpstream,
+			PullRequests:    listOfPrs,
+			SHAs:            leftShas,
+			NewContributors: newContributors,
+			Orphans:         orphans,
+			TrailerNotes:    trailerNotes,
+			CommitPRs:       commitPRs,
+			Base:            cfg.Base,
+			HeadSHA:         headSHA,
+		})
+	}
+	err2 := storeState()
+	if err2 == nil {
+		fmt.Fprintf(os.Stderr, "State stored successful in %s, please use --state-file=%s in the next run to continue\n", cfg.StateFile, cfg.StateFile)
+	} else {
+		fmt.Fprintf(os.Stderr, "Unable to store state: %s\n", err2)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if len(trailerNotes) != 0 {
+		if err := applyTrailerNotes(ctx, ghClient, prCache, src.Owner, src.Repo, trailerNotes, listOfPrs); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(cfg.CrossCheckGitHub) != 0 {
+		phaseCtx, endPhase := tracing.Phase(ctx, tracker, "cross-check")
+		err = crossCheck(phaseCtx, ghClient, cfg, prCache, prsWithUpstream, listOfPrs)
+		endPhase()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if cfg.CrossCheckSearch {
+		phaseCtx, endPhase := tracing.Phase(ctx, tracker, "cross-check")
+		err = crossCheckSearch(phaseCtx, ghClient, src, prsWithUpstream, listOfPrs)
+		endPhase()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	fmt.Fprintf(os.Stderr, "\nFound %d PRs and %d backport PRs!\n\n", len(listOfPrs), len(prsWithUpstream))
+
+	cl := &ChangeLog{
+		Config:          cfg,
+		ghClient:        ghClient,
+		prsWithUpstream: prsWithUpstream,
+		listOfPrs:       listOfPrs,
+		authors:         authors,
+		docs:            docs,
+		groups:          groups,
+		criticalPaths:   criticalPaths,
+		components:      components,
+		newContributors: newContributors,
+		orphans:         orphans,
+		headSHA:         headSHA,
+	}
+
+	if len(cfg.MergePrereleases) != 0 {
+		phaseCtx, endPhase := tracing.Phase(ctx, tracker, "pre-releases")
+		err = cl.mergePrereleases(phaseCtx)
+		endPhase()
+		if err != nil {
+			return nil, fmt.Errorf("unable to merge pre-releases notes: %w", err)
+		}
+	}
+
+	if len(cfg.ExcludePublished) != 0 {
+		phaseCtx, endPhase := tracing.Phase(ctx, tracker, "published releases")
+		err = cl.excludePublished(phaseCtx)
+		endPhase()
+		if err != nil {
+			return nil, fmt.Errorf("unable to exclude published releases: %w", err)
+		}
+	}
+
+	if cfg.ThankNewContributors {
+		phaseCtx, endPhase := tracing.Phase(ctx, tracker, "new contributors")
+		err = cl.findNewContributors(phaseCtx)
+		endPhase()
+		err2 := storeState()
+		if err2 != nil {
+			fmt.Fprintf(os.Stderr, "Unable to store state: %s\n", err2)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("unable to find new contributors: %w", err)
+		}
+	}
+
+	if cfg.CreditReviewers {
+		phaseCtx, endPhase := tracing.Phase(ctx, tracker, "reviewers")
+		err = cl.findReviewers(phaseCtx)
+		endPhase()
+		err2 := storeState()
+		if err2 != nil {
+			fmt.Fprintf(os.Stderr, "Unable to store state: %s\n", err2)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("unable to find reviewers: %w", err)
+		}
+	}
+
+	if cfg.AnnotateRisk || cfg.ComponentsSummary {
+		phaseCtx, endPhase := tracing.Phase(ctx, tracker, "files")
+		err = cl.findFiles(phaseCtx)
+		endPhase()
+		err2 := storeState()
+		if err2 != nil {
+			fmt.Fprintf(os.Stderr, "Unable to store state: %s\n", err2)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("unable to find the files of the PRs: %w", err)
+		}
+	}
+
+	if len(cfg.LateMerges) != 0 {
+		lm, err := cl.findLateMerges(ctx, tracker)
+		if err != nil {
+			return nil, err
+		}
+		lm.warn(os.Stderr, cfg.Head)
+	}
+
+	if len(cfg.RequireBackports) != 0 {
+		if err := cl.checkPendingBackports(ctx, tracker); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := cl.findAppendices(ctx, tracker); err != nil {
+		return nil, err
+	}
+
+	cl.applyOverrides(overrides)
+	cl.applyDisclosures(disclosures)
+
+	return cl, nil
+}
+
+// resolvePRs resolves the PRs of the given commits with
+// github.GeneratePatchRelease. If the rate limit is exceeded and
+// wait is set, it waits for the rate limit to be reset and resumes
+// from the commits left. Any other error is returned as is, along with the
+// PRs resolved so far and the commits left, for the state to be stored.
+func resolvePRs(ctx context.Context, ghClient *gh.Client, opts github.PatchReleaseOptions, wait bool, backportPRs types.BackportPRs, listOfPRs types.PullRequests, shas []string) (types.BackportPRs, types.PullRequests, []string, error) {
+	for {
+		var err error
+		backportPRs, listOfPRs, shas, err = github.GeneratePatchRelease(ctx, ghClient, opts, backportPRs, listOfPRs, shas)
+		reset, ok := github.RateLimitReset(err)
+		if !ok || !wait {
+			return backportPRs, listOfPRs, shas, err
+		}
+		fmt.Fprintf(os.Stderr, "\n%s, %d commits left\n", err, len(shas))
+		if err := waitForReset(ctx, reset); err != nil {
+			return backportPRs, listOfPRs, shas, err
+		}
+	}
+}
+
+// openCache opens the PR cache of cfg.CacheDir, if set, dropping the PRs of
+// the repositories PRs are resolved in that were updated since the previous
+// run.
+func openCache(ctx context.Context, ghClient *gh.Client, cfg types.Config) (*cache.Cache, error) {
+	prCache, err := cache.New(cfg.CacheDir)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create cache: %w", err)
+	}
+	src := resolutionConfig(cfg)
+	if err := prCache.Revalidate(ctx, ghClient, src.Owner, src.Repo); err != nil {
+		return nil, fmt.Errorf("unable to revalidate cache: %w", err)
+	}
+	if src.UpstreamOwner != src.Owner || src.UpstreamRepo != src.Repo {
+		if err := prCache.Revalidate(ctx, ghClient, src.UpstreamOwner, src.UpstreamRepo); err != nil {
+			return nil, fmt.Errorf("unable to revalidate cache: %w", err)
+		}
+	}
+	return prCache, nil
+}
+
+// resolveBase sets cfg.Base to the latest release of cfg.SinceLatestRelease,
+// if set, and checks that the range can be compared, in the security fork if
+// any.
+func resolveBase(ctx context.Context, ghClient *gh.Client, cfg *types.Config) error {
+	if len(cfg.SinceLatestRelease) != 0 {
+		var err error
+		cfg.Base, err = latestReleaseTag(ctx, ghClient, cfg.Owner, cfg.Repo, cfg.SinceLatestRelease)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "Using latest release %s of %s as base\n", cfg.Base, cfg.SinceLatestRelease)
+	}
+	if err := preflight(ctx, ghClient, resolutionConfig(*cfg)); err != nil {
+		return err
+	}
+	if len(cfg.SinceVersion) != 0 {
+		fmt.Fprintf(os.Stderr, "Using %s as base and %s as head\n", cfg.Base, cfg.Head)
+	}
+	return nil
+}
+
+// latestReleaseTag returns the tag of the most recent published release of
+// the given branch.
+func latestReleaseTag(ctx context.Context, ghClient *gh.Client, owner, repo, branch string) (string, error) {
+	release, err := github.LatestBranchRelease(ctx, ghClient, owner, repo, branch)
+	if err != nil {
+		return "", fmt.Errorf("unable to find latest release of %s: %w", branch, err)
+	}
+	if release == nil {
+		return "", fmt.Errorf("no published release found for %s", branch)
+	}
+	return release.GetTagName(), nil
+}
+
+// addCommits appends the SHAs of the given commits to shas and records
+// the release notes given by their trailers in trailerNotes.
+func addCommits(shas []string, trailerNotes map[string]string, commits []*gh.RepositoryCommit) []string {
+	for _, commit := range commits {
+		shas = append(shas, commit.GetSHA())
+		if note := trailerReleaseNote(commit.GetCommit().GetMessage()); len(note) != 0 {
+			trailerNotes[commit.GetSHA()] = note
+		}
+	}
+	return shas
+}
+
+// compareRepositoryCommits returns the commits between base and head,
+// ordered from head to base. A truncated comparison fails unless
+// allowTruncated, in which case it's only warned about.
+func compareRepositoryCommits(ctx context.Context, ghClient *gh.Client, owner, repo, base, head string, allowTruncated bool) ([]*gh.RepositoryCommit, error) {
+	commits, err := github.CompareCommits(ctx, ghClient, owner, repo, base, head)
+	if errors.Is(err, github.ErrTruncatedCompare) {
+		if !allowTruncated {
+			return nil, fmt.Errorf("%w: list the commits with --shas-file or allow partial release notes with --allow-truncated", err)
+		}
+		fmt.Fprintf(os.Stderr, "WARNING: %s, some commits are missing from the release notes\n", err)
+		return commits, nil
+	}
+	return commits, err
+}
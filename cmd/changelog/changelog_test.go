@@ -0,0 +1,120 @@
+// Copyright 2021 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package changelog
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	gh "github.com/google/go-github/v50/github"
+
+	"github.com/cilium/release/pkg/github"
+	"github.com/cilium/release/pkg/persistence"
+	"github.com/cilium/release/pkg/types"
+)
+
+func TestGenerateReleaseNotesAdvancedHead(t *testing.T) {
+	oldHead := strings.Repeat("a", 40)
+	newHead := strings.Repeat("b", 40)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/rate_limit":
+			fmt.Fprint(w, `{"resources": {"core": {"limit": 5000, "remaining": 5000}}}`)
+		case "/repos/cilium/cilium/compare/" + oldHead + "...v1.14":
+			fmt.Fprintf(w, `{"total_commits": 1, "commits": [{"sha": %q, "commit": {"message": "Fix bar (#124)"}}]}`, newHead)
+		case "/repos/cilium/cilium/commits/" + newHead + "/pulls":
+			fmt.Fprint(w, `[{"number": 124, "state": "closed", "title": "Fix bar", "merged_at": "2023-07-12T09:30:00Z",
+				"user": {"login": "bob"}, "labels": [{"name": "release-note/bug"}]}]`)
+		default:
+			t.Errorf("unexpected request %s", r.URL)
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+	ghClient := gh.NewClient(nil)
+	ghClient.BaseURL, _ = url.Parse(srv.URL + "/")
+
+	stateFile := filepath.Join(t.TempDir(), "state.json")
+	err := persistence.Store(stateFile, &persistence.State{
+		PullRequests: types.PullRequests{
+			123: {ReleaseNote: "Add foo", ReleaseLabel: "release-note/minor", AuthorName: "alice"},
+		},
+		Base:    "v1.14.0",
+		HeadSHA: oldHead,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := types.Config{RepoName: "cilium/cilium", Base: "v1.14.0", Head: "v1.14", StateFile: stateFile}
+	if err := cfg.Sanitize(); err != nil {
+		t.Fatal(err)
+	}
+	cl, err := GenerateReleaseNotes(context.Background(), ghClient, cfg, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := cl.listOfPrs[124]; !ok || len(cl.listOfPrs) != 2 {
+		t.Errorf("got PRs %v, want 123 and 124", cl.listOfPrs)
+	}
+	state, err := persistence.Load(stateFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if state.HeadSHA != newHead || len(state.SHAs) != 0 {
+		t.Errorf("got head %s and commits left %v, want head %s and no commit left", state.HeadSHA, state.SHAs, newHead)
+	}
+
+	cfg.Base = "v1.13.0"
+	if _, err := GenerateReleaseNotes(context.Background(), ghClient, cfg, nil); err == nil {
+		t.Errorf("resuming for another base should fail")
+	}
+}
+
+func TestCompareRepositoryCommitsTruncated(t *testing.T) {
+	// GitHub counts 3 commits between c0 and c2 but only lists 2 of them.
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/repos/cilium/cilium/compare/c0...c2":
+			fmt.Fprint(w, `{"total_commits": 3, "commits": [{"sha": "c1"}, {"sha": "c2"}]}`)
+		case "/repos/cilium/cilium/compare/c0...c1":
+			fmt.Fprint(w, `{"total_commits": 1, "commits": [{"sha": "c1"}]}`)
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL)
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+	ghClient := gh.NewClient(nil)
+	ghClient.BaseURL, _ = url.Parse(srv.URL + "/")
+
+	if _, err := compareRepositoryCommits(context.Background(), ghClient, "cilium", "cilium", "c0", "c2", false); !errors.Is(err, github.ErrTruncatedCompare) {
+		t.Fatalf("got error %v, want %v", err, github.ErrTruncatedCompare)
+	}
+	commits, err := compareRepositoryCommits(context.Background(), ghClient, "cilium", "cilium", "c0", "c2", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(commits) != 2 {
+		t.Errorf("got %d commits, want the 2 listed ones", len(commits))
+	}
+}
@@ -0,0 +1,215 @@
+// Copyright 2021 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package changelog
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	gh "github.com/google/go-github/v50/github"
+
+	"github.com/cilium/release/pkg/cache"
+	"github.com/cilium/release/pkg/github"
+	"github.com/cilium/release/pkg/profile"
+	"github.com/cilium/release/pkg/tracing"
+	"github.com/cilium/release/pkg/types"
+	"github.com/cilium/release/pkg/usage"
+)
+
+const (
+	// ModePRs builds the release notes from the PRs of the commits.
+	ModePRs = "prs"
+	// ModeCommits builds the release notes from the commit messages only,
+	// without resolving their PRs.
+	ModeCommits = "commits"
+	// ModeSearch builds the release notes from the PRs merged into the
+	// branch between the dates of the base and the head, found with the
+	// search API instead of comparing the commits.
+	ModeSearch = "search"
+)
+
+const (
+	// releaseNoteTrailer is the trailer of a commit message giving the
+	// release note of the commit, e.g. 'Release-note: Fix foo'.
+	releaseNoteTrailer = "Release-note"
+	// releaseNoteLabelTrailer is the trailer of a commit message giving
+	// the release note label of the commit, e.g.
+	// 'Release-note-label: release-note/bug'.
+	releaseNoteLabelTrailer = "Release-note-label"
+)
+
+// subjectPRRe matches the PR number GitHub appends to the subject of squashed
+// commits, e.g. 'Fix foo (#123)'.
+var subjectPRRe = regexp.MustCompile(`\s*\(#(\d+)\)$`)
+
+// trailers returns the trailers of the last paragraph of the given commit
+// message, e.g. 'Signed-off-by: Alice <alice@example.com>'. The keys are
+// case-insensitive and, for repeated trailers, the first one wins.
+func trailers(message string) map[string]string {
+	paragraphs := strings.Split(strings.TrimSpace(message), "\n\n")
+	if len(paragraphs) < 2 {
+		return nil
+	}
+	t := map[string]string{}
+	scanner := bufio.NewScanner(strings.NewReader(paragraphs[len(paragraphs)-1]))
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(scanner.Text(), ": ")
+		if !ok || strings.ContainsAny(key, " \t") {
+			continue
+		}
+		key = strings.ToLower(key)
+		if _, ok := t[key]; !ok {
+			t[key] = strings.TrimSpace(value)
+		}
+	}
+	return t
+}
+
+// trailerReleaseNote returns the release note given by the Release-note
+// trailer of the commit message, if any.
+func trailerReleaseNote(message string) string {
+	return trailers(message)[strings.ToLower(releaseNoteTrailer)]
+}
+
+// commitPullRequest returns the release note information of the given commit
+// message and the number of its PR, or 0 if the subject doesn't reference
+// any. The release note is given by the Release-note trailer, defaulting to
+// the subject, and the release note label by the Release-note-label trailer,
+// defaulting to the Conventional Commit prefix of the subject.
+func commitPullRequest(p profile.Profile, message string) (types.PullRequest, int) {
+	subject, _, _ := strings.Cut(message, "\n")
+	subject = strings.TrimSpace(subject)
+	number := 0
+	if m := subjectPRRe.FindStringSubmatch(subject); m != nil {
+		number, _ = strconv.Atoi(m[1])
+		subject = strings.TrimSuffix(subject, m[0])
+	}
+	note := trailerReleaseNote(message)
+	if len(note) == 0 {
+		note = subject
+	}
+	lbl := trailers(message)[strings.ToLower(releaseNoteLabelTrailer)]
+	if len(p.Header(lbl)) == 0 {
+		var ok bool
+		if lbl, ok = p.ConventionalLabel(subject); !ok {
+			lbl = p.DefaultLabel
+		}
+	}
+	return types.PullRequest{
+		ReleaseNote:  note,
+		ReleaseLabel: lbl,
+	}, number
+}
+
+// applyTrailerNotes sets the release note of the PRs without any in their
+// description, whose release note is therefore their title, to the one given
+// by the Release-note trailer of their commit. trailerNotes maps the SHA of
+// the commits to the release note of their trailer.
+func applyTrailerNotes(ctx context.Context, ghClient *gh.Client, prCache *cache.Cache, owner, repo string, trailerNotes map[string]string, listOfPRs types.PullRequests) error {
+	for sha, note := range trailerNotes {
+		prs, err := github.ListPRsWithCommit(ctx, ghClient, prCache, owner, repo, sha)
+		if err != nil {
+			return fmt.Errorf("unable to list PRs of commit %s: %w", sha, err)
+		}
+		for _, ghPR := range prs {
+			pr, ok := listOfPRs[ghPR.GetNumber()]
+			if !ok || pr.ReleaseNote != strings.TrimSpace(pr.Title) {
+				continue
+			}
+			pr.ReleaseNote = note
+			pr.MissingReleaseNote = false
+			listOfPRs[ghPR.GetNumber()] = pr
+		}
+	}
+	return nil
+}
+
+// generateCommitNotes builds the release notes from the messages of the
+// commits between cfg.Base and cfg.Head, without resolving their PRs nor
+// storing any state. The commits that don't reference a PR are keyed by
+// negative numbers and rendered with their SHA, unless cfg.NoPRCommits
+// drops them or fails the run.
+func generateCommitNotes(ctx context.Context, ghClient *gh.Client, cfg types.Config, tracker *usage.Tracker) (*ChangeLog, error) {
+	overrides, err := loadOverrides(cfg)
+	if err != nil {
+		return nil, err
+	}
+	disclosures, err := loadDisclosures(cfg)
+	if err != nil {
+		return nil, err
+	}
+	docs, err := loadDocs(cfg)
+	if err != nil {
+		return nil, err
+	}
+	groups, err := loadGroups(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if err := resolveBase(ctx, ghClient, &cfg); err != nil {
+		return nil, err
+	}
+	phaseCtx, endPhase := tracing.Phase(ctx, tracker, "compare")
+	src := resolutionConfig(cfg)
+	commits, err := compareRepositoryCommits(phaseCtx, ghClient, src.Owner, src.Repo, cfg.Base, cfg.Head, cfg.AllowTruncated)
+	endPhase()
+	if err != nil {
+		return nil, err
+	}
+
+	cl := &ChangeLog{
+		Config:          cfg,
+		ghClient:        ghClient,
+		prsWithUpstream: types.BackportPRs{},
+		listOfPrs:       types.PullRequests{},
+		docs:            docs,
+		groups:          groups,
+	}
+	p := cl.scheme()
+	for i, commit := range commits {
+		if len(commit.Parents) > 1 {
+			continue
+		}
+		pr, number := commitPullRequest(p, commit.GetCommit().GetMessage())
+		pr.AuthorName = commit.GetAuthor().GetLogin()
+		if len(pr.AuthorName) == 0 {
+			pr.AuthorName = commit.GetCommit().GetAuthor().GetName()
+		}
+		if number == 0 {
+			switch cfg.NoPRCommits {
+			case NoPRCommitsDrop:
+				continue
+			case NoPRCommitsFail:
+				return nil, fmt.Errorf("commit %s doesn't reference any PR, see --no-pr-commits", commit.GetSHA())
+			}
+			pr.Commit = commit.GetSHA()
+			number = -(i + 1)
+		}
+		// The commits are ordered from head to base, the most recent
+		// commit of a PR wins.
+		if _, ok := cl.listOfPrs[number]; !ok {
+			cl.listOfPrs[number] = pr
+		}
+	}
+	fmt.Fprintf(os.Stderr, "Found %d commits!\n", len(commits))
+	cl.applyOverrides(overrides)
+	cl.applyDisclosures(disclosures)
+	return cl, nil
+}
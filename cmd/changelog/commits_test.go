@@ -0,0 +1,112 @@
+// Copyright 2021 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package changelog
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	gh "github.com/google/go-github/v50/github"
+
+	"github.com/cilium/release/pkg/cache"
+	"github.com/cilium/release/pkg/profile"
+	"github.com/cilium/release/pkg/types"
+)
+
+func TestCommitPullRequest(t *testing.T) {
+	p, _ := profile.Get(profile.Default)
+	tests := []struct {
+		name    string
+		message string
+		want    types.PullRequest
+		number  int
+	}{
+		{
+			name:    "squashed",
+			message: "fix(cli): Handle empty flags (#123)\n\nSigned-off-by: Alice <alice@example.com>",
+			want:    types.PullRequest{ReleaseNote: "fix(cli): Handle empty flags", ReleaseLabel: "release-note/bug"},
+			number:  123,
+		},
+		{
+			name:    "trailer",
+			message: "Add foo\n\nSome details.\n\nRelease-note-label: release-note/major\nSigned-off-by: Alice <alice@example.com>",
+			want:    types.PullRequest{ReleaseNote: "Add foo", ReleaseLabel: "release-note/major"},
+		},
+		{
+			name:    "release note trailer",
+			message: "fix: Handle empty flags (#124)\n\nRelease-note: Fix crash on empty flags",
+			want:    types.PullRequest{ReleaseNote: "Fix crash on empty flags", ReleaseLabel: "release-note/bug"},
+			number:  124,
+		},
+		{
+			name:    "trailer not in the last paragraph",
+			message: "Add bar\n\nRelease-note: Add bar to foo\n\nSigned-off-by: Alice <alice@example.com>",
+			want:    types.PullRequest{ReleaseNote: "Add bar", ReleaseLabel: "release-note/none"},
+		},
+		{
+			name:    "unknown trailer label",
+			message: "feat: Add foo\n\nRelease-note-label: release-note/unknown",
+			want:    types.PullRequest{ReleaseNote: "feat: Add foo", ReleaseLabel: "release-note/minor"},
+		},
+		{
+			name:    "uncategorized",
+			message: "Update README",
+			want:    types.PullRequest{ReleaseNote: "Update README", ReleaseLabel: "release-note/none"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pr, number := commitPullRequest(p, tt.message)
+			if !reflect.DeepEqual(pr, tt.want) || number != tt.number {
+				t.Errorf("commitPullRequest() = %+v, %d, want %+v, %d", pr, number, tt.want, tt.number)
+			}
+		})
+	}
+}
+
+func TestApplyTrailerNotes(t *testing.T) {
+	prCache, err := cache.New(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	prCache.StoreCommitPRs("cilium", "cilium", "aaa", []*gh.PullRequest{{Number: gh.Int(123)}})
+	prCache.StoreCommitPRs("cilium", "cilium", "bbb", []*gh.PullRequest{{Number: gh.Int(124)}})
+	prs := types.PullRequests{
+		123: {Title: "Fix foo", ReleaseNote: "Fix foo", MissingReleaseNote: true},
+		124: {Title: "Fix bar", ReleaseNote: "Fix bar in the agent"},
+	}
+	trailerNotes := map[string]string{
+		"aaa": "Fix foo on IPv6",
+		"bbb": "Fix bar",
+	}
+	if err := applyTrailerNotes(context.Background(), nil, prCache, "cilium", "cilium", trailerNotes, prs); err != nil {
+		t.Fatal(err)
+	}
+	want := types.PullRequests{
+		123: {Title: "Fix foo", ReleaseNote: "Fix foo on IPv6"},
+		124: {Title: "Fix bar", ReleaseNote: "Fix bar in the agent"},
+	}
+	if !reflect.DeepEqual(prs, want) {
+		t.Errorf("applyTrailerNotes() = %+v, want %+v", prs, want)
+	}
+}
+
+func TestEntryCommit(t *testing.T) {
+	e := newEntry(0, -1, types.PullRequest{ReleaseNote: "Update README", AuthorName: "alice", Commit: "0123456789abcdef"})
+	if got, want := e.String(), "* Update README (0123456, @alice)"; got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,138 @@
+// Copyright 2021 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package changelog
+
+import (
+	"fmt"
+	"sort"
+
+	gh "github.com/google/go-github/v50/github"
+
+	"github.com/cilium/release/pkg/config"
+	"github.com/cilium/release/pkg/types"
+)
+
+// otherComponent is the component of the files not matching any component.
+const otherComponent = "other"
+
+// defaultComponents are the components of the Cilium repository, used if
+// the configuration file doesn't define any.
+var defaultComponents = []config.Component{
+	{Name: "datapath", Paths: []string{"bpf/", "pkg/datapath/"}},
+	{Name: "operator", Paths: []string{"operator/"}},
+	{Name: "CLI", Paths: []string{"cilium-dbg/", "cilium/", "bugtool/"}},
+	{Name: "docs", Paths: []string{"Documentation/"}},
+	{Name: "agent", Paths: []string{"daemon/", "pkg/"}},
+}
+
+// ComponentStats are the changes touching a component in the release
+// notes.
+type ComponentStats struct {
+	Component string
+	// Changes is the number of entries touching the component.
+	Changes int
+	// Files is the number of files of the component changed by them.
+	Files int
+}
+
+// String returns the line of the component in the summary, e.g.
+// 'datapath: 12 changes, 40 files'.
+func (c ComponentStats) String() string {
+	changes, files := "changes", "files"
+	if c.Changes == 1 {
+		changes = "change"
+	}
+	if c.Files == 1 {
+		files = "file"
+	}
+	return fmt.Sprintf("%s: %d %s, %d %s", c.Component, c.Changes, changes, c.Files, files)
+}
+
+// loadComponents reads the components from cfg.ConfigFile if the changes
+// are summarized by component, falling back to defaultComponents.
+func loadComponents(cfg types.Config) ([]config.Component, error) {
+	if !cfg.ComponentsSummary {
+		return nil, nil
+	}
+	if len(cfg.ConfigFile) == 0 {
+		return defaultComponents, nil
+	}
+	c, err := config.Load(cfg.ConfigFile)
+	if err != nil {
+		return nil, fmt.Errorf("unable to load configuration: %w", err)
+	}
+	if len(c.Components) == 0 {
+		return defaultComponents, nil
+	}
+	return c.Components, nil
+}
+
+// fileComponents maps the components of the given files to their number of
+// files.
+func (cl *ChangeLog) fileComponents(files []*gh.CommitFile) map[string]int {
+	components := map[string]int{}
+	for _, f := range files {
+		components[componentOf(cl.components, f.GetFilename())]++
+	}
+	return components
+}
+
+// componentOf returns the name of the first component file belongs to, or
+// otherComponent.
+func componentOf(components []config.Component, file string) string {
+	for _, c := range components {
+		for _, pattern := range c.Paths {
+			if config.MatchPath(pattern, file) {
+				return c.Name
+			}
+		}
+	}
+	return otherComponent
+}
+
+// ComponentStats returns the components touched by the entries of the
+// release notes, sorted by decreasing number of changes. It is empty unless
+// ComponentsSummary is set.
+func (cl *ChangeLog) ComponentStats() []ComponentStats {
+	if !cl.ComponentsSummary {
+		return nil
+	}
+	byComponent := map[string]*ComponentStats{}
+	for _, section := range cl.Sections() {
+		for _, entry := range section.Entries {
+			for component, files := range entry.Components {
+				c, ok := byComponent[component]
+				if !ok {
+					c = &ComponentStats{Component: component}
+					byComponent[component] = c
+				}
+				c.Changes++
+				c.Files += files
+			}
+		}
+	}
+
+	stats := make([]ComponentStats, 0, len(byComponent))
+	for _, c := range byComponent {
+		stats = append(stats, *c)
+	}
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].Changes != stats[j].Changes {
+			return stats[i].Changes > stats[j].Changes
+		}
+		return stats[i].Component < stats[j].Component
+	})
+	return stats
+}
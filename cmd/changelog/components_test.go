@@ -0,0 +1,102 @@
+// Copyright 2021 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package changelog
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	gh "github.com/google/go-github/v50/github"
+
+	"github.com/cilium/release/pkg/types"
+)
+
+func TestComponentsSummary(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/repos/cilium/cilium/pulls/100/files":
+			fmt.Fprint(w, `[
+				{"filename": "bpf/bpf_lxc.c"},
+				{"filename": "pkg/datapath/loader/loader.go"},
+				{"filename": "pkg/policy/repository.go"}
+			]`)
+		case "/repos/cilium/cilium/pulls/101/files":
+			fmt.Fprint(w, `[{"filename": "Documentation/network/concepts.rst"}]`)
+		case "/repos/cilium/cilium/pulls/102/files":
+			fmt.Fprint(w, `[
+				{"filename": "operator/cmd/root.go"},
+				{"filename": "Makefile"}
+			]`)
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL)
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+	ghClient := gh.NewClient(nil)
+	ghClient.BaseURL, _ = url.Parse(srv.URL + "/")
+
+	cfg := types.Config{ComponentsSummary: true}
+	cfg.Owner, cfg.Repo = "cilium", "cilium"
+	components, err := loadComponents(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cl := &ChangeLog{
+		Config:     cfg,
+		ghClient:   ghClient,
+		components: components,
+		listOfPrs: types.PullRequests{
+			100: {ReleaseNote: "Fix foo", AuthorName: "alice", Labels: []string{"release-note/bug"}},
+			101: {ReleaseNote: "Document bar", AuthorName: "bob", Labels: []string{"release-note/misc"}},
+			102: {ReleaseNote: "Add baz", AuthorName: "carol", Labels: []string{"release-note/minor"}},
+			// Restored from the state file.
+			103: {ReleaseNote: "Fix qux", AuthorName: "dave", Labels: []string{"release-note/bug"}, Components: map[string]int{"datapath": 2}},
+		},
+	}
+	if err := cl.findFiles(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	got, err := cl.Render(RenderOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "**Components Touched:**\n" +
+		"* datapath: 2 changes, 4 files\n" +
+		"* agent: 1 change, 1 file\n" +
+		"* docs: 1 change, 1 file\n" +
+		"* operator: 1 change, 1 file\n" +
+		"* other: 1 change, 1 file\n" +
+		"\n" +
+		"Summary of Changes\n" +
+		"------------------\n" +
+		"\n" +
+		"**Minor Changes:**\n" +
+		"* Add baz (#102, @carol)\n" +
+		"\n" +
+		"**Bugfixes:**\n" +
+		"* Fix foo (#100, @alice)\n" +
+		"* Fix qux (#103, @dave)\n" +
+		"\n" +
+		"**Misc Changes:**\n" +
+		"* Document bar (#101, @bob)\n"
+	if string(got) != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
@@ -0,0 +1,122 @@
+// Copyright 2021 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package changelog
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/cilium/release/pkg/github"
+	"github.com/cilium/release/pkg/types"
+)
+
+// findNewContributors looks up, for each author of the changelog not yet
+// present in cl.newContributors, whether a PR of theirs was merged before
+// the base of the range. The upstream PRs of backports are merged long
+// before they are released, so comparing with the merge time of the first
+// PR of the changelog would thank again the contributors whose first PR was
+// part of an earlier patch release.
+func (cl *ChangeLog) findNewContributors(ctx context.Context) error {
+	present := map[string]bool{}
+	addPR := func(pr types.PullRequest) {
+		if strings.HasSuffix(pr.AuthorName, "[bot]") {
+			return
+		}
+		present[pr.AuthorName] = true
+	}
+	for _, pr := range cl.listOfPrs {
+		if !cl.backportedToLastStable(pr) {
+			addPR(pr)
+		}
+	}
+	for _, upstreamPRs := range cl.prsWithUpstream {
+		for _, pr := range upstreamPRs {
+			addPR(pr)
+		}
+	}
+
+	authors := make([]string, 0, len(present))
+	for author := range present {
+		if _, ok := cl.newContributors[author]; !ok {
+			authors = append(authors, author)
+		}
+	}
+	sort.Strings(authors)
+	if len(authors) == 0 {
+		return nil
+	}
+
+	base, err := commitDate(ctx, cl.ghClient, cl.Owner, cl.Repo, cl.Base)
+	if err != nil {
+		return fmt.Errorf("unable to get the date of %s: %w", cl.Base, err)
+	}
+
+	for i, author := range authors {
+		fmt.Fprintf(os.Stderr, "Looking up previous PRs of %s (%d/%d)\n", author, i+1, len(authors))
+		query := fmt.Sprintf("repo:%s/%s is:pr is:merged author:%s merged:<%s",
+			cl.UpstreamOwner, cl.UpstreamRepo, author, base.UTC().Format(time.RFC3339))
+		n, err := github.CountIssues(ctx, cl.ghClient, query)
+		if err != nil {
+			return err
+		}
+		cl.newContributors[author] = n == 0
+	}
+	return nil
+}
+
+// NewContributors returns the sorted logins of the authors whose first
+// merged PR is part of the release notes. It is only filled in if
+// ThankNewContributors is set.
+func (cl *ChangeLog) NewContributors() []string {
+	seen := map[string]struct{}{}
+	var logins []string
+	for _, section := range cl.Sections() {
+		for _, entry := range section.Entries {
+			if _, ok := seen[entry.Author]; ok || !cl.newContributors[entry.Author] {
+				continue
+			}
+			seen[entry.Author] = struct{}{}
+			logins = append(logins, entry.Author)
+		}
+	}
+	sort.Strings(logins)
+	return logins
+}
+
+// thanksLine returns the line thanking the new contributors, or an empty
+// string if there are none.
+func (cl *ChangeLog) thanksLine() string {
+	logins := cl.NewContributors()
+	if len(logins) == 0 {
+		return ""
+	}
+	names := make([]string, 0, len(logins))
+	for _, login := range logins {
+		name, ok := cl.authors.Display(login)
+		if !ok {
+			name = "@" + login
+		}
+		names = append(names, name)
+	}
+	contributors := "contributors"
+	if len(names) == 1 {
+		contributors = "contributor"
+	}
+	return fmt.Sprintf("Thanks to our %d new %s: %s!", len(names), contributors, strings.Join(names, ", "))
+}
@@ -0,0 +1,83 @@
+// Copyright 2021 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package changelog
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+
+	gh "github.com/google/go-github/v50/github"
+
+	"github.com/cilium/release/pkg/types"
+)
+
+func TestFindNewContributors(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/repos/cilium/cilium/commits/v1.14.2":
+			fmt.Fprint(w, `{"sha": "abc", "commit": {"committer": {"date": "2023-08-01T00:00:00Z"}}}`)
+			return
+		case "/search/issues":
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL)
+			http.NotFound(w, r)
+			return
+		}
+		switch r.URL.Query().Get("q") {
+		case "repo:cilium/cilium is:pr is:merged author:alice merged:<2023-08-01T00:00:00Z":
+			// The first PR of alice was part of v1.14.2.
+			fmt.Fprint(w, `{"total_count": 1, "items": []}`)
+		case "repo:cilium/cilium is:pr is:merged author:bob merged:<2023-08-01T00:00:00Z":
+			fmt.Fprint(w, `{"total_count": 0, "items": []}`)
+		default:
+			t.Errorf("unexpected query %q", r.URL.Query().Get("q"))
+			fmt.Fprint(w, `{"total_count": 0, "items": []}`)
+		}
+	}))
+	defer srv.Close()
+	ghClient := gh.NewClient(nil)
+	ghClient.BaseURL, _ = url.Parse(srv.URL + "/")
+
+	cfg := types.Config{Base: "v1.14.2"}
+	cfg.Owner, cfg.Repo = "cilium", "cilium"
+	cfg.UpstreamOwner, cfg.UpstreamRepo = "cilium", "cilium"
+	cl := &ChangeLog{
+		Config:   cfg,
+		ghClient: ghClient,
+		prsWithUpstream: types.BackportPRs{
+			// The upstream PR of alice was merged after their first PR,
+			// released in v1.14.2, and is backported only now.
+			200: {123: {AuthorName: "alice", MergedAt: time.Date(2023, 6, 1, 0, 0, 0, 0, time.UTC)}},
+		},
+		listOfPrs: types.PullRequests{
+			124: {AuthorName: "bob", MergedAt: time.Date(2023, 8, 2, 0, 0, 0, 0, time.UTC)},
+			125: {AuthorName: "dependabot[bot]", MergedAt: time.Date(2023, 8, 3, 0, 0, 0, 0, time.UTC)},
+		},
+		newContributors: map[string]bool{},
+	}
+	if err := cl.findNewContributors(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]bool{"alice": false, "bob": true}
+	if !reflect.DeepEqual(cl.newContributors, want) {
+		t.Errorf("got new contributors %v, want %v", cl.newContributors, want)
+	}
+}
@@ -0,0 +1,220 @@
+// Copyright 2021 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package changelog
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	gh "github.com/google/go-github/v50/github"
+	flag "github.com/spf13/pflag"
+
+	"github.com/cilium/release/pkg/cache"
+	"github.com/cilium/release/pkg/github"
+	"github.com/cilium/release/pkg/profile"
+	"github.com/cilium/release/pkg/types"
+)
+
+// CommitCoverage is how a commit of the range is represented in the release
+// notes.
+type CommitCoverage struct {
+	SHA string
+	// PRs are the merged PRs of the commit.
+	PRs []int
+	// Section is the header of the section the commit is rendered in.
+	Section string
+	// Excluded is why the commit is explicitly left out of the release
+	// notes, e.g. 'backported to 1.13'.
+	Excluded string
+}
+
+// Covered returns true if the commit is either rendered or explicitly
+// excluded.
+func (c CommitCoverage) Covered() bool {
+	return len(c.Section) != 0 || len(c.Excluded) != 0
+}
+
+// prCoverage is how a PR is represented in the release notes: the header of
+// the section it is rendered in or why it is excluded.
+type prCoverage struct {
+	section  string
+	excluded string
+}
+
+// prCoverages returns the coverage of the PRs, upstream and backport ones,
+// of the changelog as it is rendered, and of the PRs removed from it.
+func (cl *ChangeLog) prCoverages() map[int]prCoverage {
+	coverages := map[int]prCoverage{}
+	for number, reason := range cl.removed {
+		coverages[number] = prCoverage{excluded: reason}
+	}
+	add := func(entries []Entry, c prCoverage) {
+		for _, entry := range entries {
+			for _, e := range entry.flatten() {
+				for _, number := range append([]int{e.PR}, e.BackportPRs...) {
+					// A PR rendered through any of its entries is
+					// covered by it.
+					if len(coverages[number].section) == 0 {
+						coverages[number] = c
+					}
+				}
+			}
+		}
+	}
+	skipped := map[string]bool{}
+	for _, lbl := range cl.skipLabels(cl.Format) {
+		skipped[lbl] = true
+	}
+	for _, section := range cl.Sections() {
+		if skipped[section.Label] {
+			add(section.Entries, prCoverage{excluded: "skipped " + section.Header})
+		} else {
+			add(section.Entries, prCoverage{section: section.Header})
+		}
+	}
+	for _, section := range cl.ExcludedSections() {
+		add(section.Entries, prCoverage{excluded: "backported to " + strings.Join(cl.LastStable, ", ")})
+	}
+	return coverages
+}
+
+// commitCoverage returns the coverage of the commit with the given merged
+// PRs. A commit rendered through any of its PRs is covered by it.
+func commitCoverage(coverages map[int]prCoverage, sha string, prs []int) CommitCoverage {
+	c := CommitCoverage{SHA: sha, PRs: prs}
+	for _, number := range prs {
+		pc, ok := coverages[number]
+		if !ok {
+			continue
+		}
+		if len(pc.section) != 0 {
+			c.Section, c.Excluded = pc.section, ""
+			break
+		}
+		c.Excluded = pc.excluded
+	}
+	return c
+}
+
+// Coverage returns the coverage of each non-merge commit between Base and
+// Head.
+func (cl *ChangeLog) Coverage(ctx context.Context, prCache *cache.Cache) ([]CommitCoverage, error) {
+	commits, err := compareRepositoryCommits(ctx, cl.ghClient, cl.Owner, cl.Repo, cl.Base, cl.Head, cl.AllowTruncated)
+	if err != nil {
+		return nil, err
+	}
+	coverages := cl.prCoverages()
+	var coverage []CommitCoverage
+	for _, commit := range commits {
+		if len(commit.Parents) > 1 {
+			continue
+		}
+		prs, err := github.ListPRsWithCommit(ctx, cl.ghClient, prCache, cl.Owner, cl.Repo, commit.GetSHA())
+		if err != nil {
+			return nil, fmt.Errorf("unable to list PRs of commit %s: %w", commit.GetSHA(), err)
+		}
+		var numbers []int
+		for _, pr := range prs {
+			if pr.MergedAt != nil {
+				numbers = append(numbers, pr.GetNumber())
+			}
+		}
+		coverage = append(coverage, commitCoverage(coverages, commit.GetSHA(), numbers))
+	}
+	return coverage, nil
+}
+
+// VerifyCommand implements the 'verify coverage' subcommand, which checks
+// that every non-merge commit between --base and --head is rendered in the
+// release notes, or explicitly excluded from them, and lists the ones that
+// are not.
+func VerifyCommand(ctx context.Context, ghClient *gh.Client, args []string) error {
+	if len(args) == 0 || args[0] != "coverage" {
+		return fmt.Errorf("usage: verify coverage [flags]")
+	}
+
+	var (
+		cfg     types.Config
+		verbose bool
+	)
+	fs := flag.NewFlagSet("verify coverage", flag.ContinueOnError)
+	fs.StringVar(&cfg.RepoName, "repo", "cilium/cilium", "GitHub organization and repository names separated by a slash")
+	fs.StringVar(&cfg.UpstreamRepoName, "upstream-repo", "", "GitHub organization and repository names, separated by a slash, of the upstream PRs referenced by backport PRs, if different from --repo")
+	fs.StringVar(&cfg.Base, "base", "", "Base commit / tag of the release notes")
+	fs.StringVar(&cfg.Head, "head", "", "Head commit of the release notes")
+	fs.StringSliceVar(&cfg.LastStable, "last-stable", nil, "Last stable versions whose backported PRs are excluded from the release notes (e.g.: '1.5', '1.6'). Can be repeated or comma-separated")
+	fs.StringVar(&cfg.CacheDir, "cache-dir", "", "Directory of the PR metadata cache shared across runs, releases and branches, e.g. ~/.cache/cilium-release. The cached PRs updated since the previous run are dropped. Disabled if empty")
+	fs.StringVar(&cfg.Profile, "profile", profile.Default, fmt.Sprintf("Label scheme of the repository, one of %s", strings.Join(profile.Names(), ", ")))
+	fs.BoolVar(&cfg.ConventionalCommits, "conventional-commits", false, "Categorize the PRs without any release note label from the Conventional Commit prefix of their title")
+	AddSkipFlags(fs, &cfg)
+	fs.StringVar(&cfg.OverridesFile, "overrides", "", "YAML file mapping PR numbers to the corrections of their entries, as for the release notes. The dropped PRs are excluded")
+	fs.StringVar(&cfg.ExcludePublished, "exclude-published", "", "When set to a branch (e.g.: '1.14'), the PRs mentioned in the published releases of that branch are excluded, as from the release notes")
+	fs.StringVar(&cfg.MergePrereleases, "merge-prereleases", "", "When set to a final version (e.g.: '1.14.0'), the notes of all its published pre-releases are merged, as into the release notes")
+	fs.BoolVar(&verbose, "verbose", false, "Also list the covered commits with their section or why they are excluded")
+	if err := types.ParseFlags(fs, "verify coverage", args[1:]); err != nil {
+		return err
+	}
+
+	stateDir, err := os.MkdirTemp("", "release-verify")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(stateDir)
+	cfg.StateFile = filepath.Join(stateDir, "state.json")
+
+	if err := cfg.Sanitize(); err != nil {
+		return err
+	}
+
+	cl, err := GenerateReleaseNotes(ctx, ghClient, cfg, nil)
+	if err != nil {
+		return err
+	}
+	prCache, err := openCache(ctx, ghClient, cfg)
+	if err != nil {
+		return err
+	}
+	coverage, err := cl.Coverage(ctx, prCache)
+	if err != nil {
+		return err
+	}
+
+	var uncovered []CommitCoverage
+	for _, c := range coverage {
+		switch {
+		case !c.Covered():
+			uncovered = append(uncovered, c)
+		case verbose && len(c.Section) != 0:
+			fmt.Printf("%.12s %v %s\n", c.SHA, c.PRs, c.Section)
+		case verbose:
+			fmt.Printf("%.12s %v excluded: %s\n", c.SHA, c.PRs, c.Excluded)
+		}
+	}
+	for _, c := range uncovered {
+		if len(c.PRs) == 0 {
+			fmt.Printf("UNCOVERED %s: no merged PR found\n", c.SHA)
+			continue
+		}
+		fmt.Printf("UNCOVERED %s: PRs %v are not in the release notes\n", c.SHA, c.PRs)
+	}
+	if len(uncovered) != 0 {
+		return fmt.Errorf("%d of the %d commits between %s and %s are not covered by the release notes", len(uncovered), len(coverage), cfg.Base, cfg.Head)
+	}
+	fmt.Printf("All %d commits between %s and %s are covered by the release notes\n", len(coverage), cfg.Base, cfg.Head)
+	return nil
+}
@@ -0,0 +1,86 @@
+// Copyright 2021 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package changelog
+
+import (
+	"testing"
+
+	"github.com/cilium/release/pkg/config"
+	"github.com/cilium/release/pkg/types"
+)
+
+func TestCommitCoverage(t *testing.T) {
+	cl := &ChangeLog{
+		prsWithUpstream: types.BackportPRs{
+			200: {
+				150: {ReleaseNote: "Fix bar", ReleaseLabel: "release-note/bug"},
+			},
+		},
+		listOfPrs: types.PullRequests{
+			123: {ReleaseNote: "Add foo", ReleaseLabel: "release-note/minor"},
+			125: {
+				ReleaseNote:      "Fix qux",
+				ReleaseLabel:     "release-note/bug",
+				BackportBranches: []string{"backport-done/1.13"},
+			},
+			126: {ReleaseNote: "Improve CI", ReleaseLabel: "release-note/ci"},
+			127: {ReleaseNote: "Fix foo", ReleaseLabel: "release-note/bug"},
+			128: {ReleaseNote: "Fix foo", ReleaseLabel: "release-note/bug"},
+			129: {ReleaseNote: "Add quux", ReleaseLabel: "release-note/minor"},
+			131: {ReleaseNote: "Fix CI", ReleaseLabel: "release-note/ci"},
+		},
+		removed: map[int]string{130: "published in 1.14"},
+		Config: types.Config{
+			LastStable:      []string{"1.13"},
+			SkipCIChanges:   true,
+			MergeDuplicates: true,
+		},
+	}
+	cl.applyOverrides(config.Overrides{
+		129: {Drop: true},
+		126: {Category: "release-note/minor"},
+	})
+
+	tests := []struct {
+		name     string
+		prs      []int
+		section  string
+		excluded string
+	}{
+		{name: "rendered", prs: []int{123}, section: "**Minor Changes:**"},
+		{name: "backport", prs: []int{200}, section: "**Bugfixes:**"},
+		{name: "backported to last stable", prs: []int{125}, excluded: "backported to 1.13"},
+		{name: "skipped", prs: []int{131}, excluded: "skipped **CI Changes:**"},
+		{name: "overridden into another section", prs: []int{126}, section: "**Minor Changes:**"},
+		{name: "duplicate", prs: []int{128}, section: "**Bugfixes:**"},
+		{name: "dropped by the overrides", prs: []int{129}, excluded: "dropped by the overrides"},
+		{name: "removed", prs: []int{130}, excluded: "published in 1.14"},
+		{name: "rendered through any PR", prs: []int{129, 123}, section: "**Minor Changes:**"},
+		{name: "unknown PR", prs: []int{999}},
+		{name: "no PR"},
+	}
+	coverages := cl.prCoverages()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := commitCoverage(coverages, "abc", tt.prs)
+			if c.Section != tt.section || c.Excluded != tt.excluded {
+				t.Errorf("commitCoverage() = %q, %q, want %q, %q", c.Section, c.Excluded, tt.section, tt.excluded)
+			}
+			if c.Covered() != (len(tt.section) != 0 || len(tt.excluded) != 0) {
+				t.Errorf("Covered() = %v", c.Covered())
+			}
+		})
+	}
+}
@@ -0,0 +1,115 @@
+// Copyright 2021 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package changelog
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+
+	gh "github.com/google/go-github/v50/github"
+
+	"github.com/cilium/release/pkg/cache"
+	"github.com/cilium/release/pkg/github"
+	"github.com/cilium/release/pkg/types"
+)
+
+const (
+	// CrossCheckDiff reports the differences between the PRs found and
+	// the ones of the release notes generated by GitHub.
+	CrossCheckDiff = "diff"
+	// CrossCheckMerge also adds the PRs only found by GitHub.
+	CrossCheckMerge = "merge"
+)
+
+var generatedPRRe = regexp.MustCompile(`/pull/(\d+)\b`)
+
+// parseGeneratedNotes returns the numbers of the PRs listed in release
+// notes generated by GitHub, e.g. '* Fix foo by @alice in
+// https://github.com/cilium/cilium/pull/123'.
+func parseGeneratedNotes(body string) map[int]bool {
+	prs := map[int]bool{}
+	for _, m := range generatedPRRe.FindAllStringSubmatch(body, -1) {
+		n, _ := strconv.Atoi(m[1])
+		prs[n] = true
+	}
+	return prs
+}
+
+// diffPRs returns the PRs only present in theirs and the ones only present
+// in ours, sorted.
+func diffPRs(ours, theirs map[int]bool) (missed, extra []int) {
+	for pr := range theirs {
+		if !ours[pr] {
+			missed = append(missed, pr)
+		}
+	}
+	for pr := range ours {
+		if !theirs[pr] {
+			extra = append(extra, pr)
+		}
+	}
+	sort.Ints(missed)
+	sort.Ints(extra)
+	return missed, extra
+}
+
+// crossCheck compares the PRs found, including the backport PRs, with the
+// ones of the release notes generated by GitHub for the same range. With
+// CrossCheckMerge, the PRs that were missed are added.
+func crossCheck(ctx context.Context, ghClient *gh.Client, cfg types.Config, prCache *cache.Cache, backportPRs types.BackportPRs, listOfPRs types.PullRequests) error {
+	notes, _, err := ghClient.Repositories.GenerateReleaseNotes(ctx, cfg.Owner, cfg.Repo, &gh.GenerateNotesOptions{
+		TagName:         cfg.Head,
+		PreviousTagName: gh.String(cfg.Base),
+		TargetCommitish: gh.String(cfg.Head),
+	})
+	if err != nil {
+		return fmt.Errorf("unable to generate release notes with GitHub: %w", err)
+	}
+	ours := map[int]bool{}
+	for pr := range listOfPRs {
+		ours[pr] = true
+	}
+	for pr := range backportPRs {
+		ours[pr] = true
+	}
+	missed, extra := diffPRs(ours, parseGeneratedNotes(notes.Body))
+
+	for _, pr := range extra {
+		fmt.Fprintf(os.Stderr, "WARNING: PR #%d is not in the release notes generated by GitHub\n", pr)
+	}
+	for _, number := range missed {
+		if cfg.CrossCheckGitHub != CrossCheckMerge {
+			fmt.Fprintf(os.Stderr, "WARNING: PR #%d is only in the release notes generated by GitHub\n", number)
+			continue
+		}
+		pr, _, err := ghClient.PullRequests.Get(ctx, cfg.Owner, cfg.Repo, number)
+		if err != nil {
+			return fmt.Errorf("unable to get PR %d: %w", number, err)
+		}
+		err = github.AddPullRequest(ctx, ghClient, prCache, cfg.UpstreamOwner, cfg.UpstreamRepo, nil, pr, backportPRs, listOfPRs)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "Added PR #%d found by GitHub\n", number)
+	}
+	if len(missed) == 0 && len(extra) == 0 {
+		fmt.Fprintf(os.Stderr, "The release notes generated by GitHub list the same PRs\n")
+	}
+	return nil
+}
@@ -0,0 +1,43 @@
+// Copyright 2021 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package changelog
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCrossCheckDiff(t *testing.T) {
+	body := "## What's Changed\n" +
+		"* Fix foo by @alice in https://github.com/cilium/cilium/pull/123\n" +
+		"* Add bar by @bob in https://github.com/cilium/cilium/pull/125\n" +
+		"\n" +
+		"## New Contributors\n" +
+		"* @bob made their first contribution in https://github.com/cilium/cilium/pull/125\n" +
+		"\n" +
+		"**Full Changelog**: https://github.com/cilium/cilium/compare/v1.14.2...v1.14.3\n"
+	theirs := parseGeneratedNotes(body)
+	if want := map[int]bool{123: true, 125: true}; !reflect.DeepEqual(theirs, want) {
+		t.Fatalf("parseGeneratedNotes() = %v, want %v", theirs, want)
+	}
+
+	missed, extra := diffPRs(map[int]bool{123: true, 124: true}, theirs)
+	if want := []int{125}; !reflect.DeepEqual(missed, want) {
+		t.Errorf("missed = %v, want %v", missed, want)
+	}
+	if want := []int{124}; !reflect.DeepEqual(extra, want) {
+		t.Errorf("extra = %v, want %v", extra, want)
+	}
+}
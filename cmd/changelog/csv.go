@@ -0,0 +1,89 @@
+// Copyright 2021 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package changelog
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// csvHeader are the columns of the FormatCSV format.
+var csvHeader = []string{"version", "section", "pr", "backport_pr", "author", "merged_at", "areas"}
+
+// writeCSV writes the entries of the release notes as CSV, one row per
+// entry, the Duplicates of an entry having their own rows. The backport PRs
+// and the areas of an entry are separated by semicolons.
+func (cl *ChangeLog) writeCSV(w io.Writer, opts RenderOptions) error {
+	ver := cl.detectVersion()
+	if len(ver) == 0 {
+		ver = cl.Head
+	}
+	skip := map[string]bool{}
+	for _, lbl := range opts.SkipLabels {
+		skip[lbl] = true
+	}
+
+	cw := csv.NewWriter(w)
+	if err := cw.Write(csvHeader); err != nil {
+		return err
+	}
+	for _, section := range cl.Sections() {
+		if skip[section.Label] {
+			continue
+		}
+		name := strings.TrimSuffix(strings.Trim(section.Header, "*"), ":")
+		var entries []Entry
+		for _, entry := range section.Entries {
+			entries = append(entries, entry.flatten()...)
+		}
+		for _, entry := range entries {
+			var backportPRs []string
+			for _, number := range entry.BackportPRs {
+				backportPRs = append(backportPRs, strconv.Itoa(number))
+			}
+			var areas []string
+			for _, lbl := range entry.Labels {
+				if strings.HasPrefix(lbl, areaLabelPrefix) {
+					areas = append(areas, strings.TrimPrefix(lbl, areaLabelPrefix))
+				}
+			}
+			var mergedAt string
+			if !entry.MergedAt.IsZero() {
+				mergedAt = entry.MergedAt.UTC().Format(time.RFC3339)
+			}
+			pr := ""
+			if entry.PR > 0 {
+				pr = strconv.Itoa(entry.PR)
+			}
+			record := []string{
+				ver,
+				name,
+				pr,
+				strings.Join(backportPRs, ";"),
+				entry.Author,
+				mergedAt,
+				strings.Join(areas, ";"),
+			}
+			if err := cw.Write(record); err != nil {
+				return err
+			}
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
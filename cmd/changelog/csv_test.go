@@ -0,0 +1,53 @@
+// Copyright 2021 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package changelog
+
+import (
+	"testing"
+	"time"
+
+	"github.com/cilium/release/pkg/types"
+)
+
+func TestRenderCSV(t *testing.T) {
+	merged := time.Date(2023, 7, 12, 9, 30, 0, 0, time.UTC)
+	cl := &ChangeLog{
+		Config: types.Config{Head: "v1.14.3", MergeDuplicates: true},
+		listOfPrs: types.PullRequests{
+			123: {ReleaseNote: "Add foo", AuthorName: "alice", MergedAt: merged, Labels: []string{"release-note/minor", "area/cli", "area/bgp"}},
+			124: {ReleaseNote: "Add foo", AuthorName: "carol", Labels: []string{"release-note/minor"}},
+			126: {ReleaseNote: "Improve CI", AuthorName: "dave", Labels: []string{"release-note/ci"}},
+		},
+		prsWithUpstream: types.BackportPRs{
+			200: {
+				150: {ReleaseNote: "Fix bar", AuthorName: "bob", MergedAt: merged, Labels: []string{"release-note/bug"}},
+			},
+			201: {
+				150: {ReleaseNote: "Fix bar", AuthorName: "bob", MergedAt: merged, Labels: []string{"release-note/bug"}},
+			},
+		},
+	}
+	got, err := cl.Render(RenderOptions{Format: FormatCSV, SkipLabels: []string{"release-note/ci"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "version,section,pr,backport_pr,author,merged_at,areas\n" +
+		"1.14.3,Minor Changes,123,,alice,2023-07-12T09:30:00Z,cli;bgp\n" +
+		"1.14.3,Minor Changes,124,,carol,,\n" +
+		"1.14.3,Bugfixes,150,200;201,bob,2023-07-12T09:30:00Z,\n"
+	if string(got) != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
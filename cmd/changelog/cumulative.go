@@ -0,0 +1,123 @@
+// Copyright 2021 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package changelog
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	gh "github.com/google/go-github/v50/github"
+	flag "github.com/spf13/pflag"
+
+	"github.com/cilium/release/pkg/github"
+	"github.com/cilium/release/pkg/profile"
+	"github.com/cilium/release/pkg/report"
+	"github.com/cilium/release/pkg/types"
+	"github.com/cilium/release/pkg/version"
+)
+
+// CumulativeCommand implements the 'cumulative' subcommand, which merges the
+// published notes of the patch releases of a minor series into a single
+// document for the users upgrading across several patch releases.
+func CumulativeCommand(ctx context.Context, ghClient *gh.Client, args []string) error {
+	var (
+		cfg    types.Config
+		since  string
+		output string
+	)
+	fs := flag.NewFlagSet("cumulative", flag.ContinueOnError)
+	fs.StringVar(&cfg.RepoName, "repo", "cilium/cilium", "GitHub organization and repository names separated by a slash")
+	fs.StringVar(&since, "since", "", "Release (e.g.: '1.14.1') whose changes, and the ones of the previous releases, are left out. Defaults to the x.y.0 release of the series")
+	fs.StringVar(&cfg.Profile, "profile", profile.Default, fmt.Sprintf("Label scheme of the repository, one of %s", strings.Join(profile.Names(), ", ")))
+	fs.StringVar(&cfg.TemplateDir, "template-dir", "", "Directory of templates overriding the default ones the release notes are rendered with")
+	fs.StringVarP(&output, "output", "o", "", "File where the notes are written instead of the standard output")
+	if err := types.ParseFlags(fs, "cumulative", args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return fmt.Errorf("usage: cumulative <x.y.z> [--since x.y.z] [flags]")
+	}
+	to, err := version.Parse(fs.Arg(0))
+	if err != nil {
+		return fmt.Errorf("version should be of the format 'x.y.z': %w", err)
+	}
+	from := version.Version{Major: to.Major, Minor: to.Minor}
+	if len(since) != 0 {
+		from, err = version.Parse(since)
+		if err != nil {
+			return fmt.Errorf("--since should be of the format 'x.y.z': %w", err)
+		}
+		if !from.SameMinor(to) || from.Compare(to) >= 0 {
+			return fmt.Errorf("--since should be a release of %s before %s", to.MinorString(), to)
+		}
+	}
+	cfg.Owner, cfg.Repo, err = types.SplitRepoName(cfg.RepoName)
+	if err != nil {
+		return err
+	}
+
+	releases, err := github.ListBranchReleases(ctx, ghClient, cfg.Owner, cfg.Repo, to.MinorString())
+	if err != nil {
+		return fmt.Errorf("unable to list releases: %w", err)
+	}
+	cl := New(ghClient, cfg, types.BackportPRs{}, types.PullRequests{})
+	n, err := cl.mergeReleases(releases, from, to)
+	if err != nil {
+		return err
+	}
+	notes, err := cl.Render(RenderOptions{})
+	if err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "Changes of the %d releases since v%s, up to v%s:\n\n", n, from, to)
+	buf.Write(notes)
+	if len(output) == 0 {
+		_, err := buf.WriteTo(os.Stdout)
+		return err
+	}
+	if err := os.WriteFile(output, buf.Bytes(), 0644); err != nil {
+		return err
+	}
+	report.Written(output)
+	return nil
+}
+
+// mergeReleases merges the notes of the given releases after from, up to
+// and including to, into the changelog and returns how many were merged. As
+// the releases are ordered from the most recent one, a PR listed by several
+// releases is listed with its most recent note. It fails if to isn't
+// published.
+func (cl *ChangeLog) mergeReleases(releases []*gh.RepositoryRelease, from, to version.Version) (int, error) {
+	seen := cl.upstreamPRNumbers()
+	n, published := 0, false
+	for _, release := range releases {
+		v, err := version.Parse(release.GetTagName())
+		if err != nil || v.Compare(from) <= 0 || v.Compare(to) > 0 {
+			continue
+		}
+		published = published || v.Compare(to) == 0
+		fmt.Fprintf(os.Stderr, "Merging notes from release %s\n", release.GetTagName())
+		cl.mergeNotes(release.GetBody(), seen)
+		n++
+	}
+	if !published {
+		return 0, fmt.Errorf("release v%s isn't published", to)
+	}
+	return n, nil
+}
@@ -0,0 +1,71 @@
+// Copyright 2021 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package changelog
+
+import (
+	"testing"
+
+	gh "github.com/google/go-github/v50/github"
+
+	"github.com/cilium/release/pkg/types"
+	"github.com/cilium/release/pkg/version"
+)
+
+func TestMergeReleases(t *testing.T) {
+	release := func(tag, body string) *gh.RepositoryRelease {
+		return &gh.RepositoryRelease{TagName: gh.String(tag), Body: gh.String(body)}
+	}
+	releases := []*gh.RepositoryRelease{
+		release("v1.14.3", "**Bugfixes:**\n* Fix qux (#130, @erin)\n"),
+		release("v1.14.2", "**Bugfixes:**\n"+
+			"* Fix bar in the agent (Backport PR #201, Upstream PR #124, @bob)\n"+
+			"* Fix baz (#125, @carol)\n"),
+		release("v1.14.1", "**Minor Changes:**\n"+
+			"* Add foo (Backport PR #200, Upstream PR #123, @alice)\n"+
+			"\n**Bugfixes:**\n"+
+			"* Fix bar (Backport PR #200, Upstream PR #124, @bob)\n"),
+		release("v1.14.0", "**Major Changes:**\n* Add everything (#100, @dave)\n"),
+	}
+
+	cl := New(nil, types.Config{}, types.BackportPRs{}, types.PullRequests{})
+	n, err := cl.mergeReleases(releases, version.Version{Major: 1, Minor: 14}, version.Version{Major: 1, Minor: 14, Patch: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 2 {
+		t.Errorf("merged %d releases, want 2", n)
+	}
+	got, err := cl.Render(RenderOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "Summary of Changes\n" +
+		"------------------\n" +
+		"\n" +
+		"**Minor Changes:**\n" +
+		"* Add foo (Backport PR #200, Upstream PR #123, @alice)\n" +
+		"\n" +
+		"**Bugfixes:**\n" +
+		"* Fix bar in the agent (Backport PR #201, Upstream PR #124, @bob)\n" +
+		"* Fix baz (#125, @carol)\n"
+	if string(got) != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+
+	cl = New(nil, types.Config{}, types.BackportPRs{}, types.PullRequests{})
+	if _, err := cl.mergeReleases(releases, version.Version{Major: 1, Minor: 14}, version.Version{Major: 1, Minor: 14, Patch: 4}); err == nil {
+		t.Error("unpublished release merged")
+	}
+}
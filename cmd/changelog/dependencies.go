@@ -0,0 +1,186 @@
+// Copyright 2021 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package changelog
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+
+	gh "github.com/google/go-github/v50/github"
+
+	"github.com/cilium/release/pkg/tracing"
+	"github.com/cilium/release/pkg/usage"
+)
+
+// DependencyChange is a module added, removed or updated in go.mod between
+// the base and the head of the release notes.
+type DependencyChange struct {
+	Module string
+	// From is the version of Module at the base, empty if it was added.
+	From string
+	// To is the version of Module at the head, empty if it was removed.
+	To string
+}
+
+func (c DependencyChange) String() string {
+	switch {
+	case len(c.From) == 0:
+		return fmt.Sprintf("Added %s %s", c.Module, c.To)
+	case len(c.To) == 0:
+		return fmt.Sprintf("Removed %s %s", c.Module, c.From)
+	}
+	return fmt.Sprintf("Updated %s from %s to %s", c.Module, c.From, c.To)
+}
+
+// goModReplace is a replace directive of a go.mod.
+type goModReplace struct {
+	// version is the version of the module replaced, empty if all of its
+	// versions are.
+	version string
+	// path and newVersion are the replacement, newVersion being empty if
+	// it is a local directory.
+	path       string
+	newVersion string
+}
+
+// parseGoMod returns the versions of the modules required by the given
+// go.mod, by module path. The modules replaced have the version of their
+// replacement, preceded by its path if it is another module (e.g.
+// 'github.com/cilium/dns v1.1.51') or being the path of the local directory.
+func parseGoMod(content string) map[string]string {
+	modules := map[string]string{}
+	replaces := map[string][]goModReplace{}
+	var block string
+	for _, line := range strings.Split(content, "\n") {
+		if i := strings.Index(line, "//"); i >= 0 {
+			line = line[:i]
+		}
+		fields := strings.Fields(line)
+		directive := block
+		switch {
+		case len(fields) == 0:
+			continue
+		case len(block) != 0 && fields[0] == ")":
+			block = ""
+			continue
+		case (fields[0] == "require" || fields[0] == "replace") && len(fields) == 2 && fields[1] == "(":
+			block = fields[0]
+			continue
+		case fields[0] == "require" || fields[0] == "replace":
+			directive, fields = fields[0], fields[1:]
+		}
+		switch directive {
+		case "require":
+			if len(fields) == 2 {
+				modules[fields[0]] = fields[1]
+			}
+		case "replace":
+			var r goModReplace
+			switch {
+			case len(fields) == 3 && fields[1] == "=>":
+				r.path = fields[2]
+			case len(fields) == 4 && fields[1] == "=>":
+				r.path, r.newVersion = fields[2], fields[3]
+			case len(fields) == 4 && fields[2] == "=>":
+				r.version, r.path = fields[1], fields[3]
+			case len(fields) == 5 && fields[2] == "=>":
+				r.version, r.path, r.newVersion = fields[1], fields[3], fields[4]
+			default:
+				continue
+			}
+			replaces[fields[0]] = append(replaces[fields[0]], r)
+		}
+	}
+
+	for module, v := range modules {
+		// A replacement of the required version takes precedence over
+		// one of all versions.
+		var replaced *goModReplace
+		for i, r := range replaces[module] {
+			if r.version == v || (len(r.version) == 0 && replaced == nil) {
+				replaced = &replaces[module][i]
+			}
+		}
+		switch {
+		case replaced == nil:
+		case len(replaced.newVersion) == 0:
+			modules[module] = replaced.path
+		case replaced.path == module:
+			modules[module] = replaced.newVersion
+		default:
+			modules[module] = replaced.path + " " + replaced.newVersion
+		}
+	}
+	return modules
+}
+
+// diffGoMod returns the modules added, removed or updated between the go.mod
+// files base and head, sorted by module path.
+func diffGoMod(base, head string) []DependencyChange {
+	from, to := parseGoMod(base), parseGoMod(head)
+	var changes []DependencyChange
+	for module, v := range from {
+		if to[module] != v {
+			changes = append(changes, DependencyChange{Module: module, From: v, To: to[module]})
+		}
+	}
+	for module, v := range to {
+		if _, ok := from[module]; !ok {
+			changes = append(changes, DependencyChange{Module: module, To: v})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Module < changes[j].Module
+	})
+	return changes
+}
+
+// findDependencyChanges computes, if cl.DependencyChanges is set, the
+// changes of the modules required by the go.mod of the repository between
+// cl.Base and cl.Head.
+func (cl *ChangeLog) findDependencyChanges(ctx context.Context, tracker *usage.Tracker) error {
+	if !cl.DependencyChanges {
+		return nil
+	}
+	ctx, endPhase := tracing.Phase(ctx, tracker, "dependency changes")
+	defer endPhase()
+
+	src := resolutionConfig(cl.Config)
+	goMod := func(ref string) (string, error) {
+		file, _, resp, err := cl.ghClient.Repositories.GetContents(ctx, src.Owner, src.Repo, "go.mod", &gh.RepositoryContentGetOptions{Ref: ref})
+		if resp != nil && resp.StatusCode == http.StatusNotFound {
+			return "", nil
+		}
+		if err != nil {
+			return "", fmt.Errorf("unable to get go.mod at %s: %w", ref, err)
+		}
+		return file.GetContent()
+	}
+	base, err := goMod(cl.Base)
+	if err != nil {
+		return err
+	}
+	head, err := goMod(cl.Head)
+	if err != nil {
+		return err
+	}
+	cl.dependencyChanges = diffGoMod(base, head)
+	fmt.Fprintf(os.Stderr, "Found %d dependency changes\n", len(cl.dependencyChanges))
+	return nil
+}
@@ -0,0 +1,118 @@
+// Copyright 2021 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package changelog
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/cilium/release/pkg/types"
+)
+
+func TestDiffGoMod(t *testing.T) {
+	base := `module github.com/cilium/cilium
+
+go 1.20
+
+require github.com/spf13/pflag v1.0.5
+
+require (
+	github.com/google/go-github/v50 v50.0.0
+	golang.org/x/net v0.7.0 // indirect
+	gopkg.in/yaml.v2 v2.4.0
+)
+
+replace github.com/foo/bar => ../bar
+`
+	head := `module github.com/cilium/cilium
+
+go 1.21
+
+require (
+	github.com/google/go-github/v50 v50.0.0
+	github.com/spf13/pflag v1.0.5
+	golang.org/x/net v0.17.0 // indirect
+	gopkg.in/yaml.v3 v3.0.1
+)
+`
+	want := []DependencyChange{
+		{Module: "golang.org/x/net", From: "v0.7.0", To: "v0.17.0"},
+		{Module: "gopkg.in/yaml.v2", From: "v2.4.0"},
+		{Module: "gopkg.in/yaml.v3", To: "v3.0.1"},
+	}
+	got := diffGoMod(base, head)
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("diffGoMod() = %+v, want %+v", got, want)
+	}
+
+	cl := &ChangeLog{Config: types.Config{DependencyChanges: true}, dependencyChanges: got}
+	notes, err := cl.Render(RenderOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	section := "**Dependency Changes:**\n" +
+		"* Updated golang.org/x/net from v0.7.0 to v0.17.0\n" +
+		"* Removed gopkg.in/yaml.v2 v2.4.0\n" +
+		"* Added gopkg.in/yaml.v3 v3.0.1\n"
+	if !strings.HasSuffix(string(notes), "\n\n"+section) {
+		t.Errorf("section not rendered:\n%s", notes)
+	}
+}
+
+func TestParseGoModReplace(t *testing.T) {
+	goMod := `module github.com/cilium/cilium
+
+require (
+	github.com/miekg/dns v1.1.50
+	github.com/vishvananda/netlink v1.2.1
+	go.universe.tf/metallb v0.11.0
+	golang.org/x/net v0.17.0
+	k8s.io/client-go v0.28.2
+)
+
+replace (
+	github.com/miekg/dns => github.com/cilium/dns v1.1.51-0.20230303133941-d3bcb3008ed2
+	go.universe.tf/metallb => github.com/cilium/metallb v0.1.1-0.20220829170633-5d7dfb1129f7
+	golang.org/x/net v0.7.0 => golang.org/x/net v0.8.0
+)
+
+replace k8s.io/client-go v0.28.2 => k8s.io/client-go v0.28.3
+
+replace github.com/vishvananda/netlink => ../netlink
+`
+	want := map[string]string{
+		"github.com/miekg/dns":           "github.com/cilium/dns v1.1.51-0.20230303133941-d3bcb3008ed2",
+		"github.com/vishvananda/netlink": "../netlink",
+		"go.universe.tf/metallb":         "github.com/cilium/metallb v0.1.1-0.20220829170633-5d7dfb1129f7",
+		"golang.org/x/net":               "v0.17.0",
+		"k8s.io/client-go":               "v0.28.3",
+	}
+	if got := parseGoMod(goMod); !reflect.DeepEqual(got, want) {
+		t.Errorf("parseGoMod() = %v, want %v", got, want)
+	}
+
+	// Bumping the fork a module is replaced by updates the module.
+	head := strings.Replace(goMod, "v1.1.51-0.20230303133941-d3bcb3008ed2", "v1.1.52", 1)
+	changes := diffGoMod(goMod, head)
+	wantChanges := []DependencyChange{{
+		Module: "github.com/miekg/dns",
+		From:   "github.com/cilium/dns v1.1.51-0.20230303133941-d3bcb3008ed2",
+		To:     "github.com/cilium/dns v1.1.52",
+	}}
+	if !reflect.DeepEqual(changes, wantChanges) {
+		t.Errorf("diffGoMod() = %+v, want %+v", changes, wantChanges)
+	}
+}
@@ -0,0 +1,191 @@
+// Copyright 2021 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package changelog
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	gh "github.com/google/go-github/v50/github"
+	flag "github.com/spf13/pflag"
+
+	"github.com/cilium/release/pkg/persistence"
+	"github.com/cilium/release/pkg/profile"
+	"github.com/cilium/release/pkg/types"
+)
+
+// DiffCommand implements the 'diff' subcommand, which compares the entries
+// of two releases, e.g. of a respin, and fails if they differ. Each release
+// is given by its state file or by its tag, whose published notes are
+// parsed. With --base, each release is given by a head instead, e.g. the
+// last release candidate and the tip of the branch, and its notes are
+// generated from --base.
+func DiffCommand(ctx context.Context, ghClient *gh.Client, args []string) error {
+	var cfg types.Config
+	fs := flag.NewFlagSet("diff", flag.ContinueOnError)
+	fs.StringVar(&cfg.RepoName, "repo", "cilium/cilium", "GitHub organization and repository names separated by a slash")
+	fs.StringVar(&cfg.Profile, "profile", profile.Default, fmt.Sprintf("Label scheme of the repository, one of %s", strings.Join(profile.Names(), ", ")))
+	fs.StringVar(&cfg.Base, "base", "", "Base commit / tag the notes of both heads are generated from, the arguments being heads instead of state files or tags")
+	fs.StringSliceVar(&cfg.LastStable, "last-stable", nil, "Last stable versions whose backported PRs are left out of the generated notes (e.g.: '1.5', '1.6'). Can be repeated or comma-separated")
+	fs.StringVar(&cfg.CacheDir, "cache-dir", "", "Directory of the PR metadata cache shared across runs, releases and branches, e.g. ~/.cache/cilium-release. The cached PRs updated since the previous run are dropped. Disabled if empty")
+	if err := types.ParseFlags(fs, "diff", args); err != nil {
+		return err
+	}
+	if fs.NArg() != 2 {
+		return fmt.Errorf("usage: diff <state-file|tag> <state-file|tag> [flags] or diff --base <ref> <head> <head> [flags]")
+	}
+	var err error
+	cfg.Owner, cfg.Repo, err = types.SplitRepoName(cfg.RepoName)
+	if err != nil {
+		return err
+	}
+	a, err := loadEntries(ctx, ghClient, cfg, fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	b, err := loadEntries(ctx, ghClient, cfg, fs.Arg(1))
+	if err != nil {
+		return err
+	}
+	if n := writeDiff(os.Stdout, fs.Arg(0), fs.Arg(1), a, b); n != 0 {
+		return fmt.Errorf("%d entries differ", n)
+	}
+	fmt.Fprintf(os.Stdout, "%s and %s have the same %d entries\n", fs.Arg(0), fs.Arg(1), len(a))
+	return nil
+}
+
+// diffEntry is an entry compared by the 'diff' subcommand, along with the
+// section it's listed in.
+type diffEntry struct {
+	Entry
+	Section string
+}
+
+func (e diffEntry) String() string {
+	return fmt.Sprintf("%s in %s", e.Entry, strings.TrimSuffix(strings.Trim(e.Section, "*"), ":"))
+}
+
+// loadRelease returns the changelog of the given state file or, if there is
+// no such file, of the published release of the given tag.
+func loadRelease(ctx context.Context, ghClient *gh.Client, cfg types.Config, ref string) (*ChangeLog, error) {
+	if _, err := os.Stat(ref); err == nil {
+		state, err := persistence.Load(ref)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read state file %s: %w", ref, err)
+		}
+		return New(ghClient, cfg, state.BackportPRs, state.PullRequests), nil
+	}
+	tag := "v" + strings.TrimPrefix(ref, "v")
+	release, _, err := ghClient.Repositories.GetReleaseByTag(ctx, cfg.Owner, cfg.Repo, tag)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get release %s: %w", tag, err)
+	}
+	cl := New(ghClient, cfg, nil, nil)
+	cl.prsWithUpstream, cl.listOfPrs = parseReleaseNotes(release.GetBody(), cl.scheme())
+	return cl, nil
+}
+
+// generateRelease returns the changelog of cfg.Base...head, generated from
+// scratch.
+func generateRelease(ctx context.Context, ghClient *gh.Client, cfg types.Config, head string) (*ChangeLog, error) {
+	stateDir, err := os.MkdirTemp("", "release-diff")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(stateDir)
+	cfg.Head = head
+	cfg.StateFile = filepath.Join(stateDir, "state.json")
+	if err := cfg.Sanitize(); err != nil {
+		return nil, err
+	}
+	return GenerateReleaseNotes(ctx, ghClient, cfg, nil)
+}
+
+// loadEntries returns the entries of the release notes, by PR number, of
+// the given head if cfg.Base is set, see generateRelease, or else of the
+// given state file or tag, see loadRelease.
+func loadEntries(ctx context.Context, ghClient *gh.Client, cfg types.Config, ref string) (map[int]diffEntry, error) {
+	var (
+		cl  *ChangeLog
+		err error
+	)
+	if len(cfg.Base) != 0 {
+		cl, err = generateRelease(ctx, ghClient, cfg, ref)
+	} else {
+		cl, err = loadRelease(ctx, ghClient, cfg, ref)
+	}
+	if err != nil {
+		return nil, err
+	}
+	entries := map[int]diffEntry{}
+	for _, section := range cl.Sections() {
+		for _, entry := range section.Entries {
+			entries[entry.PR] = diffEntry{Entry: entry, Section: section.Header}
+		}
+	}
+	return entries, nil
+}
+
+// writeDiff writes into w the entries only found in a, the ones only found
+// in b and the ones that changed, e.g. whose note or section changed, and
+// returns how many entries differ.
+func writeDiff(w io.Writer, nameA, nameB string, a, b map[int]diffEntry) int {
+	var onlyA, onlyB, changed []int
+	for pr, entry := range a {
+		other, ok := b[pr]
+		switch {
+		case !ok:
+			onlyA = append(onlyA, pr)
+		case entry.String() != other.String():
+			changed = append(changed, pr)
+		}
+	}
+	for pr := range b {
+		if _, ok := a[pr]; !ok {
+			onlyB = append(onlyB, pr)
+		}
+	}
+	sort.Ints(onlyA)
+	sort.Ints(onlyB)
+	sort.Ints(changed)
+
+	if len(onlyA) != 0 {
+		fmt.Fprintf(w, "Only in %s (%d):\n", nameA, len(onlyA))
+		for _, pr := range onlyA {
+			fmt.Fprintln(w, a[pr].Entry)
+		}
+		fmt.Fprintln(w)
+	}
+	if len(onlyB) != 0 {
+		fmt.Fprintf(w, "Only in %s (%d):\n", nameB, len(onlyB))
+		for _, pr := range onlyB {
+			fmt.Fprintln(w, b[pr].Entry)
+		}
+		fmt.Fprintln(w)
+	}
+	if len(changed) != 0 {
+		fmt.Fprintf(w, "Changed (%d):\n", len(changed))
+		for _, pr := range changed {
+			fmt.Fprintf(w, "- %s\n+ %s\n", a[pr], b[pr])
+		}
+		fmt.Fprintln(w)
+	}
+	return len(onlyA) + len(onlyB) + len(changed)
+}
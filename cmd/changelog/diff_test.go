@@ -0,0 +1,145 @@
+// Copyright 2021 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package changelog
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"testing"
+
+	gh "github.com/google/go-github/v50/github"
+
+	"github.com/cilium/release/pkg/persistence"
+	"github.com/cilium/release/pkg/types"
+)
+
+func TestDiff(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/repos/cilium/cilium/releases/tags/v1.14.3" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL)
+			http.NotFound(w, r)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]string{
+			"tag_name": "v1.14.3",
+			"body": "Summary of Changes\n------------------\n\n" +
+				"**Minor Changes:**\n" +
+				"* Add foo (Backport PR #200, Upstream PR #123, @alice)\n" +
+				"* Fix bar (#124, @bob)\n" +
+				"\n**Bugfixes:**\n" +
+				"* Fix baz (#125, @carol)\n",
+		})
+	}))
+	defer srv.Close()
+	ghClient := gh.NewClient(nil)
+	ghClient.BaseURL, _ = url.Parse(srv.URL + "/")
+
+	stateFile := filepath.Join(t.TempDir(), "state.json")
+	err := persistence.Store(stateFile, &persistence.State{
+		BackportPRs: types.BackportPRs{
+			200: {123: {ReleaseNote: "Add foo", AuthorName: "alice", Labels: []string{"release-note/minor"}}},
+		},
+		PullRequests: types.PullRequests{
+			124: {ReleaseNote: "Fix bar", AuthorName: "bob", Labels: []string{"release-note/bug"}},
+			126: {ReleaseNote: "Fix qux", AuthorName: "dave", Labels: []string{"release-note/bug"}},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := types.Config{Owner: "cilium", Repo: "cilium"}
+	a, err := loadEntries(context.Background(), ghClient, cfg, stateFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := loadEntries(context.Background(), ghClient, cfg, "1.14.3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if n := writeDiff(&buf, "rc", "v1.14.3", a, b); n != 3 {
+		t.Errorf("got %d differences, want 3", n)
+	}
+	want := "Only in rc (1):\n" +
+		"* Fix qux (#126, @dave)\n" +
+		"\n" +
+		"Only in v1.14.3 (1):\n" +
+		"* Fix baz (#125, @carol)\n" +
+		"\n" +
+		"Changed (1):\n" +
+		"- * Fix bar (#124, @bob) in Bugfixes\n" +
+		"+ * Fix bar (#124, @bob) in Minor Changes\n" +
+		"\n"
+	if buf.String() != want {
+		t.Errorf("got:\n%s\nwant:\n%s", buf.String(), want)
+	}
+}
+
+func TestDiffHeads(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/rate_limit":
+			fmt.Fprint(w, `{"resources": {"core": {"limit": 5000, "remaining": 5000}}}`)
+		case "/repos/cilium/cilium/commits/v1.14.0", "/repos/cilium/cilium/commits/v1.14.1-rc.2", "/repos/cilium/cilium/commits/v1.14":
+			fmt.Fprint(w, `{"sha": "c0"}`)
+		case "/repos/cilium/cilium/compare/v1.14.0...v1.14.1-rc.2":
+			fmt.Fprint(w, `{"total_commits": 1, "commits": [{"sha": "c1", "commit": {"message": "Fix bar (#124)"}}]}`)
+		case "/repos/cilium/cilium/compare/v1.14.0...v1.14":
+			fmt.Fprint(w, `{"total_commits": 2, "commits": [
+				{"sha": "c1", "commit": {"message": "Fix bar (#124)"}},
+				{"sha": "c2", "commit": {"message": "Fix qux (#126)"}}
+			]}`)
+		case "/repos/cilium/cilium/commits/c1/pulls":
+			fmt.Fprint(w, `[{"number": 124, "state": "closed", "title": "Fix bar", "merged_at": "2023-07-12T09:30:00Z",
+				"user": {"login": "bob"}, "labels": [{"name": "release-note/bug"}]}]`)
+		case "/repos/cilium/cilium/commits/c2/pulls":
+			fmt.Fprint(w, `[{"number": 126, "state": "closed", "title": "Fix qux", "merged_at": "2023-07-13T09:30:00Z",
+				"user": {"login": "dave"}, "labels": [{"name": "release-note/bug"}]}]`)
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL)
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+	ghClient := gh.NewClient(nil)
+	ghClient.BaseURL, _ = url.Parse(srv.URL + "/")
+
+	cfg := types.Config{RepoName: "cilium/cilium", Base: "v1.14.0"}
+	a, err := loadEntries(context.Background(), ghClient, cfg, "v1.14.1-rc.2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := loadEntries(context.Background(), ghClient, cfg, "v1.14")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if n := writeDiff(&buf, "v1.14.1-rc.2", "v1.14", a, b); n != 1 {
+		t.Errorf("got %d differences, want 1", n)
+	}
+	want := "Only in v1.14 (1):\n" +
+		"* Fix qux (#126, @dave)\n" +
+		"\n"
+	if buf.String() != want {
+		t.Errorf("got:\n%s\nwant:\n%s", buf.String(), want)
+	}
+}
@@ -0,0 +1,59 @@
+// Copyright 2021 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package changelog
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/cilium/release/pkg/config"
+	"github.com/cilium/release/pkg/types"
+)
+
+// loadDocs reads the links to the documentation from cfg.ConfigFile, if
+// set.
+func loadDocs(cfg types.Config) (config.Docs, error) {
+	if len(cfg.ConfigFile) == 0 {
+		return config.Docs{}, nil
+	}
+	c, err := config.Load(cfg.ConfigFile)
+	if err != nil {
+		return config.Docs{}, fmt.Errorf("unable to load configuration: %w", err)
+	}
+	return c.Docs, nil
+}
+
+// docLinks returns the links to the documentation of the areas among the
+// given labels, sorted by area. The area is the label without its prefix,
+// e.g. 'clustermesh' for 'area/clustermesh'.
+func (cl *ChangeLog) docLinks(lbls []string) []DocLink {
+	var links []DocLink
+	for _, lbl := range lbls {
+		url, ok := cl.docs.Areas[lbl]
+		if !ok {
+			continue
+		}
+		area := lbl
+		if i := strings.LastIndex(lbl, "/"); i != -1 {
+			area = lbl[i+1:]
+		}
+		links = append(links, DocLink{Area: area, URL: url})
+	}
+	sort.Slice(links, func(i, j int) bool {
+		return links[i].Area < links[j].Area
+	})
+	return links
+}
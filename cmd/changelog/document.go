@@ -0,0 +1,129 @@
+// Copyright 2021 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package changelog
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/cilium/release/pkg/config"
+	"github.com/cilium/release/pkg/types"
+)
+
+// documentData is the data the header and footer of the document are
+// executed with.
+type documentData struct {
+	// Version and Date are the ones of the front matter.
+	Version string
+	Date    string
+	// Repo is the repository, e.g. 'cilium/cilium'.
+	Repo string
+	// Images is config.ImagesPlaceholder.
+	Images string
+	Docs   config.Docs
+}
+
+// loadDocument reads the header and footer of the document from
+// cfg.ConfigFile, if set.
+func loadDocument(cfg types.Config) (config.Document, error) {
+	if len(cfg.ConfigFile) == 0 {
+		return config.Document{}, nil
+	}
+	c, err := config.Load(cfg.ConfigFile)
+	if err != nil {
+		return config.Document{}, fmt.Errorf("unable to load configuration: %w", err)
+	}
+	return c.Document, nil
+}
+
+// document returns the given release notes between the header and the
+// footer of the given document, each of them separated from the notes by an
+// empty line.
+func (cl *ChangeLog) document(doc config.Document, notes []byte, now time.Time) ([]byte, error) {
+	fm := cl.frontMatter(now)
+	data := documentData{
+		Version: fm.Version,
+		Date:    fm.Date,
+		Repo:    cl.Owner + "/" + cl.Repo,
+		Images:  config.ImagesPlaceholder,
+		Docs:    cl.docs,
+	}
+	header, err := executeFragment("header", doc.Header, data)
+	if err != nil {
+		return nil, err
+	}
+	footer, err := executeFragment("footer", doc.Footer, data)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if len(header) != 0 {
+		buf.WriteString(header)
+		buf.WriteString("\n\n")
+	}
+	buf.Write(notes)
+	if len(footer) != 0 {
+		buf.WriteString("\n")
+		buf.WriteString(footer)
+		buf.WriteString("\n")
+	}
+	return buf.Bytes(), nil
+}
+
+// pages returns the given rendered release notes as published: notes between
+// the header and footer of the document of cl.ConfigFile, if any, published
+// on GitHub, and page, notes preceded by the front matter if requested,
+// written into the local files.
+func (cl *ChangeLog) pages(rendered []byte, now time.Time) (page, notes []byte, err error) {
+	notes = rendered
+	if cl.Format == FormatMarkdown {
+		doc, err := loadDocument(cl.Config)
+		if err != nil {
+			return nil, nil, err
+		}
+		notes, err = cl.document(doc, rendered, now)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	page = notes
+	if cl.FrontMatter {
+		page, err = WithFrontMatter(cl.frontMatter(now), notes)
+		if err != nil {
+			return nil, nil, fmt.Errorf("unable to write front matter: %w", err)
+		}
+	}
+	return page, notes, nil
+}
+
+// executeFragment executes the given fragment of the document, returning it
+// without its trailing newlines.
+func executeFragment(name, fragment string, data documentData) (string, error) {
+	if len(fragment) == 0 {
+		return "", nil
+	}
+	t, err := template.New(name).Option("missingkey=error").Parse(fragment)
+	if err != nil {
+		return "", fmt.Errorf("unable to parse document %s: %w", name, err)
+	}
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("unable to execute document %s: %w", name, err)
+	}
+	return strings.TrimRight(buf.String(), "\n"), nil
+}
@@ -0,0 +1,106 @@
+// Copyright 2021 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package changelog
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/cilium/release/pkg/config"
+	"github.com/cilium/release/pkg/types"
+)
+
+func TestDocument(t *testing.T) {
+	now := time.Date(2021, 6, 15, 10, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name    string
+		doc     config.Document
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "no fragment",
+			want: "Summary of Changes\n",
+		},
+		{
+			name: "header and footer",
+			doc: config.Document{
+				Header: "{{ .Repo }} {{ .Version }} was released on {{ .Date }}, see the [upgrade guide]({{ .Docs.Links.upgrade }}).\n",
+				Footer: "{{ .Images }}\n",
+			},
+			want: "cilium/cilium 1.14.3 was released on 2021-06-15, see the [upgrade guide](https://docs.cilium.io/upgrade).\n\n" +
+				"Summary of Changes\n\n" + config.ImagesPlaceholder + "\n",
+		},
+		{
+			name:    "unknown link",
+			doc:     config.Document{Header: "{{ .Docs.Links.install }}"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := types.Config{Head: "v1.14.3"}
+			cfg.Owner, cfg.Repo = "cilium", "cilium"
+			cl := New(nil, cfg, nil, nil)
+			cl.docs = config.Docs{Links: map[string]string{"upgrade": "https://docs.cilium.io/upgrade"}}
+			got, err := cl.document(tt.doc, []byte("Summary of Changes\n"), now)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("got %q, want an error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("got:\n%s\nwant:\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPagesFrontMatterAndDocument(t *testing.T) {
+	cfgFile := filepath.Join(t.TempDir(), "release.yaml")
+	err := os.WriteFile(cfgFile, []byte(`
+document:
+  header: "Cilium {{ .Version }}"
+  footer: "{{ .Images }}"
+`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := types.Config{
+		Head:        "v1.14.3",
+		ConfigFile:  cfgFile,
+		Format:      FormatMarkdown,
+		FrontMatter: true,
+	}
+	cl := New(nil, cfg, nil, nil)
+	page, notes, err := cl.pages([]byte("Summary of Changes\n"), time.Date(2021, 6, 15, 10, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantNotes := "Cilium 1.14.3\n\nSummary of Changes\n\n" + config.ImagesPlaceholder + "\n"
+	if string(notes) != wantNotes {
+		t.Errorf("got notes:\n%s\nwant:\n%s", notes, wantNotes)
+	}
+	wantPage := "---\ntitle: v1.14.3\ndate: \"2021-06-15\"\nversion: 1.14.3\n---\n\n" + wantNotes
+	if string(page) != wantPage {
+		t.Errorf("got page:\n%s\nwant:\n%s", page, wantPage)
+	}
+}
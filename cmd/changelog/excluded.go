@@ -0,0 +1,144 @@
+// Copyright 2021 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package changelog
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/cilium/release/pkg/report"
+	"github.com/cilium/release/pkg/version"
+)
+
+// ExcludedEntry is a PR left out of the release notes as it was backported
+// to one of the last stable branches and is thus assumed to be already
+// released.
+type ExcludedEntry struct {
+	// Section is the release note label of the PR.
+	Section     string `json:"section"`
+	PR          int    `json:"pr"`
+	Author      string `json:"author,omitempty"`
+	ReleaseNote string `json:"release-note"`
+	// BackportBranches are the labels through which the PR was matched
+	// against the last stable branches, e.g. 'backport-done/1.13'.
+	BackportBranches []string `json:"backport-branches"`
+	URL              string   `json:"url,omitempty"`
+}
+
+// ExcludedEntries returns the PRs left out of the release notes as they were
+// backported to the last stable branches, in the order of their sections.
+func (cl *ChangeLog) ExcludedEntries() []ExcludedEntry {
+	var excluded []ExcludedEntry
+	for _, section := range cl.ExcludedSections() {
+		for _, entry := range section.Entries {
+			pr := cl.listOfPrs[entry.PR]
+			excluded = append(excluded, ExcludedEntry{
+				Section:          section.Label,
+				PR:               entry.PR,
+				Author:           entry.Author,
+				ReleaseNote:      entry.ReleaseNote,
+				BackportBranches: cl.lastStableBackports(pr),
+				URL:              pr.URL,
+			})
+		}
+	}
+	return excluded
+}
+
+// writeExcludedFile writes the PRs left out of the release notes as they were
+// backported to the last stable branches as Markdown into file, one section
+// per release note label, so that the exclusions can be reviewed.
+func (cl *ChangeLog) writeExcludedFile(file string) error {
+	var buf bytes.Buffer
+	fmt.Fprintln(&buf, "Excluded Changes")
+	fmt.Fprintln(&buf, "----------------")
+	fmt.Fprintln(&buf)
+	fmt.Fprintf(&buf, "The following PRs were not included in the release notes as they were "+
+		"backported to branches %s and are assumed to be already released.\n", strings.Join(cl.LastStable, ", "))
+	for _, section := range cl.ExcludedSections() {
+		fmt.Fprintln(&buf)
+		fmt.Fprintln(&buf, section.Header)
+		fmt.Fprintln(&buf)
+		for _, entry := range section.Entries {
+			fmt.Fprintf(&buf, "%s (%s)\n", entry, strings.Join(cl.lastStableBackports(cl.listOfPrs[entry.PR]), ", "))
+		}
+	}
+	if err := os.WriteFile(file, buf.Bytes(), 0644); err != nil {
+		return err
+	}
+	report.Written(file)
+	return nil
+}
+
+// writeExcludedReport writes the PRs left out of the release notes as they
+// were backported to the last stable branches as JSON into file.
+func (cl *ChangeLog) writeExcludedReport(file string) error {
+	excluded := cl.ExcludedEntries()
+	if excluded == nil {
+		excluded = []ExcludedEntry{}
+	}
+	b, err := json.MarshalIndent(excluded, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(file, append(b, '\n'), 0644); err != nil {
+		return err
+	}
+	report.Written(file)
+	return nil
+}
+
+// writePreviouslyReleased writes into buf the appendix listing the PRs left
+// out of the release notes as they were backported to the last stable
+// branches, without the sections of skip.
+func (cl *ChangeLog) writePreviouslyReleased(buf *bytes.Buffer, skip map[string]bool) {
+	var sections []Section
+	for _, section := range cl.ExcludedSections() {
+		if !skip[section.Label] {
+			sections = append(sections, section)
+		}
+	}
+	if len(sections) == 0 {
+		return
+	}
+	versions := make([]string, 0, len(cl.LastStable))
+	for _, lastStable := range cl.LastStable {
+		s, err := version.ParseSeries(lastStable)
+		if err != nil {
+			continue
+		}
+		v := "v" + s.MinorString() + ".x"
+		if s.UpTo {
+			v += " and earlier"
+		}
+		versions = append(versions, v)
+	}
+	title := "Previously Released in " + strings.Join(versions, ", ")
+	fmt.Fprintln(buf)
+	fmt.Fprintln(buf, title)
+	fmt.Fprintln(buf, strings.Repeat("-", len(title)))
+	fmt.Fprintln(buf)
+	fmt.Fprintln(buf, "The following changes are also part of this release but were already released through backports.")
+	for _, section := range sections {
+		fmt.Fprintln(buf)
+		fmt.Fprintln(buf, section.Header)
+		for _, entry := range section.Entries {
+			fmt.Fprintln(buf, entry)
+		}
+	}
+}
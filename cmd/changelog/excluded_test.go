@@ -0,0 +1,144 @@
+// Copyright 2021 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package changelog
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/cilium/release/pkg/types"
+)
+
+func TestWriteExcluded(t *testing.T) {
+	cl := &ChangeLog{
+		Config: types.Config{LastStable: []string{"1.13"}},
+		listOfPrs: types.PullRequests{
+			124: {
+				ReleaseNote: "Fix foo",
+				AuthorName:  "alice",
+				Labels:      []string{"release-note/bug", "backport-done/1.13", "backport-done/1.12"},
+				URL:         "https://github.com/cilium/cilium/pull/124",
+			},
+			125: {
+				ReleaseNote: "Add bar",
+				AuthorName:  "bob",
+				Labels:      []string{"release-note/minor", "backport-done/1.13"},
+			},
+			126: {ReleaseNote: "Fix baz", AuthorName: "carol", Labels: []string{"release-note/bug"}},
+		},
+	}
+	dir := t.TempDir()
+
+	file := filepath.Join(dir, "excluded.md")
+	if err := cl.writeExcludedFile(file); err != nil {
+		t.Fatal(err)
+	}
+	got, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "Excluded Changes\n" +
+		"----------------\n" +
+		"\n" +
+		"The following PRs were not included in the release notes as they were backported to branches 1.13 and are assumed to be already released.\n" +
+		"\n" +
+		"**Minor Changes:**\n" +
+		"\n" +
+		"* Add bar (#125, @bob) (backport-done/1.13)\n" +
+		"\n" +
+		"**Bugfixes:**\n" +
+		"\n" +
+		"* Fix foo (#124, @alice) (backport-done/1.13)\n"
+	if string(got) != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+
+	report := filepath.Join(dir, "excluded.json")
+	if err := cl.writeExcludedReport(report); err != nil {
+		t.Fatal(err)
+	}
+	b, err := os.ReadFile(report)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var entries []ExcludedEntry
+	if err := json.Unmarshal(b, &entries); err != nil {
+		t.Fatal(err)
+	}
+	wantEntries := []ExcludedEntry{
+		{Section: "release-note/minor", PR: 125, Author: "bob", ReleaseNote: "Add bar", BackportBranches: []string{"backport-done/1.13"}},
+		{Section: "release-note/bug", PR: 124, Author: "alice", ReleaseNote: "Fix foo", BackportBranches: []string{"backport-done/1.13"}, URL: "https://github.com/cilium/cilium/pull/124"},
+	}
+	if !reflect.DeepEqual(entries, wantEntries) {
+		t.Errorf("got %+v, want %+v", entries, wantEntries)
+	}
+}
+
+func TestRenderPreviouslyReleased(t *testing.T) {
+	cl := &ChangeLog{
+		Config: types.Config{LastStable: []string{"1.13"}, PreviouslyReleased: true},
+		listOfPrs: types.PullRequests{
+			124: {ReleaseNote: "Fix foo", AuthorName: "alice", Labels: []string{"release-note/bug", "backport-done/1.13"}},
+			125: {ReleaseNote: "Fix bar", AuthorName: "bob", Labels: []string{"release-note/bug"}},
+		},
+	}
+	var buf strings.Builder
+	if _, err := cl.WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	want := "Summary of Changes\n" +
+		"------------------\n" +
+		"\n" +
+		"**Bugfixes:**\n" +
+		"* Fix bar (#125, @bob)\n" +
+		"\n" +
+		"Previously Released in v1.13.x\n" +
+		"------------------------------\n" +
+		"\n" +
+		"The following changes are also part of this release but were already released through backports.\n" +
+		"\n" +
+		"**Bugfixes:**\n" +
+		"* Fix foo (#124, @alice)\n"
+	if got := buf.String(); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestLastStableBackports(t *testing.T) {
+	tests := []struct {
+		lastStable []string
+		labels     []string
+		want       []string
+	}{
+		{lastStable: []string{"1.1"}, labels: []string{"backport-done/1.10", "backport-done/1.11"}},
+		{lastStable: []string{"1.1"}, labels: []string{"backport-done/1.1"}, want: []string{"backport-done/1.1"}},
+		{lastStable: []string{"1.12", "1.13"}, labels: []string{"backport-done/1.13"}, want: []string{"backport-done/1.13"}},
+		{lastStable: []string{"<=1.13"}, labels: []string{"backport-done/1.12", "backport-done/1.14"}, want: []string{"backport-done/1.12"}},
+		{lastStable: []string{"<=1.13"}, labels: []string{"backport-done/foo"}},
+	}
+	for _, tt := range tests {
+		t.Run(strings.Join(tt.lastStable, ",")+" "+strings.Join(tt.labels, ","), func(t *testing.T) {
+			cl := &ChangeLog{Config: types.Config{LastStable: tt.lastStable}}
+			got := cl.lastStableBackports(types.PullRequest{Labels: append([]string{"release-note/bug"}, tt.labels...)})
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("lastStableBackports() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,74 @@
+// Copyright 2021 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package changelog
+
+import (
+	"bytes"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// FrontMatter is the YAML front matter, understood by Hugo and Docusaurus,
+// of a release notes page of a docs site.
+type FrontMatter struct {
+	Title   string   `yaml:"title"`
+	Date    string   `yaml:"date"`
+	Version string   `yaml:"version,omitempty"`
+	Aliases []string `yaml:"aliases,omitempty"`
+}
+
+// frontMatter returns the front matter of the release notes. The version
+// defaults to the one detected from the head, the title to the version and
+// the date to now.
+func (cl *ChangeLog) frontMatter(now time.Time) FrontMatter {
+	fm := FrontMatter{
+		Title:   cl.FrontMatterTitle,
+		Date:    cl.FrontMatterDate,
+		Version: cl.FrontMatterVersion,
+		Aliases: cl.FrontMatterAliases,
+	}
+	if len(fm.Version) == 0 {
+		fm.Version = cl.detectVersion()
+	}
+	if len(fm.Title) == 0 {
+		fm.Title = "Release Notes"
+		if len(fm.Version) != 0 {
+			fm.Title = "v" + fm.Version
+		}
+	}
+	if len(fm.Date) == 0 {
+		fm.Date = now.Format("2006-01-02")
+	}
+	return fm
+}
+
+// WithFrontMatter returns the given release notes preceded by the front
+// matter.
+func WithFrontMatter(fm FrontMatter, notes []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteString("---\n")
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(fm); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	buf.WriteString("---\n\n")
+	buf.Write(notes)
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,65 @@
+// Copyright 2021 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package changelog
+
+import (
+	"testing"
+	"time"
+
+	"github.com/cilium/release/pkg/types"
+)
+
+func TestFrontMatter(t *testing.T) {
+	now := time.Date(2021, 6, 15, 10, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name string
+		cfg  types.Config
+		want string
+	}{
+		{
+			name: "defaults from the head",
+			cfg:  types.Config{Head: "v1.14.3"},
+			want: "---\ntitle: v1.14.3\ndate: \"2021-06-15\"\nversion: 1.14.3\n---\n\n* notes\n",
+		},
+		{
+			name: "no version",
+			cfg:  types.Config{Head: "main"},
+			want: "---\ntitle: Release Notes\ndate: \"2021-06-15\"\n---\n\n* notes\n",
+		},
+		{
+			name: "configured",
+			cfg: types.Config{
+				Head:               "main",
+				FrontMatterTitle:   "Cilium 1.14.3",
+				FrontMatterDate:    "2021-06-20",
+				FrontMatterVersion: "1.14.3",
+				FrontMatterAliases: []string{"/releases/latest", "/releases/1.14"},
+			},
+			want: "---\ntitle: Cilium 1.14.3\ndate: \"2021-06-20\"\nversion: 1.14.3\naliases:\n  - /releases/latest\n  - /releases/1.14\n---\n\n* notes\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cl := New(nil, tt.cfg, nil, nil)
+			got, err := WithFrontMatter(cl.frontMatter(now), []byte("* notes\n"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("got:\n%s\nwant:\n%s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,105 @@
+// Copyright 2021 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package changelog
+
+import (
+	"fmt"
+
+	"github.com/cilium/release/pkg/config"
+	"github.com/cilium/release/pkg/types"
+)
+
+const (
+	// GroupByType lists the entries in the sections of their type of
+	// change, e.g. the bugfixes.
+	GroupByType = "type"
+	// GroupByOwner lists the entries in the sections of the groups owning
+	// them, e.g. 'SIG Datapath'.
+	GroupByOwner = "owner"
+
+	// unownedHeader is the header of the section of the entries that
+	// aren't owned by any group.
+	unownedHeader = "**Unowned Changes:**"
+)
+
+// loadGroups reads the groups owning the changes from cfg.ConfigFile if
+// they are used as sections.
+func loadGroups(cfg types.Config) ([]config.Group, error) {
+	if cfg.GroupBy != GroupByOwner {
+		return nil, nil
+	}
+	c, err := config.Load(cfg.ConfigFile)
+	if err != nil {
+		return nil, fmt.Errorf("unable to load configuration: %w", err)
+	}
+	if len(c.Groups) == 0 {
+		return nil, fmt.Errorf("no groups in %s", cfg.ConfigFile)
+	}
+	return c.Groups, nil
+}
+
+// groupSections regroups the entries of the given sections, except the
+// skipped ones, into the sections of the groups owning them, in the order
+// of cl.groups. An entry with the labels of several groups is listed in the
+// section of the first one and the entries without any are listed last.
+// Within a section, the entries keep the order of their types. The
+// collapsed sections are kept as is, after the groups.
+func (cl *ChangeLog) groupSections(sections []Section, skip, collapsed map[string]bool) []Section {
+	grouped := make([]Section, len(cl.groups)+1)
+	for i, g := range cl.groups {
+		grouped[i] = Section{
+			Label:  g.Label,
+			Header: fmt.Sprintf("**%s:**", g.Name),
+			Docs:   cl.docs.Sections[g.Label],
+		}
+	}
+	grouped[len(cl.groups)] = Section{Header: unownedHeader}
+
+	var kept []Section
+	for _, section := range sections {
+		if skip[section.Label] {
+			continue
+		}
+		if collapsed[section.Label] {
+			kept = append(kept, section)
+			continue
+		}
+		for _, entry := range section.Entries {
+			i := cl.groupIndex(entry.Labels)
+			grouped[i].Entries = append(grouped[i].Entries, entry)
+		}
+	}
+
+	var result []Section
+	for _, section := range grouped {
+		if len(section.Entries) != 0 {
+			result = append(result, section)
+		}
+	}
+	return append(result, kept...)
+}
+
+// groupIndex returns the index in cl.groups of the first group among the
+// given labels, or len(cl.groups) if there is none.
+func (cl *ChangeLog) groupIndex(lbls []string) int {
+	for i, g := range cl.groups {
+		for _, lbl := range lbls {
+			if lbl == g.Label {
+				return i
+			}
+		}
+	}
+	return len(cl.groups)
+}
@@ -0,0 +1,91 @@
+// Copyright 2021 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package changelog
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// keepAChangelogCategories are the categories of https://keepachangelog.com,
+// in the order they are rendered. When a PR has the labels of several
+// categories, the first one wins.
+var keepAChangelogCategories = []string{
+	"Security",
+	"Removed",
+	"Deprecated",
+	"Added",
+	"Changed",
+	"Fixed",
+}
+
+// writeKeepAChangelog writes the release notes in the Keep a Changelog
+// format, with the version detected from the head and the given date.
+func (cl *ChangeLog) writeKeepAChangelog(buf *bytes.Buffer, opts RenderOptions) {
+	mapping := cl.scheme().KeepAChangelog
+	rank := map[string]int{}
+	for i, category := range keepAChangelogCategories {
+		rank[category] = i
+	}
+
+	skip := map[string]bool{}
+	for _, lbl := range opts.SkipLabels {
+		skip[lbl] = true
+	}
+	byCategory := map[string][]Entry{}
+	for _, section := range cl.Sections() {
+		if skip[section.Label] {
+			continue
+		}
+		for _, entry := range section.Entries {
+			category, ok := mapping[section.Label]
+			// The Duplicates of the entry are listed with it, so that
+			// their labels weigh as much as its own.
+			for _, e := range entry.flatten() {
+				for _, lbl := range e.Labels {
+					if c, found := mapping[lbl]; found && (!ok || rank[c] < rank[category]) {
+						category, ok = c, true
+					}
+				}
+			}
+			if ok {
+				byCategory[category] = append(byCategory[category], entry)
+			}
+		}
+	}
+
+	date := opts.Date
+	if date.IsZero() {
+		date = time.Now()
+	}
+	if ver := cl.detectVersion(); len(ver) != 0 {
+		fmt.Fprintf(buf, "## [%s] - %s\n", ver, date.Format("2006-01-02"))
+	} else {
+		fmt.Fprintf(buf, "## [Unreleased]\n")
+	}
+	for _, category := range keepAChangelogCategories {
+		entries := byCategory[category]
+		if len(entries) == 0 {
+			continue
+		}
+		sortEntries(entries, cl.NaturalSort)
+		fmt.Fprintf(buf, "\n### %s\n\n", category)
+		for _, entry := range entries {
+			fmt.Fprintf(buf, "- %s\n", strings.TrimPrefix(entry.String(), "* "))
+		}
+	}
+}
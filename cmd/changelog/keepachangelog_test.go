@@ -0,0 +1,68 @@
+// Copyright 2021 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package changelog
+
+import (
+	"testing"
+	"time"
+
+	"github.com/cilium/release/pkg/types"
+)
+
+func TestRenderKeepAChangelog(t *testing.T) {
+	cl := &ChangeLog{
+		Config: types.Config{Head: "v1.14.3", MergeDuplicates: true},
+		listOfPrs: types.PullRequests{
+			1: {ReleaseNote: "Add foo", ReleaseLabel: "release-note/minor", AuthorName: "alice"},
+			7: {ReleaseNote: "Add foo", ReleaseLabel: "release-note/minor", AuthorName: "bob"},
+			2: {ReleaseNote: "Fix bar", ReleaseLabel: "release-note/bug", AuthorName: "bob"},
+			3: {
+				ReleaseNote: "Fix CVE in baz",
+				AuthorName:  "carol",
+				Labels:      []string{"release-note/bug", "kind/security"},
+			},
+			4: {ReleaseNote: "Bump deps", ReleaseLabel: "release-note/misc", AuthorName: "dave"},
+			5: {ReleaseNote: "Fix flake", ReleaseLabel: "release-note/ci", AuthorName: "erin"},
+			6: {ReleaseNote: "Refactor qux", ReleaseLabel: "release-note/none", AuthorName: "frank"},
+		},
+	}
+	got, err := cl.Render(RenderOptions{
+		Format: FormatKeepAChangelog,
+		Date:   time.Date(2021, 6, 15, 0, 0, 0, 0, time.UTC),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "## [1.14.3] - 2021-06-15\n" +
+		"\n" +
+		"### Security\n" +
+		"\n" +
+		"- Fix CVE in baz (#3, @carol)\n" +
+		"\n" +
+		"### Added\n" +
+		"\n" +
+		"- Add foo (#1, #7, @alice, @bob)\n" +
+		"\n" +
+		"### Changed\n" +
+		"\n" +
+		"- Bump deps (#4, @dave)\n" +
+		"\n" +
+		"### Fixed\n" +
+		"\n" +
+		"- Fix bar (#2, @bob)\n"
+	if string(got) != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
@@ -0,0 +1,95 @@
+// Copyright 2021 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package changelog
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+
+	gh "github.com/google/go-github/v50/github"
+
+	"github.com/cilium/release/pkg/tracing"
+	"github.com/cilium/release/pkg/usage"
+)
+
+// knownIssueLabelPrefix is the prefix of the labels of the known issues of
+// a branch, e.g. 'known-issue/v1.14'.
+const knownIssueLabelPrefix = "known-issue/v"
+
+// KnownIssue is an open issue listed in the Known Issues section of the
+// release notes.
+type KnownIssue struct {
+	Number int
+	Title  string
+	URL    string
+}
+
+// findAppendices finds what is listed after the sections of the release
+// notes, if requested: the reverted changes, the dependency changes and the
+// known issues.
+func (cl *ChangeLog) findAppendices(ctx context.Context, tracker *usage.Tracker) error {
+	if cl.FoldReverts {
+		cl.foldReverts()
+	}
+	if err := cl.findDependencyChanges(ctx, tracker); err != nil {
+		return err
+	}
+	return cl.findKnownIssues(ctx, tracker)
+}
+
+// findKnownIssues fetches the open issues labeled as known issues of the
+// cl.KnownIssues branch, if set.
+func (cl *ChangeLog) findKnownIssues(ctx context.Context, tracker *usage.Tracker) error {
+	if len(cl.KnownIssues) == 0 {
+		return nil
+	}
+	ctx, endPhase := tracing.Phase(ctx, tracker, "known issues")
+	defer endPhase()
+
+	label := knownIssueLabelPrefix + cl.KnownIssues
+	opts := &gh.IssueListByRepoOptions{
+		State:       "open",
+		Labels:      []string{label},
+		ListOptions: gh.ListOptions{PerPage: 100},
+	}
+	cl.knownIssues = nil
+	for {
+		issues, resp, err := cl.ghClient.Issues.ListByRepo(ctx, cl.Owner, cl.Repo, opts)
+		if err != nil {
+			return fmt.Errorf("unable to list the issues labeled %s: %w", label, err)
+		}
+		for _, issue := range issues {
+			if issue.IsPullRequest() {
+				continue
+			}
+			cl.knownIssues = append(cl.knownIssues, KnownIssue{
+				Number: issue.GetNumber(),
+				Title:  issue.GetTitle(),
+				URL:    issue.GetHTMLURL(),
+			})
+		}
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+	sort.Slice(cl.knownIssues, func(i, j int) bool {
+		return cl.knownIssues[i].Number < cl.knownIssues[j].Number
+	})
+	fmt.Fprintf(os.Stderr, "Found %d known issues labeled %s\n", len(cl.knownIssues), label)
+	return nil
+}
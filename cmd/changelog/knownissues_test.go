@@ -0,0 +1,71 @@
+// Copyright 2021 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package changelog
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	gh "github.com/google/go-github/v50/github"
+
+	"github.com/cilium/release/pkg/types"
+)
+
+func TestKnownIssues(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/repos/cilium/cilium/issues" || r.URL.Query().Get("labels") != "known-issue/v1.14" || r.URL.Query().Get("state") != "open" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL)
+		}
+		fmt.Fprint(w, `[
+			{"number": 28010, "title": "Agent restarts on large clusters"},
+			{"number": 28020, "title": "Fix restarts", "pull_request": {"url": "https://api.github.com/repos/cilium/cilium/pulls/28020"}},
+			{"number": 27990, "title": "DNS proxy drops responses"}
+		]`)
+	}))
+	defer srv.Close()
+	ghClient := gh.NewClient(nil)
+	ghClient.BaseURL, _ = url.Parse(srv.URL + "/")
+
+	cl := &ChangeLog{
+		Config:   types.Config{Owner: "cilium", Repo: "cilium", KnownIssues: "1.14"},
+		ghClient: ghClient,
+		listOfPrs: types.PullRequests{
+			123: {ReleaseNote: "Fix foo", AuthorName: "alice", Labels: []string{"release-note/bug"}},
+		},
+	}
+	if err := cl.findKnownIssues(context.Background(), nil); err != nil {
+		t.Fatal(err)
+	}
+	got, err := cl.Render(RenderOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "Summary of Changes\n" +
+		"------------------\n" +
+		"\n" +
+		"**Bugfixes:**\n" +
+		"* Fix foo (#123, @alice)\n" +
+		"\n" +
+		"**Known Issues:**\n" +
+		"* DNS proxy drops responses (#27990)\n" +
+		"* Agent restarts on large clusters (#28010)\n"
+	if string(got) != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
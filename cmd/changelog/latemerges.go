@@ -0,0 +1,111 @@
+// Copyright 2021 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package changelog
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/cilium/release/pkg/github"
+	"github.com/cilium/release/pkg/tracing"
+	"github.com/cilium/release/pkg/usage"
+)
+
+// latePR is a PR that may belong to the release but isn't part of its range.
+type latePR struct {
+	Number int
+	Title  string
+}
+
+// lateMerges are the PRs that may belong to the release but are not part of
+// its range.
+type lateMerges struct {
+	// Branch is the stable branch of the release, e.g. 'v1.14'.
+	Branch string
+	// BranchSHA is the head of Branch.
+	BranchSHA string
+	// Merged are the PRs merged into Branch after Head.
+	Merged []latePR
+	// NeedsBackport are the merged PRs labeled as needing a backport to
+	// Branch that are not part of the release notes, not even through
+	// their backports.
+	NeedsBackport []latePR
+}
+
+// findLateMerges finds the PRs merged into the stable branch of
+// cl.LateMerges after the head of the release notes, and the merged ones
+// labeled as needing a backport to that branch that are not part of the
+// release notes, i.e. the "one more fix landed after the cut".
+func (cl *ChangeLog) findLateMerges(ctx context.Context, tracker *usage.Tracker) (*lateMerges, error) {
+	ctx, endPhase := tracing.Phase(ctx, tracker, "late merges")
+	defer endPhase()
+
+	p := cl.scheme()
+	lm := &lateMerges{Branch: p.StableBranch(cl.LateMerges)}
+	head := cl.headSHA
+	if len(head) == 0 {
+		head = cl.Head
+	}
+	commits, err := compareRepositoryCommits(ctx, cl.ghClient, cl.Owner, cl.Repo, head, lm.Branch, cl.AllowTruncated)
+	if err != nil {
+		return nil, fmt.Errorf("unable to compare %s with %s: %w", cl.Head, lm.Branch, err)
+	}
+	if len(commits) != 0 {
+		lm.BranchSHA = commits[0].GetSHA()
+	}
+	seen := map[int]bool{}
+	for _, commit := range commits {
+		prs, err := github.ListPRsWithCommit(ctx, cl.ghClient, nil, cl.Owner, cl.Repo, commit.GetSHA())
+		if err != nil {
+			return nil, fmt.Errorf("unable to find the PRs of commit %s: %w", commit.GetSHA(), err)
+		}
+		for _, pr := range prs {
+			if pr.MergedAt == nil || pr.GetBase().GetRef() != lm.Branch || seen[pr.GetNumber()] {
+				continue
+			}
+			seen[pr.GetNumber()] = true
+			lm.Merged = append(lm.Merged, latePR{Number: pr.GetNumber(), Title: pr.GetTitle()})
+		}
+	}
+	sort.Slice(lm.Merged, func(i, j int) bool { return lm.Merged[i].Number < lm.Merged[j].Number })
+
+	needsBackport, err := listMergedLabeledPRs(ctx, cl.ghClient, cl.UpstreamOwner, cl.UpstreamRepo, p.NeedsBackportPrefix+cl.LateMerges)
+	if err != nil {
+		return nil, fmt.Errorf("unable to list the PRs needing a backport to %s: %w", lm.Branch, err)
+	}
+	released := cl.releasedPRs()
+	for _, pr := range needsBackport {
+		if !released[pr.Number] {
+			lm.NeedsBackport = append(lm.NeedsBackport, pr)
+		}
+	}
+	return lm, nil
+}
+
+// warn writes a warning for each of the late PRs into w and, if any PR was
+// merged after Head, how to bump Head to include them.
+func (lm *lateMerges) warn(w io.Writer, head string) {
+	for _, pr := range lm.Merged {
+		fmt.Fprintf(w, "WARNING: PR #%d was merged into %s after --head %s: %s\n", pr.Number, lm.Branch, head, pr.Title)
+	}
+	for _, pr := range lm.NeedsBackport {
+		fmt.Fprintf(w, "WARNING: PR #%d needs a backport to %s that isn't merged yet: %s\n", pr.Number, lm.Branch, pr.Title)
+	}
+	if len(lm.Merged) != 0 {
+		fmt.Fprintf(w, "Use --head %s, the head of %s, to include the %d PRs merged after --head %s\n", lm.BranchSHA, lm.Branch, len(lm.Merged), head)
+	}
+}
@@ -0,0 +1,91 @@
+// Copyright 2021 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package changelog
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	gh "github.com/google/go-github/v50/github"
+
+	"github.com/cilium/release/pkg/types"
+)
+
+func TestLateMerges(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/repos/cilium/cilium/compare/c1...v1.14":
+			fmt.Fprint(w, `{"total_commits": 2, "commits": [
+				{"sha": "c2", "commit": {"message": "Fix bar (#124)"}},
+				{"sha": "c3", "commit": {"message": "Fix baz (#125)"}}
+			]}`)
+		case "/repos/cilium/cilium/commits/c2/pulls":
+			fmt.Fprint(w, `[{"number": 124, "state": "closed", "title": "Fix bar", "merged_at": "2023-07-12T09:30:00Z", "base": {"ref": "v1.14"}}]`)
+		case "/repos/cilium/cilium/commits/c3/pulls":
+			fmt.Fprint(w, `[
+				{"number": 125, "state": "closed", "title": "Fix baz", "merged_at": "2023-07-12T10:30:00Z", "base": {"ref": "v1.14"}},
+				{"number": 120, "state": "closed", "title": "Fix baz upstream", "merged_at": "2023-07-10T10:30:00Z", "base": {"ref": "main"}}
+			]`)
+		case "/search/issues":
+			if q := r.URL.Query().Get("q"); q != `repo:cilium/cilium is:pr is:merged label:"needs-backport/1.14"` {
+				t.Errorf("unexpected query %q", q)
+			}
+			// PR 110 is backported by a PR of the release notes and
+			// PR 115 is part of them, e.g. with a stale label.
+			fmt.Fprint(w, `{"total_count": 3, "items": [
+				{"number": 110, "title": "Fix foo"},
+				{"number": 115, "title": "Fix bar"},
+				{"number": 130, "title": "Fix qux"}
+			]}`)
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL)
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+	ghClient := gh.NewClient(nil)
+	ghClient.BaseURL, _ = url.Parse(srv.URL + "/")
+
+	cl := &ChangeLog{
+		Config:   types.Config{Owner: "cilium", Repo: "cilium", UpstreamOwner: "cilium", UpstreamRepo: "cilium", Head: "v1.14.3-rc", LateMerges: "1.14"},
+		ghClient: ghClient,
+		headSHA:  "c1",
+		listOfPrs: types.PullRequests{
+			111: {Title: "v1.14 backports"},
+			115: {Title: "Fix bar"},
+		},
+		prsWithUpstream: types.BackportPRs{
+			111: {110: {Title: "Fix foo"}},
+		},
+	}
+	lm, err := cl.findLateMerges(context.Background(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	lm.warn(&buf, cl.Head)
+	want := "WARNING: PR #124 was merged into v1.14 after --head v1.14.3-rc: Fix bar\n" +
+		"WARNING: PR #125 was merged into v1.14 after --head v1.14.3-rc: Fix baz\n" +
+		"WARNING: PR #130 needs a backport to v1.14 that isn't merged yet: Fix qux\n" +
+		"Use --head c3, the head of v1.14, to include the 2 PRs merged after --head v1.14.3-rc\n"
+	if got := buf.String(); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
@@ -0,0 +1,256 @@
+// Copyright 2021 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package changelog
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	gh "github.com/google/go-github/v50/github"
+	flag "github.com/spf13/pflag"
+
+	"github.com/cilium/release/pkg/profile"
+	"github.com/cilium/release/pkg/report"
+	"github.com/cilium/release/pkg/types"
+)
+
+// BackportLatency is the time an upstream PR took to be backported, from its
+// merge to the merge of its backport PR.
+type BackportLatency struct {
+	PR         int
+	BackportPR int
+	// Areas are the areas, e.g. 'clustermesh' for 'area/clustermesh', of
+	// PR.
+	Areas   []string
+	Latency time.Duration
+}
+
+// LatencyStats is the distribution of the backport latencies of a stable
+// branch.
+type LatencyStats struct {
+	Branch string
+	PRs    int
+	P50    time.Duration
+	P90    time.Duration
+	// Areas are the latencies by area, the slowest ones first.
+	Areas []AreaLatency
+}
+
+// AreaLatency is the distribution of the backport latencies of an area.
+type AreaLatency struct {
+	Area string
+	PRs  int
+	P50  time.Duration
+	// Worst is the slowest backport of the area.
+	Worst BackportLatency
+}
+
+// backportLatencies returns the backport latencies of the upstream PRs of
+// the changelog, looking up when their backport PRs were merged. An upstream
+// PR backported through several backport PRs is backported once the last of
+// them is merged.
+func (cl *ChangeLog) backportLatencies(ctx context.Context) ([]BackportLatency, error) {
+	src := resolutionConfig(cl.Config)
+	byPR := map[int]*BackportLatency{}
+	for backportPR, upstreamPRs := range cl.prsWithUpstream {
+		pr, _, err := cl.ghClient.PullRequests.Get(ctx, src.Owner, src.Repo, backportPR)
+		if err != nil {
+			return nil, fmt.Errorf("unable to get backport PR %d: %w", backportPR, err)
+		}
+		if pr.MergedAt == nil {
+			continue
+		}
+		for number, upstreamPR := range upstreamPRs {
+			if upstreamPR.MergedAt.IsZero() {
+				continue
+			}
+			latency := pr.GetMergedAt().Sub(upstreamPR.MergedAt)
+			if l, ok := byPR[number]; ok && l.Latency >= latency {
+				continue
+			}
+			var areas []string
+			for _, lbl := range upstreamPR.Labels {
+				if strings.HasPrefix(lbl, areaLabelPrefix) {
+					areas = append(areas, strings.TrimPrefix(lbl, areaLabelPrefix))
+				}
+			}
+			byPR[number] = &BackportLatency{PR: number, BackportPR: backportPR, Areas: areas, Latency: latency}
+		}
+	}
+	latencies := make([]BackportLatency, 0, len(byPR))
+	for _, l := range byPR {
+		latencies = append(latencies, *l)
+	}
+	sort.Slice(latencies, func(i, j int) bool {
+		if latencies[i].Latency != latencies[j].Latency {
+			return latencies[i].Latency > latencies[j].Latency
+		}
+		return latencies[i].PR < latencies[j].PR
+	})
+	return latencies, nil
+}
+
+// percentile returns the nearest-rank percentile p, e.g. 0.9, of the given
+// latencies sorted by decreasing latency.
+func percentile(latencies []BackportLatency, p float64) time.Duration {
+	if len(latencies) == 0 {
+		return 0
+	}
+	rank := int(math.Ceil(p * float64(len(latencies))))
+	if rank < 1 {
+		rank = 1
+	}
+	return latencies[len(latencies)-rank].Latency
+}
+
+// latencyStats returns the distribution of the given latencies, sorted by
+// decreasing latency, of the given branch.
+func latencyStats(branch string, latencies []BackportLatency) LatencyStats {
+	stats := LatencyStats{
+		Branch: branch,
+		PRs:    len(latencies),
+		P50:    percentile(latencies, 0.5),
+		P90:    percentile(latencies, 0.9),
+	}
+	byArea := map[string][]BackportLatency{}
+	for _, l := range latencies {
+		for _, area := range l.Areas {
+			byArea[area] = append(byArea[area], l)
+		}
+	}
+	for area, l := range byArea {
+		stats.Areas = append(stats.Areas, AreaLatency{
+			Area:  area,
+			PRs:   len(l),
+			P50:   percentile(l, 0.5),
+			Worst: l[0],
+		})
+	}
+	sort.Slice(stats.Areas, func(i, j int) bool {
+		a, b := stats.Areas[i], stats.Areas[j]
+		if a.Worst.Latency != b.Worst.Latency {
+			return a.Worst.Latency > b.Worst.Latency
+		}
+		return a.Area < b.Area
+	})
+	return stats
+}
+
+// formatLatency returns the latency in days and hours, e.g. '3d 4h'.
+func formatLatency(d time.Duration) string {
+	days := int(d / (24 * time.Hour))
+	hours := int(d % (24 * time.Hour) / time.Hour)
+	if days == 0 {
+		return fmt.Sprintf("%dh", hours)
+	}
+	return fmt.Sprintf("%dd %dh", days, hours)
+}
+
+// writeLatencyMarkdown writes the backport latencies of each branch into w,
+// with the slowest backport of each area.
+func writeLatencyMarkdown(w io.Writer, stats []LatencyStats) error {
+	for i, s := range stats {
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+		fmt.Fprintf(w, "# Backport latency of %s\n\n", s.Branch)
+		if s.PRs == 0 {
+			fmt.Fprintf(w, "No upstream PR backported.\n")
+			continue
+		}
+		fmt.Fprintf(w, "%d upstream PRs backported, p50 %s, p90 %s.\n", s.PRs, formatLatency(s.P50), formatLatency(s.P90))
+		if len(s.Areas) == 0 {
+			continue
+		}
+		fmt.Fprintf(w, "\n| Area | PRs | p50 | Worst |\n")
+		fmt.Fprintf(w, "|---|---|---|---|\n")
+		for _, a := range s.Areas {
+			fmt.Fprintf(w, "| %s | %d | %s | #%d in #%d, %s |\n",
+				a.Area, a.PRs, formatLatency(a.P50), a.Worst.PR, a.Worst.BackportPR, formatLatency(a.Worst.Latency))
+		}
+	}
+	return nil
+}
+
+// backportsCommand implements the 'stats backports' subcommand, which
+// reports, for each stable branch, the distribution of the time the upstream
+// PRs released since its latest release took to be backported.
+func backportsCommand(ctx context.Context, ghClient *gh.Client, args []string) error {
+	var (
+		cfg    types.Config
+		output string
+	)
+	fs := flag.NewFlagSet("stats backports", flag.ContinueOnError)
+	fs.StringVar(&cfg.RepoName, "repo", "cilium/cilium", "GitHub organization and repository names separated by a slash")
+	fs.StringVar(&cfg.UpstreamRepoName, "upstream-repo", "", "GitHub organization and repository names, separated by a slash, of the upstream PRs referenced by backport PRs, if different from --repo")
+	fs.StringSliceVar(&cfg.Branches, "branches", nil, "Stable branches (e.g.: '1.13,1.14') whose backports merged since their latest release are measured. Can be repeated or comma-separated")
+	fs.StringVar(&cfg.CacheDir, "cache-dir", "", "Directory of the PR metadata cache shared across runs, releases and branches, e.g. ~/.cache/cilium-release. The cached PRs updated since the previous run are dropped. Disabled if empty")
+	fs.StringVar(&cfg.Profile, "profile", profile.Default, fmt.Sprintf("Label scheme of the repository, one of %s", strings.Join(profile.Names(), ", ")))
+	fs.StringVar(&output, "output", "", "File where the latencies are written instead of the standard output")
+	if err := types.ParseFlags(fs, "stats backports", args); err != nil {
+		return err
+	}
+	if len(cfg.Branches) == 0 {
+		return fmt.Errorf("--branches must be set")
+	}
+
+	stateDir, err := os.MkdirTemp("", "release-stats")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(stateDir)
+	cfg.StateFile = filepath.Join(stateDir, "state.json")
+	cfg.Output = filepath.Join(stateDir, "release-notes.md")
+	if err := cfg.Sanitize(); err != nil {
+		return err
+	}
+
+	cls, err := GenerateBranchesReleaseNotes(ctx, ghClient, cfg, nil)
+	if err != nil {
+		return err
+	}
+	var stats []LatencyStats
+	for i, cl := range cls {
+		latencies, err := cl.backportLatencies(ctx)
+		if err != nil {
+			return err
+		}
+		stats = append(stats, latencyStats(cl.scheme().StableBranch(cfg.Branches[i]), latencies))
+	}
+
+	if len(output) == 0 {
+		return writeLatencyMarkdown(os.Stdout, stats)
+	}
+	f, err := os.Create(output)
+	if err != nil {
+		return err
+	}
+	if err := writeLatencyMarkdown(f, stats); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	report.Written(output)
+	return nil
+}
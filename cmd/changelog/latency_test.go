@@ -0,0 +1,109 @@
+// Copyright 2021 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package changelog
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	gh "github.com/google/go-github/v50/github"
+
+	"github.com/cilium/release/pkg/types"
+)
+
+func TestBackportLatency(t *testing.T) {
+	merged := map[string]string{
+		"/repos/cilium/cilium/pulls/200": "2023-05-03T12:00:00Z",
+		"/repos/cilium/cilium/pulls/201": "2023-05-11T00:00:00Z",
+		"/repos/cilium/cilium/pulls/202": "2023-05-02T06:00:00Z",
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mergedAt, ok := merged[r.URL.Path]
+		if !ok {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL)
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprintf(w, `{"merged_at": %q}`, mergedAt)
+	}))
+	defer srv.Close()
+	ghClient := gh.NewClient(nil)
+	ghClient.BaseURL, _ = url.Parse(srv.URL + "/")
+
+	upstreamMerge := time.Date(2023, 5, 1, 0, 0, 0, 0, time.UTC)
+	cfg := types.Config{}
+	cfg.Owner, cfg.Repo = "cilium", "cilium"
+	cl := &ChangeLog{
+		Config:   cfg,
+		ghClient: ghClient,
+		prsWithUpstream: types.BackportPRs{
+			200: {
+				123: {MergedAt: upstreamMerge, Labels: []string{"area/datapath"}},
+				124: {MergedAt: upstreamMerge, Labels: []string{"area/clustermesh"}},
+			},
+			// #123 is split across #200 and #201, it's backported
+			// once #201 is merged.
+			201: {123: {MergedAt: upstreamMerge, Labels: []string{"area/datapath"}}},
+			202: {
+				125: {MergedAt: upstreamMerge, Labels: []string{"area/datapath"}},
+				// Unknown merge time.
+				126: {},
+			},
+		},
+	}
+
+	latencies, err := cl.backportLatencies(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	stats := latencyStats("v1.14", latencies)
+	var buf bytes.Buffer
+	if err := writeLatencyMarkdown(&buf, []LatencyStats{stats}); err != nil {
+		t.Fatal(err)
+	}
+	want := `# Backport latency of v1.14
+
+3 upstream PRs backported, p50 2d 12h, p90 10d 0h.
+
+| Area | PRs | p50 | Worst |
+|---|---|---|---|
+| datapath | 2 | 1d 6h | #123 in #201, 10d 0h |
+| clustermesh | 1 | 2d 12h | #124 in #200, 2d 12h |
+`
+	if got := buf.String(); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestFormatLatency(t *testing.T) {
+	for _, tt := range []struct {
+		latency time.Duration
+		want    string
+	}{
+		{0, "0h"},
+		{5*time.Hour + 30*time.Minute, "5h"},
+		{3*24*time.Hour + 4*time.Hour, "3d 4h"},
+	} {
+		if got := formatLatency(tt.latency); got != tt.want {
+			t.Errorf("formatLatency(%s) = %q, want %q", tt.latency, got, tt.want)
+		}
+	}
+}
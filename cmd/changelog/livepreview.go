@@ -0,0 +1,248 @@
+// Copyright 2021 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package changelog
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	gh "github.com/google/go-github/v50/github"
+	flag "github.com/spf13/pflag"
+
+	"github.com/cilium/release/pkg/config"
+	"github.com/cilium/release/pkg/persistence"
+	"github.com/cilium/release/pkg/profile"
+	"github.com/cilium/release/pkg/types"
+)
+
+// PreviewInterval is the interval at which the files rendered by the
+// 'preview' subcommand are checked for changes.
+var PreviewInterval = time.Second
+
+// previewPage is the page served by the 'preview' subcommand. It reloads
+// itself when the generation of the release notes changes.
+const previewPage = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Release notes preview</title>
+<style>body { max-width: 980px; margin: 2em auto; font-family: sans-serif; }</style>
+</head>
+<body>
+%s
+<script>
+setInterval(async () => {
+	const resp = await fetch("/generation");
+	if (resp.ok && (await resp.text()) !== "%d") {
+		location.reload();
+	}
+}, 1000);
+</script>
+</body>
+</html>
+`
+
+// previewer renders the release notes of a state file as HTML, rendering
+// them again whenever any of the files they are rendered from changes.
+type previewer struct {
+	ghClient *gh.Client
+	cfg      types.Config
+
+	mu sync.Mutex
+	// modTimes are the modification times of the files the release notes
+	// were last rendered from.
+	modTimes   map[string]time.Time
+	html       string
+	generation int
+}
+
+// PreviewCommand implements the 'preview' subcommand, which serves the
+// release notes of a state file locally and renders them again whenever the
+// state file, the overrides file, the authors file or the templates change,
+// so that their wording can be iterated on.
+func PreviewCommand(ctx context.Context, ghClient *gh.Client, args []string) error {
+	var (
+		cfg  types.Config
+		addr string
+	)
+	fs := flag.NewFlagSet("preview", flag.ContinueOnError)
+	fs.StringVar(&addr, "addr", "localhost:8000", "Address to listen on")
+	fs.StringVar(&cfg.RepoName, "repo", "cilium/cilium", "GitHub organization and repository names separated by a slash")
+	fs.StringVar(&cfg.UpstreamRepoName, "upstream-repo", "", "GitHub organization and repository names, separated by a slash, of the upstream PRs referenced by backport PRs, if different from --repo")
+	fs.StringVar(&cfg.StateFile, "state-file", "release-state.json", "State file of the release notes, e.g. stored by a previous run")
+	fs.StringVar(&cfg.OverridesFile, "overrides", "", "YAML file mapping PR numbers to the corrections of their entries")
+	fs.StringVar(&cfg.AuthorsFile, "authors-file", "", "YAML file mapping the GitHub login of the PR authors to their display name")
+	fs.StringVar(&cfg.TemplateDir, "template-dir", "", "Directory of templates overriding the default ones the release notes are rendered with")
+	fs.StringSliceVar(&cfg.LastStable, "last-stable", nil, "Last stable versions whose backported PRs are excluded (e.g.: '1.5', '1.6'). Can be repeated or comma-separated")
+	fs.StringVar(&cfg.Profile, "profile", profile.Default, fmt.Sprintf("Label scheme of the repository, one of %s", strings.Join(profile.Names(), ", ")))
+	if err := types.ParseFlags(fs, "preview", args); err != nil {
+		return err
+	}
+	var err error
+	cfg.Owner, cfg.Repo, err = types.SplitRepoName(cfg.RepoName)
+	if err != nil {
+		return err
+	}
+	cfg.UpstreamOwner, cfg.UpstreamRepo = cfg.Owner, cfg.Repo
+	if len(cfg.UpstreamRepoName) != 0 {
+		cfg.UpstreamOwner, cfg.UpstreamRepo, err = types.SplitRepoName(cfg.UpstreamRepoName)
+		if err != nil {
+			return err
+		}
+	}
+
+	p := &previewer{ghClient: ghClient, cfg: cfg}
+	p.refresh(ctx)
+	go func() {
+		ticker := time.NewTicker(PreviewInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				p.refresh(ctx)
+			}
+		}
+	}()
+
+	srv := &http.Server{
+		Addr:              addr,
+		Handler:           p.handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		srv.Shutdown(shutdownCtx)
+	}()
+	fmt.Fprintf(os.Stderr, "Serving the release notes preview on http://%s\n", addr)
+	err = srv.ListenAndServe()
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+	return err
+}
+
+func (p *previewer) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/" {
+			http.NotFound(w, r)
+			return
+		}
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		fmt.Fprintf(w, previewPage, p.html, p.generation)
+	})
+	mux.HandleFunc("/generation", func(w http.ResponseWriter, r *http.Request) {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		w.Header().Set("Content-Type", "text/plain")
+		fmt.Fprint(w, strconv.Itoa(p.generation))
+	})
+	return mux
+}
+
+// files returns the files the release notes are rendered from.
+func (p *previewer) files() []string {
+	files := []string{p.cfg.StateFile}
+	for _, file := range []string{p.cfg.OverridesFile, p.cfg.AuthorsFile} {
+		if len(file) != 0 {
+			files = append(files, file)
+		}
+	}
+	if len(p.cfg.TemplateDir) != 0 {
+		templates, _ := filepath.Glob(filepath.Join(p.cfg.TemplateDir, "*.tmpl"))
+		files = append(files, templates...)
+	}
+	return files
+}
+
+// refresh renders the release notes again if any of the files they are
+// rendered from changed, or if they were never rendered. The errors are
+// rendered instead of the release notes.
+func (p *previewer) refresh(ctx context.Context) {
+	modTimes := map[string]time.Time{}
+	changed := p.modTimes == nil
+	for _, file := range p.files() {
+		if fi, err := os.Stat(file); err == nil {
+			modTimes[file] = fi.ModTime()
+		}
+		if !modTimes[file].Equal(p.modTimes[file]) {
+			changed = true
+		}
+	}
+	if !changed && len(modTimes) == len(p.modTimes) {
+		return
+	}
+
+	body, err := p.render(ctx)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Unable to render release notes: %s\n", err)
+		body = fmt.Sprintf("<p><strong>Unable to render release notes:</strong></p>\n<pre>%s</pre>", html.EscapeString(err.Error()))
+	} else {
+		fmt.Fprintf(os.Stderr, "Release notes rendered at %s\n", time.Now().Format(time.Kitchen))
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.modTimes = modTimes
+	p.html = body
+	p.generation++
+}
+
+// render returns the release notes as HTML, rendered by GitHub as they
+// would be in a GitHub release. They are shown as plain text if GitHub
+// can't render them.
+func (p *previewer) render(ctx context.Context) (string, error) {
+	state, err := persistence.Load(p.cfg.StateFile)
+	if err != nil {
+		return "", fmt.Errorf("unable to read state file: %w", err)
+	}
+	overrides, err := loadOverrides(p.cfg)
+	if err != nil {
+		return "", err
+	}
+	cl := New(p.ghClient, p.cfg, state.BackportPRs, state.PullRequests)
+	if len(p.cfg.AuthorsFile) != 0 {
+		cl.authors, err = config.LoadAuthors(p.cfg.AuthorsFile)
+		if err != nil {
+			return "", fmt.Errorf("unable to read authors file: %w", err)
+		}
+	}
+	cl.newContributors = state.NewContributors
+	cl.applyOverrides(overrides)
+	notes, err := cl.Render(RenderOptions{})
+	if err != nil {
+		return "", err
+	}
+	body, _, err := p.ghClient.Markdown(ctx, string(notes), &gh.MarkdownOptions{Mode: "gfm", Context: p.cfg.RepoName})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "WARNING: unable to render the Markdown with GitHub, showing it as plain text: %s\n", err)
+		return "<pre>" + html.EscapeString(string(notes)) + "</pre>", nil
+	}
+	return body, nil
+}
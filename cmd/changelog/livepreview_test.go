@@ -0,0 +1,101 @@
+// Copyright 2021 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package changelog
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"html"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	gh "github.com/google/go-github/v50/github"
+
+	"github.com/cilium/release/pkg/persistence"
+	"github.com/cilium/release/pkg/types"
+)
+
+func TestPreviewer(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Text    string `json:"text"`
+			Context string `json:"context"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || r.URL.Path != "/markdown" || req.Context != "cilium/cilium" {
+			t.Errorf("unexpected request %s %s: %+v", r.Method, r.URL, req)
+		}
+		fmt.Fprintf(w, "<pre>%s</pre>", html.EscapeString(req.Text))
+	}))
+	defer srv.Close()
+	ghClient := gh.NewClient(nil)
+	ghClient.BaseURL, _ = url.Parse(srv.URL + "/")
+
+	dir := t.TempDir()
+	stateFile := filepath.Join(dir, "state.json")
+	overridesFile := filepath.Join(dir, "overrides.yaml")
+	err := persistence.Store(stateFile, &persistence.State{
+		PullRequests: types.PullRequests{
+			123: {ReleaseNote: "Fix foo", AuthorName: "alice", Labels: []string{"release-note/bug"}},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(overridesFile, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	p := &previewer{
+		ghClient: ghClient,
+		cfg:      types.Config{RepoName: "cilium/cilium", StateFile: stateFile, OverridesFile: overridesFile},
+	}
+	ctx := context.Background()
+	p.refresh(ctx)
+	p.refresh(ctx)
+	if p.generation != 1 || !strings.Contains(p.html, "Fix foo (#123, @alice)") {
+		t.Fatalf("got generation %d:\n%s", p.generation, p.html)
+	}
+
+	// The overrides are rendered once changed.
+	if err := os.WriteFile(overridesFile, []byte("123:\n  note: Fix foo in the agent\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	future := time.Now().Add(time.Minute)
+	if err := os.Chtimes(overridesFile, future, future); err != nil {
+		t.Fatal(err)
+	}
+	p.refresh(ctx)
+	if p.generation != 2 || !strings.Contains(p.html, "Fix foo in the agent (#123, @alice)") {
+		t.Fatalf("got generation %d:\n%s", p.generation, p.html)
+	}
+
+	rec := httptest.NewRecorder()
+	p.handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if !strings.Contains(rec.Body.String(), "Fix foo in the agent") || !strings.Contains(rec.Body.String(), `!== "2"`) {
+		t.Errorf("unexpected page:\n%s", rec.Body.String())
+	}
+	rec = httptest.NewRecorder()
+	p.handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/generation", nil))
+	if rec.Body.String() != "2" {
+		t.Errorf("got generation %q, want 2", rec.Body.String())
+	}
+}
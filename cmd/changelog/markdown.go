@@ -0,0 +1,141 @@
+// Copyright 2021 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package changelog
+
+import (
+	"html"
+	"html/template"
+	"regexp"
+	"strings"
+)
+
+var (
+	codeSpanRe = regexp.MustCompile("`([^`]+)`")
+	boldRe     = regexp.MustCompile(`\*\*([^*]+)\*\*`)
+	linkRe     = regexp.MustCompile(`\[([^\]]+)\]\((https?://[^)\s]+)\)`)
+	headingRe  = regexp.MustCompile(`^(#{1,6})\s+(.*)$`)
+	listItemRe = regexp.MustCompile(`^\s*[*-]\s+(.*)$`)
+	setextRe   = regexp.MustCompile(`^(-{3,}|={3,})\s*$`)
+	tableSepRe = regexp.MustCompile(`^\|[\s|:-]+\|$`)
+)
+
+// markdownHTML converts the subset of Markdown the release notes are
+// rendered in, i.e. headings, paragraphs, lists, tables, fenced code blocks,
+// code spans, bold text and links, into HTML. Raw HTML is escaped.
+func markdownHTML(md string) template.HTML {
+	var (
+		b         strings.Builder
+		paragraph []string
+		inList    bool
+		inTable   bool
+		inCode    bool
+	)
+	closeBlocks := func() {
+		if len(paragraph) != 0 {
+			b.WriteString("<p>" + inlineHTML(strings.Join(paragraph, " ")) + "</p>\n")
+			paragraph = nil
+		}
+		if inList {
+			b.WriteString("</ul>\n")
+			inList = false
+		}
+		if inTable {
+			b.WriteString("</table>\n")
+			inTable = false
+		}
+	}
+	lines := strings.Split(strings.ReplaceAll(md, "\r\n", "\n"), "\n")
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "```") {
+			if inCode {
+				b.WriteString("</code></pre>\n")
+			} else {
+				closeBlocks()
+				b.WriteString("<pre><code>")
+			}
+			inCode = !inCode
+			continue
+		}
+		if inCode {
+			b.WriteString(html.EscapeString(line) + "\n")
+			continue
+		}
+		switch {
+		case len(trimmed) == 0:
+			closeBlocks()
+		case headingRe.MatchString(trimmed):
+			closeBlocks()
+			m := headingRe.FindStringSubmatch(trimmed)
+			writeHeading(&b, len(m[1]), m[2])
+		case len(paragraph) == 0 && !inList && !inTable && i+1 < len(lines) && setextRe.MatchString(lines[i+1]):
+			closeBlocks()
+			level := 2
+			if strings.HasPrefix(lines[i+1], "=") {
+				level = 1
+			}
+			writeHeading(&b, level, trimmed)
+			i++
+		case listItemRe.MatchString(line):
+			if !inList {
+				closeBlocks()
+				b.WriteString("<ul>\n")
+				inList = true
+			}
+			b.WriteString("<li>" + inlineHTML(listItemRe.FindStringSubmatch(line)[1]) + "</li>\n")
+		case strings.HasPrefix(trimmed, "|"):
+			if tableSepRe.MatchString(trimmed) {
+				continue
+			}
+			cell := "td"
+			if !inTable {
+				closeBlocks()
+				b.WriteString("<table>\n")
+				inTable = true
+				cell = "th"
+			}
+			b.WriteString("<tr>")
+			for _, c := range strings.Split(strings.Trim(trimmed, "|"), "|") {
+				b.WriteString("<" + cell + ">" + inlineHTML(strings.TrimSpace(c)) + "</" + cell + ">")
+			}
+			b.WriteString("</tr>\n")
+		default:
+			if inList || inTable {
+				closeBlocks()
+			}
+			paragraph = append(paragraph, trimmed)
+		}
+	}
+	if inCode {
+		b.WriteString("</code></pre>\n")
+	}
+	closeBlocks()
+	return template.HTML(b.String())
+}
+
+func writeHeading(b *strings.Builder, level int, text string) {
+	tag := "h" + string(rune('0'+level))
+	b.WriteString("<" + tag + ">" + inlineHTML(text) + "</" + tag + ">\n")
+}
+
+// inlineHTML escapes the given text and converts its code spans, bold text
+// and HTTP links.
+func inlineHTML(text string) string {
+	s := html.EscapeString(text)
+	s = codeSpanRe.ReplaceAllString(s, "<code>$1</code>")
+	s = boldRe.ReplaceAllString(s, "<strong>$1</strong>")
+	return linkRe.ReplaceAllString(s, `<a href="$2">$1</a>`)
+}
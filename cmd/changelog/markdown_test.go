@@ -0,0 +1,70 @@
+// Copyright 2021 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package changelog
+
+import "testing"
+
+func TestMarkdownHTML(t *testing.T) {
+	tests := []struct {
+		name string
+		md   string
+		want string
+	}{
+		{
+			name: "notes",
+			md: "Summary of Changes\n" +
+				"------------------\n" +
+				"\n" +
+				"**Bugfixes:**\n" +
+				"* Fix `foo` <bar> ([docs](https://docs.cilium.io)) (#123, @alice)\n" +
+				"* Fix baz (#124, @bob)\n",
+			want: "<h2>Summary of Changes</h2>\n" +
+				"<p><strong>Bugfixes:</strong></p>\n" +
+				"<ul>\n" +
+				"<li>Fix <code>foo</code> &lt;bar&gt; (<a href=\"https://docs.cilium.io\">docs</a>) (#123, @alice)</li>\n" +
+				"<li>Fix baz (#124, @bob)</li>\n" +
+				"</ul>\n",
+		},
+		{
+			name: "table and code",
+			md: "## Docker Manifests\n" +
+				"| Image | Digest |\n" +
+				"|-------|--------|\n" +
+				"| `cilium` | `sha256:abc` |\n" +
+				"```\n" +
+				"helm upgrade <release>\n" +
+				"```\n",
+			want: "<h2>Docker Manifests</h2>\n" +
+				"<table>\n" +
+				"<tr><th>Image</th><th>Digest</th></tr>\n" +
+				"<tr><td><code>cilium</code></td><td><code>sha256:abc</code></td></tr>\n" +
+				"</table>\n" +
+				"<pre><code>helm upgrade &lt;release&gt;\n" +
+				"</code></pre>\n",
+		},
+		{
+			name: "unsafe link",
+			md:   "[click](javascript:alert(1))\n",
+			want: "<p>[click](javascript:alert(1))</p>\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := string(markdownHTML(tt.md)); got != tt.want {
+				t.Errorf("got:\n%s\nwant:\n%s", got, tt.want)
+			}
+		})
+	}
+}
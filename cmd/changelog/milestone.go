@@ -0,0 +1,151 @@
+// Copyright 2021 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package changelog
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	gh "github.com/google/go-github/v50/github"
+	flag "github.com/spf13/pflag"
+
+	"github.com/cilium/release/pkg/profile"
+	"github.com/cilium/release/pkg/types"
+	"github.com/cilium/release/pkg/version"
+)
+
+// MilestoneCommand implements the 'milestone' subcommand, which sets the
+// milestone of a release on every PR that shipped in it, once published.
+func MilestoneCommand(ctx context.Context, ghClient *gh.Client, args []string) error {
+	var (
+		cfg       types.Config
+		stateFile string
+		dryRun    bool
+	)
+	fs := flag.NewFlagSet("milestone", flag.ContinueOnError)
+	fs.StringVar(&cfg.RepoName, "repo", "cilium/cilium", "GitHub organization and repository names separated by a slash")
+	fs.StringVar(&cfg.Profile, "profile", profile.Default, fmt.Sprintf("Label scheme of the repository, one of %s", strings.Join(profile.Names(), ", ")))
+	fs.StringVar(&stateFile, "state-file", "", "State file of the release notes of the release. The notes of the published release are parsed if empty")
+	fs.BoolVar(&dryRun, "dry-run", false, "Only print the PRs the milestone would be set on")
+	if err := types.ParseFlags(fs, "milestone", args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return fmt.Errorf("usage: milestone <version> [flags]")
+	}
+	v, err := version.Parse(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	cfg.Owner, cfg.Repo, err = types.SplitRepoName(cfg.RepoName)
+	if err != nil {
+		return err
+	}
+	title := "v" + v.String()
+	ref := stateFile
+	if len(ref) == 0 {
+		ref = title
+	}
+	cl, err := loadRelease(ctx, ghClient, cfg, ref)
+	if err != nil {
+		return err
+	}
+	prs := cl.shippedPRs()
+	if dryRun {
+		for _, pr := range prs {
+			fmt.Fprintf(os.Stdout, "Would set milestone %s on #%d\n", title, pr)
+		}
+		return nil
+	}
+	return setMilestone(ctx, ghClient, os.Stdout, cfg.Owner, cfg.Repo, title, prs)
+}
+
+// shippedPRs returns the numbers, sorted, of the PRs merged into the released
+// branch for the entries of the release notes: their backport PRs, or the
+// PRs themselves if they were not backported. The PRs of other repositories,
+// e.g. of a security fork, are left out.
+func (cl *ChangeLog) shippedPRs() []int {
+	seen := map[int]bool{}
+	var prs []int
+	for _, section := range cl.Sections() {
+		for _, entry := range section.Entries {
+			if entry.PR <= 0 || len(entry.Repo) != 0 {
+				continue
+			}
+			shipped := entry.BackportPRs
+			if len(shipped) == 0 {
+				shipped = []int{entry.PR}
+			}
+			for _, pr := range shipped {
+				if !seen[pr] {
+					seen[pr] = true
+					prs = append(prs, pr)
+				}
+			}
+		}
+	}
+	sort.Ints(prs)
+	return prs
+}
+
+// setMilestone sets the milestone of the given title on the PRs, creating it
+// if it doesn't exist yet.
+func setMilestone(ctx context.Context, ghClient *gh.Client, w io.Writer, owner, repo, title string, prs []int) error {
+	milestone, err := findMilestone(ctx, ghClient, owner, repo, title)
+	if err != nil {
+		return err
+	}
+	if milestone == nil {
+		milestone, _, err = ghClient.Issues.CreateMilestone(ctx, owner, repo, &gh.Milestone{Title: &title})
+		if err != nil {
+			return fmt.Errorf("unable to create milestone %s: %w", title, err)
+		}
+		fmt.Fprintf(w, "Created milestone %s\n", title)
+	}
+	number := milestone.GetNumber()
+	for _, pr := range prs {
+		_, _, err := ghClient.Issues.Edit(ctx, owner, repo, pr, &gh.IssueRequest{Milestone: &number})
+		if err != nil {
+			return fmt.Errorf("unable to set milestone %s on #%d: %w", title, pr, err)
+		}
+	}
+	fmt.Fprintf(w, "Milestone %s set on %d PRs\n", title, len(prs))
+	return nil
+}
+
+// findMilestone returns the milestone, open or closed, of the given title,
+// or nil if there is none.
+func findMilestone(ctx context.Context, ghClient *gh.Client, owner, repo, title string) (*gh.Milestone, error) {
+	opts := &gh.MilestoneListOptions{State: "all", ListOptions: gh.ListOptions{PerPage: 100}}
+	for {
+		milestones, resp, err := ghClient.Issues.ListMilestones(ctx, owner, repo, opts)
+		if err != nil {
+			return nil, fmt.Errorf("unable to list milestones: %w", err)
+		}
+		for _, m := range milestones {
+			if m.GetTitle() == title {
+				return m, nil
+			}
+		}
+		if resp.NextPage == 0 {
+			return nil, nil
+		}
+		opts.Page = resp.NextPage
+	}
+}
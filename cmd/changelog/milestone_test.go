@@ -0,0 +1,84 @@
+// Copyright 2021 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package changelog
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"testing"
+
+	gh "github.com/google/go-github/v50/github"
+
+	"github.com/cilium/release/pkg/types"
+)
+
+func TestShippedPRs(t *testing.T) {
+	cl := New(nil, types.Config{}, types.BackportPRs{
+		200: {
+			100: {ReleaseNote: "Fix foo", AuthorName: "alice", Labels: []string{"release-note/bug"}},
+			101: {ReleaseNote: "Fix bar", AuthorName: "bob", Labels: []string{"release-note/bug"}},
+		},
+	}, types.PullRequests{
+		150: {ReleaseNote: "Add baz", AuthorName: "carol", Labels: []string{"release-note/minor"}},
+		160: {ReleaseNote: "Fix qux", AuthorName: "dave", Labels: []string{"release-note/bug"}, Repo: "cilium/cilium-security"},
+	})
+	if got, want := cl.shippedPRs(), []int{150, 200}; !reflect.DeepEqual(got, want) {
+		t.Errorf("shippedPRs() = %v, want %v", got, want)
+	}
+}
+
+func TestSetMilestone(t *testing.T) {
+	edited := map[string]int{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method + " " + r.URL.Path {
+		case "GET /repos/cilium/cilium/milestones":
+			if r.URL.Query().Get("state") != "all" {
+				t.Errorf("milestones should be listed whatever their state")
+			}
+			fmt.Fprint(w, `[{"number": 7, "title": "v1.14.2"}]`)
+		case "POST /repos/cilium/cilium/milestones":
+			fmt.Fprint(w, `{"number": 8, "title": "v1.14.3"}`)
+		case "PATCH /repos/cilium/cilium/issues/150", "PATCH /repos/cilium/cilium/issues/200":
+			var req gh.IssueRequest
+			json.NewDecoder(r.Body).Decode(&req)
+			edited[r.URL.Path] = req.GetMilestone()
+			fmt.Fprint(w, `{}`)
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL)
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+	ghClient := gh.NewClient(nil)
+	ghClient.BaseURL, _ = url.Parse(srv.URL + "/")
+
+	var buf bytes.Buffer
+	if err := setMilestone(context.Background(), ghClient, &buf, "cilium", "cilium", "v1.14.3", []int{150, 200}); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]int{"/repos/cilium/cilium/issues/150": 8, "/repos/cilium/cilium/issues/200": 8}
+	if !reflect.DeepEqual(edited, want) {
+		t.Errorf("got %v, want %v", edited, want)
+	}
+	if got := buf.String(); got != "Created milestone v1.14.3\nMilestone v1.14.3 set on 2 PRs\n" {
+		t.Errorf("got output %q", got)
+	}
+}
@@ -0,0 +1,37 @@
+// Copyright 2021 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package changelog
+
+import "testing"
+
+func TestNaturalCompare(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{a: "Add v2 API", b: "Add v10 API", want: -1},
+		{a: "Add v10 API", b: "Add v2 API", want: 1},
+		{a: "add V2 api", b: "Add v2 API", want: 0},
+		{a: "Add v02 API", b: "Add v2 API", want: 0},
+		{a: "Bump to 1.9.1", b: "Bump to 1.10.0", want: -1},
+		{a: "Fix", b: "Fix foo", want: -1},
+		{a: "Fix 2", b: "Fix a", want: -1},
+	}
+	for _, tt := range tests {
+		if got := naturalCompare(tt.a, tt.b); got != tt.want {
+			t.Errorf("naturalCompare(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
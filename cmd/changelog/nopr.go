@@ -0,0 +1,87 @@
+// Copyright 2021 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package changelog
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	gh "github.com/google/go-github/v50/github"
+
+	"github.com/cilium/release/pkg/profile"
+	"github.com/cilium/release/pkg/types"
+)
+
+const (
+	// NoPRCommitsDrop leaves the commits without any PR out of the
+	// release notes. It is the default with ModePRs.
+	NoPRCommitsDrop = "drop"
+	// NoPRCommitsRender lists the commits without any PR in the section
+	// of the default label, e.g. 'Other Changes', with their subject. It
+	// is the default with ModeCommits.
+	NoPRCommitsRender = "render"
+	// NoPRCommitsFail fails the run on the first commit without any PR.
+	NoPRCommitsFail = "fail"
+)
+
+// noPRCommit applies cfg.NoPRCommits to the given commit of the pull
+// requests mode, for which no merged PR was found. Rendered commits are
+// added to listOfPRs.
+func noPRCommit(ctx context.Context, ghClient *gh.Client, cfg types.Config, listOfPRs types.PullRequests, sha string) error {
+	switch cfg.NoPRCommits {
+	case NoPRCommitsFail:
+		return fmt.Errorf("no merged PR found for commit %s, see --no-pr-commits", sha)
+	case NoPRCommitsRender:
+		commit, _, err := ghClient.Repositories.GetCommit(ctx, cfg.Owner, cfg.Repo, sha, nil)
+		if err != nil {
+			return fmt.Errorf("unable to get commit %s: %w", sha, err)
+		}
+		p, err := profile.Get(cfg.Profile)
+		if err != nil {
+			return err
+		}
+		listOfPRs[nextCommitKey(listOfPRs)] = commitChange(p, commit)
+	}
+	return nil
+}
+
+// commitChange returns the release note information of the given commit
+// without any PR: its subject, listed in the section of the default label.
+func commitChange(p profile.Profile, commit *gh.RepositoryCommit) types.PullRequest {
+	subject, _, _ := strings.Cut(commit.GetCommit().GetMessage(), "\n")
+	author := commit.GetAuthor().GetLogin()
+	if len(author) == 0 {
+		author = commit.GetCommit().GetAuthor().GetName()
+	}
+	return types.PullRequest{
+		ReleaseNote:  strings.TrimSpace(subject),
+		ReleaseLabel: p.DefaultLabel,
+		AuthorName:   author,
+		Commit:       commit.GetSHA(),
+	}
+}
+
+// nextCommitKey returns the negative number keying the next commit without
+// any PR in listOfPRs.
+func nextCommitKey(listOfPRs types.PullRequests) int {
+	key := -1
+	for number := range listOfPRs {
+		if number <= key {
+			key = number - 1
+		}
+	}
+	return key
+}
@@ -0,0 +1,102 @@
+// Copyright 2021 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package changelog
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	gh "github.com/google/go-github/v50/github"
+
+	"github.com/cilium/release/pkg/profile"
+	"github.com/cilium/release/pkg/types"
+)
+
+func TestNoPRCommit(t *testing.T) {
+	tests := []struct {
+		policy  string
+		wantErr bool
+	}{
+		{policy: ""},
+		{policy: NoPRCommitsDrop},
+		{policy: NoPRCommitsFail, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.policy, func(t *testing.T) {
+			prs := types.PullRequests{123: {Title: "Add foo"}}
+			err := noPRCommit(context.Background(), nil, types.Config{NoPRCommits: tt.policy}, prs, "aaa")
+			if (err != nil) != tt.wantErr {
+				t.Errorf("noPRCommit() error = %v, want error %t", err, tt.wantErr)
+			}
+			if len(prs) != 1 {
+				t.Errorf("noPRCommit() added %d PRs, want none", len(prs)-1)
+			}
+		})
+	}
+}
+
+func TestCommitChange(t *testing.T) {
+	p, _ := profile.Get("")
+	tests := []struct {
+		name   string
+		commit *gh.RepositoryCommit
+		want   types.PullRequest
+	}{
+		{
+			name: "GitHub user",
+			commit: &gh.RepositoryCommit{
+				SHA:    gh.String("aaa"),
+				Author: &gh.User{Login: gh.String("alice")},
+				Commit: &gh.Commit{Message: gh.String("Update README \n\nSigned-off-by: Alice <alice@example.com>")},
+			},
+			want: types.PullRequest{ReleaseNote: "Update README", ReleaseLabel: "release-note/none", AuthorName: "alice", Commit: "aaa"},
+		},
+		{
+			name: "unknown user",
+			commit: &gh.RepositoryCommit{
+				SHA: gh.String("bbb"),
+				Commit: &gh.Commit{
+					Message: gh.String("Prepare for release v1.14.1"),
+					Author:  &gh.CommitAuthor{Name: gh.String("Release Bot")},
+				},
+			},
+			want: types.PullRequest{ReleaseNote: "Prepare for release v1.14.1", ReleaseLabel: "release-note/none", AuthorName: "Release Bot", Commit: "bbb"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := commitChange(p, tt.commit); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("commitChange() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNextCommitKey(t *testing.T) {
+	tests := []struct {
+		prs  types.PullRequests
+		want int
+	}{
+		{prs: types.PullRequests{}, want: -1},
+		{prs: types.PullRequests{123: {}}, want: -1},
+		{prs: types.PullRequests{123: {}, -1: {}, -2: {}}, want: -3},
+	}
+	for _, tt := range tests {
+		if got := nextCommitKey(tt.prs); got != tt.want {
+			t.Errorf("nextCommitKey(%v) = %d, want %d", tt.prs, got, tt.want)
+		}
+	}
+}
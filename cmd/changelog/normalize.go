@@ -0,0 +1,45 @@
+// Copyright 2021 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package changelog
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// thisPRRe matches the 'This PR' prefixes of the release notes, e.g. 'This
+// PR fixes'.
+var thisPRRe = regexp.MustCompile(`(?i)^this (pr|pull request)[:,]?\s+`)
+
+// normalizeNote returns the release note with its whitespace collapsed, its
+// 'This PR' prefix, wrapping backticks and trailing periods removed, and its
+// first letter capitalized, e.g. 'This PR  fixes foo.' becomes 'Fixes foo'.
+func normalizeNote(note string) string {
+	note = strings.Join(strings.Fields(note), " ")
+	note = thisPRRe.ReplaceAllString(note, "")
+	if len(note) > 2 && strings.HasPrefix(note, "`") && strings.HasSuffix(note, "`") &&
+		strings.Count(note, "`") == 2 {
+		note = strings.TrimSpace(note[1 : len(note)-1])
+	}
+	note = strings.TrimRight(note, ".")
+	note = strings.TrimSpace(note)
+	r, size := utf8.DecodeRuneInString(note)
+	if unicode.IsLower(r) {
+		note = string(unicode.ToUpper(r)) + note[size:]
+	}
+	return note
+}
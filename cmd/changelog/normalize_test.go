@@ -0,0 +1,39 @@
+// Copyright 2021 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package changelog
+
+import "testing"
+
+func TestNormalizeNote(t *testing.T) {
+	for _, tt := range []struct {
+		note string
+		want string
+	}{
+		{"Add foo", "Add foo"},
+		{"add foo.", "Add foo"},
+		{"  Fix   bar\n in baz...  ", "Fix bar in baz"},
+		{"This PR fixes foo.", "Fixes foo"},
+		{"this pull request: adds bar", "Adds bar"},
+		{"`cilium status` shows foo", "`cilium status` shows foo"},
+		{"`Fix foo.`", "Fix foo"},
+		{"Support v1.2.", "Support v1.2"},
+		{"éviter foo", "Éviter foo"},
+		{"", ""},
+	} {
+		if got := normalizeNote(tt.note); got != tt.want {
+			t.Errorf("normalizeNote(%q) = %q, want %q", tt.note, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,92 @@
+// Copyright 2021 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package changelog
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/cilium/release/pkg/report"
+	"github.com/cilium/release/pkg/types"
+)
+
+// Orphans returns the commits and PRs that can't be properly categorized,
+// grouped by reason: the commits without any merged PR, the backport PRs
+// referencing an upstream PR that doesn't exist and the PRs without any
+// release note label.
+func (cl *ChangeLog) Orphans() map[string][]types.Orphan {
+	orphans := map[string][]types.Orphan{}
+	for _, o := range cl.orphans {
+		orphans[o.Reason] = append(orphans[o.Reason], o)
+	}
+	// An upstream PR can be backported through several backport PRs.
+	prs := types.PullRequests{}
+	for number, pr := range cl.listOfPrs {
+		prs[number] = pr
+	}
+	for _, upstreamPRs := range cl.prsWithUpstream {
+		for number, pr := range upstreamPRs {
+			prs[number] = pr
+		}
+	}
+	p := cl.scheme()
+	for number, pr := range prs {
+		// The labels of the PRs parsed from published release notes are
+		// unknown.
+		if len(pr.Labels) == 0 && len(pr.Title) == 0 {
+			continue
+		}
+		if _, ok := p.SectionLabel(pr.Labels); ok {
+			continue
+		}
+		orphans[types.OrphanNoLabel] = append(orphans[types.OrphanNoLabel], types.Orphan{
+			Reason: types.OrphanNoLabel,
+			PR:     number,
+			Title:  pr.Title,
+			URL:    pr.URL,
+		})
+	}
+	for _, group := range orphans {
+		sort.Slice(group, func(i, j int) bool {
+			if group[i].PR != group[j].PR {
+				return group[i].PR < group[j].PR
+			}
+			return group[i].SHA < group[j].SHA
+		})
+	}
+	return orphans
+}
+
+// writeOrphansReport writes the orphans, grouped by reason, as JSON into
+// file and their count into stderr.
+func (cl *ChangeLog) writeOrphansReport(file string) error {
+	orphans := cl.Orphans()
+	for _, reason := range []string{types.OrphanNoPR, types.OrphanUnresolvedBackport, types.OrphanNoLabel} {
+		if n := len(orphans[reason]); n != 0 {
+			fmt.Fprintf(os.Stderr, "%d orphans: %s\n", n, reason)
+		}
+	}
+	b, err := json.MarshalIndent(orphans, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(file, append(b, '\n'), 0644); err != nil {
+		return err
+	}
+	report.Written(file)
+	return nil
+}
@@ -0,0 +1,63 @@
+// Copyright 2021 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package changelog
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/cilium/release/pkg/types"
+)
+
+func TestOrphans(t *testing.T) {
+	cl := &ChangeLog{
+		prsWithUpstream: types.BackportPRs{
+			200: {
+				150: {Title: "Fix bar", Labels: []string{"kind/bug"}},
+			},
+			201: {
+				150: {Title: "Fix bar", Labels: []string{"kind/bug"}},
+			},
+		},
+		listOfPrs: types.PullRequests{
+			123: {Title: "Add foo", Labels: []string{"release-note/minor"}},
+			124: {Title: "Add baz", URL: "https://github.com/cilium/cilium/pull/124"},
+			// Parsed from published release notes.
+			125: {ReleaseNote: "Fix qux", ReleaseLabel: "release-note/bug"},
+		},
+		orphans: []types.Orphan{
+			{Reason: types.OrphanNoPR, SHA: "bbb"},
+			{Reason: types.OrphanUnresolvedBackport, SHA: "ccc", PR: 202, UpstreamPR: 999},
+			{Reason: types.OrphanNoPR, SHA: "aaa"},
+		},
+	}
+
+	want := map[string][]types.Orphan{
+		types.OrphanNoPR: {
+			{Reason: types.OrphanNoPR, SHA: "aaa"},
+			{Reason: types.OrphanNoPR, SHA: "bbb"},
+		},
+		types.OrphanUnresolvedBackport: {
+			{Reason: types.OrphanUnresolvedBackport, SHA: "ccc", PR: 202, UpstreamPR: 999},
+		},
+		types.OrphanNoLabel: {
+			{Reason: types.OrphanNoLabel, PR: 124, Title: "Add baz", URL: "https://github.com/cilium/cilium/pull/124"},
+			{Reason: types.OrphanNoLabel, PR: 150, Title: "Fix bar"},
+		},
+	}
+	if got := cl.Orphans(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Orphans() = %+v, want %+v", got, want)
+	}
+}
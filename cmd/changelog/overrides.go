@@ -0,0 +1,107 @@
+// Copyright 2021 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package changelog
+
+import (
+	"fmt"
+
+	"github.com/cilium/release/pkg/config"
+	"github.com/cilium/release/pkg/profile"
+	"github.com/cilium/release/pkg/types"
+)
+
+// loadOverrides reads cfg.OverridesFile, if set, and checks that its
+// categories are sections of the label scheme.
+func loadOverrides(cfg types.Config) (config.Overrides, error) {
+	if len(cfg.OverridesFile) == 0 {
+		return nil, nil
+	}
+	overrides, err := config.LoadOverrides(cfg.OverridesFile)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read overrides file: %w", err)
+	}
+	p, err := profile.Get(cfg.Profile)
+	if err != nil {
+		return nil, err
+	}
+	for number, o := range overrides {
+		if len(o.Category) != 0 && len(p.Header(o.Category)) == 0 {
+			return nil, fmt.Errorf("overrides file: unknown category %q of PR %d", o.Category, number)
+		}
+	}
+	return overrides, nil
+}
+
+// applyOverrides corrects, or drops, the PRs, including the upstream PRs of
+// the backports, as set in the overrides. The PRs stored in the state are not
+// modified so that the overrides can be changed and applied again.
+func (cl *ChangeLog) applyOverrides(overrides config.Overrides) {
+	if len(overrides) == 0 {
+		return
+	}
+	const dropped = "dropped by the overrides"
+	listOfPrs := make(types.PullRequests, len(cl.listOfPrs))
+	for number, pr := range cl.listOfPrs {
+		if overrides[number].Drop {
+			cl.markRemoved(number, dropped)
+			continue
+		}
+		listOfPrs[number] = cl.override(overrides, number, pr)
+	}
+	prsWithUpstream := make(types.BackportPRs, len(cl.prsWithUpstream))
+	for backportPR, upstreamPRs := range cl.prsWithUpstream {
+		prs := make(map[int]types.PullRequest, len(upstreamPRs))
+		for number, pr := range upstreamPRs {
+			if !overrides[number].Drop {
+				prs[number] = cl.override(overrides, number, pr)
+			}
+		}
+		if len(prs) == 0 {
+			cl.markRemoved(backportPR, dropped)
+			continue
+		}
+		prsWithUpstream[backportPR] = prs
+	}
+	cl.listOfPrs, cl.prsWithUpstream = listOfPrs, prsWithUpstream
+}
+
+// override returns the given PR corrected as set in the overrides.
+func (cl *ChangeLog) override(overrides config.Overrides, number int, pr types.PullRequest) types.PullRequest {
+	o, ok := overrides[number]
+	if !ok {
+		return pr
+	}
+	if len(o.Category) != 0 {
+		// The labels of the other sections are dropped so that the
+		// category wins over them.
+		p := cl.scheme()
+		lbls := []string{o.Category}
+		for _, lbl := range pr.Labels {
+			if len(p.Header(lbl)) == 0 {
+				lbls = append(lbls, lbl)
+			}
+		}
+		pr.Labels = lbls
+		pr.ReleaseLabel = o.Category
+	}
+	if len(o.Note) != 0 {
+		pr.ReleaseNote = o.Note
+		pr.MissingReleaseNote = false
+	}
+	if len(o.Author) != 0 {
+		pr.AuthorName = o.Author
+	}
+	return pr
+}
@@ -0,0 +1,90 @@
+// Copyright 2021 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package changelog
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/cilium/release/pkg/types"
+)
+
+func TestOverrides(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "overrides.yaml")
+	err := os.WriteFile(file, []byte(`
+123:
+  category: release-note/bug
+124:
+  note: Add baz to the CLI
+  author: dave
+125:
+  drop: true
+150:
+  category: release-note/minor
+151:
+  drop: true
+`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	overrides, err := loadOverrides(types.Config{OverridesFile: file})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	backportPRs := types.BackportPRs{
+		200: {
+			150: {ReleaseNote: "Fix bar", AuthorName: "bob", Labels: []string{"release-note/bug"}},
+		},
+		201: {
+			151: {ReleaseNote: "Fix qux", AuthorName: "bob", Labels: []string{"release-note/bug"}},
+		},
+	}
+	prs := types.PullRequests{
+		123: {ReleaseNote: "Fix foo", AuthorName: "alice", Labels: []string{"release-note/minor", "backport-done/1.13"}},
+		124: {ReleaseNote: "Add baz", AuthorName: "carol", Labels: []string{"release-note/minor"}},
+		125: {ReleaseNote: "Bump deps", AuthorName: "erin", Labels: []string{"release-note/misc"}},
+	}
+	cl := &ChangeLog{prsWithUpstream: backportPRs, listOfPrs: prs}
+	cl.applyOverrides(overrides)
+
+	got, err := cl.Render(RenderOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "Summary of Changes\n" +
+		"------------------\n" +
+		"\n" +
+		"**Minor Changes:**\n" +
+		"* Add baz to the CLI (#124, @dave)\n" +
+		"* Fix bar (Backport PR #200, Upstream PR #150, @bob)\n" +
+		"\n" +
+		"**Bugfixes:**\n" +
+		"* Fix foo (#123, @alice)\n"
+	if string(got) != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+	if lbls := prs[123].Labels; len(lbls) != 2 || lbls[0] != "release-note/minor" {
+		t.Errorf("the PRs of the state were modified: %v", lbls)
+	}
+
+	if err := os.WriteFile(file, []byte("123:\n  category: release-note/unknown\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := loadOverrides(types.Config{OverridesFile: file}); err == nil {
+		t.Error("expected an error for an unknown category")
+	}
+}
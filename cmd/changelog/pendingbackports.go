@@ -0,0 +1,98 @@
+// Copyright 2021 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package changelog
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+
+	gh "github.com/google/go-github/v50/github"
+
+	"github.com/cilium/release/pkg/github"
+	"github.com/cilium/release/pkg/tracing"
+	"github.com/cilium/release/pkg/usage"
+)
+
+// checkPendingBackports fails if any of the PRs labeled as needing a
+// backport to the stable branch of cl.RequireBackports, or with a pending
+// backport to it, is not part of the release notes, so that a patch release
+// doesn't omit the fixes promised to it. Only the merged PRs are
+// considered, and the PRs whose label is stale, i.e. whose backport is part
+// of the release notes, are fine. With cl.Force, the missing PRs are only
+// warned about.
+func (cl *ChangeLog) checkPendingBackports(ctx context.Context, tracker *usage.Tracker) error {
+	ctx, endPhase := tracing.Phase(ctx, tracker, "pending backports")
+	defer endPhase()
+
+	p := cl.scheme()
+	released := cl.releasedPRs()
+	var missing []latePR
+	seen := map[int]bool{}
+	for _, prefix := range []string{p.NeedsBackportPrefix, p.PendingBackportPrefix} {
+		label := prefix + cl.RequireBackports
+		prs, err := listMergedLabeledPRs(ctx, cl.ghClient, cl.UpstreamOwner, cl.UpstreamRepo, label)
+		if err != nil {
+			return fmt.Errorf("unable to list the PRs labeled %s: %w", label, err)
+		}
+		for _, pr := range prs {
+			if released[pr.Number] || seen[pr.Number] {
+				continue
+			}
+			seen[pr.Number] = true
+			missing = append(missing, pr)
+			fmt.Fprintf(os.Stderr, "PR #%d is labeled %s but isn't part of the release notes: %s\n", pr.Number, label, pr.Title)
+		}
+	}
+	if len(missing) != 0 && cl.Force {
+		fmt.Fprintf(os.Stderr, "WARNING: %d PRs pending a backport to %s are not part of the release notes, ignored with --force\n", len(missing), p.StableBranch(cl.RequireBackports))
+		return nil
+	}
+	if len(missing) != 0 {
+		return fmt.Errorf("%d PRs pending a backport to %s are not part of the release notes", len(missing), p.StableBranch(cl.RequireBackports))
+	}
+	return nil
+}
+
+// releasedPRs returns the PRs part of the release notes, including the
+// upstream PRs of their backports.
+func (cl *ChangeLog) releasedPRs() map[int]bool {
+	released := map[int]bool{}
+	for number := range cl.listOfPrs {
+		released[number] = true
+	}
+	for _, upstreamPRs := range cl.prsWithUpstream {
+		for number := range upstreamPRs {
+			released[number] = true
+		}
+	}
+	return released
+}
+
+// listMergedLabeledPRs returns the merged PRs, sorted by number, with the
+// given label.
+func listMergedLabeledPRs(ctx context.Context, ghClient *gh.Client, owner, repo, label string) ([]latePR, error) {
+	issues, err := github.SearchIssues(ctx, ghClient, fmt.Sprintf("repo:%s/%s is:pr is:merged label:%q", owner, repo, label))
+	if err != nil {
+		return nil, err
+	}
+	prs := make([]latePR, 0, len(issues))
+	for _, issue := range issues {
+		prs = append(prs, latePR{Number: issue.GetNumber(), Title: issue.GetTitle()})
+	}
+	sort.Slice(prs, func(i, j int) bool { return prs[i].Number < prs[j].Number })
+	return prs, nil
+}
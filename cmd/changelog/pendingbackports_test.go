@@ -0,0 +1,80 @@
+// Copyright 2021 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package changelog
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	gh "github.com/google/go-github/v50/github"
+
+	"github.com/cilium/release/pkg/types"
+)
+
+func TestCheckPendingBackports(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/search/issues" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL)
+			http.NotFound(w, r)
+			return
+		}
+		switch r.URL.Query().Get("q") {
+		case `repo:cilium/cilium is:pr is:merged label:"needs-backport/1.14"`:
+			fmt.Fprint(w, `{"total_count": 2, "items": [
+				{"number": 130, "title": "Fix qux", "pull_request": {"url": "https://api.github.com/repos/cilium/cilium/pulls/130"}},
+				{"number": 123, "title": "Fix foo", "pull_request": {"url": "https://api.github.com/repos/cilium/cilium/pulls/123"}}
+			]}`)
+		case `repo:cilium/cilium is:pr is:merged label:"backport-pending/1.14"`:
+			fmt.Fprint(w, `{"total_count": 1, "items": [{"number": 132, "title": "Fix quux", "pull_request": {"url": "https://api.github.com/repos/cilium/cilium/pulls/132"}}]}`)
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL)
+			fmt.Fprint(w, `{"total_count": 0, "items": []}`)
+		}
+	}))
+	defer srv.Close()
+	ghClient := gh.NewClient(nil)
+	ghClient.BaseURL, _ = url.Parse(srv.URL + "/")
+
+	cfg := types.Config{RequireBackports: "1.14"}
+	cfg.UpstreamOwner, cfg.UpstreamRepo = "cilium", "cilium"
+	cl := &ChangeLog{
+		Config:   cfg,
+		ghClient: ghClient,
+		prsWithUpstream: types.BackportPRs{
+			// The needs-backport/1.14 label of #123 is stale.
+			200: {123: {ReleaseNote: "Fix foo"}},
+		},
+		listOfPrs: types.PullRequests{},
+	}
+	err := cl.checkPendingBackports(context.Background(), nil)
+	if err == nil || err.Error() != "2 PRs pending a backport to v1.14 are not part of the release notes" {
+		t.Errorf("got error %v, want the 2 PRs #130 and #132 missing", err)
+	}
+
+	cl.Force = true
+	if err := cl.checkPendingBackports(context.Background(), nil); err != nil {
+		t.Errorf("got error %v with --force, want none", err)
+	}
+	cl.Force = false
+
+	cl.prsWithUpstream[201] = map[int]types.PullRequest{130: {}, 132: {}}
+	if err := cl.checkPendingBackports(context.Background(), nil); err != nil {
+		t.Errorf("got error %v, want none", err)
+	}
+}
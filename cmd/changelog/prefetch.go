@@ -0,0 +1,81 @@
+// Copyright 2021 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package changelog
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	gh "github.com/google/go-github/v50/github"
+	flag "github.com/spf13/pflag"
+
+	"github.com/cilium/release/pkg/profile"
+	"github.com/cilium/release/pkg/types"
+)
+
+// PrefetchCommand implements the 'prefetch' subcommand, which resolves the
+// PRs of the commits of a range ahead of the release, e.g. days before it,
+// so that they are found in the cache when the release notes are generated.
+// Only the PR cache is filled, the other requests of the release notes, e.g.
+// the comparison of the range, aren't cached.
+func PrefetchCommand(ctx context.Context, ghClient *gh.Client, args []string) error {
+	var cfg types.Config
+	fs := flag.NewFlagSet("prefetch", flag.ContinueOnError)
+	fs.StringVar(&cfg.RepoName, "repo", "cilium/cilium", "GitHub organization and repository names separated by a slash")
+	fs.StringVar(&cfg.UpstreamRepoName, "upstream-repo", "", "GitHub organization and repository names, separated by a slash, of the upstream PRs referenced by backport PRs, if different from --repo")
+	fs.StringVar(&cfg.Base, "base", "", "Base commit / tag of the range")
+	fs.StringVar(&cfg.Head, "head", "", "Head commit of the range, e.g. the stable branch")
+	fs.StringSliceVar(&cfg.LastStable, "last-stable", nil, "Last stable versions whose backported PRs are not released (e.g.: '1.5', '1.6'). Can be repeated or comma-separated")
+	fs.StringVar(&cfg.CacheDir, "cache-dir", "", "Directory of the PR metadata cache shared across runs, releases and branches, e.g. ~/.cache/cilium-release, to fill. Only the PRs are cached, not the other requests, and the PRs updated after the prefetch are dropped by the next run")
+	fs.StringVar(&cfg.Profile, "profile", profile.Default, fmt.Sprintf("Label scheme of the repository, one of %s", strings.Join(profile.Names(), ", ")))
+	if err := types.ParseFlags(fs, "prefetch", args); err != nil {
+		return err
+	}
+	if len(cfg.CacheDir) == 0 {
+		return fmt.Errorf("--cache-dir must be set")
+	}
+	return prefetch(ctx, ghClient, os.Stdout, cfg)
+}
+
+// prefetch generates, and discards, the release notes of cfg.Base...cfg.Head
+// for their PRs to be stored in the cache of cfg.CacheDir, and writes into w
+// how many were.
+func prefetch(ctx context.Context, ghClient *gh.Client, w io.Writer, cfg types.Config) error {
+	// Always start from scratch, only the cache is kept.
+	stateDir, err := os.MkdirTemp("", "release-prefetch")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(stateDir)
+	cfg.StateFile = filepath.Join(stateDir, "state.json")
+	if err := cfg.Sanitize(); err != nil {
+		return err
+	}
+
+	cl, err := GenerateReleaseNotes(ctx, ghClient, cfg, nil)
+	if err != nil {
+		return err
+	}
+	prs := len(cl.listOfPrs)
+	for _, upstreamPRs := range cl.prsWithUpstream {
+		prs += len(upstreamPRs)
+	}
+	fmt.Fprintf(w, "Cached the %d PRs of %s...%s in %s\n", prs, cl.Base, cfg.Head, cfg.CacheDir)
+	return nil
+}
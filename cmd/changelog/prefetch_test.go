@@ -0,0 +1,68 @@
+// Copyright 2021 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package changelog
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	gh "github.com/google/go-github/v50/github"
+
+	"github.com/cilium/release/pkg/cache"
+	"github.com/cilium/release/pkg/types"
+)
+
+func TestPrefetch(t *testing.T) {
+	sha := strings.Repeat("a", 40)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/rate_limit":
+			fmt.Fprint(w, `{"resources": {"core": {"limit": 5000, "remaining": 5000}}}`)
+		case "/repos/cilium/cilium/commits/v1.14.0", "/repos/cilium/cilium/commits/v1.14":
+			fmt.Fprintf(w, `{"sha": %q}`, sha)
+		case "/repos/cilium/cilium/compare/v1.14.0...v1.14":
+			fmt.Fprintf(w, `{"total_commits": 1, "commits": [{"sha": %q, "commit": {"message": "Fix bar (#124)"}}]}`, sha)
+		case "/repos/cilium/cilium/commits/" + sha + "/pulls":
+			fmt.Fprint(w, `[{"number": 124, "state": "closed", "title": "Fix bar", "merged_at": "2023-07-12T09:30:00Z",
+				"user": {"login": "bob"}, "labels": [{"name": "release-note/bug"}]}]`)
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL)
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+	ghClient := gh.NewClient(nil)
+	ghClient.BaseURL, _ = url.Parse(srv.URL + "/")
+
+	cacheDir := t.TempDir()
+	cfg := types.Config{RepoName: "cilium/cilium", Base: "v1.14.0", Head: "v1.14", CacheDir: cacheDir}
+	var buf bytes.Buffer
+	if err := prefetch(context.Background(), ghClient, &buf, cfg); err != nil {
+		t.Fatal(err)
+	}
+	if want := fmt.Sprintf("Cached the 1 PRs of v1.14.0...v1.14 in %s\n", cacheDir); buf.String() != want {
+		t.Errorf("got %q, want %q", buf.String(), want)
+	}
+	prCache, _ := cache.New(cacheDir)
+	if prs, ok := prCache.CommitPRs("cilium", "cilium", sha); !ok || len(prs) != 1 || prs[0].GetNumber() != 124 {
+		t.Errorf("got cached PRs %v, want #124", prs)
+	}
+}
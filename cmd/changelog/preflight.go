@@ -0,0 +1,86 @@
+// Copyright 2021 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package changelog
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	gh "github.com/google/go-github/v50/github"
+
+	"github.com/cilium/release/pkg/profile"
+	"github.com/cilium/release/pkg/types"
+	"github.com/cilium/release/pkg/version"
+)
+
+// preflight verifies that the base and head commits, the last stable
+// branches and the tracking issue exist so that a typo fails the run before
+// the commits are compared.
+func preflight(ctx context.Context, ghClient *gh.Client, cfg types.Config) error {
+	refs := []struct {
+		flag, ref string
+	}{
+		{"--base", cfg.Base},
+		{"--head", cfg.Head},
+	}
+	for _, r := range refs {
+		_, resp, err := ghClient.Repositories.GetCommitSHA1(ctx, cfg.Owner, cfg.Repo, r.ref, "")
+		if isNotFound(resp) {
+			if len(cfg.SinceVersion) != 0 && r.ref == cfg.Base {
+				return fmt.Errorf("tag %s not found in %s/%s, has version %s been released?", r.ref, cfg.Owner, cfg.Repo, cfg.SinceVersion)
+			}
+			return fmt.Errorf("%s %s is not a commit, tag or branch of %s/%s", r.flag, r.ref, cfg.Owner, cfg.Repo)
+		}
+		if err != nil {
+			return fmt.Errorf("unable to resolve %s %s: %w", r.flag, r.ref, err)
+		}
+	}
+	if len(cfg.TrackingIssue) != 0 {
+		if err := checkTrackingIssue(ctx, ghClient, cfg.TrackingIssue); err != nil {
+			return err
+		}
+	}
+	return checkLastStable(ctx, ghClient, cfg)
+}
+
+// checkLastStable verifies that the last stable versions correspond to
+// stable branches of the repository, as a mistyped version would otherwise
+// silently exclude nothing from the release notes.
+func checkLastStable(ctx context.Context, ghClient *gh.Client, cfg types.Config) error {
+	p, err := profile.Get(cfg.Profile)
+	if err != nil {
+		return err
+	}
+	for _, lastStable := range cfg.LastStable {
+		s, err := version.ParseSeries(lastStable)
+		if err != nil {
+			return fmt.Errorf("--last-stable %s should be of the format 'x.y' or '<=x.y'", lastStable)
+		}
+		branch := p.StableBranch(s.MinorString())
+		_, resp, err := ghClient.Repositories.GetBranch(ctx, cfg.Owner, cfg.Repo, branch, true)
+		if isNotFound(resp) {
+			return fmt.Errorf("--last-stable %s doesn't correspond to a stable branch: branch %s not found in %s/%s", lastStable, branch, cfg.Owner, cfg.Repo)
+		}
+		if err != nil {
+			return fmt.Errorf("unable to get branch %s: %w", branch, err)
+		}
+	}
+	return nil
+}
+
+func isNotFound(resp *gh.Response) bool {
+	return resp != nil && resp.StatusCode == http.StatusNotFound
+}
@@ -0,0 +1,62 @@
+// Copyright 2021 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package changelog
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	gh "github.com/google/go-github/v50/github"
+
+	"github.com/cilium/release/pkg/types"
+)
+
+func TestCheckLastStable(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/repos/cilium/cilium/branches/v1.13", "/repos/kubernetes/kubernetes/branches/release-1.28":
+			fmt.Fprint(w, `{"name": "branch"}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+	ghClient := gh.NewClient(nil)
+	ghClient.BaseURL, _ = url.Parse(srv.URL + "/")
+
+	tests := []struct {
+		name    string
+		cfg     types.Config
+		wantErr bool
+	}{
+		{name: "existing branch", cfg: types.Config{Owner: "cilium", Repo: "cilium", LastStable: []string{"1.13"}}},
+		{name: "up to existing branch", cfg: types.Config{Owner: "cilium", Repo: "cilium", LastStable: []string{"<=1.13"}}},
+		{name: "missing branch", cfg: types.Config{Owner: "cilium", Repo: "cilium", LastStable: []string{"1.13", "1.31"}}, wantErr: true},
+		{name: "profile branch", cfg: types.Config{Owner: "kubernetes", Repo: "kubernetes", Profile: "kubernetes", LastStable: []string{"1.28"}}},
+		{name: "invalid version", cfg: types.Config{Owner: "cilium", Repo: "cilium", LastStable: []string{"1.13.2"}}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkLastStable(context.Background(), ghClient, tt.cfg)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("checkLastStable() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
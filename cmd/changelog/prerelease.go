@@ -0,0 +1,143 @@
+// Copyright 2021 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package changelog
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/cilium/release/pkg/github"
+	"github.com/cilium/release/pkg/profile"
+	"github.com/cilium/release/pkg/types"
+)
+
+var (
+	backportEntryRe = regexp.MustCompile(`^\* (.*) \(Backport PR (#\d+(?:, #\d+)*), Upstream PR (?:[\w.-]+/[\w.-]+)?#(\d+), @?([^,)]*)(?:, reviewed by [^)]*)?\)$`)
+	entryRe         = regexp.MustCompile(`^\* (.*) \(#(\d+), @?([^,)]*)(?:, reviewed by [^)]*)?\)$`)
+)
+
+// ParseReleaseNotes parses release notes previously rendered by
+// PrintReleaseNotes, e.g. from the body of a published GitHub release.
+// Lines that are not recognized as changelog entries are ignored.
+func ParseReleaseNotes(body string) (types.BackportPRs, types.PullRequests) {
+	p, _ := profile.Get(profile.Default)
+	return parseReleaseNotes(body, p)
+}
+
+// parseReleaseNotes parses release notes whose sections are the ones of
+// the given profile.
+func parseReleaseNotes(body string, p profile.Profile) (types.BackportPRs, types.PullRequests) {
+	labels := map[string]string{}
+	for _, s := range p.Sections {
+		labels[s.Header] = s.Label
+	}
+
+	backportPRs := types.BackportPRs{}
+	prs := types.PullRequests{}
+	releaseLabel := p.DefaultLabel
+	for _, line := range strings.Split(body, "\n") {
+		line = strings.TrimSpace(line)
+		if lbl, ok := labels[line]; ok {
+			releaseLabel = lbl
+			continue
+		}
+		if m := backportEntryRe.FindStringSubmatch(line); m != nil {
+			upstreamPR, _ := strconv.Atoi(m[3])
+			for _, number := range strings.Split(m[2], ", ") {
+				backportPR, _ := strconv.Atoi(strings.TrimPrefix(number, "#"))
+				if _, ok := backportPRs[backportPR]; !ok {
+					backportPRs[backportPR] = map[int]types.PullRequest{}
+				}
+				backportPRs[backportPR][upstreamPR] = types.PullRequest{
+					ReleaseNote:  m[1],
+					ReleaseLabel: releaseLabel,
+					AuthorName:   m[4],
+				}
+			}
+			continue
+		}
+		if m := entryRe.FindStringSubmatch(line); m != nil {
+			prNumber, _ := strconv.Atoi(m[2])
+			prs[prNumber] = types.PullRequest{
+				ReleaseNote:  m[1],
+				ReleaseLabel: releaseLabel,
+				AuthorName:   m[3],
+			}
+		}
+	}
+	return backportPRs, prs
+}
+
+// upstreamPRNumbers returns the set of upstream PR numbers present in the
+// changelog, either merged directly or through a backport PR.
+func (cl *ChangeLog) upstreamPRNumbers() map[int]struct{} {
+	numbers := map[int]struct{}{}
+	for prNumber := range cl.listOfPrs {
+		numbers[prNumber] = struct{}{}
+	}
+	for _, upstreamPRs := range cl.prsWithUpstream {
+		for prNumber := range upstreamPRs {
+			numbers[prNumber] = struct{}{}
+		}
+	}
+	return numbers
+}
+
+// mergePrereleases merges the notes of all published pre-releases of
+// cl.MergePrereleases into the changelog. PRs that are already part of the
+// changelog, or that were part of a previous pre-release, are only added
+// once.
+func (cl *ChangeLog) mergePrereleases(ctx context.Context) error {
+	prereleases, err := github.ListPrereleases(ctx, cl.ghClient, cl.Owner, cl.Repo, cl.MergePrereleases)
+	if err != nil {
+		return err
+	}
+
+	seen := cl.upstreamPRNumbers()
+	for _, prerelease := range prereleases {
+		fmt.Fprintf(os.Stderr, "Merging notes from pre-release %s\n", prerelease.GetTagName())
+		cl.mergeNotes(prerelease.GetBody(), seen)
+	}
+	return nil
+}
+
+// mergeNotes merges the given release notes into the changelog, leaving out
+// the PRs of seen and adding the ones merged to it.
+func (cl *ChangeLog) mergeNotes(body string, seen map[int]struct{}) {
+	backportPRs, prs := parseReleaseNotes(body, cl.scheme())
+	for prNumber, pr := range prs {
+		if _, ok := seen[prNumber]; ok {
+			continue
+		}
+		seen[prNumber] = struct{}{}
+		cl.listOfPrs[prNumber] = pr
+	}
+	for backportPR, upstreamPRs := range backportPRs {
+		for prNumber, pr := range upstreamPRs {
+			if _, ok := seen[prNumber]; ok {
+				continue
+			}
+			seen[prNumber] = struct{}{}
+			if _, ok := cl.prsWithUpstream[backportPR]; !ok {
+				cl.prsWithUpstream[backportPR] = map[int]types.PullRequest{}
+			}
+			cl.prsWithUpstream[backportPR][prNumber] = pr
+		}
+	}
+}
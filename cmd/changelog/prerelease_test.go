@@ -0,0 +1,154 @@
+// Copyright 2021 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package changelog
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/cilium/release/pkg/types"
+)
+
+func TestParseReleaseNotes(t *testing.T) {
+	tests := []struct {
+		name            string
+		body            string
+		wantBackportPRs types.BackportPRs
+		wantPRs         types.PullRequests
+	}{
+		{
+			name: "all kinds of entries",
+			body: "Summary of Changes\r\n" +
+				"------------------\r\n" +
+				"\r\n" +
+				"**Minor Changes:**\r\n" +
+				"* Add foo (#123, @alice)\r\n" +
+				"\r\n" +
+				"**Bugfixes:**\r\n" +
+				"* Fix (nested) bar (Backport PR #200, Upstream PR #150, @bob)\r\n" +
+				"* Fix baz (#124, @carol)\r\n",
+			wantBackportPRs: types.BackportPRs{
+				200: {
+					150: {
+						ReleaseNote:  "Fix (nested) bar",
+						ReleaseLabel: "release-note/bug",
+						AuthorName:   "bob",
+					},
+				},
+			},
+			wantPRs: types.PullRequests{
+				123: {
+					ReleaseNote:  "Add foo",
+					ReleaseLabel: "release-note/minor",
+					AuthorName:   "alice",
+				},
+				124: {
+					ReleaseNote:  "Fix baz",
+					ReleaseLabel: "release-note/bug",
+					AuthorName:   "carol",
+				},
+			},
+		},
+		{
+			name: "upstream PR of another repository",
+			body: "**Bugfixes:**\n" +
+				"* Fix bar (Backport PR #200, Upstream PR cilium/cilium#150, @bob)\n",
+			wantBackportPRs: types.BackportPRs{
+				200: {
+					150: {
+						ReleaseNote:  "Fix bar",
+						ReleaseLabel: "release-note/bug",
+						AuthorName:   "bob",
+					},
+				},
+			},
+			wantPRs: types.PullRequests{},
+		},
+		{
+			name: "upstream PR with several backport PRs",
+			body: "**Bugfixes:**\n" +
+				"* Fix bar (Backport PR #200, #201, Upstream PR #150, @bob)\n",
+			wantBackportPRs: types.BackportPRs{
+				200: {
+					150: {
+						ReleaseNote:  "Fix bar",
+						ReleaseLabel: "release-note/bug",
+						AuthorName:   "bob",
+					},
+				},
+				201: {
+					150: {
+						ReleaseNote:  "Fix bar",
+						ReleaseLabel: "release-note/bug",
+						AuthorName:   "bob",
+					},
+				},
+			},
+			wantPRs: types.PullRequests{},
+		},
+		{
+			name: "authors without mention",
+			body: "**Misc Changes:**\n" +
+				"* Bump deps (#125, Carol Smith)\n",
+			wantBackportPRs: types.BackportPRs{},
+			wantPRs: types.PullRequests{
+				125: {
+					ReleaseNote:  "Bump deps",
+					ReleaseLabel: "release-note/misc",
+					AuthorName:   "Carol Smith",
+				},
+			},
+		},
+		{
+			name: "credited reviewers",
+			body: "**Bugfixes:**\n" +
+				"* Fix bar (Backport PR #200, Upstream PR #150, @bob, reviewed by @alice, @carol)\n" +
+				"* Fix baz (#124, @carol, reviewed by @bob)\n",
+			wantBackportPRs: types.BackportPRs{
+				200: {
+					150: {
+						ReleaseNote:  "Fix bar",
+						ReleaseLabel: "release-note/bug",
+						AuthorName:   "bob",
+					},
+				},
+			},
+			wantPRs: types.PullRequests{
+				124: {
+					ReleaseNote:  "Fix baz",
+					ReleaseLabel: "release-note/bug",
+					AuthorName:   "carol",
+				},
+			},
+		},
+		{
+			name:            "no entries",
+			body:            "We are pleased to release Cilium v1.14.0-rc.1",
+			wantBackportPRs: types.BackportPRs{},
+			wantPRs:         types.PullRequests{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			backportPRs, prs := ParseReleaseNotes(tt.body)
+			if !reflect.DeepEqual(backportPRs, tt.wantBackportPRs) {
+				t.Errorf("ParseReleaseNotes() backportPRs = %v, want %v", backportPRs, tt.wantBackportPRs)
+			}
+			if !reflect.DeepEqual(prs, tt.wantPRs) {
+				t.Errorf("ParseReleaseNotes() prs = %v, want %v", prs, tt.wantPRs)
+			}
+		})
+	}
+}
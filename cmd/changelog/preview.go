@@ -0,0 +1,66 @@
+// Copyright 2021 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package changelog
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	gh "github.com/google/go-github/v50/github"
+
+	"github.com/cilium/release/pkg/github"
+	"github.com/cilium/release/pkg/types"
+	"github.com/cilium/release/pkg/version"
+)
+
+// previewMarker identifies the comment with the preview of the release
+// notes in a release preparation PR.
+const previewMarker = "<!-- release-notes-preview -->"
+
+// previewRange fills in the range of the release notes of the release
+// preparation PR cfg.PreviewPR that were not given: the head defaults to
+// the head of the PR and the base to the most recent release of the branch
+// targeted by the PR.
+func previewRange(ctx context.Context, ghClient *gh.Client, cfg *types.Config) error {
+	pr, _, err := ghClient.PullRequests.Get(ctx, cfg.Owner, cfg.Repo, cfg.PreviewPR)
+	if err != nil {
+		return fmt.Errorf("unable to get PR %d: %w", cfg.PreviewPR, err)
+	}
+	if len(cfg.Head) == 0 {
+		cfg.Head = pr.GetHead().GetSHA()
+	}
+	if len(cfg.Base) != 0 || len(cfg.SinceLatestRelease) != 0 {
+		return nil
+	}
+	branch, err := version.Parse(pr.GetBase().GetRef())
+	if err != nil {
+		return fmt.Errorf("unable to find the release branch of PR %d targeting %q, use --base", cfg.PreviewPR, pr.GetBase().GetRef())
+	}
+	cfg.SinceLatestRelease = branch.MinorString()
+	return nil
+}
+
+// postPreview posts, or updates, the preview of the release notes as a
+// comment of the release preparation PR.
+func (cl *ChangeLog) postPreview(ctx context.Context, notes []byte) error {
+	body := fmt.Sprintf("### Release notes preview\n\nGenerated for `%s...%s`.\n\n%s", cl.Base, cl.Head, notes)
+	comment, err := github.UpsertComment(ctx, cl.ghClient, cl.Owner, cl.Repo, cl.PreviewPR, previewMarker, body)
+	if err != nil {
+		return fmt.Errorf("unable to post preview: %w", err)
+	}
+	fmt.Fprintf(os.Stderr, "Release notes preview posted at %s\n", comment.GetHTMLURL())
+	return nil
+}
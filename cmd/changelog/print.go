@@ -0,0 +1,904 @@
+// Copyright 2020-2021 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package changelog
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	flag "github.com/spf13/pflag"
+
+	"github.com/cilium/release/pkg/actions"
+	"github.com/cilium/release/pkg/artifact"
+	"github.com/cilium/release/pkg/profile"
+	"github.com/cilium/release/pkg/report"
+	"github.com/cilium/release/pkg/types"
+	"github.com/cilium/release/pkg/version"
+)
+
+// Entry is a single entry of the release notes.
+type Entry struct {
+	// PR is the number of the PR that introduced the change.
+	PR int
+	// BackportPRs are the numbers, sorted, of the backport PRs through
+	// which PR was merged. It is empty if PR was not merged through a
+	// backport PR.
+	BackportPRs []int
+	// Author is the GitHub login of the author of PR.
+	Author string
+	// AuthorDisplay, if set, is shown instead of the mention of Author.
+	AuthorDisplay string
+	ReleaseNote   string
+	// Labels are the labels of PR, if known.
+	Labels []string
+	// Community is set if PR was authored by someone outside of the
+	// maintainers of the repository.
+	Community bool
+	// Marker, if set, is shown before the release note, e.g. to highlight
+	// community contributions.
+	Marker string
+	// MissingReleaseNote is set if PR has no release note, in which case
+	// ReleaseNote is its title.
+	MissingReleaseNote bool
+	// Commit, if set, is the SHA of the commit that introduced the change,
+	// for the changes that don't reference any PR.
+	Commit string
+	// Repo, if set, is the repository of PR and BackportPRs, e.g.
+	// 'cilium/cilium-security', when it isn't the released one.
+	Repo string
+	// UpstreamRepo, if set, is the repository of PR, e.g. 'cilium/cilium',
+	// when it is the upstream PR of a backport into another repository.
+	UpstreamRepo string
+	// Docs are the links to the documentation of the areas of PR.
+	Docs []DocLink
+	// Reviewers, if set, are the logins of the users who approved PR.
+	Reviewers []string
+	// Risk, if set, is shown after the release note.
+	Risk *types.Risk
+	// Components, if set, maps the components touched by PR to their
+	// number of files changed.
+	Components map[string]int
+	// MergedAt is when PR was merged, if known.
+	MergedAt time.Time
+	// Duplicates are the entries of the other PRs with the same release
+	// note, merged into this one with MergeDuplicates.
+	Duplicates []Entry
+}
+
+// DocLink is a link to the documentation of an area, e.g. 'clustermesh'.
+type DocLink struct {
+	Area string
+	URL  string
+}
+
+func (l DocLink) String() string {
+	return fmt.Sprintf("[%s docs](%s)", l.Area, l.URL)
+}
+
+// ref returns the reference to the change, e.g. '#123'.
+func (e Entry) ref() string {
+	if len(e.Commit) != 0 {
+		return fmt.Sprintf("%.7s", e.Commit)
+	}
+	if len(e.UpstreamRepo) != 0 {
+		return fmt.Sprintf("%s#%d", e.UpstreamRepo, e.PR)
+	}
+	return e.prRef(e.PR)
+}
+
+// prRef returns the reference to the given PR of the entry, e.g. '#123' or
+// 'cilium/cilium-security#123'.
+func (e Entry) prRef(number int) string {
+	return fmt.Sprintf("%s#%d", e.Repo, number)
+}
+
+// authorRef returns the mention of the author of the entry, e.g. '@alice'.
+func (e Entry) authorRef() string {
+	if len(e.AuthorDisplay) != 0 {
+		return e.AuthorDisplay
+	}
+	return "@" + e.Author
+}
+
+// String returns the entry as it is written in the release notes.
+func (e Entry) String() string {
+	author := e.authorRef()
+	prs := []string{e.ref()}
+	backportPRs := make([]string, 0, len(e.BackportPRs))
+	for _, backportPR := range e.BackportPRs {
+		backportPRs = append(backportPRs, e.prRef(backportPR))
+	}
+	authors := map[string]bool{author: true}
+	for _, d := range e.Duplicates {
+		prs = append(prs, d.ref())
+		for _, backportPR := range d.BackportPRs {
+			backportPRs = append(backportPRs, d.prRef(backportPR))
+		}
+		if a := d.authorRef(); !authors[a] {
+			authors[a] = true
+			author += ", " + a
+		}
+	}
+	releaseNote := e.ReleaseNote
+	if len(e.Marker) != 0 {
+		releaseNote = e.Marker + " " + releaseNote
+	}
+	if len(e.Reviewers) != 0 {
+		reviewers := make([]string, 0, len(e.Reviewers))
+		for _, reviewer := range e.Reviewers {
+			reviewers = append(reviewers, "@"+reviewer)
+		}
+		author += ", reviewed by " + strings.Join(reviewers, ", ")
+	}
+	if e.Risk != nil {
+		releaseNote += " " + riskAnnotation(*e.Risk)
+	}
+	if len(e.Docs) != 0 {
+		links := make([]string, 0, len(e.Docs))
+		for _, l := range e.Docs {
+			links = append(links, l.String())
+		}
+		releaseNote += " (" + strings.Join(links, ", ") + ")"
+	}
+	if len(backportPRs) != 0 {
+		return fmt.Sprintf("* %s (Backport PR %s, Upstream PR %s, %s)",
+			releaseNote, strings.Join(backportPRs, ", "), strings.Join(prs, ", "), author)
+	}
+	return fmt.Sprintf("* %s (%s, %s)", releaseNote, strings.Join(prs, ", "), author)
+}
+
+// Section is a category of the release notes, e.g. the bugfixes.
+type Section struct {
+	// Label is the release note label of the PRs of the section, e.g.
+	// 'release-note/bug'.
+	Label  string
+	Header string
+	// Docs, if set, is the URL of the documentation of the section.
+	Docs    string
+	Entries []Entry
+}
+
+// newEntry returns the entry of the given PR. backportPR is 0 for PRs that
+// were not merged through a backport PR.
+func newEntry(backportPR, prNumber int, pr types.PullRequest) Entry {
+	e := Entry{
+		PR:          prNumber,
+		Author:      pr.AuthorName,
+		ReleaseNote: pr.ReleaseNote,
+		Labels:      pr.Labels,
+		Community:   pr.IsCommunity(),
+		MergedAt:    pr.MergedAt,
+
+		MissingReleaseNote: pr.MissingReleaseNote,
+		Commit:             pr.Commit,
+		Repo:               pr.Repo,
+	}
+	if backportPR != 0 {
+		e.BackportPRs = []int{backportPR}
+	}
+	return e
+}
+
+// entry returns the entry of the given PR with its author shown as set in
+// the authors mapping and, if enabled, the community marker.
+func (cl *ChangeLog) entry(backportPR, prNumber int, pr types.PullRequest) Entry {
+	e := newEntry(backportPR, prNumber, pr)
+	if backportPR != 0 && len(e.Repo) == 0 && len(cl.UpstreamRepo) != 0 &&
+		(cl.UpstreamOwner != cl.Owner || cl.UpstreamRepo != cl.Repo) {
+		e.UpstreamRepo = cl.UpstreamOwner + "/" + cl.UpstreamRepo
+	}
+	e.AuthorDisplay, _ = cl.authors.Display(e.Author)
+	if cl.NormalizeNotes {
+		e.ReleaseNote = normalizeNote(e.ReleaseNote)
+	}
+	e.Docs = cl.docLinks(e.Labels)
+	if cl.CreditReviewers {
+		e.Reviewers = pr.Reviewers
+	}
+	if cl.AnnotateRisk {
+		e.Risk = pr.Risk
+	}
+	if cl.ComponentsSummary {
+		e.Components = pr.Components
+	}
+	if e.Community {
+		e.Marker = cl.CommunityMarker
+	}
+	return e
+}
+
+// scheme returns the label scheme of the repository, see types.Config.Profile.
+func (cl *ChangeLog) scheme() profile.Profile {
+	p, err := profile.Get(cl.Profile)
+	if err != nil {
+		p, _ = profile.Get(profile.Default)
+	}
+	return p
+}
+
+// releaseLabel returns the label of the section the PR is listed in. The
+// PRs whose labels are unknown, e.g. parsed from published release notes,
+// are listed in the section of their release label. If ConventionalCommits
+// is set, the PRs without any section label are categorized from their
+// title.
+func (cl *ChangeLog) releaseLabel(pr types.PullRequest) string {
+	if len(pr.Labels) == 0 && len(pr.Title) == 0 {
+		return pr.ReleaseLabel
+	}
+	p := cl.scheme()
+	if lbl, ok := p.SectionLabel(pr.Labels); ok {
+		return lbl
+	}
+	if cl.ConventionalCommits {
+		if lbl, ok := p.ConventionalLabel(pr.Title); ok {
+			return lbl
+		}
+	}
+	return p.DefaultLabel
+}
+
+// backportedToLastStable returns true if the PR was backported to any of the
+// last stable branches.
+func (cl *ChangeLog) backportedToLastStable(pr types.PullRequest) bool {
+	return len(cl.lastStableBackports(pr)) != 0
+}
+
+// lastStableBackports returns the backport labels of the PR, e.g.
+// 'backport-done/1.13', of the last stable branches.
+func (cl *ChangeLog) lastStableBackports(pr types.PullRequest) []string {
+	backportBranches := pr.BackportBranches
+	if len(pr.Labels) != 0 {
+		backportBranches = cl.scheme().BackportBranches(pr.Labels)
+	}
+	var series []version.Series
+	for _, lastStable := range cl.LastStable {
+		// The last stable versions were validated by Sanitize.
+		if s, err := version.ParseSeries(lastStable); err == nil {
+			series = append(series, s)
+		}
+	}
+	var lbls []string
+	for _, bb := range backportBranches {
+		v, ok := branchVersion(bb)
+		if !ok {
+			continue
+		}
+		for _, s := range series {
+			if s.Contains(v) {
+				lbls = append(lbls, bb)
+				break
+			}
+		}
+	}
+	return lbls
+}
+
+// branchVersion returns the 'x.y' version of the branch of a backport label,
+// e.g. 1.13 for 'backport-done/1.13' or 'cherry-picked/v1.13'.
+func branchVersion(lbl string) (version.Version, bool) {
+	branch := strings.TrimPrefix(lbl[strings.LastIndex(lbl, "/")+1:], "v")
+	v, err := version.Parse(branch)
+	if err != nil || v.MinorString() != branch {
+		return version.Version{}, false
+	}
+	return v, true
+}
+
+// RenderOptions are the options used to render the release notes.
+type RenderOptions struct {
+	// Notice, if not nil, is where the PRs that were not included in the
+	// release notes, as they were backported to the last stable branches,
+	// are written.
+	Notice io.Writer
+	// Format is the format of the release notes, FormatMarkdown if empty.
+	Format string
+	// Top is the number of entries of the FormatSummary format.
+	Top int
+	// PriorityLabels are the labels, by decreasing priority, of the entries
+	// listed first in the FormatSummary format.
+	PriorityLabels []string
+	// Reactions, if not nil, returns the number of reactions of the PR of
+	// an entry, by which the entries of the FormatSummary format are
+	// ranked after PriorityLabels.
+	Reactions func(Entry) int
+	// MaxSize, if not 0, is the size in bytes above which the low-value
+	// sections, see profile.Profile.CollapsibleLabels, are replaced by
+	// their count.
+	MaxSize int
+	// FullList is where the full list of changes can be found when
+	// sections are collapsed, e.g. 'CHANGELOG.md'.
+	FullList string
+	// Date is the release date shown by the FormatKeepAChangelog format,
+	// now if zero.
+	Date time.Time
+	// SkipLabels are the release note labels, e.g. 'release-note/ci', of
+	// the sections left out of the release notes.
+	SkipLabels []string
+}
+
+// writeBudget writes the release notes into buf, collapsing as many
+// sections as needed to fit in opts.MaxSize. The release notes can still
+// exceed opts.MaxSize once all collapsible sections are collapsed.
+func (cl *ChangeLog) writeBudget(buf *bytes.Buffer, opts RenderOptions) error {
+	collapsible := cl.scheme().CollapsibleLabels
+	collapsed := map[string]bool{}
+	for i := 0; ; i++ {
+		buf.Reset()
+		if _, err := cl.writeNotes(buf, opts, collapsed); err != nil {
+			return err
+		}
+		if opts.MaxSize == 0 || buf.Len() <= opts.MaxSize || i == len(collapsible) {
+			return nil
+		}
+		collapsed[collapsible[i]] = true
+	}
+}
+
+const (
+	FormatMarkdown = "markdown"
+	FormatSummary  = "summary"
+	// FormatKeepAChangelog is the format of https://keepachangelog.com.
+	FormatKeepAChangelog = "keepachangelog"
+	// FormatCSV lists the entries as CSV, one row per entry.
+	FormatCSV = "csv"
+	// FormatRelnotes is the JSON format of the Kubernetes release notes,
+	// as consumed by https://relnotes.k8s.io.
+	FormatRelnotes = "relnotes"
+)
+
+// PrintReleaseNotes prints the release notes, between the header and footer
+// of the document configured in cl.ConfigFile if any, into stdout, or into
+// cl.Output and cl.Sinks if set, and the PRs that were excluded from them into
+// stderr. If cl.PreviewPR, or cl.TrackingIssue, is set, the release notes
+// are also posted as a comment of that PR, or issue. If cl.StrictLabels is set, it fails if any PR has
+// several release note labels.
+func (cl *ChangeLog) PrintReleaseNotes(ctx context.Context) error {
+	if cl.StrictLabels {
+		if entries := cl.multipleReleaseLabels(); len(entries) != 0 {
+			lines := make([]string, 0, len(entries))
+			for _, entry := range entries {
+				lines = append(lines, entry.String())
+			}
+			return fmt.Errorf("%d PRs have several release note labels, keep only one of them:\n%s", len(entries), strings.Join(lines, "\n"))
+		}
+	}
+	notes, err := cl.Render(RenderOptions{
+		Notice:         os.Stderr,
+		Format:         cl.Format,
+		Top:            cl.Top,
+		PriorityLabels: cl.PriorityLabels,
+		Reactions:      cl.reactions(ctx),
+		MaxSize:        cl.MaxSize,
+		FullList:       cl.FullList,
+		SkipLabels:     cl.skipLabels(cl.Format),
+	})
+	if err != nil {
+		return fmt.Errorf("unable to render release notes: %w", err)
+	}
+	page, notes, err := cl.pages(notes, time.Now())
+	if err != nil {
+		return err
+	}
+	if len(cl.Output) != 0 {
+		err = os.WriteFile(cl.Output, page, 0644)
+		if err != nil {
+			return fmt.Errorf("unable to write release notes: %w", err)
+		}
+		report.Written(cl.Output)
+		if err := cl.verifiable(); err != nil {
+			return err
+		}
+	} else if len(cl.Sinks) == 0 {
+		os.Stdout.Write(page)
+	}
+	if err := cl.publish(ctx, page, notes); err != nil {
+		return err
+	}
+
+	if cl.SkipCIChanges && len(cl.CIChangesFile) != 0 {
+		if err := cl.writeSectionFile(cl.CIChangesFile, cl.scheme().CILabel); err != nil {
+			return fmt.Errorf("unable to write CI changes: %w", err)
+		}
+	}
+
+	if len(cl.ExcludedFile) != 0 {
+		if err := cl.writeExcludedFile(cl.ExcludedFile); err != nil {
+			return fmt.Errorf("unable to write excluded PRs: %w", err)
+		}
+	}
+	if len(cl.ExcludedReport) != 0 {
+		if err := cl.writeExcludedReport(cl.ExcludedReport); err != nil {
+			return fmt.Errorf("unable to write excluded PRs report: %w", err)
+		}
+	}
+
+	if len(cl.UpgradeNotesFile) != 0 {
+		if err := cl.writeUpgradeNotes(cl.UpgradeNotesFile); err != nil {
+			return fmt.Errorf("unable to write upgrade notes: %w", err)
+		}
+	}
+
+	if len(cl.OrphansReport) != 0 {
+		if err := cl.writeOrphansReport(cl.OrphansReport); err != nil {
+			return fmt.Errorf("unable to write orphans report: %w", err)
+		}
+	}
+
+	if len(cl.ProvenanceFile) != 0 {
+		if err := cl.writeProvenance(ctx, cl.ProvenanceFile); err != nil {
+			return fmt.Errorf("unable to write provenance: %w", err)
+		}
+	}
+
+	if cl.PreviewPR != 0 {
+		if err := cl.postPreview(ctx, notes); err != nil {
+			return err
+		}
+	}
+
+	if len(cl.TrackingIssue) != 0 {
+		if err := cl.postTrackingIssue(ctx, notes); err != nil {
+			return err
+		}
+	}
+
+	if cl.GitHubActions {
+		return cl.reportGitHubActions(notes)
+	}
+	return nil
+}
+
+// verifiable adds the checksum of cl.Output into cl.ChecksumsFile and signs
+// it, if requested.
+func (cl *ChangeLog) verifiable() error {
+	if len(cl.ChecksumsFile) != 0 {
+		if err := artifact.AddChecksum(cl.ChecksumsFile, cl.Output); err != nil {
+			return fmt.Errorf("unable to write checksum: %w", err)
+		}
+	}
+	if len(cl.Sign) != 0 {
+		files, err := artifact.Sign(cl.Sign, cl.Output)
+		if err != nil {
+			return fmt.Errorf("unable to sign release notes: %w", err)
+		}
+		fmt.Fprintf(os.Stderr, "Release notes signed into %s\n", strings.Join(files, ", "))
+	}
+	return nil
+}
+
+// writeProvenance writes into file the SLSA provenance of the release notes,
+// of the other files written along with them and of cl.ProvenanceArtifacts.
+// The provenance is signed like the release notes and uploaded, with its
+// signatures, as assets of the releases of cl.Sinks.
+func (cl *ChangeLog) writeProvenance(ctx context.Context, file string) error {
+	files := []string{cl.Output}
+	if cl.SkipCIChanges && len(cl.CIChangesFile) != 0 {
+		files = append(files, cl.CIChangesFile)
+	}
+	for _, f := range []string{cl.UpgradeNotesFile, cl.ExcludedFile, cl.ExcludedReport, cl.OrphansReport} {
+		if len(f) != 0 {
+			files = append(files, f)
+		}
+	}
+	files = append(files, cl.ProvenanceArtifacts...)
+	owner, repo := cl.Owner, cl.Repo
+	if len(cl.ForkOwner) != 0 {
+		owner, repo = cl.ForkOwner, cl.ForkRepo
+	}
+	sha := cl.headSHA
+	if len(sha) == 0 {
+		// The state was restored without the commits, e.g. in search
+		// mode, so the head is resolved now.
+		var err error
+		sha, _, err = cl.ghClient.Repositories.GetCommitSHA1(ctx, owner, repo, cl.Head, "")
+		if err != nil {
+			return fmt.Errorf("unable to resolve %s: %w", cl.Head, err)
+		}
+	}
+	err := artifact.WriteProvenance(file, files, artifact.Build{
+		Parameters: map[string]string{
+			"repository": cl.Owner + "/" + cl.Repo,
+			"base":       cl.Base,
+			"head":       cl.Head,
+		},
+		Source:     fmt.Sprintf("git+https://github.com/%s/%s", owner, repo),
+		SourceSHA:  sha,
+		BuilderID:  actions.RunURL(),
+		FinishedOn: time.Now().UTC(),
+	})
+	if err != nil {
+		return err
+	}
+	assets := []string{file}
+	if len(cl.Sign) != 0 {
+		sigs, err := artifact.Sign(cl.Sign, file)
+		if err != nil {
+			return fmt.Errorf("unable to sign provenance: %w", err)
+		}
+		fmt.Fprintf(os.Stderr, "Provenance signed into %s\n", strings.Join(sigs, ", "))
+		assets = append(assets, sigs...)
+	}
+	for _, s := range cl.Sinks {
+		sk, err := types.ParseSink(s)
+		if err != nil || sk.Kind != types.SinkRelease {
+			continue
+		}
+		if err := cl.uploadReleaseAssets(ctx, sk.Target, assets); err != nil {
+			return fmt.Errorf("unable to upload provenance to release %s: %w", sk.Target, err)
+		}
+	}
+	return cl.Gate.Report(ctx)
+}
+
+// AddSkipFlags adds the --skip-ci-changes and --skip-none flags, leaving
+// sections out of the release notes, to fs.
+func AddSkipFlags(fs *flag.FlagSet, cfg *types.Config) {
+	fs.BoolVar(&cfg.SkipCIChanges, "skip-ci-changes", false, "Leave the CI changes out of the release notes")
+	fs.BoolVar(&cfg.SkipNone, "skip-none", false, fmt.Sprintf("Leave the Other Changes, i.e. the PRs labeled release-note/none, out of the release notes, except in the %q JSON format", FormatRelnotes))
+}
+
+// skipLabels returns the release note labels left out of the release notes
+// rendered in format. The Other Changes left out with SkipNone are kept in
+// the JSON format, FormatRelnotes, for the tools consuming all the entries.
+func (cl *ChangeLog) skipLabels(format string) []string {
+	var lbls []string
+	if cl.SkipCIChanges {
+		lbls = append(lbls, cl.scheme().CILabel)
+	}
+	if cl.SkipNone && format != FormatRelnotes {
+		lbls = append(lbls, cl.scheme().DefaultLabel)
+	}
+	return lbls
+}
+
+// writeSectionFile writes the section of the given release note label into
+// file.
+func (cl *ChangeLog) writeSectionFile(file, label string) error {
+	var sections []Section
+	for _, section := range cl.Sections() {
+		if section.Label == label {
+			sections = append(sections, section)
+		}
+	}
+	var buf bytes.Buffer
+	writeSections(&buf, sections)
+	if err := os.WriteFile(file, buf.Bytes(), 0644); err != nil {
+		return err
+	}
+	report.Written(file)
+	return nil
+}
+
+// Render returns the release notes.
+func (cl *ChangeLog) Render(opts RenderOptions) ([]byte, error) {
+	var buf bytes.Buffer
+	switch opts.Format {
+	case "", FormatMarkdown:
+		if err := cl.writeBudget(&buf, opts); err != nil {
+			return nil, err
+		}
+	case FormatKeepAChangelog:
+		cl.writeKeepAChangelog(&buf, opts)
+	case FormatSummary:
+		fmt.Fprintln(&buf, cl.Summary(opts.Top, opts.PriorityLabels, opts.Reactions))
+	case FormatCSV:
+		if err := cl.writeCSV(&buf, opts); err != nil {
+			return nil, err
+		}
+	case FormatRelnotes:
+		if err := cl.writeRelnotes(&buf, opts); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unknown format %q", opts.Format)
+	}
+	if opts.Notice != nil {
+		if err := cl.writeNotice(opts.Notice); err != nil {
+			return nil, err
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+// Sections returns the non-empty sections of the release notes, in the
+// order they are rendered, with their entries sorted.
+func (cl *ChangeLog) Sections() []Section {
+	return cl.sections(false)
+}
+
+// ExcludedSections returns the sections of the PRs that were not included
+// in the release notes as they were backported to the last stable branches.
+func (cl *ChangeLog) ExcludedSections() []Section {
+	return cl.sections(true)
+}
+
+func (cl *ChangeLog) sections(excluded bool) []Section {
+	var sections []Section
+	for _, s := range cl.scheme().Sections {
+		releaseLabel := s.Label
+		var entries []Entry
+		if !excluded {
+			// An upstream PR can be backported through several backport
+			// PRs, e.g. if its backport was split. It is listed once with
+			// all its backport PRs.
+			upstreamEntries := map[int]int{}
+			for backportPR, listOfPrs := range cl.prsWithUpstream {
+				for prID, pr := range listOfPrs {
+					if cl.releaseLabel(pr) != releaseLabel {
+						continue
+					}
+					if i, ok := upstreamEntries[prID]; ok {
+						entries[i].BackportPRs = append(entries[i].BackportPRs, backportPR)
+						continue
+					}
+					upstreamEntries[prID] = len(entries)
+					entries = append(entries, cl.entry(backportPR, prID, pr))
+				}
+			}
+			for _, entry := range entries {
+				sort.Ints(entry.BackportPRs)
+			}
+		}
+		for prID, pr := range cl.listOfPrs {
+			if cl.releaseLabel(pr) != releaseLabel {
+				continue
+			}
+			if cl.backportedToLastStable(pr) != excluded {
+				continue
+			}
+			entries = append(entries, cl.entry(0, prID, pr))
+		}
+		if len(entries) == 0 {
+			continue
+		}
+		if cl.MergeDuplicates {
+			entries = mergeDuplicates(entries)
+		}
+		sortEntries(entries, cl.NaturalSort)
+		sections = append(sections, Section{
+			Label:   releaseLabel,
+			Header:  s.Header,
+			Docs:    cl.docs.Sections[releaseLabel],
+			Entries: entries,
+		})
+	}
+	return sections
+}
+
+// WriteTo writes the release notes into w.
+func (cl *ChangeLog) WriteTo(w io.Writer) (int64, error) {
+	return cl.writeNotes(w, RenderOptions{}, nil)
+}
+
+// writeNotes writes the release notes into w, without the sections of
+// opts.SkipLabels. The entries of the collapsed sections are replaced by
+// their count and a reference to opts.FullList.
+func (cl *ChangeLog) writeNotes(w io.Writer, opts RenderOptions, collapsed map[string]bool) (int64, error) {
+	var buf bytes.Buffer
+
+	skip := map[string]bool{}
+	for _, lbl := range opts.SkipLabels {
+		skip[lbl] = true
+	}
+
+	sections := cl.Sections()
+	if cl.GroupBy == GroupByOwner {
+		sections = cl.groupSections(sections, skip, collapsed)
+	}
+
+	data := notesData{
+		Components:        cl.ComponentStats(),
+		Thanks:            cl.thanksLine(),
+		Reverts:           cl.reverts,
+		DependencyChanges: cl.dependencyChanges,
+		KnownIssues:       cl.knownIssues,
+	}
+	for _, section := range sections {
+		if skip[section.Label] {
+			continue
+		}
+		data.Sections = append(data.Sections, sectionData{
+			Section:   section,
+			Collapsed: collapsed[section.Label],
+			FullList:  opts.FullList,
+		})
+	}
+	if cl.PreviouslyReleased {
+		var appendix bytes.Buffer
+		cl.writePreviouslyReleased(&appendix, skip)
+		data.PreviouslyReleased = appendix.String()
+	}
+
+	t, err := cl.template()
+	if err != nil {
+		return 0, err
+	}
+	if err := t.ExecuteTemplate(&buf, "notes.md.tmpl", data); err != nil {
+		return 0, fmt.Errorf("unable to execute template: %w", err)
+	}
+	return buf.WriteTo(w)
+}
+
+// writeNotice writes into w the PRs that were not included in the release
+// notes as they were backported to the last stable branches.
+func (cl *ChangeLog) writeNotice(w io.Writer) error {
+	var buf bytes.Buffer
+	if sections := cl.ExcludedSections(); len(sections) != 0 && len(cl.ExcludedFile) != 0 {
+		n := 0
+		for _, section := range sections {
+			n += len(section.Entries)
+		}
+		fmt.Fprintf(&buf, "\n\033[1mNOTICE\033[0m: %d PRs were not included in the changelog as they were backported "+
+			"to branches %s and assumed to be already released, see %s.\n", n, strings.Join(cl.LastStable, ", "), cl.ExcludedFile)
+	} else if len(sections) != 0 {
+		fmt.Fprintf(&buf, "\n\033[1mNOTICE\033[0m: The following PRs were not included in the "+
+			"changelog as they were backported to branches %s and assumed to be already released.\n", strings.Join(cl.LastStable, ", "))
+		writeSections(&buf, sections)
+	}
+	if entries := cl.multipleReleaseLabels(); len(entries) != 0 {
+		fmt.Fprintf(&buf, "\n\033[1mNOTICE\033[0m: The following PRs have several release note labels, "+
+			"they were listed in the section of the first one.\n\n")
+		for _, entry := range entries {
+			fmt.Fprintln(&buf, entry)
+		}
+	}
+	if entries := cl.missingReleaseNotes(); len(entries) != 0 {
+		fmt.Fprintf(&buf, "\n\033[1mNOTICE\033[0m: The following PRs have a release note label "+
+			"but no release note, their title was used instead.\n\n")
+		for _, entry := range entries {
+			fmt.Fprintln(&buf, entry)
+		}
+	}
+	_, err := buf.WriteTo(w)
+	return err
+}
+
+// labelsEntry is the entry of a PR with several release note labels.
+type labelsEntry struct {
+	Entry
+	// releaseLabels are the release note labels of the PR, by decreasing
+	// priority.
+	releaseLabels []string
+}
+
+func (e labelsEntry) String() string {
+	return fmt.Sprintf("%s: %s", e.Entry, strings.Join(e.releaseLabels, ", "))
+}
+
+// multipleReleaseLabels returns the entries of the PRs that have the labels
+// of several sections, with these labels by decreasing priority.
+func (cl *ChangeLog) multipleReleaseLabels() []labelsEntry {
+	p := cl.scheme()
+	var entries []labelsEntry
+	add := func(prNumber int, pr types.PullRequest) {
+		if lbls := p.SectionLabels(pr.Labels); len(lbls) > 1 {
+			entries = append(entries, labelsEntry{Entry: cl.entry(0, prNumber, pr), releaseLabels: lbls})
+		}
+	}
+	// An upstream PR can be backported through several backport PRs.
+	upstreamPRs := types.PullRequests{}
+	for _, prs := range cl.prsWithUpstream {
+		for prNumber, pr := range prs {
+			upstreamPRs[prNumber] = pr
+		}
+	}
+	for prNumber, pr := range upstreamPRs {
+		add(prNumber, pr)
+	}
+	for prNumber, pr := range cl.listOfPrs {
+		add(prNumber, pr)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].PR < entries[j].PR
+	})
+	return entries
+}
+
+// missingReleaseNotes returns the entries of the release notes whose PR has
+// no release note.
+func (cl *ChangeLog) missingReleaseNotes() []Entry {
+	var entries []Entry
+	for _, section := range cl.Sections() {
+		for _, entry := range section.Entries {
+			if entry.MissingReleaseNote {
+				entries = append(entries, entry)
+			}
+		}
+	}
+	return entries
+}
+
+func writeSections(buf *bytes.Buffer, sections []Section) {
+	for _, section := range sections {
+		fmt.Fprintln(buf)
+		fmt.Fprintln(buf, section.Header)
+		for _, entry := range section.Entries {
+			fmt.Fprintln(buf, entry)
+		}
+	}
+}
+
+// sortKey returns the entry without its marker so that markers don't change
+// the position of the entries.
+func (e Entry) sortKey() string {
+	e.Marker = ""
+	return e.String()
+}
+
+// mergeDuplicates merges the entries with the same release note into the
+// one of the lowest PR number, the others being its Duplicates sorted by PR
+// number. The changes without any PR are never merged, and the backported
+// changes are only merged with each other.
+func mergeDuplicates(entries []Entry) []Entry {
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].PR < entries[j].PR
+	})
+	type key struct {
+		note       string
+		backported bool
+	}
+	merged := make([]Entry, 0, len(entries))
+	byNote := map[key]int{}
+	for _, entry := range entries {
+		if len(entry.Commit) != 0 {
+			merged = append(merged, entry)
+			continue
+		}
+		k := key{note: entry.ReleaseNote, backported: len(entry.BackportPRs) != 0}
+		if i, ok := byNote[k]; ok {
+			merged[i].Duplicates = append(merged[i].Duplicates, entry)
+			continue
+		}
+		byNote[k] = len(merged)
+		merged = append(merged, entry)
+	}
+	return merged
+}
+
+// flatten returns the entry, without its Duplicates, followed by them, for
+// the formats listing one PR per row.
+func (e Entry) flatten() []Entry {
+	entries := make([]Entry, 0, 1+len(e.Duplicates))
+	entry := e
+	entry.Duplicates = nil
+	return append(append(entries, entry), e.Duplicates...)
+}
+
+// sortEntries sorts the entries case-insensitively, comparing numbers by
+// their value if natural is set. Ties are broken by the case-sensitive entry
+// and then by the PR numbers so that the release notes are always rendered
+// in the same order.
+func sortEntries(entries []Entry, natural bool) {
+	sort.Slice(entries, func(i, j int) bool {
+		a, b := entries[i].sortKey(), entries[j].sortKey()
+		if natural {
+			if c := naturalCompare(a, b); c != 0 {
+				return c < 0
+			}
+		} else if la, lb := strings.ToLower(a), strings.ToLower(b); la != lb {
+			return la < lb
+		}
+		if a != b {
+			return a < b
+		}
+		return entries[i].PR < entries[j].PR
+	})
+}
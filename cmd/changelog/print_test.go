@@ -0,0 +1,599 @@
+// Copyright 2021 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package changelog
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/cilium/release/pkg/config"
+	"github.com/cilium/release/pkg/types"
+)
+
+func TestSections(t *testing.T) {
+	cl := &ChangeLog{
+		Config: types.Config{LastStable: []string{"1.13"}},
+		prsWithUpstream: types.BackportPRs{
+			200: {
+				150: {ReleaseNote: "Fix bar", ReleaseLabel: "release-note/bug", AuthorName: "bob"},
+			},
+		},
+		listOfPrs: types.PullRequests{
+			123: {ReleaseNote: "add foo", ReleaseLabel: "release-note/minor", AuthorName: "alice"},
+			124: {ReleaseNote: "Add baz", ReleaseLabel: "release-note/minor", AuthorName: "carol"},
+			125: {
+				ReleaseNote:      "Fix qux",
+				ReleaseLabel:     "release-note/bug",
+				AuthorName:       "dave",
+				BackportBranches: []string{"backport-done/1.13"},
+			},
+		},
+	}
+
+	want := []Section{
+		{
+			Label:  "release-note/minor",
+			Header: "**Minor Changes:**",
+			Entries: []Entry{
+				{PR: 124, Author: "carol", ReleaseNote: "Add baz"},
+				{PR: 123, Author: "alice", ReleaseNote: "add foo"},
+			},
+		},
+		{
+			Label:  "release-note/bug",
+			Header: "**Bugfixes:**",
+			Entries: []Entry{
+				{PR: 150, BackportPRs: []int{200}, Author: "bob", ReleaseNote: "Fix bar"},
+			},
+		},
+	}
+	wantExcluded := []Section{
+		{
+			Label:  "release-note/bug",
+			Header: "**Bugfixes:**",
+			Entries: []Entry{
+				{PR: 125, Author: "dave", ReleaseNote: "Fix qux"},
+			},
+		},
+	}
+
+	// Sections must not modify the change log, so calling it twice
+	// returns the same result.
+	for i := 0; i < 2; i++ {
+		if got := cl.Sections(); !reflect.DeepEqual(got, want) {
+			t.Errorf("Sections() = %+v, want %+v", got, want)
+		}
+		if got := cl.ExcludedSections(); !reflect.DeepEqual(got, wantExcluded) {
+			t.Errorf("ExcludedSections() = %+v, want %+v", got, wantExcluded)
+		}
+	}
+}
+
+var update = flag.Bool("update", false, "update the golden files")
+
+func TestRender(t *testing.T) {
+	cl := &ChangeLog{
+		Config: types.Config{
+			LastStable:      []string{"1.12", "1.13"},
+			CommunityMarker: ":star:",
+		},
+		prsWithUpstream: types.BackportPRs{
+			200: {
+				150: {ReleaseNote: "Fix bar", ReleaseLabel: "release-note/bug", AuthorName: "bob"},
+				151: {ReleaseNote: "fix bar", ReleaseLabel: "release-note/bug", AuthorName: "bob"},
+			},
+			201: {
+				150: {ReleaseNote: "Fix bar", ReleaseLabel: "release-note/bug", AuthorName: "bob"},
+			},
+		},
+		listOfPrs: types.PullRequests{
+			123: {ReleaseNote: "Add foo", ReleaseLabel: "release-note/minor", AuthorName: "alice"},
+			124: {ReleaseNote: "add foo", ReleaseLabel: "release-note/minor", AuthorName: "alice"},
+			129: {
+				ReleaseNote:       "Add docs",
+				ReleaseLabel:      "release-note/minor",
+				AuthorName:        "grace",
+				AuthorAssociation: "CONTRIBUTOR",
+			},
+			130: {
+				ReleaseNote:       "Add tests",
+				ReleaseLabel:      "release-note/minor",
+				AuthorName:        "heidi",
+				AuthorAssociation: "MEMBER",
+			},
+			125: {ReleaseNote: "Bump deps", ReleaseLabel: "release-note/misc", AuthorName: "carol"},
+			126: {ReleaseNote: "Improve CI", ReleaseLabel: "release-note/ci", AuthorName: "dave"},
+			131: {
+				ReleaseNote:        "docs: Fix typo",
+				ReleaseLabel:       "release-note/misc",
+				AuthorName:         "ivan",
+				MissingReleaseNote: true,
+			},
+			127: {
+				ReleaseNote:      "Fix qux",
+				ReleaseLabel:     "release-note/bug",
+				AuthorName:       "erin",
+				BackportBranches: []string{"backport-done/1.13"},
+			},
+			128: {
+				ReleaseNote:      "Add quux",
+				ReleaseLabel:     "release-note/major",
+				AuthorName:       "frank",
+				BackportBranches: []string{"backport-done/1.12"},
+			},
+		},
+		authors: config.Authors{
+			"carol": {Name: "Carol Smith"},
+			"dave":  {NoMention: true},
+		},
+		newContributors: map[string]bool{
+			"grace": true,
+			"heidi": false,
+			"frank": true,
+			"carol": true,
+		},
+	}
+
+	// Maps are iterated in random order, render several times to make
+	// sure the output doesn't depend on it.
+	for i := 0; i < 10; i++ {
+		var notice bytes.Buffer
+		notes, err := cl.Render(RenderOptions{Notice: &notice})
+		if err != nil {
+			t.Fatal(err)
+		}
+		checkGolden(t, "release-notes.golden", notes)
+		checkGolden(t, "notice.golden", notice.Bytes())
+	}
+}
+
+func checkGolden(t *testing.T, name string, got []byte) {
+	t.Helper()
+	file := filepath.Join("testdata", name)
+	if *update {
+		if err := os.WriteFile(file, got, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("%s mismatch, got:\n%s\nwant:\n%s", name, got, want)
+	}
+}
+
+func TestRenderMaxSize(t *testing.T) {
+	cl := &ChangeLog{
+		listOfPrs: types.PullRequests{
+			1: {ReleaseNote: "Fix foo", ReleaseLabel: "release-note/bug", AuthorName: "alice"},
+			2: {ReleaseNote: "Bump bar", ReleaseLabel: "release-note/misc", AuthorName: "bob"},
+			3: {ReleaseNote: "Bump baz", ReleaseLabel: "release-note/misc", AuthorName: "bob"},
+			4: {ReleaseNote: "Refactor qux", ReleaseLabel: "release-note/none", AuthorName: "carol"},
+			5: {ReleaseNote: "Refactor quux", ReleaseLabel: "release-note/none", AuthorName: "carol"},
+			6: {ReleaseNote: "Refactor corge", ReleaseLabel: "release-note/none", AuthorName: "carol"},
+		},
+	}
+	full, err := cl.Render(RenderOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		maxSize int
+		want    string
+	}{
+		{
+			name:    "fits",
+			maxSize: len(full),
+			want:    string(full),
+		},
+		{
+			name:    "collapse other changes",
+			maxSize: len(full) - 1,
+			want: "Summary of Changes\n" +
+				"------------------\n" +
+				"\n" +
+				"**Bugfixes:**\n" +
+				"* Fix foo (#1, @alice)\n" +
+				"\n" +
+				"**Misc Changes:**\n" +
+				"* Bump bar (#2, @bob)\n" +
+				"* Bump baz (#3, @bob)\n" +
+				"\n" +
+				"**Other Changes:**\n" +
+				"* 3 miscellaneous changes (full list in CHANGELOG.md)\n",
+		},
+		{
+			name:    "collapse all",
+			maxSize: 1,
+			want: "Summary of Changes\n" +
+				"------------------\n" +
+				"\n" +
+				"**Bugfixes:**\n" +
+				"* Fix foo (#1, @alice)\n" +
+				"\n" +
+				"**Misc Changes:**\n" +
+				"* 2 miscellaneous changes (full list in CHANGELOG.md)\n" +
+				"\n" +
+				"**Other Changes:**\n" +
+				"* 3 miscellaneous changes (full list in CHANGELOG.md)\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := cl.Render(RenderOptions{MaxSize: tt.maxSize, FullList: "CHANGELOG.md"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("got:\n%s\nwant:\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRenderSkipLabels(t *testing.T) {
+	cl := &ChangeLog{
+		listOfPrs: types.PullRequests{
+			1: {ReleaseNote: "Fix foo", ReleaseLabel: "release-note/bug", AuthorName: "alice"},
+			2: {ReleaseNote: "Fix flake", ReleaseLabel: "release-note/ci", AuthorName: "bob"},
+		},
+	}
+	got, err := cl.Render(RenderOptions{SkipLabels: []string{"release-note/ci"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "Summary of Changes\n" +
+		"------------------\n" +
+		"\n" +
+		"**Bugfixes:**\n" +
+		"* Fix foo (#1, @alice)\n"
+	if string(got) != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestRenderSkipNone(t *testing.T) {
+	cl := &ChangeLog{
+		Config: types.Config{Owner: "cilium", Repo: "cilium", SkipNone: true},
+		listOfPrs: types.PullRequests{
+			1: {ReleaseNote: "Fix foo", ReleaseLabel: "release-note/bug", AuthorName: "alice"},
+			2: {ReleaseNote: "Refactor bar", ReleaseLabel: "release-note/none", AuthorName: "bob"},
+		},
+	}
+	got, err := cl.Render(RenderOptions{SkipLabels: cl.skipLabels(FormatMarkdown)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(got), "Other Changes") || strings.Contains(string(got), "Refactor bar") {
+		t.Errorf("Other Changes not left out of the release notes:\n%s", got)
+	}
+	if !strings.Contains(string(got), "Fix foo") {
+		t.Errorf("Bugfixes missing from the release notes:\n%s", got)
+	}
+
+	got, err = cl.Render(RenderOptions{Format: FormatRelnotes, SkipLabels: cl.skipLabels(FormatRelnotes)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var notes map[string]relnotesNote
+	if err := json.Unmarshal(got, &notes); err != nil {
+		t.Fatal(err)
+	}
+	if notes["2"].Text != "Refactor bar" {
+		t.Errorf("Other Changes missing from the JSON release notes:\n%s", got)
+	}
+}
+
+func TestRenderProfile(t *testing.T) {
+	cl := &ChangeLog{
+		Config: types.Config{
+			Profile:    "kubernetes",
+			LastStable: []string{"1.27"},
+		},
+		listOfPrs: types.PullRequests{
+			1: {ReleaseNote: "Add foo", AuthorName: "alice", Labels: []string{"sig/network", "kind/feature"}},
+			2: {ReleaseNote: "Fix bar", AuthorName: "bob", Labels: []string{"kind/bug", "cherry-picked/1.27"}},
+			3: {ReleaseNote: "Refactor baz", AuthorName: "carol", Labels: []string{"sig/node"}},
+		},
+	}
+	got, err := cl.Render(RenderOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "Summary of Changes\n" +
+		"------------------\n" +
+		"\n" +
+		"**Features:**\n" +
+		"* Add foo (#1, @alice)\n" +
+		"\n" +
+		"**Uncategorized:**\n" +
+		"* Refactor baz (#3, @carol)\n"
+	if string(got) != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestRenderConventionalCommits(t *testing.T) {
+	cl := &ChangeLog{
+		Config: types.Config{ConventionalCommits: true},
+		listOfPrs: types.PullRequests{
+			1: {Title: "feat(cli): add foo", ReleaseNote: "Add foo to the CLI", AuthorName: "alice", Labels: []string{"area/cli"}},
+			2: {Title: "fix: bar", ReleaseNote: "Fix bar", AuthorName: "bob", Labels: []string{"release-note/misc"}},
+			3: {Title: "Update baz", ReleaseNote: "Update baz", AuthorName: "carol"},
+		},
+	}
+	got, err := cl.Render(RenderOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "Summary of Changes\n" +
+		"------------------\n" +
+		"\n" +
+		"**Minor Changes:**\n" +
+		"* Add foo to the CLI (#1, @alice)\n" +
+		"\n" +
+		"**Misc Changes:**\n" +
+		"* Fix bar (#2, @bob)\n" +
+		"\n" +
+		"**Other Changes:**\n" +
+		"* Update baz (#3, @carol)\n"
+	if string(got) != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestRenderReviewers(t *testing.T) {
+	cl := &ChangeLog{
+		Config: types.Config{CreditReviewers: true},
+		listOfPrs: types.PullRequests{
+			1: {ReleaseNote: "Add foo", ReleaseLabel: "release-note/minor", AuthorName: "alice", Reviewers: []string{"bob", "carol"}},
+			2: {ReleaseNote: "Fix bar", ReleaseLabel: "release-note/bug", AuthorName: "bob", Reviewers: []string{}},
+		},
+		prsWithUpstream: types.BackportPRs{
+			200: {
+				150: {ReleaseNote: "Fix baz", ReleaseLabel: "release-note/bug", AuthorName: "carol", Reviewers: []string{"alice"}},
+			},
+		},
+	}
+	got, err := cl.Render(RenderOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "Summary of Changes\n" +
+		"------------------\n" +
+		"\n" +
+		"**Minor Changes:**\n" +
+		"* Add foo (#1, @alice, reviewed by @bob, @carol)\n" +
+		"\n" +
+		"**Bugfixes:**\n" +
+		"* Fix bar (#2, @bob)\n" +
+		"* Fix baz (Backport PR #200, Upstream PR #150, @carol, reviewed by @alice)\n"
+	if string(got) != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestRenderUpstreamRepo(t *testing.T) {
+	cfg := types.Config{}
+	cfg.Owner, cfg.Repo = "example", "cilium-release"
+	cfg.UpstreamOwner, cfg.UpstreamRepo = "cilium", "cilium"
+	cl := &ChangeLog{
+		Config: cfg,
+		listOfPrs: types.PullRequests{
+			1: {ReleaseNote: "Fix bar", ReleaseLabel: "release-note/bug", AuthorName: "bob"},
+		},
+		prsWithUpstream: types.BackportPRs{
+			200: {
+				150: {ReleaseNote: "Fix baz", ReleaseLabel: "release-note/bug", AuthorName: "carol"},
+			},
+		},
+	}
+	got, err := cl.Render(RenderOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "Summary of Changes\n" +
+		"------------------\n" +
+		"\n" +
+		"**Bugfixes:**\n" +
+		"* Fix bar (#1, @bob)\n" +
+		"* Fix baz (Backport PR #200, Upstream PR cilium/cilium#150, @carol)\n"
+	if string(got) != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestRenderMergeDuplicates(t *testing.T) {
+	cl := &ChangeLog{
+		Config: types.Config{MergeDuplicates: true},
+		listOfPrs: types.PullRequests{
+			3: {ReleaseNote: "Add foo", ReleaseLabel: "release-note/minor", AuthorName: "bob"},
+			1: {ReleaseNote: "Add foo", ReleaseLabel: "release-note/minor", AuthorName: "alice"},
+			2: {ReleaseNote: "Add foo", ReleaseLabel: "release-note/minor", AuthorName: "alice"},
+			4: {ReleaseNote: "Add foo.", ReleaseLabel: "release-note/minor", AuthorName: "carol"},
+			// Same note, different section.
+			5: {ReleaseNote: "Add foo", ReleaseLabel: "release-note/bug", AuthorName: "dave"},
+			// Same note as backports, but not backported.
+			6: {ReleaseNote: "Fix baz", ReleaseLabel: "release-note/bug", AuthorName: "carol"},
+		},
+		prsWithUpstream: types.BackportPRs{
+			200: {150: {ReleaseNote: "Fix baz", ReleaseLabel: "release-note/bug", AuthorName: "carol"}},
+			201: {151: {ReleaseNote: "Fix baz", ReleaseLabel: "release-note/bug", AuthorName: "carol"}},
+		},
+	}
+	got, err := cl.Render(RenderOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "Summary of Changes\n" +
+		"------------------\n" +
+		"\n" +
+		"**Minor Changes:**\n" +
+		"* Add foo (#1, #2, #3, @alice, @bob)\n" +
+		"* Add foo. (#4, @carol)\n" +
+		"\n" +
+		"**Bugfixes:**\n" +
+		"* Add foo (#5, @dave)\n" +
+		"* Fix baz (#6, @carol)\n" +
+		"* Fix baz (Backport PR #200, #201, Upstream PR #150, #151, @carol)\n"
+	if string(got) != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestRenderGroupByOwner(t *testing.T) {
+	cl := &ChangeLog{
+		Config: types.Config{GroupBy: GroupByOwner},
+		listOfPrs: types.PullRequests{
+			1: {ReleaseNote: "Add foo", AuthorName: "alice", Labels: []string{"release-note/minor", "sig/policy"}},
+			2: {ReleaseNote: "Fix bar", AuthorName: "bob", Labels: []string{"release-note/bug", "sig/policy", "sig/datapath"}},
+			3: {ReleaseNote: "Fix baz", AuthorName: "carol", Labels: []string{"release-note/bug", "sig/datapath"}},
+			4: {ReleaseNote: "Bump deps", AuthorName: "dave", Labels: []string{"release-note/misc"}},
+		},
+		groups: []config.Group{
+			{Label: "sig/datapath", Name: "SIG Datapath"},
+			{Label: "sig/policy", Name: "SIG Policy"},
+			{Label: "sig/agent", Name: "SIG Agent"},
+		},
+		docs: config.Docs{
+			Sections: map[string]string{
+				"sig/policy": "https://docs.cilium.io/en/stable/security/policy/",
+			},
+		},
+	}
+	got, err := cl.Render(RenderOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "Summary of Changes\n" +
+		"------------------\n" +
+		"\n" +
+		"**SIG Datapath:**\n" +
+		"* Fix bar (#2, @bob)\n" +
+		"* Fix baz (#3, @carol)\n" +
+		"\n" +
+		"**SIG Policy:**\n" +
+		"See the [documentation](https://docs.cilium.io/en/stable/security/policy/).\n" +
+		"* Add foo (#1, @alice)\n" +
+		"\n" +
+		"**Unowned Changes:**\n" +
+		"* Bump deps (#4, @dave)\n"
+	if string(got) != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestRenderDocs(t *testing.T) {
+	cl := &ChangeLog{
+		listOfPrs: types.PullRequests{
+			1: {Title: "Add foo", ReleaseNote: "Add foo", AuthorName: "alice", Labels: []string{"release-note/minor", "area/clustermesh", "area/bgp"}},
+			2: {Title: "Fix bar", ReleaseNote: "Fix bar", AuthorName: "bob", Labels: []string{"release-note/bug", "area/cli"}},
+		},
+		docs: config.Docs{
+			Areas: map[string]string{
+				"area/clustermesh": "https://docs.cilium.io/en/stable/network/clustermesh/",
+				"area/bgp":         "https://docs.cilium.io/en/stable/network/bgp/",
+			},
+			Sections: map[string]string{
+				"release-note/bug": "https://docs.cilium.io/en/stable/operations/upgrade/",
+			},
+		},
+	}
+	got, err := cl.Render(RenderOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "Summary of Changes\n" +
+		"------------------\n" +
+		"\n" +
+		"**Minor Changes:**\n" +
+		"* Add foo ([bgp docs](https://docs.cilium.io/en/stable/network/bgp/), [clustermesh docs](https://docs.cilium.io/en/stable/network/clustermesh/)) (#1, @alice)\n" +
+		"\n" +
+		"**Bugfixes:**\n" +
+		"See the [documentation](https://docs.cilium.io/en/stable/operations/upgrade/).\n" +
+		"* Fix bar (#2, @bob)\n"
+	if string(got) != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+
+	// The links are part of the release note once published.
+	_, prs := ParseReleaseNotes(string(got))
+	if note := prs[1].ReleaseNote; !strings.HasPrefix(note, "Add foo ([bgp docs]") {
+		t.Errorf("parsed release note %q, want it to keep the links", note)
+	}
+}
+
+func TestMultipleReleaseLabels(t *testing.T) {
+	cl := &ChangeLog{
+		prsWithUpstream: types.BackportPRs{
+			200: {
+				150: {ReleaseNote: "Fix bar", AuthorName: "bob", Labels: []string{"release-note/none", "release-note/bug"}},
+			},
+		},
+		listOfPrs: types.PullRequests{
+			123: {ReleaseNote: "Add foo", AuthorName: "alice", Labels: []string{"release-note/bug", "release-note/minor"}},
+			124: {ReleaseNote: "Add baz", AuthorName: "carol", Labels: []string{"release-note/minor", "area/cli"}},
+		},
+	}
+	var got []string
+	for _, entry := range cl.multipleReleaseLabels() {
+		got = append(got, entry.String())
+	}
+	want := []string{
+		"* Add foo (#123, @alice): release-note/minor, release-note/bug",
+		"* Fix bar (#150, @bob): release-note/bug, release-note/none",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("multipleReleaseLabels() = %q, want %q", got, want)
+	}
+
+	cl.StrictLabels = true
+	if err := cl.PrintReleaseNotes(context.Background()); err == nil {
+		t.Error("expected an error with StrictLabels")
+	}
+}
+
+func TestBackportedToLastStable(t *testing.T) {
+	cl := &ChangeLog{Config: types.Config{LastStable: []string{"1.13", "1.12"}}}
+	tests := []struct {
+		name             string
+		backportBranches []string
+		want             bool
+	}{
+		{name: "first branch", backportBranches: []string{"backport-done/1.13"}, want: true},
+		{name: "second branch", backportBranches: []string{"backport-done/1.12"}, want: true},
+		{name: "older branch", backportBranches: []string{"backport-done/1.11"}},
+		{name: "not backported"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pr := types.PullRequest{ReleaseLabel: "release-note/bug", BackportBranches: tt.backportBranches}
+			if got := cl.backportedToLastStable(pr); got != tt.want {
+				t.Errorf("backportedToLastStable(%v) = %v, want %v", tt.backportBranches, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,91 @@
+// Copyright 2021 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package changelog
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/cilium/release/pkg/github"
+)
+
+// excludePublished removes from the changelog all PRs mentioned in the
+// published releases of the cl.ExcludePublished branch.
+func (cl *ChangeLog) excludePublished(ctx context.Context) error {
+	releases, err := github.ListBranchReleases(ctx, cl.ghClient, cl.Owner, cl.Repo, cl.ExcludePublished)
+	if err != nil {
+		return err
+	}
+
+	published := map[int]struct{}{}
+	for _, release := range releases {
+		backportPRs, prs := parseReleaseNotes(release.GetBody(), cl.scheme())
+		for prNumber := range prs {
+			published[prNumber] = struct{}{}
+		}
+		for backportPR, upstreamPRs := range backportPRs {
+			published[backportPR] = struct{}{}
+			for prNumber := range upstreamPRs {
+				published[prNumber] = struct{}{}
+			}
+		}
+	}
+
+	excluded := cl.removePRs(published, "published in "+cl.ExcludePublished)
+	fmt.Fprintf(os.Stderr, "Excluded %d PRs already mentioned in %d published releases of %s\n", excluded, len(releases), cl.ExcludePublished)
+	return nil
+}
+
+// removePRs removes the given PRs from the changelog, for the given reason,
+// and returns how many entries were removed. Removing a backport PR removes
+// all its upstream PRs.
+func (cl *ChangeLog) removePRs(prNumbers map[int]struct{}, reason string) int {
+	removed := 0
+	for prNumber := range cl.listOfPrs {
+		if _, ok := prNumbers[prNumber]; ok {
+			delete(cl.listOfPrs, prNumber)
+			cl.markRemoved(prNumber, reason)
+			removed++
+		}
+	}
+	for backportPR, upstreamPRs := range cl.prsWithUpstream {
+		if _, ok := prNumbers[backportPR]; ok {
+			removed += len(upstreamPRs)
+			delete(cl.prsWithUpstream, backportPR)
+			cl.markRemoved(backportPR, reason)
+			continue
+		}
+		for prNumber := range upstreamPRs {
+			if _, ok := prNumbers[prNumber]; ok {
+				delete(upstreamPRs, prNumber)
+				removed++
+			}
+		}
+		if len(upstreamPRs) == 0 {
+			delete(cl.prsWithUpstream, backportPR)
+			cl.markRemoved(backportPR, reason)
+		}
+	}
+	return removed
+}
+
+// markRemoved records why the given PR was removed from the changelog.
+func (cl *ChangeLog) markRemoved(prNumber int, reason string) {
+	if cl.removed == nil {
+		cl.removed = map[int]string{}
+	}
+	cl.removed[prNumber] = reason
+}
@@ -0,0 +1,52 @@
+// Copyright 2021 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package changelog
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/cilium/release/pkg/types"
+)
+
+func TestRemovePRs(t *testing.T) {
+	cl := &ChangeLog{
+		prsWithUpstream: types.BackportPRs{
+			200: {150: {}, 151: {}},
+			201: {152: {}},
+			202: {153: {}},
+		},
+		listOfPrs: types.PullRequests{
+			123: {},
+			124: {},
+		},
+	}
+
+	// 201 is a published backport PR, 153 an upstream PR published
+	// through another backport PR and 123 a published PR.
+	removed := cl.removePRs(map[int]struct{}{201: {}, 153: {}, 150: {}, 123: {}}, "published in 1.14")
+	if removed != 4 {
+		t.Errorf("removePRs() = %d, want 4", removed)
+	}
+
+	wantBackportPRs := types.BackportPRs{200: {151: {}}}
+	if !reflect.DeepEqual(cl.prsWithUpstream, wantBackportPRs) {
+		t.Errorf("got backport PRs %v, want %v", cl.prsWithUpstream, wantBackportPRs)
+	}
+	wantPRs := types.PullRequests{124: {}}
+	if !reflect.DeepEqual(cl.listOfPrs, wantPRs) {
+		t.Errorf("got PRs %v, want %v", cl.listOfPrs, wantPRs)
+	}
+}
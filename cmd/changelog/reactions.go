@@ -0,0 +1,55 @@
+// Copyright 2021 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package changelog
+
+import (
+	"context"
+	"fmt"
+	"os"
+)
+
+// reactions returns the function looking up the number of 👍 and 🎉
+// reactions of the PR of an entry, or nil if cl.RankByReactions isn't set or
+// the summary format isn't used. The reactions are fetched when first
+// needed and the PRs whose reactions can't be fetched have none.
+func (cl *ChangeLog) reactions(ctx context.Context) func(Entry) int {
+	if !cl.RankByReactions || cl.Format != FormatSummary || cl.ghClient == nil {
+		return nil
+	}
+	counts := map[string]int{}
+	return func(e Entry) int {
+		if len(e.Commit) != 0 || len(e.Repo) != 0 {
+			return 0
+		}
+		// The PR of a backport entry is its upstream PR.
+		owner, repo := cl.Owner, cl.Repo
+		if len(e.BackportPRs) != 0 {
+			owner, repo = cl.UpstreamOwner, cl.UpstreamRepo
+		}
+		key := fmt.Sprintf("%s/%s#%d", owner, repo, e.PR)
+		if n, ok := counts[key]; ok {
+			return n
+		}
+		issue, _, err := cl.ghClient.Issues.Get(ctx, owner, repo, e.PR)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Unable to get the reactions of PR %s: %s\n", key, err)
+			counts[key] = 0
+			return 0
+		}
+		r := issue.GetReactions()
+		counts[key] = r.GetPlusOne() + r.GetHooray()
+		return counts[key]
+	}
+}
@@ -0,0 +1,120 @@
+// Copyright 2021 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package changelog
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+const (
+	kindLabelPrefix = "kind/"
+	sigLabelPrefix  = "sig/"
+)
+
+// relnotesNote is a release note in the JSON format of the Kubernetes
+// release notes, as consumed by https://relnotes.k8s.io.
+type relnotesNote struct {
+	Text           string         `json:"text"`
+	Markdown       string         `json:"markdown"`
+	Documentation  []relnotesDocs `json:"documentation,omitempty"`
+	Author         string         `json:"author"`
+	AuthorURL      string         `json:"author_url"`
+	PRURL          string         `json:"pr_url"`
+	PRNumber       int            `json:"pr_number"`
+	Areas          []string       `json:"areas,omitempty"`
+	Kinds          []string       `json:"kinds,omitempty"`
+	SIGs           []string       `json:"sigs,omitempty"`
+	ActionRequired bool           `json:"action_required,omitempty"`
+	ReleaseVersion string         `json:"release_version,omitempty"`
+}
+
+// relnotesDocs is a link to the documentation of a release note.
+type relnotesDocs struct {
+	Description string `json:"description"`
+	URL         string `json:"url"`
+	Type        string `json:"type"`
+}
+
+// writeRelnotes writes the entries of the release notes in the JSON format
+// of the Kubernetes release notes, keyed by PR number. The kind of an entry
+// is the last part of the label of its section, e.g. 'bug' for
+// 'release-note/bug', followed by its own 'kind/*' labels. The Duplicates of
+// an entry are written as notes of their own PRs, and the entries of the
+// commits without any PR are left out.
+func (cl *ChangeLog) writeRelnotes(w io.Writer, opts RenderOptions) error {
+	ver := cl.detectVersion()
+	skip := map[string]bool{}
+	for _, lbl := range opts.SkipLabels {
+		skip[lbl] = true
+	}
+	notes := map[string]relnotesNote{}
+	for _, section := range cl.Sections() {
+		if skip[section.Label] {
+			continue
+		}
+		var entries []Entry
+		for _, entry := range section.Entries {
+			entries = append(entries, entry.flatten()...)
+		}
+		for _, entry := range entries {
+			if entry.PR <= 0 {
+				continue
+			}
+			repo, ref := cl.Owner+"/"+cl.Repo, fmt.Sprintf("#%d", entry.PR)
+			if len(entry.UpstreamRepo) != 0 {
+				repo, ref = entry.UpstreamRepo, entry.ref()
+			}
+			note := relnotesNote{
+				Text:           entry.ReleaseNote,
+				Author:         entry.Author,
+				AuthorURL:      "https://github.com/" + entry.Author,
+				PRURL:          fmt.Sprintf("https://github.com/%s/pull/%d", repo, entry.PR),
+				PRNumber:       entry.PR,
+				Kinds:          []string{section.Label[strings.LastIndex(section.Label, "/")+1:]},
+				ActionRequired: hasLabel(entry.Labels, upgradeImpactLabel),
+				ReleaseVersion: ver,
+			}
+			note.Markdown = fmt.Sprintf("%s ([%s](%s), [@%s](%s))", note.Text, ref, note.PRURL, note.Author, note.AuthorURL)
+			for _, lbl := range entry.Labels {
+				switch {
+				case strings.HasPrefix(lbl, areaLabelPrefix):
+					note.Areas = append(note.Areas, strings.TrimPrefix(lbl, areaLabelPrefix))
+				case strings.HasPrefix(lbl, sigLabelPrefix):
+					note.SIGs = append(note.SIGs, strings.TrimPrefix(lbl, sigLabelPrefix))
+				case strings.HasPrefix(lbl, kindLabelPrefix) && lbl != section.Label:
+					note.Kinds = append(note.Kinds, strings.TrimPrefix(lbl, kindLabelPrefix))
+				}
+			}
+			for _, l := range entry.Docs {
+				note.Documentation = append(note.Documentation, relnotesDocs{
+					Description: l.Area + " docs",
+					URL:         l.URL,
+					Type:        "official",
+				})
+			}
+			notes[strconv.Itoa(entry.PR)] = note
+		}
+	}
+	b, err := json.MarshalIndent(notes, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(b, '\n'))
+	return err
+}
@@ -0,0 +1,90 @@
+// Copyright 2021 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package changelog
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/cilium/release/pkg/config"
+	"github.com/cilium/release/pkg/types"
+)
+
+func TestRenderRelnotes(t *testing.T) {
+	cl := &ChangeLog{
+		Config: types.Config{Head: "v1.14.3", Owner: "cilium", Repo: "cilium", MergeDuplicates: true},
+		listOfPrs: types.PullRequests{
+			123: {ReleaseNote: "Add foo", AuthorName: "alice", Labels: []string{"release-note/minor", "area/cli", "sig/datapath", "upgrade-impact"}},
+			124: {ReleaseNote: "Add foo", AuthorName: "carol", Labels: []string{"release-note/minor"}},
+			126: {ReleaseNote: "Improve CI", AuthorName: "dave", Labels: []string{"release-note/ci"}},
+		},
+		prsWithUpstream: types.BackportPRs{
+			200: {
+				150: {ReleaseNote: "Fix bar", AuthorName: "bob", Labels: []string{"release-note/bug", "kind/regression"}},
+			},
+		},
+		docs: config.Docs{Areas: map[string]string{"area/cli": "https://docs.cilium.io/cli"}},
+	}
+	got, err := cl.Render(RenderOptions{Format: FormatRelnotes, SkipLabels: []string{"release-note/ci"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var notes map[string]relnotesNote
+	if err := json.Unmarshal(got, &notes); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]relnotesNote{
+		"123": {
+			Text:     "Add foo",
+			Markdown: "Add foo ([#123](https://github.com/cilium/cilium/pull/123), [@alice](https://github.com/alice))",
+			Documentation: []relnotesDocs{
+				{Description: "cli docs", URL: "https://docs.cilium.io/cli", Type: "official"},
+			},
+			Author:         "alice",
+			AuthorURL:      "https://github.com/alice",
+			PRURL:          "https://github.com/cilium/cilium/pull/123",
+			PRNumber:       123,
+			Areas:          []string{"cli"},
+			Kinds:          []string{"minor"},
+			SIGs:           []string{"datapath"},
+			ActionRequired: true,
+			ReleaseVersion: "1.14.3",
+		},
+		"124": {
+			Text:           "Add foo",
+			Markdown:       "Add foo ([#124](https://github.com/cilium/cilium/pull/124), [@carol](https://github.com/carol))",
+			Author:         "carol",
+			AuthorURL:      "https://github.com/carol",
+			PRURL:          "https://github.com/cilium/cilium/pull/124",
+			PRNumber:       124,
+			Kinds:          []string{"minor"},
+			ReleaseVersion: "1.14.3",
+		},
+		"150": {
+			Text:           "Fix bar",
+			Markdown:       "Fix bar ([#150](https://github.com/cilium/cilium/pull/150), [@bob](https://github.com/bob))",
+			Author:         "bob",
+			AuthorURL:      "https://github.com/bob",
+			PRURL:          "https://github.com/cilium/cilium/pull/150",
+			PRNumber:       150,
+			Kinds:          []string{"bug", "regression"},
+			ReleaseVersion: "1.14.3",
+		},
+	}
+	if !reflect.DeepEqual(notes, want) {
+		t.Errorf("got %+v, want %+v", notes, want)
+	}
+}
@@ -0,0 +1,119 @@
+// Copyright 2021 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package changelog
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/cilium/release/pkg/types"
+)
+
+var (
+	// revertTitleRe matches the title GitHub gives to the PRs reverting a
+	// PR, capturing the title of the reverted PR.
+	revertTitleRe = regexp.MustCompile(`^Revert "(.+)"$`)
+	// relandPrefixRe matches the prefix of the titles of the PRs landing
+	// again a reverted change, e.g. 'Reland: ' or 'Re-land '.
+	relandPrefixRe = regexp.MustCompile(`(?i)^re-?land(ed)?\b[:\s]*`)
+)
+
+// Revert is a change reverted within the release notes, and possibly landed
+// again, summarized in the Reverted Changes appendix instead of being listed
+// as several entries.
+type Revert struct {
+	Title string
+	// PR is the PR of the change, Revert the PR reverting it and Reland
+	// the PR landing it again, if any.
+	PR     int
+	Revert int
+	Reland int
+}
+
+func (r Revert) String() string {
+	if r.Reland == 0 {
+		return fmt.Sprintf("%s (#%d), reverted by #%d", r.Title, r.PR, r.Revert)
+	}
+	return fmt.Sprintf("%s (#%d), reverted by #%d and re-landed by #%d", r.Title, r.PR, r.Revert, r.Reland)
+}
+
+// relandTitle returns the title of the change a PR lands again, or its
+// title if it isn't a reland.
+func relandTitle(title string) string {
+	return strings.Trim(relandPrefixRe.ReplaceAllString(title, ""), `" `)
+}
+
+// foldReverts finds the PRs of the release notes reverting another of their
+// PRs, and the PRs landing the reverted change again. The reverted PR and
+// its revert are left out of the sections, the reland being the only entry
+// of the change, if any, and are listed in cl.reverts instead. The PRs are
+// matched by title, as given by GitHub to the reverts. The PRs stored in the
+// state are not modified.
+func (cl *ChangeLog) foldReverts() {
+	numbers := make([]int, 0, len(cl.listOfPrs))
+	for number := range cl.listOfPrs {
+		numbers = append(numbers, number)
+	}
+	sort.Ints(numbers)
+
+	folded := map[int]bool{}
+	cl.reverts = nil
+	for _, revert := range numbers {
+		m := revertTitleRe.FindStringSubmatch(cl.listOfPrs[revert].Title)
+		if m == nil || folded[revert] {
+			continue
+		}
+		r := Revert{Title: m[1], Revert: revert}
+		revertedAt := cl.listOfPrs[revert].MergedAt
+		for _, number := range numbers {
+			pr := cl.listOfPrs[number]
+			if number == revert || folded[number] {
+				continue
+			}
+			switch {
+			case pr.Title == r.Title && !pr.MergedAt.After(revertedAt):
+				// The latest PR of the title merged before the revert.
+				r.PR = number
+			case r.PR != 0 && r.Reland == 0 && pr.MergedAt.After(revertedAt) &&
+				(relandTitle(pr.Title) == r.Title || pr.Title == `Revert "`+cl.listOfPrs[revert].Title+`"`):
+				r.Reland = number
+			}
+		}
+		if r.PR == 0 {
+			// The reverted PR was released before.
+			continue
+		}
+		folded[r.PR], folded[r.Revert] = true, true
+		if r.Reland != 0 {
+			folded[r.Reland] = true
+		}
+		cl.reverts = append(cl.reverts, r)
+	}
+	if len(cl.reverts) == 0 {
+		return
+	}
+
+	listOfPrs := make(types.PullRequests, len(cl.listOfPrs))
+	for number, pr := range cl.listOfPrs {
+		listOfPrs[number] = pr
+	}
+	for _, r := range cl.reverts {
+		delete(listOfPrs, r.PR)
+		delete(listOfPrs, r.Revert)
+	}
+	cl.listOfPrs = listOfPrs
+}
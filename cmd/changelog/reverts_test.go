@@ -0,0 +1,65 @@
+// Copyright 2021 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package changelog
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/cilium/release/pkg/types"
+)
+
+func TestFoldReverts(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2023, 7, d, 0, 0, 0, 0, time.UTC) }
+	prs := types.PullRequests{
+		100: {Title: "Add foo", ReleaseNote: "Add foo", AuthorName: "alice", Labels: []string{"release-note/minor"}, MergedAt: day(1)},
+		101: {Title: `Revert "Add foo"`, ReleaseNote: `Revert "Add foo"`, AuthorName: "bob", Labels: []string{"release-note/minor"}, MergedAt: day(2)},
+		102: {Title: "Reland: Add foo", ReleaseNote: "Add foo", AuthorName: "alice", Labels: []string{"release-note/minor"}, MergedAt: day(3)},
+		110: {Title: "Fix bar", ReleaseNote: "Fix bar", AuthorName: "carol", Labels: []string{"release-note/bug"}, MergedAt: day(1)},
+		111: {Title: `Revert "Fix bar"`, ReleaseNote: `Revert "Fix bar"`, AuthorName: "carol", Labels: []string{"release-note/bug"}, MergedAt: day(4)},
+		// The reverted PR was released before.
+		120: {Title: `Revert "Fix baz"`, ReleaseNote: `Revert "Fix baz"`, AuthorName: "dave", Labels: []string{"release-note/bug"}, MergedAt: day(2)},
+	}
+	cl := &ChangeLog{
+		Config:    types.Config{FoldReverts: true},
+		listOfPrs: prs,
+	}
+	if err := cl.findAppendices(context.Background(), nil); err != nil {
+		t.Fatal(err)
+	}
+	if len(prs) != 6 {
+		t.Errorf("the PRs of the state should not be modified")
+	}
+	got, err := cl.Render(RenderOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "Summary of Changes\n" +
+		"------------------\n" +
+		"\n" +
+		"**Minor Changes:**\n" +
+		"* Add foo (#102, @alice)\n" +
+		"\n" +
+		"**Bugfixes:**\n" +
+		"* Revert \"Fix baz\" (#120, @dave)\n" +
+		"\n" +
+		"**Reverted Changes:**\n" +
+		"* Add foo (#100), reverted by #101 and re-landed by #102\n" +
+		"* Fix bar (#110), reverted by #111\n"
+	if string(got) != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
@@ -0,0 +1,90 @@
+// Copyright 2021 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package changelog
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/cilium/release/pkg/github"
+	"github.com/cilium/release/pkg/types"
+)
+
+// findReviewers looks up the approvers of the PRs of the changelog whose
+// reviewers are not known yet, e.g. restored from the state file.
+func (cl *ChangeLog) findReviewers(ctx context.Context) error {
+	return cl.updatePRs("reviewers", func(pr types.PullRequest) bool {
+		return pr.Reviewers == nil
+	}, func(owner, repo string, number int, pr *types.PullRequest) error {
+		reviewers, err := github.ListApprovers(ctx, cl.ghClient, owner, repo, number, pr.AuthorName)
+		pr.Reviewers = reviewers
+		return err
+	})
+}
+
+// updatePRs calls update, with the repository of the PR, for each PR of the
+// changelog for which needed returns true, printing the progress of the
+// lookups of the given information.
+func (cl *ChangeLog) updatePRs(
+	what string,
+	needed func(pr types.PullRequest) bool,
+	update func(owner, repo string, number int, pr *types.PullRequest) error,
+) error {
+	src := resolutionConfig(cl.Config)
+
+	total := 0
+	for number, pr := range cl.listOfPrs {
+		if number > 0 && needed(pr) {
+			total++
+		}
+	}
+	for _, upstreamPRs := range cl.prsWithUpstream {
+		for _, pr := range upstreamPRs {
+			if needed(pr) {
+				total++
+			}
+		}
+	}
+
+	done := 0
+	lookup := func(owner, repo string, number int, pr *types.PullRequest) error {
+		done++
+		fmt.Fprintf(os.Stderr, "Looking up %s of %s/%s#%d (%d/%d)\n", what, owner, repo, number, done, total)
+		return update(owner, repo, number, pr)
+	}
+	for number, pr := range cl.listOfPrs {
+		// The commits without any PR are keyed by negative numbers.
+		if number < 0 || !needed(pr) {
+			continue
+		}
+		if err := lookup(src.Owner, src.Repo, number, &pr); err != nil {
+			return err
+		}
+		cl.listOfPrs[number] = pr
+	}
+	for _, upstreamPRs := range cl.prsWithUpstream {
+		for number, pr := range upstreamPRs {
+			if !needed(pr) {
+				continue
+			}
+			if err := lookup(src.UpstreamOwner, src.UpstreamRepo, number, &pr); err != nil {
+				return err
+			}
+			upstreamPRs[number] = pr
+		}
+	}
+	return nil
+}
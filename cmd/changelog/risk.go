@@ -0,0 +1,122 @@
+// Copyright 2021 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package changelog
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/cilium/release/pkg/config"
+	"github.com/cilium/release/pkg/github"
+	"github.com/cilium/release/pkg/types"
+)
+
+// manyFiles is the number of files changed from which a PR is deemed of
+// medium risk.
+const manyFiles = 20
+
+// sizes are the size classes of the PRs by increasing number of lines
+// changed, below which a PR is of that size.
+var sizes = []struct {
+	name  string
+	below int
+}{
+	{"XS", 10},
+	{"S", 50},
+	{"M", 250},
+	{"L", 1000},
+}
+
+// loadCriticalPaths reads the critical paths of the backports from
+// cfg.ConfigFile if the entries are annotated with their risk.
+func loadCriticalPaths(cfg types.Config) ([]string, error) {
+	if !cfg.AnnotateRisk || len(cfg.ConfigFile) == 0 {
+		return nil, nil
+	}
+	c, err := config.Load(cfg.ConfigFile)
+	if err != nil {
+		return nil, fmt.Errorf("unable to load configuration: %w", err)
+	}
+	return c.Backports.CriticalPaths, nil
+}
+
+// findFiles looks up the files changed by the PRs of the changelog whose
+// risk, with AnnotateRisk, or components, with ComponentsSummary, are not
+// known yet, e.g. restored from the state file.
+func (cl *ChangeLog) findFiles(ctx context.Context) error {
+	return cl.updatePRs("files", func(pr types.PullRequest) bool {
+		return (cl.AnnotateRisk && pr.Risk == nil) || (cl.ComponentsSummary && pr.Components == nil)
+	}, func(owner, repo string, number int, pr *types.PullRequest) error {
+		files, err := github.ListFiles(ctx, cl.ghClient, owner, repo, number)
+		if err != nil {
+			return fmt.Errorf("unable to list files of PR %d: %w", number, err)
+		}
+		if cl.ComponentsSummary {
+			pr.Components = cl.fileComponents(files)
+		}
+		risk := &types.Risk{Files: len(files)}
+		for _, f := range files {
+			risk.Additions += f.GetAdditions()
+			risk.Deletions += f.GetDeletions()
+		}
+		for _, pattern := range cl.criticalPaths {
+			for _, f := range files {
+				if config.MatchPath(pattern, f.GetFilename()) {
+					risk.CriticalPaths = append(risk.CriticalPaths, pattern)
+					break
+				}
+			}
+		}
+		pr.Risk = risk
+		return nil
+	})
+}
+
+// riskSize returns the size class of the PR, e.g. 'M'.
+func riskSize(r types.Risk) string {
+	for _, s := range sizes {
+		if r.Additions+r.Deletions < s.below {
+			return s.name
+		}
+	}
+	return "XL"
+}
+
+// riskLevel returns 'high' for the PRs touching critical paths, 'medium'
+// for the large ones or those changing many files, and 'low' otherwise.
+func riskLevel(r types.Risk) string {
+	switch size := riskSize(r); {
+	case len(r.CriticalPaths) != 0:
+		return "high"
+	case r.Files >= manyFiles || size == "L" || size == "XL":
+		return "medium"
+	}
+	return "low"
+}
+
+// riskAnnotation returns the annotation of an entry, e.g. '[size M: +120/-30
+// in 5 files, risk high: pkg/datapath/]'.
+func riskAnnotation(r types.Risk) string {
+	files := "files"
+	if r.Files == 1 {
+		files = "file"
+	}
+	risk := riskLevel(r)
+	if len(r.CriticalPaths) != 0 {
+		risk += ": " + strings.Join(r.CriticalPaths, ", ")
+	}
+	return fmt.Sprintf("[size %s: +%d/-%d in %d %s, risk %s]", riskSize(r), r.Additions, r.Deletions, r.Files, files, risk)
+}
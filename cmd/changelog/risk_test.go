@@ -0,0 +1,57 @@
+// Copyright 2021 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package changelog
+
+import (
+	"testing"
+
+	"github.com/cilium/release/pkg/types"
+)
+
+func TestRiskAnnotation(t *testing.T) {
+	tests := []struct {
+		name string
+		risk types.Risk
+		want string
+	}{
+		{
+			name: "small change",
+			risk: types.Risk{Additions: 3, Deletions: 1, Files: 1},
+			want: "[size XS: +3/-1 in 1 file, risk low]",
+		},
+		{
+			name: "many files",
+			risk: types.Risk{Additions: 30, Deletions: 10, Files: 25},
+			want: "[size S: +30/-10 in 25 files, risk medium]",
+		},
+		{
+			name: "large change",
+			risk: types.Risk{Additions: 900, Deletions: 300, Files: 4},
+			want: "[size XL: +900/-300 in 4 files, risk medium]",
+		},
+		{
+			name: "critical paths",
+			risk: types.Risk{Additions: 100, Deletions: 20, Files: 5, CriticalPaths: []string{"pkg/datapath/", "bpf/*.h"}},
+			want: "[size M: +100/-20 in 5 files, risk high: pkg/datapath/, bpf/*.h]",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := riskAnnotation(tt.risk); got != tt.want {
+				t.Errorf("riskAnnotation() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
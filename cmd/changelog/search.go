@@ -0,0 +1,180 @@
+// Copyright 2021 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package changelog
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	gh "github.com/google/go-github/v50/github"
+
+	"github.com/cilium/release/pkg/config"
+	"github.com/cilium/release/pkg/github"
+	"github.com/cilium/release/pkg/tracing"
+	"github.com/cilium/release/pkg/types"
+	"github.com/cilium/release/pkg/usage"
+)
+
+// searchQuery returns the query of the PRs merged into the search branch
+// of cfg between the given dates.
+func searchQuery(cfg types.Config, since, until time.Time) string {
+	branch := cfg.SearchBranch
+	if len(branch) == 0 {
+		branch = cfg.Head
+	}
+	return fmt.Sprintf("repo:%s/%s is:pr is:merged base:%s merged:%s..%s",
+		cfg.Owner, cfg.Repo, branch, since.UTC().Format(time.RFC3339), until.UTC().Format(time.RFC3339))
+}
+
+// commitDate returns the date the given commit, branch or tag was
+// committed.
+func commitDate(ctx context.Context, ghClient *gh.Client, owner, repo, ref string) (time.Time, error) {
+	commit, _, err := ghClient.Repositories.GetCommit(ctx, owner, repo, ref, nil)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("unable to get commit %s: %w", ref, err)
+	}
+	return commit.GetCommit().GetCommitter().GetDate().Time, nil
+}
+
+// searchPRs returns the PRs merged into the search branch of cfg after the
+// base was committed and until the head was.
+func searchPRs(ctx context.Context, ghClient *gh.Client, cfg types.Config) ([]*gh.PullRequest, error) {
+	since, err := commitDate(ctx, ghClient, cfg.Owner, cfg.Repo, cfg.Base)
+	if err != nil {
+		return nil, err
+	}
+	until, err := commitDate(ctx, ghClient, cfg.Owner, cfg.Repo, cfg.Head)
+	if err != nil {
+		return nil, err
+	}
+	// The base commit was merged at, or right after, its commit date.
+	query := searchQuery(cfg, since.Add(time.Second), until)
+	fmt.Fprintf(os.Stderr, "Searching %s\n", query)
+	prs, err := github.SearchMergedPRs(ctx, ghClient, query)
+	if err != nil {
+		return nil, fmt.Errorf("unable to search merged PRs: %w", err)
+	}
+	return prs, nil
+}
+
+// generateSearchNotes builds the release notes from the PRs merged into
+// the search branch between the dates of cfg.Base and cfg.Head, found with
+// the search API instead of comparing the commits. The upstream PRs of the
+// backport PRs are still resolved, but no state is stored.
+func generateSearchNotes(ctx context.Context, ghClient *gh.Client, cfg types.Config, tracker *usage.Tracker) (*ChangeLog, error) {
+	overrides, err := loadOverrides(cfg)
+	if err != nil {
+		return nil, err
+	}
+	disclosures, err := loadDisclosures(cfg)
+	if err != nil {
+		return nil, err
+	}
+	docs, err := loadDocs(cfg)
+	if err != nil {
+		return nil, err
+	}
+	groups, err := loadGroups(cfg)
+	if err != nil {
+		return nil, err
+	}
+	var authors config.Authors
+	if len(cfg.AuthorsFile) != 0 {
+		authors, err = config.LoadAuthors(cfg.AuthorsFile)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read authors file: %w", err)
+		}
+	}
+	prCache, err := openCache(ctx, ghClient, cfg)
+	if err != nil {
+		return nil, err
+	}
+	if err := resolveBase(ctx, ghClient, &cfg); err != nil {
+		return nil, err
+	}
+	src := resolutionConfig(cfg)
+
+	phaseCtx, endPhase := tracing.Phase(ctx, tracker, "search")
+	prs, err := searchPRs(phaseCtx, ghClient, src)
+	endPhase()
+	if err != nil {
+		return nil, err
+	}
+
+	cl := &ChangeLog{
+		Config:          cfg,
+		ghClient:        ghClient,
+		prsWithUpstream: types.BackportPRs{},
+		listOfPrs:       types.PullRequests{},
+		authors:         authors,
+		docs:            docs,
+		groups:          groups,
+	}
+	phaseCtx, endPhase = tracing.Phase(ctx, tracker, "PR resolution")
+	defer endPhase()
+	for _, pr := range prs {
+		err := github.AddPullRequest(phaseCtx, ghClient, prCache, src.UpstreamOwner, src.UpstreamRepo, nil, pr, cl.prsWithUpstream, cl.listOfPrs)
+		var unresolved *github.UnresolvedUpstreamError
+		if errors.As(err, &unresolved) {
+			fmt.Fprintf(os.Stderr, "WARNING: %s!\n", unresolved)
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	fmt.Fprintf(os.Stderr, "Found %d PRs and %d backport PRs!\n", len(cl.listOfPrs), len(cl.prsWithUpstream))
+	cl.applyOverrides(overrides)
+	cl.applyDisclosures(disclosures)
+	return cl, nil
+}
+
+// crossCheckSearch compares the PRs found from the commits, including the
+// backport PRs, with the ones found by the search API for the same range,
+// and reports the differences.
+func crossCheckSearch(ctx context.Context, ghClient *gh.Client, cfg types.Config, backportPRs types.BackportPRs, listOfPRs types.PullRequests) error {
+	prs, err := searchPRs(ctx, ghClient, cfg)
+	if err != nil {
+		return err
+	}
+	ours := map[int]bool{}
+	for pr := range listOfPRs {
+		// The commits without any PR are keyed by negative numbers.
+		if pr > 0 {
+			ours[pr] = true
+		}
+	}
+	for pr := range backportPRs {
+		ours[pr] = true
+	}
+	theirs := map[int]bool{}
+	for _, pr := range prs {
+		theirs[pr.GetNumber()] = true
+	}
+	missed, extra := diffPRs(ours, theirs)
+	for _, pr := range extra {
+		fmt.Fprintf(os.Stderr, "WARNING: PR #%d is not found by the search API\n", pr)
+	}
+	for _, pr := range missed {
+		fmt.Fprintf(os.Stderr, "WARNING: PR #%d is only found by the search API\n", pr)
+	}
+	if len(missed) == 0 && len(extra) == 0 {
+		fmt.Fprintf(os.Stderr, "The search API finds the same PRs\n")
+	}
+	return nil
+}
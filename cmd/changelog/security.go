@@ -0,0 +1,111 @@
+// Copyright 2021 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package changelog
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/cilium/release/pkg/config"
+	"github.com/cilium/release/pkg/types"
+)
+
+// resolutionConfig returns cfg with its repository, and therefore the one of
+// the upstream PRs, replaced by cfg.SecurityFork, if set, for the commits to
+// be compared and the PRs to be resolved in the fork.
+func resolutionConfig(cfg types.Config) types.Config {
+	if len(cfg.SecurityFork) == 0 {
+		return cfg
+	}
+	cfg.Owner, cfg.Repo = cfg.ForkOwner, cfg.ForkRepo
+	cfg.UpstreamOwner, cfg.UpstreamRepo = cfg.ForkOwner, cfg.ForkRepo
+	return cfg
+}
+
+// loadDisclosures reads cfg.DisclosuresFile, if set.
+func loadDisclosures(cfg types.Config) (config.Disclosures, error) {
+	if len(cfg.DisclosuresFile) == 0 {
+		return nil, nil
+	}
+	disclosures, err := config.LoadDisclosures(cfg.DisclosuresFile)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read disclosures file: %w", err)
+	}
+	return disclosures, nil
+}
+
+// applyDisclosures renumbers the PRs of the security fork into the public
+// PRs they were disclosed as. The entries with any PR not disclosed yet keep
+// the PRs of the fork, referenced with the name of the fork so that they
+// don't link to unrelated public PRs. As for the overrides, the PRs stored in
+// the state are not modified.
+func (cl *ChangeLog) applyDisclosures(disclosures config.Disclosures) {
+	if len(cl.SecurityFork) == 0 {
+		return
+	}
+	undisclosed := 0
+	disclose := func(numbers ...int) ([]int, bool) {
+		public := make([]int, 0, len(numbers))
+		for _, number := range numbers {
+			n, ok := disclosures[number]
+			if !ok {
+				undisclosed++
+				return numbers, false
+			}
+			public = append(public, n)
+		}
+		return public, true
+	}
+	url := func(number int) string {
+		return fmt.Sprintf("https://github.com/%s/%s/pull/%d", cl.Owner, cl.Repo, number)
+	}
+
+	listOfPrs := make(types.PullRequests, len(cl.listOfPrs))
+	for number, pr := range cl.listOfPrs {
+		// The commits without any PR are keyed by negative numbers.
+		if number < 0 {
+			listOfPrs[number] = pr
+			continue
+		}
+		if public, ok := disclose(number); ok {
+			number = public[0]
+			pr.URL = url(number)
+		} else {
+			pr.Repo = cl.SecurityFork
+		}
+		listOfPrs[number] = pr
+	}
+	prsWithUpstream := make(types.BackportPRs, len(cl.prsWithUpstream))
+	for backportPR, upstreamPRs := range cl.prsWithUpstream {
+		for number, pr := range upstreamPRs {
+			key, upstream := backportPR, number
+			if public, ok := disclose(backportPR, number); ok {
+				key, upstream = public[0], public[1]
+				pr.URL = url(upstream)
+			} else {
+				pr.Repo = cl.SecurityFork
+			}
+			if prsWithUpstream[key] == nil {
+				prsWithUpstream[key] = map[int]types.PullRequest{}
+			}
+			prsWithUpstream[key][upstream] = pr
+		}
+	}
+	cl.listOfPrs, cl.prsWithUpstream = listOfPrs, prsWithUpstream
+
+	if undisclosed != 0 {
+		fmt.Fprintf(os.Stderr, "WARNING: %d entries reference PRs of %s not disclosed yet, see --disclosures\n", undisclosed, cl.SecurityFork)
+	}
+}
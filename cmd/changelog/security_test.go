@@ -0,0 +1,78 @@
+// Copyright 2021 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package changelog
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/cilium/release/pkg/config"
+	"github.com/cilium/release/pkg/types"
+)
+
+func TestApplyDisclosures(t *testing.T) {
+	cl := &ChangeLog{
+		Config: types.Config{Owner: "cilium", Repo: "cilium", SecurityFork: "cilium/cilium-security"},
+		listOfPrs: types.PullRequests{
+			1:  {Title: "Fix CVE-2023-1234"},
+			2:  {Title: "Fix CVE-2023-5678"},
+			-1: {ReleaseNote: "Prepare for release", Commit: "aaa"},
+		},
+		prsWithUpstream: types.BackportPRs{
+			10: {1: {Title: "Fix CVE-2023-1234"}},
+			11: {2: {Title: "Fix CVE-2023-5678"}},
+		},
+	}
+	state := cl.listOfPrs
+	cl.applyDisclosures(config.Disclosures{1: 28000, 10: 28010, 11: 28011})
+
+	wantPRs := types.PullRequests{
+		28000: {Title: "Fix CVE-2023-1234", URL: "https://github.com/cilium/cilium/pull/28000"},
+		2:     {Title: "Fix CVE-2023-5678", Repo: "cilium/cilium-security"},
+		-1:    {ReleaseNote: "Prepare for release", Commit: "aaa"},
+	}
+	if !reflect.DeepEqual(cl.listOfPrs, wantPRs) {
+		t.Errorf("applyDisclosures() PRs = %+v, want %+v", cl.listOfPrs, wantPRs)
+	}
+	// The upstream PR of the second backport isn't disclosed yet.
+	wantBackports := types.BackportPRs{
+		28010: {28000: {Title: "Fix CVE-2023-1234", URL: "https://github.com/cilium/cilium/pull/28000"}},
+		11:    {2: {Title: "Fix CVE-2023-5678", Repo: "cilium/cilium-security"}},
+	}
+	if !reflect.DeepEqual(cl.prsWithUpstream, wantBackports) {
+		t.Errorf("applyDisclosures() backports = %+v, want %+v", cl.prsWithUpstream, wantBackports)
+	}
+	if _, ok := state[1]; !ok {
+		t.Errorf("applyDisclosures() modified the state")
+	}
+
+	e := newEntry(11, 2, cl.prsWithUpstream[11][2])
+	e.Author, e.ReleaseNote = "alice", "Fix CVE-2023-5678"
+	if got, want := e.String(), "* Fix CVE-2023-5678 (Backport PR cilium/cilium-security#11, Upstream PR cilium/cilium-security#2, @alice)"; got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+}
+
+func TestResolutionConfig(t *testing.T) {
+	cfg := types.Config{Owner: "cilium", Repo: "cilium", UpstreamOwner: "cilium", UpstreamRepo: "cilium"}
+	if got := resolutionConfig(cfg); !reflect.DeepEqual(got, cfg) {
+		t.Errorf("resolutionConfig() = %+v without fork, want %+v", got, cfg)
+	}
+	cfg.SecurityFork, cfg.ForkOwner, cfg.ForkRepo = "cilium/cilium-security", "cilium", "cilium-security"
+	got := resolutionConfig(cfg)
+	if got.Repo != "cilium-security" || got.UpstreamRepo != "cilium-security" {
+		t.Errorf("resolutionConfig() = %s/%s and %s/%s, want the fork", got.Owner, got.Repo, got.UpstreamOwner, got.UpstreamRepo)
+	}
+}
@@ -0,0 +1,110 @@
+// Copyright 2021 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package changelog
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	gh "github.com/google/go-github/v50/github"
+
+	"github.com/cilium/release/pkg/github"
+	"github.com/cilium/release/pkg/report"
+)
+
+// shaRe matches the full SHA of a commit.
+var shaRe = regexp.MustCompile(`^[0-9a-f]{40}$`)
+
+// readSHAs returns the commits listed in the given file, one SHA per line,
+// e.g. as written by 'git rev-list'. Blank lines and lines starting with
+// '#' are ignored.
+func readSHAs(file string) ([]string, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read SHAs file: %w", err)
+	}
+	defer f.Close()
+
+	var shas []string
+	scanner := bufio.NewScanner(f)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if len(line) == 0 || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if !shaRe.MatchString(line) {
+			return nil, fmt.Errorf("%s:%d: %q is not the full SHA of a commit", file, n, line)
+		}
+		shas = append(shas, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("unable to read SHAs file: %w", err)
+	}
+	return shas, nil
+}
+
+// writeSHAs writes the given commits into file, one SHA per line, so that
+// they can be corrected and read back with readSHAs.
+func writeSHAs(file string, shas []string) error {
+	var b strings.Builder
+	for _, sha := range shas {
+		b.WriteString(sha)
+		b.WriteByte('\n')
+	}
+	if err := os.WriteFile(file, []byte(b.String()), 0644); err != nil {
+		return fmt.Errorf("unable to write SHAs file: %w", err)
+	}
+	report.Written(file)
+	fmt.Fprintf(os.Stderr, "Commits written into %s\n", file)
+	return nil
+}
+
+// commitsOfSHAs returns the commits of shas, e.g. read from a SHAs file, in
+// their order, so that the release notes given by the trailers of their
+// messages aren't lost. They are verified against the comparison of base and
+// head, which each of them must be part of. If the comparison is truncated,
+// the commits it lacks are fetched individually and only warned about.
+func commitsOfSHAs(ctx context.Context, ghClient *gh.Client, owner, repo, base, head string, shas []string) ([]*gh.RepositoryCommit, error) {
+	compared, err := github.CompareCommits(ctx, ghClient, owner, repo, base, head)
+	truncated := errors.Is(err, github.ErrTruncatedCompare)
+	if err != nil && !truncated {
+		return nil, err
+	}
+	bySHA := make(map[string]*gh.RepositoryCommit, len(compared))
+	for _, commit := range compared {
+		bySHA[commit.GetSHA()] = commit
+	}
+	commits := make([]*gh.RepositoryCommit, 0, len(shas))
+	for _, sha := range shas {
+		commit, ok := bySHA[sha]
+		if !ok && !truncated {
+			return nil, fmt.Errorf("commit %s of the SHAs file isn't between %s and %s", sha, base, head)
+		}
+		if !ok {
+			fmt.Fprintf(os.Stderr, "WARNING: commit %s of the SHAs file couldn't be verified to be between %s and %s: %s\n", sha, base, head, err)
+			commit, _, err = ghClient.Repositories.GetCommit(ctx, owner, repo, sha, nil)
+			if err != nil {
+				return nil, fmt.Errorf("unable to get commit %s: %w", sha, err)
+			}
+		}
+		commits = append(commits, commit)
+	}
+	return commits, nil
+}
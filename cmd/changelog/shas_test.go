@@ -0,0 +1,116 @@
+// Copyright 2021 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package changelog
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	gh "github.com/google/go-github/v50/github"
+
+	"github.com/cilium/release/pkg/types"
+)
+
+func TestSHAsFile(t *testing.T) {
+	sha1 := strings.Repeat("a", 40)
+	sha2 := strings.Repeat("b", 40)
+	sha3 := strings.Repeat("c", 40)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/rate_limit":
+			fmt.Fprint(w, `{"resources": {"core": {"limit": 5000, "remaining": 5000}}}`)
+		case "/repos/cilium/cilium/commits/v1.14.0", "/repos/cilium/cilium/commits/v1.14":
+			fmt.Fprintf(w, `{"sha": %q}`, sha2)
+		case "/repos/cilium/cilium/compare/v1.14.0...v1.14":
+			fmt.Fprintf(w, `{"total_commits": 2, "commits": [
+				{"sha": %q, "commit": {"message": "Fix bar (#124)"}},
+				{"sha": %q, "commit": {"message": "Fix qux (#126)\n\nRelease-note: Fix the qux"}}
+			]}`, sha1, sha2)
+		case "/repos/cilium/cilium/commits/" + sha1 + "/pulls":
+			fmt.Fprint(w, `[{"number": 124, "state": "closed", "title": "Fix bar", "merged_at": "2023-07-12T09:30:00Z",
+				"user": {"login": "bob"}, "labels": [{"name": "release-note/bug"}]}]`)
+		case "/repos/cilium/cilium/commits/" + sha2 + "/pulls":
+			fmt.Fprint(w, `[{"number": 126, "state": "closed", "title": "Fix qux", "merged_at": "2023-07-13T09:30:00Z",
+				"user": {"login": "dave"}, "labels": [{"name": "release-note/bug"}]}]`)
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL)
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+	ghClient := gh.NewClient(nil)
+	ghClient.BaseURL, _ = url.Parse(srv.URL + "/")
+
+	dir := t.TempDir()
+	cfg := types.Config{
+		RepoName:  "cilium/cilium",
+		Base:      "v1.14.0",
+		Head:      "v1.14",
+		StateFile: filepath.Join(dir, "state.json"),
+		ShasFile:  filepath.Join(dir, "shas.txt"),
+	}
+	if err := cfg.Sanitize(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Without SHAs file, the compared commits are written into it.
+	if _, err := GenerateReleaseNotes(context.Background(), ghClient, cfg, nil); err != nil {
+		t.Fatal(err)
+	}
+	shas, err := readSHAs(cfg.ShasFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(shas, []string{sha2, sha1}) {
+		t.Errorf("got SHAs %v, want %s and %s", shas, sha2, sha1)
+	}
+
+	// The SHAs file, e.g. corrected, is read instead of the commits
+	// compared, which only verify it and give the trailers of its commits.
+	os.Remove(cfg.StateFile)
+	err = os.WriteFile(cfg.ShasFile, []byte("# git rev-list v1.14.0..v1.14\n"+sha2+"\n\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cl, err := GenerateReleaseNotes(context.Background(), ghClient, cfg, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cl.listOfPrs) != 1 || cl.headSHA != sha2 {
+		t.Errorf("got PRs %v and head %s, want 126 and head %s", cl.listOfPrs, cl.headSHA, sha2)
+	}
+	if got := cl.listOfPrs[126].ReleaseNote; got != "Fix the qux" {
+		t.Errorf("got release note %q, want the one of the trailer", got)
+	}
+
+	os.Remove(cfg.StateFile)
+	os.WriteFile(cfg.ShasFile, []byte(sha3+"\n"), 0644)
+	if _, err := GenerateReleaseNotes(context.Background(), ghClient, cfg, nil); err == nil || !strings.Contains(err.Error(), "isn't between v1.14.0 and v1.14") {
+		t.Errorf("got error %v, want commit %s out of the range", err, sha3)
+	}
+
+	os.WriteFile(cfg.ShasFile, []byte(sha1+"\nv1.14\n"), 0644)
+	if _, err := readSHAs(cfg.ShasFile); err == nil || !strings.Contains(err.Error(), "shas.txt:2") {
+		t.Errorf("got error %v, want an error at line 2", err)
+	}
+}
@@ -0,0 +1,172 @@
+// Copyright 2021 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package changelog
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	gh "github.com/google/go-github/v50/github"
+
+	"github.com/cilium/release/pkg/github"
+	"github.com/cilium/release/pkg/report"
+	"github.com/cilium/release/pkg/types"
+)
+
+// publish publishes the release notes into each of cl.Sinks. page is the
+// release notes with their front matter, if any, written into the local
+// sinks, and notes the release notes without it, published on GitHub, see
+// pages. All the sinks are published into even if some of them fail, and
+// the releases edited are then reported on the tracking issue of cl.Gate.
+func (cl *ChangeLog) publish(ctx context.Context, page, notes []byte) error {
+	var failed []string
+	for _, s := range cl.Sinks {
+		sk, err := types.ParseSink(s)
+		if err == nil {
+			err = cl.publishSink(ctx, sk, page, notes)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Unable to publish the release notes into %s: %s\n", s, err)
+			failed = append(failed, s)
+		}
+	}
+	if err := cl.Gate.Report(ctx); err != nil {
+		return err
+	}
+	if len(failed) != 0 {
+		return fmt.Errorf("unable to publish the release notes into %s", strings.Join(failed, ", "))
+	}
+	return nil
+}
+
+func (cl *ChangeLog) publishSink(ctx context.Context, s types.Sink, page, notes []byte) error {
+	switch s.Kind {
+	case types.SinkStdout:
+		_, err := os.Stdout.Write(page)
+		return err
+	case types.SinkFile:
+		if err := os.WriteFile(s.Target, page, 0644); err != nil {
+			return err
+		}
+		report.Written(s.Target)
+		fmt.Fprintf(os.Stderr, "Release notes written into %s\n", s.Target)
+	case types.SinkRelease:
+		release, err := cl.releaseByTag(ctx, s.Target)
+		if err != nil {
+			return err
+		}
+		body := string(notes)
+		release, _, err = cl.ghClient.Repositories.EditRelease(ctx, cl.Owner, cl.Repo, release.GetID(), &gh.RepositoryRelease{Body: &body})
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "Release notes set as the body of %s\n", release.GetHTMLURL())
+		cl.Gate.Executed("Set the release notes as the body of %s", release.GetHTMLURL())
+	case types.SinkGist:
+		url, err := cl.uploadGist(ctx, s.Target, notes)
+		if err != nil {
+			return err
+		}
+		cl.gistURL = url
+		fmt.Fprintf(os.Stderr, "Release notes uploaded to %s\n", url)
+	case types.SinkIssue:
+		number, _ := strconv.Atoi(s.Target)
+		body := string(notes)
+		comment, _, err := cl.ghClient.Issues.CreateComment(ctx, cl.Owner, cl.Repo, number, &gh.IssueComment{Body: &body})
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "Release notes posted at %s\n", comment.GetHTMLURL())
+	}
+	return nil
+}
+
+// releaseByTag returns the release, draft or not, of the given tag.
+func (cl *ChangeLog) releaseByTag(ctx context.Context, tag string) (*gh.RepositoryRelease, error) {
+	releases, err := github.ListReleases(ctx, cl.ghClient, cl.Owner, cl.Repo)
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range releases {
+		if r.GetTagName() == tag {
+			return r, nil
+		}
+	}
+	return nil, fmt.Errorf("no release of tag %s", tag)
+}
+
+// uploadReleaseAssets uploads the files as assets of the release of the given
+// tag, replacing its assets of the same names so that regenerating the
+// release notes doesn't fail.
+func (cl *ChangeLog) uploadReleaseAssets(ctx context.Context, tag string, files []string) error {
+	release, err := cl.releaseByTag(ctx, tag)
+	if err != nil {
+		return err
+	}
+	for _, file := range files {
+		name := filepath.Base(file)
+		for _, asset := range release.Assets {
+			if asset.GetName() != name {
+				continue
+			}
+			if _, err := cl.ghClient.Repositories.DeleteReleaseAsset(ctx, cl.Owner, cl.Repo, asset.GetID()); err != nil {
+				return fmt.Errorf("unable to delete previous asset %s: %w", name, err)
+			}
+		}
+		f, err := os.Open(file)
+		if err != nil {
+			return err
+		}
+		asset, _, err := cl.ghClient.Repositories.UploadReleaseAsset(ctx, cl.Owner, cl.Repo, release.GetID(), &gh.UploadOptions{Name: name}, f)
+		f.Close()
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "%s uploaded to %s\n", name, release.GetHTMLURL())
+		cl.Gate.Executed("Uploaded %s to %s", asset.GetBrowserDownloadURL(), release.GetHTMLURL())
+	}
+	return nil
+}
+
+// gistFile is the name of the file of the release notes in a gist.
+const gistFile = "release-notes.md"
+
+// uploadGist uploads the release notes as a new secret gist, or as a new
+// revision of the gist of the given ID or URL, and returns its URL.
+func (cl *ChangeLog) uploadGist(ctx context.Context, id string, notes []byte) (string, error) {
+	gist := &gh.Gist{
+		Description: gh.String(fmt.Sprintf("Release notes of %s/%s for %s...%s", cl.Owner, cl.Repo, cl.Base, cl.Head)),
+		Files: map[gh.GistFilename]gh.GistFile{
+			gistFile: {Content: gh.String(string(notes))},
+		},
+	}
+	var err error
+	if len(id) == 0 {
+		gist.Public = gh.Bool(false)
+		gist, _, err = cl.ghClient.Gists.Create(ctx, gist)
+	} else {
+		id = strings.TrimSuffix(id, "/")
+		id = id[strings.LastIndex(id, "/")+1:]
+		gist, _, err = cl.ghClient.Gists.Edit(ctx, id, gist)
+	}
+	if err != nil {
+		return "", err
+	}
+	return gist.GetHTMLURL(), nil
+}
@@ -0,0 +1,200 @@
+// Copyright 2021 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package changelog
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	gh "github.com/google/go-github/v50/github"
+
+	"github.com/cilium/release/pkg/types"
+)
+
+func TestPublish(t *testing.T) {
+	published := map[string]string{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method + " " + r.URL.Path {
+		case "GET /repos/cilium/cilium/releases":
+			fmt.Fprint(w, `[{"id": 2, "tag_name": "v1.14.3", "draft": true}, {"id": 1, "tag_name": "v1.14.2"}]`)
+		case "PATCH /repos/cilium/cilium/releases/2":
+			var release gh.RepositoryRelease
+			json.NewDecoder(r.Body).Decode(&release)
+			published["release"] = release.GetBody()
+			fmt.Fprint(w, `{"id": 2}`)
+		case "POST /gists":
+			var gist gh.Gist
+			json.NewDecoder(r.Body).Decode(&gist)
+			if gist.GetPublic() {
+				t.Errorf("gist should be secret")
+			}
+			f := gist.Files["release-notes.md"]
+			published["gist"] = f.GetContent()
+			fmt.Fprint(w, `{"html_url": "https://gist.github.com/abc"}`)
+		case "POST /repos/cilium/cilium/issues/1234/comments":
+			var comment gh.IssueComment
+			json.NewDecoder(r.Body).Decode(&comment)
+			published["issue"] = comment.GetBody()
+			fmt.Fprint(w, `{}`)
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL)
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+	ghClient := gh.NewClient(nil)
+	ghClient.BaseURL, _ = url.Parse(srv.URL + "/")
+
+	file := filepath.Join(t.TempDir(), "NOTES.md")
+	cl := &ChangeLog{
+		Config: types.Config{
+			Owner: "cilium",
+			Repo:  "cilium",
+			Sinks: []string{"file:" + file, "release:v1.14.3", "gist", "issue:1234", "release:v1.14.4"},
+		},
+		ghClient: ghClient,
+	}
+	err := cl.publish(context.Background(), []byte("---\ntitle: v1.14.3\n---\nnotes\n"), []byte("notes\n"))
+	if err == nil || err.Error() != "unable to publish the release notes into release:v1.14.4" {
+		t.Errorf("got error %v, want the release:v1.14.4 sink to fail", err)
+	}
+	want := map[string]string{"release": "notes\n", "gist": "notes\n", "issue": "notes\n"}
+	if !reflect.DeepEqual(published, want) {
+		t.Errorf("got %q, want %q", published, want)
+	}
+	if cl.gistURL != "https://gist.github.com/abc" {
+		t.Errorf("got gist URL %q", cl.gistURL)
+	}
+	b, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != "---\ntitle: v1.14.3\n---\nnotes\n" {
+		t.Errorf("got file %q", b)
+	}
+}
+
+func TestUploadGist(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method+" "+r.URL.Path != "PATCH /gists/abc123" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL)
+		}
+		var gist gh.Gist
+		json.NewDecoder(r.Body).Decode(&gist)
+		if f := gist.Files[gistFile]; f.GetContent() != "notes\n" {
+			t.Errorf("got content %q", f.GetContent())
+		}
+		fmt.Fprint(w, `{"html_url": "https://gist.github.com/maintainer/abc123"}`)
+	}))
+	defer srv.Close()
+	ghClient := gh.NewClient(nil)
+	ghClient.BaseURL, _ = url.Parse(srv.URL + "/")
+
+	cl := &ChangeLog{Config: types.Config{Owner: "cilium", Repo: "cilium"}, ghClient: ghClient}
+	for _, id := range []string{"abc123", "https://gist.github.com/maintainer/abc123"} {
+		got, err := cl.uploadGist(context.Background(), id, []byte("notes\n"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != "https://gist.github.com/maintainer/abc123" {
+			t.Errorf("uploadGist(%q) = %s", id, got)
+		}
+	}
+}
+
+func TestWriteProvenance(t *testing.T) {
+	var uploaded, deleted []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method + " " + r.URL.Path {
+		case "GET /repos/cilium/cilium/commits/v1.14":
+			fmt.Fprint(w, "abc123")
+		case "GET /repos/cilium/cilium/releases":
+			fmt.Fprint(w, `[{"id": 2, "tag_name": "v1.14.3", "draft": true, "assets": [{"id": 5, "name": "notes.intoto.json"}, {"id": 6, "name": "cilium-linux-amd64"}]}]`)
+		case "DELETE /repos/cilium/cilium/releases/assets/5":
+			deleted = append(deleted, "notes.intoto.json")
+			w.WriteHeader(http.StatusNoContent)
+		case "POST /repos/cilium/cilium/releases/2/assets":
+			uploaded = append(uploaded, r.URL.Query().Get("name"))
+			fmt.Fprint(w, `{}`)
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL)
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+	ghClient := gh.NewClient(nil)
+	ghClient.BaseURL, _ = url.Parse(srv.URL + "/")
+	ghClient.UploadURL, _ = url.Parse(srv.URL + "/")
+
+	dir := t.TempDir()
+	output := filepath.Join(dir, "NOTES.md")
+	binary := filepath.Join(dir, "cilium-linux-amd64")
+	provenance := filepath.Join(dir, "notes.intoto.json")
+	for _, f := range []string{output, binary} {
+		if err := os.WriteFile(f, []byte(f), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	cl := &ChangeLog{
+		Config: types.Config{
+			Owner:               "cilium",
+			Repo:                "cilium",
+			Head:                "v1.14",
+			Output:              output,
+			ProvenanceArtifacts: []string{binary},
+			Sinks:               []string{"stdout", "release:v1.14.3"},
+		},
+		ghClient: ghClient,
+	}
+	if err := cl.writeProvenance(context.Background(), provenance); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"notes.intoto.json"}; !reflect.DeepEqual(uploaded, want) || !reflect.DeepEqual(deleted, want) {
+		t.Errorf("got uploaded %q and deleted %q, want %q replaced", uploaded, deleted, want)
+	}
+	b, err := os.ReadFile(provenance)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var st struct {
+		Subject []struct {
+			Name string `json:"name"`
+		} `json:"subject"`
+		Predicate struct {
+			BuildDefinition struct {
+				ResolvedDependencies []struct {
+					Digest map[string]string `json:"digest"`
+				} `json:"resolvedDependencies"`
+			} `json:"buildDefinition"`
+		} `json:"predicate"`
+	}
+	if err := json.Unmarshal(b, &st); err != nil {
+		t.Fatal(err)
+	}
+	if len(st.Subject) != 2 || st.Subject[0].Name != "NOTES.md" || st.Subject[1].Name != "cilium-linux-amd64" {
+		t.Errorf("got subjects %+v, want the release notes and the binary", st.Subject)
+	}
+	if deps := st.Predicate.BuildDefinition.ResolvedDependencies; len(deps) != 1 || deps[0].Digest["gitCommit"] != "abc123" {
+		t.Errorf("got dependencies %+v, want the resolved head", deps)
+	}
+}
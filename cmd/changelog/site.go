@@ -0,0 +1,312 @@
+// Copyright 2021 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package changelog
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	gh "github.com/google/go-github/v50/github"
+	flag "github.com/spf13/pflag"
+
+	"github.com/cilium/release/pkg/config"
+	"github.com/cilium/release/pkg/github"
+	"github.com/cilium/release/pkg/profile"
+	"github.com/cilium/release/pkg/report"
+	"github.com/cilium/release/pkg/types"
+	"github.com/cilium/release/pkg/version"
+)
+
+// SiteRelease is a page of the changelog site.
+type SiteRelease struct {
+	Tag     string
+	Version version.Version
+	Date    time.Time
+	// Notes are the release notes rendered with the templates, along with
+	// the parts of the published ones that aren't entries, e.g. upgrade
+	// notes or Docker manifests, or the published ones if they couldn't be
+	// parsed, e.g. of old releases.
+	Notes string
+	// PRs are the numbers, sorted, of the upstream PRs of the release.
+	PRs []int
+}
+
+// Page returns the file name of the page of the release.
+func (r SiteRelease) Page() string {
+	return r.Tag + ".html"
+}
+
+// HTML returns the notes of the release converted into HTML.
+func (r SiteRelease) HTML() template.HTML {
+	return markdownHTML(r.Notes)
+}
+
+// siteSeries is a minor series of the index of the site, e.g. '1.14'.
+type siteSeries struct {
+	Series   string
+	Releases []SiteRelease
+}
+
+// searchEntry is the search metadata of a release, written into
+// search.json for the client-side search of the site.
+type searchEntry struct {
+	Version string `json:"version"`
+	Series  string `json:"series"`
+	Date    string `json:"date"`
+	URL     string `json:"url"`
+	PRs     []int  `json:"prs"`
+	Text    string `json:"text"`
+}
+
+const siteLayout = `<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{ template "title" . }}</title>
+</head>
+<body>
+{{ template "body" . }}
+</body>
+</html>
+`
+
+var (
+	siteIndexTemplate = template.Must(template.Must(template.New("index").Parse(siteLayout)).Parse(`
+{{- define "title" }}{{ .Repo }} releases{{ end }}
+{{- define "body" }}<h1>{{ .Repo }} releases</h1>
+{{- range .Series }}
+<h2 id="v{{ .Series }}">{{ .Series }}</h2>
+<ul>
+{{- range .Releases }}
+<li><a href="{{ .Page }}">{{ .Tag }}</a> ({{ .Date.Format "2006-01-02" }})</li>
+{{- end }}
+</ul>
+{{- end }}{{ end }}`))
+
+	sitePageTemplate = template.Must(template.Must(template.New("page").Parse(siteLayout)).Parse(`
+{{- define "title" }}{{ .Repo }} {{ .Release.Tag }}{{ end }}
+{{- define "body" }}<p><a href="index.html#v{{ .Release.Version.MinorString }}">{{ .Repo }} releases</a></p>
+<h1>{{ .Release.Tag }}</h1>
+<p>Released on {{ .Release.Date.Format "2006-01-02" }}.</p>
+{{ .Release.HTML }}{{ end }}`))
+)
+
+// splitNotes returns the parts of the published release notes body before and
+// after its sections of entries, i.e. from its 'Summary of Changes' title, or
+// its first section header, to its last entry, so that they are kept when the
+// entries are rendered again.
+func splitNotes(body string, p profile.Profile) (string, string) {
+	headers := map[string]bool{}
+	for _, s := range p.Sections {
+		headers[s.Header] = true
+	}
+	lines := strings.Split(body, "\n")
+	start, end := -1, -1
+	for i, line := range lines {
+		line = strings.TrimSpace(line)
+		switch {
+		case headers[line]:
+			if start == -1 {
+				start = i
+			}
+			end = i
+		case start != -1 && (entryRe.MatchString(line) || backportEntryRe.MatchString(line)):
+			end = i
+		}
+	}
+	if start == -1 {
+		return body, ""
+	}
+	// The title of the sections is rendered along with them.
+	title := start
+	for title > 0 && strings.TrimSpace(lines[title-1]) == "" {
+		title--
+	}
+	if title >= 2 && strings.Trim(strings.TrimSpace(lines[title-1]), "-") == "" && strings.TrimSpace(lines[title-2]) == "Summary of Changes" {
+		start = title - 2
+	}
+	before := strings.TrimRight(strings.Join(lines[:start], "\n"), "\n")
+	after := strings.TrimLeft(strings.Join(lines[end+1:], "\n"), "\n")
+	return before, after
+}
+
+// siteReleases returns the pages of the given published releases, their
+// notes rendered with the templates of cfg, from the most recent one, the
+// rest of their published notes being kept, see splitNotes. The drafts, the
+// releases not tagged with a version and, if skipPrereleases is set, the
+// pre-releases are left out.
+func siteReleases(cfg types.Config, docs config.Docs, releases []*gh.RepositoryRelease, skipPrereleases bool) ([]SiteRelease, error) {
+	var pages []SiteRelease
+	for _, release := range releases {
+		v, err := version.Parse(release.GetTagName())
+		if err != nil || release.GetDraft() || (skipPrereleases && v.IsPrerelease()) {
+			continue
+		}
+		cl := New(nil, cfg, types.BackportPRs{}, types.PullRequests{})
+		cl.docs = docs
+		cl.mergeNotes(release.GetBody(), map[int]struct{}{})
+		page := SiteRelease{
+			Tag:     release.GetTagName(),
+			Version: v,
+			Date:    release.GetPublishedAt().Time,
+			Notes:   release.GetBody(),
+		}
+		for number := range cl.upstreamPRNumbers() {
+			page.PRs = append(page.PRs, number)
+		}
+		if len(page.PRs) != 0 {
+			sort.Ints(page.PRs)
+			notes, err := cl.Render(RenderOptions{})
+			if err != nil {
+				return nil, fmt.Errorf("unable to render the notes of %s: %w", page.Tag, err)
+			}
+			before, after := splitNotes(release.GetBody(), cl.scheme())
+			page.Notes = string(notes)
+			if len(before) != 0 {
+				page.Notes = before + "\n\n" + page.Notes
+			}
+			if len(after) != 0 {
+				page.Notes = strings.TrimRight(page.Notes, "\n") + "\n\n" + after
+			}
+		}
+		pages = append(pages, page)
+	}
+	sort.SliceStable(pages, func(i, j int) bool {
+		return pages[i].Version.Compare(pages[j].Version) > 0
+	})
+	return pages, nil
+}
+
+// writeSite writes into dir the index of the releases by minor series, the
+// page of each release and their search metadata.
+func writeSite(dir, repo string, releases []SiteRelease) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	var (
+		series []siteSeries
+		search = make([]searchEntry, 0, len(releases))
+	)
+	for _, r := range releases {
+		minor := r.Version.MinorString()
+		if len(series) == 0 || series[len(series)-1].Series != minor {
+			series = append(series, siteSeries{Series: minor})
+		}
+		s := &series[len(series)-1]
+		s.Releases = append(s.Releases, r)
+
+		if err := writeSitePage(filepath.Join(dir, r.Page()), sitePageTemplate, struct {
+			Repo    string
+			Release SiteRelease
+		}{repo, r}); err != nil {
+			return err
+		}
+		search = append(search, searchEntry{
+			Version: r.Version.String(),
+			Series:  minor,
+			Date:    r.Date.Format("2006-01-02"),
+			URL:     r.Page(),
+			PRs:     r.PRs,
+			Text:    r.Notes,
+		})
+	}
+	if err := writeSitePage(filepath.Join(dir, "index.html"), siteIndexTemplate, struct {
+		Repo   string
+		Series []siteSeries
+	}{repo, series}); err != nil {
+		return err
+	}
+	b, err := json.MarshalIndent(search, "", "  ")
+	if err != nil {
+		return err
+	}
+	searchFile := filepath.Join(dir, "search.json")
+	if err := os.WriteFile(searchFile, append(b, '\n'), 0644); err != nil {
+		return err
+	}
+	report.Written(searchFile)
+	return nil
+}
+
+func writeSitePage(file string, t *template.Template, data interface{}) error {
+	f, err := os.Create(file)
+	if err != nil {
+		return err
+	}
+	if err := t.Execute(f, data); err != nil {
+		f.Close()
+		return fmt.Errorf("unable to write %s: %w", file, err)
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	report.Written(file)
+	return nil
+}
+
+// SiteCommand implements the 'site generate' subcommand, which renders the
+// notes of all the published releases of the repository into a static HTML
+// site hosting its complete release history.
+func SiteCommand(ctx context.Context, ghClient *gh.Client, args []string) error {
+	if len(args) == 0 || args[0] != "generate" {
+		return fmt.Errorf("usage: site generate [flags]")
+	}
+
+	var (
+		cfg             types.Config
+		outputDir       string
+		skipPrereleases bool
+	)
+	fs := flag.NewFlagSet("site generate", flag.ContinueOnError)
+	fs.StringVar(&cfg.RepoName, "repo", "cilium/cilium", "GitHub organization and repository names separated by a slash")
+	fs.StringVar(&cfg.Profile, "profile", profile.Default, fmt.Sprintf("Label scheme of the repository, one of %s", strings.Join(profile.Names(), ", ")))
+	fs.StringVar(&cfg.TemplateDir, "template-dir", "", "Directory of templates overriding the default ones the release notes are rendered with")
+	fs.StringVar(&cfg.ConfigFile, "config", "", "Configuration file whose docs links the entries and sections of the release notes to the documentation")
+	fs.StringVarP(&outputDir, "output-dir", "o", "site", "Directory the site is written into")
+	fs.BoolVar(&skipPrereleases, "skip-prereleases", false, "Leave the pre-releases out of the site")
+	if err := types.ParseFlags(fs, "site generate", args[1:]); err != nil {
+		return err
+	}
+	var err error
+	cfg.Owner, cfg.Repo, err = types.SplitRepoName(cfg.RepoName)
+	if err != nil {
+		return err
+	}
+	docs, err := loadDocs(cfg)
+	if err != nil {
+		return err
+	}
+
+	releases, err := github.ListReleases(ctx, ghClient, cfg.Owner, cfg.Repo)
+	if err != nil {
+		return fmt.Errorf("unable to list releases: %w", err)
+	}
+	pages, err := siteReleases(cfg, docs, releases, skipPrereleases)
+	if err != nil {
+		return err
+	}
+	if err := writeSite(outputDir, cfg.RepoName, pages); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "Site of the %d releases of %s written into %s\n", len(pages), cfg.RepoName, outputDir)
+	return nil
+}
@@ -0,0 +1,151 @@
+// Copyright 2021 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package changelog
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	gh "github.com/google/go-github/v50/github"
+
+	"github.com/cilium/release/pkg/config"
+	"github.com/cilium/release/pkg/types"
+)
+
+func TestGenerateSite(t *testing.T) {
+	release := func(tag, date, body string, draft bool) *gh.RepositoryRelease {
+		published, _ := time.Parse("2006-01-02", date)
+		return &gh.RepositoryRelease{
+			TagName:     gh.String(tag),
+			Body:        gh.String(body),
+			Draft:       gh.Bool(draft),
+			PublishedAt: &gh.Timestamp{Time: published},
+		}
+	}
+	releases := []*gh.RepositoryRelease{
+		release("v1.14.1", "2023-08-10", "**Bugfixes:**\n* Fix bar (Backport PR #200, Upstream PR #124, @bob)\n", false),
+		release("v1.15.0-rc.0", "2023-09-01", "**Minor Changes:**\n* Add foo (#123, @alice)\n", false),
+		release("v1.14.2", "2023-09-05", "", true),
+		release("v1.13.0", "2023-01-10", "The first release of 1.13.\n", false),
+		release("nightly", "2023-09-06", "", false),
+		release("v1.14.0", "2023-07-20", "**Major Changes:**\n* Add everything (#100, @dave)\n", false),
+	}
+
+	pages, err := siteReleases(types.Config{}, config.Docs{}, releases, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var tags []string
+	for _, p := range pages {
+		tags = append(tags, p.Tag)
+	}
+	if want := []string{"v1.15.0-rc.0", "v1.14.1", "v1.14.0", "v1.13.0"}; !reflect.DeepEqual(tags, want) {
+		t.Fatalf("got releases %v, want %v", tags, want)
+	}
+	if want := "The first release of 1.13.\n"; pages[3].Notes != want {
+		t.Errorf("got notes %q of v1.13.0, want the published ones %q", pages[3].Notes, want)
+	}
+
+	dir := t.TempDir()
+	if err := writeSite(dir, "cilium/cilium", pages); err != nil {
+		t.Fatal(err)
+	}
+	index, err := os.ReadFile(filepath.Join(dir, "index.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		`<h2 id="v1.15">1.15</h2>`,
+		`<li><a href="v1.14.1.html">v1.14.1</a> (2023-08-10)</li>`,
+		`<h2 id="v1.13">1.13</h2>`,
+	} {
+		if !strings.Contains(string(index), want) {
+			t.Errorf("index doesn't contain %q:\n%s", want, index)
+		}
+	}
+	page, err := os.ReadFile(filepath.Join(dir, "v1.14.1.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		`<title>cilium/cilium v1.14.1</title>`,
+		`<a href="index.html#v1.14">`,
+		`<li>Fix bar (Backport PR #200, Upstream PR #124, @bob)</li>`,
+	} {
+		if !strings.Contains(string(page), want) {
+			t.Errorf("page doesn't contain %q:\n%s", want, page)
+		}
+	}
+
+	b, err := os.ReadFile(filepath.Join(dir, "search.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var search []searchEntry
+	if err := json.Unmarshal(b, &search); err != nil {
+		t.Fatal(err)
+	}
+	if len(search) != 4 {
+		t.Fatalf("got %d search entries, want 4", len(search))
+	}
+	if got := search[1]; got.Version != "1.14.1" || got.Series != "1.14" || got.Date != "2023-08-10" ||
+		got.URL != "v1.14.1.html" || !reflect.DeepEqual(got.PRs, []int{124}) {
+		t.Errorf("got search entry %+v of v1.14.1", got)
+	}
+}
+
+func TestSiteReleasesKeepPublishedParts(t *testing.T) {
+	body := "Upgrading from 1.13 requires the new CRDs.\n" +
+		"\n" +
+		"Summary of Changes\n" +
+		"------------------\n" +
+		"\n" +
+		"**Bugfixes:**\n" +
+		"* Fix bar (Backport PR #200, Upstream PR #124, @bob)\n" +
+		"\n" +
+		"Docker Manifests\n" +
+		"----------------\n" +
+		"\n" +
+		"quay.io/cilium/cilium:v1.14.1@sha256:0123\n"
+	releases := []*gh.RepositoryRelease{{
+		TagName:     gh.String("v1.14.1"),
+		Body:        gh.String(body),
+		PublishedAt: &gh.Timestamp{Time: time.Date(2023, 8, 10, 0, 0, 0, 0, time.UTC)},
+	}}
+	pages, err := siteReleases(types.Config{}, config.Docs{}, releases, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "Upgrading from 1.13 requires the new CRDs.\n" +
+		"\n" +
+		"Summary of Changes\n" +
+		"------------------\n" +
+		"\n" +
+		"**Bugfixes:**\n" +
+		"* Fix bar (Backport PR #200, Upstream PR #124, @bob)\n" +
+		"\n" +
+		"Docker Manifests\n" +
+		"----------------\n" +
+		"\n" +
+		"quay.io/cilium/cilium:v1.14.1@sha256:0123\n"
+	if len(pages) != 1 || pages[0].Notes != want {
+		t.Errorf("got notes:\n%s\nwant:\n%s", pages[0].Notes, want)
+	}
+}
@@ -0,0 +1,282 @@
+// Copyright 2021 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package changelog
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	gh "github.com/google/go-github/v50/github"
+	flag "github.com/spf13/pflag"
+
+	"github.com/cilium/release/pkg/profile"
+	"github.com/cilium/release/pkg/report"
+	"github.com/cilium/release/pkg/types"
+)
+
+const (
+	StatsFormatMarkdown = "markdown"
+	StatsFormatCSV      = "csv"
+
+	areaLabelPrefix = "area/"
+)
+
+// ContributorStats are the PRs of an author in the release notes.
+type ContributorStats struct {
+	Author string
+	PRs    int
+	// New is set if the first merged PR of Author is part of the release
+	// notes.
+	New bool
+	// Areas maps the areas, e.g. 'clustermesh' for 'area/clustermesh', of
+	// the PRs of Author to their number of PRs.
+	Areas map[string]int
+}
+
+// AreaStats are the PRs of an area in the release notes.
+type AreaStats struct {
+	Area         string
+	PRs          int
+	Contributors int
+}
+
+// ContributorStats returns the leaderboards of the authors and of the areas
+// of the PRs in the release notes, sorted by decreasing number of PRs. The
+// changes without any PR are not counted.
+func (cl *ChangeLog) ContributorStats() ([]ContributorStats, []AreaStats) {
+	byAuthor := map[string]*ContributorStats{}
+	byArea := map[string]*AreaStats{}
+	areaAuthors := map[string]map[string]struct{}{}
+	for _, section := range cl.Sections() {
+		for _, entry := range section.Entries {
+			if entry.PR <= 0 || len(entry.Author) == 0 {
+				continue
+			}
+			c, ok := byAuthor[entry.Author]
+			if !ok {
+				c = &ContributorStats{
+					Author: entry.Author,
+					New:    cl.newContributors[entry.Author],
+					Areas:  map[string]int{},
+				}
+				byAuthor[entry.Author] = c
+			}
+			c.PRs++
+			for _, lbl := range entry.Labels {
+				if !strings.HasPrefix(lbl, areaLabelPrefix) {
+					continue
+				}
+				area := strings.TrimPrefix(lbl, areaLabelPrefix)
+				c.Areas[area]++
+				a, ok := byArea[area]
+				if !ok {
+					a = &AreaStats{Area: area}
+					byArea[area] = a
+					areaAuthors[area] = map[string]struct{}{}
+				}
+				a.PRs++
+				areaAuthors[area][entry.Author] = struct{}{}
+			}
+		}
+	}
+
+	contributors := make([]ContributorStats, 0, len(byAuthor))
+	for _, c := range byAuthor {
+		contributors = append(contributors, *c)
+	}
+	sort.Slice(contributors, func(i, j int) bool {
+		if contributors[i].PRs != contributors[j].PRs {
+			return contributors[i].PRs > contributors[j].PRs
+		}
+		return contributors[i].Author < contributors[j].Author
+	})
+	areas := make([]AreaStats, 0, len(byArea))
+	for area, a := range byArea {
+		a.Contributors = len(areaAuthors[area])
+		areas = append(areas, *a)
+	}
+	sort.Slice(areas, func(i, j int) bool {
+		if areas[i].PRs != areas[j].PRs {
+			return areas[i].PRs > areas[j].PRs
+		}
+		return areas[i].Area < areas[j].Area
+	})
+	return contributors, areas
+}
+
+// sortedAreas returns the areas of c, sorted by decreasing number of PRs.
+func (c ContributorStats) sortedAreas() []string {
+	areas := make([]string, 0, len(c.Areas))
+	for area := range c.Areas {
+		areas = append(areas, area)
+	}
+	sort.Slice(areas, func(i, j int) bool {
+		if c.Areas[areas[i]] != c.Areas[areas[j]] {
+			return c.Areas[areas[i]] > c.Areas[areas[j]]
+		}
+		return areas[i] < areas[j]
+	})
+	return areas
+}
+
+func (c ContributorStats) kind() string {
+	if c.New {
+		return "new"
+	}
+	return "returning"
+}
+
+// writeStatsMarkdown writes the leaderboards as Markdown tables.
+func writeStatsMarkdown(w io.Writer, base, head string, contributors []ContributorStats, areas []AreaStats) error {
+	var newContributors int
+	for _, c := range contributors {
+		if c.New {
+			newContributors++
+		}
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Contributors between %s and %s\n\n", base, head)
+	fmt.Fprintf(&b, "%d contributors, %d new and %d returning.\n\n", len(contributors), newContributors, len(contributors)-newContributors)
+	fmt.Fprintf(&b, "| # | Contributor | PRs | | Areas |\n")
+	fmt.Fprintf(&b, "|---|---|---|---|---|\n")
+	for i, c := range contributors {
+		fmt.Fprintf(&b, "| %d | @%s | %d | %s | %s |\n", i+1, c.Author, c.PRs, c.kind(), strings.Join(c.sortedAreas(), ", "))
+	}
+	if len(areas) != 0 {
+		fmt.Fprintf(&b, "\n## Areas\n\n")
+		fmt.Fprintf(&b, "| # | Area | PRs | Contributors |\n")
+		fmt.Fprintf(&b, "|---|---|---|---|\n")
+		for i, a := range areas {
+			fmt.Fprintf(&b, "| %d | %s | %d | %d |\n", i+1, a.Area, a.PRs, a.Contributors)
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// writeStatsCSV writes the contributors leaderboard as CSV, with the areas
+// of each contributor separated by semicolons.
+func writeStatsCSV(w io.Writer, contributors []ContributorStats) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{"rank", "author", "prs", "contributor", "areas"}); err != nil {
+		return err
+	}
+	for i, c := range contributors {
+		record := []string{
+			strconv.Itoa(i + 1),
+			c.Author,
+			strconv.Itoa(c.PRs),
+			c.kind(),
+			strings.Join(c.sortedAreas(), ";"),
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// StatsCommand implements the 'stats' subcommand, dispatching to 'stats
+// contributors' and 'stats backports'.
+func StatsCommand(ctx context.Context, ghClient *gh.Client, args []string) error {
+	if len(args) != 0 {
+		switch args[0] {
+		case "contributors":
+			return contributorsCommand(ctx, ghClient, args[1:])
+		case "backports":
+			return backportsCommand(ctx, ghClient, args[1:])
+		}
+	}
+	return fmt.Errorf("usage: stats {contributors|backports} [flags]")
+}
+
+// contributorsCommand implements the 'stats contributors' subcommand, which
+// aggregates the PRs merged between --base and --head by author and by area
+// into leaderboards, telling the new contributors apart from the returning
+// ones.
+func contributorsCommand(ctx context.Context, ghClient *gh.Client, args []string) error {
+	var (
+		cfg    types.Config
+		format string
+		output string
+	)
+	fs := flag.NewFlagSet("stats contributors", flag.ContinueOnError)
+	fs.StringVar(&cfg.RepoName, "repo", "cilium/cilium", "GitHub organization and repository names separated by a slash")
+	fs.StringVar(&cfg.UpstreamRepoName, "upstream-repo", "", "GitHub organization and repository names, separated by a slash, of the upstream PRs referenced by backport PRs, if different from --repo")
+	fs.StringVar(&cfg.Base, "base", "", "Base commit / tag of the range")
+	fs.StringVar(&cfg.Head, "head", "", "Head commit of the range")
+	fs.StringSliceVar(&cfg.LastStable, "last-stable", nil, "Last stable versions whose backported PRs are not counted (e.g.: '1.5', '1.6'). Can be repeated or comma-separated")
+	fs.StringVar(&cfg.CacheDir, "cache-dir", "", "Directory of the PR metadata cache shared across runs, releases and branches, e.g. ~/.cache/cilium-release. The cached PRs updated since the previous run are dropped. Disabled if empty")
+	fs.StringVar(&cfg.Profile, "profile", profile.Default, fmt.Sprintf("Label scheme of the repository, one of %s", strings.Join(profile.Names(), ", ")))
+	fs.StringVar(&format, "format", StatsFormatMarkdown, fmt.Sprintf("Format of the leaderboards, one of %s, %s", StatsFormatMarkdown, StatsFormatCSV))
+	fs.StringVar(&output, "output", "", "File where the leaderboards are written instead of the standard output")
+	if err := types.ParseFlags(fs, "stats contributors", args); err != nil {
+		return err
+	}
+	switch format {
+	case StatsFormatMarkdown, StatsFormatCSV:
+	default:
+		return fmt.Errorf("unknown format %q", format)
+	}
+
+	stateDir, err := os.MkdirTemp("", "release-stats")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(stateDir)
+	cfg.StateFile = filepath.Join(stateDir, "state.json")
+	cfg.ThankNewContributors = true
+
+	if err := cfg.Sanitize(); err != nil {
+		return err
+	}
+
+	cl, err := GenerateReleaseNotes(ctx, ghClient, cfg, nil)
+	if err != nil {
+		return err
+	}
+	contributors, areas := cl.ContributorStats()
+
+	write := func(w io.Writer) error {
+		if format == StatsFormatCSV {
+			return writeStatsCSV(w, contributors)
+		}
+		return writeStatsMarkdown(w, cfg.Base, cfg.Head, contributors, areas)
+	}
+	if len(output) == 0 {
+		return write(os.Stdout)
+	}
+	f, err := os.Create(output)
+	if err != nil {
+		return err
+	}
+	if err := write(f); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	report.Written(output)
+	return nil
+}
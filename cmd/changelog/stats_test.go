@@ -0,0 +1,76 @@
+// Copyright 2021 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package changelog
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/cilium/release/pkg/types"
+)
+
+func TestContributorStats(t *testing.T) {
+	cl := &ChangeLog{
+		listOfPrs: types.PullRequests{
+			1: {ReleaseNote: "Add foo", ReleaseLabel: "release-note/minor", AuthorName: "alice", Labels: []string{"release-note/minor", "area/cli"}},
+			2: {ReleaseNote: "Fix foo", ReleaseLabel: "release-note/bug", AuthorName: "alice", Labels: []string{"release-note/bug", "area/cli", "area/bgp"}},
+			3: {ReleaseNote: "Fix bar", ReleaseLabel: "release-note/bug", AuthorName: "bob", Labels: []string{"release-note/bug", "area/bgp"}},
+			4: {ReleaseNote: "Bump deps", ReleaseLabel: "release-note/misc", AuthorName: "carol"},
+		},
+		newContributors: map[string]bool{
+			"alice": false,
+			"bob":   true,
+			"carol": false,
+		},
+	}
+
+	contributors, areas := cl.ContributorStats()
+
+	var md bytes.Buffer
+	if err := writeStatsMarkdown(&md, "v1.14.0", "v1.14.1", contributors, areas); err != nil {
+		t.Fatal(err)
+	}
+	want := "# Contributors between v1.14.0 and v1.14.1\n" +
+		"\n" +
+		"3 contributors, 1 new and 2 returning.\n" +
+		"\n" +
+		"| # | Contributor | PRs | | Areas |\n" +
+		"|---|---|---|---|---|\n" +
+		"| 1 | @alice | 2 | returning | cli, bgp |\n" +
+		"| 2 | @bob | 1 | new | bgp |\n" +
+		"| 3 | @carol | 1 | returning |  |\n" +
+		"\n" +
+		"## Areas\n" +
+		"\n" +
+		"| # | Area | PRs | Contributors |\n" +
+		"|---|---|---|---|\n" +
+		"| 1 | bgp | 2 | 2 |\n" +
+		"| 2 | cli | 2 | 1 |\n"
+	if md.String() != want {
+		t.Errorf("got:\n%s\nwant:\n%s", md.String(), want)
+	}
+
+	var csv bytes.Buffer
+	if err := writeStatsCSV(&csv, contributors); err != nil {
+		t.Fatal(err)
+	}
+	want = "rank,author,prs,contributor,areas\n" +
+		"1,alice,2,returning,cli;bgp\n" +
+		"2,bob,1,new,bgp\n" +
+		"3,carol,1,returning,\n"
+	if csv.String() != want {
+		t.Errorf("got:\n%s\nwant:\n%s", csv.String(), want)
+	}
+}
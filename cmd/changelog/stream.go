@@ -0,0 +1,162 @@
+// Copyright 2021 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package changelog
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+
+	"github.com/cilium/release/pkg/config"
+	"github.com/cilium/release/pkg/report"
+	"github.com/cilium/release/pkg/types"
+)
+
+const (
+	StreamFormatJSONLines = "jsonl"
+	StreamFormatMarkdown  = "markdown"
+)
+
+// StreamEntry is a changelog entry emitted as soon as its PR is resolved.
+type StreamEntry struct {
+	Section      string `json:"section"`
+	ReleaseLabel string `json:"releaseLabel"`
+	PR           int    `json:"pr"`
+	BackportPR   int    `json:"backportPR,omitempty"`
+	Author       string `json:"author"`
+	ReleaseNote  string `json:"releaseNote"`
+	// Excluded is set for PRs that were backported to one of the last
+	// stable branches and therefore will not be part of the notes.
+	Excluded bool   `json:"excluded,omitempty"`
+	Line     string `json:"line"`
+}
+
+// streamer writes the changelog entries into a file while the PRs are
+// being fetched.
+type streamer struct {
+	cl     *ChangeLog
+	format string
+	w      io.WriteCloser
+	err    error
+	// section is the section of the last markdown entry written.
+	section string
+}
+
+// newStreamer creates the stream file and writes into it the entries of the
+// PRs already resolved, e.g. restored from the state file, so that a resumed
+// run streams all the entries of the range.
+func newStreamer(cfg types.Config, authors config.Authors, backportPRs types.BackportPRs, listOfPRs types.PullRequests) (*streamer, error) {
+	if len(cfg.StreamFile) == 0 {
+		return nil, nil
+	}
+	switch cfg.StreamFormat {
+	case StreamFormatJSONLines, StreamFormatMarkdown:
+	default:
+		return nil, fmt.Errorf("unknown stream format %q", cfg.StreamFormat)
+	}
+	f, err := os.Create(cfg.StreamFile)
+	if err != nil {
+		return nil, err
+	}
+	report.Written(cfg.StreamFile)
+	s := &streamer{
+		cl:     &ChangeLog{Config: cfg, authors: authors},
+		format: cfg.StreamFormat,
+		w:      f,
+	}
+	for _, prNumber := range sortedPRNumbers(listOfPRs) {
+		s.write(0, prNumber, listOfPRs[prNumber])
+	}
+	for _, backportPR := range sortedBackportPRNumbers(backportPRs) {
+		upstreamPRs := backportPRs[backportPR]
+		for _, prNumber := range sortedPRNumbers(upstreamPRs) {
+			s.write(backportPR, prNumber, upstreamPRs[prNumber])
+		}
+	}
+	return s, nil
+}
+
+func sortedPRNumbers(prs types.PullRequests) []int {
+	numbers := make([]int, 0, len(prs))
+	for number := range prs {
+		numbers = append(numbers, number)
+	}
+	sort.Ints(numbers)
+	return numbers
+}
+
+func sortedBackportPRNumbers(prs types.BackportPRs) []int {
+	numbers := make([]int, 0, len(prs))
+	for number := range prs {
+		numbers = append(numbers, number)
+	}
+	sort.Ints(numbers)
+	return numbers
+}
+
+// write emits the entry of the given PR. Write errors are kept and returned
+// by close so that fetching the PRs is not interrupted.
+func (s *streamer) write(backportPR, prNumber int, pr types.PullRequest) {
+	if s.err != nil {
+		return
+	}
+	// The PRs of a security fork are only disclosed once the release
+	// notes are generated.
+	if len(s.cl.SecurityFork) != 0 {
+		pr.Repo = s.cl.SecurityFork
+	}
+	releaseLabel := s.cl.releaseLabel(pr)
+	entry := StreamEntry{
+		Section:      s.cl.scheme().Header(releaseLabel),
+		ReleaseLabel: releaseLabel,
+		PR:           prNumber,
+		BackportPR:   backportPR,
+		Author:       pr.AuthorName,
+		ReleaseNote:  pr.ReleaseNote,
+		Excluded:     backportPR == 0 && s.cl.backportedToLastStable(pr),
+		Line:         s.cl.entry(backportPR, prNumber, pr).String(),
+	}
+	switch s.format {
+	case StreamFormatJSONLines:
+		s.err = json.NewEncoder(s.w).Encode(entry)
+	case StreamFormatMarkdown:
+		if entry.Excluded {
+			return
+		}
+		// The PRs aren't resolved in section order, the header is
+		// repeated whenever the section changes.
+		if entry.Section != s.section {
+			sep := "\n"
+			if len(s.section) == 0 {
+				sep = ""
+			}
+			s.section = entry.Section
+			if _, s.err = fmt.Fprintf(s.w, "%s%s\n", sep, entry.Section); s.err != nil {
+				return
+			}
+		}
+		_, s.err = fmt.Fprintf(s.w, "%s\n", entry.Line)
+	}
+}
+
+func (s *streamer) close() error {
+	err := s.w.Close()
+	if s.err != nil {
+		return s.err
+	}
+	return err
+}
@@ -0,0 +1,113 @@
+// Copyright 2021 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package changelog
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/cilium/release/pkg/types"
+)
+
+func TestStreamer(t *testing.T) {
+	bug := types.PullRequest{
+		ReleaseNote:  "Fix foo",
+		ReleaseLabel: "release-note/bug",
+		AuthorName:   "alice",
+	}
+	released := types.PullRequest{
+		ReleaseNote:      "Add bar",
+		ReleaseLabel:     "release-note/minor",
+		AuthorName:       "bob",
+		BackportBranches: []string{"needs-backport/1.13"},
+	}
+
+	tests := []struct {
+		format string
+		want   string
+	}{
+		{
+			format: StreamFormatJSONLines,
+			want: `{"section":"**Bugfixes:**","releaseLabel":"release-note/bug","pr":10,"backportPR":20,"author":"alice","releaseNote":"Fix foo","line":"* Fix foo (Backport PR #20, Upstream PR #10, @alice)"}` + "\n" +
+				`{"section":"**Minor Changes:**","releaseLabel":"release-note/minor","pr":11,"author":"bob","releaseNote":"Add bar","excluded":true,"line":"* Add bar (#11, @bob)"}` + "\n",
+		},
+		{
+			format: StreamFormatMarkdown,
+			want: "**Bugfixes:**\n" +
+				"* Fix foo (Backport PR #20, Upstream PR #10, @alice)\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			file := filepath.Join(t.TempDir(), "stream")
+			s, err := newStreamer(types.Config{
+				LastStable:   []string{"1.13"},
+				StreamFile:   file,
+				StreamFormat: tt.format,
+			}, nil, nil, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			s.write(20, 10, bug)
+			s.write(0, 11, released)
+			if err := s.close(); err != nil {
+				t.Fatal(err)
+			}
+			got, err := os.ReadFile(file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("got:\n%s\nwant:\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStreamerResume(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "stream")
+	if err := os.WriteFile(file, []byte("stale\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	restored := types.PullRequests{
+		12: {ReleaseNote: "Fix baz", ReleaseLabel: "release-note/bug", AuthorName: "carol"},
+		11: {ReleaseNote: "Add qux", ReleaseLabel: "release-note/minor", AuthorName: "bob"},
+	}
+	s, err := newStreamer(types.Config{
+		StreamFile:   file,
+		StreamFormat: StreamFormatMarkdown,
+	}, nil, nil, restored)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.write(0, 13, types.PullRequest{ReleaseNote: "Fix foo", ReleaseLabel: "release-note/bug", AuthorName: "alice"})
+	if err := s.close(); err != nil {
+		t.Fatal(err)
+	}
+	got, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "**Minor Changes:**\n" +
+		"* Add qux (#11, @bob)\n" +
+		"\n" +
+		"**Bugfixes:**\n" +
+		"* Fix baz (#12, @carol)\n" +
+		"* Fix foo (#13, @alice)\n"
+	if string(got) != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
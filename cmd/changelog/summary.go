@@ -0,0 +1,110 @@
+// Copyright 2021 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package changelog
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// TopEntries returns the n entries of the release notes with the highest
+// priority. Entries with one of the given labels come first, by the order of
+// the labels. If reactions is not nil, the entries with the most reactions
+// come next, followed by the entries of the sections rendered first, e.g.
+// major changes before bugfixes. The reactions are only looked up for the
+// entries outside of the low-value sections, e.g. the CI changes.
+func (cl *ChangeLog) TopEntries(n int, priorityLabels []string, reactions func(Entry) int) []Entry {
+	type rankedEntry struct {
+		Entry
+		labelRank   int
+		reactions   int
+		sectionRank int
+	}
+	p := cl.scheme()
+	lowValue := map[string]bool{p.CILabel: true}
+	for _, lbl := range p.CollapsibleLabels {
+		lowValue[lbl] = true
+	}
+	var ranked []rankedEntry
+	for i, section := range cl.Sections() {
+		for _, entry := range section.Entries {
+			r := rankedEntry{
+				Entry:       entry,
+				labelRank:   labelRank(entry.Labels, priorityLabels),
+				sectionRank: i,
+			}
+			if reactions != nil && !lowValue[section.Label] {
+				r.reactions = reactions(entry)
+			}
+			ranked = append(ranked, r)
+		}
+	}
+	sort.SliceStable(ranked, func(i, j int) bool {
+		if ranked[i].labelRank != ranked[j].labelRank {
+			return ranked[i].labelRank < ranked[j].labelRank
+		}
+		if ranked[i].reactions != ranked[j].reactions {
+			return ranked[i].reactions > ranked[j].reactions
+		}
+		return ranked[i].sectionRank < ranked[j].sectionRank
+	})
+
+	if n > len(ranked) {
+		n = len(ranked)
+	}
+	entries := make([]Entry, 0, n)
+	for _, r := range ranked[:n] {
+		entries = append(entries, r.Entry)
+	}
+	return entries
+}
+
+// labelRank returns the index of the first of priorityLabels present in
+// labels, or len(priorityLabels) if none is present.
+func labelRank(labels, priorityLabels []string) int {
+	for i, priorityLabel := range priorityLabels {
+		for _, label := range labels {
+			if label == priorityLabel {
+				return i
+			}
+		}
+	}
+	return len(priorityLabels)
+}
+
+// Summary returns a short paragraph with the n entries of the release notes
+// with the highest priority, see TopEntries, suitable for announcements.
+func (cl *ChangeLog) Summary(n int, priorityLabels []string, reactions func(Entry) int) string {
+	total := 0
+	for _, section := range cl.Sections() {
+		total += len(section.Entries)
+	}
+	changes := "changes"
+	if total == 1 {
+		changes = "change"
+	}
+	summary := fmt.Sprintf("This release contains %d %s.", total, changes)
+
+	top := cl.TopEntries(n, priorityLabels, reactions)
+	if len(top) == 0 {
+		return summary
+	}
+	highlights := make([]string, 0, len(top))
+	for _, entry := range top {
+		highlights = append(highlights, fmt.Sprintf("%s (%s)", strings.TrimSuffix(entry.ReleaseNote, "."), entry.ref()))
+	}
+	return fmt.Sprintf("%s Highlights: %s.", summary, strings.Join(highlights, "; "))
+}
@@ -0,0 +1,111 @@
+// Copyright 2021 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package changelog
+
+import (
+	"testing"
+
+	"github.com/cilium/release/pkg/types"
+)
+
+func TestSummary(t *testing.T) {
+	cl := &ChangeLog{
+		listOfPrs: types.PullRequests{
+			1: {ReleaseNote: "Fix foo.", ReleaseLabel: "release-note/bug", AuthorName: "alice"},
+			2: {ReleaseNote: "Add bar", ReleaseLabel: "release-note/minor", AuthorName: "bob"},
+			3: {ReleaseNote: "Add baz", ReleaseLabel: "release-note/major", AuthorName: "carol"},
+			4: {
+				ReleaseNote:  "Fix CVE",
+				ReleaseLabel: "release-note/bug",
+				AuthorName:   "dave",
+				Labels:       []string{"release-note/bug", "kind/security"},
+			},
+		},
+	}
+
+	tests := []struct {
+		name           string
+		n              int
+		priorityLabels []string
+		reactions      map[int]int
+		want           string
+	}{
+		{
+			name: "by section",
+			n:    3,
+			want: "This release contains 4 changes. Highlights: Add baz (#3); Add bar (#2); Fix CVE (#4).",
+		},
+		{
+			name:           "priority labels first",
+			n:              2,
+			priorityLabels: []string{"kind/security"},
+			want:           "This release contains 4 changes. Highlights: Fix CVE (#4); Add baz (#3).",
+		},
+		{
+			name:           "reactions after priority labels",
+			n:              3,
+			priorityLabels: []string{"kind/security"},
+			reactions:      map[int]int{1: 10, 2: 3},
+			want:           "This release contains 4 changes. Highlights: Fix CVE (#4); Fix foo (#1); Add bar (#2).",
+		},
+		{
+			name: "no highlights",
+			n:    0,
+			want: "This release contains 4 changes.",
+		},
+		{
+			name: "more than available",
+			n:    10,
+			want: "This release contains 4 changes. Highlights: Add baz (#3); Add bar (#2); Fix CVE (#4); Fix foo (#1).",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var reactions func(Entry) int
+			if tt.reactions != nil {
+				reactions = func(e Entry) int { return tt.reactions[e.PR] }
+			}
+			if got := cl.Summary(tt.n, tt.priorityLabels, reactions); got != tt.want {
+				t.Errorf("Summary() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTagMessage(t *testing.T) {
+	cl := &ChangeLog{
+		Config: types.Config{Owner: "cilium", Repo: "cilium"},
+		listOfPrs: types.PullRequests{
+			123: {ReleaseNote: "Add foo.", AuthorName: "alice", Labels: []string{"release-note/minor"}},
+			124: {ReleaseNote: "Add baz", AuthorName: "carol", Labels: []string{"release-note/minor", "priority/high"}},
+			125: {ReleaseNote: "Fix bar", AuthorName: "bob", Labels: []string{"release-note/bug"}},
+		},
+	}
+	got := cl.TagMessage("v1.14.3", 2, []string{"priority/high"})
+	want := "Release v1.14.3\n" +
+		"\n" +
+		"Summary of Changes:\n" +
+		"- Minor Changes: 2\n" +
+		"- Bugfixes: 1\n" +
+		"\n" +
+		"Highlights:\n" +
+		"- Add baz (#124)\n" +
+		"- Add foo (#123)\n" +
+		"\n" +
+		"See https://github.com/cilium/cilium/releases/tag/v1.14.3 for the full release notes.\n"
+	if got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
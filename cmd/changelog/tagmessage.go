@@ -0,0 +1,120 @@
+// Copyright 2021 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package changelog
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	gh "github.com/google/go-github/v50/github"
+	flag "github.com/spf13/pflag"
+
+	"github.com/cilium/release/pkg/profile"
+	"github.com/cilium/release/pkg/report"
+	"github.com/cilium/release/pkg/types"
+)
+
+// TagMessageCommand implements the 'tag-message' subcommand, which renders
+// a condensed changelog of the changes between --base and --head for the
+// message of the annotated tag of the release, e.g. with
+// 'git tag -a v1.14.3 -F <file>'.
+func TagMessageCommand(ctx context.Context, ghClient *gh.Client, args []string) error {
+	var (
+		cfg    types.Config
+		tag    string
+		output string
+	)
+	fs := flag.NewFlagSet("tag-message", flag.ContinueOnError)
+	fs.StringVar(&cfg.RepoName, "repo", "cilium/cilium", "GitHub organization and repository names separated by a slash")
+	fs.StringVar(&cfg.UpstreamRepoName, "upstream-repo", "", "GitHub organization and repository names, separated by a slash, of the upstream PRs referenced by backport PRs, if different from --repo")
+	fs.StringVar(&cfg.Base, "base", "", "Base commit / tag of the release")
+	fs.StringVar(&cfg.Head, "head", "", "Head commit of the release")
+	fs.StringVar(&tag, "tag", "", "Tag of the release, --head if empty")
+	fs.StringSliceVar(&cfg.LastStable, "last-stable", nil, "Last stable versions whose backported PRs are excluded (e.g.: '1.5', '1.6'). Can be repeated or comma-separated")
+	fs.StringVar(&cfg.StateFile, "state-file", "", "State file of the release notes, reused so that the message matches the published notes. The changes are looked up from scratch if empty")
+	fs.StringVar(&cfg.CacheDir, "cache-dir", "", "Directory of the PR metadata cache shared across runs, releases and branches, e.g. ~/.cache/cilium-release. The cached PRs updated since the previous run are dropped. Disabled if empty")
+	fs.StringVar(&cfg.Profile, "profile", profile.Default, fmt.Sprintf("Label scheme of the repository, one of %s", strings.Join(profile.Names(), ", ")))
+	fs.IntVar(&cfg.Top, "top", 5, "Number of highlights")
+	fs.StringSliceVar(&cfg.PriorityLabels, "priority-labels", nil, "Labels, by decreasing priority, of the entries highlighted first")
+	fs.StringVar(&output, "output", "", "File where the message is written instead of the standard output")
+	if err := types.ParseFlags(fs, "tag-message", args); err != nil {
+		return err
+	}
+	if len(tag) == 0 {
+		tag = cfg.Head
+	}
+
+	if len(cfg.StateFile) == 0 {
+		stateDir, err := os.MkdirTemp("", "release-tag-message")
+		if err != nil {
+			return err
+		}
+		defer os.RemoveAll(stateDir)
+		cfg.StateFile = filepath.Join(stateDir, "state.json")
+	}
+
+	if err := cfg.Sanitize(); err != nil {
+		return err
+	}
+
+	cl, err := GenerateReleaseNotes(ctx, ghClient, cfg, nil)
+	if err != nil {
+		return err
+	}
+	msg := cl.TagMessage(tag, cfg.Top, cfg.PriorityLabels)
+	if len(output) == 0 {
+		_, err := fmt.Fprint(os.Stdout, msg)
+		return err
+	}
+	if err := os.WriteFile(output, []byte(msg), 0644); err != nil {
+		return err
+	}
+	report.Written(output)
+	return nil
+}
+
+// TagMessage returns the message of the annotated tag of the release: the
+// number of entries of each section and the n entries with the highest
+// priority, see TopEntries, as plain text.
+func (cl *ChangeLog) TagMessage(tag string, n int, priorityLabels []string) string {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "Release %s\n", tag)
+
+	sections := cl.Sections()
+	if len(sections) != 0 {
+		fmt.Fprintln(&buf)
+		fmt.Fprintln(&buf, "Summary of Changes:")
+		for _, section := range sections {
+			name := strings.TrimSuffix(strings.Trim(section.Header, "*"), ":")
+			fmt.Fprintf(&buf, "- %s: %d\n", name, len(section.Entries))
+		}
+	}
+
+	if top := cl.TopEntries(n, priorityLabels, nil); len(top) != 0 {
+		fmt.Fprintln(&buf)
+		fmt.Fprintln(&buf, "Highlights:")
+		for _, entry := range top {
+			fmt.Fprintf(&buf, "- %s (%s)\n", strings.TrimSuffix(entry.ReleaseNote, "."), entry.ref())
+		}
+	}
+
+	fmt.Fprintln(&buf)
+	fmt.Fprintf(&buf, "See https://github.com/%s/%s/releases/tag/%s for the full release notes.\n", cl.Owner, cl.Repo, tag)
+	return buf.String()
+}
@@ -0,0 +1,101 @@
+// Copyright 2021 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package changelog
+
+import (
+	"embed"
+	"fmt"
+	"os"
+	"path/filepath"
+	"text/template"
+)
+
+// defaultTemplates are the templates the release notes are rendered with in
+// the markdown format: notes.md.tmpl renders the whole notes,
+// section.md.tmpl each section and entry.md.tmpl each entry.
+//
+//go:embed templates/*.tmpl
+var defaultTemplates embed.FS
+
+var defaultTemplate = template.Must(template.ParseFS(defaultTemplates, "templates/*.tmpl"))
+
+// notesData is the data notes.md.tmpl is executed with.
+type notesData struct {
+	// Components are the components touched by the changes, if
+	// summarized.
+	Components []ComponentStats
+	Sections   []sectionData
+	// Thanks is the line thanking the new contributors, if any.
+	Thanks string
+	// PreviouslyReleased is the 'Previously Released' appendix, if any.
+	PreviouslyReleased string
+	Reverts            []Revert
+	DependencyChanges  []DependencyChange
+	KnownIssues        []KnownIssue
+}
+
+// sectionData is the data section.md.tmpl is executed with. Each of its
+// entries is rendered with entry.md.tmpl.
+type sectionData struct {
+	Section
+	// Collapsed is set if the entries are replaced by their count and a
+	// reference to FullList.
+	Collapsed bool
+	FullList  string
+}
+
+// loadTemplates returns the default templates overridden by the *.tmpl
+// files of dir, if set. Any subset of the default templates can be
+// overridden by a file of the same name, and other files can define
+// templates used by the overriding ones.
+func loadTemplates(dir string) (*template.Template, error) {
+	if len(dir) == 0 {
+		return defaultTemplate, nil
+	}
+	files, err := filepath.Glob(filepath.Join(dir, "*.tmpl"))
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no *.tmpl file found in template directory %s", dir)
+	}
+	t, err := defaultTemplate.Clone()
+	if err != nil {
+		return nil, err
+	}
+	for _, file := range files {
+		b, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := t.New(filepath.Base(file)).Parse(string(b)); err != nil {
+			return nil, fmt.Errorf("unable to parse template %s: %w", file, err)
+		}
+	}
+	return t, nil
+}
+
+// template returns the templates the release notes are rendered with,
+// loading them from cl.TemplateDir the first time.
+func (cl *ChangeLog) template() (*template.Template, error) {
+	if cl.templates == nil {
+		t, err := loadTemplates(cl.TemplateDir)
+		if err != nil {
+			return nil, err
+		}
+		cl.templates = t
+	}
+	return cl.templates, nil
+}
@@ -0,0 +1,93 @@
+// Copyright 2021 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package changelog
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/cilium/release/pkg/types"
+)
+
+func TestTemplates(t *testing.T) {
+	prs := types.PullRequests{
+		123: {ReleaseNote: "Fix foo", AuthorName: "alice", Labels: []string{"release-note/bug"}},
+		124: {ReleaseNote: "Add bar", AuthorName: "bob", Labels: []string{"release-note/minor"}},
+	}
+	newContributors := map[string]bool{"bob": true}
+
+	// Only the entries are overridden, the other templates are the default
+	// ones.
+	dir := t.TempDir()
+	err := os.WriteFile(filepath.Join(dir, "entry.md.tmpl"), []byte(`- {{ .ReleaseNote }} ([#{{ .PR }}](https://github.com/cilium/cilium/pull/{{ .PR }}))`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name        string
+		templateDir string
+		want        string
+	}{
+		{
+			name: "default",
+			want: "Summary of Changes\n" +
+				"------------------\n" +
+				"\n" +
+				"**Minor Changes:**\n" +
+				"* Add bar (#124, @bob)\n" +
+				"\n" +
+				"**Bugfixes:**\n" +
+				"* Fix foo (#123, @alice)\n" +
+				"\n" +
+				"Thanks to our 1 new contributor: @bob!\n",
+		},
+		{
+			name:        "entry overridden",
+			templateDir: dir,
+			want: "Summary of Changes\n" +
+				"------------------\n" +
+				"\n" +
+				"**Minor Changes:**\n" +
+				"- Add bar ([#124](https://github.com/cilium/cilium/pull/124))\n" +
+				"\n" +
+				"**Bugfixes:**\n" +
+				"- Fix foo ([#123](https://github.com/cilium/cilium/pull/123))\n" +
+				"\n" +
+				"Thanks to our 1 new contributor: @bob!\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cl := &ChangeLog{
+				Config:          types.Config{TemplateDir: tt.templateDir},
+				listOfPrs:       prs,
+				newContributors: newContributors,
+			}
+			got, err := cl.Render(RenderOptions{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("got:\n%s\nwant:\n%s", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := loadTemplates(t.TempDir()); err == nil {
+		t.Error("empty template directory loaded")
+	}
+}
@@ -0,0 +1,71 @@
+// Copyright 2021 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package changelog
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	gh "github.com/google/go-github/v50/github"
+
+	"github.com/cilium/release/pkg/confirm"
+	"github.com/cilium/release/pkg/github"
+)
+
+// trackingIssueMarker identifies the comment with the release notes in the
+// tracking issue of the release.
+const trackingIssueMarker = "<!-- release-notes -->"
+
+// checkTrackingIssue verifies that the tracking issue of the given URL
+// exists, so that a typo fails the run before the commits are compared.
+func checkTrackingIssue(ctx context.Context, ghClient *gh.Client, issueURL string) error {
+	issue, err := confirm.ParseIssueURL(issueURL)
+	if err != nil {
+		return fmt.Errorf("--tracking-issue: %w", err)
+	}
+	i, resp, err := ghClient.Issues.Get(ctx, issue.Owner, issue.Repo, issue.Number)
+	if isNotFound(resp) {
+		return fmt.Errorf("--tracking-issue %s not found", issueURL)
+	}
+	if err != nil {
+		return fmt.Errorf("unable to get tracking issue %s: %w", issueURL, err)
+	}
+	if i.IsPullRequest() {
+		return fmt.Errorf("--tracking-issue %s is a PR, not an issue", issueURL)
+	}
+	return nil
+}
+
+// postTrackingIssue posts, or updates, the release notes as a comment of
+// the tracking issue of the release. As the same comment is edited on every
+// run, its edit history is the history of the regenerations.
+func (cl *ChangeLog) postTrackingIssue(ctx context.Context, notes []byte) error {
+	issue, err := confirm.ParseIssueURL(cl.TrackingIssue)
+	if err != nil {
+		return fmt.Errorf("--tracking-issue: %w", err)
+	}
+	generated := fmt.Sprintf("`%s...%s`", cl.Base, cl.Head)
+	if len(cl.headSHA) != 0 {
+		generated += fmt.Sprintf(", %s being at %s", cl.Head, cl.headSHA)
+	}
+	body := fmt.Sprintf("### Release notes\n\nGenerated for %s.\n\n%s", generated, notes)
+	comment, err := github.UpsertComment(ctx, cl.ghClient, issue.Owner, issue.Repo, issue.Number, trackingIssueMarker, body)
+	if err != nil {
+		return fmt.Errorf("unable to post the release notes on the tracking issue: %w", err)
+	}
+	fmt.Fprintf(os.Stderr, "Release notes posted at %s\n", comment.GetHTMLURL())
+	return nil
+}
@@ -0,0 +1,97 @@
+// Copyright 2021 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package changelog
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	gh "github.com/google/go-github/v50/github"
+
+	"github.com/cilium/release/pkg/types"
+)
+
+func TestPostTrackingIssue(t *testing.T) {
+	var edited string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method + " " + r.URL.Path {
+		case "GET /repos/cilium/release/issues/42/comments":
+			fmt.Fprint(w, `[{"id": 1, "body": "Release starts tomorrow"}, {"id": 2, "body": "<!-- release-notes -->\n### Release notes\n\nold"}]`)
+		case "PATCH /repos/cilium/release/issues/comments/2":
+			var comment gh.IssueComment
+			json.NewDecoder(r.Body).Decode(&comment)
+			edited = comment.GetBody()
+			fmt.Fprint(w, `{"id": 2}`)
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL)
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+	ghClient := gh.NewClient(nil)
+	ghClient.BaseURL, _ = url.Parse(srv.URL + "/")
+
+	cl := &ChangeLog{
+		Config: types.Config{
+			Owner:         "cilium",
+			Repo:          "cilium",
+			Base:          "v1.14.2",
+			Head:          "v1.14",
+			TrackingIssue: "https://github.com/cilium/release/issues/42",
+		},
+		ghClient: ghClient,
+		headSHA:  "0123456789abcdef0123456789abcdef01234567",
+	}
+	if err := cl.postTrackingIssue(context.Background(), []byte("notes\n")); err != nil {
+		t.Fatal(err)
+	}
+	want := "<!-- release-notes -->\n### Release notes\n\nGenerated for `v1.14.2...v1.14`, v1.14 being at 0123456789abcdef0123456789abcdef01234567.\n\nnotes\n"
+	if edited != want {
+		t.Errorf("got:\n%q\nwant:\n%q", edited, want)
+	}
+}
+
+func TestCheckTrackingIssue(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/repos/cilium/release/issues/42":
+			fmt.Fprint(w, `{"number": 42}`)
+		case "/repos/cilium/release/issues/43":
+			fmt.Fprint(w, `{"number": 43, "pull_request": {"url": "https://api.github.com/repos/cilium/release/pulls/43"}}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+	ghClient := gh.NewClient(nil)
+	ghClient.BaseURL, _ = url.Parse(srv.URL + "/")
+
+	tests := map[string]bool{
+		"https://github.com/cilium/release/issues/42": true,
+		"https://github.com/cilium/release/issues/43": false,
+		"https://github.com/cilium/release/issues/44": false,
+		"https://github.com/cilium/release/pull/42":   false,
+	}
+	for issueURL, ok := range tests {
+		if err := checkTrackingIssue(context.Background(), ghClient, issueURL); (err == nil) != ok {
+			t.Errorf("checkTrackingIssue(%s) = %v", issueURL, err)
+		}
+	}
+}
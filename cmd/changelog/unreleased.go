@@ -0,0 +1,133 @@
+// Copyright 2021 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package changelog
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	gh "github.com/google/go-github/v50/github"
+	flag "github.com/spf13/pflag"
+
+	"github.com/cilium/release/pkg/github"
+	"github.com/cilium/release/pkg/types"
+	"github.com/cilium/release/pkg/version"
+)
+
+// UnreleasedCommand implements the 'unreleased' subcommand, which reports
+// the changes merged into a stable branch since its last release.
+func UnreleasedCommand(ctx context.Context, ghClient *gh.Client, args []string) error {
+	var (
+		cfg    types.Config
+		branch string
+		issue  int
+		dryRun bool
+	)
+	fs := flag.NewFlagSet("unreleased", flag.ContinueOnError)
+	fs.StringVar(&cfg.RepoName, "repo", "cilium/cilium", "GitHub organization and repository names separated by a slash")
+	fs.StringVar(&branch, "branch", "", "Stable branch (e.g.: '1.14') of the unreleased changes")
+	fs.StringVar(&cfg.CacheDir, "cache-dir", "", "Directory of the PR metadata cache shared across runs, releases and branches, e.g. ~/.cache/cilium-release. The cached PRs updated since the previous run are dropped. Disabled if empty")
+	fs.IntVar(&issue, "issue", 0, "Issue whose description is replaced by the report. By default, the open issue titled after the branch is updated, or created and pinned")
+	fs.BoolVar(&dryRun, "dry-run", false, "Print the report instead of posting it")
+	if err := types.ParseFlags(fs, "unreleased", args); err != nil {
+		return err
+	}
+	ver, err := version.Parse(branch)
+	if err != nil {
+		return fmt.Errorf("--branch should be of the format 'x.y': %w", err)
+	}
+	cfg.SinceLatestRelease = ver.MinorString()
+	cfg.Head = "v" + ver.MinorString()
+
+	// Always start from scratch, the report covers a moving range.
+	stateDir, err := os.MkdirTemp("", "release-unreleased")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(stateDir)
+	cfg.StateFile = filepath.Join(stateDir, "state.json")
+
+	if err := cfg.Sanitize(); err != nil {
+		return err
+	}
+
+	cl, err := GenerateReleaseNotes(ctx, ghClient, cfg, nil)
+	if err != nil {
+		return err
+	}
+	notes, err := cl.Render(RenderOptions{})
+	if err != nil {
+		return err
+	}
+	title := fmt.Sprintf("Unreleased changes in %s", cfg.Head)
+	body := fmt.Sprintf("Changes merged into `%s` since %s, as of %s.\n\n%s",
+		cfg.Head, cl.Base, time.Now().UTC().Format("2006-01-02 15:04 MST"), notes)
+
+	if dryRun {
+		fmt.Fprint(os.Stdout, body)
+		return nil
+	}
+	if issue == 0 {
+		issue, err = findIssue(ctx, ghClient, cfg.Owner, cfg.Repo, title)
+		if err != nil {
+			return err
+		}
+	}
+	if issue != 0 {
+		i, _, err := ghClient.Issues.Edit(ctx, cfg.Owner, cfg.Repo, issue, &gh.IssueRequest{Body: &body})
+		if err != nil {
+			return fmt.Errorf("unable to update issue %d: %w", issue, err)
+		}
+		fmt.Fprintf(os.Stdout, "Report updated: %s\n", i.GetHTMLURL())
+		return nil
+	}
+	i, _, err := ghClient.Issues.Create(ctx, cfg.Owner, cfg.Repo, &gh.IssueRequest{Title: &title, Body: &body})
+	if err != nil {
+		return fmt.Errorf("unable to create issue: %w", err)
+	}
+	if err := pinIssue(ctx, ghClient, i.GetNodeID()); err != nil {
+		fmt.Fprintf(os.Stderr, "Unable to pin issue %d: %s\n", i.GetNumber(), err)
+	}
+	fmt.Fprintf(os.Stdout, "Report posted: %s\n", i.GetHTMLURL())
+	return nil
+}
+
+// findIssue returns the number of the open issue with the given title, or 0
+// if there is none.
+func findIssue(ctx context.Context, ghClient *gh.Client, owner, repo, title string) (int, error) {
+	query := fmt.Sprintf("repo:%s/%s is:issue is:open in:title %q", owner, repo, title)
+	issues, err := github.SearchIssues(ctx, ghClient, query)
+	if err != nil {
+		return 0, fmt.Errorf("unable to search issues: %w", err)
+	}
+	for _, issue := range issues {
+		if issue.GetTitle() == title {
+			return issue.GetNumber(), nil
+		}
+	}
+	return 0, nil
+}
+
+func pinIssue(ctx context.Context, ghClient *gh.Client, issueID string) error {
+	const mutation = `mutation($issueId: ID!) {
+  pinIssue(input: {issueId: $issueId}) {
+    issue { id }
+  }
+}`
+	return github.GraphQL(ctx, ghClient, mutation, map[string]interface{}{"issueId": issueID}, nil)
+}
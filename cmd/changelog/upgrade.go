@@ -0,0 +1,109 @@
+// Copyright 2021 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package changelog
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/cilium/release/pkg/report"
+	"github.com/cilium/release/pkg/types"
+)
+
+// upgradeImpactLabel is the label of the PRs that need upgrade notes.
+const upgradeImpactLabel = "upgrade-impact"
+
+// upgradeNote is the upgrade note of a PR labeled upgrade-impact.
+type upgradeNote struct {
+	Entry
+	// Notes is empty if the PR doesn't give any upgrade notes.
+	Notes string
+	URL   string
+}
+
+// upgradeNotes returns the upgrade notes of the PRs of the release notes
+// labeled upgrade-impact, sorted by PR number.
+func (cl *ChangeLog) upgradeNotes() []upgradeNote {
+	prs := map[int]types.PullRequest{}
+	for number, pr := range cl.listOfPrs {
+		prs[number] = pr
+	}
+	for _, upstreamPRs := range cl.prsWithUpstream {
+		for number, pr := range upstreamPRs {
+			prs[number] = pr
+		}
+	}
+
+	var notes []upgradeNote
+	for _, section := range cl.Sections() {
+		for _, entry := range section.Entries {
+			if !hasLabel(entry.Labels, upgradeImpactLabel) {
+				continue
+			}
+			pr := prs[entry.PR]
+			notes = append(notes, upgradeNote{Entry: entry, Notes: pr.UpgradeNotes, URL: pr.URL})
+		}
+	}
+	sort.Slice(notes, func(i, j int) bool {
+		return notes[i].PR < notes[j].PR
+	})
+	return notes
+}
+
+// writeUpgradeNotes writes the consolidated upgrade notes into file. The
+// PRs labeled upgrade-impact without any upgrade notes are listed with a
+// link to their description, and reported.
+func (cl *ChangeLog) writeUpgradeNotes(file string) error {
+	title := cl.Head
+	if ver := cl.detectVersion(); len(ver) != 0 {
+		title = "v" + ver
+	}
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "# Upgrade Notes for %s\n", title)
+	notes := cl.upgradeNotes()
+	if len(notes) == 0 {
+		fmt.Fprintf(&buf, "\nNo change with an upgrade impact.\n")
+	}
+	for _, n := range notes {
+		fmt.Fprintf(&buf, "\n## %s (%s)\n\n", n.ReleaseNote, n.ref())
+		if len(n.Notes) != 0 {
+			fmt.Fprintln(&buf, n.Notes)
+			continue
+		}
+		fmt.Fprintf(os.Stderr, "WARNING: PR %s is labeled %s but has no upgrade notes\n", n.ref(), upgradeImpactLabel)
+		if len(n.URL) != 0 {
+			fmt.Fprintf(&buf, "No upgrade notes were given, see the [PR](%s).\n", n.URL)
+		} else {
+			fmt.Fprintf(&buf, "No upgrade notes were given.\n")
+		}
+	}
+	if err := os.WriteFile(file, buf.Bytes(), 0644); err != nil {
+		return err
+	}
+	report.Written(file)
+	return nil
+}
+
+// hasLabel returns true if lbls contains the given label.
+func hasLabel(lbls []string, label string) bool {
+	for _, lbl := range lbls {
+		if lbl == label {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,80 @@
+// Copyright 2021 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package changelog
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/cilium/release/pkg/types"
+)
+
+func TestWriteUpgradeNotes(t *testing.T) {
+	cl := &ChangeLog{
+		Config: types.Config{Head: "v1.14.3"},
+		listOfPrs: types.PullRequests{
+			124: {
+				ReleaseNote:  "Rename foo",
+				AuthorName:   "alice",
+				Labels:       []string{"release-note/minor", "upgrade-impact"},
+				UpgradeNotes: "The `foo` option was renamed to `bar`.\n\n* Set `bar` in your Helm values.",
+			},
+			125: {
+				ReleaseNote: "Drop baz",
+				AuthorName:  "carol",
+				Labels:      []string{"release-note/major", "upgrade-impact"},
+				URL:         "https://github.com/cilium/cilium/pull/125",
+			},
+			126: {ReleaseNote: "Fix qux", AuthorName: "dave", Labels: []string{"release-note/bug"}},
+		},
+		prsWithUpstream: types.BackportPRs{
+			200: {
+				150: {
+					ReleaseNote:  "Fix bar",
+					AuthorName:   "bob",
+					Labels:       []string{"release-note/bug", "upgrade-impact"},
+					UpgradeNotes: "Restart the agents after the upgrade.",
+				},
+			},
+		},
+	}
+	file := filepath.Join(t.TempDir(), "upgrade-notes.md")
+	if err := cl.writeUpgradeNotes(file); err != nil {
+		t.Fatal(err)
+	}
+	got, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "# Upgrade Notes for v1.14.3\n" +
+		"\n" +
+		"## Rename foo (#124)\n" +
+		"\n" +
+		"The `foo` option was renamed to `bar`.\n" +
+		"\n" +
+		"* Set `bar` in your Helm values.\n" +
+		"\n" +
+		"## Drop baz (#125)\n" +
+		"\n" +
+		"No upgrade notes were given, see the [PR](https://github.com/cilium/cilium/pull/125).\n" +
+		"\n" +
+		"## Fix bar (#150)\n" +
+		"\n" +
+		"Restart the agents after the upgrade.\n"
+	if string(got) != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
@@ -0,0 +1,83 @@
+// Copyright 2021 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package changelog
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	gh "github.com/google/go-github/v50/github"
+
+	"github.com/cilium/release/pkg/types"
+	"github.com/cilium/release/pkg/usage"
+)
+
+// Watch checks every cfg.WatchInterval, until ctx is done, whether cfg.Head
+// gained new commits since cl was generated and, if so, regenerates the
+// release notes and prints them again. As the state is resumed from
+// cfg.StateFile, only the PRs of the new commits are resolved. A failed
+// regeneration is retried at the next check.
+func Watch(ctx context.Context, ghClient *gh.Client, cfg types.Config, tracker *usage.Tracker, cl *ChangeLog) {
+	src := resolutionConfig(cfg)
+	sha := cl.headSHA
+	// The release sinks are published into under the confirmation of the
+	// first run.
+	gate := cl.Gate
+	fmt.Fprintf(os.Stderr, "Watching %s for new commits every %s\n", cfg.Head, cfg.WatchInterval)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(cfg.WatchInterval):
+		}
+		// The request is conditional so that it doesn't count against
+		// the rate limit while the head doesn't move.
+		head, resp, err := ghClient.Repositories.GetCommitSHA1(ctx, src.Owner, src.Repo, cfg.Head, sha)
+		if resp != nil && resp.StatusCode == http.StatusNotModified {
+			continue
+		}
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			fmt.Fprintf(os.Stderr, "WARNING: unable to get the head of %s: %s\n", cfg.Head, err)
+			continue
+		}
+		if len(sha) == 0 {
+			sha = head
+			continue
+		}
+		if head == sha {
+			continue
+		}
+		fmt.Fprintf(os.Stderr, "%s moved to %s, regenerating the release notes\n", cfg.Head, head)
+		cl, err := GenerateReleaseNotes(ctx, ghClient, cfg, tracker)
+		if err == nil {
+			cl.Gate = gate
+			err = cl.PrintReleaseNotes(ctx)
+		}
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			fmt.Fprintf(os.Stderr, "WARNING: unable to regenerate the release notes: %s\n", err)
+			continue
+		}
+		sha = head
+	}
+}
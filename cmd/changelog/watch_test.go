@@ -0,0 +1,125 @@
+// Copyright 2021 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package changelog
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	gh "github.com/google/go-github/v50/github"
+
+	"github.com/cilium/release/pkg/confirm"
+	"github.com/cilium/release/pkg/persistence"
+	"github.com/cilium/release/pkg/types"
+)
+
+func TestWatch(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	// The head doesn't move, then moves to c2 with a new PR.
+	heads := []string{"c1", "c1", "c2"}
+	var reported string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/repos/cilium/cilium/issues/1":
+			fmt.Fprint(w, `{"number": 1, "state": "open"}`)
+		case r.URL.Path == "/repos/cilium/cilium/releases":
+			fmt.Fprint(w, `[{"id": 7, "tag_name": "v1.14.3", "html_url": "https://github.com/cilium/cilium/releases/tag/v1.14.3"}]`)
+		case r.Method == http.MethodPatch && r.URL.Path == "/repos/cilium/cilium/releases/7":
+			fmt.Fprint(w, `{"id": 7, "tag_name": "v1.14.3", "html_url": "https://github.com/cilium/cilium/releases/tag/v1.14.3"}`)
+		case r.Method == http.MethodPost && r.URL.Path == "/repos/cilium/cilium/issues/1/comments":
+			var c gh.IssueComment
+			json.NewDecoder(r.Body).Decode(&c)
+			reported = c.GetBody()
+			fmt.Fprint(w, `{}`)
+		case r.URL.Path == "/rate_limit":
+			fmt.Fprint(w, `{"resources": {"core": {"limit": 5000, "remaining": 5000}}}`)
+		case r.URL.Path == "/repos/cilium/cilium/compare/c1...main":
+			fmt.Fprint(w, `{"total_commits": 1, "commits": [{"sha": "c2", "commit": {"message": "Fix bar (#124)"}}]}`)
+		case r.URL.Path == "/repos/cilium/cilium/commits/c2/pulls":
+			fmt.Fprint(w, `[{"number": 124, "state": "closed", "title": "Fix bar", "merged_at": "2023-07-12T09:30:00Z",
+				"user": {"login": "bob"}, "labels": [{"name": "release-note/bug"}]}]`)
+		case r.URL.Path == "/repos/cilium/cilium/commits/main":
+			if len(heads) == 0 {
+				cancel()
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+			head := heads[0]
+			heads = heads[1:]
+			if r.Header.Get("If-None-Match") == `"`+head+`"` {
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+			fmt.Fprint(w, head)
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL)
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+	ghClient := gh.NewClient(nil)
+	ghClient.BaseURL, _ = url.Parse(srv.URL + "/")
+
+	dir := t.TempDir()
+	cfg := types.Config{
+		Base:          "v1.14.2",
+		Head:          "main",
+		RepoName:      "cilium/cilium",
+		Owner:         "cilium",
+		Repo:          "cilium",
+		StateFile:     filepath.Join(dir, "state.json"),
+		Output:        filepath.Join(dir, "notes.md"),
+		Sinks:         []string{"release:v1.14.3"},
+		WatchInterval: time.Millisecond,
+	}
+	err := persistence.Store(cfg.StateFile, &persistence.State{
+		Base:    "v1.14.2",
+		HeadSHA: "c1",
+		PullRequests: types.PullRequests{
+			123: {ReleaseNote: "Fix foo", AuthorName: "alice", Labels: []string{"release-note/bug"}},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	gate, err := confirm.New(ctx, ghClient, "publish the release notes", "https://github.com/cilium/cilium/issues/1", nil, io.Discard)
+	if err != nil {
+		t.Fatal(err)
+	}
+	Watch(ctx, ghClient, cfg, nil, &ChangeLog{Config: cfg, headSHA: "c1", Gate: gate})
+	notes, err := os.ReadFile(cfg.Output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(notes), "Fix foo") || !strings.Contains(string(notes), "Fix bar (#124, @bob)") {
+		t.Errorf("release notes not regenerated:\n%s", notes)
+	}
+	// The release edited by the regeneration is reported under the
+	// confirmation of the first run.
+	if !strings.Contains(reported, "Set the release notes as the body of https://github.com/cilium/cilium/releases/tag/v1.14.3") {
+		t.Errorf("release edit not reported, got %q", reported)
+	}
+}
@@ -0,0 +1,233 @@
+// Copyright 2021 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package check
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	gh "github.com/google/go-github/v50/github"
+	flag "github.com/spf13/pflag"
+
+	"github.com/cilium/release/pkg/github"
+	"github.com/cilium/release/pkg/types"
+)
+
+// requirement is what a token needs to perform an operation of the release
+// tool.
+type requirement struct {
+	// description of the operation.
+	description string
+	// permission is the minimum permission on the repository, e.g. 'pull'.
+	permission string
+	// scopes are the OAuth scopes needed by a classic token. Each group
+	// is satisfied by any of its scopes.
+	scopes [][]string
+	// fineGrained are the permissions needed by a fine-grained token.
+	fineGrained string
+}
+
+// requirements returns the requirements of the given operation.
+func requirements(op string, private bool) (requirement, bool) {
+	repoScope := []string{"repo", "public_repo"}
+	if private {
+		repoScope = []string{"repo"}
+	}
+	switch op {
+	case "changelog":
+		r := requirement{
+			description: "generate release notes",
+			permission:  "pull",
+			fineGrained: "Contents: read, Pull requests: read",
+		}
+		if private {
+			r.scopes = [][]string{{"repo"}}
+		}
+		return r, true
+	case "backports":
+		return requirement{
+			description: "update the backport labels and projects",
+			permission:  "triage",
+			scopes:      [][]string{repoScope},
+			fineGrained: "Issues: read and write, Pull requests: read and write",
+		}, true
+	case "projects":
+		return requirement{
+			description: "manage the backport ProjectsV2",
+			permission:  "triage",
+			scopes:      [][]string{repoScope, {"project"}},
+			fineGrained: "Projects (organization): read and write",
+		}, true
+	case "tag":
+		return requirement{
+			description: "create tags and releases",
+			permission:  "push",
+			scopes:      [][]string{repoScope},
+			fineGrained: "Contents: read and write",
+		}, true
+	}
+	return requirement{}, false
+}
+
+var operations = []string{"changelog", "backports", "projects", "tag"}
+
+// hasScope returns true if scope is granted by the given scopes, including
+// through a broader scope.
+func hasScope(scopes []string, scope string) bool {
+	for _, s := range scopes {
+		switch {
+		case s == scope:
+			return true
+		case s == "repo" && scope == "public_repo":
+			return true
+		case s == "project" && scope == "read:project":
+			return true
+		}
+	}
+	return false
+}
+
+// missing returns what the token lacks to fulfil the requirement. scopes is
+// nil if the token isn't a classic token, in which case its permissions
+// can't be inspected.
+func missing(req requirement, scopes []string, perms map[string]bool) []string {
+	var lacks []string
+	if !perms[req.permission] {
+		lacks = append(lacks, fmt.Sprintf("%q permission on the repository", req.permission))
+	}
+	if scopes == nil {
+		return lacks
+	}
+	for _, group := range req.scopes {
+		found := false
+		for _, scope := range group {
+			if hasScope(scopes, scope) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			lacks = append(lacks, fmt.Sprintf("%q scope", strings.Join(group, `" or "`)))
+		}
+	}
+	return lacks
+}
+
+// Command implements the 'check' subcommand.
+func Command(ctx context.Context, ghClient *gh.Client, args []string) error {
+	if len(args) != 0 {
+		switch args[0] {
+		case "auth":
+			return authCommand(ctx, ghClient, args[1:])
+		case "setup":
+			return setupCommand(ctx, ghClient, args[1:])
+		}
+	}
+	return fmt.Errorf("usage: check auth|setup [flags]")
+}
+
+// authenticate returns the login of the user the token authenticates and,
+// for a classic token, its sorted scopes. The scopes are nil for other
+// tokens, whose permissions can't be inspected.
+func authenticate(ctx context.Context, ghClient *gh.Client) (string, []string, error) {
+	user, resp, err := ghClient.Users.Get(ctx, "")
+	if resp != nil && resp.StatusCode == http.StatusUnauthorized {
+		return "", nil, fmt.Errorf("the token is missing, invalid or expired, %s", github.TokenHint())
+	}
+	if err != nil {
+		return "", nil, fmt.Errorf("unable to get the authenticated user: %w", err)
+	}
+	if _, ok := resp.Header["X-Oauth-Scopes"]; !ok {
+		return user.GetLogin(), nil, nil
+	}
+	scopes := []string{}
+	for _, scope := range strings.Split(resp.Header.Get("X-Oauth-Scopes"), ",") {
+		if scope = strings.TrimSpace(scope); len(scope) != 0 {
+			scopes = append(scopes, scope)
+		}
+	}
+	sort.Strings(scopes)
+	return user.GetLogin(), scopes, nil
+}
+
+// getRepository returns the repository, along with the permissions of the
+// token on it.
+func getRepository(ctx context.Context, ghClient *gh.Client, owner, repo string) (*gh.Repository, error) {
+	r, resp, err := ghClient.Repositories.Get(ctx, owner, repo)
+	if resp != nil && resp.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("%s/%s is not accessible with the token: it doesn't exist, or the token isn't granted access to it", owner, repo)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to get %s/%s: %w", owner, repo, err)
+	}
+	return r, nil
+}
+
+func authCommand(ctx context.Context, ghClient *gh.Client, args []string) error {
+	var (
+		repoName string
+		ops      []string
+	)
+	fs := flag.NewFlagSet("check auth", flag.ContinueOnError)
+	fs.StringVar(&repoName, "repo", "cilium/cilium", "GitHub organization and repository names separated by a slash")
+	fs.StringSliceVar(&ops, "operation", operations, "Operations to check the token for, among "+strings.Join(operations, ", "))
+	if err := types.ParseFlags(fs, "check auth", args); err != nil {
+		return err
+	}
+	owner, repo, err := types.SplitRepoName(repoName)
+	if err != nil {
+		return err
+	}
+
+	login, scopes, err := authenticate(ctx, ghClient)
+	if err != nil {
+		return err
+	}
+	if scopes != nil {
+		fmt.Printf("Authenticated as %s with a classic token, scopes: %s\n", login, strings.Join(scopes, ", "))
+	} else {
+		fmt.Printf("Authenticated as %s with a fine-grained or GitHub App token, only the repository permissions can be checked\n", login)
+	}
+
+	r, err := getRepository(ctx, ghClient, owner, repo)
+	if err != nil {
+		return err
+	}
+
+	failed := 0
+	for _, op := range ops {
+		req, ok := requirements(op, r.GetPrivate())
+		if !ok {
+			return fmt.Errorf("unknown operation %q, should be one of %s", op, strings.Join(operations, ", "))
+		}
+		lacks := missing(req, scopes, r.Permissions)
+		if len(lacks) == 0 {
+			fmt.Printf("OK      %-10s %s\n", op, req.description)
+			continue
+		}
+		failed++
+		fmt.Printf("MISSING %-10s %s needs the %s\n", op, req.description, strings.Join(lacks, " and the "))
+		if scopes == nil {
+			fmt.Printf("        %-10s a fine-grained token also needs %s\n", "", req.fineGrained)
+		}
+	}
+	if failed != 0 {
+		return fmt.Errorf("the token can't perform %d of the %d operations checked", failed, len(ops))
+	}
+	return nil
+}
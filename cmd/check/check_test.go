@@ -0,0 +1,78 @@
+// Copyright 2021 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package check
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMissing(t *testing.T) {
+	write := map[string]bool{"pull": true, "triage": true, "push": true}
+	read := map[string]bool{"pull": true}
+	tests := []struct {
+		name    string
+		op      string
+		private bool
+		scopes  []string
+		perms   map[string]bool
+		want    []string
+	}{
+		{
+			name:   "changelog of a public repository",
+			op:     "changelog",
+			scopes: []string{},
+			perms:  read,
+		},
+		{
+			name:    "changelog of a private repository",
+			op:      "changelog",
+			private: true,
+			scopes:  []string{"public_repo"},
+			perms:   read,
+			want:    []string{`"repo" scope`},
+		},
+		{
+			name:   "public_repo granted by repo",
+			op:     "tag",
+			scopes: []string{"repo"},
+			perms:  write,
+		},
+		{
+			name:   "projects",
+			op:     "projects",
+			scopes: []string{"public_repo", "read:project"},
+			perms:  read,
+			want:   []string{`"triage" permission on the repository`, `"project" scope`},
+		},
+		{
+			name:  "fine-grained token",
+			op:    "tag",
+			perms: read,
+			want:  []string{`"push" permission on the repository`},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, ok := requirements(tt.op, tt.private)
+			if !ok {
+				t.Fatalf("unknown operation %s", tt.op)
+			}
+			if got := missing(req, tt.scopes, tt.perms); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("missing() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,230 @@
+// Copyright 2021 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package check
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+
+	gh "github.com/google/go-github/v50/github"
+	flag "github.com/spf13/pflag"
+
+	"github.com/cilium/release/cmd/projects"
+	"github.com/cilium/release/pkg/config"
+	"github.com/cilium/release/pkg/profile"
+	"github.com/cilium/release/pkg/types"
+	"github.com/cilium/release/pkg/version"
+)
+
+// setup is what a release of a version of a repository needs to be set up
+// before the release window.
+type setup struct {
+	owner, repo string
+	version     version.Version
+	profile     profile.Profile
+	projectsV2  bool
+	// workflows are the file names of the workflows, e.g. 'release.yaml',
+	// that must be active in the repository.
+	workflows []string
+	// siblings are the repositories, e.g. 'cilium/cilium-cli', the token
+	// must be able to push to.
+	siblings []string
+}
+
+// checker prints the outcome of each check and counts the failed ones.
+type checker struct {
+	out            io.Writer
+	checks, failed int
+}
+
+// report prints the outcome of a check. An empty problem means the check
+// passed.
+func (c *checker) report(name, description, problem string) {
+	c.checks++
+	if len(problem) == 0 {
+		fmt.Fprintf(c.out, "OK      %-10s %s\n", name, description)
+		return
+	}
+	c.failed++
+	fmt.Fprintf(c.out, "MISSING %-10s %s: %s\n", name, description, problem)
+}
+
+// run checks everything the release needs, printing the outcome of each
+// check into c.
+func (s *setup) run(ctx context.Context, ghClient *gh.Client, c *checker) error {
+	_, scopes, err := authenticate(ctx, ghClient)
+	if err != nil {
+		return err
+	}
+	r, err := getRepository(ctx, ghClient, s.owner, s.repo)
+	if err != nil {
+		return err
+	}
+	for _, op := range operations {
+		if op == "projects" && !s.projectsV2 {
+			continue
+		}
+		req, _ := requirements(op, r.GetPrivate())
+		var problem string
+		if lacks := missing(req, scopes, r.Permissions); len(lacks) != 0 {
+			problem = "the token needs the " + strings.Join(lacks, " and the ")
+		}
+		c.report("token", req.description, problem)
+	}
+
+	if err := s.checkProject(ctx, ghClient, c); err != nil {
+		return err
+	}
+
+	minor := s.version.MinorString()
+	branch := s.profile.StableBranch(minor)
+	_, resp, err := ghClient.Repositories.GetBranch(ctx, s.owner, s.repo, branch, false)
+	if err != nil && (resp == nil || resp.StatusCode != http.StatusNotFound) {
+		return fmt.Errorf("unable to get branch %s: %w", branch, err)
+	}
+	var problem string
+	if err != nil {
+		problem = "not found"
+	}
+	c.report("branch", fmt.Sprintf("stable branch %s", branch), problem)
+
+	for _, prefix := range []string{s.profile.NeedsBackportPrefix, s.profile.PendingBackportPrefix, s.profile.DoneBackportPrefix} {
+		lbl := prefix + minor
+		_, resp, err := ghClient.Issues.GetLabel(ctx, s.owner, s.repo, lbl)
+		if err != nil && (resp == nil || resp.StatusCode != http.StatusNotFound) {
+			return fmt.Errorf("unable to get label %s: %w", lbl, err)
+		}
+		problem := ""
+		if err != nil {
+			problem = "not found, create it with 'release labels sync'"
+		}
+		c.report("label", fmt.Sprintf("backport label %s", lbl), problem)
+	}
+
+	for _, file := range s.workflows {
+		w, resp, err := ghClient.Actions.GetWorkflowByFileName(ctx, s.owner, s.repo, file)
+		if err != nil && (resp == nil || resp.StatusCode != http.StatusNotFound) {
+			return fmt.Errorf("unable to get workflow %s: %w", file, err)
+		}
+		problem := ""
+		switch {
+		case err != nil:
+			problem = "not found"
+		case w.GetState() != "active":
+			problem = fmt.Sprintf("%s, enable it", strings.ReplaceAll(w.GetState(), "_", " "))
+		}
+		c.report("workflow", fmt.Sprintf("workflow %s", file), problem)
+	}
+
+	for _, sibling := range s.siblings {
+		owner, repo, err := types.SplitRepoName(sibling)
+		if err != nil {
+			return err
+		}
+		problem := ""
+		sr, err := getRepository(ctx, ghClient, owner, repo)
+		switch {
+		case err != nil:
+			problem = err.Error()
+		case !sr.Permissions["push"]:
+			problem = `the token needs the "push" permission on the repository`
+		}
+		c.report("repo", fmt.Sprintf("write access to %s", sibling), problem)
+	}
+	return nil
+}
+
+// checkProject checks that the backport project of the version exists.
+func (s *setup) checkProject(ctx context.Context, ghClient *gh.Client, c *checker) error {
+	ver := s.version.String()
+	var (
+		url string
+		err error
+	)
+	if s.projectsV2 {
+		url, err = projects.NewProjectManagementV2(ghClient, s.owner, s.repo).ProjectURL(ctx, ver)
+	} else {
+		url, err = projects.NewProjectManagement(ghClient, s.owner, s.repo).ProjectURL(ctx, ver)
+	}
+	if err != nil {
+		return fmt.Errorf("unable to find the project of %s: %w", ver, err)
+	}
+	problem := ""
+	if len(url) == 0 {
+		problem = "not found, create it with 'release projects create'"
+	}
+	c.report("project", fmt.Sprintf("backport project %s", ver), problem)
+	return nil
+}
+
+func setupCommand(ctx context.Context, ghClient *gh.Client, args []string) error {
+	var (
+		repoName    string
+		cfgFile     string
+		ver         string
+		profileName string
+		s           setup
+	)
+	fs := flag.NewFlagSet("check setup", flag.ContinueOnError)
+	fs.StringVar(&repoName, "repo", "cilium/cilium", "GitHub organization and repository names separated by a slash")
+	fs.StringVar(&cfgFile, "config", "", "Configuration file whose downstream repositories must be writable by the token")
+	fs.StringVar(&ver, "version", "", "Version about to be released (e.g.: '1.14.3')")
+	fs.StringVar(&profileName, "profile", profile.Default, fmt.Sprintf("Label scheme of the repository defining the backport labels, one of %s", strings.Join(profile.Names(), ", ")))
+	fs.BoolVar(&s.projectsV2, "projects-v2", false, "The backport projects are GitHub ProjectsV2 instead of classic projects")
+	fs.StringSliceVar(&s.workflows, "workflows", nil, "File names of the workflows, e.g. 'release.yaml', that must be active in the repository. Can be repeated or comma-separated")
+	fs.StringSliceVar(&s.siblings, "sibling-repos", nil, "Other repositories, separated by a slash, the token must be able to push to, in addition to the downstream repositories of --config. Can be repeated or comma-separated")
+	if err := types.ParseFlags(fs, "check setup", args); err != nil {
+		return err
+	}
+
+	var err error
+	s.owner, s.repo, err = types.SplitRepoName(repoName)
+	if err != nil {
+		return err
+	}
+	if len(ver) == 0 {
+		return fmt.Errorf("--version must be set")
+	}
+	s.version, err = version.Parse(ver)
+	if err != nil {
+		return err
+	}
+	s.profile, err = profile.Get(profileName)
+	if err != nil {
+		return err
+	}
+	if len(cfgFile) != 0 {
+		c, err := config.Load(cfgFile)
+		if err != nil {
+			return fmt.Errorf("unable to load configuration: %w", err)
+		}
+		for _, d := range c.Downstream {
+			s.siblings = append(s.siblings, d.Repo)
+		}
+	}
+
+	c := &checker{out: os.Stdout}
+	if err := s.run(ctx, ghClient, c); err != nil {
+		return err
+	}
+	if c.failed != 0 {
+		return fmt.Errorf("%d of the %d checks failed", c.failed, c.checks)
+	}
+	return nil
+}
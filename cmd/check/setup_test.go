@@ -0,0 +1,94 @@
+// Copyright 2021 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package check
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	gh "github.com/google/go-github/v50/github"
+
+	"github.com/cilium/release/pkg/profile"
+	"github.com/cilium/release/pkg/version"
+)
+
+func TestSetup(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/user":
+			w.Header().Set("X-OAuth-Scopes", "repo, workflow")
+			fmt.Fprint(w, `{"login": "maintainer"}`)
+		case "/repos/cilium/cilium":
+			fmt.Fprint(w, `{"permissions": {"pull": true, "triage": true, "push": true}}`)
+		case "/repos/cilium/cilium-cli":
+			fmt.Fprint(w, `{"permissions": {"pull": true}}`)
+		case "/repos/cilium/cilium/projects":
+			fmt.Fprint(w, `[{"name": "1.14.3", "html_url": "https://github.com/cilium/cilium/projects/1"}]`)
+		case "/repos/cilium/cilium/branches/v1.14":
+			fmt.Fprint(w, `{"name": "v1.14"}`)
+		case "/repos/cilium/cilium/labels/needs-backport/1.14", "/repos/cilium/cilium/labels/backport-done/1.14":
+			fmt.Fprint(w, `{}`)
+		case "/repos/cilium/cilium/actions/workflows/release.yaml":
+			fmt.Fprint(w, `{"state": "active"}`)
+		case "/repos/cilium/cilium/actions/workflows/images.yaml":
+			fmt.Fprint(w, `{"state": "disabled_manually"}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+	ghClient := gh.NewClient(nil)
+	ghClient.BaseURL, _ = url.Parse(srv.URL + "/")
+
+	p, _ := profile.Get(profile.Default)
+	v, _ := version.Parse("1.14.3")
+	s := &setup{
+		owner:     "cilium",
+		repo:      "cilium",
+		version:   v,
+		profile:   p,
+		workflows: []string{"release.yaml", "images.yaml"},
+		siblings:  []string{"cilium/cilium-cli", "cilium/charts"},
+	}
+	var buf bytes.Buffer
+	c := &checker{out: &buf}
+	if err := s.run(context.Background(), ghClient, c); err != nil {
+		t.Fatal(err)
+	}
+	want := `OK      token      generate release notes
+OK      token      update the backport labels and projects
+OK      token      create tags and releases
+OK      project    backport project 1.14.3
+OK      branch     stable branch v1.14
+OK      label      backport label needs-backport/1.14
+MISSING label      backport label backport-pending/1.14: not found, create it with 'release labels sync'
+OK      label      backport label backport-done/1.14
+OK      workflow   workflow release.yaml
+MISSING workflow   workflow images.yaml: disabled manually, enable it
+MISSING repo       write access to cilium/cilium-cli: the token needs the "push" permission on the repository
+MISSING repo       write access to cilium/charts: cilium/charts is not accessible with the token: it doesn't exist, or the token isn't granted access to it
+`
+	if got := buf.String(); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+	if c.checks != 12 || c.failed != 4 {
+		t.Errorf("got %d failed checks of %d, want 4 of 12", c.failed, c.checks)
+	}
+}
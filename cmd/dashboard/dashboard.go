@@ -0,0 +1,192 @@
+// Copyright 2021 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dashboard
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+	texttemplate "text/template"
+	"time"
+
+	gh "github.com/google/go-github/v50/github"
+	flag "github.com/spf13/pflag"
+
+	"github.com/cilium/release/pkg/config"
+	"github.com/cilium/release/pkg/github"
+	"github.com/cilium/release/pkg/profile"
+	"github.com/cilium/release/pkg/types"
+)
+
+const (
+	dateFormat          = "2006-01-02"
+	defaultBlockerLabel = "release-blocker/{{ .Branch }}"
+)
+
+// Row is the release health of a branch of a repository.
+type Row struct {
+	Repo            string    `json:"repo"`
+	Branch          string    `json:"branch"`
+	LastRelease     string    `json:"lastRelease,omitempty"`
+	LastReleaseDate time.Time `json:"lastReleaseDate"`
+	// CommitsSince is the number of commits of the branch since its last
+	// release.
+	CommitsSince int `json:"commitsSince"`
+	// PendingBackports is the number of merged PRs that still need to be
+	// backported to the branch.
+	PendingBackports int `json:"pendingBackports"`
+	// Blockers is the number of open issues and PRs blocking the release
+	// of the branch.
+	Blockers int `json:"blockers"`
+	// Error is why the row is incomplete, if it is.
+	Error string `json:"error,omitempty"`
+}
+
+// Command implements the 'dashboard' subcommand.
+func Command(ctx context.Context, ghClient *gh.Client, args []string) error {
+	var (
+		cfgFile string
+		output  string
+	)
+	fs := flag.NewFlagSet("dashboard", flag.ContinueOnError)
+	fs.StringVar(&cfgFile, "config", "release.yaml", "Configuration file containing the repositories and branches of the dashboard")
+	fs.StringVar(&output, "output", "text", "Output format, one of 'text', 'json' or 'html'")
+	if err := types.ParseFlags(fs, "dashboard", args); err != nil {
+		return err
+	}
+	cfg, err := config.Load(cfgFile)
+	if err != nil {
+		return fmt.Errorf("unable to load configuration: %w", err)
+	}
+	if len(cfg.Dashboard.Repos) == 0 {
+		return fmt.Errorf("no repository configured in the dashboard section of %s", cfgFile)
+	}
+
+	var rows []Row
+	for _, r := range cfg.Dashboard.Repos {
+		owner, repo, err := types.SplitRepoName(r.Repo)
+		if err != nil {
+			return err
+		}
+		p, err := profile.Get(r.Profile)
+		if err != nil {
+			return fmt.Errorf("%s: %w", r.Repo, err)
+		}
+		for _, branch := range r.Branches {
+			blockerLabel, err := label(r.BlockerLabel, branch)
+			if err != nil {
+				return fmt.Errorf("%s: %w", r.Repo, err)
+			}
+			row := Row{Repo: r.Repo, Branch: branch}
+			if err := fill(ctx, ghClient, &row, owner, repo, p.NeedsBackportPrefix+branch, blockerLabel); err != nil {
+				row.Error = err.Error()
+				fmt.Fprintf(os.Stderr, "%s %s: %s\n", r.Repo, branch, err)
+			}
+			rows = append(rows, row)
+		}
+	}
+	return write(os.Stdout, output, rows)
+}
+
+// label returns the blocker label of the given branch.
+func label(tmpl, branch string) (string, error) {
+	if len(tmpl) == 0 {
+		tmpl = defaultBlockerLabel
+	}
+	t, err := texttemplate.New("label").Parse(tmpl)
+	if err != nil {
+		return "", fmt.Errorf("invalid blocker label %q: %w", tmpl, err)
+	}
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, struct{ Branch string }{Branch: branch}); err != nil {
+		return "", fmt.Errorf("invalid blocker label %q: %w", tmpl, err)
+	}
+	return buf.String(), nil
+}
+
+// fill looks up the release health of the branch of the row.
+func fill(ctx context.Context, ghClient *gh.Client, row *Row, owner, repo, needsBackportLabel, blockerLabel string) error {
+	release, err := github.LatestBranchRelease(ctx, ghClient, owner, repo, row.Branch)
+	if err != nil {
+		return fmt.Errorf("unable to find latest release: %w", err)
+	}
+	if release != nil {
+		row.LastRelease = release.GetTagName()
+		row.LastReleaseDate = release.GetPublishedAt().Time
+		cc, _, err := ghClient.Repositories.CompareCommits(ctx, owner, repo, row.LastRelease, "v"+row.Branch, &gh.ListOptions{PerPage: 1})
+		if err != nil {
+			return fmt.Errorf("unable to compare %s with v%s: %w", row.LastRelease, row.Branch, err)
+		}
+		row.CommitsSince = cc.GetAheadBy()
+	}
+	row.PendingBackports, err = github.CountIssues(ctx, ghClient,
+		fmt.Sprintf("repo:%s/%s is:pr is:merged label:%q", owner, repo, needsBackportLabel))
+	if err != nil {
+		return fmt.Errorf("unable to count pending backports: %w", err)
+	}
+	row.Blockers, err = github.CountIssues(ctx, ghClient,
+		fmt.Sprintf("repo:%s/%s is:open label:%q", owner, repo, blockerLabel))
+	if err != nil {
+		return fmt.Errorf("unable to count release blockers: %w", err)
+	}
+	return nil
+}
+
+var htmlTemplate = template.Must(template.New("dashboard").Funcs(template.FuncMap{
+	"release": lastRelease,
+}).Parse(`<table>
+<tr><th>Repository</th><th>Branch</th><th>Last release</th><th>Commits since</th><th>Pending backports</th><th>Blockers</th></tr>
+{{- range . }}
+<tr{{ if .Blockers }} class="blocked"{{ end }}><td>{{ .Repo }}</td><td>{{ .Branch }}</td><td>{{ release . }}</td><td>{{ .CommitsSince }}</td><td>{{ .PendingBackports }}</td><td>{{ .Blockers }}</td></tr>
+{{- end }}
+</table>
+`))
+
+func write(w io.Writer, output string, rows []Row) error {
+	switch output {
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(rows)
+	case "html":
+		return htmlTemplate.Execute(w, rows)
+	case "text":
+		tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+		fmt.Fprintln(tw, "REPOSITORY\tBRANCH\tLAST RELEASE\tCOMMITS SINCE\tPENDING BACKPORTS\tBLOCKERS")
+		for _, r := range rows {
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%d\t%d\t%d\n",
+				r.Repo, r.Branch, lastRelease(r), r.CommitsSince, r.PendingBackports, r.Blockers)
+		}
+		return tw.Flush()
+	default:
+		return fmt.Errorf("unknown output format %q", output)
+	}
+}
+
+func lastRelease(r Row) string {
+	switch {
+	case len(r.Error) != 0:
+		return "error: " + strings.SplitN(r.Error, "\n", 2)[0]
+	case len(r.LastRelease) == 0:
+		return "-"
+	}
+	return fmt.Sprintf("%s (%s)", r.LastRelease, r.LastReleaseDate.Format(dateFormat))
+}
@@ -0,0 +1,77 @@
+// Copyright 2021 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dashboard
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestLabel(t *testing.T) {
+	tests := []struct {
+		tmpl, want string
+	}{
+		{"", "release-blocker/1.14"},
+		{"blocker/v{{ .Branch }}", "blocker/v1.14"},
+		{"release-blocker", "release-blocker"},
+	}
+	for _, tt := range tests {
+		if got, err := label(tt.tmpl, "1.14"); err != nil || got != tt.want {
+			t.Errorf("label(%q) = %q, %v, want %q", tt.tmpl, got, err, tt.want)
+		}
+	}
+	if _, err := label("{{ .Foo", "1.14"); err == nil {
+		t.Error("expected an error for an invalid template")
+	}
+}
+
+func TestWrite(t *testing.T) {
+	rows := []Row{
+		{
+			Repo:             "cilium/cilium",
+			Branch:           "1.14",
+			LastRelease:      "v1.14.3",
+			LastReleaseDate:  time.Date(2023, 10, 18, 0, 0, 0, 0, time.UTC),
+			CommitsSince:     42,
+			PendingBackports: 7,
+			Blockers:         1,
+		},
+		{Repo: "cilium/tetragon", Branch: "0.11", Error: "unable to find latest release: 403 Forbidden"},
+	}
+
+	var buf bytes.Buffer
+	if err := write(&buf, "text", rows); err != nil {
+		t.Fatal(err)
+	}
+	want := "REPOSITORY       BRANCH  LAST RELEASE                                         COMMITS SINCE  PENDING BACKPORTS  BLOCKERS\n" +
+		"cilium/cilium    1.14    v1.14.3 (2023-10-18)                                 42             7                  1\n" +
+		"cilium/tetragon  0.11    error: unable to find latest release: 403 Forbidden  0              0                  0\n"
+	if buf.String() != want {
+		t.Errorf("got:\n%s\nwant:\n%s", buf.String(), want)
+	}
+
+	buf.Reset()
+	if err := write(&buf, "html", rows[:1]); err != nil {
+		t.Fatal(err)
+	}
+	want = "<table>\n" +
+		"<tr><th>Repository</th><th>Branch</th><th>Last release</th><th>Commits since</th><th>Pending backports</th><th>Blockers</th></tr>\n" +
+		"<tr class=\"blocked\"><td>cilium/cilium</td><td>1.14</td><td>v1.14.3 (2023-10-18)</td><td>42</td><td>7</td><td>1</td></tr>\n" +
+		"</table>\n"
+	if buf.String() != want {
+		t.Errorf("got:\n%s\nwant:\n%s", buf.String(), want)
+	}
+}
@@ -0,0 +1,274 @@
+// Copyright 2021 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package downstream
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"regexp"
+	"text/template"
+
+	gh "github.com/google/go-github/v50/github"
+	flag "github.com/spf13/pflag"
+
+	"github.com/cilium/release/pkg/config"
+	"github.com/cilium/release/pkg/confirm"
+	"github.com/cilium/release/pkg/persistence"
+	"github.com/cilium/release/pkg/types"
+	"github.com/cilium/release/pkg/version"
+)
+
+// Command implements the 'downstream' subcommand.
+func Command(ctx context.Context, ghClient *gh.Client, args []string) (err error) {
+	if len(args) == 0 || (args[0] != "bump" && args[0] != "status") {
+		return fmt.Errorf("usage: downstream {bump|status} [flags]")
+	}
+
+	var (
+		cfgFile     string
+		stateFile   string
+		ver         string
+		confirmURL  string
+		environment string
+		dryRun      bool
+	)
+	fs := flag.NewFlagSet("downstream "+args[0], flag.ContinueOnError)
+	fs.StringVar(&stateFile, "state-file", persistence.DownstreamStateFile, "State file into which the URLs of the version bump PRs are stored")
+	if args[0] == "status" {
+		fs.StringVar(&ver, "version", "", "Version released (e.g.: '1.14.3') whose version bump PRs are reported, all of them if empty")
+	}
+	if args[0] == "bump" {
+		fs.StringVar(&cfgFile, "config", "release.yaml", "Configuration file containing the downstream repositories")
+		fs.StringVar(&ver, "version", "", "Version released (e.g.: '1.14.3')")
+		confirm.AddFlags(fs, &confirmURL, &environment)
+		fs.BoolVar(&dryRun, "dry-run", false, "Only print the files that would be updated")
+	}
+	if err := types.ParseFlags(fs, "downstream "+args[0], args[1:]); err != nil {
+		return err
+	}
+
+	state := &persistence.State{}
+	if _, err := os.Stat(stateFile); err == nil {
+		state, err = persistence.Load(stateFile)
+		if err != nil {
+			return fmt.Errorf("unable to read state file: %w", err)
+		}
+	}
+
+	if args[0] == "status" {
+		prs := state.DownstreamPRs
+		if len(ver) != 0 {
+			v, err := version.Parse(ver)
+			if err != nil {
+				return fmt.Errorf("--version should be of the format 'x.y.z': %w", err)
+			}
+			prs = nil
+			for _, pr := range state.DownstreamPRs {
+				if pr.Version == v.String() {
+					prs = append(prs, pr)
+				}
+			}
+		}
+		return status(ctx, ghClient, prs)
+	}
+
+	v, err := version.Parse(ver)
+	if err != nil {
+		return fmt.Errorf("--version should be of the format 'x.y.z': %w", err)
+	}
+	cfg, err := config.Load(cfgFile)
+	if err != nil {
+		return fmt.Errorf("unable to load configuration: %w", err)
+	}
+	var gate *confirm.Gate
+	if !dryRun {
+		gate, err = confirm.Open(ctx, ghClient, "downstream bump v"+v.String(), confirmURL, environment, bufio.NewReader(os.Stdin), os.Stdout)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			if rerr := gate.Report(ctx); rerr != nil && err == nil {
+				err = rerr
+			}
+		}()
+	}
+	opened := map[string]bool{}
+	for _, pr := range state.DownstreamPRs {
+		if pr.Version == v.String() {
+			opened[pr.Repo] = true
+		}
+	}
+	for _, ds := range cfg.Downstream {
+		if opened[ds.Repo] {
+			fmt.Fprintf(os.Stderr, "Version bump PR of %s already opened, skipping\n", ds.Repo)
+			continue
+		}
+		pr, err := bump(ctx, ghClient, ds, v, dryRun)
+		if err != nil {
+			return fmt.Errorf("unable to bump %s: %w", ds.Repo, err)
+		}
+		if pr == nil {
+			continue
+		}
+		fmt.Printf("Opened %s\n", pr.URL)
+		gate.Executed("Opened %s", pr.URL)
+		state.DownstreamPRs = append(state.DownstreamPRs, *pr)
+		if err := persistence.Store(stateFile, state); err != nil {
+			return fmt.Errorf("unable to store state: %w", err)
+		}
+	}
+	return nil
+}
+
+// bump opens a PR updating the files of the downstream repository to the
+// given version. It returns nil if no file needed to be updated, or if
+// dryRun is set.
+func bump(ctx context.Context, ghClient *gh.Client, ds config.Downstream, v version.Version, dryRun bool) (*persistence.DownstreamPR, error) {
+	owner, repo, err := types.SplitRepoName(ds.Repo)
+	if err != nil {
+		return nil, err
+	}
+	base := ds.Base
+	if len(base) == 0 {
+		r, _, err := ghClient.Repositories.Get(ctx, owner, repo)
+		if err != nil {
+			return nil, err
+		}
+		base = r.GetDefaultBranch()
+	}
+
+	type update struct {
+		path, content, sha string
+	}
+	var updates []update
+	for _, rule := range ds.Files {
+		file, _, _, err := ghClient.Repositories.GetContents(ctx, owner, repo, rule.Path, &gh.RepositoryContentGetOptions{Ref: base})
+		if err != nil {
+			return nil, fmt.Errorf("unable to get %s: %w", rule.Path, err)
+		}
+		content, err := file.GetContent()
+		if err != nil {
+			return nil, err
+		}
+		bumped, err := bumpContent(content, rule, v)
+		if err != nil {
+			return nil, err
+		}
+		if bumped == content {
+			continue
+		}
+		updates = append(updates, update{path: rule.Path, content: bumped, sha: file.GetSHA()})
+	}
+	if len(updates) == 0 {
+		fmt.Fprintf(os.Stderr, "%s is already up to date\n", ds.Repo)
+		return nil, nil
+	}
+	if dryRun {
+		for _, u := range updates {
+			fmt.Printf("Would update %s in %s\n", u.path, ds.Repo)
+		}
+		return nil, nil
+	}
+
+	ref, _, err := ghClient.Git.GetRef(ctx, owner, repo, "heads/"+base)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get %s: %w", base, err)
+	}
+	branch := "bump-v" + v.String()
+	_, _, err = ghClient.Git.CreateRef(ctx, owner, repo, &gh.Reference{
+		Ref:    gh.String("refs/heads/" + branch),
+		Object: &gh.GitObject{SHA: ref.GetObject().SHA},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to create branch %s: %w", branch, err)
+	}
+	title := fmt.Sprintf("Bump to v%s", v)
+	for _, u := range updates {
+		_, _, err := ghClient.Repositories.UpdateFile(ctx, owner, repo, u.path, &gh.RepositoryContentFileOptions{
+			Message: gh.String(fmt.Sprintf("%s: %s", u.path, title)),
+			Content: []byte(u.content),
+			SHA:     gh.String(u.sha),
+			Branch:  gh.String(branch),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("unable to update %s: %w", u.path, err)
+		}
+	}
+	pr, _, err := ghClient.PullRequests.Create(ctx, owner, repo, &gh.NewPullRequest{
+		Title: gh.String(title),
+		Head:  gh.String(branch),
+		Base:  gh.String(base),
+		Body:  gh.String(fmt.Sprintf("v%s was released, this updates the references to the new version.", v)),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to open PR: %w", err)
+	}
+	return &persistence.DownstreamPR{
+		Repo:    ds.Repo,
+		Version: v.String(),
+		Number:  pr.GetNumber(),
+		URL:     pr.GetHTMLURL(),
+	}, nil
+}
+
+// bumpContent returns the content with the matches of the rule replaced by
+// the given version.
+func bumpContent(content string, rule config.BumpRule, v version.Version) (string, error) {
+	re, err := regexp.Compile(rule.Regex)
+	if err != nil {
+		return "", fmt.Errorf("invalid regex of %s: %w", rule.Path, err)
+	}
+	t, err := template.New(rule.Path).Parse(rule.Replace)
+	if err != nil {
+		return "", fmt.Errorf("invalid replace template of %s: %w", rule.Path, err)
+	}
+	var buf bytes.Buffer
+	err = t.Execute(&buf, struct{ Version, Minor string }{v.String(), v.MinorString()})
+	if err != nil {
+		return "", fmt.Errorf("invalid replace template of %s: %w", rule.Path, err)
+	}
+	return re.ReplaceAllString(content, buf.String()), nil
+}
+
+// status reports whether the version bump PRs were merged and returns an
+// error while some are still open.
+func status(ctx context.Context, ghClient *gh.Client, prs []persistence.DownstreamPR) error {
+	pending := 0
+	for _, dpr := range prs {
+		owner, repo, err := types.SplitRepoName(dpr.Repo)
+		if err != nil {
+			return err
+		}
+		pr, _, err := ghClient.PullRequests.Get(ctx, owner, repo, dpr.Number)
+		if err != nil {
+			return fmt.Errorf("unable to get %s: %w", dpr.URL, err)
+		}
+		state := pr.GetState()
+		if pr.GetMerged() {
+			state = "merged"
+		} else {
+			pending++
+		}
+		fmt.Printf("%-8s %s\n", state, dpr.URL)
+	}
+	if pending != 0 {
+		return fmt.Errorf("%d of the %d version bump PRs are not merged yet", pending, len(prs))
+	}
+	fmt.Printf("All %d version bump PRs are merged\n", len(prs))
+	return nil
+}
@@ -0,0 +1,111 @@
+// Copyright 2021 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package downstream
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"testing"
+
+	gh "github.com/google/go-github/v50/github"
+
+	"github.com/cilium/release/pkg/config"
+	"github.com/cilium/release/pkg/persistence"
+	"github.com/cilium/release/pkg/version"
+)
+
+func Test_bumpContent(t *testing.T) {
+	v, _ := version.Parse("1.14.3")
+	tests := []struct {
+		name    string
+		content string
+		rule    config.BumpRule
+		want    string
+	}{
+		{
+			name:    "version file",
+			content: "v1.14.2\n",
+			rule:    config.BumpRule{Regex: `v[0-9]+\.[0-9]+\.[0-9]+`, Replace: "v{{ .Version }}"},
+			want:    "v1.14.3\n",
+		},
+		{
+			name:    "submatch",
+			content: "version: 1.14.2\nappVersion: 1.14.2\n",
+			rule:    config.BumpRule{Regex: `(?m)^(appVersion): .*$`, Replace: "$1: {{ .Version }}"},
+			want:    "version: 1.14.2\nappVersion: 1.14.3\n",
+		},
+		{
+			name:    "minor",
+			content: "docs for v1.13\n",
+			rule:    config.BumpRule{Regex: `v1\.[0-9]+`, Replace: "v{{ .Minor }}"},
+			want:    "docs for v1.14\n",
+		},
+		{
+			name:    "no match",
+			content: "foo\n",
+			rule:    config.BumpRule{Regex: `v[0-9]+`, Replace: "v{{ .Version }}"},
+			want:    "foo\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := bumpContent(tt.content, tt.rule, v)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("bumpContent() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStatusVersion(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/repos/cilium/cilium-cli/pulls/10":
+			fmt.Fprint(w, `{"number": 10, "state": "closed", "merged": true}`)
+		case "/repos/cilium/cilium-cli/pulls/9":
+			fmt.Fprint(w, `{"number": 9, "state": "open"}`)
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL)
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+	ghClient := gh.NewClient(nil)
+	ghClient.BaseURL, _ = url.Parse(srv.URL + "/")
+
+	stateFile := filepath.Join(t.TempDir(), persistence.DownstreamStateFile)
+	err := persistence.Store(stateFile, &persistence.State{
+		DownstreamPRs: []persistence.DownstreamPR{
+			{Repo: "cilium/cilium-cli", Version: "1.13.8", Number: 9, URL: "https://github.com/cilium/cilium-cli/pull/9"},
+			{Repo: "cilium/cilium-cli", Version: "1.14.3", Number: 10, URL: "https://github.com/cilium/cilium-cli/pull/10"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := Command(context.Background(), ghClient, []string{"status", "--state-file", stateFile, "--version", "1.14.3"}); err != nil {
+		t.Errorf("the PRs of 1.14.3 are merged, got %v", err)
+	}
+	if err := Command(context.Background(), ghClient, []string{"status", "--state-file", stateFile}); err == nil {
+		t.Error("the PR of 1.13.8 is open, got no error")
+	}
+}
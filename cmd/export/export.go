@@ -0,0 +1,281 @@
+// Copyright 2021 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package export
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	gh "github.com/google/go-github/v50/github"
+	flag "github.com/spf13/pflag"
+
+	"github.com/cilium/release/pkg/persistence"
+	"github.com/cilium/release/pkg/report"
+	"github.com/cilium/release/pkg/types"
+)
+
+const usage = "usage: export sqlite --state-file <file> [flags]"
+
+// schema creates the tables of the exported database.
+const schema = `CREATE TABLE pull_requests (
+  repo TEXT NOT NULL,
+  number INTEGER NOT NULL,
+  title TEXT,
+  release_note TEXT,
+  release_label TEXT,
+  author TEXT,
+  author_association TEXT,
+  milestone TEXT,
+  url TEXT,
+  merged_at TEXT,
+  commit_sha TEXT,
+  additions INTEGER,
+  deletions INTEGER,
+  files INTEGER,
+  PRIMARY KEY (repo, number)
+);
+CREATE TABLE labels (repo TEXT, pr INTEGER, label TEXT);
+CREATE TABLE reviewers (repo TEXT, pr INTEGER, login TEXT);
+CREATE TABLE backports (backport_repo TEXT, backport_pr INTEGER, upstream_repo TEXT, upstream_pr INTEGER);
+CREATE TABLE commits (sha TEXT, repo TEXT, pr INTEGER);
+CREATE TABLE orphans (reason TEXT, sha TEXT, pr INTEGER, upstream_pr INTEGER, title TEXT, url TEXT);
+CREATE TABLE pending_commits (sha TEXT PRIMARY KEY);
+CREATE TABLE new_contributors (login TEXT PRIMARY KEY, new INTEGER);
+`
+
+// Command implements the 'export' subcommand.
+func Command(ctx context.Context, ghClient *gh.Client, args []string) error {
+	if len(args) == 0 || args[0] != "sqlite" {
+		return fmt.Errorf(usage)
+	}
+	var stateFile, output, sqlFile, repoName, upstreamRepoName string
+	fs := flag.NewFlagSet("export sqlite", flag.ContinueOnError)
+	fs.StringVar(&stateFile, "state-file", "", "State file of the release notes generation whose PRs are exported")
+	fs.StringVar(&repoName, "repo", "cilium/cilium", "GitHub organization and repository names, separated by a slash, of the commits and PRs of the state file")
+	fs.StringVar(&upstreamRepoName, "upstream-repo", "", "GitHub organization and repository names, separated by a slash, of the upstream PRs referenced by backport PRs, if different from --repo")
+	fs.StringVar(&output, "output", "release.db", "SQLite database the PRs are exported into, replaced if it exists")
+	fs.StringVar(&sqlFile, "sql", "", "When set, the SQL statements are written into this file instead of being run with the sqlite3 command")
+	if err := types.ParseFlags(fs, "export sqlite", args[1:]); err != nil {
+		return err
+	}
+	if len(stateFile) == 0 {
+		return fmt.Errorf(usage)
+	}
+	if _, _, err := types.SplitRepoName(repoName); err != nil {
+		return err
+	}
+	if len(upstreamRepoName) == 0 {
+		upstreamRepoName = repoName
+	} else if _, _, err := types.SplitRepoName(upstreamRepoName); err != nil {
+		return err
+	}
+
+	state, err := persistence.Load(stateFile)
+	if err != nil {
+		return fmt.Errorf("unable to read state file: %w", err)
+	}
+	script, err := sqlScript(state, repoName, upstreamRepoName)
+	if err != nil {
+		return err
+	}
+	if len(sqlFile) != 0 {
+		if err := os.WriteFile(sqlFile, script, 0644); err != nil {
+			return err
+		}
+		report.Written(sqlFile)
+		return nil
+	}
+
+	if err := os.Remove(output); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "sqlite3", output)
+	cmd.Stdin = bytes.NewReader(script)
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("sqlite3 %s: %w: %s", output, err, strings.TrimSpace(stderr.String()))
+	}
+	report.Written(output)
+	fmt.Fprintf(os.Stderr, "PRs of %s exported into %s\n", stateFile, output)
+	return nil
+}
+
+// exportedPR is a PR of the state along with its repository.
+type exportedPR struct {
+	repo   string
+	number int
+	pr     types.PullRequest
+}
+
+// sqlScript returns the SQL statements creating the tables and inserting
+// the PRs, backports, commits, orphans and pending commits of the given
+// state. The PRs are keyed on their repository, repo for the PRs and the
+// backport PRs and upstreamRepo for the upstream PRs of the backports,
+// which are stored with the other PRs, unless they are set on the PRs.
+func sqlScript(state *persistence.State, repo, upstreamRepo string) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteString("BEGIN TRANSACTION;\n")
+	buf.WriteString(schema)
+
+	prRepo := func(pr types.PullRequest, repo string) string {
+		if len(pr.Repo) != 0 {
+			return pr.Repo
+		}
+		return repo
+	}
+	var prs []exportedPR
+	seen := map[string]map[int]bool{}
+	add := func(repo string, number int, pr types.PullRequest) {
+		if seen[repo] == nil {
+			seen[repo] = map[int]bool{}
+		}
+		if seen[repo][number] {
+			return
+		}
+		seen[repo][number] = true
+		prs = append(prs, exportedPR{repo: repo, number: number, pr: pr})
+	}
+	for number, pr := range state.PullRequests {
+		add(prRepo(pr, repo), number, pr)
+	}
+	type backport struct {
+		backportPR, upstreamPR int
+		upstreamRepo           string
+	}
+	var backports []backport
+	for backportPR, upstreamPRs := range state.BackportPRs {
+		for number, pr := range upstreamPRs {
+			add(prRepo(pr, upstreamRepo), number, pr)
+			backports = append(backports, backport{backportPR, number, prRepo(pr, upstreamRepo)})
+		}
+	}
+	sort.Slice(backports, func(i, j int) bool {
+		if backports[i].backportPR != backports[j].backportPR {
+			return backports[i].backportPR < backports[j].backportPR
+		}
+		return backports[i].upstreamPR < backports[j].upstreamPR
+	})
+	for _, b := range backports {
+		if err := insert(&buf, "backports", repo, b.backportPR, b.upstreamRepo, b.upstreamPR); err != nil {
+			return nil, err
+		}
+	}
+	sort.Slice(prs, func(i, j int) bool {
+		if prs[i].repo != prs[j].repo {
+			return prs[i].repo < prs[j].repo
+		}
+		return prs[i].number < prs[j].number
+	})
+	for _, e := range prs {
+		pr := e.pr
+		var mergedAt, additions, deletions, files interface{}
+		if !pr.MergedAt.IsZero() {
+			mergedAt = pr.MergedAt.UTC().Format(time.RFC3339)
+		}
+		if pr.Risk != nil {
+			additions, deletions, files = pr.Risk.Additions, pr.Risk.Deletions, pr.Risk.Files
+		}
+		err := insert(&buf, "pull_requests", e.repo, e.number, pr.Title, pr.ReleaseNote, pr.ReleaseLabel, pr.AuthorName,
+			pr.AuthorAssociation, pr.Milestone, pr.URL, mergedAt, pr.Commit, additions, deletions, files)
+		if err != nil {
+			return nil, err
+		}
+		for _, lbl := range pr.Labels {
+			if err := insert(&buf, "labels", e.repo, e.number, lbl); err != nil {
+				return nil, err
+			}
+		}
+		for _, login := range pr.Reviewers {
+			if err := insert(&buf, "reviewers", e.repo, e.number, login); err != nil {
+				return nil, err
+			}
+		}
+	}
+	shas := make([]string, 0, len(state.CommitPRs))
+	for sha := range state.CommitPRs {
+		shas = append(shas, sha)
+	}
+	sort.Strings(shas)
+	for _, sha := range shas {
+		for _, number := range state.CommitPRs[sha] {
+			if err := insert(&buf, "commits", sha, repo, number); err != nil {
+				return nil, err
+			}
+		}
+	}
+	for _, o := range state.Orphans {
+		if err := insert(&buf, "orphans", o.Reason, o.SHA, o.PR, o.UpstreamPR, o.Title, o.URL); err != nil {
+			return nil, err
+		}
+	}
+	for _, sha := range state.SHAs {
+		if err := insert(&buf, "pending_commits", sha); err != nil {
+			return nil, err
+		}
+	}
+	logins := make([]string, 0, len(state.NewContributors))
+	for login := range state.NewContributors {
+		logins = append(logins, login)
+	}
+	sort.Strings(logins)
+	for _, login := range logins {
+		if err := insert(&buf, "new_contributors", login, state.NewContributors[login]); err != nil {
+			return nil, err
+		}
+	}
+
+	buf.WriteString("COMMIT;\n")
+	return buf.Bytes(), nil
+}
+
+// insert writes the statement inserting the given values into table.
+func insert(buf *bytes.Buffer, table string, values ...interface{}) error {
+	literals := make([]string, 0, len(values))
+	for _, v := range values {
+		l, err := literal(v)
+		if err != nil {
+			return fmt.Errorf("unable to insert into %s: %w", table, err)
+		}
+		literals = append(literals, l)
+	}
+	fmt.Fprintf(buf, "INSERT INTO %s VALUES (%s);\n", table, strings.Join(literals, ", "))
+	return nil
+}
+
+// literal returns the SQL literal of the given value.
+func literal(v interface{}) (string, error) {
+	switch v := v.(type) {
+	case nil:
+		return "NULL", nil
+	case int:
+		return strconv.Itoa(v), nil
+	case bool:
+		if v {
+			return "1", nil
+		}
+		return "0", nil
+	case string:
+		return "'" + strings.ReplaceAll(v, "'", "''") + "'", nil
+	}
+	return "", fmt.Errorf("unsupported SQL value %T", v)
+}
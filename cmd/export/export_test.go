@@ -0,0 +1,90 @@
+// Copyright 2021 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package export
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cilium/release/pkg/persistence"
+	"github.com/cilium/release/pkg/types"
+)
+
+func TestSQLScript(t *testing.T) {
+	state := &persistence.State{
+		PullRequests: types.PullRequests{
+			123: {
+				Title:       "Add foo",
+				ReleaseNote: "Add Alice's foo",
+				AuthorName:  "alice",
+				Labels:      []string{"release-note/minor", "area/cli"},
+				MergedAt:    time.Date(2023, 7, 12, 9, 30, 0, 0, time.UTC),
+				Reviewers:   []string{"bob"},
+				Risk:        &types.Risk{Additions: 10, Deletions: 2, Files: 3},
+			},
+		},
+		BackportPRs: types.BackportPRs{
+			200: {
+				// The upstream PR has the number of a PR of
+				// the released repository.
+				123: {ReleaseNote: "Fix bar", AuthorName: "bob"},
+			},
+		},
+		CommitPRs: map[string][]int{
+			"5e3f0a2b9e3f1c0a2b9e3f1c0a2b9e3f1c0a2b9e": {123},
+			"6f4a0a2b9e3f1c0a2b9e3f1c0a2b9e3f1c0a2b9e": {200},
+		},
+		SHAs:            []string{"3f1c0a2b9e3f1c0a2b9e3f1c0a2b9e3f1c0a2b9e"},
+		NewContributors: map[string]bool{"alice": true},
+		Orphans:         []types.Orphan{{Reason: types.OrphanNoPR, SHA: "4d2e"}},
+	}
+	script, err := sqlScript(state, "example/cilium-release", "cilium/cilium")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := string(script)
+	want := []string{
+		"INSERT INTO backports VALUES ('example/cilium-release', 200, 'cilium/cilium', 123);",
+		"INSERT INTO pull_requests VALUES ('cilium/cilium', 123, '', 'Fix bar', '', 'bob', '', '', '', NULL, '', NULL, NULL, NULL);",
+		"INSERT INTO pull_requests VALUES ('example/cilium-release', 123, 'Add foo', 'Add Alice''s foo', '', 'alice', '', '', '', '2023-07-12T09:30:00Z', '', 10, 2, 3);",
+		"INSERT INTO labels VALUES ('example/cilium-release', 123, 'release-note/minor');",
+		"INSERT INTO labels VALUES ('example/cilium-release', 123, 'area/cli');",
+		"INSERT INTO reviewers VALUES ('example/cilium-release', 123, 'bob');",
+		"INSERT INTO commits VALUES ('5e3f0a2b9e3f1c0a2b9e3f1c0a2b9e3f1c0a2b9e', 'example/cilium-release', 123);",
+		"INSERT INTO commits VALUES ('6f4a0a2b9e3f1c0a2b9e3f1c0a2b9e3f1c0a2b9e', 'example/cilium-release', 200);",
+		"INSERT INTO orphans VALUES ('no-pr', '4d2e', 0, 0, '', '');",
+		"INSERT INTO pending_commits VALUES ('3f1c0a2b9e3f1c0a2b9e3f1c0a2b9e3f1c0a2b9e');",
+		"INSERT INTO new_contributors VALUES ('alice', 1);",
+	}
+	var inserts []string
+	for _, line := range strings.Split(got, "\n") {
+		if strings.HasPrefix(line, "INSERT") {
+			inserts = append(inserts, line)
+		}
+	}
+	if strings.Join(inserts, "\n") != strings.Join(want, "\n") {
+		t.Errorf("got:\n%s\nwant:\n%s", strings.Join(inserts, "\n"), strings.Join(want, "\n"))
+	}
+	if !strings.HasPrefix(got, "BEGIN TRANSACTION;\n") || !strings.HasSuffix(got, "COMMIT;\n") {
+		t.Errorf("statements are not run in a transaction:\n%s", got)
+	}
+}
+
+func TestLiteralUnsupported(t *testing.T) {
+	if _, err := literal(1.5); err == nil {
+		t.Error("literal(1.5) succeeded, want an unsupported value error")
+	}
+}
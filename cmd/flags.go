@@ -0,0 +1,43 @@
+// Copyright 2021 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import "strings"
+
+// extractFlag returns the value of the given string flag, e.g. 'token-file',
+// found in args, and args without it, so that the flag can be given to any
+// command before its arguments are parsed. The arguments after '--' are
+// left untouched.
+func extractFlag(args []string, name string) (string, []string) {
+	var (
+		value string
+		rest  []string
+	)
+	flag := "--" + name
+	for i := 0; i < len(args); i++ {
+		switch {
+		case args[i] == "--":
+			return value, append(rest, args[i:]...)
+		case args[i] == flag && i+1 < len(args):
+			value = args[i+1]
+			i++
+		case strings.HasPrefix(args[i], flag+"="):
+			value = strings.TrimPrefix(args[i], flag+"=")
+		default:
+			rest = append(rest, args[i])
+		}
+	}
+	return value, rest
+}
@@ -0,0 +1,41 @@
+// Copyright 2021 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestExtractFlag(t *testing.T) {
+	tests := []struct {
+		args     []string
+		wantFile string
+		wantArgs []string
+	}{
+		{args: []string{"check", "auth", "--token-file", "/run/token"}, wantFile: "/run/token", wantArgs: []string{"check", "auth"}},
+		{args: []string{"--token-file=-", "--base", "v1.14.0"}, wantFile: "-", wantArgs: []string{"--base", "v1.14.0"}},
+		{args: []string{"--base", "v1.14.0", "--", "--token-file", "x"}, wantArgs: []string{"--base", "v1.14.0", "--", "--token-file", "x"}},
+	}
+	for _, tt := range tests {
+		t.Run(strings.Join(tt.args, " "), func(t *testing.T) {
+			gotFile, gotArgs := extractFlag(tt.args, "token-file")
+			if gotFile != tt.wantFile || !reflect.DeepEqual(gotArgs, tt.wantArgs) {
+				t.Errorf("extractFlag() = %q, %v, want %q, %v", gotFile, gotArgs, tt.wantFile, tt.wantArgs)
+			}
+		})
+	}
+}
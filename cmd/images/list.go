@@ -0,0 +1,136 @@
+// Copyright 2021 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package images
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+
+	gh "github.com/google/go-github/v50/github"
+	flag "github.com/spf13/pflag"
+
+	"github.com/cilium/release/pkg/config"
+	"github.com/cilium/release/pkg/report"
+	"github.com/cilium/release/pkg/types"
+)
+
+// Command implements the 'images' subcommand.
+func Command(ctx context.Context, ghClient *gh.Client, args []string) error {
+	if len(args) == 0 || args[0] != "list" {
+		return fmt.Errorf("usage: images list <version> [flags]")
+	}
+	return listCommand(ctx, ghClient, args[1:])
+}
+
+// listCommand implements the 'images list' subcommand, which lists the
+// images of the release with their digest and platforms as the Docker
+// Manifests table of the release notes.
+func listCommand(ctx context.Context, _ *gh.Client, args []string) error {
+	var (
+		cfgFile     string
+		profileName string
+		notes       string
+	)
+	fs := flag.NewFlagSet("images list", flag.ContinueOnError)
+	fs.StringVar(&cfgFile, "config", "release.yaml", "Configuration file containing the image repositories")
+	fs.StringVar(&profileName, "profile", "", "Profile of --config whose image repositories are listed instead of the top-level ones")
+	fs.StringVar(&notes, "notes", "", "Release notes file the table is written into, replacing its placeholder or appended, instead of the standard output")
+	if err := types.ParseFlags(fs, "images list", args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return fmt.Errorf("usage: images list <version> [flags]")
+	}
+	tag := "v" + strings.TrimPrefix(fs.Arg(0), "v")
+
+	cfg, err := config.Load(cfgFile)
+	if err != nil {
+		return fmt.Errorf("unable to load configuration: %w", err)
+	}
+	if _, ok := cfg.UseProfile(profileName); !ok && len(profileName) != 0 {
+		return fmt.Errorf("no profile %q in %s", profileName, cfgFile)
+	}
+	if len(cfg.Images.Repositories) == 0 {
+		return fmt.Errorf("no image repositories in %s", cfgFile)
+	}
+
+	images, err := listImages(ctx, &registry{client: http.DefaultClient, scheme: "https"}, cfg.Images, tag)
+	if err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	writeManifests(&buf, images)
+	if len(notes) == 0 {
+		_, err := buf.WriteTo(os.Stdout)
+		return err
+	}
+	return writeNotesManifests(notes, buf.Bytes())
+}
+
+// writeNotesManifests writes the Docker Manifests table into the release
+// notes file, in place of config.ImagesPlaceholder if the notes contain it,
+// or after them otherwise.
+func writeNotesManifests(notes string, table []byte) error {
+	b, err := os.ReadFile(notes)
+	if err != nil {
+		return err
+	}
+	placeholder := []byte(config.ImagesPlaceholder)
+	if bytes.Contains(b, placeholder) {
+		b = bytes.Replace(b, placeholder, bytes.TrimRight(table, "\n"), 1)
+	} else {
+		b = append(append(b, '\n'), table...)
+	}
+	if err := os.WriteFile(notes, b, 0644); err != nil {
+		return err
+	}
+	report.Written(notes)
+	return nil
+}
+
+// listImages returns the tag of each of the repositories. It fails if any
+// of them doesn't have the tag.
+func listImages(ctx context.Context, r *registry, images config.Images, tag string) ([]*image, error) {
+	var list []*image
+	for _, repository := range images.Repositories {
+		img, err := r.image(ctx, repository, tag)
+		if err != nil {
+			return nil, fmt.Errorf("unable to get the manifest of %s:%s: %w", repository, tag, err)
+		}
+		if img == nil {
+			return nil, fmt.Errorf("%s:%s not found", repository, tag)
+		}
+		list = append(list, img)
+	}
+	return list, nil
+}
+
+// writeManifests writes the images as the Docker Manifests table of the
+// release notes.
+func writeManifests(w io.Writer, images []*image) {
+	fmt.Fprintln(w, "Docker Manifests")
+	fmt.Fprintln(w, "----------------")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "| Image | Digest | Platforms |")
+	fmt.Fprintln(w, "|-------|--------|-----------|")
+	for _, img := range images {
+		fmt.Fprintf(w, "| `%s:%s` | `%s` | %s |\n", img.Repository, img.Tag, img.Digest, strings.Join(img.Platforms, ", "))
+	}
+}
@@ -0,0 +1,113 @@
+// Copyright 2021 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package images
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/cilium/release/pkg/config"
+)
+
+func TestListImages(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v2/cilium/cilium/manifests/v1.14.3":
+			w.Header().Set("Docker-Content-Digest", "sha256:111")
+			fmt.Fprint(w, `{"manifests": [
+				{"platform": {"os": "linux", "architecture": "arm64"}},
+				{"platform": {"os": "linux", "architecture": "amd64"}}
+			]}`)
+		case "/v2/cilium/operator-generic/manifests/v1.14.3":
+			w.Header().Set("Docker-Content-Digest", "sha256:222")
+			fmt.Fprint(w, `{"config": {"digest": "sha256:abc"}}`)
+		case "/v2/cilium/operator-generic/blobs/sha256:abc":
+			fmt.Fprint(w, `{"os": "linux", "architecture": "amd64"}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+	host := strings.TrimPrefix(srv.URL, "http://")
+	r := &registry{client: srv.Client(), scheme: "http"}
+
+	images := config.Images{Repositories: []string{host + "/cilium/cilium", host + "/cilium/operator-generic"}}
+	list, err := listImages(context.Background(), r, images, "v1.14.3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	writeManifests(&buf, list)
+	want := "Docker Manifests\n" +
+		"----------------\n" +
+		"\n" +
+		"| Image | Digest | Platforms |\n" +
+		"|-------|--------|-----------|\n" +
+		"| `" + host + "/cilium/cilium:v1.14.3` | `sha256:111` | linux/amd64, linux/arm64 |\n" +
+		"| `" + host + "/cilium/operator-generic:v1.14.3` | `sha256:222` | linux/amd64 |\n"
+	if buf.String() != want {
+		t.Errorf("got:\n%s\nwant:\n%s", buf.String(), want)
+	}
+
+	images.Repositories = append(images.Repositories, host+"/cilium/clustermesh-apiserver")
+	if _, err := listImages(context.Background(), r, images, "v1.14.3"); err == nil {
+		t.Error("missing image listed")
+	}
+}
+
+func TestWriteNotesManifests(t *testing.T) {
+	table := "Docker Manifests\n----------------\n"
+	tests := []struct {
+		name  string
+		notes string
+		want  string
+	}{
+		{
+			name:  "appended",
+			notes: "Summary of Changes\n",
+			want:  "Summary of Changes\n\nDocker Manifests\n----------------\n",
+		},
+		{
+			name:  "placeholder",
+			notes: "Summary of Changes\n\n" + config.ImagesPlaceholder + "\n\nThanks!\n",
+			want:  "Summary of Changes\n\nDocker Manifests\n----------------\n\nThanks!\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			notes := filepath.Join(t.TempDir(), "release-notes.md")
+			if err := os.WriteFile(notes, []byte(tt.notes), 0644); err != nil {
+				t.Fatal(err)
+			}
+			if err := writeNotesManifests(notes, []byte(table)); err != nil {
+				t.Fatal(err)
+			}
+			got, err := os.ReadFile(notes)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("got:\n%s\nwant:\n%s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,298 @@
+// Copyright 2021 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package images
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	gh "github.com/google/go-github/v50/github"
+	flag "github.com/spf13/pflag"
+
+	"github.com/cilium/release/pkg/config"
+	"github.com/cilium/release/pkg/types"
+)
+
+// manifestTypes are the media types of the manifests accepted from the
+// registries, the multi-platform ones first.
+var manifestTypes = []string{
+	"application/vnd.oci.image.index.v1+json",
+	"application/vnd.docker.distribution.manifest.list.v2+json",
+	"application/vnd.oci.image.manifest.v1+json",
+	"application/vnd.docker.distribution.manifest.v2+json",
+}
+
+// manifest is an image manifest or, if Manifests is set, an image index
+// referencing the manifest of each platform.
+type manifest struct {
+	Manifests []struct {
+		Platform struct {
+			OS           string `json:"os"`
+			Architecture string `json:"architecture"`
+			Variant      string `json:"variant"`
+		} `json:"platform"`
+	} `json:"manifests"`
+	Config struct {
+		Digest string `json:"digest"`
+	} `json:"config"`
+}
+
+// imageConfig is the part of the configuration of an image giving its
+// platform.
+type imageConfig struct {
+	OS           string `json:"os"`
+	Architecture string `json:"architecture"`
+	Variant      string `json:"variant"`
+}
+
+// platform returns the 'os/arch[/variant]' representation of a platform.
+func platform(goos, arch, variant string) string {
+	p := goos + "/" + arch
+	if len(variant) != 0 {
+		p += "/" + variant
+	}
+	return p
+}
+
+// registry queries the image manifests through the OCI distribution API,
+// anonymously.
+type registry struct {
+	client *http.Client
+	// scheme is the scheme of the registry URLs, 'https' except in
+	// tests.
+	scheme string
+}
+
+// splitRepository splits an image repository, e.g. 'quay.io/cilium/cilium',
+// into its registry host and its name in the registry. The repositories
+// without registry are the ones of Docker Hub.
+func splitRepository(repository string) (host, name string) {
+	parts := strings.SplitN(repository, "/", 2)
+	if len(parts) == 2 && strings.ContainsAny(parts[0], ".:") {
+		return parts[0], parts[1]
+	}
+	if len(parts) == 1 {
+		return "registry-1.docker.io", "library/" + repository
+	}
+	return "registry-1.docker.io", repository
+}
+
+var challengeParamRe = regexp.MustCompile(`(\w+)="([^"]*)"`)
+
+// get fetches the given path of the registry of host with the given Accept
+// header. On an authentication challenge, an anonymous token is requested
+// from the realm of the challenge and the request retried with it.
+func (r *registry) get(ctx context.Context, host, path string, accept []string) (*http.Response, error) {
+	do := func(token string) (*http.Response, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, r.scheme+"://"+host+path, nil)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Accept", strings.Join(accept, ", "))
+		if len(token) != 0 {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		return r.client.Do(req)
+	}
+	resp, err := do("")
+	if err != nil || resp.StatusCode != http.StatusUnauthorized {
+		return resp, err
+	}
+	resp.Body.Close()
+	challenge := resp.Header.Get("WWW-Authenticate")
+	if !strings.HasPrefix(challenge, "Bearer ") {
+		return nil, fmt.Errorf("unsupported authentication challenge %q from %s", challenge, host)
+	}
+	params := map[string]string{}
+	for _, m := range challengeParamRe.FindAllStringSubmatch(challenge, -1) {
+		params[m[1]] = m[2]
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, params["realm"], nil)
+	if err != nil {
+		return nil, err
+	}
+	q := req.URL.Query()
+	for _, k := range []string{"service", "scope"} {
+		if v, ok := params[k]; ok {
+			q.Set(k, v)
+		}
+	}
+	req.URL.RawQuery = q.Encode()
+	tokenResp, err := r.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer tokenResp.Body.Close()
+	if tokenResp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unable to get a token from %s: %s", params["realm"], tokenResp.Status)
+	}
+	var token struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(tokenResp.Body).Decode(&token); err != nil {
+		return nil, err
+	}
+	if len(token.Token) == 0 {
+		token.Token = token.AccessToken
+	}
+	return do(token.Token)
+}
+
+// getJSON decodes the JSON of the given path of the registry into v and
+// returns the headers of the response. It returns false if the path doesn't
+// exist.
+func (r *registry) getJSON(ctx context.Context, host, path string, accept []string, v interface{}) (http.Header, bool, error) {
+	resp, err := r.get(ctx, host, path, accept)
+	if err != nil {
+		return nil, false, err
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return resp.Header, true, json.NewDecoder(resp.Body).Decode(v)
+	case http.StatusNotFound:
+		return nil, false, nil
+	default:
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return nil, false, fmt.Errorf("GET %s%s: %s: %s", host, path, resp.Status, strings.TrimSpace(string(body)))
+	}
+}
+
+// image is a tag of an image repository.
+type image struct {
+	Repository string
+	Tag        string
+	// Digest is the digest of the manifest, or of the index of the
+	// manifests of each platform, the tag points to.
+	Digest string
+	// Platforms are the platforms, sorted, the tag is available for.
+	Platforms []string
+}
+
+// image returns the given tag of the repository, or nil if the tag doesn't
+// exist.
+func (r *registry) image(ctx context.Context, repository, tag string) (*image, error) {
+	host, name := splitRepository(repository)
+	var m manifest
+	header, found, err := r.getJSON(ctx, host, "/v2/"+name+"/manifests/"+tag, manifestTypes, &m)
+	if err != nil || !found {
+		return nil, err
+	}
+	var platforms []string
+	if len(m.Manifests) != 0 {
+		for _, d := range m.Manifests {
+			// Attestations are referenced with an unknown platform.
+			if d.Platform.OS == "unknown" || len(d.Platform.OS) == 0 {
+				continue
+			}
+			platforms = append(platforms, platform(d.Platform.OS, d.Platform.Architecture, d.Platform.Variant))
+		}
+	} else {
+		var c imageConfig
+		_, found, err := r.getJSON(ctx, host, "/v2/"+name+"/blobs/"+m.Config.Digest, nil, &c)
+		if err != nil {
+			return nil, err
+		}
+		if !found {
+			return nil, fmt.Errorf("configuration %s of %s:%s not found", m.Config.Digest, repository, tag)
+		}
+		platforms = append(platforms, platform(c.OS, c.Architecture, c.Variant))
+	}
+	sort.Strings(platforms)
+	return &image{
+		Repository: repository,
+		Tag:        tag,
+		Digest:     header.Get("Docker-Content-Digest"),
+		Platforms:  platforms,
+	}, nil
+}
+
+// missingImages returns, for each of the repositories, what is missing of
+// the tag: the tag itself or the platforms it isn't available for. The
+// repositories not missing anything are left out.
+func missingImages(ctx context.Context, r *registry, images config.Images, tag string) (map[string][]string, error) {
+	missing := map[string][]string{}
+	for _, repository := range images.Repositories {
+		img, err := r.image(ctx, repository, tag)
+		if err != nil {
+			return nil, fmt.Errorf("unable to get the manifest of %s:%s: %w", repository, tag, err)
+		}
+		if img == nil {
+			missing[repository] = []string{"tag " + tag}
+			continue
+		}
+		available := map[string]bool{}
+		for _, p := range img.Platforms {
+			available[p] = true
+		}
+		for _, p := range images.Platforms {
+			if !available[p] {
+				missing[repository] = append(missing[repository], p)
+			}
+		}
+	}
+	return missing, nil
+}
+
+// TagsCommand implements the 'verify images' subcommand, which checks that
+// all the image repositories have the tag of the given version, for all the
+// platforms, before the release is published.
+func TagsCommand(ctx context.Context, _ *gh.Client, args []string) error {
+	var cfgFile, profileName string
+	fs := flag.NewFlagSet("verify images", flag.ContinueOnError)
+	fs.StringVar(&cfgFile, "config", "release.yaml", "Configuration file containing the image repositories and their platforms")
+	fs.StringVar(&profileName, "profile", "", "Profile of --config whose image repositories are checked instead of the top-level ones")
+	if err := types.ParseFlags(fs, "verify images", args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return fmt.Errorf("usage: verify images <version> [flags]")
+	}
+	tag := "v" + strings.TrimPrefix(fs.Arg(0), "v")
+
+	cfg, err := config.Load(cfgFile)
+	if err != nil {
+		return fmt.Errorf("unable to load configuration: %w", err)
+	}
+	if _, ok := cfg.UseProfile(profileName); !ok && len(profileName) != 0 {
+		return fmt.Errorf("no profile %q in %s", profileName, cfgFile)
+	}
+	if len(cfg.Images.Repositories) == 0 {
+		return fmt.Errorf("no image repositories in %s", cfgFile)
+	}
+
+	missing, err := missingImages(ctx, &registry{client: http.DefaultClient, scheme: "https"}, cfg.Images, tag)
+	if err != nil {
+		return err
+	}
+	for _, repository := range cfg.Images.Repositories {
+		if m, ok := missing[repository]; ok {
+			fmt.Printf("MISSING %s:%s: %s\n", repository, tag, strings.Join(m, ", "))
+		}
+	}
+	if len(missing) != 0 {
+		return fmt.Errorf("%d of the %d image repositories are missing %s", len(missing), len(cfg.Images.Repositories), tag)
+	}
+	fmt.Fprintf(os.Stderr, "All the %d image repositories have %s\n", len(cfg.Images.Repositories), tag)
+	return nil
+}
@@ -0,0 +1,94 @@
+// Copyright 2021 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package images
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/cilium/release/pkg/config"
+)
+
+func TestMissingImages(t *testing.T) {
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/token":
+			if r.URL.Query().Get("scope") != "repository:cilium/cilium:pull" {
+				t.Errorf("unexpected scope %q", r.URL.Query().Get("scope"))
+			}
+			fmt.Fprint(w, `{"token": "anonymous"}`)
+		case "/v2/cilium/cilium/manifests/v1.14.3":
+			if r.Header.Get("Authorization") != "Bearer anonymous" {
+				w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer realm="%s/token",service="registry",scope="repository:cilium/cilium:pull"`, srv.URL))
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			fmt.Fprint(w, `{"manifests": [
+				{"platform": {"os": "linux", "architecture": "amd64"}},
+				{"platform": {"os": "linux", "architecture": "arm64"}},
+				{"platform": {"os": "unknown", "architecture": "unknown"}}
+			]}`)
+		case "/v2/cilium/operator/manifests/v1.14.3":
+			fmt.Fprint(w, `{"config": {"digest": "sha256:abc"}}`)
+		case "/v2/cilium/operator/blobs/sha256:abc":
+			fmt.Fprint(w, `{"os": "linux", "architecture": "amd64"}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+	host := strings.TrimPrefix(srv.URL, "http://")
+
+	images := config.Images{
+		Repositories: []string{host + "/cilium/cilium", host + "/cilium/operator", host + "/cilium/hubble-relay"},
+		Platforms:    []string{"linux/amd64", "linux/arm64"},
+	}
+	got, err := missingImages(context.Background(), &registry{client: srv.Client(), scheme: "http"}, images, "v1.14.3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string][]string{
+		host + "/cilium/operator":     {"linux/arm64"},
+		host + "/cilium/hubble-relay": {"tag v1.14.3"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestSplitRepository(t *testing.T) {
+	tests := []struct {
+		repository, host, name string
+	}{
+		{repository: "quay.io/cilium/cilium", host: "quay.io", name: "cilium/cilium"},
+		{repository: "localhost:5000/cilium", host: "localhost:5000", name: "cilium"},
+		{repository: "cilium/cilium", host: "registry-1.docker.io", name: "cilium/cilium"},
+		{repository: "alpine", host: "registry-1.docker.io", name: "library/alpine"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.repository, func(t *testing.T) {
+			host, name := splitRepository(tt.repository)
+			if host != tt.host || name != tt.name {
+				t.Errorf("splitRepository() = %q, %q, want %q, %q", host, name, tt.host, tt.name)
+			}
+		})
+	}
+}
@@ -0,0 +1,245 @@
+// Copyright 2021 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package images lists, and verifies before the release is published, the
+// container images of a release.
+package images
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	gh "github.com/google/go-github/v50/github"
+	flag "github.com/spf13/pflag"
+
+	"github.com/cilium/release/pkg/config"
+	"github.com/cilium/release/pkg/types"
+)
+
+// Vulnerability is a vulnerability found in an image.
+type Vulnerability struct {
+	// Image is the scanned image, e.g. 'quay.io/cilium/cilium:v1.14.3'.
+	Image            string
+	ID               string
+	Package          string
+	InstalledVersion string
+	FixedVersion     string
+	Severity         string
+	// Accepted is set if the vulnerability is accepted by the
+	// configuration and doesn't block the release.
+	Accepted bool
+}
+
+// trivyReport is the JSON report of 'trivy image --format json'.
+type trivyReport struct {
+	ArtifactName string
+	Results      []struct {
+		Vulnerabilities []struct {
+			VulnerabilityID  string
+			PkgName          string
+			InstalledVersion string
+			FixedVersion     string
+			Severity         string
+		}
+	}
+}
+
+// VulnerabilitiesCommand implements the 'verify vulnerabilities' subcommand,
+// which fails if the images of the given version have vulnerabilities of the
+// given severities that aren't accepted by the configuration, so that the
+// release isn't published with them.
+func VulnerabilitiesCommand(ctx context.Context, _ *gh.Client, args []string) error {
+	var (
+		cfgFile     string
+		profileName string
+		reportsDir  string
+		severities  []string
+	)
+	fs := flag.NewFlagSet("verify vulnerabilities", flag.ContinueOnError)
+	fs.StringVar(&cfgFile, "config", "release.yaml", "Configuration file containing the image repositories and the accepted vulnerabilities")
+	fs.StringVar(&profileName, "profile", "", "Profile of --config whose image repositories are scanned instead of the top-level ones")
+	fs.StringVar(&reportsDir, "reports", "", "Directory of the JSON reports of 'trivy image --format json' of the images, e.g. downloaded from the scanning workflow, one of each image repository being required. The images are scanned with trivy if empty")
+	fs.StringSliceVar(&severities, "severity", []string{"CRITICAL"}, "Severities of the vulnerabilities blocking the release. Can be repeated or comma-separated")
+	if err := types.ParseFlags(fs, "verify vulnerabilities", args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return fmt.Errorf("usage: verify vulnerabilities <version> [flags]")
+	}
+	tag := "v" + strings.TrimPrefix(fs.Arg(0), "v")
+
+	cfg, err := config.Load(cfgFile)
+	if err != nil {
+		return fmt.Errorf("unable to load configuration: %w", err)
+	}
+	if _, ok := cfg.UseProfile(profileName); !ok && len(profileName) != 0 {
+		return fmt.Errorf("no profile %q in %s", profileName, cfgFile)
+	}
+	if len(cfg.Images.Repositories) == 0 {
+		return fmt.Errorf("no image repositories in %s", cfgFile)
+	}
+
+	var reports []trivyReport
+	if len(reportsDir) != 0 {
+		reports, err = readReports(reportsDir)
+		if err == nil {
+			if missing := missingReports(reports, cfg.Images.Repositories); len(missing) != 0 {
+				err = fmt.Errorf("no report in %s of the images %s", reportsDir, strings.Join(missing, ", "))
+			}
+		}
+	} else {
+		reports, err = scan(ctx, cfg.Images.Repositories, tag)
+	}
+	if err != nil {
+		return err
+	}
+
+	vulns := vulnerabilities(reports, cfg.Images, severities)
+	blocking := 0
+	for _, v := range vulns {
+		status := "blocking"
+		if v.Accepted {
+			status = "accepted"
+		} else {
+			blocking++
+		}
+		fixed := v.FixedVersion
+		if len(fixed) == 0 {
+			fixed = "no fix"
+		}
+		fmt.Printf("%s\t%s\t%s\t%s %s (%s)\t%s\n", v.Image, v.Severity, v.ID, v.Package, v.InstalledVersion, fixed, status)
+	}
+	if blocking != 0 {
+		return fmt.Errorf("%d vulnerabilities of severity %s without accepted exception in the images of %s", blocking, strings.Join(severities, ", "), tag)
+	}
+	fmt.Fprintf(os.Stderr, "No blocking vulnerability in the %d images of %s\n", len(cfg.Images.Repositories), tag)
+	return nil
+}
+
+// scan scans the images of the given tag with trivy.
+func scan(ctx context.Context, repositories []string, tag string) ([]trivyReport, error) {
+	reports := make([]trivyReport, 0, len(repositories))
+	for _, repo := range repositories {
+		image := repo + ":" + tag
+		fmt.Fprintf(os.Stderr, "Scanning %s\n", image)
+		var stdout, stderr bytes.Buffer
+		cmd := exec.CommandContext(ctx, "trivy", "image", "--quiet", "--format", "json", image)
+		cmd.Stdout = &stdout
+		cmd.Stderr = &stderr
+		if err := cmd.Run(); err != nil {
+			return nil, fmt.Errorf("unable to scan %s: %w: %s", image, err, strings.TrimSpace(stderr.String()))
+		}
+		var report trivyReport
+		if err := json.Unmarshal(stdout.Bytes(), &report); err != nil {
+			return nil, fmt.Errorf("unable to parse the report of %s: %w", image, err)
+		}
+		reports = append(reports, report)
+	}
+	return reports, nil
+}
+
+// readReports reads the trivy reports of the JSON files of dir.
+func readReports(dir string) ([]trivyReport, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no JSON report in %s", dir)
+	}
+	reports := make([]trivyReport, 0, len(files))
+	for _, file := range files {
+		b, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		var report trivyReport
+		if err := json.Unmarshal(b, &report); err != nil {
+			return nil, fmt.Errorf("unable to parse %s: %w", file, err)
+		}
+		reports = append(reports, report)
+	}
+	return reports, nil
+}
+
+// imageRepository returns the repository of the image, without its tag or
+// digest, e.g. 'quay.io/cilium/cilium' for 'quay.io/cilium/cilium:v1.14.3'.
+func imageRepository(image string) string {
+	if i := strings.LastIndexAny(image, ":@"); i > strings.LastIndex(image, "/") {
+		return image[:i]
+	}
+	return image
+}
+
+// missingReports returns the repositories without any report, so that an
+// image left out of the reports isn't silently considered free of
+// vulnerabilities.
+func missingReports(reports []trivyReport, repositories []string) []string {
+	reported := map[string]bool{}
+	for _, report := range reports {
+		reported[imageRepository(report.ArtifactName)] = true
+	}
+	var missing []string
+	for _, repo := range repositories {
+		if !reported[repo] {
+			missing = append(missing, repo)
+		}
+	}
+	return missing
+}
+
+// vulnerabilities returns the vulnerabilities of the reports of the given
+// severities, sorted by image and ID, marking the ones accepted by images.
+// A vulnerability found in several packages of an image is listed once per
+// package.
+func vulnerabilities(reports []trivyReport, images config.Images, severities []string) []Vulnerability {
+	wanted := map[string]bool{}
+	for _, s := range severities {
+		wanted[strings.ToUpper(s)] = true
+	}
+	var vulns []Vulnerability
+	for _, report := range reports {
+		repository := imageRepository(report.ArtifactName)
+		for _, result := range report.Results {
+			for _, v := range result.Vulnerabilities {
+				if !wanted[v.Severity] {
+					continue
+				}
+				vulns = append(vulns, Vulnerability{
+					Image:            report.ArtifactName,
+					ID:               v.VulnerabilityID,
+					Package:          v.PkgName,
+					InstalledVersion: v.InstalledVersion,
+					FixedVersion:     v.FixedVersion,
+					Severity:         v.Severity,
+					Accepted:         images.Accepts(v.VulnerabilityID, repository),
+				})
+			}
+		}
+	}
+	sort.SliceStable(vulns, func(i, j int) bool {
+		if vulns[i].Image != vulns[j].Image {
+			return vulns[i].Image < vulns[j].Image
+		}
+		return vulns[i].ID < vulns[j].ID
+	})
+	return vulns
+}
@@ -0,0 +1,66 @@
+// Copyright 2021 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package images
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/cilium/release/pkg/config"
+)
+
+func TestVulnerabilities(t *testing.T) {
+	dir := t.TempDir()
+	reports := map[string]string{
+		"cilium.json": `{"ArtifactName": "quay.io/cilium/cilium:v1.14.3", "Results": [{"Vulnerabilities": [
+			{"VulnerabilityID": "CVE-2023-2", "PkgName": "openssl", "InstalledVersion": "3.0.1", "FixedVersion": "3.0.2", "Severity": "CRITICAL"},
+			{"VulnerabilityID": "CVE-2023-1", "PkgName": "curl", "InstalledVersion": "8.0.0", "Severity": "CRITICAL"},
+			{"VulnerabilityID": "CVE-2023-3", "PkgName": "zlib", "InstalledVersion": "1.2.13", "Severity": "HIGH"}
+		]}]}`,
+		"operator.json": `{"ArtifactName": "quay.io/cilium/operator:v1.14.3", "Results": [{"Vulnerabilities": [
+			{"VulnerabilityID": "CVE-2023-1", "PkgName": "curl", "InstalledVersion": "8.0.0", "Severity": "CRITICAL"}
+		]}]}`,
+	}
+	for name, content := range reports {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	parsed, err := readReports(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	images := config.Images{
+		AcceptedVulnerabilities: []config.AcceptedVulnerability{
+			{ID: "CVE-2023-1", Repositories: []string{"quay.io/cilium/operator"}, Reason: "curl isn't used"},
+		},
+	}
+	got := vulnerabilities(parsed, images, []string{"critical"})
+	want := []Vulnerability{
+		{Image: "quay.io/cilium/cilium:v1.14.3", ID: "CVE-2023-1", Package: "curl", InstalledVersion: "8.0.0", Severity: "CRITICAL"},
+		{Image: "quay.io/cilium/cilium:v1.14.3", ID: "CVE-2023-2", Package: "openssl", InstalledVersion: "3.0.1", FixedVersion: "3.0.2", Severity: "CRITICAL"},
+		{Image: "quay.io/cilium/operator:v1.14.3", ID: "CVE-2023-1", Package: "curl", InstalledVersion: "8.0.0", Severity: "CRITICAL", Accepted: true},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+
+	missing := missingReports(parsed, []string{"quay.io/cilium/cilium", "quay.io/cilium/operator", "quay.io/cilium/hubble-relay"})
+	if !reflect.DeepEqual(missing, []string{"quay.io/cilium/hubble-relay"}) {
+		t.Errorf("got missing reports %v, want quay.io/cilium/hubble-relay", missing)
+	}
+}
@@ -0,0 +1,216 @@
+// Copyright 2021 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package labels
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/template"
+
+	gh "github.com/google/go-github/v50/github"
+	flag "github.com/spf13/pflag"
+
+	"github.com/cilium/release/pkg/config"
+	"github.com/cilium/release/pkg/confirm"
+	"github.com/cilium/release/pkg/types"
+)
+
+// Command implements the 'labels' subcommand.
+func Command(ctx context.Context, ghClient *gh.Client, args []string) (err error) {
+	if len(args) == 0 || args[0] != "sync" {
+		return fmt.Errorf("usage: labels sync [flags]")
+	}
+
+	var (
+		cfgFile     string
+		repoName    string
+		branches    []string
+		confirmURL  string
+		environment string
+		dryRun      bool
+	)
+	fs := flag.NewFlagSet("labels sync", flag.ContinueOnError)
+	fs.StringVar(&cfgFile, "config", "release.yaml", "Configuration file containing the label definitions")
+	fs.StringVar(&repoName, "repo", "cilium/cilium", "GitHub organization and repository names separated by a slash")
+	fs.StringSliceVar(&branches, "branch", nil, "Additional stable branches (e.g.: '1.15') to create the branch labels for, e.g. when a new stable branch is cut")
+	confirm.AddFlags(fs, &confirmURL, &environment)
+	fs.BoolVar(&dryRun, "dry-run", false, "Only report the drift between the repository and the label definitions")
+	if err := types.ParseFlags(fs, "labels sync", args[1:]); err != nil {
+		return err
+	}
+	owner, repo, err := types.SplitRepoName(repoName)
+	if err != nil {
+		return err
+	}
+	cfg, err := config.Load(cfgFile)
+	if err != nil {
+		return fmt.Errorf("unable to load configuration: %w", err)
+	}
+
+	desired, err := expand(cfg.Labels, branches)
+	if err != nil {
+		return err
+	}
+	existing, err := listLabels(ctx, ghClient, owner, repo)
+	if err != nil {
+		return fmt.Errorf("unable to list labels: %w", err)
+	}
+	var gate *confirm.Gate
+	if !dryRun {
+		gate, err = confirm.Open(ctx, ghClient, "labels sync "+repoName, confirmURL, environment, bufio.NewReader(os.Stdin), os.Stdout)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			if rerr := gate.Report(ctx); rerr != nil && err == nil {
+				err = rerr
+			}
+		}()
+	}
+	return sync(ctx, ghClient, owner, repo, desired, existing, gate)
+}
+
+// expand returns the label definitions with the '{{ .Branch }}' templates,
+// in their name and description, rendered for each of the configured and given branches.
+func expand(cfg config.Labels, extraBranches []string) ([]config.Label, error) {
+	branches := append(append([]string{}, cfg.Branches...), extraBranches...)
+	var labels []config.Label
+	seen := map[string]bool{}
+	for _, def := range cfg.Definitions {
+		if !strings.Contains(def.Name, "{{") {
+			if !seen[def.Name] {
+				seen[def.Name] = true
+				labels = append(labels, def)
+			}
+			continue
+		}
+		for _, branch := range branches {
+			data := struct{ Branch string }{Branch: strings.TrimPrefix(branch, "v")}
+			lbl := def
+			var err error
+			lbl.Name, err = render(def.Name, data)
+			if err != nil {
+				return nil, err
+			}
+			lbl.Description, err = render(def.Description, data)
+			if err != nil {
+				return nil, err
+			}
+			if !seen[lbl.Name] {
+				seen[lbl.Name] = true
+				labels = append(labels, lbl)
+			}
+		}
+	}
+	return labels, nil
+}
+
+func render(tmpl string, data interface{}) (string, error) {
+	t, err := template.New("label").Parse(tmpl)
+	if err != nil {
+		return "", fmt.Errorf("invalid label template %q: %w", tmpl, err)
+	}
+	var buf bytes.Buffer
+	err = t.Execute(&buf, data)
+	if err != nil {
+		return "", fmt.Errorf("invalid label template %q: %w", tmpl, err)
+	}
+	return buf.String(), nil
+}
+
+func listLabels(ctx context.Context, ghClient *gh.Client, owner, repo string) (map[string]*gh.Label, error) {
+	labels := map[string]*gh.Label{}
+	opts := &gh.ListOptions{PerPage: 100}
+	for {
+		lbls, resp, err := ghClient.Issues.ListLabels(ctx, owner, repo, opts)
+		if err != nil {
+			return nil, err
+		}
+		for _, lbl := range lbls {
+			labels[lbl.GetName()] = lbl
+		}
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+	return labels, nil
+}
+
+// sync creates the missing labels and updates the ones whose color or
+// description differ from their definition. Labels that are not defined but
+// share their prefix (e.g. 'area/') with defined labels are only reported.
+// The labels are only reported, not changed, if gate is nil, e.g. with
+// --dry-run, and the changes are recorded into it otherwise.
+func sync(ctx context.Context, ghClient *gh.Client, owner, repo string, desired []config.Label, existing map[string]*gh.Label, gate *confirm.Gate) error {
+	prefixes := map[string]bool{}
+	defined := map[string]bool{}
+	for _, def := range desired {
+		defined[def.Name] = true
+		if idx := strings.Index(def.Name, "/"); idx != -1 {
+			prefixes[def.Name[:idx+1]] = true
+		}
+
+		lbl := &gh.Label{
+			Name:        gh.String(def.Name),
+			Color:       gh.String(strings.TrimPrefix(def.Color, "#")),
+			Description: gh.String(def.Description),
+		}
+		curr, ok := existing[def.Name]
+		switch {
+		case !ok:
+			fmt.Fprintf(os.Stdout, "creating label %q\n", def.Name)
+			if gate == nil {
+				continue
+			}
+			_, _, err := ghClient.Issues.CreateLabel(ctx, owner, repo, lbl)
+			if err != nil {
+				return fmt.Errorf("unable to create label %q: %w", def.Name, err)
+			}
+			gate.Executed("Created label `%s`", def.Name)
+		case !strings.EqualFold(curr.GetColor(), lbl.GetColor()) || curr.GetDescription() != lbl.GetDescription():
+			fmt.Fprintf(os.Stdout, "updating label %q: color %q -> %q, description %q -> %q\n",
+				def.Name, curr.GetColor(), lbl.GetColor(), curr.GetDescription(), lbl.GetDescription())
+			if gate == nil {
+				continue
+			}
+			_, _, err := ghClient.Issues.EditLabel(ctx, owner, repo, def.Name, lbl)
+			if err != nil {
+				return fmt.Errorf("unable to update label %q: %w", def.Name, err)
+			}
+			gate.Executed("Updated label `%s`", def.Name)
+		}
+	}
+
+	var undefined []string
+	for name := range existing {
+		if defined[name] {
+			continue
+		}
+		if idx := strings.Index(name, "/"); idx != -1 && prefixes[name[:idx+1]] {
+			undefined = append(undefined, name)
+		}
+	}
+	sort.Strings(undefined)
+	for _, name := range undefined {
+		fmt.Fprintf(os.Stdout, "label %q exists in the repository but is not defined\n", name)
+	}
+	return nil
+}
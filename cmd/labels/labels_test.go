@@ -0,0 +1,159 @@
+// Copyright 2021 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package labels
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	gh "github.com/google/go-github/v50/github"
+
+	"github.com/cilium/release/pkg/config"
+)
+
+func Test_expand(t *testing.T) {
+	cfg := config.Labels{
+		Branches: []string{"1.13", "v1.14"},
+		Definitions: []config.Label{
+			{Name: "kind/bug", Color: "d73a4a"},
+			{Name: "needs-backport/{{ .Branch }}", Color: "fbca04", Description: "Needs a backport to v{{ .Branch }}"},
+			{Name: "kind/bug", Color: "000000"},
+		},
+	}
+	tests := []struct {
+		name     string
+		branches []string
+		want     []config.Label
+	}{
+		{
+			name: "configured branches",
+			want: []config.Label{
+				{Name: "kind/bug", Color: "d73a4a"},
+				{Name: "needs-backport/1.13", Color: "fbca04", Description: "Needs a backport to v1.13"},
+				{Name: "needs-backport/1.14", Color: "fbca04", Description: "Needs a backport to v1.14"},
+			},
+		},
+		{
+			name:     "new branch",
+			branches: []string{"1.15", "1.14"},
+			want: []config.Label{
+				{Name: "kind/bug", Color: "d73a4a"},
+				{Name: "needs-backport/1.13", Color: "fbca04", Description: "Needs a backport to v1.13"},
+				{Name: "needs-backport/1.14", Color: "fbca04", Description: "Needs a backport to v1.14"},
+				{Name: "needs-backport/1.15", Color: "fbca04", Description: "Needs a backport to v1.15"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		got, err := expand(cfg, tt.branches)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: got %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+
+	cfg.Definitions = []config.Label{{Name: "needs-backport/{{ .Branch"}}
+	if _, err := expand(cfg, nil); err == nil {
+		t.Error("expected an error for an invalid template")
+	}
+}
+
+func TestCommand(t *testing.T) {
+	cfgFile := filepath.Join(t.TempDir(), "release.yaml")
+	err := os.WriteFile(cfgFile, []byte(`labels:
+  branches: ["1.14"]
+  definitions:
+    - name: kind/bug
+      color: "#d73a4a"
+      description: Something isn't working
+    - name: kind/feature
+      color: a2eeef
+    - name: needs-backport/{{ .Branch }}
+      color: fbca04
+`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name     string
+		args     []string
+		requests []string
+	}{
+		{
+			name: "sync",
+			args: []string{"--confirm", "https://github.com/cilium/cilium/issues/1"},
+			requests: []string{
+				`PATCH /repos/cilium/cilium/labels/kind/bug {"name":"kind/bug","color":"d73a4a","description":"Something isn't working"}`,
+				`POST /repos/cilium/cilium/labels {"name":"needs-backport/1.14","color":"fbca04","description":""}`,
+				`POST /repos/cilium/cilium/issues/1/comments {"body":"` + "`release labels sync cilium/cilium`" + ` executed:\n\n- Updated label ` + "`kind/bug`" + `\n- Created label ` + "`needs-backport/1.14`" + `\n"}`,
+			},
+		},
+		{
+			name: "dry run",
+			args: []string{"--dry-run"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var requests []string
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch r.Method + " " + r.URL.Path {
+				case "GET /repos/cilium/cilium/labels":
+					// The color of kind/bug drifted, kind/foo is only reported and
+					// area/foo is left alone as no area/ label is defined.
+					fmt.Fprint(w, `[
+						{"name": "kind/bug", "color": "ff0000", "description": "Something isn't working"},
+						{"name": "kind/feature", "color": "A2EEEF"},
+						{"name": "kind/foo", "color": "ffffff"},
+						{"name": "area/foo", "color": "ffffff"}
+					]`)
+				case "GET /repos/cilium/cilium/issues/1":
+					fmt.Fprint(w, `{"number": 1, "state": "open"}`)
+				default:
+					if r.Method == http.MethodGet {
+						t.Errorf("unexpected request %s %s", r.Method, r.URL)
+						http.NotFound(w, r)
+						return
+					}
+					body, _ := io.ReadAll(r.Body)
+					requests = append(requests, r.Method+" "+r.URL.Path+" "+strings.TrimSpace(string(body)))
+					fmt.Fprint(w, `{}`)
+				}
+			}))
+			defer srv.Close()
+			ghClient := gh.NewClient(nil)
+			ghClient.BaseURL, _ = url.Parse(srv.URL + "/")
+
+			args := append([]string{"sync", "--config", cfgFile}, tt.args...)
+			if err := Command(context.Background(), ghClient, args); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(requests, tt.requests) {
+				t.Errorf("got requests:\n%s\nwant:\n%s", strings.Join(requests, "\n"), strings.Join(tt.requests, "\n"))
+			}
+		})
+	}
+}
@@ -15,91 +15,175 @@
 package main
 
 import (
+	"bufio"
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"os/signal"
-	"sort"
+	"strconv"
 	"strings"
+	"time"
 
 	gh "github.com/google/go-github/v50/github"
 	flag "github.com/spf13/pflag"
+	"go.opentelemetry.io/otel/trace"
 
+	"github.com/cilium/release/cmd/abort"
+	"github.com/cilium/release/cmd/backport"
+	"github.com/cilium/release/cmd/changelog"
+	"github.com/cilium/release/cmd/check"
+	"github.com/cilium/release/cmd/dashboard"
+	"github.com/cilium/release/cmd/downstream"
+	"github.com/cilium/release/cmd/export"
+	"github.com/cilium/release/cmd/images"
+	"github.com/cilium/release/cmd/labels"
 	"github.com/cilium/release/cmd/projects"
+	"github.com/cilium/release/cmd/schedule"
+	"github.com/cilium/release/cmd/serve"
+	"github.com/cilium/release/cmd/state"
+	"github.com/cilium/release/cmd/verify"
+	"github.com/cilium/release/pkg/artifact"
+	"github.com/cilium/release/pkg/config"
+	"github.com/cilium/release/pkg/confirm"
+	"github.com/cilium/release/pkg/git"
 	"github.com/cilium/release/pkg/github"
-	"github.com/cilium/release/pkg/persistence"
+	"github.com/cilium/release/pkg/journal"
+	"github.com/cilium/release/pkg/profile"
+	"github.com/cilium/release/pkg/report"
+	"github.com/cilium/release/pkg/tracing"
 	"github.com/cilium/release/pkg/types"
+	"github.com/cilium/release/pkg/usage"
 )
 
-var releaseNotes = map[string]string{
-	"release-note/major": "**Major Changes:**",
-	"release-note/minor": "**Minor Changes:**",
-	"release-note/bug":   "**Bugfixes:**",
-	"release-note/ci":    "**CI Changes:**",
-	"release-note/misc":  "**Misc Changes:**",
-	"release-note/none":  "**Other Changes:**",
-}
-
-var releaseNotesOrder = []string{
-	"release-note/major",
-	"release-note/minor",
-	"release-note/bug",
-	"release-note/ci",
-	"release-note/misc",
-	"release-note/none",
-}
+var cfg types.Config
 
-var (
-	base       string
-	head       string
-	lastStable string
-	stateFile  string
-	repoName   string
-	currVer    string
-	nextVer    string
-
-	// forceMovePending lets "pending" backports be moved from one project
-	// to another. By default this is set to false, since most commonly
-	// this is a mistake and the PR should have been previously marked as
-	// "backport-done".
-	forceMovePending bool
-)
+// configProfile is the profile of cfg.ConfigFile selected with --profile, if
+// any, see useProfile.
+var configProfile string
 
 func init() {
-	flag.StringVar(&currVer, "current-version", "", "Current version - the one being released")
-	flag.StringVar(&nextVer, "next-dev-version", "", "Next version - the next development cycle")
-	flag.StringVar(&base, "base", "", "Base commit / tag used to generate release notes")
-	flag.StringVar(&head, "head", "", "Head commit used to generate release notes")
-	flag.StringVar(&lastStable, "last-stable", "", "When last stable version is set, it will be used to detect if a bug was already backported or not to that particular branch (e.g.: '1.5', '1.6')")
-	flag.StringVar(&stateFile, "state-file", "release-state.json", "When set, it will use the already fetched information from a previous run")
-	flag.StringVar(&repoName, "repo", "cilium/cilium", "GitHub organization and repository names separated by a slash")
-	flag.BoolVar(&forceMovePending, "force-move-pending-backports", false, "Force move pending backports to the next version's project")
-	flag.Parse()
-
-	if len(base) == 0 && len(currVer) == 0 {
-		fmt.Fprintf(os.Stderr, "--base can't be empty\n")
-		flag.Usage()
-		os.Exit(-1)
-
-	}
-	if len(head) == 0 && len(currVer) == 0 {
-		fmt.Fprintf(os.Stderr, "--head can't be empty\n")
-		flag.Usage()
-		os.Exit(-1)
-	}
-	if len(stateFile) == 0 {
-		fmt.Fprintf(os.Stderr, "--state-file can't be empty\n")
-		flag.Usage()
-		os.Exit(-1)
-	}
-	if strings.Contains(lastStable, "v") {
-		fmt.Fprintf(os.Stderr, "--last-stable can't contain letters, should be of the format 'x.y'\n")
-		flag.Usage()
-		os.Exit(-1)
-	}
+	flag.StringVar(&cfg.CurrVer, "current-version", "", "Current version - the one being released")
+	flag.StringVar(&cfg.NextVer, "next-dev-version", "", "Next version - the next development cycle")
+	flag.StringVar(&cfg.Base, "base", "", "Base commit / tag used to generate release notes")
+	flag.StringVar(&cfg.Head, "head", "", "Head commit used to generate release notes")
+	flag.StringVar(&cfg.SinceVersion, "since-version", "", "When set to a released version (e.g.: '1.14.2'), its tag is used as --base and its branch, e.g. 'v1.14', as --head if not set")
+	flag.StringSliceVar(&cfg.LastStable, "last-stable", nil, "When last stable versions are set, they will be used to detect if a bug was already backported or not to those particular branches (e.g.: '1.5', '1.6', or '<=1.6' for 1.6 and all the earlier ones). Can be repeated or comma-separated")
+	flag.StringVar(&cfg.StateFile, "state-file", "release-state.json", "When set, it will use the already fetched information from a previous run")
+	flag.BoolVar(&cfg.AllowTruncated, "allow-truncated", false, "Generate partial release notes, only warning about it, when GitHub doesn't list all the commits between --base and --head, instead of failing")
+	flag.StringVar(&cfg.ShasFile, "shas-file", "", "File of the commits of the release, one SHA per line from head to base, e.g. from 'git rev-list <base>..<head>'. If it exists, its commits are used instead of the ones of --base and --head, which they must be part of, and it is written with the compared commits otherwise")
+	flag.StringVar(&cfg.CacheDir, "cache-dir", "", "Directory of the PR metadata cache shared across runs, releases and branches, e.g. ~/.cache/cilium-release. The cached PRs updated since the previous run are dropped. Disabled if empty")
+	flag.StringVar(&cfg.RepoName, "repo", "cilium/cilium", "GitHub organization and repository names separated by a slash, or URL of the repository")
+	// The token file is extracted from the arguments of any command before
+	// they are parsed, it's only declared here to be listed in the usage.
+	flag.String(github.TokenFileFlag, "", "File the GitHub token is read from, or '-' for stdin, the confirmations of the mutating steps being then typed on the terminal, instead of the GITHUB_TOKEN or GH_TOKEN environment variables. Can be given to any command")
+	flag.Float64(github.RateLimitFlag, github.DefaultRateLimit, "Maximum number of requests per second sent to GitHub, shared by all the parallel operations of the run, 0 disabling the limit. Can be given to any command")
+	flag.String(journal.Flag, "", "When set, every write made to the GitHub API, e.g. a label change or a release edit, is appended to this journal file (e.g. ~/.config/cilium-release/journal.jsonl) with the command line, the request and the response. Can be given to any command")
+	flag.String(report.Flag, "", "When set, a JSON report of the run, i.e. its arguments, the duration of its phases, its warnings, its API usage and the files it wrote, is written into this file at its end. Can be given to any command")
+	flag.StringVar(&cfg.Remote, "repo-from-remote", "", "Derive --repo, if not set, from this git remote of the current clone, 'origin' if given without a value")
+	flag.Lookup("repo-from-remote").NoOptDefVal = "origin"
+	flag.StringVar(&cfg.UpstreamRepoName, "upstream-repo", "", "GitHub organization and repository names, separated by a slash, of the upstream PRs referenced by backport PRs, if different from --repo")
+	flag.BoolVar(&cfg.ForceMovePending, "force-move-pending-backports", false, "Force move pending backports to the next version's project")
+	flag.IntSliceVar(&cfg.MovePending, "move-pending", nil, "Pending backports (PR numbers) to move to the next version's project, other pending backports are left in the current project")
+	flag.BoolVar(&cfg.InteractiveMovePending, "interactive-move-pending", false, "Ask for each pending backport whether it should be moved to the next version's project")
+	flag.BoolVar(&cfg.ProjectsV2, "projects-v2", false, "Manage the backport projects as GitHub ProjectsV2 instead of classic projects")
+	flag.StringVar(&cfg.MergePrereleases, "merge-prereleases", "", "When set to a final version (e.g.: '1.14.0'), the notes of all its published pre-releases are merged into the generated notes")
+	flag.StringVar(&cfg.SinceLatestRelease, "since-latest-release", "", "When set to a branch (e.g.: '1.14'), the tag of the most recent published release of that branch is used as --base")
+	flag.StringVar(&cfg.ExcludePublished, "exclude-published", "", "When set to a branch (e.g.: '1.14'), the PRs mentioned in the published releases of that branch are excluded from the generated notes")
+	flag.BoolVar(&cfg.MergeDuplicates, "merge-duplicates", false, "Merge the entries of a section with the exact same release note, e.g. of split backports or stacked PRs, into one entry listing all their PRs")
+	flag.BoolVar(&cfg.FoldReverts, "fold-reverts", false, "Leave the PRs reverted by another PR of the release notes, and their reverts, out of the sections and list them, along with the PRs landing them again, in a Reverted Changes section at the bottom of the release notes")
+	flag.BoolVar(&cfg.DependencyChanges, "dependency-changes", false, "Add a Dependency Changes section listing the modules added, removed or updated in the go.mod of the repository between --base and --head")
+	flag.StringVar(&cfg.KnownIssues, "known-issues", "", "When set to a branch (e.g.: '1.14'), the open issues labeled known-issue/v1.14 are listed in a Known Issues section at the bottom of the release notes")
+	flag.StringVar(&cfg.RequireBackports, "require-backports", "", "When set to a branch (e.g.: '1.14'), fail, unless --force is set, if any merged PR labeled as needing a backport to that branch, or with a pending backport, e.g. needs-backport/1.14, is not part of the release notes")
+	flag.StringVar(&cfg.LateMerges, "late-merges", "", "When set to a branch (e.g.: '1.14'), warn about the PRs merged into that branch after --head, telling the --head including them, and the PRs labeled as needing a backport to it, e.g. needs-backport/1.14")
+	flag.StringVar(&cfg.StreamFile, "stream-file", "", "When set, the changelog entries are written into this file as soon as their PRs are resolved")
+	flag.StringVar(&cfg.AuthorsFile, "authors-file", "", "YAML file mapping the GitHub login of the PR authors to their display name, or disabling their @-mention, in the release notes")
+	flag.StringVar(&cfg.SecurityFork, "security-fork", "", "Private fork, separated by a slash, e.g. of an embargoed security release, in which the commits are compared and the PRs resolved instead of --repo. Its PRs are referenced with the name of the fork until disclosed, see --disclosures")
+	flag.StringVar(&cfg.DisclosuresFile, "disclosures", "", "YAML file mapping the PR numbers of --security-fork to the numbers of the public PRs of --repo they were disclosed as")
+	flag.StringVar(&cfg.OverridesFile, "overrides", "", "YAML file mapping PR numbers to the corrections of their entries: the category, i.e. the label of a section, they are forced into, their note, their author, or whether they are dropped")
+	flag.StringVar(&cfg.CommunityMarker, "community-marker", "", "When set (e.g.: ':star:'), it is shown before the release notes of the PRs authored by someone that isn't a member or collaborator of the repository")
+	flag.BoolVar(&cfg.ThankNewContributors, "thank-new-contributors", false, "Add a line thanking the authors whose first merged PR is part of the release notes")
+	flag.BoolVar(&cfg.AnnotateRisk, "annotate-risk", false, "Annotate the entries with the size of their PR and a risk hint computed from the files it changes, the critical paths being the backports ones of --config")
+	flag.BoolVar(&cfg.ComponentsSummary, "components-summary", false, "Summarize the components, e.g. datapath or operator, touched by the changes from the files changed by their PR, the components being the ones of --config if any")
+	flag.BoolVar(&cfg.CreditReviewers, "credit-reviewers", false, "Credit the users who approved each PR alongside its author in the release notes")
+	flag.StringVar(&cfg.TemplateDir, "template-dir", "", "Directory of templates, e.g. notes.md.tmpl, section.md.tmpl or entry.md.tmpl, overriding the default ones the release notes are rendered with in the markdown format")
+	flag.StringVar(&cfg.Format, "format", changelog.FormatMarkdown, fmt.Sprintf("Format of the release notes: %q, %q, a short paragraph with the top entries, %q, the format of keepachangelog.com, %q, one row per entry, or %q, the JSON format of relnotes.k8s.io", changelog.FormatMarkdown, changelog.FormatSummary, changelog.FormatKeepAChangelog, changelog.FormatCSV, changelog.FormatRelnotes))
+	flag.IntVar(&cfg.Top, "top", 5, "Number of entries listed in the summary format")
+	flag.StringSliceVar(&cfg.PriorityLabels, "priority-labels", nil, "Labels, by decreasing priority, of the entries listed first in the summary format")
+	flag.BoolVar(&cfg.RankByReactions, "rank-by-reactions", false, "Rank the entries listed in the summary format, after --priority-labels, by the number of 👍 and 🎉 reactions of their PRs")
+	flag.IntVar(&cfg.MaxSize, "max-size", 0, "When set, the Other and Misc sections are collapsed into their number of changes if the release notes exceed this size in bytes (GitHub limits release notes to 125000 characters)")
+	flag.StringVar(&cfg.FullList, "full-list", "CHANGELOG.md", "Where the full list of changes can be found when sections are collapsed by --max-size")
+	changelog.AddSkipFlags(flag.CommandLine, &cfg)
+	flag.StringVar(&cfg.ExcludedFile, "excluded-file", "", "When set, the PRs left out of the release notes as they were backported to the --last-stable branches are written as Markdown into this file instead of being listed in stderr")
+	flag.StringVar(&cfg.ExcludedReport, "excluded-report", "", "When set, the PRs left out of the release notes as they were backported to the --last-stable branches are written as JSON into this file")
+	flag.BoolVar(&cfg.PreviouslyReleased, "previously-released", false, "Append the PRs left out of the release notes as they were backported to the --last-stable branches as a 'Previously Released' appendix")
+	flag.StringVar(&cfg.UpgradeNotesFile, "upgrade-notes-file", "", "When set, the upgrade notes of the PRs labeled upgrade-impact, given in an upgrade-notes block of their description, are consolidated into this file")
+	flag.StringVar(&cfg.CIChangesFile, "ci-changes-file", "", "When set with --skip-ci-changes, the CI changes are written into this file")
+	flag.StringVarP(&cfg.Output, "output", "o", "", "Write the release notes into this file instead of stdout")
+	flag.StringSliceVar(&cfg.Sinks, "sink", nil, fmt.Sprintf("Publish the release notes into this destination, instead of stdout, in addition to --output: %q, %q, %q, setting the body of the release of the tag, draft or not, %q, uploading a secret gist, or a new revision of the given one, whose URL is printed, or %q, posting a comment. Can be repeated or comma-separated", types.SinkStdout, types.SinkFile+":<path>", types.SinkRelease+":<tag>", types.SinkGist+"[:<id>]", types.SinkIssue+":<number>"))
+	confirm.AddFlags(flag.CommandLine, &cfg.Confirm, &cfg.Environment)
+	flag.StringVar(&cfg.Profile, "profile", profile.Default, fmt.Sprintf("Label scheme of the repository defining the sections of the release notes and the backport labels, one of %s, or profile of --config giving the repository, its label scheme and its schedule", strings.Join(profile.Names(), ", ")))
+	flag.BoolVar(&cfg.StrictLabels, "strict-labels", false, "Fail, instead of warning, if any PR has several release note labels, e.g. both release-note/bug and release-note/minor")
+	flag.BoolVar(&cfg.ConventionalCommits, "conventional-commits", false, "Categorize the PRs without any release note label from the Conventional Commit prefix of their title, e.g. 'feat:' or 'fix:'")
+	flag.StringVar(&cfg.Mode, "mode", changelog.ModePRs, fmt.Sprintf("How the release notes are built: %q, from the PRs of the commits, %q, from the commit subjects only, without resolving any PR, for quick previews, or %q, from the PRs merged into --search-branch between the dates of --base and --head, found with the search API, much cheaper for branches with a linear history", changelog.ModePRs, changelog.ModeCommits, changelog.ModeSearch))
+	flag.StringVar(&cfg.SearchBranch, "search-branch", "", fmt.Sprintf("Branch the PRs are merged into with --mode=%s or --cross-check-search. Defaults to --head", changelog.ModeSearch))
+	flag.BoolVar(&cfg.CrossCheckSearch, "cross-check-search", false, "Compare the PRs found from the commits with the ones merged into --search-branch between the dates of --base and --head, found with the search API")
+	flag.StringVar(&cfg.GroupBy, "group-by", changelog.GroupByType, fmt.Sprintf("How the entries are grouped into sections: %q, by type of change, or %q, by the group owning them as mapped from their labels by the groups of --config", changelog.GroupByType, changelog.GroupByOwner))
+	flag.StringVar(&cfg.NoPRCommits, "no-pr-commits", "", fmt.Sprintf("What to do with the commits without any PR: %q leaves them out, %q lists them under Other Changes with their subject and %q fails the run. Defaults to %q, or %q with --mode=%s", changelog.NoPRCommitsDrop, changelog.NoPRCommitsRender, changelog.NoPRCommitsFail, changelog.NoPRCommitsDrop, changelog.NoPRCommitsRender, changelog.ModeCommits))
+	flag.StringVar(&cfg.CrossCheckGitHub, "cross-check-github", "", fmt.Sprintf("Compare the PRs found with the release notes generated by GitHub for the same range: %q reports the differences and %q also adds the PRs only found by GitHub. --base must be a tag", changelog.CrossCheckDiff, changelog.CrossCheckMerge))
+	flag.StringVar(&cfg.ConfigFile, "config", "", "Configuration file whose schedule gives the end of life dates of the branches, releasing a branch past its end of life is refused, whose docs links the entries and sections of the release notes to the documentation, and whose groups are the sections of --group-by=owner")
+	flag.BoolVar(&cfg.Force, "force", false, "Release, or move the backports of, a branch past its end of life, and only warn about the missing backports of --require-backports")
+	flag.StringSliceVar(&cfg.Branches, "branches", nil, "Generate in parallel the release notes of each of these branches (e.g.: '1.13,1.14') since their latest release, writing them into --output and --state-file suffixed with the branch")
+	flag.StringVar(&cfg.ChecksumsFile, "checksums-file", "", "When set with --output, the SHA256 checksum of the release notes is added into this file, e.g. 'SHA256SUMS'")
+	flag.StringVar(&cfg.ProvenanceFile, "provenance", "", "When set with --output, the SLSA provenance of the release notes, and of the other files written along with them, is written into this file, e.g. 'release-notes.intoto.json'. It's signed with --sign and uploaded as an asset of the release of --sink=release:<tag>")
+	flag.StringSliceVar(&cfg.ProvenanceArtifacts, "provenance-artifact", nil, "File of the release, e.g. a binary, also covered by --provenance. Can be repeated or comma-separated")
+	flag.StringVar(&cfg.Sign, "sign", "", fmt.Sprintf("When set with --output, a detached signature of the release notes is created with %q, using the default key, or %q, keyless", artifact.SignGPG, artifact.SignCosign))
+	flag.BoolVar(&cfg.GitHubActions, "github-actions", false, "Write the release notes into the GitHub Actions step summary, set the step outputs (changes, prs, backport-prs, version, changelog-path) and annotate warnings")
+	flag.IntVar(&cfg.PreviewPR, "preview-pr", 0, "Post, or update, the release notes as a comment of this release preparation PR. --head defaults to the head of the PR and --base to the latest release of the branch targeted by the PR")
+	flag.StringVar(&cfg.TrackingIssue, "tracking-issue", "", "Post, or update, the release notes as a comment of the tracking issue of the release with this URL, e.g. 'https://github.com/cilium/cilium/issues/28000', so that each regeneration is a revision of the same comment")
+	flag.BoolVar(&cfg.NormalizeNotes, "normalize-notes", false, "Copy-edit the release notes for a consistent style: collapse whitespace, remove 'This PR' prefixes, wrapping backticks and trailing periods, and capitalize the first letter")
+	flag.BoolVar(&cfg.NaturalSort, "natural-sort", false, "Sort the release notes entries comparing numbers by their value, e.g. 'v2' before 'v10'")
+	flag.BoolVar(&cfg.FrontMatter, "front-matter", false, "Precede the release notes with a YAML front matter so that they can be published by a Hugo or Docusaurus docs site")
+	flag.StringVar(&cfg.FrontMatterTitle, "front-matter-title", "", "Title of the front matter, defaults to the version released")
+	flag.StringVar(&cfg.FrontMatterDate, "front-matter-date", "", "Date of the front matter, defaults to today")
+	flag.StringVar(&cfg.FrontMatterVersion, "front-matter-version", "", "Version of the front matter, defaults to the version of --head if it is a version tag")
+	flag.StringSliceVar(&cfg.FrontMatterAliases, "front-matter-aliases", nil, "Aliases, i.e. redirected paths, of the front matter. Can be repeated or comma-separated")
+	flag.BoolVar(&cfg.WaitForReset, "wait-for-reset", false, "Wait for the API rate limit to be reset, instead of failing, if the remaining rate limit isn't enough to fetch the PRs")
+	flag.StringVar(&cfg.OrphansReport, "orphans-report", "", "When set, the commits without any merged PR, the PRs without any release note label and the backport PRs referencing an upstream PR that doesn't exist are written as JSON into this file, grouped by reason. Such backport PRs are then reported instead of failing the run")
+	flag.StringVar(&cfg.UsageReport, "usage-report", "", "When set, the report of the API calls made and of the time spent is also written as JSON into this file")
+	flag.BoolVar(&cfg.Watch, "watch", false, "Keep running once the release notes are written and regenerate them whenever --head gains new commits, resolving only the PRs of the new commits")
+	flag.DurationVar(&cfg.WatchInterval, "watch-interval", time.Minute, "Interval at which --head is checked for new commits with --watch")
+	flag.StringVar(&cfg.StreamFormat, "stream-format", changelog.StreamFormatJSONLines, fmt.Sprintf("Format of --stream-file: %q or %q", changelog.StreamFormatJSONLines, changelog.StreamFormatMarkdown))
 	go signals()
 }
 
+// commands are the subcommands of the release tool. When no subcommand is
+// given, the release notes are generated.
+var commands = map[string]func(ctx context.Context, ghClient *gh.Client, args []string) error{
+	"abort":       abort.Command,
+	"backport":    backport.Command,
+	"check":       check.Command,
+	"cumulative":  changelog.CumulativeCommand,
+	"dashboard":   dashboard.Command,
+	"diff":        changelog.DiffCommand,
+	"downstream":  downstream.Command,
+	"export":      export.Command,
+	"images":      images.Command,
+	"labels":      labels.Command,
+	"milestone":   changelog.MilestoneCommand,
+	"prefetch":    changelog.PrefetchCommand,
+	"preview":     changelog.PreviewCommand,
+	"projects":    projects.Command,
+	"schedule":    schedule.Command,
+	"serve":       serve.Command,
+	"site":        changelog.SiteCommand,
+	"state":       state.Command,
+	"stats":       changelog.StatsCommand,
+	"tag-message": changelog.TagMessageCommand,
+	"unreleased":  changelog.UnreleasedCommand,
+	"verify":      verify.Command,
+}
+
 var globalCtx, cancel = context.WithCancel(context.Background())
 
 func signals() {
@@ -109,205 +193,347 @@ func signals() {
 	cancel()
 }
 
-func main() {
-	ghClient := github.NewClient(os.Getenv("GITHUB_TOKEN"))
-
-	var (
-		backportPRs = types.BackportPRs{}
-		listOfPRs   = types.PullRequests{}
-		shas        []string
-	)
-	ownerRepo := strings.Split(repoName, "/")
-	if len(ownerRepo) != 2 {
-		fmt.Fprintf(os.Stderr, "Invalid repo name: %s\n", repoName)
-		os.Exit(-1)
-	}
-	owner := ownerRepo[0]
-	repo := ownerRepo[1]
+// stdin is the input of the run from which both the confirmations of the
+// mutating steps and the answers of --interactive-move-pending are read.
+var stdin *bufio.Reader
 
-	if len(currVer) != 0 {
-		pm := projects.NewProjectManagement(ghClient, owner, repo)
-		err := pm.SyncProjects(globalCtx, currVer, nextVer, forceMovePending)
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "Unable to manage project: %s\n", err)
-			os.Exit(-1)
+// endTracing ends the span of the run and flushes the spans recorded.
+var endTracing = func() {}
+
+// endReport writes the report of the run, which ended with the given exit
+// code, if requested.
+var endReport = func(code int) {}
+
+// exit writes the report and ends the tracing of the run, and exits with the
+// given code.
+func exit(code int) {
+	endReport(code)
+	endTracing()
+	os.Exit(code)
+}
+
+// startReport records the run of the given command, whose report is written
+// into file by endReport.
+func startReport(file, command string, args []string, tracker *usage.Tracker) {
+	rec, err := report.Start(command, args, tracker)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "WARNING: unable to record the run: %s\n", err)
+		return
+	}
+	endReport = func(code int) {
+		endReport = func(int) {}
+		if err := rec.Stop(code).WriteFile(file); err != nil {
+			fmt.Fprintf(os.Stderr, "Unable to write run report: %s\n", err)
 		}
+	}
+}
+
+// startTracing records the run as a span with the given name, exported if
+// an OTLP endpoint is configured.
+func startTracing(name string) {
+	shutdown, err := tracing.Setup(globalCtx)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "WARNING: unable to set up tracing: %s\n", err)
 		return
 	}
+	var span trace.Span
+	globalCtx, span = tracing.Start(globalCtx, name)
+	endTracing = func() {
+		span.End()
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if err := shutdown(ctx); err != nil {
+			fmt.Fprintf(os.Stderr, "WARNING: unable to export traces: %s\n", err)
+		}
+	}
+}
 
-	if _, err := os.Stat(stateFile); err == nil {
-		fmt.Fprintf(os.Stderr, "Found state file, resuming from stored state\n")
-		var err error
-		backportPRs, listOfPRs, shas, err = persistence.LoadState(stateFile)
+func main() {
+	tracker := usage.New()
+	tokenFile, args := extractFlag(os.Args[1:], github.TokenFileFlag)
+	reportFile, args := extractFlag(args, report.Flag)
+	rateLimit, args := extractFlag(args, github.RateLimitFlag)
+	journalFile, args := extractFlag(args, journal.Flag)
+	os.Args = append(os.Args[:1], args...)
+	// The extracted flags are given to any command, so they are set from
+	// the environment variables of the release notes flags.
+	for _, f := range []struct {
+		name  string
+		value *string
+	}{
+		{github.TokenFileFlag, &tokenFile},
+		{report.Flag, &reportFile},
+		{github.RateLimitFlag, &rateLimit},
+		{journal.Flag, &journalFile},
+	} {
+		if env, ok := os.LookupEnv(types.EnvName(f.name)); ok && len(*f.value) == 0 {
+			*f.value = env
+		}
+	}
+	token, err := github.Token(tokenFile, os.Stdin, os.Getenv)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s\n", err)
+		os.Exit(-1)
+	}
+	if tokenFile == "-" {
+		os.Stdin = terminalInput()
+	}
+	stdin = bufio.NewReader(os.Stdin)
+	if len(rateLimit) != 0 {
+		rate, err := strconv.ParseFloat(rateLimit, 64)
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "Unable to read persistence file: %s", err)
+			fmt.Fprintf(os.Stderr, "--%s should be a number of requests per second: %s\n", github.RateLimitFlag, err)
 			os.Exit(-1)
-			return
 		}
-	} else {
-		cont := false
-		prevHead := ""
+		github.SetRateLimit(rate)
+	}
+	ghClient := github.NewClient(token, tracker, journal.New(journalFile, strings.Join(os.Args, " ")))
 
-		for {
-			fmt.Fprintf(os.Stderr, "Comparing %s...%s\n", base, head)
-			cc, _, err := ghClient.Repositories.CompareCommits(globalCtx, owner, repo, base, head, &gh.ListOptions{})
-			if err != nil {
-				fmt.Fprintf(os.Stderr, "Unable to compare commits %s %s: %s\n", base, head, err)
-				os.Exit(-1)
-				return
-			}
-			if prevHead == cc.Commits[len(cc.Commits)-1].GetSHA() {
-				sha := cc.Commits[0].GetSHA()
-				if sha != "" {
-					shas = append(shas, sha)
-				}
-				break
+	if len(os.Args) > 1 {
+		if command, ok := commands[os.Args[1]]; ok {
+			if len(reportFile) != 0 {
+				startReport(reportFile, os.Args[1], os.Args[2:], tracker)
 			}
-			start := len(cc.Commits) - 1
-			if cont {
-				// We want to ignore the last sha for if the number of commits
-				// returned by github are throttled. If they are throttled
-				// we will keep comparing commits until the last commit
-				// points to the base commit.
-				start = start - 1
+			startTracing(os.Args[1])
+			defer endTracing()
+			defer endReport(0)
+			err := command(globalCtx, ghClient, os.Args[2:])
+			if errors.Is(err, flag.ErrHelp) {
+				return
 			}
-			// List of commits are ordered from base to head
-			// so we want to order them from head to base
-			// For example, assuming commit SHAs are integers:
-			// compare 1...10 will return [6,7,8,9,10]
-			// We will store [10,9,8,7,6] and ask for compare 1...6
-			// This will return [6,5,4,3,2,1] which we will ignore 6
-			// since it's already stored in the list of SHAs and continue
-			for i := start; i != 0; i-- {
-				sha := cc.Commits[i].GetSHA()
-				if sha != "" {
-					shas = append(shas, sha)
-				}
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "%s: %s\n", os.Args[1], err)
+				printHint(err)
+				exit(-1)
 			}
-			head = shas[len(shas)-1]
-			cont = true
-			prevHead = cc.Commits[len(cc.Commits)-1].GetSHA()
+			return
+		}
+	}
+
+	if len(reportFile) != 0 {
+		startReport(reportFile, "release-notes", os.Args[1:], tracker)
+	}
+	startTracing("release-notes")
+	defer endTracing()
+	defer endReport(0)
+
+	flag.Parse()
+	if err := types.SetFromEnv(flag.CommandLine, os.LookupEnv); err != nil {
+		fmt.Fprintf(os.Stderr, "%s\n", err)
+		exit(-1)
+	}
+	if err := useProfile(); err != nil {
+		fmt.Fprintf(os.Stderr, "%s\n", err)
+		exit(-1)
+	}
+	if len(cfg.Remote) != 0 && !flag.CommandLine.Changed("repo") {
+		repoName, err := git.RemoteRepoName("", cfg.Remote)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Unable to derive the repository from remote %s: %s\n", cfg.Remote, err)
+			exit(-1)
 		}
+		fmt.Fprintf(os.Stderr, "Using repository %s of remote %s\n", repoName, cfg.Remote)
+		cfg.RepoName = repoName
+	}
+	if err := cfg.Sanitize(); err != nil {
+		fmt.Fprintf(os.Stderr, "%s\n", err)
+		flag.Usage()
+		exit(-1)
 	}
 
-	fmt.Fprintf(os.Stderr, "Found %d commits!\n", len(shas))
+	if err := checkEOL(); err != nil {
+		fmt.Fprintf(os.Stderr, "%s\n", err)
+		exit(-1)
+	}
 
-	printer := func(msg string) {
-		fmt.Fprintf(os.Stderr, msg)
+	if len(cfg.CurrVer) != 0 {
+		policy := &projects.MovePendingPolicy{
+			Force:       cfg.ForceMovePending,
+			PRs:         cfg.MovePending,
+			Interactive: cfg.InteractiveMovePending,
+			In:          stdin,
+		}
+		gate, err := openGate(ghClient, "projects sync v"+cfg.CurrVer)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s\n", err)
+			exit(-1)
+		}
+		// The profile was validated by cfg.Sanitize.
+		scheme, _ := profile.Get(cfg.Profile)
+		if cfg.ProjectsV2 {
+			pm := projects.NewProjectManagementV2(ghClient, cfg.Owner, cfg.Repo)
+			err = pm.SyncProjects(globalCtx, scheme, cfg.CurrVer, cfg.NextVer, policy)
+		} else {
+			pm := projects.NewProjectManagement(ghClient, cfg.Owner, cfg.Repo)
+			err = pm.SyncProjects(globalCtx, scheme, cfg.CurrVer, cfg.NextVer, policy)
+		}
+		if err == nil {
+			gate.Executed("Synced the projects of v%s and v%s", cfg.CurrVer, cfg.NextVer)
+		}
+		if rerr := gate.Report(globalCtx); rerr != nil {
+			fmt.Fprintf(os.Stderr, "%s\n", rerr)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Unable to manage project: %s\n", err)
+			printHint(err)
+			exit(-1)
+		}
+		return
 	}
 
-	prsWithUpstream, listOfPrs, leftShas, err := github.GeneratePatchRelease(globalCtx, ghClient, owner, repo, printer, backportPRs, listOfPRs, shas)
-	fmt.Println()
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Unable to retrieve PRs for commits: %s\n", err)
-		fmt.Fprintf(os.Stderr, "Storing state in %s before existing!\n", stateFile)
+	var gate *confirm.Gate
+	for _, s := range cfg.Sinks {
+		// The sinks were validated by Sanitize.
+		if sk, _ := types.ParseSink(s); sk.Kind == types.SinkRelease {
+			var err error
+			gate, err = openGate(ghClient, "publish the release notes into "+s)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "%s\n", err)
+				exit(-1)
+			}
+			break
+		}
 	}
-	err2 := persistence.StoreState(stateFile, prsWithUpstream, listOfPrs, leftShas)
-	if err2 == nil {
-		fmt.Fprintf(os.Stderr, "State stored successful in %s, please use --state-file=%s in the next run to continue\n", stateFile, stateFile)
-	} else {
-		fmt.Fprintf(os.Stderr, "Unable to store state: %s\n", err2)
+
+	if len(cfg.Branches) != 0 {
+		generateBranches(ghClient, tracker)
+		return
 	}
+
+	cl, err := changelog.GenerateReleaseNotes(globalCtx, ghClient, cfg, tracker)
 	if err != nil {
-		os.Exit(-1)
-		return
+		printUsage(tracker)
+		fmt.Fprintf(os.Stderr, "Unable to generate release notes: %s\n", err)
+		printHint(err)
+		exit(-1)
 	}
+	cl.Gate = gate
 
-	fmt.Fprintf(os.Stderr, "\nFound %d PRs and %d backport PRs!\n\n", len(listOfPrs), len(prsWithUpstream))
+	ctx, endPhase := tracing.Phase(globalCtx, tracker, "rendering")
+	err = cl.PrintReleaseNotes(ctx)
+	endPhase()
+	if err != nil {
+		printUsage(tracker)
+		fmt.Fprintf(os.Stderr, "%s\n", err)
+		printHint(err)
+		exit(-1)
+	}
 
-	printReleaseNotes(prsWithUpstream, listOfPrs)
+	if cfg.Watch {
+		changelog.Watch(globalCtx, ghClient, cfg, tracker, cl)
+	}
 
+	printUsage(tracker)
 }
 
-func printReleaseNotes(prsWithUpstream types.BackportPRs, listOfPrs types.PullRequests) {
-	fmt.Println("Summary of Changes")
-	fmt.Println("------------------")
-
-	for _, releaseLabel := range releaseNotesOrder {
-		var changelogItems []string
-		printedReleaseNoteHeader := false
-		for backportPR, listOfPrs := range prsWithUpstream {
-			for prID, pr := range listOfPrs {
-				if pr.ReleaseLabel != releaseLabel {
-					continue
-				}
-				if !printedReleaseNoteHeader {
-					fmt.Println()
-					fmt.Println(releaseNotes[releaseLabel])
-					printedReleaseNoteHeader = true
-				}
-
-				changelogItems = append(
-					changelogItems,
-					fmt.Sprintf("* %s (Backport PR #%d, Upstream PR #%d, @%s)",
-						pr.ReleaseNote, backportPR, prID, pr.AuthorName),
-				)
-				delete(listOfPrs, prID)
-			}
-		}
-		for prID, pr := range listOfPrs {
-			if pr.ReleaseLabel != releaseLabel {
-				continue
-			}
-			if len(lastStable) != 0 {
-				var backported bool
-				for _, bb := range pr.BackportBranches {
-					if strings.Contains(bb, lastStable) {
-						backported = true
-					}
-				}
-				if backported {
-					continue
-				}
-			}
-			if !printedReleaseNoteHeader {
-				fmt.Println()
-				fmt.Println(releaseNotes[releaseLabel])
-				printedReleaseNoteHeader = true
-			}
+// terminalInput returns the terminal of the run, which the confirmations of
+// the mutating steps are typed on once stdin was consumed by '--token-file -',
+// or an empty input if there is none, e.g. in CI, so that they fail asking for
+// --confirm instead of reading the rest of the token.
+func terminalInput() *os.File {
+	if tty, err := os.Open("/dev/tty"); err == nil {
+		return tty
+	}
+	devNull, _ := os.Open(os.DevNull)
+	return devNull
+}
 
-			changelogItems = append(
-				changelogItems,
-				fmt.Sprintf("* %s (#%d, @%s)", pr.ReleaseNote, prID, pr.AuthorName),
-			)
-			delete(listOfPrs, prID)
-		}
-		sort.Slice(changelogItems, func(i, j int) bool {
-			return strings.ToLower(changelogItems[i]) < strings.ToLower(changelogItems[j])
-		})
-		for _, changeLogItem := range changelogItems {
-			fmt.Println(changeLogItem)
-		}
+// openGate confirms the given mutating step with --confirm and, if set,
+// --environment. The prompts are written into stderr, stdout being the
+// release notes.
+func openGate(ghClient *gh.Client, step string) (*confirm.Gate, error) {
+	return confirm.Open(globalCtx, ghClient, step, cfg.Confirm, cfg.Environment, stdin, os.Stderr)
+}
+
+// useProfile sets the repository, if --repo isn't given, and the label
+// scheme of the release notes from the profile of cfg.ConfigFile named after
+// --profile, if any.
+func useProfile() error {
+	if len(cfg.ConfigFile) == 0 {
+		return nil
 	}
+	c, err := config.Load(cfg.ConfigFile)
+	if err != nil {
+		return fmt.Errorf("unable to load configuration: %w", err)
+	}
+	p, ok := c.UseProfile(cfg.Profile)
+	if !ok {
+		return nil
+	}
+	configProfile = cfg.Profile
+	if len(p.Repo) != 0 && !flag.CommandLine.Changed("repo") {
+		cfg.RepoName = p.Repo
+	}
+	cfg.Profile = p.LabelScheme
+	return nil
+}
 
-	if len(listOfPrs) == 0 {
-		return
+// checkEOL returns an error if any of the branches released, or whose
+// backports are moved, reached its end of life according to cfg.ConfigFile.
+// With cfg.Force, only a warning is printed.
+func checkEOL() error {
+	if len(cfg.ConfigFile) == 0 {
+		return nil
+	}
+	c, err := config.Load(cfg.ConfigFile)
+	if err != nil {
+		return fmt.Errorf("unable to load configuration: %w", err)
 	}
-	fmt.Fprintf(os.Stderr, "\n\033[1mNOTICE\033[0m: The following PRs were not included in the "+
-		"changelog as they were backported to branch %s and assumed to be already released.\n", lastStable)
+	c.UseProfile(configProfile)
+	versions := append([]string{cfg.CurrVer, cfg.Head, cfg.SinceLatestRelease, cfg.SinceVersion}, cfg.Branches...)
+	return c.Schedule.CheckVersionsEOL(time.Now(), cfg.Force, versions...)
+}
 
-	for _, releaseLabel := range releaseNotesOrder {
-		var changelogItems []string
-		printedReleaseNoteHeader := false
-		for prID, pr := range listOfPrs {
-			if pr.ReleaseLabel != releaseLabel {
-				continue
-			}
-			if !printedReleaseNoteHeader {
-				fmt.Fprintf(os.Stderr, releaseNotes[releaseLabel])
-				printedReleaseNoteHeader = true
-			}
-			changelogItems = append(
-				changelogItems,
-				fmt.Sprintf("* %s (#%d, @%s)", pr.ReleaseNote, prID, pr.AuthorName),
-			)
-			delete(listOfPrs, prID)
+// generateBranches generates the release notes of each of cfg.Branches.
+// The release notes of the branches that succeeded are written even if
+// other branches failed.
+func generateBranches(ghClient *gh.Client, tracker *usage.Tracker) {
+	cls, err := changelog.GenerateBranchesReleaseNotes(globalCtx, ghClient, cfg, tracker)
+	failed := err != nil
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Unable to generate release notes: %s\n", err)
+		printHint(err)
+	}
+	ctx, endPhase := tracing.Phase(globalCtx, tracker, "rendering")
+	for i, cl := range cls {
+		if cl == nil {
+			continue
 		}
-		sort.Slice(changelogItems, func(i, j int) bool {
-			return strings.ToLower(changelogItems[i]) < strings.ToLower(changelogItems[j])
-		})
-		for _, changeLogItem := range changelogItems {
-			fmt.Fprintf(os.Stderr, changeLogItem)
+		if err := cl.PrintReleaseNotes(ctx); err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %s\n", cfg.Branches[i], err)
+			printHint(err)
+			failed = true
+			continue
 		}
+		fmt.Fprintf(os.Stderr, "Release notes of %s written into %s\n", cfg.Branches[i], cl.Output)
+	}
+	endPhase()
+	printUsage(tracker)
+	if failed {
+		exit(-1)
+	}
+}
+
+// printHint prints how to fix err, if it was caused by the permissions of
+// the token.
+func printHint(err error) {
+	if hint := github.Explain(err); len(hint) != 0 {
+		fmt.Fprintf(os.Stderr, "%s\n", hint)
+	}
+}
+
+// printUsage prints the API usage and timing report into stderr and, if
+// requested, writes it into cfg.UsageReport.
+func printUsage(tracker *usage.Tracker) {
+	usageReport := tracker.Report()
+	fmt.Fprintln(os.Stderr)
+	usageReport.Print(os.Stderr)
+	if len(cfg.UsageReport) == 0 {
+		return
+	}
+	if err := usageReport.WriteFile(cfg.UsageReport); err != nil {
+		fmt.Fprintf(os.Stderr, "Unable to write usage report: %s\n", err)
+		return
 	}
+	report.Written(cfg.UsageReport)
 }
@@ -0,0 +1,294 @@
+// Copyright 2021 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package projects
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	gh "github.com/google/go-github/v50/github"
+	flag "github.com/spf13/pflag"
+
+	"github.com/cilium/release/pkg/config"
+	"github.com/cilium/release/pkg/confirm"
+	"github.com/cilium/release/pkg/github"
+	"github.com/cilium/release/pkg/report"
+	"github.com/cilium/release/pkg/types"
+	"github.com/cilium/release/pkg/version"
+)
+
+// ArchiveReport records what was archived in the project of a version.
+type ArchiveReport struct {
+	Project string `json:"project"`
+	URL     string `json:"url"`
+	// Archived are the items archived. Their number is 0 for notes and
+	// drafts.
+	Archived []ArchivedItem `json:"archived"`
+	// Closed is set if the project was closed by the run.
+	Closed bool `json:"closed"`
+}
+
+// ArchivedItem is an item of a project, i.e. a card of a classic project,
+// that was archived.
+type ArchivedItem struct {
+	Number int    `json:"number"`
+	Status string `json:"status"`
+}
+
+// WriteFile writes the report as JSON into the given file.
+func (r *ArchiveReport) WriteFile(file string) error {
+	b, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(file, append(b, '\n'), 0644); err != nil {
+		return err
+	}
+	report.Written(file)
+	return nil
+}
+
+// archivable returns true if the items with the given status, or in the
+// column of that name, are archived: only the done backports to the version
+// unless the whole project is archived.
+func archivable(status, ver string, whole bool) bool {
+	return whole || status == columnName(doneBackportPrefix, ver)
+}
+
+// ArchiveProject archives the cards of the done backports in the project of
+// the given version, open or closed. If whole is set, all of its cards are
+// archived and the project is closed.
+func (pm *ProjectManagement) ArchiveProject(ctx context.Context, ver string, whole bool) (*ArchiveReport, error) {
+	projs, err := pm.listProjects(ctx, "all")
+	if err != nil {
+		return nil, err
+	}
+	var proj *gh.Project
+	for _, p := range projs {
+		if p.GetName() == ver && (proj == nil || proj.GetState() == "closed") {
+			proj = p
+		}
+	}
+	if proj == nil {
+		return nil, fmt.Errorf("project %q not found", ver)
+	}
+	report := &ArchiveReport{Project: ver, URL: proj.GetHTMLURL()}
+
+	columns, err := pm.listColumns(ctx, proj.GetID())
+	if err != nil {
+		return nil, err
+	}
+	for _, column := range columns {
+		if !archivable(column.GetName(), ver, whole) {
+			continue
+		}
+		cards, err := pm.listCards(ctx, column.GetID())
+		if err != nil {
+			return nil, err
+		}
+		for _, card := range cards {
+			number, _ := strconv.Atoi(filepath.Base(card.GetContentURL()))
+			fmt.Fprintf(os.Stdout, "archiving #%d from %q\n", number, column.GetName())
+			_, _, err := pm.ghClient.Projects.UpdateProjectCard(ctx, card.GetID(), &gh.ProjectCardOptions{
+				Archived: gh.Bool(true),
+			})
+			if err != nil {
+				return nil, err
+			}
+			report.Archived = append(report.Archived, ArchivedItem{Number: number, Status: column.GetName()})
+		}
+	}
+
+	if whole && proj.GetState() != "closed" {
+		fmt.Fprintf(os.Stdout, "Closing project %q\n", ver)
+		_, _, err = pm.ghClient.Projects.UpdateProject(ctx, proj.GetID(), &gh.ProjectOptions{
+			State: gh.String("closed"),
+		})
+		if err != nil {
+			return nil, err
+		}
+		report.Closed = true
+	}
+	return report, nil
+}
+
+// listCards returns the cards of the given column that are not archived.
+func (pm *ProjectManagement) listCards(ctx context.Context, columnID int64) ([]*gh.ProjectCard, error) {
+	var cards []*gh.ProjectCard
+	opts := &gh.ProjectCardListOptions{
+		ArchivedState: gh.String("not_archived"),
+		ListOptions:   gh.ListOptions{PerPage: 100},
+	}
+	for {
+		page, resp, err := pm.ghClient.Projects.ListProjectCards(ctx, columnID, opts)
+		if err != nil {
+			return nil, err
+		}
+		cards = append(cards, page...)
+		if resp.NextPage == 0 {
+			return cards, nil
+		}
+		opts.Page = resp.NextPage
+	}
+}
+
+// ArchiveProject is the ProjectsV2 equivalent of
+// ProjectManagement.ArchiveProject.
+func (pm *ProjectManagementV2) ArchiveProject(ctx context.Context, ver string, whole bool) (*ArchiveReport, error) {
+	proj, err := pm.findProject(ctx, ver, true)
+	if err != nil {
+		return nil, err
+	}
+	if proj == nil {
+		return nil, fmt.Errorf("project %q not found", ver)
+	}
+	report := &ArchiveReport{Project: ver, URL: proj.URL}
+
+	items, err := pm.listItems(ctx, proj)
+	if err != nil {
+		return nil, err
+	}
+	for _, item := range archivableItems(items, ver, whole) {
+		fmt.Fprintf(os.Stdout, "archiving #%d from %q\n", item.Content.Number, item.FieldValueByName.Name)
+		err := pm.archiveItem(ctx, proj, item.ID)
+		if err != nil {
+			return nil, err
+		}
+		report.Archived = append(report.Archived, ArchivedItem{Number: item.Content.Number, Status: item.FieldValueByName.Name})
+	}
+
+	if whole && !proj.Closed {
+		fmt.Fprintf(os.Stdout, "Closing project %q\n", ver)
+		err = pm.closeProject(ctx, proj)
+		if err != nil {
+			return nil, err
+		}
+		report.Closed = true
+	}
+	return report, nil
+}
+
+// ArchiveReleasedProject archives the done backports of the project of the
+// given released version, e.g. once its release is published.
+func ArchiveReleasedProject(ctx context.Context, ghClient *gh.Client, owner, repo, ver string, projectsV2 bool) (*ArchiveReport, error) {
+	return archiveProject(ctx, ghClient, owner, repo, ver, false, projectsV2)
+}
+
+func archiveProject(ctx context.Context, ghClient *gh.Client, owner, repo, ver string, whole, projectsV2 bool) (*ArchiveReport, error) {
+	if projectsV2 {
+		return NewProjectManagementV2(ghClient, owner, repo).ArchiveProject(ctx, ver, whole)
+	}
+	return NewProjectManagement(ghClient, owner, repo).ArchiveProject(ctx, ver, whole)
+}
+
+// archivableItems returns the items, not archived yet, to archive.
+func archivableItems(items []projectV2Item, ver string, whole bool) []projectV2Item {
+	var archived []projectV2Item
+	for _, item := range items {
+		if !item.IsArchived && archivable(item.FieldValueByName.Name, ver, whole) {
+			archived = append(archived, item)
+		}
+	}
+	return archived
+}
+
+func (pm *ProjectManagementV2) archiveItem(ctx context.Context, proj *projectV2, itemID string) error {
+	return github.GraphQL(ctx, pm.ghClient, `
+mutation($input: ArchiveProjectV2ItemInput!) {
+	archiveProjectV2Item(input: $input) {
+		item { id }
+	}
+}`, map[string]interface{}{
+		"input": map[string]interface{}{
+			"projectId": proj.ID,
+			"itemId":    itemID,
+		},
+	}, nil)
+}
+
+func archiveCommand(ctx context.Context, ghClient *gh.Client, args []string) (err error) {
+	var (
+		repoName    string
+		ver         string
+		whole       bool
+		reportFile  string
+		projectsV2  bool
+		cfgFile     string
+		force       bool
+		confirmURL  string
+		environment string
+	)
+	fs := flag.NewFlagSet("projects archive", flag.ContinueOnError)
+	fs.StringVar(&repoName, "repo", "cilium/cilium", "GitHub organization and repository names separated by a slash")
+	fs.StringVar(&ver, "released-version", "", "Version that was just released, whose project is archived (e.g.: '1.14.3')")
+	fs.BoolVar(&whole, "whole-project", false, "Archive all the items of the project, not only the done backports, and close it")
+	fs.StringVar(&reportFile, "report", "", "When set, the items archived are written as JSON into this file")
+	fs.BoolVar(&projectsV2, "projects-v2", false, "Archive the items of a GitHub ProjectV2 instead of a classic project")
+	fs.StringVar(&cfgFile, "config", "", "Configuration file containing the end of life dates of the branches")
+	fs.BoolVar(&force, "force", false, "Archive the project even if its branch reached its end of life according to --config")
+	confirm.AddFlags(fs, &confirmURL, &environment)
+	if err := types.ParseFlags(fs, "projects archive", args); err != nil {
+		return err
+	}
+
+	owner, repo, err := types.SplitRepoName(repoName)
+	if err != nil {
+		return err
+	}
+	if len(ver) == 0 {
+		return fmt.Errorf("--released-version must be set")
+	}
+	if _, err := version.Parse(ver); err != nil {
+		return err
+	}
+	if len(cfgFile) != 0 {
+		cfg, err := config.Load(cfgFile)
+		if err != nil {
+			return fmt.Errorf("unable to load configuration: %w", err)
+		}
+		if err := cfg.Schedule.CheckVersionsEOL(time.Now(), force, ver); err != nil {
+			return err
+		}
+	}
+
+	gate, err := confirm.Open(ctx, ghClient, "projects archive "+ver, confirmURL, environment, bufio.NewReader(os.Stdin), os.Stdout)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if rerr := gate.Report(ctx); rerr != nil && err == nil {
+			err = rerr
+		}
+	}()
+
+	report, err := archiveProject(ctx, ghClient, owner, repo, ver, whole, projectsV2)
+	if err != nil {
+		return fmt.Errorf("unable to archive project %q: %w", ver, err)
+	}
+	fmt.Fprintf(os.Stdout, "Archived %d items of project %q: %s\n", len(report.Archived), ver, report.URL)
+	gate.Executed("Archived %d items of project %s", len(report.Archived), report.URL)
+	if len(reportFile) != 0 {
+		if err := report.WriteFile(reportFile); err != nil {
+			return fmt.Errorf("unable to write report: %w", err)
+		}
+	}
+	return nil
+}
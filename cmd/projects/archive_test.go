@@ -0,0 +1,54 @@
+// Copyright 2021 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package projects
+
+import (
+	"reflect"
+	"testing"
+)
+
+func Test_archivableItems(t *testing.T) {
+	item := func(id, status string, archived bool) projectV2Item {
+		i := projectV2Item{ID: id, IsArchived: archived}
+		i.FieldValueByName.Name = status
+		return i
+	}
+	items := []projectV2Item{
+		item("a", "Needs backport from main", false),
+		item("b", "Backport pending to v1.14", false),
+		item("c", "Backport done to v1.14", false),
+		item("d", "Backport done to v1.14", true),
+		item("e", "", false),
+	}
+	tests := []struct {
+		name  string
+		whole bool
+		want  []string
+	}{
+		{name: "done backports", want: []string{"c"}},
+		{name: "whole project", whole: true, want: []string{"a", "b", "c", "e"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, item := range archivableItems(items, "1.14.3", tt.whole) {
+				got = append(got, item.ID)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("archivableItems() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
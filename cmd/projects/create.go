@@ -0,0 +1,307 @@
+// Copyright 2021 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package projects
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"text/template"
+	"time"
+
+	gh "github.com/google/go-github/v50/github"
+	flag "github.com/spf13/pflag"
+
+	"github.com/cilium/release/pkg/config"
+	"github.com/cilium/release/pkg/confirm"
+	"github.com/cilium/release/pkg/types"
+	"github.com/cilium/release/pkg/version"
+)
+
+// defaultColumns are the column templates used when none are configured.
+var defaultColumns = []string{
+	needsBackport,
+	pendingBackportPrefix + "{{ .Minor }}",
+	doneBackportPrefix + "{{ .Minor }}",
+}
+
+// renderColumns renders the column templates for the given version. The
+// columns required to sync the projects are always part of the result.
+func renderColumns(templates []string, ver string) ([]string, error) {
+	v, err := version.Parse(ver)
+	if err != nil {
+		return nil, err
+	}
+	data := struct {
+		Version string
+		Minor   string
+	}{
+		Version: v.String(),
+		Minor:   v.MinorString(),
+	}
+
+	var columns []string
+	present := map[string]bool{}
+	all := append(append([]string{}, templates...), defaultColumns...)
+	for _, tmpl := range all {
+		t, err := template.New("column").Parse(tmpl)
+		if err != nil {
+			return nil, fmt.Errorf("invalid column template %q: %w", tmpl, err)
+		}
+		var buf bytes.Buffer
+		err = t.Execute(&buf, data)
+		if err != nil {
+			return nil, fmt.Errorf("invalid column template %q: %w", tmpl, err)
+		}
+		column := buf.String()
+		if present[column] {
+			continue
+		}
+		present[column] = true
+		columns = append(columns, column)
+	}
+	return columns, nil
+}
+
+// ProjectURL returns the URL of the open project of the given version, or an
+// empty string if there is none.
+func (pm *ProjectManagement) ProjectURL(ctx context.Context, ver string) (string, error) {
+	projs, err := pm.listProjects(ctx, "open")
+	if err != nil {
+		return "", err
+	}
+	for _, p := range projs {
+		if p.GetName() == ver {
+			return p.GetHTMLURL(), nil
+		}
+	}
+	return "", nil
+}
+
+// CreateProject creates the project for the given version with the given
+// columns and returns its URL. If the project already exists, only the
+// missing columns are created.
+func (pm *ProjectManagement) CreateProject(ctx context.Context, ver string, columns []string) (string, error) {
+	projs, err := pm.listProjects(ctx, "open")
+	if err != nil {
+		return "", err
+	}
+	var proj *gh.Project
+	for _, p := range projs {
+		if p.GetName() == ver {
+			proj = p
+			break
+		}
+	}
+
+	existing := map[string]bool{}
+	if proj == nil {
+		proj, _, err = pm.ghClient.Repositories.CreateProject(ctx, pm.owner, pm.repo, &gh.ProjectOptions{
+			Name: &ver,
+		})
+		if err != nil {
+			return "", err
+		}
+	} else {
+		cols, err := pm.listColumns(ctx, proj.GetID())
+		if err != nil {
+			return "", err
+		}
+		for _, col := range cols {
+			existing[col.GetName()] = true
+		}
+	}
+
+	for _, column := range columns {
+		if existing[column] {
+			continue
+		}
+		_, err := pm.createColumn(ctx, proj.GetID(), column)
+		if err != nil {
+			return "", err
+		}
+	}
+	return proj.GetHTMLURL(), nil
+}
+
+// ProjectURL returns the URL of the open project of the given version, or an
+// empty string if there is none.
+func (pm *ProjectManagementV2) ProjectURL(ctx context.Context, ver string) (string, error) {
+	proj, err := pm.findProject(ctx, ver, false)
+	if err != nil || proj == nil {
+		return "", err
+	}
+	return proj.URL, nil
+}
+
+// CreateProject creates the project for the given version with a status
+// option for each of the given columns and returns its URL. If the project
+// already exists, the status options it lacks are created.
+func (pm *ProjectManagementV2) CreateProject(ctx context.Context, ver string, columns []string) (string, error) {
+	proj, err := pm.findProject(ctx, ver, false)
+	if err != nil {
+		return "", err
+	}
+	if proj == nil {
+		proj, err = pm.createProject(ctx, ver)
+		if err != nil {
+			return "", err
+		}
+	}
+	if err := pm.ensureStatusOptions(ctx, proj, columns...); err != nil {
+		return "", err
+	}
+	return proj.URL, nil
+}
+
+// Command implements the 'projects' subcommand.
+func Command(ctx context.Context, ghClient *gh.Client, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("usage: projects create|archive [flags]")
+	}
+	switch args[0] {
+	case "create":
+		return createCommand(ctx, ghClient, args[1:])
+	case "archive":
+		return archiveCommand(ctx, ghClient, args[1:])
+	}
+	return fmt.Errorf("usage: projects create|archive [flags]")
+}
+
+func createCommand(ctx context.Context, ghClient *gh.Client, args []string) (err error) {
+	var (
+		cfgFile         string
+		profileName     string
+		repoName        string
+		ver             string
+		releasedVersion string
+		projectsV2      bool
+		force           bool
+		confirmURL      string
+		environment     string
+	)
+	fs := flag.NewFlagSet("projects create", flag.ContinueOnError)
+	fs.StringVar(&cfgFile, "config", "", "Configuration file containing the column templates")
+	fs.StringVar(&profileName, "profile", "", "Profile of --config giving the repository, if --repo isn't given, and the column templates")
+	fs.StringVar(&repoName, "repo", "cilium/cilium", "GitHub organization and repository names separated by a slash")
+	fs.StringVar(&ver, "version", "", "Version of the project to create (e.g.: '1.14.3')")
+	fs.StringVar(&releasedVersion, "released-version", "", "Version that was just released, the project is created for its next patch version")
+	fs.BoolVar(&projectsV2, "projects-v2", false, "Create a GitHub ProjectV2 instead of a classic project")
+	fs.BoolVar(&force, "force", false, "Create the project even if its branch reached its end of life according to --config")
+	confirm.AddFlags(fs, &confirmURL, &environment)
+	if err := types.ParseFlags(fs, "projects create", args); err != nil {
+		return err
+	}
+
+	var (
+		templates []string
+		schedule  config.Schedule
+	)
+	if len(cfgFile) != 0 {
+		cfg, err := config.Load(cfgFile)
+		if err != nil {
+			return fmt.Errorf("unable to load configuration: %w", err)
+		}
+		p, ok := cfg.UseProfile(profileName)
+		if !ok && len(profileName) != 0 {
+			return fmt.Errorf("no profile %q in %s", profileName, cfgFile)
+		}
+		if len(p.Repo) != 0 && !fs.Changed("repo") {
+			repoName = p.Repo
+		}
+		templates = cfg.Projects.Columns
+		schedule = cfg.Schedule
+	}
+
+	owner, repo, err := types.SplitRepoName(repoName)
+	if err != nil {
+		return err
+	}
+	if len(ver) == 0 {
+		if len(releasedVersion) == 0 {
+			return fmt.Errorf("--version or --released-version must be set")
+		}
+		ver, err = nextPatchVersion(releasedVersion)
+		if err != nil {
+			return err
+		}
+	}
+
+	if err := schedule.CheckVersionsEOL(time.Now(), force, ver); err != nil {
+		return err
+	}
+	columns, err := renderColumns(templates, ver)
+	if err != nil {
+		return err
+	}
+
+	gate, err := confirm.Open(ctx, ghClient, "projects create "+ver, confirmURL, environment, bufio.NewReader(os.Stdin), os.Stdout)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if rerr := gate.Report(ctx); rerr != nil && err == nil {
+			err = rerr
+		}
+	}()
+
+	url, err := createProject(ctx, ghClient, owner, repo, ver, columns, projectsV2)
+	if err != nil {
+		return fmt.Errorf("unable to create project %q: %w", ver, err)
+	}
+	fmt.Fprintf(os.Stdout, "Project for %q: %s\n", ver, url)
+	gate.Executed("Created project %s", url)
+	return nil
+}
+
+// CreateNextProject creates the project of the patch version following the
+// given released version, with the columns rendered from the given templates,
+// and returns that version and the URL of the project.
+func CreateNextProject(ctx context.Context, ghClient *gh.Client, owner, repo, releasedVersion string, templates []string, projectsV2 bool) (string, string, error) {
+	ver, err := nextPatchVersion(releasedVersion)
+	if err != nil {
+		return "", "", err
+	}
+	columns, err := renderColumns(templates, ver)
+	if err != nil {
+		return "", "", err
+	}
+	url, err := createProject(ctx, ghClient, owner, repo, ver, columns, projectsV2)
+	if err != nil {
+		return "", "", fmt.Errorf("unable to create project %q: %w", ver, err)
+	}
+	return ver, url, nil
+}
+
+func createProject(ctx context.Context, ghClient *gh.Client, owner, repo, ver string, columns []string, projectsV2 bool) (string, error) {
+	if projectsV2 {
+		return NewProjectManagementV2(ghClient, owner, repo).CreateProject(ctx, ver, columns)
+	}
+	return NewProjectManagement(ghClient, owner, repo).CreateProject(ctx, ver, columns)
+}
+
+// nextPatchVersion returns the patch version following the given released
+// version.
+func nextPatchVersion(released string) (string, error) {
+	v, err := version.Parse(released)
+	if err != nil {
+		return "", err
+	}
+	v.Patch++
+	v.Pre = ""
+	return v.String(), nil
+}
@@ -0,0 +1,62 @@
+// Copyright 2021 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package projects
+
+import (
+	"reflect"
+	"testing"
+)
+
+func Test_renderColumns(t *testing.T) {
+	tests := []struct {
+		name      string
+		templates []string
+		want      []string
+	}{
+		{
+			name: "default columns",
+			want: []string{
+				"Needs backport from main",
+				"Backport pending to v1.14",
+				"Backport done to v1.14",
+			},
+		},
+		{
+			name: "extra columns",
+			templates: []string{
+				"Needs backport from main",
+				"Blocking v{{ .Version }}",
+				"Backport pending to v{{ .Minor }}",
+			},
+			want: []string{
+				"Needs backport from main",
+				"Blocking v1.14.3",
+				"Backport pending to v1.14",
+				"Backport done to v1.14",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := renderColumns(tt.templates, "1.14.3")
+			if err != nil {
+				t.Fatalf("renderColumns() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("renderColumns() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
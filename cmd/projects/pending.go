@@ -0,0 +1,77 @@
+// Copyright 2021 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package projects
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// MovePendingPolicy decides which PRs, found pending a backport while
+// syncing the projects, are moved to the project of the next version. Most
+// commonly a pending PR is a mistake and it should have been previously
+// marked as "backport-done", but some are legitimate carries.
+type MovePendingPolicy struct {
+	// Force moves all pending PRs.
+	Force bool
+	// PRs are the numbers of the pending PRs that are moved.
+	PRs []int
+	// Interactive asks, for each pending PR not present in PRs, whether it
+	// should be moved.
+	Interactive bool
+	// In is the input the answers of Interactive are read from, stdin if
+	// nil. It must be shared with the other readers of stdin, e.g. the
+	// confirmation of the step, as they buffer it.
+	In *bufio.Reader
+
+	out io.Writer
+}
+
+// shouldMove returns true if the given pending PR should be moved to the
+// next project. Without an allow-list nor interactive mode, an error is
+// returned as pending PRs are not expected.
+func (p *MovePendingPolicy) shouldMove(owner, repo string, prNumber int) (bool, error) {
+	if p.Force {
+		return true, nil
+	}
+	for _, pr := range p.PRs {
+		if pr == prNumber {
+			return true, nil
+		}
+	}
+	prURL := fmt.Sprintf("https://github.com/%s/%s/pull/%d", owner, repo, prNumber)
+	if p.Interactive {
+		if p.In == nil {
+			p.In = bufio.NewReader(os.Stdin)
+		}
+		if p.out == nil {
+			p.out = os.Stdout
+		}
+		fmt.Fprintf(p.out, "PR %s is pending a backport, move it to the next project? [y/N] ", prURL)
+		answer, err := p.In.ReadString('\n')
+		if err != nil && err != io.EOF {
+			return false, err
+		}
+		answer = strings.ToLower(strings.TrimSpace(answer))
+		return answer == "y" || answer == "yes", nil
+	}
+	if len(p.PRs) != 0 {
+		return false, nil
+	}
+	return false, fmt.Errorf("Found unexpected pending PR %s in project. Please ensure that all backported PRs have been moved to the done column.", prURL)
+}
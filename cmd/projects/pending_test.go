@@ -0,0 +1,84 @@
+// Copyright 2021 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package projects
+
+import (
+	"bufio"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestMovePendingPolicy_shouldMove(t *testing.T) {
+	tests := []struct {
+		name     string
+		policy   MovePendingPolicy
+		input    string
+		prNumber int
+		want     bool
+		wantErr  bool
+	}{
+		{
+			name:     "default policy fails",
+			prNumber: 1,
+			wantErr:  true,
+		},
+		{
+			name:     "force",
+			policy:   MovePendingPolicy{Force: true},
+			prNumber: 1,
+			want:     true,
+		},
+		{
+			name:     "in allow-list",
+			policy:   MovePendingPolicy{PRs: []int{1, 2}},
+			prNumber: 2,
+			want:     true,
+		},
+		{
+			name:     "not in allow-list",
+			policy:   MovePendingPolicy{PRs: []int{1, 2}},
+			prNumber: 3,
+			want:     false,
+		},
+		{
+			name:     "interactive yes",
+			policy:   MovePendingPolicy{Interactive: true},
+			input:    "y\n",
+			prNumber: 3,
+			want:     true,
+		},
+		{
+			name:     "interactive no input",
+			policy:   MovePendingPolicy{Interactive: true},
+			prNumber: 3,
+			want:     false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.policy.In = bufio.NewReader(strings.NewReader(tt.input))
+			tt.policy.out = io.Discard
+			got, err := tt.policy.shouldMove("cilium", "cilium", tt.prNumber)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("shouldMove() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("shouldMove() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
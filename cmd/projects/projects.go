@@ -23,16 +23,14 @@ import (
 	"strings"
 
 	gh "github.com/google/go-github/v50/github"
+
+	"github.com/cilium/release/pkg/profile"
 )
 
 const (
 	needsBackport         = "Needs backport from main"
 	pendingBackportPrefix = "Backport pending to v"
 	doneBackportPrefix    = "Backport done to v"
-
-	needsBackportLbl   = "needs-backport/"
-	pendingBackportLbl = "backport-pending/"
-	doneBackportLbl    = "backport-done/"
 )
 
 func columnName(prefix, version string) string {
@@ -53,8 +51,46 @@ type ProjectManagement struct {
 	ghClient *gh.Client
 }
 
+// listProjects returns all the classic projects of the repository in the
+// given state, 'open', 'closed' or 'all'.
+func (pm *ProjectManagement) listProjects(ctx context.Context, state string) ([]*gh.Project, error) {
+	var projs []*gh.Project
+	opts := &gh.ProjectListOptions{
+		State:       state,
+		ListOptions: gh.ListOptions{PerPage: 100},
+	}
+	for {
+		page, resp, err := pm.ghClient.Repositories.ListProjects(ctx, pm.owner, pm.repo, opts)
+		if err != nil {
+			return nil, err
+		}
+		projs = append(projs, page...)
+		if resp.NextPage == 0 {
+			return projs, nil
+		}
+		opts.Page = resp.NextPage
+	}
+}
+
+// listColumns returns all the columns of the given project.
+func (pm *ProjectManagement) listColumns(ctx context.Context, projID int64) ([]*gh.ProjectColumn, error) {
+	var columns []*gh.ProjectColumn
+	opts := &gh.ListOptions{PerPage: 100}
+	for {
+		page, resp, err := pm.ghClient.Projects.ListProjectColumns(ctx, projID, opts)
+		if err != nil {
+			return nil, err
+		}
+		columns = append(columns, page...)
+		if resp.NextPage == 0 {
+			return columns, nil
+		}
+		opts.Page = resp.NextPage
+	}
+}
+
 func (pm *ProjectManagement) findProjects(ctx context.Context, curr, next string) (int64, int64, error) {
-	projs, _, err := pm.ghClient.Repositories.ListProjects(ctx, pm.owner, pm.repo, &gh.ProjectListOptions{State: "open"})
+	projs, err := pm.listProjects(ctx, "open")
 	if err != nil {
 		return 0, 0, err
 	}
@@ -77,7 +113,7 @@ func (pm *ProjectManagement) findColumnIDs(ctx context.Context, projID int64, ve
 	pending = int64(-1)
 	done = int64(-1)
 
-	columns, _, err = pm.ghClient.Projects.ListProjectColumns(ctx, projID, &gh.ListOptions{})
+	columns, err = pm.listColumns(ctx, projID)
 	if err != nil {
 		return
 	}
@@ -158,7 +194,7 @@ func (pm *ProjectManagement) createCard(ctx context.Context, columnID, prID int6
 	return err
 }
 
-func (pm *ProjectManagement) syncCards(ctx context.Context, currVer, nextVer string, currColumnID, nextColumnID, currDoneColumnID, nextPendingColumnID int64, forceMovePending bool) error {
+func (pm *ProjectManagement) syncCards(ctx context.Context, p profile.Profile, currVer, nextVer string, currColumnID, nextColumnID, currDoneColumnID, nextPendingColumnID int64, policy *MovePendingPolicy) error {
 	// get base cards
 	currCards, _, err := pm.ghClient.Projects.ListProjectCards(ctx, currColumnID, &gh.ProjectCardListOptions{})
 	if err != nil {
@@ -186,7 +222,7 @@ func (pm *ProjectManagement) syncCards(ctx context.Context, currVer, nextVer str
 		moveToColumnID := nextColumnID
 		var labelFound bool
 		for _, lbl := range pr.Labels {
-			if lbl.GetName() == labelName(doneBackportLbl, currVer) {
+			if lbl.GetName() == labelName(p.DoneBackportPrefix, currVer) {
 				labelFound = true
 				// If it is already backported them move it to the right column
 				// in the current project.
@@ -196,13 +232,17 @@ func (pm *ProjectManagement) syncCards(ctx context.Context, currVer, nextVer str
 					return err
 				}
 				goto endForLoop
-			} else if lbl.GetName() == labelName(pendingBackportLbl, currVer) {
+			} else if lbl.GetName() == labelName(p.PendingBackportPrefix, currVer) {
 				labelFound = true
 				// If it is pending, them move it to the right column in the new
 				// project.
-				if !forceMovePending {
-					return fmt.Errorf("Found unexpected pending PR https://github.com/%s/%s/pull/%d in project. Please ensure that all backported PRs have been moved to the done column.",
-						pm.owner, pm.repo, prNumber)
+				move, err := policy.shouldMove(pm.owner, pm.repo, prNumber)
+				if err != nil {
+					return err
+				}
+				if !move {
+					fmt.Fprintf(os.Stdout, "leaving PR %d in %q\n", prNumber, columnName(pendingBackportPrefix, currVer))
+					goto endForLoop
 				}
 				moveToColumnID = nextPendingColumnID
 				fmt.Fprintf(os.Stdout, "moving PR %d to %q\n", prNumber, columnName(pendingBackportPrefix, nextVer))
@@ -236,7 +276,11 @@ func NewProjectManagement(ghClient *gh.Client, owner, repo string) *ProjectManag
 	}
 }
 
-func (pm *ProjectManagement) SyncProjects(ctx context.Context, currVer, nextVer string, forceMovePending bool) error {
+// SyncProjects moves all PRs that still need to be backported from the
+// project of currVer to the project of nextVer, according to the backport
+// labels of p. The PRs pending a backport are only moved if allowed by the
+// given policy.
+func (pm *ProjectManagement) SyncProjects(ctx context.Context, p profile.Profile, currVer, nextVer string, policy *MovePendingPolicy) error {
 	currProjID, nextProjID, err := pm.findProjects(ctx, currVer, nextVer)
 	if err != nil {
 		return err
@@ -306,12 +350,12 @@ func (pm *ProjectManagement) SyncProjects(ctx context.Context, currVer, nextVer
 	}
 
 	// Move needs backport column cards to the correct columns
-	err = pm.syncCards(ctx, currVer, nextVer, currNeedsColumnID, nextNeedsColumnID, currDoneColumnID, nextPendingColumnID, true)
+	err = pm.syncCards(ctx, p, currVer, nextVer, currNeedsColumnID, nextNeedsColumnID, currDoneColumnID, nextPendingColumnID, &MovePendingPolicy{Force: true})
 	if err != nil {
 		return err
 	}
 	// Move pending backport column cards to the correct columns
-	err = pm.syncCards(ctx, currVer, nextVer, currPendingColumnID, nextPendingColumnID, currDoneColumnID, nextPendingColumnID, forceMovePending)
+	err = pm.syncCards(ctx, p, currVer, nextVer, currPendingColumnID, nextPendingColumnID, currDoneColumnID, nextPendingColumnID, policy)
 	if err != nil {
 		return err
 	}
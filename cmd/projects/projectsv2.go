@@ -0,0 +1,567 @@
+// Copyright 2021 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package projects
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	gh "github.com/google/go-github/v50/github"
+
+	"github.com/cilium/release/pkg/github"
+	"github.com/cilium/release/pkg/profile"
+)
+
+// statusFieldName is the name of the single select field used to track the
+// backport status of each PR in a ProjectV2. It replaces the columns used in
+// classic projects.
+const statusFieldName = "Backport Status"
+
+// ProjectManagementV2 is the equivalent of ProjectManagement for GitHub
+// ProjectsV2, which are owned by the organization and managed through the
+// GraphQL API.
+type ProjectManagementV2 struct {
+	owner    string
+	repo     string
+	ghClient *gh.Client
+}
+
+type projectV2 struct {
+	ID     string
+	Number int
+	URL    string
+	Title  string
+	Closed bool
+	Field  projectV2Field
+}
+
+// projectV2Field is the status field of a project.
+type projectV2Field struct {
+	ID      string
+	Options []struct {
+		ID          string
+		Name        string
+		Color       string
+		Description string
+	}
+}
+
+// statusOption returns the ID of the status option with the given name or
+// an empty string if the project does not have it.
+func (p *projectV2) statusOption(name string) string {
+	for _, opt := range p.Field.Options {
+		if opt.Name == name {
+			return opt.ID
+		}
+	}
+	return ""
+}
+
+type projectV2Item struct {
+	ID               string
+	IsArchived       bool
+	FieldValueByName struct {
+		Name string
+	}
+	Content struct {
+		ID     string
+		Number int
+		Labels struct {
+			Nodes []struct {
+				Name string
+			}
+		}
+	}
+}
+
+func NewProjectManagementV2(ghClient *gh.Client, owner, repo string) *ProjectManagementV2 {
+	return &ProjectManagementV2{
+		owner:    owner,
+		repo:     repo,
+		ghClient: ghClient,
+	}
+}
+
+const projectV2Fields = `
+	id
+	number
+	url
+	title
+	closed
+	field(name: "` + statusFieldName + `") {
+		... on ProjectV2SingleSelectField {
+			id
+			options { id name color description }
+		}
+	}`
+
+// findProject returns the open project with exactly the given title or, if
+// includeClosed is set and there is none, the closed one. It returns nil if
+// there is no such project. As the search of the projects is fuzzy, e.g.
+// '1.14' also matches '1.14.1', all the pages of its results are looked
+// through.
+func (pm *ProjectManagementV2) findProject(ctx context.Context, title string, includeClosed bool) (*projectV2, error) {
+	var (
+		closed *projectV2
+		cursor *string
+	)
+	for {
+		var resp struct {
+			Organization struct {
+				ProjectsV2 struct {
+					PageInfo struct {
+						HasNextPage bool
+						EndCursor   string
+					}
+					Nodes []projectV2
+				}
+			}
+		}
+		err := github.GraphQL(ctx, pm.ghClient, `
+query($owner: String!, $title: String!, $cursor: String) {
+	organization(login: $owner) {
+		projectsV2(first: 100, after: $cursor, query: $title) {
+			pageInfo { hasNextPage endCursor }
+			nodes {`+projectV2Fields+`
+			}
+		}
+	}
+}`, map[string]interface{}{
+			"owner":  pm.owner,
+			"title":  title,
+			"cursor": cursor,
+		}, &resp)
+		if err != nil {
+			return nil, err
+		}
+		for _, proj := range resp.Organization.ProjectsV2.Nodes {
+			if proj.Title != title {
+				continue
+			}
+			proj := proj
+			if !proj.Closed {
+				return &proj, nil
+			}
+			if includeClosed && closed == nil {
+				closed = &proj
+			}
+		}
+		if !resp.Organization.ProjectsV2.PageInfo.HasNextPage {
+			return closed, nil
+		}
+		endCursor := resp.Organization.ProjectsV2.PageInfo.EndCursor
+		cursor = &endCursor
+	}
+}
+
+func (pm *ProjectManagementV2) createProject(ctx context.Context, title string) (*projectV2, error) {
+	var repoResp struct {
+		Repository struct {
+			ID    string
+			Owner struct {
+				ID string
+			}
+		}
+	}
+	err := github.GraphQL(ctx, pm.ghClient, `
+query($owner: String!, $repo: String!) {
+	repository(owner: $owner, name: $repo) {
+		id
+		owner { id }
+	}
+}`, map[string]interface{}{
+		"owner": pm.owner,
+		"repo":  pm.repo,
+	}, &repoResp)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp struct {
+		CreateProjectV2 struct {
+			ProjectV2 projectV2
+		}
+	}
+	err = github.GraphQL(ctx, pm.ghClient, `
+mutation($input: CreateProjectV2Input!) {
+	createProjectV2(input: $input) {
+		projectV2 {`+projectV2Fields+`
+		}
+	}
+}`, map[string]interface{}{
+		"input": map[string]interface{}{
+			"ownerId":      repoResp.Repository.Owner.ID,
+			"repositoryId": repoResp.Repository.ID,
+			"title":        title,
+		},
+	}, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return &resp.CreateProjectV2.ProjectV2, nil
+}
+
+// createStatusField creates the status field, with one option for each of
+// the given names, in the given project.
+func (pm *ProjectManagementV2) createStatusField(ctx context.Context, proj *projectV2, names ...string) error {
+	options := make([]map[string]interface{}, 0, len(names))
+	for _, name := range names {
+		options = append(options, map[string]interface{}{
+			"name":        name,
+			"color":       "GRAY",
+			"description": "",
+		})
+	}
+	var resp struct {
+		CreateProjectV2Field struct {
+			ProjectV2Field projectV2Field
+		}
+	}
+	err := github.GraphQL(ctx, pm.ghClient, `
+mutation($input: CreateProjectV2FieldInput!) {
+	createProjectV2Field(input: $input) {
+		projectV2Field {
+			... on ProjectV2SingleSelectField {
+				id
+				options { id name color description }
+			}
+		}
+	}
+}`, map[string]interface{}{
+		"input": map[string]interface{}{
+			"projectId":           proj.ID,
+			"dataType":            "SINGLE_SELECT",
+			"name":                statusFieldName,
+			"singleSelectOptions": options,
+		},
+	}, &resp)
+	if err != nil {
+		return err
+	}
+	proj.Field = resp.CreateProjectV2Field.ProjectV2Field
+	return nil
+}
+
+// ensureStatusOptions creates the status field of the given project, or the
+// options of the given names it lacks. The existing options are given back
+// with their ID so that the items keep their status.
+func (pm *ProjectManagementV2) ensureStatusOptions(ctx context.Context, proj *projectV2, names ...string) error {
+	if proj.Field.ID == "" {
+		return pm.createStatusField(ctx, proj, names...)
+	}
+	options := make([]map[string]interface{}, 0, len(proj.Field.Options)+len(names))
+	for _, opt := range proj.Field.Options {
+		options = append(options, map[string]interface{}{
+			"id":          opt.ID,
+			"name":        opt.Name,
+			"color":       opt.Color,
+			"description": opt.Description,
+		})
+	}
+	missing := false
+	for _, name := range names {
+		if proj.statusOption(name) != "" {
+			continue
+		}
+		fmt.Fprintf(os.Stdout, "creating status %q in project %q\n", name, proj.Title)
+		missing = true
+		options = append(options, map[string]interface{}{
+			"name":        name,
+			"color":       "GRAY",
+			"description": "",
+		})
+	}
+	if !missing {
+		return nil
+	}
+	var resp struct {
+		UpdateProjectV2Field struct {
+			ProjectV2Field projectV2Field
+		}
+	}
+	err := github.GraphQL(ctx, pm.ghClient, `
+mutation($input: UpdateProjectV2FieldInput!) {
+	updateProjectV2Field(input: $input) {
+		projectV2Field {
+			... on ProjectV2SingleSelectField {
+				id
+				options { id name color description }
+			}
+		}
+	}
+}`, map[string]interface{}{
+		"input": map[string]interface{}{
+			"fieldId":             proj.Field.ID,
+			"singleSelectOptions": options,
+		},
+	}, &resp)
+	if err != nil {
+		return err
+	}
+	proj.Field = resp.UpdateProjectV2Field.ProjectV2Field
+	return nil
+}
+
+func (pm *ProjectManagementV2) listItems(ctx context.Context, proj *projectV2) ([]projectV2Item, error) {
+	var (
+		items  []projectV2Item
+		cursor *string
+	)
+	for {
+		var resp struct {
+			Node struct {
+				Items struct {
+					PageInfo struct {
+						HasNextPage bool
+						EndCursor   string
+					}
+					Nodes []projectV2Item
+				}
+			}
+		}
+		err := github.GraphQL(ctx, pm.ghClient, `
+query($project: ID!, $cursor: String) {
+	node(id: $project) {
+		... on ProjectV2 {
+			items(first: 100, after: $cursor) {
+				pageInfo { hasNextPage endCursor }
+				nodes {
+					id
+					isArchived
+					fieldValueByName(name: "`+statusFieldName+`") {
+						... on ProjectV2ItemFieldSingleSelectValue { name }
+					}
+					content {
+						... on PullRequest {
+							id
+							number
+							labels(first: 100) { nodes { name } }
+						}
+					}
+				}
+			}
+		}
+	}
+}`, map[string]interface{}{
+			"project": proj.ID,
+			"cursor":  cursor,
+		}, &resp)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, resp.Node.Items.Nodes...)
+		if !resp.Node.Items.PageInfo.HasNextPage {
+			return items, nil
+		}
+		endCursor := resp.Node.Items.PageInfo.EndCursor
+		cursor = &endCursor
+	}
+}
+
+func (pm *ProjectManagementV2) addItem(ctx context.Context, proj *projectV2, contentID string) (string, error) {
+	var resp struct {
+		AddProjectV2ItemById struct {
+			Item struct {
+				ID string
+			}
+		}
+	}
+	err := github.GraphQL(ctx, pm.ghClient, `
+mutation($input: AddProjectV2ItemByIdInput!) {
+	addProjectV2ItemById(input: $input) {
+		item { id }
+	}
+}`, map[string]interface{}{
+		"input": map[string]interface{}{
+			"projectId": proj.ID,
+			"contentId": contentID,
+		},
+	}, &resp)
+	return resp.AddProjectV2ItemById.Item.ID, err
+}
+
+func (pm *ProjectManagementV2) setStatus(ctx context.Context, proj *projectV2, itemID, status string) error {
+	return github.GraphQL(ctx, pm.ghClient, `
+mutation($input: UpdateProjectV2ItemFieldValueInput!) {
+	updateProjectV2ItemFieldValue(input: $input) {
+		projectV2Item { id }
+	}
+}`, map[string]interface{}{
+		"input": map[string]interface{}{
+			"projectId": proj.ID,
+			"itemId":    itemID,
+			"fieldId":   proj.Field.ID,
+			"value": map[string]interface{}{
+				"singleSelectOptionId": proj.statusOption(status),
+			},
+		},
+	}, nil)
+}
+
+func (pm *ProjectManagementV2) deleteItem(ctx context.Context, proj *projectV2, itemID string) error {
+	return github.GraphQL(ctx, pm.ghClient, `
+mutation($input: DeleteProjectV2ItemInput!) {
+	deleteProjectV2Item(input: $input) {
+		deletedItemId
+	}
+}`, map[string]interface{}{
+		"input": map[string]interface{}{
+			"projectId": proj.ID,
+			"itemId":    itemID,
+		},
+	}, nil)
+}
+
+func (pm *ProjectManagementV2) closeProject(ctx context.Context, proj *projectV2) error {
+	return github.GraphQL(ctx, pm.ghClient, `
+mutation($input: UpdateProjectV2Input!) {
+	updateProjectV2(input: $input) {
+		projectV2 { id }
+	}
+}`, map[string]interface{}{
+		"input": map[string]interface{}{
+			"projectId": proj.ID,
+			"closed":    true,
+		},
+	}, nil)
+}
+
+func (pm *ProjectManagementV2) syncItems(ctx context.Context, p profile.Profile, currVer, nextVer string, currProj, nextProj *projectV2, items []projectV2Item, status, nextStatus string, policy *MovePendingPolicy) error {
+	for _, item := range items {
+		if item.FieldValueByName.Name != status {
+			continue
+		}
+		prNumber := item.Content.Number
+		// it's not a PR, it's a GH issue or a draft
+		if prNumber == 0 {
+			continue
+		}
+		// If it is not backported them move it to the right status in the
+		// next project.
+		moveToStatus := nextStatus
+		var labelFound, done, skip bool
+		for _, lbl := range item.Content.Labels.Nodes {
+			if lbl.Name == labelName(p.DoneBackportPrefix, currVer) {
+				labelFound = true
+				done = true
+			} else if lbl.Name == labelName(p.PendingBackportPrefix, currVer) {
+				labelFound = true
+				// If it is pending, them move it to the right status in the
+				// new project.
+				move, err := policy.shouldMove(pm.owner, pm.repo, prNumber)
+				if err != nil {
+					return err
+				}
+				if !move {
+					skip = true
+				}
+				moveToStatus = columnName(pendingBackportPrefix, nextVer)
+			}
+			if labelFound {
+				break
+			}
+		}
+		if skip {
+			fmt.Fprintf(os.Stdout, "leaving PR %d in %q\n", prNumber, status)
+			continue
+		}
+		if done {
+			// If it is already backported them move it to the right status
+			// in the current project.
+			doneStatus := columnName(doneBackportPrefix, currVer)
+			fmt.Fprintf(os.Stdout, "moving PR %d to %q\n", prNumber, doneStatus)
+			err := pm.setStatus(ctx, currProj, item.ID, doneStatus)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+		fmt.Fprintf(os.Stdout, "moving PR %d to %q in the project %q\n", prNumber, moveToStatus, nextVer)
+		itemID, err := pm.addItem(ctx, nextProj, item.Content.ID)
+		if err != nil {
+			return err
+		}
+		err = pm.setStatus(ctx, nextProj, itemID, moveToStatus)
+		if err != nil {
+			return err
+		}
+		// Since the item was moved we can delete it from the current project.
+		err = pm.deleteItem(ctx, currProj, item.ID)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SyncProjects is the ProjectsV2 equivalent of ProjectManagement.SyncProjects.
+func (pm *ProjectManagementV2) SyncProjects(ctx context.Context, p profile.Profile, currVer, nextVer string, policy *MovePendingPolicy) error {
+	currProj, err := pm.findProject(ctx, currVer, false)
+	if err != nil {
+		return err
+	}
+	if currProj == nil {
+		return fmt.Errorf("current project %q not found", currVer)
+	}
+	currStatuses := []string{
+		needsBackport,
+		columnName(pendingBackportPrefix, currVer),
+		columnName(doneBackportPrefix, currVer),
+	}
+	if err := pm.ensureStatusOptions(ctx, currProj, currStatuses...); err != nil {
+		return fmt.Errorf("unable to create the statuses of project %q: %w", currVer, err)
+	}
+
+	nextProj, err := pm.findProject(ctx, nextVer, false)
+	if err != nil {
+		return err
+	}
+	if nextProj == nil {
+		fmt.Fprintf(os.Stdout, "Next project %q not found, creating it...\n", nextVer)
+		nextProj, err = pm.createProject(ctx, nextVer)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stdout, "Project created for %q: %s\n", nextVer, nextProj.URL)
+	}
+	nextStatuses := []string{
+		needsBackport,
+		columnName(pendingBackportPrefix, nextVer),
+		columnName(doneBackportPrefix, nextVer),
+	}
+	if err := pm.ensureStatusOptions(ctx, nextProj, nextStatuses...); err != nil {
+		return fmt.Errorf("unable to create the statuses of project %q: %w", nextVer, err)
+	}
+
+	items, err := pm.listItems(ctx, currProj)
+	if err != nil {
+		return err
+	}
+	// Move needs backport items to the correct statuses
+	err = pm.syncItems(ctx, p, currVer, nextVer, currProj, nextProj, items, needsBackport, needsBackport, &MovePendingPolicy{Force: true})
+	if err != nil {
+		return err
+	}
+	// Move pending backport items to the correct statuses
+	err = pm.syncItems(ctx, p, currVer, nextVer, currProj, nextProj, items, columnName(pendingBackportPrefix, currVer), columnName(pendingBackportPrefix, nextVer), policy)
+	if err != nil {
+		return err
+	}
+
+	// Close the current project
+	fmt.Fprintf(os.Stdout, "Closing project %q\n", currVer)
+	return pm.closeProject(ctx, currProj)
+}
@@ -0,0 +1,233 @@
+// Copyright 2021 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package projects
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+
+	gh "github.com/google/go-github/v50/github"
+
+	"github.com/cilium/release/pkg/profile"
+)
+
+// fakeProjectsV2 is a fake of the GraphQL API of the ProjectsV2 of an
+// organization, recording the mutations it receives.
+type fakeProjectsV2 struct {
+	t        *testing.T
+	projects []map[string]interface{}
+	items    map[string][]map[string]interface{}
+	calls    []string
+}
+
+func (f *fakeProjectsV2) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Query     string
+		Variables map[string]interface{}
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		f.t.Fatal(err)
+	}
+	input, _ := req.Variables["input"].(map[string]interface{})
+	var data interface{}
+	switch q := req.Query; {
+	case strings.Contains(q, "projectsV2(first"):
+		// The projects are listed 2 per page, the cursor being the index of
+		// the first one of the page.
+		start := 0
+		if cursor, ok := req.Variables["cursor"].(string); ok {
+			start, _ = strconv.Atoi(cursor)
+		}
+		end := start + 2
+		if end > len(f.projects) {
+			end = len(f.projects)
+		}
+		data = map[string]interface{}{"organization": map[string]interface{}{
+			"projectsV2": map[string]interface{}{
+				"pageInfo": map[string]interface{}{"hasNextPage": end < len(f.projects), "endCursor": strconv.Itoa(end)},
+				"nodes":    f.projects[start:end],
+			},
+		}}
+	case strings.Contains(q, "repository(owner"):
+		data = map[string]interface{}{"repository": map[string]interface{}{"id": "R", "owner": map[string]interface{}{"id": "O"}}}
+	case strings.Contains(q, "createProjectV2("):
+		proj := map[string]interface{}{"id": "P2", "title": input["title"], "url": "https://github.com/orgs/cilium/projects/2"}
+		f.projects = append(f.projects, proj)
+		f.calls = append(f.calls, fmt.Sprintf("create project %s", input["title"]))
+		data = map[string]interface{}{"createProjectV2": map[string]interface{}{"projectV2": proj}}
+	case strings.Contains(q, "createProjectV2Field("), strings.Contains(q, "updateProjectV2Field("):
+		var names []string
+		var options []map[string]interface{}
+		for i, o := range input["singleSelectOptions"].([]interface{}) {
+			opt := o.(map[string]interface{})
+			if opt["id"] == nil {
+				opt["id"] = fmt.Sprintf("new%d", i)
+			}
+			names = append(names, fmt.Sprintf("%s=%s", opt["id"], opt["name"]))
+			options = append(options, opt)
+		}
+		field := map[string]interface{}{"id": "F", "options": options}
+		if strings.Contains(q, "createProjectV2Field(") {
+			f.calls = append(f.calls, fmt.Sprintf("create field %s in %s", strings.Join(names, ", "), input["projectId"]))
+			data = map[string]interface{}{"createProjectV2Field": map[string]interface{}{"projectV2Field": field}}
+		} else {
+			f.calls = append(f.calls, fmt.Sprintf("update field %s: %s", input["fieldId"], strings.Join(names, ", ")))
+			data = map[string]interface{}{"updateProjectV2Field": map[string]interface{}{"projectV2Field": field}}
+		}
+	case strings.Contains(q, "items(first"):
+		data = map[string]interface{}{"node": map[string]interface{}{"items": map[string]interface{}{
+			"pageInfo": map[string]interface{}{"hasNextPage": false},
+			"nodes":    f.items[req.Variables["project"].(string)],
+		}}}
+	case strings.Contains(q, "addProjectV2ItemById("):
+		f.calls = append(f.calls, fmt.Sprintf("add %s to %s", input["contentId"], input["projectId"]))
+		data = map[string]interface{}{"addProjectV2ItemById": map[string]interface{}{"item": map[string]interface{}{"id": "new-" + input["contentId"].(string)}}}
+	case strings.Contains(q, "updateProjectV2ItemFieldValue("):
+		value := input["value"].(map[string]interface{})
+		f.calls = append(f.calls, fmt.Sprintf("set %s of %s to %s", input["itemId"], input["projectId"], value["singleSelectOptionId"]))
+	case strings.Contains(q, "deleteProjectV2Item("):
+		f.calls = append(f.calls, fmt.Sprintf("delete %s of %s", input["itemId"], input["projectId"]))
+	case strings.Contains(q, "updateProjectV2("):
+		f.calls = append(f.calls, fmt.Sprintf("close %s", input["projectId"]))
+	default:
+		f.t.Errorf("unexpected query %s", q)
+	}
+	json.NewEncoder(w).Encode(map[string]interface{}{"data": data})
+}
+
+func fakeItem(id, status, content string, number int, labels ...string) map[string]interface{} {
+	var nodes []map[string]interface{}
+	for _, lbl := range labels {
+		nodes = append(nodes, map[string]interface{}{"name": lbl})
+	}
+	return map[string]interface{}{
+		"id":               id,
+		"fieldValueByName": map[string]interface{}{"name": status},
+		"content": map[string]interface{}{
+			"id":     content,
+			"number": number,
+			"labels": map[string]interface{}{"nodes": nodes},
+		},
+	}
+}
+
+func TestSyncProjectsV2(t *testing.T) {
+	f := &fakeProjectsV2{
+		t: t,
+		projects: []map[string]interface{}{{
+			"id":    "P1",
+			"title": "1.14.3",
+			"field": map[string]interface{}{
+				"id": "F1",
+				// The done status is missing.
+				"options": []map[string]interface{}{
+					{"id": "needs", "name": "Needs backport from main", "color": "RED"},
+					{"id": "pending", "name": "Backport pending to v1.14", "color": "YELLOW"},
+				},
+			},
+		}},
+		items: map[string][]map[string]interface{}{
+			"P1": {
+				fakeItem("I1", "Needs backport from main", "PR1", 1, "backport-done/1.14"),
+				fakeItem("I2", "Needs backport from main", "PR2", 2),
+				fakeItem("I3", "Backport pending to v1.14", "PR3", 3, "backport-pending/1.14"),
+				// Not a PR.
+				fakeItem("I4", "Needs backport from main", "", 0),
+			},
+		},
+	}
+	srv := httptest.NewServer(f)
+	defer srv.Close()
+	ghClient := gh.NewClient(nil)
+	ghClient.BaseURL, _ = url.Parse(srv.URL + "/")
+
+	pm := NewProjectManagementV2(ghClient, "cilium", "cilium")
+	p, _ := profile.Get(profile.Default)
+	if err := pm.SyncProjects(context.Background(), p, "1.14.3", "1.14.4", &MovePendingPolicy{Force: true}); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"update field F1: needs=Needs backport from main, pending=Backport pending to v1.14, new2=Backport done to v1.14",
+		"create project 1.14.4",
+		"create field new0=Needs backport from main, new1=Backport pending to v1.14, new2=Backport done to v1.14 in P2",
+		"set I1 of P1 to new2",
+		"add PR2 to P2",
+		"set new-PR2 of P2 to new0",
+		"delete I2 of P1",
+		"add PR3 to P2",
+		"set new-PR3 of P2 to new1",
+		"delete I3 of P1",
+		"close P1",
+	}
+	if !reflect.DeepEqual(f.calls, want) {
+		t.Errorf("got calls:\n%s\nwant:\n%s", strings.Join(f.calls, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestSyncProjectsV2NoCurrentProject(t *testing.T) {
+	f := &fakeProjectsV2{t: t}
+	srv := httptest.NewServer(f)
+	defer srv.Close()
+	ghClient := gh.NewClient(nil)
+	ghClient.BaseURL, _ = url.Parse(srv.URL + "/")
+
+	pm := NewProjectManagementV2(ghClient, "cilium", "cilium")
+	p, _ := profile.Get(profile.Default)
+	err := pm.SyncProjects(context.Background(), p, "1.14.3", "1.14.4", &MovePendingPolicy{Force: true})
+	if err == nil || err.Error() != `current project "1.14.3" not found` {
+		t.Errorf("got error %v, want the current project not found", err)
+	}
+	if len(f.calls) != 0 {
+		t.Errorf("got mutations %v, want none", f.calls)
+	}
+}
+
+func TestFindProjectV2(t *testing.T) {
+	f := &fakeProjectsV2{t: t, projects: []map[string]interface{}{
+		{"id": "P1", "title": "1.14.1"},
+		{"id": "P2", "title": "1.14", "closed": true},
+		{"id": "P3", "title": "1.14.2"},
+		{"id": "P4", "title": "1.14"},
+	}}
+	srv := httptest.NewServer(f)
+	defer srv.Close()
+	ghClient := gh.NewClient(nil)
+	ghClient.BaseURL, _ = url.Parse(srv.URL + "/")
+	pm := NewProjectManagementV2(ghClient, "cilium", "cilium")
+
+	// The open project is on the second page of the results.
+	proj, err := pm.findProject(context.Background(), "1.14", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if proj == nil || proj.ID != "P4" {
+		t.Errorf("got project %+v, want P4", proj)
+	}
+	proj, err = pm.findProject(context.Background(), "1.14.3", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if proj != nil {
+		t.Errorf("got project %+v for a missing title", proj)
+	}
+}
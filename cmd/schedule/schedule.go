@@ -0,0 +1,227 @@
+// Copyright 2021 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schedule
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	gh "github.com/google/go-github/v50/github"
+	flag "github.com/spf13/pflag"
+
+	"github.com/cilium/release/pkg/config"
+	"github.com/cilium/release/pkg/github"
+	"github.com/cilium/release/pkg/types"
+	"github.com/cilium/release/pkg/version"
+)
+
+const dateFormat = "2006-01-02"
+
+// Release is an upcoming release of a branch.
+type Release struct {
+	Branch          string    `json:"branch"`
+	LastRelease     string    `json:"lastRelease"`
+	LastReleaseDate time.Time `json:"lastReleaseDate"`
+	NextRelease     string    `json:"nextRelease"`
+	NextReleaseDate time.Time `json:"nextReleaseDate"`
+	Overdue         bool      `json:"overdue"`
+	// EOL is the end of life date of the branch, if configured.
+	EOL *time.Time `json:"eol,omitempty"`
+}
+
+// Schedule contains the next minor release and the next patch release of
+// each maintained branch.
+type Schedule struct {
+	Minor   *Release  `json:"minor,omitempty"`
+	Patches []Release `json:"patches"`
+}
+
+type publishedRelease struct {
+	version version.Version
+	date    time.Time
+}
+
+// Command implements the 'schedule' subcommand.
+func Command(ctx context.Context, ghClient *gh.Client, args []string) error {
+	var (
+		cfgFile  string
+		repoName string
+		output   string
+	)
+	fs := flag.NewFlagSet("schedule", flag.ContinueOnError)
+	fs.StringVar(&cfgFile, "config", "release.yaml", "Configuration file containing the release cadence")
+	fs.StringVar(&repoName, "repo", "cilium/cilium", "GitHub organization and repository names separated by a slash")
+	fs.StringVar(&output, "output", "text", "Output format, one of 'text', 'json' or 'markdown'")
+	if err := types.ParseFlags(fs, "schedule", args); err != nil {
+		return err
+	}
+
+	owner, repo, err := types.SplitRepoName(repoName)
+	if err != nil {
+		return err
+	}
+	cfg, err := config.Load(cfgFile)
+	if err != nil {
+		return fmt.Errorf("unable to load configuration: %w", err)
+	}
+
+	ghReleases, err := github.ListReleases(ctx, ghClient, owner, repo)
+	if err != nil {
+		return fmt.Errorf("unable to list releases: %w", err)
+	}
+	var releases []publishedRelease
+	for _, r := range ghReleases {
+		if r.GetDraft() {
+			continue
+		}
+		v, err := version.Parse(r.GetTagName())
+		if err != nil || v.IsPrerelease() {
+			continue
+		}
+		releases = append(releases, publishedRelease{
+			version: v,
+			date:    r.GetPublishedAt().Time,
+		})
+	}
+
+	s, err := computeSchedule(cfg.Schedule, releases, time.Now())
+	if err != nil {
+		return err
+	}
+	return s.write(os.Stdout, output)
+}
+
+// computeSchedule computes the date of the next release of each maintained
+// branch based on the date of its last release and the configured cadence.
+func computeSchedule(cfg config.Schedule, releases []publishedRelease, now time.Time) (*Schedule, error) {
+	s := &Schedule{}
+
+	var lastMinor *publishedRelease
+	for i, r := range releases {
+		if r.version.Patch != 0 {
+			continue
+		}
+		if lastMinor == nil || r.version.Compare(lastMinor.version) > 0 {
+			lastMinor = &releases[i]
+		}
+	}
+	if lastMinor != nil && cfg.MinorCadenceDays > 0 {
+		next := version.Version{Major: lastMinor.version.Major, Minor: lastMinor.version.Minor + 1}
+		s.Minor = newRelease(lastMinor.version.MinorString(), *lastMinor, next, cfg.MinorCadenceDays, now)
+		s.Minor.Branch = "main"
+	}
+
+	if cfg.PatchCadenceDays <= 0 {
+		return s, nil
+	}
+	for _, branch := range cfg.Branches {
+		branchVersion, err := version.Parse(branch)
+		if err != nil {
+			return nil, fmt.Errorf("invalid branch in configuration: %w", err)
+		}
+		var last *publishedRelease
+		for i, r := range releases {
+			if !r.version.SameMinor(branchVersion) {
+				continue
+			}
+			if last == nil || r.version.Compare(last.version) > 0 {
+				last = &releases[i]
+			}
+		}
+		if last == nil {
+			continue
+		}
+		next := last.version
+		next.Patch++
+		r := newRelease(branchVersion.MinorString(), *last, next, cfg.PatchCadenceDays, now)
+		eol, ok, err := cfg.EOLDate(r.Branch)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			r.EOL = &eol
+		}
+		s.Patches = append(s.Patches, *r)
+	}
+	return s, nil
+}
+
+func newRelease(branch string, last publishedRelease, next version.Version, cadenceDays int, now time.Time) *Release {
+	nextDate := last.date.AddDate(0, 0, cadenceDays)
+	return &Release{
+		Branch:          branch,
+		LastRelease:     "v" + last.version.String(),
+		LastReleaseDate: last.date,
+		NextRelease:     "v" + next.String(),
+		NextReleaseDate: nextDate,
+		Overdue:         now.After(nextDate),
+	}
+}
+
+func (s *Schedule) releases() []Release {
+	var releases []Release
+	if s.Minor != nil {
+		releases = append(releases, *s.Minor)
+	}
+	return append(releases, s.Patches...)
+}
+
+func (s *Schedule) write(w io.Writer, output string) error {
+	switch output {
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(s)
+	case "markdown":
+		fmt.Fprintln(w, "| Branch | Last release | Next release | Date | Overdue | EOL |")
+		fmt.Fprintln(w, "|--------|--------------|--------------|------|---------|-----|")
+		for _, r := range s.releases() {
+			fmt.Fprintf(w, "| %s | %s (%s) | %s | %s | %s | %s |\n",
+				r.Branch, r.LastRelease, r.LastReleaseDate.Format(dateFormat),
+				r.NextRelease, r.NextReleaseDate.Format(dateFormat), overdue(r.Overdue), eol(r.EOL))
+		}
+		return nil
+	case "text":
+		tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+		fmt.Fprintln(tw, "BRANCH\tLAST RELEASE\tNEXT RELEASE\tDATE\tOVERDUE\tEOL")
+		for _, r := range s.releases() {
+			fmt.Fprintf(tw, "%s\t%s (%s)\t%s\t%s\t%s\t%s\n",
+				r.Branch, r.LastRelease, r.LastReleaseDate.Format(dateFormat),
+				r.NextRelease, r.NextReleaseDate.Format(dateFormat), overdue(r.Overdue), eol(r.EOL))
+		}
+		return tw.Flush()
+	default:
+		return fmt.Errorf("unknown output format %q", output)
+	}
+}
+
+func eol(t *time.Time) string {
+	if t == nil {
+		return "-"
+	}
+	return t.Format(dateFormat)
+}
+
+func overdue(b bool) string {
+	if b {
+		return "yes"
+	}
+	return "no"
+}
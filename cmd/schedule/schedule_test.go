@@ -0,0 +1,75 @@
+// Copyright 2021 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schedule
+
+import (
+	"testing"
+	"time"
+
+	"github.com/cilium/release/pkg/config"
+	"github.com/cilium/release/pkg/version"
+)
+
+func Test_computeSchedule(t *testing.T) {
+	date := func(s string) time.Time {
+		d, _ := time.Parse(dateFormat, s)
+		return d
+	}
+	release := func(v, d string) publishedRelease {
+		ver, _ := version.Parse(v)
+		return publishedRelease{version: ver, date: date(d)}
+	}
+	releases := []publishedRelease{
+		release("1.14.1", "2023-08-10"),
+		release("1.14.0", "2023-07-20"),
+		release("1.13.5", "2023-06-01"),
+		release("1.13.0", "2023-01-15"),
+	}
+	cfg := config.Schedule{
+		PatchCadenceDays: 30,
+		MinorCadenceDays: 180,
+		Branches:         []string{"1.14", "1.13", "1.12"},
+		EOL:              map[string]string{"1.13": "2024-01-15"},
+	}
+
+	s, err := computeSchedule(cfg, releases, date("2023-08-20"))
+	if err != nil {
+		t.Fatalf("computeSchedule() error = %v", err)
+	}
+	if s.Minor == nil || s.Minor.NextRelease != "v1.15.0" || s.Minor.Overdue {
+		t.Errorf("computeSchedule() minor = %+v, want v1.15.0 not overdue", s.Minor)
+	}
+	if len(s.Patches) != 2 {
+		t.Fatalf("computeSchedule() patches = %+v, want 2 patches", s.Patches)
+	}
+	if p := s.Patches[0]; p.NextRelease != "v1.14.2" || !p.NextReleaseDate.Equal(date("2023-09-09")) || p.Overdue {
+		t.Errorf("computeSchedule() patch = %+v, want v1.14.2 on 2023-09-09 not overdue", p)
+	}
+	if p := s.Patches[1]; p.NextRelease != "v1.13.6" || !p.Overdue {
+		t.Errorf("computeSchedule() patch = %+v, want v1.13.6 overdue", p)
+	}
+	if p := s.Patches[0]; p.EOL != nil {
+		t.Errorf("computeSchedule() patch = %+v, want no EOL", p)
+	}
+	if p := s.Patches[1]; p.EOL == nil || !p.EOL.Equal(date("2024-01-15")) {
+		t.Errorf("computeSchedule() patch = %+v, want EOL on 2024-01-15", p)
+	}
+	if err := cfg.CheckEOL("1.13", date("2023-08-20")); err != nil {
+		t.Errorf("CheckEOL() before EOL error = %v", err)
+	}
+	if err := cfg.CheckEOL("1.13", date("2024-01-15")); err == nil {
+		t.Errorf("CheckEOL() after EOL expected an error")
+	}
+}
@@ -0,0 +1,174 @@
+// Copyright 2021 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package serve
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	gh "github.com/google/go-github/v50/github"
+	flag "github.com/spf13/pflag"
+
+	"github.com/cilium/release/pkg/config"
+	"github.com/cilium/release/pkg/types"
+)
+
+// Command implements the 'serve' subcommand.
+func Command(ctx context.Context, ghClient *gh.Client, args []string) error {
+	var (
+		addr             string
+		repoName         string
+		upstreamRepoName string
+		stateDir         string
+		lastStable       []string
+		cfgFile          string
+		createProjects   bool
+		archiveProjects  bool
+		projectsV2       bool
+	)
+	fs := flag.NewFlagSet("serve", flag.ContinueOnError)
+	fs.StringVar(&addr, "addr", ":8080", "Address to listen on")
+	fs.StringVar(&repoName, "repo", "cilium/cilium", "GitHub organization and repository names separated by a slash")
+	fs.StringVar(&upstreamRepoName, "upstream-repo", "", "GitHub organization and repository names, separated by a slash, of the upstream PRs referenced by backport PRs, if different from --repo")
+	fs.StringVar(&stateDir, "state-dir", "serve-state", "Directory where the unreleased PRs of each branch are stored")
+	fs.StringSliceVar(&lastStable, "last-stable", nil, "Stable versions (e.g.: '1.13') whose backported PRs are left out of the notes of the main branch")
+	fs.BoolVar(&createProjects, "create-projects", false, "Create the project of the next patch version when a release is published")
+	fs.BoolVar(&archiveProjects, "archive-projects", false, "Archive the done backports of the project of the released version when a release is published")
+	fs.BoolVar(&projectsV2, "projects-v2", false, "Use GitHub ProjectV2s instead of classic projects with --create-projects and --archive-projects")
+	fs.StringVar(&cfgFile, "config", "", "Configuration file containing the column templates of the projects created with --create-projects")
+	if err := types.ParseFlags(fs, "serve", args); err != nil {
+		return err
+	}
+	var columns []string
+	if len(cfgFile) != 0 {
+		c, err := config.Load(cfgFile)
+		if err != nil {
+			return fmt.Errorf("unable to load configuration: %w", err)
+		}
+		columns = c.Projects.Columns
+	}
+	// Without a secret, the signature of the payloads isn't verified and
+	// anyone could drive the server.
+	secret := os.Getenv("GITHUB_WEBHOOK_SECRET")
+	if len(secret) == 0 {
+		return fmt.Errorf("GITHUB_WEBHOOK_SECRET must be set to the secret of the webhook")
+	}
+	cfg := types.Config{
+		RepoName:         repoName,
+		UpstreamRepoName: upstreamRepoName,
+		LastStable:       lastStable,
+	}
+	var err error
+	cfg.Owner, cfg.Repo, err = types.SplitRepoName(repoName)
+	if err != nil {
+		return err
+	}
+	cfg.UpstreamOwner, cfg.UpstreamRepo = cfg.Owner, cfg.Repo
+	if len(upstreamRepoName) != 0 {
+		cfg.UpstreamOwner, cfg.UpstreamRepo, err = types.SplitRepoName(upstreamRepoName)
+		if err != nil {
+			return err
+		}
+	}
+	if err := os.MkdirAll(stateDir, 0755); err != nil {
+		return err
+	}
+
+	s := &server{
+		ghClient: ghClient,
+		cfg:      cfg,
+		stateDir: stateDir,
+		secret:   []byte(secret),
+
+		createProjects:  createProjects,
+		archiveProjects: archiveProjects,
+		projectsV2:      projectsV2,
+		columns:         columns,
+	}
+	srv := &http.Server{
+		Addr:              addr,
+		Handler:           s.handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		srv.Shutdown(shutdownCtx)
+	}()
+	fmt.Fprintf(os.Stderr, "Listening on %s\n", addr)
+	err = srv.ListenAndServe()
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+	return err
+}
+
+func (s *server) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/webhook", s.handleWebhook)
+	mux.HandleFunc("/branches", s.handleBranches)
+	mux.HandleFunc("/notes/", s.handleNotes)
+	return mux
+}
+
+func (s *server) handleWebhook(w http.ResponseWriter, r *http.Request) {
+	payload, err := gh.ValidatePayload(r, s.secret)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	event, err := gh.ParseWebHook(gh.WebHookType(r), payload)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := s.handleEvent(r.Context(), event); err != nil {
+		fmt.Fprintf(os.Stderr, "Unable to handle %s event: %s\n", gh.WebHookType(r), err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *server) handleBranches(w http.ResponseWriter, r *http.Request) {
+	branches, err := s.branches()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(branches)
+}
+
+func (s *server) handleNotes(w http.ResponseWriter, r *http.Request) {
+	branch := r.URL.Path[len("/notes/"):]
+	if len(branch) == 0 {
+		http.NotFound(w, r)
+		return
+	}
+	notes, err := s.notes(branch)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/markdown; charset=utf-8")
+	w.Write(notes)
+}
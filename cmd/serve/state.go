@@ -0,0 +1,284 @@
+// Copyright 2021 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package serve
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	gh "github.com/google/go-github/v50/github"
+
+	"github.com/cilium/release/cmd/changelog"
+	"github.com/cilium/release/cmd/projects"
+	"github.com/cilium/release/pkg/github"
+	"github.com/cilium/release/pkg/types"
+	"github.com/cilium/release/pkg/version"
+)
+
+type server struct {
+	ghClient *gh.Client
+	cfg      types.Config
+	stateDir string
+	secret   []byte
+
+	// createProjects creates the project of the next patch version, with
+	// the given column templates, when a release is published.
+	createProjects bool
+	projectsV2     bool
+	columns        []string
+	// archiveProjects archives the done backports of the project of a
+	// version when its release is published.
+	archiveProjects bool
+
+	// mu serializes the updates of the branch states.
+	mu sync.Mutex
+}
+
+// branchState contains the PRs merged into a branch since its last release.
+type branchState struct {
+	BackportPRs  types.BackportPRs
+	PullRequests types.PullRequests
+	// MergedAt maps the PRs merged into the branch, either PullRequests or
+	// the backport PRs, to their merge time.
+	MergedAt map[int]time.Time
+}
+
+// stateFile returns the state file of the given branch, named after the
+// branch path-escaped so that it can be told back, see branches.
+func (s *server) stateFile(branch string) string {
+	return filepath.Join(s.stateDir, url.PathEscape(branch)+".json")
+}
+
+func (s *server) load(branch string) (*branchState, error) {
+	st := &branchState{
+		BackportPRs:  types.BackportPRs{},
+		PullRequests: types.PullRequests{},
+		MergedAt:     map[int]time.Time{},
+	}
+	data, err := os.ReadFile(s.stateFile(branch))
+	if os.IsNotExist(err) {
+		return st, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, st); err != nil {
+		return nil, err
+	}
+	return st, nil
+}
+
+func (s *server) store(branch string, st *branchState) error {
+	data, err := json.Marshal(st)
+	if err != nil {
+		return err
+	}
+	file := s.stateFile(branch)
+	tmp := file + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, file)
+}
+
+// branches returns the branches with a stored state.
+func (s *server) branches() ([]string, error) {
+	files, err := filepath.Glob(filepath.Join(s.stateDir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	branches := []string{}
+	for _, file := range files {
+		branch, err := url.PathUnescape(strings.TrimSuffix(filepath.Base(file), ".json"))
+		if err != nil {
+			continue
+		}
+		branches = append(branches, branch)
+	}
+	sort.Strings(branches)
+	return branches, nil
+}
+
+// notes returns the unreleased notes of the given branch.
+func (s *server) notes(branch string) ([]byte, error) {
+	s.mu.Lock()
+	st, err := s.load(branch)
+	s.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	cl := changelog.New(s.ghClient, s.cfg, st.BackportPRs, st.PullRequests)
+	return cl.Render(changelog.RenderOptions{})
+}
+
+// handleEvent updates the branch states with the given webhook event.
+func (s *server) handleEvent(ctx context.Context, event interface{}) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch e := event.(type) {
+	case *gh.PullRequestEvent:
+		pr := e.GetPullRequest()
+		merged := pr.GetMerged() && sameRepo(e.GetRepo(), s.cfg.Owner, s.cfg.Repo)
+		switch e.GetAction() {
+		case "closed":
+			if merged {
+				return s.addPR(ctx, pr)
+			}
+		case "labeled", "unlabeled", "edited":
+			if merged {
+				if err := s.addPR(ctx, pr); err != nil {
+					return err
+				}
+			}
+			// The PR can also be the upstream PR of backport PRs
+			// whose notes change with it.
+			return s.refreshUpstream(ctx, e.GetRepo(), pr.GetNumber())
+		}
+	case *gh.ReleaseEvent:
+		if e.GetAction() != "published" || e.GetRelease().GetPrerelease() || !sameRepo(e.GetRepo(), s.cfg.Owner, s.cfg.Repo) {
+			return nil
+		}
+		return s.release(ctx, e.GetRelease())
+	}
+	return nil
+}
+
+func sameRepo(repo *gh.Repository, owner, name string) bool {
+	return repo.GetOwner().GetLogin() == owner && repo.GetName() == name
+}
+
+// addPR adds, or updates, the given merged PR in the state of the branch
+// it was merged into.
+func (s *server) addPR(ctx context.Context, pr *gh.PullRequest) error {
+	branch := pr.GetBase().GetRef()
+	st, err := s.load(branch)
+	if err != nil {
+		return err
+	}
+	delete(st.PullRequests, pr.GetNumber())
+	delete(st.BackportPRs, pr.GetNumber())
+	err = github.AddPullRequest(ctx, s.ghClient, nil, s.cfg.UpstreamOwner, s.cfg.UpstreamRepo, nil, pr, st.BackportPRs, st.PullRequests)
+	if err != nil {
+		return err
+	}
+	st.MergedAt[pr.GetNumber()] = pr.GetMergedAt().Time
+	fmt.Fprintf(os.Stderr, "Updated PR %d of %s\n", pr.GetNumber(), branch)
+	return s.store(branch, st)
+}
+
+// refreshUpstream updates the given upstream PR in all branch states where
+// it was backported.
+func (s *server) refreshUpstream(ctx context.Context, repo *gh.Repository, number int) error {
+	if !sameRepo(repo, s.cfg.UpstreamOwner, s.cfg.UpstreamRepo) {
+		return nil
+	}
+	branches, err := s.branches()
+	if err != nil {
+		return err
+	}
+	for _, branch := range branches {
+		st, err := s.load(branch)
+		if err != nil {
+			return err
+		}
+		var backportPRs []int
+		for backportPR, upstreamPRs := range st.BackportPRs {
+			if _, ok := upstreamPRs[number]; ok {
+				backportPRs = append(backportPRs, backportPR)
+			}
+		}
+		if len(backportPRs) == 0 {
+			continue
+		}
+		sort.Ints(backportPRs)
+		for _, backportPR := range backportPRs {
+			pr, _, err := s.ghClient.PullRequests.Get(ctx, s.cfg.Owner, s.cfg.Repo, backportPR)
+			if err != nil {
+				return err
+			}
+			delete(st.BackportPRs, backportPR)
+			err = github.AddPullRequest(ctx, s.ghClient, nil, s.cfg.UpstreamOwner, s.cfg.UpstreamRepo, nil, pr, st.BackportPRs, st.PullRequests)
+			if err != nil {
+				return err
+			}
+		}
+		if err := s.store(branch, st); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// release removes from the state of the branch of the given release all PRs
+// merged before the release was created.
+func (s *server) release(ctx context.Context, release *gh.RepositoryRelease) error {
+	branch := release.GetTargetCommitish()
+	ver, verErr := version.Parse(release.GetTagName())
+	if verErr == nil {
+		// Releases are tagged on their stable branch.
+		branch = "v" + ver.MinorString()
+	}
+	st, err := s.load(branch)
+	if err != nil {
+		return err
+	}
+	releasedAt := release.GetCreatedAt().Time
+	removed := 0
+	for number, mergedAt := range st.MergedAt {
+		if mergedAt.After(releasedAt) {
+			continue
+		}
+		delete(st.PullRequests, number)
+		delete(st.BackportPRs, number)
+		delete(st.MergedAt, number)
+		removed++
+	}
+	fmt.Fprintf(os.Stderr, "Release %s published, removed %d PRs from %s\n", release.GetTagName(), removed, branch)
+	if err := s.store(branch, st); err != nil {
+		return err
+	}
+	if verErr != nil {
+		return nil
+	}
+	if s.archiveProjects {
+		// Archived items are skipped, a redelivered event archives
+		// nothing more.
+		report, err := projects.ArchiveReleasedProject(ctx, s.ghClient, s.cfg.Owner, s.cfg.Repo, ver.String(), s.projectsV2)
+		if err != nil {
+			return fmt.Errorf("unable to archive project %q: %w", ver.String(), err)
+		}
+		fmt.Fprintf(os.Stderr, "Archived %d items of project %q: %s\n", len(report.Archived), ver.String(), report.URL)
+	}
+	if !s.createProjects {
+		return nil
+	}
+	// Creating the project is idempotent, a redelivered event only creates
+	// what is missing.
+	next, url, err := projects.CreateNextProject(ctx, s.ghClient, s.cfg.Owner, s.cfg.Repo, ver.String(), s.columns, s.projectsV2)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "Project for %q: %s\n", next, url)
+	return nil
+}
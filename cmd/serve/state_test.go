@@ -0,0 +1,274 @@
+// Copyright 2021 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package serve
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	gh "github.com/google/go-github/v50/github"
+
+	"github.com/cilium/release/pkg/types"
+)
+
+func mergedPREvent(number int, note string, mergedAt time.Time) *gh.PullRequestEvent {
+	return &gh.PullRequestEvent{
+		Action: gh.String("closed"),
+		Repo: &gh.Repository{
+			Owner: &gh.User{Login: gh.String("cilium")},
+			Name:  gh.String("cilium"),
+		},
+		PullRequest: &gh.PullRequest{
+			Number:   gh.Int(number),
+			Title:    gh.String(note),
+			Merged:   gh.Bool(true),
+			MergedAt: &gh.Timestamp{Time: mergedAt},
+			Base:     &gh.PullRequestBranch{Ref: gh.String("v1.14")},
+			User:     &gh.User{Login: gh.String("alice")},
+			Labels:   []*gh.Label{{Name: gh.String("release-note/bug")}},
+		},
+	}
+}
+
+func TestServerState(t *testing.T) {
+	s := &server{
+		cfg: types.Config{
+			Owner:         "cilium",
+			Repo:          "cilium",
+			UpstreamOwner: "cilium",
+			UpstreamRepo:  "cilium",
+		},
+		stateDir: t.TempDir(),
+	}
+	ctx := context.Background()
+	released := time.Date(2023, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	for _, event := range []interface{}{
+		mergedPREvent(1, "Fix foo", released.Add(-time.Hour)),
+		mergedPREvent(2, "Fix bar", released.Add(time.Hour)),
+	} {
+		if err := s.handleEvent(ctx, event); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	branches, err := s.branches()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(branches, []string{"v1.14"}) {
+		t.Errorf("branches() = %v, want [v1.14]", branches)
+	}
+
+	notes, err := s.notes("v1.14")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "Summary of Changes\n" +
+		"------------------\n" +
+		"\n" +
+		"**Bugfixes:**\n" +
+		"* Fix bar (#2, @alice)\n" +
+		"* Fix foo (#1, @alice)\n"
+	if string(notes) != want {
+		t.Errorf("notes() =\n%s\nwant:\n%s", notes, want)
+	}
+
+	err = s.handleEvent(ctx, &gh.ReleaseEvent{
+		Action: gh.String("published"),
+		Repo: &gh.Repository{
+			Owner: &gh.User{Login: gh.String("cilium")},
+			Name:  gh.String("cilium"),
+		},
+		Release: &gh.RepositoryRelease{
+			TagName:   gh.String("v1.14.1"),
+			CreatedAt: &gh.Timestamp{Time: released},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	notes, err = s.notes("v1.14")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = "Summary of Changes\n" +
+		"------------------\n" +
+		"\n" +
+		"**Bugfixes:**\n" +
+		"* Fix bar (#2, @alice)\n"
+	if string(notes) != want {
+		t.Errorf("notes() after release =\n%s\nwant:\n%s", notes, want)
+	}
+}
+
+func TestReleaseCreatesProject(t *testing.T) {
+	var (
+		created string
+		columns []string
+	)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/cilium/cilium/projects", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			var opts gh.ProjectOptions
+			json.NewDecoder(r.Body).Decode(&opts)
+			created = opts.GetName()
+			json.NewEncoder(w).Encode(gh.Project{
+				ID:      gh.Int64(1),
+				Name:    opts.Name,
+				HTMLURL: gh.String("https://github.com/cilium/cilium/projects/1"),
+			})
+			return
+		}
+		w.Write([]byte("[]"))
+	})
+	mux.HandleFunc("/projects/1/columns", func(w http.ResponseWriter, r *http.Request) {
+		var opts gh.ProjectColumnOptions
+		json.NewDecoder(r.Body).Decode(&opts)
+		columns = append(columns, opts.Name)
+		json.NewEncoder(w).Encode(gh.ProjectColumn{ID: gh.Int64(int64(len(columns))), Name: &opts.Name})
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+	ghClient := gh.NewClient(nil)
+	ghClient.BaseURL, _ = url.Parse(srv.URL + "/")
+
+	s := &server{
+		ghClient:       ghClient,
+		cfg:            types.Config{Owner: "cilium", Repo: "cilium"},
+		stateDir:       t.TempDir(),
+		createProjects: true,
+	}
+	err := s.handleEvent(context.Background(), &gh.ReleaseEvent{
+		Action: gh.String("published"),
+		Repo: &gh.Repository{
+			Owner: &gh.User{Login: gh.String("cilium")},
+			Name:  gh.String("cilium"),
+		},
+		Release: &gh.RepositoryRelease{
+			TagName:   gh.String("v1.14.1"),
+			CreatedAt: &gh.Timestamp{Time: time.Now()},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if created != "1.14.2" {
+		t.Errorf("created project %q, want 1.14.2", created)
+	}
+	want := []string{"Needs backport from main", "Backport pending to v1.14", "Backport done to v1.14"}
+	if !reflect.DeepEqual(columns, want) {
+		t.Errorf("created columns %v, want %v", columns, want)
+	}
+}
+
+func TestReleaseArchivesProject(t *testing.T) {
+	var archived []string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/cilium/cilium/projects", func(w http.ResponseWriter, r *http.Request) {
+		// The project of the release is on the second page.
+		if r.URL.Query().Get("page") != "2" {
+			w.Header().Set("Link", `<`+r.URL.Path+`?page=2>; rel="next"`)
+			json.NewEncoder(w).Encode([]*gh.Project{{ID: gh.Int64(1), Name: gh.String("1.13.5")}})
+			return
+		}
+		json.NewEncoder(w).Encode([]*gh.Project{{
+			ID:      gh.Int64(2),
+			Name:    gh.String("1.14.1"),
+			State:   gh.String("open"),
+			HTMLURL: gh.String("https://github.com/cilium/cilium/projects/2"),
+		}})
+	})
+	mux.HandleFunc("/projects/2/columns", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode([]*gh.ProjectColumn{
+			{ID: gh.Int64(20), Name: gh.String("Backport pending to v1.14")},
+			{ID: gh.Int64(21), Name: gh.String("Backport done to v1.14")},
+		})
+	})
+	mux.HandleFunc("/projects/columns/21/cards", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode([]*gh.ProjectCard{{
+			ID:         gh.Int64(210),
+			ContentURL: gh.String("https://api.github.com/repos/cilium/cilium/issues/123"),
+		}})
+	})
+	mux.HandleFunc("/projects/columns/cards/", func(w http.ResponseWriter, r *http.Request) {
+		archived = append(archived, r.Method+" "+r.URL.Path)
+		w.Write([]byte("{}"))
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+	ghClient := gh.NewClient(nil)
+	ghClient.BaseURL, _ = url.Parse(srv.URL + "/")
+
+	s := &server{
+		ghClient:        ghClient,
+		cfg:             types.Config{Owner: "cilium", Repo: "cilium"},
+		stateDir:        t.TempDir(),
+		archiveProjects: true,
+	}
+	err := s.handleEvent(context.Background(), &gh.ReleaseEvent{
+		Action: gh.String("published"),
+		Repo: &gh.Repository{
+			Owner: &gh.User{Login: gh.String("cilium")},
+			Name:  gh.String("cilium"),
+		},
+		Release: &gh.RepositoryRelease{
+			TagName:   gh.String("v1.14.1"),
+			CreatedAt: &gh.Timestamp{Time: time.Now()},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"PATCH /projects/columns/cards/210"}
+	if !reflect.DeepEqual(archived, want) {
+		t.Errorf("archived cards %v, want %v", archived, want)
+	}
+}
+
+func TestStateFile(t *testing.T) {
+	s := &server{stateDir: t.TempDir()}
+	want := []string{"feature/foo_bar", "feature_foo/bar", "v1.14"}
+	for _, branch := range want {
+		if filepath.Dir(s.stateFile(branch)) != s.stateDir {
+			t.Errorf("state file %s of %s outside of the state directory", s.stateFile(branch), branch)
+		}
+		if err := s.store(branch, &branchState{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	got, err := s.branches()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got branches %v, want %v", got, want)
+	}
+}
+
+func TestCommandWithoutSecret(t *testing.T) {
+	t.Setenv("GITHUB_WEBHOOK_SECRET", "")
+	if err := Command(context.Background(), nil, []string{"--state-dir", t.TempDir()}); err == nil {
+		t.Error("server started without a webhook secret")
+	}
+}
@@ -0,0 +1,70 @@
+// Copyright 2021 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package state
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	gh "github.com/google/go-github/v50/github"
+	flag "github.com/spf13/pflag"
+
+	"github.com/cilium/release/pkg/persistence"
+	"github.com/cilium/release/pkg/types"
+)
+
+const usage = "usage: state validate <state-file>... | state schema"
+
+// Command implements the 'state' subcommand.
+func Command(ctx context.Context, ghClient *gh.Client, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf(usage)
+	}
+	fs := flag.NewFlagSet("state "+args[0], flag.ContinueOnError)
+	if err := types.ParseFlags(fs, "state "+args[0], args[1:]); err != nil {
+		return err
+	}
+	switch args[0] {
+	case "schema":
+		_, err := os.Stdout.Write(persistence.Schema)
+		return err
+	case "validate":
+		if fs.NArg() == 0 {
+			return fmt.Errorf(usage)
+		}
+		invalid := 0
+		for _, file := range fs.Args() {
+			data, err := os.ReadFile(file)
+			if err != nil {
+				return err
+			}
+			errs := persistence.Validate(data)
+			for _, err := range errs {
+				fmt.Printf("%s: %s\n", file, err)
+			}
+			if len(errs) != 0 {
+				invalid++
+				continue
+			}
+			fmt.Printf("%s: OK\n", file)
+		}
+		if invalid != 0 {
+			return fmt.Errorf("%d of the %d state files are invalid", invalid, fs.NArg())
+		}
+		return nil
+	}
+	return fmt.Errorf(usage)
+}
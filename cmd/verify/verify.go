@@ -0,0 +1,45 @@
+// Copyright 2021 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package verify implements the checks run before a release is published.
+package verify
+
+import (
+	"context"
+	"fmt"
+
+	gh "github.com/google/go-github/v50/github"
+
+	"github.com/cilium/release/cmd/changelog"
+	"github.com/cilium/release/cmd/images"
+)
+
+const usage = "usage: verify {coverage|images|vulnerabilities} [flags]"
+
+// Command implements the 'verify' subcommand.
+func Command(ctx context.Context, ghClient *gh.Client, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf(usage)
+	}
+	switch args[0] {
+	case "coverage":
+		return changelog.VerifyCommand(ctx, ghClient, args)
+	case "images":
+		return images.TagsCommand(ctx, ghClient, args[1:])
+	case "vulnerabilities":
+		return images.VulnerabilitiesCommand(ctx, ghClient, args[1:])
+	default:
+		return fmt.Errorf(usage)
+	}
+}
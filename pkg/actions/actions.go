@@ -0,0 +1,83 @@
+// Copyright 2021 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package actions implements the GitHub Actions workflow commands and
+// environment files used to report the results of a run.
+package actions
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+const (
+	stepSummaryEnv = "GITHUB_STEP_SUMMARY"
+	outputEnv      = "GITHUB_OUTPUT"
+)
+
+// escape escapes the data of a workflow command.
+func escape(s string) string {
+	s = strings.ReplaceAll(s, "%", "%25")
+	s = strings.ReplaceAll(s, "\r", "%0D")
+	return strings.ReplaceAll(s, "\n", "%0A")
+}
+
+// Warning returns the workflow command that annotates msg as a warning.
+func Warning(msg string) string {
+	return "::warning::" + escape(strings.TrimSpace(msg))
+}
+
+// AppendStepSummary appends the given markdown to the summary of the step.
+func AppendStepSummary(markdown string) error {
+	return appendEnvFile(stepSummaryEnv, markdown)
+}
+
+// SetOutput sets an output of the step.
+func SetOutput(name, value string) error {
+	if !strings.Contains(value, "\n") {
+		return appendEnvFile(outputEnv, fmt.Sprintf("%s=%s\n", name, value))
+	}
+	delimiter := "EOF"
+	for strings.Contains(value, delimiter) {
+		delimiter += "_EOF"
+	}
+	return appendEnvFile(outputEnv, fmt.Sprintf("%s<<%s\n%s\n%s\n", name, delimiter, value, delimiter))
+}
+
+func appendEnvFile(env, content string) error {
+	file := os.Getenv(env)
+	if len(file) == 0 {
+		return fmt.Errorf("%s is not set, not running in GitHub Actions?", env)
+	}
+	f, err := os.OpenFile(file, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	_, err = f.WriteString(content)
+	if err2 := f.Close(); err == nil {
+		err = err2
+	}
+	return err
+}
+
+// RunURL returns the URL of the current workflow run, or an empty string if
+// not running in GitHub Actions.
+func RunURL() string {
+	server, repo, id := os.Getenv("GITHUB_SERVER_URL"), os.Getenv("GITHUB_REPOSITORY"), os.Getenv("GITHUB_RUN_ID")
+	if len(server) == 0 || len(repo) == 0 || len(id) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%s/%s/actions/runs/%s", server, repo, id)
+}
@@ -0,0 +1,62 @@
+// Copyright 2021 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package actions
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWarning(t *testing.T) {
+	got := Warning("WARNING: PR not found for commit abc!\n100%\r")
+	want := "::warning::WARNING: PR not found for commit abc!%0A100%25"
+	if got != want {
+		t.Errorf("Warning() = %q, want %q", got, want)
+	}
+}
+
+func TestSetOutput(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "output")
+	t.Setenv(outputEnv, file)
+
+	if err := SetOutput("changes", "42"); err != nil {
+		t.Fatal(err)
+	}
+	if err := SetOutput("notes", "foo\nEOF\nbar"); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "changes=42\n" +
+		"notes<<EOF_EOF\n" +
+		"foo\n" +
+		"EOF\n" +
+		"bar\n" +
+		"EOF_EOF\n"
+	if string(got) != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestSetOutputOutsideActions(t *testing.T) {
+	t.Setenv(outputEnv, "")
+	if err := SetOutput("changes", "42"); err == nil {
+		t.Errorf("SetOutput() succeeded without %s", outputEnv)
+	}
+}
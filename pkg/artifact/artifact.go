@@ -0,0 +1,113 @@
+// Copyright 2021 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package artifact lets the files generated by the release tool be verified
+// by the ones downloading them.
+package artifact
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/cilium/release/pkg/report"
+)
+
+const (
+	SignGPG    = "gpg"
+	SignCosign = "cosign"
+)
+
+// AddChecksum adds the SHA256 checksum of file to sumsFile, in the format of
+// sha256sum(1), replacing the previous checksum of file if any. The file is
+// named relatively to the directory of sumsFile.
+func AddChecksum(sumsFile, file string) error {
+	b, err := os.ReadFile(file)
+	if err != nil {
+		return err
+	}
+	sum := sha256.Sum256(b)
+
+	name, err := filepath.Rel(filepath.Dir(sumsFile), file)
+	if err != nil {
+		name = filepath.Base(file)
+	}
+	name = filepath.ToSlash(name)
+
+	sums := map[string]string{}
+	existing, err := os.ReadFile(sumsFile)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	for _, line := range strings.Split(string(existing), "\n") {
+		fields := strings.SplitN(line, "  ", 2)
+		if len(fields) != 2 {
+			continue
+		}
+		sums[fields[1]] = fields[0]
+	}
+	sums[name] = hex.EncodeToString(sum[:])
+
+	names := make([]string, 0, len(sums))
+	for n := range sums {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	var buf bytes.Buffer
+	for _, n := range names {
+		fmt.Fprintf(&buf, "%s  %s\n", sums[n], n)
+	}
+	if err := os.WriteFile(sumsFile, buf.Bytes(), 0644); err != nil {
+		return err
+	}
+	report.Written(sumsFile)
+	return nil
+}
+
+// Sign creates a detached signature of file with the given method, SignGPG
+// or SignCosign, and returns the files created. GPG uses the default key of
+// the user. Cosign signs keyless, i.e. with a short lived certificate
+// obtained through OIDC, and also stores the certificate.
+func Sign(method, file string) ([]string, error) {
+	var (
+		args  []string
+		files []string
+	)
+	switch method {
+	case SignGPG:
+		files = []string{file + ".asc"}
+		args = []string{"gpg", "--batch", "--yes", "--armor", "--detach-sign", "--output", files[0], file}
+	case SignCosign:
+		files = []string{file + ".sig", file + ".pem"}
+		args = []string{"cosign", "sign-blob", "--yes", "--output-signature", files[0], "--output-certificate", files[1], file}
+	default:
+		return nil, fmt.Errorf("unknown signing method %q", method)
+	}
+	var stderr bytes.Buffer
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("%s: %w: %s", strings.Join(args, " "), err, strings.TrimSpace(stderr.String()))
+	}
+	for _, f := range files {
+		report.Written(f)
+	}
+	return files, nil
+}
@@ -0,0 +1,128 @@
+// Copyright 2021 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package artifact
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestAddChecksum(t *testing.T) {
+	dir := t.TempDir()
+	sums := filepath.Join(dir, "SHA256SUMS")
+	write := func(name, content string) string {
+		file := filepath.Join(dir, name)
+		if err := os.WriteFile(file, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return file
+	}
+
+	notes := write("notes.md", "foo\n")
+	if err := AddChecksum(sums, notes); err != nil {
+		t.Fatal(err)
+	}
+	if err := AddChecksum(sums, write("ci.md", "bar\n")); err != nil {
+		t.Fatal(err)
+	}
+	// Regenerating a file replaces its checksum.
+	write("notes.md", "baz\n")
+	if err := AddChecksum(sums, notes); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := os.ReadFile(sums)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "7d865e959b2466918c9863afca942d0fb89d7c9ac0c99bafc3749504ded97730  ci.md\n" +
+		"bf07a7fbb825fc0aae7bf4a1177b2b31fcf8a3feeaf7092761e18c859ee52a9c  notes.md\n"
+	if string(got) != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestSignUnknownMethod(t *testing.T) {
+	if _, err := Sign("pgp", "notes.md"); err == nil {
+		t.Error("expected an error")
+	}
+}
+
+func TestWriteProvenance(t *testing.T) {
+	dir := t.TempDir()
+	notes := filepath.Join(dir, "notes.md")
+	if err := os.WriteFile(notes, []byte("foo\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	file := filepath.Join(dir, "notes.intoto.json")
+	finished := time.Date(2023, 7, 12, 9, 30, 0, 0, time.UTC)
+	err := WriteProvenance(file, []string{notes}, Build{
+		Parameters: map[string]string{"repository": "cilium/cilium", "base": "v1.14.2", "head": "v1.14.3"},
+		Source:     "git+https://github.com/cilium/cilium",
+		SourceSHA:  "abc123",
+		FinishedOn: finished,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{
+  "_type": "https://in-toto.io/Statement/v1",
+  "subject": [
+    {
+      "name": "notes.md",
+      "digest": {
+        "sha256": "b5bb9d8014a0f9b1d61e21e796d78dccdf1352f23cd32812f4850b878ae4944c"
+      }
+    }
+  ],
+  "predicateType": "https://slsa.dev/provenance/v1",
+  "predicate": {
+    "buildDefinition": {
+      "buildType": "https://github.com/cilium/release/changelog@v1",
+      "externalParameters": {
+        "base": "v1.14.2",
+        "head": "v1.14.3",
+        "repository": "cilium/cilium"
+      },
+      "resolvedDependencies": [
+        {
+          "uri": "git+https://github.com/cilium/cilium",
+          "digest": {
+            "gitCommit": "abc123"
+          }
+        }
+      ]
+    },
+    "runDetails": {
+      "builder": {
+        "id": "https://github.com/cilium/release"
+      },
+      "metadata": {
+        "finishedOn": "2023-07-12T09:30:00Z"
+      }
+    }
+  }
+}
+`
+	if string(got) != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
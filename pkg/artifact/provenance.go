@@ -0,0 +1,135 @@
+// Copyright 2021 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package artifact
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/cilium/release/pkg/report"
+)
+
+const (
+	statementType       = "https://in-toto.io/Statement/v1"
+	provenanceType      = "https://slsa.dev/provenance/v1"
+	provenanceBuilder   = "https://github.com/cilium/release"
+	provenanceBuildType = "https://github.com/cilium/release/changelog@v1"
+)
+
+// Build describes how the files the provenance is generated for were built.
+type Build struct {
+	// Parameters are the parameters of the build given by the user, e.g.
+	// the repository, base and head of the release notes.
+	Parameters map[string]string
+	// Source is the URI of the source the files were generated from, e.g.
+	// 'git+https://github.com/cilium/cilium', and SourceSHA the commit,
+	// if known.
+	Source    string
+	SourceSHA string
+	// BuilderID identifies who generated the files, e.g. the URL of the
+	// GitHub Actions workflow run.
+	BuilderID  string
+	StartedOn  time.Time
+	FinishedOn time.Time
+}
+
+type statement struct {
+	Type          string     `json:"_type"`
+	Subject       []resource `json:"subject"`
+	PredicateType string     `json:"predicateType"`
+	Predicate     predicate  `json:"predicate"`
+}
+
+type resource struct {
+	Name   string            `json:"name,omitempty"`
+	URI    string            `json:"uri,omitempty"`
+	Digest map[string]string `json:"digest,omitempty"`
+}
+
+type predicate struct {
+	BuildDefinition struct {
+		BuildType            string            `json:"buildType"`
+		ExternalParameters   map[string]string `json:"externalParameters"`
+		ResolvedDependencies []resource        `json:"resolvedDependencies,omitempty"`
+	} `json:"buildDefinition"`
+	RunDetails struct {
+		Builder struct {
+			ID string `json:"id"`
+		} `json:"builder"`
+		Metadata struct {
+			StartedOn  *time.Time `json:"startedOn,omitempty"`
+			FinishedOn *time.Time `json:"finishedOn,omitempty"`
+		} `json:"metadata"`
+	} `json:"runDetails"`
+}
+
+// WriteProvenance writes into file the SLSA provenance, as an in-toto
+// statement, of the given files built as described by b. The files are
+// named relatively to the directory of file.
+func WriteProvenance(file string, files []string, b Build) error {
+	var st statement
+	st.Type = statementType
+	st.PredicateType = provenanceType
+	for _, f := range files {
+		content, err := os.ReadFile(f)
+		if err != nil {
+			return err
+		}
+		sum := sha256.Sum256(content)
+		name, err := filepath.Rel(filepath.Dir(file), f)
+		if err != nil {
+			name = filepath.Base(f)
+		}
+		st.Subject = append(st.Subject, resource{
+			Name:   filepath.ToSlash(name),
+			Digest: map[string]string{"sha256": hex.EncodeToString(sum[:])},
+		})
+	}
+
+	p := &st.Predicate
+	p.BuildDefinition.BuildType = provenanceBuildType
+	p.BuildDefinition.ExternalParameters = b.Parameters
+	if len(b.Source) != 0 {
+		dep := resource{URI: b.Source}
+		if len(b.SourceSHA) != 0 {
+			dep.Digest = map[string]string{"gitCommit": b.SourceSHA}
+		}
+		p.BuildDefinition.ResolvedDependencies = append(p.BuildDefinition.ResolvedDependencies, dep)
+	}
+	p.RunDetails.Builder.ID = b.BuilderID
+	if len(b.BuilderID) == 0 {
+		p.RunDetails.Builder.ID = provenanceBuilder
+	}
+	if !b.StartedOn.IsZero() {
+		p.RunDetails.Metadata.StartedOn = &b.StartedOn
+	}
+	if !b.FinishedOn.IsZero() {
+		p.RunDetails.Metadata.FinishedOn = &b.FinishedOn
+	}
+
+	out, err := json.MarshalIndent(st, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(file, append(out, '\n'), 0644); err != nil {
+		return err
+	}
+	report.Written(file)
+	return nil
+}
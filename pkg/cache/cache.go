@@ -0,0 +1,258 @@
+// Copyright 2021 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	gh "github.com/google/go-github/v50/github"
+
+	"github.com/cilium/release/pkg/types"
+)
+
+// Cache stores the PR metadata resolved from GitHub so that it can be reused
+// across runs, releases and branches. Entries are addressed by commit SHA or
+// PR number and stored as one file per entry. A commit only maps to the
+// numbers of its PRs, which never change, while the metadata of the PRs,
+// e.g. their labels and release notes, can still be edited once they are
+// closed: it must be revalidated, see Revalidate, before being used.
+// A nil *Cache is valid and caches nothing.
+type Cache struct {
+	dir string
+}
+
+// revalidationOverlap is how far back before the previous revalidation the
+// updated PRs are listed, to cope with clock skews.
+const revalidationOverlap = 10 * time.Minute
+
+// New returns a cache stored in the given directory. An empty directory
+// returns a nil cache.
+func New(dir string) (*Cache, error) {
+	if len(dir) == 0 {
+		return nil, nil
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	return &Cache{dir: dir}, nil
+}
+
+func (c *Cache) commitFile(owner, repo, sha string) string {
+	return filepath.Join(c.dir, owner, repo, "commits", sha+".json")
+}
+
+func (c *Cache) prFile(owner, repo string, number int) string {
+	return filepath.Join(c.dir, owner, repo, "prs", strconv.Itoa(number)+".json")
+}
+
+func (c *Cache) pullFile(owner, repo string, number int) string {
+	return filepath.Join(c.dir, owner, repo, "pulls", strconv.Itoa(number)+".json")
+}
+
+func (c *Cache) syncFile(owner, repo string) string {
+	return filepath.Join(c.dir, owner, repo, "revalidated")
+}
+
+// Revalidate drops the metadata of the PRs of the repository updated, e.g.
+// relabeled, since the previous revalidation. The whole repository is
+// dropped if it was never revalidated.
+func (c *Cache) Revalidate(ctx context.Context, ghClient *gh.Client, owner, repo string) error {
+	if c == nil {
+		return nil
+	}
+	return c.revalidate(ctx, ghClient, owner, repo, time.Now())
+}
+
+func (c *Cache) revalidate(ctx context.Context, ghClient *gh.Client, owner, repo string, now time.Time) error {
+	data, err := os.ReadFile(c.syncFile(owner, repo))
+	if errors.Is(err, fs.ErrNotExist) {
+		if err := os.RemoveAll(filepath.Join(c.dir, owner, repo)); err != nil {
+			return err
+		}
+		return c.markRevalidated(owner, repo, now)
+	}
+	if err != nil {
+		return err
+	}
+	since, err := time.Parse(time.RFC3339, strings.TrimSpace(string(data)))
+	if err != nil {
+		return fmt.Errorf("invalid revalidation time in %s: %w", c.syncFile(owner, repo), err)
+	}
+	opts := &gh.IssueListByRepoOptions{
+		State:       "all",
+		Since:       since.Add(-revalidationOverlap),
+		ListOptions: gh.ListOptions{PerPage: 100},
+	}
+	for {
+		issues, resp, err := ghClient.Issues.ListByRepo(ctx, owner, repo, opts)
+		if err != nil {
+			return fmt.Errorf("unable to list the PRs updated since %s: %w", since.Format(time.RFC3339), err)
+		}
+		for _, issue := range issues {
+			if !issue.IsPullRequest() {
+				continue
+			}
+			for _, file := range []string{c.prFile(owner, repo, issue.GetNumber()), c.pullFile(owner, repo, issue.GetNumber())} {
+				if err := os.Remove(file); err != nil && !errors.Is(err, fs.ErrNotExist) {
+					return err
+				}
+			}
+		}
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+	return c.markRevalidated(owner, repo, now)
+}
+
+func (c *Cache) markRevalidated(owner, repo string, now time.Time) error {
+	file := c.syncFile(owner, repo)
+	if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(file, []byte(now.UTC().Format(time.RFC3339)+"\n"), 0644)
+}
+
+func (c *Cache) load(file string, v interface{}) bool {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return false
+	}
+	return json.Unmarshal(data, v) == nil
+}
+
+func (c *Cache) store(file string, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+		return err
+	}
+	// Write to a temporary file first so that an interrupted run never
+	// leaves a truncated entry behind. The temporary file is unique as the
+	// same entry can be stored concurrently, e.g. by runs for different
+	// branches.
+	tmp, err := os.CreateTemp(filepath.Dir(file), filepath.Base(file)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), file)
+}
+
+// CommitPRs returns the PRs associated with the given commit. It returns
+// false if the metadata of any of them isn't cached, e.g. as it was
+// updated since.
+func (c *Cache) CommitPRs(owner, repo, sha string) ([]*gh.PullRequest, bool) {
+	if c == nil {
+		return nil, false
+	}
+	var numbers []int
+	if !c.load(c.commitFile(owner, repo, sha), &numbers) {
+		return nil, false
+	}
+	prs := make([]*gh.PullRequest, 0, len(numbers))
+	for _, number := range numbers {
+		var pr gh.PullRequest
+		if !c.load(c.pullFile(owner, repo, number), &pr) {
+			return nil, false
+		}
+		prs = append(prs, &pr)
+	}
+	return prs, true
+}
+
+// StoreCommitPRs stores the numbers of the PRs associated with the given
+// commit and their metadata. Only the fields required to generate the
+// release notes are kept.
+func (c *Cache) StoreCommitPRs(owner, repo, sha string, prs []*gh.PullRequest) error {
+	if c == nil {
+		return nil
+	}
+	numbers := make([]int, 0, len(prs))
+	for _, pr := range prs {
+		if err := c.store(c.pullFile(owner, repo, pr.GetNumber()), trimPR(pr)); err != nil {
+			return err
+		}
+		numbers = append(numbers, pr.GetNumber())
+	}
+	return c.store(c.commitFile(owner, repo, sha), numbers)
+}
+
+// PullRequest returns the resolved metadata of the given PR.
+func (c *Cache) PullRequest(owner, repo string, number int) (types.PullRequest, bool) {
+	if c == nil {
+		return types.PullRequest{}, false
+	}
+	var pr types.PullRequest
+	ok := c.load(c.prFile(owner, repo, number), &pr)
+	return pr, ok
+}
+
+// StorePullRequest stores the resolved metadata of the given PR.
+func (c *Cache) StorePullRequest(owner, repo string, number int, pr types.PullRequest) error {
+	if c == nil {
+		return nil
+	}
+	return c.store(c.prFile(owner, repo, number), pr)
+}
+
+func trimPR(pr *gh.PullRequest) *gh.PullRequest {
+	trimmed := &gh.PullRequest{
+		Number:            pr.Number,
+		State:             pr.State,
+		Title:             pr.Title,
+		Body:              pr.Body,
+		MergedAt:          pr.MergedAt,
+		HTMLURL:           pr.HTMLURL,
+		AuthorAssociation: pr.AuthorAssociation,
+	}
+	if pr.Milestone != nil {
+		trimmed.Milestone = &gh.Milestone{Title: pr.Milestone.Title}
+	}
+	if pr.User != nil {
+		trimmed.User = &gh.User{Login: pr.User.Login}
+	}
+	if pr.Base != nil {
+		trimmed.Base = &gh.PullRequestBranch{Ref: pr.Base.Ref}
+	}
+	for _, lbl := range pr.Labels {
+		trimmed.Labels = append(trimmed.Labels, &gh.Label{Name: lbl.Name})
+	}
+	return trimmed
+}
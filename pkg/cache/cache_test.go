@@ -0,0 +1,154 @@
+// Copyright 2021 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+
+	gh "github.com/google/go-github/v50/github"
+
+	"github.com/cilium/release/pkg/types"
+)
+
+func TestCache(t *testing.T) {
+	c, err := New(t.TempDir())
+	if err != nil {
+		t.Fatal("Unable to create cache:", err)
+	}
+
+	const sha = "9ba79ef2517ede0ece6c1d1a7798c57d33d24f77"
+	if _, ok := c.CommitPRs("cilium", "cilium", sha); ok {
+		t.Errorf("CommitPRs() found entry in empty cache")
+	}
+	prs := []*gh.PullRequest{
+		{
+			Number:    gh.Int(1),
+			State:     gh.String("closed"),
+			Title:     gh.String("Fix foo"),
+			HTMLURL:   gh.String("https://github.com/cilium/cilium/pull/1"),
+			Additions: gh.Int(3),
+			Milestone: &gh.Milestone{Title: gh.String("1.14"), Number: gh.Int(5)},
+			User:      &gh.User{Login: gh.String("alice"), ID: gh.Int64(10)},
+			Labels:    []*gh.Label{{Name: gh.String("release-note/bug"), Color: gh.String("fff")}},
+		},
+	}
+	if err := c.StoreCommitPRs("cilium", "cilium", sha, prs); err != nil {
+		t.Fatalf("StoreCommitPRs() error = %v", err)
+	}
+	got, ok := c.CommitPRs("cilium", "cilium", sha)
+	want := []*gh.PullRequest{
+		{
+			Number:    gh.Int(1),
+			State:     gh.String("closed"),
+			Title:     gh.String("Fix foo"),
+			HTMLURL:   gh.String("https://github.com/cilium/cilium/pull/1"),
+			Milestone: &gh.Milestone{Title: gh.String("1.14")},
+			User:      &gh.User{Login: gh.String("alice")},
+			Labels:    []*gh.Label{{Name: gh.String("release-note/bug")}},
+		},
+	}
+	if !ok || !reflect.DeepEqual(got, want) {
+		t.Errorf("CommitPRs() = %v, want %v", got, want)
+	}
+
+	pr := types.PullRequest{
+		ReleaseNote:  "Fix foo",
+		ReleaseLabel: "release-note/bug",
+		AuthorName:   "alice",
+	}
+	if err := c.StorePullRequest("cilium", "cilium", 1, pr); err != nil {
+		t.Fatalf("StorePullRequest() error = %v", err)
+	}
+	if gotPR, ok := c.PullRequest("cilium", "cilium", 1); !ok || !reflect.DeepEqual(gotPR, pr) {
+		t.Errorf("PullRequest() = %v, want %v", gotPR, pr)
+	}
+
+	var nilCache *Cache
+	if _, ok := nilCache.PullRequest("cilium", "cilium", 1); ok {
+		t.Errorf("PullRequest() found entry in nil cache")
+	}
+}
+
+func TestRevalidate(t *testing.T) {
+	var since string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/repos/cilium/cilium/issues" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL)
+			http.NotFound(w, r)
+			return
+		}
+		since = r.URL.Query().Get("since")
+		fmt.Fprint(w, `[
+			{"number": 1, "pull_request": {"url": "https://api.github.com/repos/cilium/cilium/pulls/1"}},
+			{"number": 2}
+		]`)
+	}))
+	defer srv.Close()
+	ghClient := gh.NewClient(nil)
+	ghClient.BaseURL, _ = url.Parse(srv.URL + "/")
+
+	c, err := New(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := func() {
+		for _, number := range []int{1, 2} {
+			pr := &gh.PullRequest{Number: gh.Int(number), State: gh.String("closed")}
+			if err := c.StoreCommitPRs("cilium", "cilium", fmt.Sprintf("sha%d", number), []*gh.PullRequest{pr}); err != nil {
+				t.Fatal(err)
+			}
+			if err := c.StorePullRequest("cilium", "cilium", number, types.PullRequest{ReleaseNote: "Fix foo"}); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	cached := func(number int) bool {
+		_, commitOK := c.CommitPRs("cilium", "cilium", fmt.Sprintf("sha%d", number))
+		_, prOK := c.PullRequest("cilium", "cilium", number)
+		return commitOK && prOK
+	}
+
+	// Entries of unknown freshness are dropped.
+	store()
+	first := time.Date(2023, 9, 1, 12, 0, 0, 0, time.UTC)
+	if err := c.revalidate(context.Background(), ghClient, "cilium", "cilium", first); err != nil {
+		t.Fatal(err)
+	}
+	if cached(1) || cached(2) {
+		t.Error("PRs cached before the first revalidation kept")
+	}
+
+	// Only the PRs updated since are dropped.
+	store()
+	if err := c.revalidate(context.Background(), ghClient, "cilium", "cilium", first.Add(24*time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	if want := "2023-09-01T11:50:00Z"; since != want {
+		t.Errorf("listed the PRs updated since %s, want %s", since, want)
+	}
+	if cached(1) {
+		t.Error("updated PR #1 kept")
+	}
+	if !cached(2) {
+		t.Error("issue #2 dropped PR #2")
+	}
+}
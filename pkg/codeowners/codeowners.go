@@ -0,0 +1,123 @@
+// Copyright 2021 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package codeowners
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Paths where GitHub looks for the CODEOWNERS file, in order.
+var Paths = []string{
+	".github/CODEOWNERS",
+	"CODEOWNERS",
+	"docs/CODEOWNERS",
+}
+
+type rule struct {
+	re     *regexp.Regexp
+	owners []string
+}
+
+// CodeOwners is a parsed CODEOWNERS file.
+type CodeOwners struct {
+	rules []rule
+}
+
+// Parse parses the content of a CODEOWNERS file. Invalid lines are ignored.
+func Parse(content string) *CodeOwners {
+	co := &CodeOwners{}
+	for _, line := range strings.Split(content, "\n") {
+		if idx := strings.Index(line, "#"); idx != -1 {
+			line = line[:idx]
+		}
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		re, err := patternToRegexp(fields[0])
+		if err != nil {
+			continue
+		}
+		co.rules = append(co.rules, rule{
+			re:     re,
+			owners: fields[1:],
+		})
+	}
+	return co
+}
+
+// Owners returns the owners of the given path, as written in the CODEOWNERS
+// file (e.g. '@cilium/sig-datapath'). As in GitHub, the last matching rule
+// takes precedence.
+func (co *CodeOwners) Owners(path string) []string {
+	path = strings.TrimPrefix(path, "/")
+	for i := len(co.rules) - 1; i >= 0; i-- {
+		if co.rules[i].re.MatchString(path) {
+			return co.rules[i].owners
+		}
+	}
+	return nil
+}
+
+// patternToRegexp converts a gitignore-style CODEOWNERS pattern into a
+// regular expression matching the paths, and their subpaths, it owns.
+func patternToRegexp(pattern string) (*regexp.Regexp, error) {
+	anchored := strings.HasPrefix(pattern, "/") || strings.Contains(strings.TrimSuffix(pattern, "/"), "/")
+	pattern = strings.TrimPrefix(pattern, "/")
+	dirOnly := strings.HasSuffix(pattern, "/")
+	pattern = strings.TrimSuffix(pattern, "/")
+	// A single '*' in the last segment matches files, not their subpaths:
+	// 'docs/*' owns 'docs/a.md' but not 'docs/build/b.md'.
+	last := pattern[strings.LastIndex(pattern, "/")+1:]
+	fileOnly := !dirOnly && strings.Contains(last, "*") && !strings.Contains(last, "**")
+
+	var sb strings.Builder
+	if anchored {
+		sb.WriteString("^")
+	} else {
+		sb.WriteString("(^|/)")
+	}
+	for i := 0; i < len(pattern); i++ {
+		switch c := pattern[i]; c {
+		case '*':
+			if strings.HasPrefix(pattern[i:], "**/") {
+				// Matches zero or more directories.
+				sb.WriteString("(.*/)?")
+				i += 2
+				continue
+			}
+			if i+1 < len(pattern) && pattern[i+1] == '*' {
+				sb.WriteString(".*")
+				i++
+				continue
+			}
+			sb.WriteString("[^/]*")
+		case '?':
+			sb.WriteString("[^/]")
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	switch {
+	case dirOnly:
+		sb.WriteString("/")
+	case fileOnly:
+		sb.WriteString("$")
+	default:
+		sb.WriteString("(/|$)")
+	}
+	return regexp.Compile(sb.String())
+}
@@ -0,0 +1,53 @@
+// Copyright 2021 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package codeowners
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCodeOwners_Owners(t *testing.T) {
+	co := Parse(`# Default owners
+* @cilium/tophat
+
+/bpf/ @cilium/sig-datapath
+*.md @cilium/docs-structure # docs
+pkg/policy/ @cilium/sig-policy @alice
+/Documentation/**/*.rst @cilium/docs
+/api/v1/* @cilium/api
+`)
+	tests := []struct {
+		path string
+		want []string
+	}{
+		{path: "Makefile", want: []string{"@cilium/tophat"}},
+		{path: "bpf/bpf_lxc.c", want: []string{"@cilium/sig-datapath"}},
+		{path: "bpf/README.md", want: []string{"@cilium/docs-structure"}},
+		{path: "pkg/policy/api/rule.go", want: []string{"@cilium/sig-policy", "@alice"}},
+		{path: "vendor/pkg/policy/x.go", want: []string{"@cilium/tophat"}},
+		{path: "Documentation/network/concepts/routing.rst", want: []string{"@cilium/docs"}},
+		{path: "Documentation/index.rst", want: []string{"@cilium/docs"}},
+		{path: "api/v1/openapi.yaml", want: []string{"@cilium/api"}},
+		{path: "api/v1/models/endpoint.go", want: []string{"@cilium/tophat"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			if got := co.Owners(tt.path); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Owners() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,64 @@
+// Copyright 2021 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"os"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Authors maps the GitHub login of a PR author to how the author is shown in
+// the release notes.
+type Authors map[string]Author
+
+// Author describes how a PR author is shown in the release notes.
+type Author struct {
+	// Name is shown instead of the GitHub login of the author.
+	Name string `yaml:"name"`
+	// NoMention shows the GitHub login without the '@' so that the author
+	// isn't notified when the release notes are pasted into GitHub.
+	NoMention bool `yaml:"no-mention"`
+}
+
+// LoadAuthors reads the authors mapping file.
+func LoadAuthors(file string) (Authors, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	authors := Authors{}
+	err = yaml.Unmarshal(data, &authors)
+	if err != nil {
+		return nil, err
+	}
+	return authors, nil
+}
+
+// Display returns how the author with the given login is shown in the
+// release notes, or false if the author isn't present in the mapping.
+func (a Authors) Display(login string) (string, bool) {
+	author, ok := a[login]
+	if !ok {
+		return "", false
+	}
+	if len(author.Name) != 0 {
+		return author.Name, true
+	}
+	if author.NoMention {
+		return login, true
+	}
+	return "@" + login, true
+}
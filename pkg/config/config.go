@@ -0,0 +1,353 @@
+// Copyright 2021 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/cilium/release/pkg/version"
+)
+
+// Config is the content of the release configuration file.
+type Config struct {
+	Schedule  Schedule  `yaml:"schedule"`
+	Projects  Projects  `yaml:"projects"`
+	Labels    Labels    `yaml:"labels"`
+	Backports Backports `yaml:"backports"`
+	// Downstream are the repositories whose version is bumped once a
+	// release is published.
+	Downstream []Downstream `yaml:"downstream"`
+	Dashboard  Dashboard    `yaml:"dashboard"`
+	Docs       Docs         `yaml:"docs"`
+	// Groups are the groups owning the changes, e.g. SIGs or teams, by
+	// decreasing priority, used as the sections of the release notes
+	// instead of the types of changes with --group-by=owner.
+	Groups []Group `yaml:"groups"`
+	// Images are the container images published for each release.
+	Images Images `yaml:"images"`
+	// Components are the high-level components, e.g. the datapath or the
+	// operator, the changed files are mapped to by --components-summary.
+	// The first component matching a file wins.
+	Components []Component `yaml:"components"`
+	// Profiles are the release workflows of the projects of the
+	// organization, e.g. cilium, tetragon and hubble, sharing the
+	// configuration file, by name. See UseProfile.
+	Profiles map[string]Profile `yaml:"profiles"`
+	// Document wraps the release notes into the published document.
+	Document Document `yaml:"document"`
+}
+
+// ImagesPlaceholder stands for the Docker Manifests table of the images of
+// the release in the published document, replaced by 'images list --notes'
+// once the images are built.
+const ImagesPlaceholder = "<!-- docker-manifests -->"
+
+// Document holds the fragments written around the release notes, so that
+// the published document needs no manual assembly. They are text/template
+// templates executed with the version and date of the release, the
+// repository, the documentation links and ImagesPlaceholder, e.g.:
+//
+//	header: |
+//	  Cilium {{ .Version }} was released on {{ .Date }}, see the
+//	  [upgrade guide]({{ .Docs.Links.upgrade }}).
+//	footer: |
+//	  {{ .Images }}
+type Document struct {
+	// Header is written before the release notes.
+	Header string `yaml:"header"`
+	// Footer is written after the release notes.
+	Footer string `yaml:"footer"`
+}
+
+// Profile is the release workflow of one of the projects sharing the
+// configuration file, selected with --profile.
+type Profile struct {
+	// Repo is the repository of the project, e.g. 'cilium/tetragon', used
+	// if none is given on the command line.
+	Repo string `yaml:"repo"`
+	// LabelScheme is the label scheme of the repository, see profile.Get.
+	// Defaults to the name of the profile.
+	LabelScheme string `yaml:"label-scheme"`
+	// Schedule, Projects and Images, if set, replace the ones of the
+	// configuration file for the project.
+	Schedule *Schedule `yaml:"schedule"`
+	Projects *Projects `yaml:"projects"`
+	Images   *Images   `yaml:"images"`
+}
+
+// UseProfile replaces the schedule, projects and images of c by the ones of
+// the profile of the given name, if set, and returns the profile, its label
+// scheme defaulting to its name. It returns false if there is no such
+// profile, e.g. when the name is only the one of a label scheme.
+func (c *Config) UseProfile(name string) (Profile, bool) {
+	p, ok := c.Profiles[name]
+	if !ok {
+		return Profile{}, false
+	}
+	if len(p.LabelScheme) == 0 {
+		p.LabelScheme = name
+	}
+	if p.Schedule != nil {
+		c.Schedule = *p.Schedule
+	}
+	if p.Projects != nil {
+		c.Projects = *p.Projects
+	}
+	if p.Images != nil {
+		c.Images = *p.Images
+	}
+	return p, true
+}
+
+// Component is a high-level component of the project.
+type Component struct {
+	// Name is the name of the component, e.g. 'datapath'.
+	Name string `yaml:"name"`
+	// Paths are the directories, e.g. 'bpf/', or globs of the files of the
+	// component, see MatchPath.
+	Paths []string `yaml:"paths"`
+}
+
+// Images are the container images published for each release.
+type Images struct {
+	// Repositories are the image repositories, e.g.
+	// 'quay.io/cilium/cilium', tagged with the released version.
+	Repositories []string `yaml:"repositories"`
+	// Platforms are the platforms, e.g. 'linux/amd64' or 'linux/arm64',
+	// every image must be available for.
+	Platforms []string `yaml:"platforms"`
+	// AcceptedVulnerabilities are the vulnerabilities that don't block
+	// the publication of a release, e.g. the ones not exploitable.
+	AcceptedVulnerabilities []AcceptedVulnerability `yaml:"accepted-vulnerabilities"`
+}
+
+// AcceptedVulnerability is a vulnerability accepted in the images.
+type AcceptedVulnerability struct {
+	// ID is the identifier of the vulnerability, e.g. 'CVE-2023-1234'.
+	ID string `yaml:"id"`
+	// Repositories are the image repositories the vulnerability is
+	// accepted in, all of them if empty.
+	Repositories []string `yaml:"repositories"`
+	// Reason is why the vulnerability is accepted.
+	Reason string `yaml:"reason"`
+}
+
+// Accepts returns true if the vulnerability of the given ID is accepted in
+// the image repository.
+func (i Images) Accepts(id, repository string) bool {
+	for _, a := range i.AcceptedVulnerabilities {
+		if a.ID != id {
+			continue
+		}
+		if len(a.Repositories) == 0 {
+			return true
+		}
+		for _, r := range a.Repositories {
+			if r == repository {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Group is a group owning the changes of the PRs with its label.
+type Group struct {
+	// Label is the label of the PRs of the group, e.g. 'sig/datapath'.
+	Label string `yaml:"label"`
+	// Name is shown in the header of its section, e.g. 'SIG Datapath'.
+	Name string `yaml:"name"`
+}
+
+// Docs links the release notes to the documentation of the project.
+type Docs struct {
+	// Areas maps an area label, e.g. 'area/clustermesh', to the URL of
+	// its documentation, linked from the entries of the PRs with it.
+	Areas map[string]string `yaml:"areas"`
+	// Sections maps the label of a section, e.g. 'release-note/major', to
+	// the URL of the documentation linked from the section.
+	Sections map[string]string `yaml:"sections"`
+	// Links are other links to the documentation by name, e.g. 'upgrade',
+	// available to the header and footer of the Document.
+	Links map[string]string `yaml:"links"`
+}
+
+// Dashboard lists the repositories and branches whose release health is
+// reported by the 'dashboard' subcommand.
+type Dashboard struct {
+	Repos []DashboardRepo `yaml:"repos"`
+}
+
+// DashboardRepo is a repository of the dashboard.
+type DashboardRepo struct {
+	// Repo is the repository, e.g. 'cilium/tetragon'.
+	Repo string `yaml:"repo"`
+	// Branches are its stable branches, e.g. '1.14'.
+	Branches []string `yaml:"branches"`
+	// Profile is its label scheme, see profile.Get.
+	Profile string `yaml:"profile"`
+	// BlockerLabel is the template of the label of the issues and PRs
+	// blocking the release of a branch, '{{ .Branch }}' being replaced by
+	// the branch. Defaults to 'release-blocker/{{ .Branch }}'.
+	BlockerLabel string `yaml:"blocker-label"`
+}
+
+// Downstream is a repository depending on the released project, e.g. a
+// CLI, Helm charts or the docs, whose files referencing the released
+// version are updated through a PR.
+type Downstream struct {
+	// Repo is the repository, e.g. 'cilium/cilium-cli'.
+	Repo string `yaml:"repo"`
+	// Base is the branch the PR is opened against, the default branch of
+	// the repository if empty.
+	Base string `yaml:"base"`
+	// Files are the files updated in the repository.
+	Files []BumpRule `yaml:"files"`
+}
+
+// BumpRule replaces the version in a file of a downstream repository.
+type BumpRule struct {
+	Path string `yaml:"path"`
+	// Regex matches the version to replace, e.g. 'v[0-9]+\.[0-9]+\.[0-9]+'.
+	Regex string `yaml:"regex"`
+	// Replace is the template of the replacement. '{{ .Version }}' and
+	// '{{ .Minor }}' are replaced by the released version, e.g. '1.14.3'
+	// and '1.14', and '$1' by the first submatch of Regex.
+	Replace string `yaml:"replace"`
+}
+
+// Backports describes how the backports are managed.
+type Backports struct {
+	// CriticalPaths are the directories, e.g. 'pkg/datapath/', or globs,
+	// e.g. 'bpf/*.h', whose changes are suggested for backport.
+	CriticalPaths []string `yaml:"critical-paths"`
+}
+
+// MatchPath returns true if file matches the given pattern, either a
+// directory ending with a slash, e.g. 'pkg/datapath/', or a glob, e.g.
+// 'bpf/*.h'.
+func MatchPath(pattern, file string) bool {
+	if strings.HasSuffix(pattern, "/") {
+		return strings.HasPrefix(file, pattern)
+	}
+	ok, _ := path.Match(pattern, file)
+	return ok
+}
+
+// Labels describes the labels that the repository should have.
+type Labels struct {
+	// Branches are the stable branches, e.g. '1.14', for which the label
+	// definitions containing '{{ .Branch }}' are created.
+	Branches []string `yaml:"branches"`
+	// Definitions are the labels of the repository.
+	Definitions []Label `yaml:"definitions"`
+}
+
+// Label is the definition of a repository label.
+type Label struct {
+	Name        string `yaml:"name"`
+	Color       string `yaml:"color"`
+	Description string `yaml:"description"`
+}
+
+// Projects describes the backport projects created for each release.
+type Projects struct {
+	// Columns are the templates of the columns, or ProjectV2 statuses,
+	// created in a new project. '{{ .Version }}' and '{{ .Minor }}' are
+	// replaced by the version of the project, e.g. '1.14.3' and '1.14'.
+	Columns []string `yaml:"columns"`
+}
+
+// Schedule describes the release cadence of the project.
+type Schedule struct {
+	// PatchCadenceDays is the number of days between two patch releases of
+	// the same stable branch.
+	PatchCadenceDays int `yaml:"patch-cadence-days"`
+	// MinorCadenceDays is the number of days between two minor releases.
+	MinorCadenceDays int `yaml:"minor-cadence-days"`
+	// Branches are the maintained stable branches, e.g. '1.14'.
+	Branches []string `yaml:"branches"`
+	// EOL maps a minor version, e.g. '1.14', to the date, e.g.
+	// '2024-07-20', at which it stops being supported.
+	EOL map[string]string `yaml:"eol"`
+}
+
+// EOLDate returns the end of life date of the given minor version, e.g.
+// '1.14', if configured.
+func (s Schedule) EOLDate(minor string) (time.Time, bool, error) {
+	date, ok := s.EOL[minor]
+	if !ok {
+		return time.Time{}, false, nil
+	}
+	t, err := time.Parse("2006-01-02", date)
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("invalid end of life date of %s: %w", minor, err)
+	}
+	return t, true, nil
+}
+
+// CheckEOL returns an error if the given minor version, e.g. '1.14', reached
+// its end of life at the given time.
+func (s Schedule) CheckEOL(minor string, now time.Time) error {
+	eol, ok, err := s.EOLDate(minor)
+	if err != nil || !ok {
+		return err
+	}
+	if now.Before(eol) {
+		return nil
+	}
+	return fmt.Errorf("%s reached its end of life on %s", minor, eol.Format("2006-01-02"))
+}
+
+// CheckVersionsEOL runs CheckEOL on the minor version of each of the given
+// versions, e.g. '1.14.3' or 'v1.14', skipping the ones that aren't versions
+// such as 'main'. With force, the errors are only printed as warnings.
+func (s Schedule) CheckVersionsEOL(now time.Time, force bool, versions ...string) error {
+	for _, ver := range versions {
+		v, err := version.Parse(ver)
+		if err != nil {
+			continue
+		}
+		err = s.CheckEOL(v.MinorString(), now)
+		if err == nil {
+			continue
+		}
+		if !force {
+			return fmt.Errorf("%w, use --force to proceed anyway", err)
+		}
+		fmt.Fprintf(os.Stderr, "WARNING: %s\n", err)
+	}
+	return nil
+}
+
+// Load reads the configuration file.
+func Load(file string) (*Config, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	cfg := &Config{}
+	err = yaml.Unmarshal(data, cfg)
+	if err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
@@ -0,0 +1,107 @@
+// Copyright 2021 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestMatchPath(t *testing.T) {
+	tests := []struct {
+		pattern string
+		file    string
+		want    bool
+	}{
+		{"pkg/datapath/", "pkg/datapath/linux/node.go", true},
+		{"pkg/datapath/", "pkg/datapathfoo/node.go", false},
+		{"bpf/*.h", "bpf/lib.h", true},
+		{"bpf/*.h", "bpf/lib/nat.h", false},
+		{"Makefile", "Makefile", true},
+	}
+	for _, tt := range tests {
+		if got := MatchPath(tt.pattern, tt.file); got != tt.want {
+			t.Errorf("MatchPath(%q, %q) = %v, want %v", tt.pattern, tt.file, got, tt.want)
+		}
+	}
+}
+
+func TestCheckVersionsEOL(t *testing.T) {
+	s := Schedule{EOL: map[string]string{"1.12": "2024-04-30"}}
+	now := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		versions []string
+		force    bool
+		wantErr  bool
+	}{
+		{versions: []string{"main", "v1.13"}},
+		{versions: []string{"v1.13", "v1.12"}, wantErr: true},
+		{versions: []string{"1.12.3"}, wantErr: true},
+		{versions: []string{"1.12.3"}, force: true},
+	}
+	for _, tt := range tests {
+		err := s.CheckVersionsEOL(now, tt.force, tt.versions...)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("CheckVersionsEOL(%v, force=%v) = %v, want error %v", tt.versions, tt.force, err, tt.wantErr)
+		}
+	}
+}
+
+func TestUseProfile(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "release.yaml")
+	err := os.WriteFile(file, []byte(`
+projects:
+  columns: ["To backport", "Done"]
+images:
+  repositories: ["quay.io/cilium/cilium"]
+profiles:
+  tetragon:
+    repo: cilium/tetragon
+    images:
+      repositories: ["quay.io/cilium/tetragon"]
+  hubble:
+    repo: cilium/hubble
+    label-scheme: cilium
+`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := Load(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := c.UseProfile("cilium"); ok {
+		t.Errorf("got profile cilium, want none")
+	}
+
+	p, ok := c.UseProfile("tetragon")
+	if !ok || p.Repo != "cilium/tetragon" || p.LabelScheme != "tetragon" {
+		t.Errorf("got profile %+v, want the tetragon one with its own label scheme", p)
+	}
+	if !reflect.DeepEqual(c.Images.Repositories, []string{"quay.io/cilium/tetragon"}) {
+		t.Errorf("got images %v, want the ones of the profile", c.Images.Repositories)
+	}
+	if !reflect.DeepEqual(c.Projects.Columns, []string{"To backport", "Done"}) {
+		t.Errorf("got columns %v, want the top-level ones", c.Projects.Columns)
+	}
+
+	c, _ = Load(file)
+	if p, ok := c.UseProfile("hubble"); !ok || p.LabelScheme != "cilium" {
+		t.Errorf("got profile %+v, want the hubble one with the cilium label scheme", p)
+	}
+}
@@ -0,0 +1,39 @@
+// Copyright 2021 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"os"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Disclosures maps the number of a PR of a private security fork to the
+// number of the public PR it was disclosed as, e.g. '1234: 28000'.
+type Disclosures map[int]int
+
+// LoadDisclosures reads the disclosures file.
+func LoadDisclosures(file string) (Disclosures, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	disclosures := Disclosures{}
+	err = yaml.Unmarshal(data, &disclosures)
+	if err != nil {
+		return nil, err
+	}
+	return disclosures, nil
+}
@@ -0,0 +1,53 @@
+// Copyright 2021 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"os"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Overrides maps the number of a PR to the corrections applied to its entry
+// of the release notes once the PRs are fetched. Kept in the repository,
+// they are applied again each time the release notes are regenerated.
+type Overrides map[int]Override
+
+// Override is the correction of the entry of a PR.
+type Override struct {
+	// Category is the label of the section the PR is forced into, e.g.
+	// 'release-note/bug', whatever its labels on GitHub.
+	Category string `yaml:"category"`
+	// Note replaces the release note of the PR.
+	Note string `yaml:"note"`
+	// Author replaces the GitHub login of the author of the PR.
+	Author string `yaml:"author"`
+	// Drop leaves the PR out of the release notes.
+	Drop bool `yaml:"drop"`
+}
+
+// LoadOverrides reads the overrides file.
+func LoadOverrides(file string) (Overrides, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	overrides := Overrides{}
+	err = yaml.Unmarshal(data, &overrides)
+	if err != nil {
+		return nil, err
+	}
+	return overrides, nil
+}
@@ -0,0 +1,183 @@
+// Copyright 2021 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package confirm gates the mutating steps of a release behind a
+// confirmation tied to the tracking issue of the release, on which what was
+// executed is then reported.
+package confirm
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"strconv"
+	"strings"
+
+	gh "github.com/google/go-github/v50/github"
+	flag "github.com/spf13/pflag"
+
+	"github.com/cilium/release/pkg/github"
+)
+
+const (
+	// FlagUsage is the usage of the --confirm flag of the mutating steps.
+	FlagUsage = "URL of the tracking issue of the release, confirming the step without prompting"
+	// EnvironmentFlagUsage is the usage of the --environment flag of the
+	// mutating steps.
+	EnvironmentFlagUsage = "GitHub Environment of the repository of the tracking issue in which the step must be approved"
+)
+
+// Issue is the tracking issue of a release.
+type Issue struct {
+	Owner, Repo string
+	Number      int
+}
+
+// URL returns the URL of the issue.
+func (i Issue) URL() string {
+	return fmt.Sprintf("https://github.com/%s/%s/issues/%d", i.Owner, i.Repo, i.Number)
+}
+
+// ParseIssueURL parses the URL of a GitHub issue, e.g.
+// 'https://github.com/cilium/cilium/issues/28000'.
+func ParseIssueURL(s string) (Issue, error) {
+	u, err := url.Parse(strings.TrimSpace(s))
+	if err != nil {
+		return Issue{}, err
+	}
+	parts := strings.Split(strings.Trim(u.Path, "/"), "/")
+	if u.Host != "github.com" || len(parts) != 4 || parts[2] != "issues" {
+		return Issue{}, fmt.Errorf("%q is not the URL of a GitHub issue", s)
+	}
+	number, err := strconv.Atoi(parts[3])
+	if err != nil || number <= 0 {
+		return Issue{}, fmt.Errorf("%q is not the URL of a GitHub issue", s)
+	}
+	return Issue{Owner: parts[0], Repo: parts[1], Number: number}, nil
+}
+
+// Gate is the confirmation of a mutating step. The actions executed by the
+// step are recorded with Executed and reported on the tracking issue with
+// Report. A nil Gate, e.g. of a dry run, records and reports nothing.
+type Gate struct {
+	ghClient *gh.Client
+	issue    Issue
+	step     string
+
+	executed []string
+}
+
+// AddFlags adds the --confirm and --environment flags of a mutating step to
+// fs.
+func AddFlags(fs *flag.FlagSet, confirmURL, environment *string) {
+	fs.StringVar(confirmURL, "confirm", "", FlagUsage)
+	fs.StringVar(environment, "environment", "", EnvironmentFlagUsage)
+}
+
+// Open confirms the step, see New, and waits for its approval in
+// environment, if set, see Gate.Approve.
+func Open(ctx context.Context, ghClient *gh.Client, step, confirmURL, environment string, in *bufio.Reader, out io.Writer) (*Gate, error) {
+	g, err := New(ctx, ghClient, step, confirmURL, in, out)
+	if err != nil {
+		return nil, err
+	}
+	if environment != "" {
+		if err := g.Approve(ctx, environment, out); err != nil {
+			return nil, err
+		}
+	}
+	return g, nil
+}
+
+// New confirms the step, e.g. 'abort v1.14.3', with the tracking issue URL
+// given with --confirm or, if empty, typed on in after a prompt written to
+// out. Only the line of the URL is consumed from in, so that the step can
+// read its own answers from it. It fails if the issue doesn't exist or is
+// closed.
+func New(ctx context.Context, ghClient *gh.Client, step, confirmURL string, in *bufio.Reader, out io.Writer) (*Gate, error) {
+	if confirmURL == "" {
+		fmt.Fprintf(out, "Type the URL of the tracking issue of the release to confirm '%s': ", step)
+		line, err := in.ReadString('\n')
+		if err != nil && err != io.EOF {
+			return nil, err
+		}
+		confirmURL = strings.TrimSpace(line)
+		if confirmURL == "" {
+			return nil, fmt.Errorf("'%s' not confirmed, type the URL of the tracking issue or use --confirm", step)
+		}
+	}
+	issue, err := ParseIssueURL(confirmURL)
+	if err != nil {
+		return nil, err
+	}
+	i, _, err := ghClient.Issues.Get(ctx, issue.Owner, issue.Repo, issue.Number)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get tracking issue %s: %w", issue.URL(), err)
+	}
+	if i.IsPullRequest() {
+		return nil, fmt.Errorf("%s is a pull request, not a tracking issue", issue.URL())
+	}
+	if i.GetState() != "open" {
+		return nil, fmt.Errorf("tracking issue %s is closed", issue.URL())
+	}
+	return &Gate{ghClient: ghClient, issue: issue, step: step}, nil
+}
+
+// Approve waits for the step to be approved in the GitHub Environment of the
+// repository of the tracking issue, through a deployment of its default
+// branch. See github.WaitForApproval.
+func (g *Gate) Approve(ctx context.Context, environment string, out io.Writer) error {
+	repo, _, err := g.ghClient.Repositories.Get(ctx, g.issue.Owner, g.issue.Repo)
+	if err != nil {
+		return fmt.Errorf("unable to get repository %s/%s: %w", g.issue.Owner, g.issue.Repo, err)
+	}
+	fmt.Fprintf(out, "Waiting for '%s' to be approved in environment %s of %s/%s\n", g.step, environment, g.issue.Owner, g.issue.Repo)
+	deployment, err := github.WaitForApproval(ctx, g.ghClient, g.issue.Owner, g.issue.Repo, repo.GetDefaultBranch(), environment, g.issue.URL()+": "+g.step)
+	if err != nil {
+		return err
+	}
+	g.Executed("Approved in environment %s (deployment %d)", environment, deployment.GetID())
+	return nil
+}
+
+// Executed records an action executed by the step.
+func (g *Gate) Executed(format string, a ...interface{}) {
+	if g == nil {
+		return
+	}
+	g.executed = append(g.executed, fmt.Sprintf(format, a...))
+}
+
+// Report comments on the tracking issue with the actions executed by the
+// step, if any, since the previous report. It's meant to be deferred so that
+// the actions executed before a failure are reported as well.
+func (g *Gate) Report(ctx context.Context) error {
+	if g == nil || len(g.executed) == 0 {
+		return nil
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "`release %s` executed:\n\n", g.step)
+	for _, action := range g.executed {
+		fmt.Fprintf(&b, "- %s\n", action)
+	}
+	body := b.String()
+	_, _, err := g.ghClient.Issues.CreateComment(ctx, g.issue.Owner, g.issue.Repo, g.issue.Number, &gh.IssueComment{Body: &body})
+	if err != nil {
+		return fmt.Errorf("unable to report on tracking issue %s: %w", g.issue.URL(), err)
+	}
+	g.executed = nil
+	return nil
+}
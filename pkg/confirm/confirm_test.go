@@ -0,0 +1,102 @@
+// Copyright 2021 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package confirm
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	gh "github.com/google/go-github/v50/github"
+)
+
+func TestParseIssueURL(t *testing.T) {
+	tests := []struct {
+		url     string
+		want    Issue
+		wantErr bool
+	}{
+		{url: "https://github.com/cilium/cilium/issues/28000", want: Issue{Owner: "cilium", Repo: "cilium", Number: 28000}},
+		{url: " https://github.com/cilium/cilium/issues/28000/\n", want: Issue{Owner: "cilium", Repo: "cilium", Number: 28000}},
+		{url: "https://github.com/cilium/cilium/pull/28000", wantErr: true},
+		{url: "https://example.com/cilium/cilium/issues/28000", wantErr: true},
+		{url: "https://github.com/cilium/cilium/issues/abc", wantErr: true},
+		{url: "yes", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			got, err := ParseIssueURL(tt.url)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseIssueURL() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseIssueURL() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGate(t *testing.T) {
+	var comment string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method + " " + r.URL.Path {
+		case "GET /repos/cilium/cilium/issues/1":
+			fmt.Fprint(w, `{"number": 1, "state": "open"}`)
+		case "GET /repos/cilium/cilium/issues/2":
+			fmt.Fprint(w, `{"number": 2, "state": "closed"}`)
+		case "POST /repos/cilium/cilium/issues/1/comments":
+			b, _ := io.ReadAll(r.Body)
+			comment = string(b)
+			fmt.Fprint(w, `{}`)
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL)
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+	ghClient := gh.NewClient(nil)
+	ghClient.BaseURL, _ = url.Parse(srv.URL + "/")
+	ctx := context.Background()
+
+	var out bytes.Buffer
+	if _, err := New(ctx, ghClient, "abort v1.14.3", "", bufio.NewReader(strings.NewReader("\n")), &out); err == nil {
+		t.Error("step confirmed without a tracking issue")
+	}
+	if _, err := New(ctx, ghClient, "abort v1.14.3", "https://github.com/cilium/cilium/issues/2", nil, &out); err == nil {
+		t.Error("step confirmed with a closed tracking issue")
+	}
+
+	g, err := New(ctx, ghClient, "abort v1.14.3", "", bufio.NewReader(strings.NewReader("https://github.com/cilium/cilium/issues/1\n")), &out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := g.Report(ctx); err != nil || comment != "" {
+		t.Fatalf("nothing executed but reported %q: %v", comment, err)
+	}
+	g.Executed("Deleted the tag %s", "v1.14.3")
+	if err := g.Report(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(comment, "`release abort v1.14.3` executed:\\n\\n- Deleted the tag v1.14.3") {
+		t.Errorf("unexpected comment %s", comment)
+	}
+}
@@ -0,0 +1,68 @@
+// Copyright 2021 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package git
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// Run executes git with the given arguments in the given directory and
+// returns its trimmed standard output. An empty dir means the current
+// working directory.
+func Run(dir string, args ...string) (string, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	err := cmd.Run()
+	if err != nil {
+		return "", fmt.Errorf("git %s: %w: %s", strings.Join(args, " "), err, strings.TrimSpace(stderr.String()))
+	}
+	return strings.TrimSpace(stdout.String()), nil
+}
+
+// RemoteRepoName returns the 'owner/repo' name of the GitHub repository the
+// given remote of the clone in dir points to.
+func RemoteRepoName(dir, remote string) (string, error) {
+	u, err := Run(dir, "remote", "get-url", remote)
+	if err != nil {
+		return "", err
+	}
+	return ParseRemoteURL(u)
+}
+
+// ParseRemoteURL returns the 'owner/repo' name of a GitHub remote URL, e.g.
+// 'git@github.com:cilium/cilium.git' or 'https://github.com/cilium/cilium'.
+func ParseRemoteURL(u string) (string, error) {
+	path := u
+	for _, prefix := range []string{"https://github.com/", "http://github.com/", "ssh://git@github.com/", "git@github.com:", "github.com/"} {
+		if strings.HasPrefix(u, prefix) {
+			path = strings.TrimPrefix(u, prefix)
+			break
+		}
+	}
+	if path == u {
+		return "", fmt.Errorf("remote URL %s is not a GitHub repository", u)
+	}
+	path = strings.TrimSuffix(strings.TrimSuffix(path, "/"), ".git")
+	if parts := strings.Split(path, "/"); len(parts) != 2 || len(parts[0]) == 0 || len(parts[1]) == 0 {
+		return "", fmt.Errorf("remote URL %s is not a GitHub repository", u)
+	}
+	return path, nil
+}
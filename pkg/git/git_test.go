@@ -0,0 +1,45 @@
+// Copyright 2021 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package git
+
+import "testing"
+
+func TestParseRemoteURL(t *testing.T) {
+	tests := []struct {
+		url     string
+		want    string
+		wantErr bool
+	}{
+		{url: "git@github.com:cilium/cilium.git", want: "cilium/cilium"},
+		{url: "git@github.com:cilium/cilium", want: "cilium/cilium"},
+		{url: "https://github.com/cilium/tetragon.git", want: "cilium/tetragon"},
+		{url: "https://github.com/cilium/tetragon/", want: "cilium/tetragon"},
+		{url: "ssh://git@github.com/cilium/release.git", want: "cilium/release"},
+		{url: "github.com/cilium/cilium", want: "cilium/cilium"},
+		{url: "https://gitlab.com/cilium/cilium.git", wantErr: true},
+		{url: "https://github.com/cilium", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			got, err := ParseRemoteURL(tt.url)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseRemoteURL() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseRemoteURL() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
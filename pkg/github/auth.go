@@ -0,0 +1,68 @@
+// Copyright 2020 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package github
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// TokenEnvVars are the environment variables the token is read from, in
+// order of precedence.
+var TokenEnvVars = []string{"GITHUB_TOKEN", "GH_TOKEN"}
+
+// TokenFileFlag is the flag, accepted by all the commands, of the file the
+// token is read from.
+const TokenFileFlag = "token-file"
+
+// Token returns the token to authenticate with. In order of precedence, it
+// is read from tokenFile, from stdin if tokenFile is '-', or from the first
+// of TokenEnvVars set. An empty token is returned if none of them is given,
+// so that the public repositories can still be queried.
+func Token(tokenFile string, stdin io.Reader, getenv func(string) string) (string, error) {
+	if len(tokenFile) != 0 {
+		var (
+			b   []byte
+			err error
+		)
+		if tokenFile == "-" {
+			b, err = io.ReadAll(stdin)
+		} else {
+			b, err = os.ReadFile(tokenFile)
+		}
+		if err != nil {
+			return "", fmt.Errorf("unable to read the token: %w", err)
+		}
+		token := strings.TrimSpace(string(b))
+		if len(token) == 0 {
+			return "", fmt.Errorf("--%s %s is empty", TokenFileFlag, tokenFile)
+		}
+		return token, nil
+	}
+	for _, env := range TokenEnvVars {
+		if token := strings.TrimSpace(getenv(env)); len(token) != 0 {
+			return token, nil
+		}
+	}
+	return "", nil
+}
+
+// TokenHint returns how to give the token, for the errors about a missing or
+// invalid token.
+func TokenHint() string {
+	return fmt.Sprintf("please set %s or --%s", strings.Join(TokenEnvVars, ", "), TokenFileFlag)
+}
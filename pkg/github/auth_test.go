@@ -0,0 +1,59 @@
+// Copyright 2020 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package github
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestToken(t *testing.T) {
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	if err := os.WriteFile(tokenFile, []byte("file-token\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	emptyFile := filepath.Join(t.TempDir(), "empty")
+	if err := os.WriteFile(emptyFile, nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name      string
+		tokenFile string
+		env       map[string]string
+		want      string
+		wantErr   bool
+	}{
+		{name: "file over env", tokenFile: tokenFile, env: map[string]string{"GITHUB_TOKEN": "env-token"}, want: "file-token"},
+		{name: "stdin", tokenFile: "-", want: "stdin-token"},
+		{name: "empty file", tokenFile: emptyFile, wantErr: true},
+		{name: "missing file", tokenFile: tokenFile + ".missing", wantErr: true},
+		{name: "GITHUB_TOKEN over GH_TOKEN", env: map[string]string{"GITHUB_TOKEN": "github", "GH_TOKEN": "gh"}, want: "github"},
+		{name: "GH_TOKEN", env: map[string]string{"GH_TOKEN": "gh"}, want: "gh"},
+		{name: "none"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Token(tt.tokenFile, strings.NewReader("stdin-token\n"), func(k string) string { return tt.env[k] })
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Token() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Token() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -16,20 +16,38 @@ package github
 
 import (
 	"context"
+	"time"
 
 	gh "github.com/google/go-github/v50/github"
 	"golang.org/x/oauth2"
+
+	"github.com/cilium/release/pkg/journal"
+	"github.com/cilium/release/pkg/tracing"
+	"github.com/cilium/release/pkg/usage"
 )
 
-func NewClient(ghToken string) *gh.Client {
-	return gh.NewClient(
-		oauth2.NewClient(
-			context.Background(),
-			oauth2.StaticTokenSource(
-				&oauth2.Token{
-					AccessToken: ghToken,
-				},
-			),
+// NewClient returns a GitHub client authenticated with the given token. The
+// API calls made by the client are recorded in tracker, if not nil, and
+// traced, and the mutating ones in j, if not nil. The idempotent calls
+// failing with a transient error are retried and incomplete results caused
+// by SAML single sign-on are warned about. All the clients share the same
+// rate limiter, see SetRateLimit.
+func NewClient(ghToken string, tracker *usage.Tracker, j *journal.Journal) *gh.Client {
+	httpClient := oauth2.NewClient(
+		context.Background(),
+		oauth2.StaticTokenSource(
+			&oauth2.Token{
+				AccessToken: ghToken,
+			},
 		),
 	)
+	httpClient.Transport = &retryTransport{
+		next: &rateLimitTransport{
+			next:    newSSOTransport(tracing.RoundTripper(tracker.RoundTripper(j.RoundTripper(httpClient.Transport)))),
+			limiter: sharedLimiter,
+		},
+		maxRetries: 5,
+		baseDelay:  time.Second,
+	}
+	return gh.NewClient(httpClient)
 }
@@ -0,0 +1,50 @@
+// Copyright 2021 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package github
+
+import (
+	"context"
+	"strings"
+
+	gh "github.com/google/go-github/v50/github"
+)
+
+// UpsertComment creates a comment with the given body on the issue or PR,
+// or updates the comment previously created with the same marker so that a
+// single "sticky" comment is kept up to date. The marker, e.g. an HTML
+// comment, is added to the body.
+func UpsertComment(ctx context.Context, ghClient *gh.Client, owner, repo string, number int, marker, body string) (*gh.IssueComment, error) {
+	body = marker + "\n" + body
+	opts := &gh.IssueListCommentsOptions{ListOptions: gh.ListOptions{PerPage: 100}}
+	for {
+		comments, resp, err := ghClient.Issues.ListComments(ctx, owner, repo, number, opts)
+		if err != nil {
+			return nil, err
+		}
+		for _, comment := range comments {
+			if !strings.HasPrefix(comment.GetBody(), marker) {
+				continue
+			}
+			comment, _, err := ghClient.Issues.EditComment(ctx, owner, repo, comment.GetID(), &gh.IssueComment{Body: &body})
+			return comment, err
+		}
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+	comment, _, err := ghClient.Issues.CreateComment(ctx, owner, repo, number, &gh.IssueComment{Body: &body})
+	return comment, err
+}
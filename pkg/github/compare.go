@@ -0,0 +1,89 @@
+// Copyright 2021 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package github
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	gh "github.com/google/go-github/v50/github"
+)
+
+// CompareCommits returns the commits between base and head, ordered from
+// head to base. As GitHub lists at most 250 commits per comparison, base is
+// compared with the oldest commit found until base is reached.
+// ErrTruncatedCompare is returned, along with the commits found, if fewer
+// commits than GitHub counted could be listed.
+func CompareCommits(ctx context.Context, ghClient *gh.Client, owner, repo, base, head string) ([]*gh.RepositoryCommit, error) {
+	var commits []*gh.RepositoryCommit
+	cont := false
+	prevHead := ""
+	total := -1
+	origHead := head
+
+	for {
+		fmt.Fprintf(os.Stderr, "Comparing %s...%s\n", base, head)
+		cc, _, err := ghClient.Repositories.CompareCommits(ctx, owner, repo, base, head, &gh.ListOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("unable to compare commits %s %s: %w", base, head, classify(err))
+		}
+		if total == -1 {
+			total = cc.GetTotalCommits()
+		}
+		if len(cc.Commits) == 0 {
+			break
+		}
+		if !cont && len(cc.Commits) == total {
+			for i := len(cc.Commits) - 1; i >= 0; i-- {
+				commits = append(commits, cc.Commits[i])
+			}
+			return commits, nil
+		}
+		if prevHead == cc.Commits[len(cc.Commits)-1].GetSHA() {
+			if cc.Commits[0].GetSHA() != "" {
+				commits = append(commits, cc.Commits[0])
+			}
+			break
+		}
+		start := len(cc.Commits) - 1
+		if cont {
+			// We want to ignore the last sha for if the number of commits
+			// returned by github are throttled. If they are throttled
+			// we will keep comparing commits until the last commit
+			// points to the base commit.
+			start = start - 1
+		}
+		// List of commits are ordered from base to head
+		// so we want to order them from head to base
+		// For example, assuming commit SHAs are integers:
+		// compare 1...10 will return [6,7,8,9,10]
+		// We will store [10,9,8,7,6] and ask for compare 1...6
+		// This will return [6,5,4,3,2,1] which we will ignore 6
+		// since it's already stored in the list of SHAs and continue
+		for i := start; i != 0; i-- {
+			if cc.Commits[i].GetSHA() != "" {
+				commits = append(commits, cc.Commits[i])
+			}
+		}
+		head = commits[len(commits)-1].GetSHA()
+		cont = true
+		prevHead = cc.Commits[len(cc.Commits)-1].GetSHA()
+	}
+	if len(commits) < total {
+		return commits, fmt.Errorf("%w: found %d of the %d commits between %s and %s", ErrTruncatedCompare, len(commits), total, base, origHead)
+	}
+	return commits, nil
+}
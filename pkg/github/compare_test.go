@@ -0,0 +1,84 @@
+// Copyright 2021 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+
+	gh "github.com/google/go-github/v50/github"
+)
+
+// newTestClient returns a client sending its requests to the given handler.
+func newTestClient(t *testing.T, handler http.HandlerFunc) *gh.Client {
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+	ghClient := gh.NewClient(nil)
+	ghClient.BaseURL, _ = url.Parse(srv.URL + "/")
+	return ghClient
+}
+
+func TestCompareCommits(t *testing.T) {
+	tests := []struct {
+		name    string
+		commits int
+		// missing is the number of commits counted by GitHub but never
+		// listed.
+		missing int
+		wantErr error
+	}{
+		{name: "single commit", commits: 1},
+		{name: "single page", commits: 42},
+		{name: "several pages", commits: 600},
+		{name: "truncated", commits: 300, missing: 5, wantErr: ErrTruncatedCompare},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// The commits are c1..cN on top of the base c0 and, as GitHub
+			// does, at most the 250 most recent ones are listed.
+			ghClient := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+				_, head, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/repos/o/r/compare/"), "...")
+				n, _ := strconv.Atoi(strings.TrimPrefix(head, "c"))
+				cc := &gh.CommitsComparison{TotalCommits: gh.Int(n + tt.missing)}
+				for i := n - 249; i <= n; i++ {
+					if i > 0 {
+						cc.Commits = append(cc.Commits, &gh.RepositoryCommit{SHA: gh.String(fmt.Sprintf("c%d", i))})
+					}
+				}
+				json.NewEncoder(w).Encode(cc)
+			})
+			commits, err := CompareCommits(context.Background(), ghClient, "o", "r", "c0", fmt.Sprintf("c%d", tt.commits))
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("got error %v, want %v", err, tt.wantErr)
+			}
+			if len(commits) != tt.commits {
+				t.Fatalf("got %d commits, want %d", len(commits), tt.commits)
+			}
+			for i, c := range commits {
+				if want := fmt.Sprintf("c%d", tt.commits-i); c.GetSHA() != want {
+					t.Fatalf("got commit %s at %d, want %s", c.GetSHA(), i, want)
+				}
+			}
+		})
+	}
+}
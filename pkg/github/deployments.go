@@ -0,0 +1,77 @@
+// Copyright 2021 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package github
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	gh "github.com/google/go-github/v50/github"
+)
+
+// ApprovalInterval is the interval at which the status of a deployment
+// waiting for approval is polled.
+var ApprovalInterval = 30 * time.Second
+
+// WaitForApproval creates a deployment of ref to the GitHub Environment and
+// waits for it to be approved. As the required reviewers of an environment
+// only gate workflow jobs, the deployment is approved by setting its status
+// to 'success' or 'in_progress', e.g. from a workflow job of that
+// environment, and rejected by setting it to 'failure' or 'error'. The
+// statuses set by the authenticated user are ignored so that the step can't
+// be approved by the one running it.
+func WaitForApproval(ctx context.Context, ghClient *gh.Client, owner, repo, ref, environment, description string) (*gh.Deployment, error) {
+	user, _, err := ghClient.Users.Get(ctx, "")
+	if err != nil {
+		return nil, fmt.Errorf("unable to get authenticated user: %w", err)
+	}
+	deployment, _, err := ghClient.Repositories.CreateDeployment(ctx, owner, repo, &gh.DeploymentRequest{
+		Ref:              &ref,
+		Task:             gh.String("release"),
+		AutoMerge:        gh.Bool(false),
+		RequiredContexts: &[]string{},
+		Environment:      &environment,
+		Description:      &description,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to create deployment to environment %s: %w", environment, err)
+	}
+	for {
+		statuses, _, err := ghClient.Repositories.ListDeploymentStatuses(ctx, owner, repo, deployment.GetID(), &gh.ListOptions{PerPage: 100})
+		if err != nil {
+			return nil, fmt.Errorf("unable to get status of deployment %d: %w", deployment.GetID(), err)
+		}
+		// The statuses are listed from the most recent one.
+		var status *gh.DeploymentStatus
+		for _, s := range statuses {
+			if s.GetCreator().GetLogin() != user.GetLogin() {
+				status = s
+				break
+			}
+		}
+		switch status.GetState() {
+		case "success", "in_progress":
+			return deployment, nil
+		case "failure", "error", "inactive":
+			return nil, fmt.Errorf("deployment %d to environment %s was rejected by %s", deployment.GetID(), environment, status.GetCreator().GetLogin())
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(ApprovalInterval):
+		}
+	}
+}
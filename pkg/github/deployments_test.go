@@ -0,0 +1,80 @@
+// Copyright 2021 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package github
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestWaitForApproval(t *testing.T) {
+	ApprovalInterval = 0
+	tests := []struct {
+		name string
+		// statuses are the states of the deployment returned by
+		// successive polls, the last one being returned afterwards.
+		statuses []string
+		// selfPolls is the number of polls returning an approval by
+		// the authenticated user before the given statuses.
+		selfPolls int
+		wantErr   bool
+	}{
+		{name: "approved", statuses: []string{"", "pending", "success"}},
+		{name: "approved in progress", statuses: []string{"in_progress"}},
+		{name: "rejected", statuses: []string{"pending", "failure"}, wantErr: true},
+		{name: "self-approval ignored", statuses: []string{"success", "success"}, selfPolls: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			polls := 0
+			ghClient := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+				switch r.Method + " " + r.URL.Path {
+				case "GET /user":
+					fmt.Fprint(w, `{"login": "releaser"}`)
+				case "POST /repos/o/r/deployments":
+					fmt.Fprint(w, `{"id": 1}`)
+				case "GET /repos/o/r/deployments/1/statuses":
+					state := tt.statuses[len(tt.statuses)-1]
+					if polls < len(tt.statuses) {
+						state = tt.statuses[polls]
+					}
+					self := polls < tt.selfPolls
+					polls++
+					if state == "" {
+						fmt.Fprint(w, `[]`)
+						return
+					}
+					if self {
+						fmt.Fprintf(w, `[{"state": %q, "creator": {"login": "releaser"}}]`, state)
+						return
+					}
+					fmt.Fprintf(w, `[{"state": %q, "creator": {"login": "maintainer"}}, {"state": "success", "creator": {"login": "releaser"}}]`, state)
+				default:
+					t.Errorf("unexpected request %s %s", r.Method, r.URL)
+					http.NotFound(w, r)
+				}
+			})
+			_, err := WaitForApproval(context.Background(), ghClient, "o", "r", "main", "release", "abort v1.14.3")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("WaitForApproval() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if polls != len(tt.statuses) {
+				t.Errorf("polled %d times, want %d", polls, len(tt.statuses))
+			}
+		})
+	}
+}
@@ -0,0 +1,146 @@
+// Copyright 2021 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package github
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	gh "github.com/google/go-github/v50/github"
+)
+
+var (
+	// ErrRateLimited is returned when the API rate limit, primary or
+	// secondary, is exceeded. RateLimitReset returns when it is reset.
+	ErrRateLimited = errors.New("API rate limit exceeded")
+	// ErrNotFound is returned when a resource doesn't exist or the token
+	// can't see it.
+	ErrNotFound = errors.New("not found")
+	// ErrForbidden is returned when the token isn't allowed to access a
+	// resource.
+	ErrForbidden = errors.New("forbidden")
+	// ErrTruncatedCompare is returned, along with the commits found, when
+	// not all the commits between two refs could be listed.
+	ErrTruncatedCompare = errors.New("truncated comparison")
+	// ErrTooManyResults is returned when a search matches more results
+	// than the search API returns, see maxSearchResults.
+	ErrTooManyResults = errors.New("too many search results")
+)
+
+// Error is an error of the GitHub API classified as ErrRateLimited,
+// ErrNotFound or ErrForbidden, which errors.Is reports.
+type Error struct {
+	// Kind is ErrRateLimited, ErrNotFound or ErrForbidden.
+	Kind error
+	// Reset is the time at which the rate limit is reset, for
+	// ErrRateLimited.
+	Reset time.Time
+	Err   error
+}
+
+func (e *Error) Error() string {
+	return e.Err.Error()
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+func (e *Error) Is(target error) bool {
+	return target == e.Kind
+}
+
+// classify returns err wrapped into an *Error if it is a rate limit, not
+// found or forbidden error, or err as is otherwise.
+func classify(err error) error {
+	var (
+		rateLimit *gh.RateLimitError
+		abuse     *gh.AbuseRateLimitError
+		errResp   *gh.ErrorResponse
+	)
+	switch {
+	case errors.As(err, &rateLimit):
+		return &Error{Kind: ErrRateLimited, Reset: rateLimit.Rate.Reset.Time, Err: err}
+	case errors.As(err, &abuse):
+		return &Error{Kind: ErrRateLimited, Reset: time.Now().Add(abuse.GetRetryAfter()), Err: err}
+	case errors.As(err, &errResp) && errResp.Response != nil:
+		switch errResp.Response.StatusCode {
+		case http.StatusNotFound:
+			return &Error{Kind: ErrNotFound, Err: err}
+		case http.StatusForbidden:
+			return &Error{Kind: ErrForbidden, Err: err}
+		}
+	}
+	return err
+}
+
+// RateLimitReset returns the time at which the rate limit is reset if err
+// is an ErrRateLimited.
+func RateLimitReset(err error) (time.Time, bool) {
+	var e *Error
+	if errors.As(err, &e) && e.Kind == ErrRateLimited {
+		return e.Reset, true
+	}
+	return time.Time{}, false
+}
+
+// Explain returns how to fix the given error if it was caused by the token
+// not being allowed to access a resource, or an empty string otherwise.
+func Explain(err error) string {
+	var gqlErr *GraphQLError
+	if errors.As(err, &gqlErr) {
+		if len(gqlErr.SSO) == 0 && strings.Contains(strings.Join(gqlErr.Messages, " "), "SAML enforcement") {
+			return ssoHint("required")
+		}
+		return ssoHint(gqlErr.SSO)
+	}
+	var errResp *gh.ErrorResponse
+	if !errors.As(err, &errResp) || errResp.Response == nil {
+		return ""
+	}
+	resp := errResp.Response
+
+	if sso := resp.Header.Get(ssoHeader); strings.HasPrefix(sso, "required") {
+		return ssoHint(sso)
+	}
+
+	switch resp.StatusCode {
+	case http.StatusUnauthorized:
+		return "The token is missing, invalid or expired, " + TokenHint() + "."
+	case http.StatusForbidden:
+		needed := resp.Header.Get("X-Accepted-GitHub-Permissions")
+		switch {
+		case strings.Contains(errResp.Message, "personal access token"):
+			hint := "The fine-grained token lacks a permission on the repository."
+			if len(needed) != 0 {
+				hint += fmt.Sprintf(" It needs one of: %s.", needed)
+			}
+			return hint + " Run 'release check auth' to check the token."
+		case strings.Contains(errResp.Message, "by integration"):
+			hint := "The GitHub App, or the GITHUB_TOKEN of the workflow, lacks a permission."
+			if len(needed) != 0 {
+				hint += fmt.Sprintf(" It needs one of: %s, e.g. in the 'permissions' of the workflow.", needed)
+			}
+			return hint
+		}
+	case http.StatusNotFound:
+		return "The resource doesn't exist or the token can't see it: fine-grained tokens must be granted access to the repository " +
+			"and classic tokens need the 'repo' scope for private repositories. Run 'release check auth' to check the token."
+	}
+	return ""
+}
@@ -0,0 +1,171 @@
+// Copyright 2021 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package github
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	gh "github.com/google/go-github/v50/github"
+)
+
+func TestExplain(t *testing.T) {
+	errResp := func(status int, message string, header map[string]string) error {
+		h := http.Header{}
+		for k, v := range header {
+			h.Set(k, v)
+		}
+		return fmt.Errorf("unable to get PR: %w", &gh.ErrorResponse{
+			Response: &http.Response{StatusCode: status, Header: h},
+			Message:  message,
+		})
+	}
+	tests := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{
+			name: "not an API error",
+			err:  errors.New("connection reset"),
+		},
+		{
+			name: "SAML SSO",
+			err: errResp(http.StatusForbidden, "Resource protected by organization SAML enforcement.", map[string]string{
+				"X-GitHub-SSO": "required; url=https://github.com/orgs/cilium/sso?authorization_request=abc",
+			}),
+			want: "Authorize it at https://github.com/orgs/cilium/sso?authorization_request=abc",
+		},
+		{
+			name: "fine-grained token",
+			err: errResp(http.StatusForbidden, "Resource not accessible by personal access token", map[string]string{
+				"X-Accepted-GitHub-Permissions": "contents=write",
+			}),
+			want: "It needs one of: contents=write.",
+		},
+		{
+			name: "workflow token",
+			err:  errResp(http.StatusForbidden, "Resource not accessible by integration", nil),
+			want: "GITHUB_TOKEN of the workflow",
+		},
+		{
+			name: "not found",
+			err:  errResp(http.StatusNotFound, "Not Found", nil),
+			want: "fine-grained tokens must be granted access to the repository",
+		},
+		{
+			name: "validation failed",
+			err:  errResp(http.StatusUnprocessableEntity, "Validation Failed", nil),
+		},
+		{
+			name: "GraphQL SAML SSO",
+			err: &GraphQLError{
+				Messages: []string{"Resource protected by organization SAML enforcement. You must grant your Personal Access token access to this organization."},
+				Types:    []string{"FORBIDDEN"},
+				SSO:      "partial-results; organizations=21955855",
+			},
+			want: "Authorize it in the settings of the token.",
+		},
+		{
+			name: "GraphQL SAML SSO without header",
+			err: &GraphQLError{
+				Messages: []string{"Resource protected by organization SAML enforcement. You must grant your Personal Access token access to this organization."},
+			},
+			want: "The organization enforces SAML single sign-on",
+		},
+		{
+			name: "GraphQL error",
+			err:  &GraphQLError{Messages: []string{"Could not resolve to a Repository"}, Types: []string{"NOT_FOUND"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Explain(tt.err)
+			if len(tt.want) == 0 && len(got) != 0 || !strings.Contains(got, tt.want) {
+				t.Errorf("Explain() = %q, want it to contain %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClassify(t *testing.T) {
+	reset := time.Date(2023, 4, 1, 12, 0, 0, 0, time.UTC)
+	errResp := func(status int) error {
+		return &gh.ErrorResponse{Response: &http.Response{StatusCode: status}}
+	}
+	tests := []struct {
+		name      string
+		err       error
+		want      error
+		wantReset time.Time
+	}{
+		{
+			name:      "rate limited",
+			err:       &gh.RateLimitError{Rate: gh.Rate{Reset: gh.Timestamp{Time: reset}}},
+			want:      ErrRateLimited,
+			wantReset: reset,
+		},
+		{
+			name: "secondary rate limit",
+			err:  &gh.AbuseRateLimitError{},
+			want: ErrRateLimited,
+		},
+		{
+			name: "not found",
+			err:  errResp(http.StatusNotFound),
+			want: ErrNotFound,
+		},
+		{
+			name: "forbidden",
+			err:  errResp(http.StatusForbidden),
+			want: ErrForbidden,
+		},
+		{
+			name: "unresolved upstream PR",
+			err:  &UnresolvedUpstreamError{BackportPR: 2, UpstreamPR: 1},
+			want: ErrNotFound,
+		},
+		{
+			name: "GraphQL not found",
+			err:  &GraphQLError{Types: []string{"NOT_FOUND"}},
+			want: ErrNotFound,
+		},
+		{
+			name: "other error",
+			err:  errResp(http.StatusUnprocessableEntity),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := fmt.Errorf("unable to get PR: %w", classify(tt.err))
+			for _, kind := range []error{ErrRateLimited, ErrNotFound, ErrForbidden} {
+				if got := errors.Is(err, kind); got != (kind == tt.want) {
+					t.Errorf("errors.Is(%v) = %t, want %t", kind, got, kind == tt.want)
+				}
+			}
+			if !errors.Is(err, tt.err) {
+				t.Errorf("the original error isn't wrapped")
+			}
+			got, ok := RateLimitReset(err)
+			if ok != (tt.want == ErrRateLimited) || !tt.wantReset.IsZero() && !got.Equal(tt.wantReset) {
+				t.Errorf("RateLimitReset() = %s, %t, want %s", got, ok, tt.wantReset)
+			}
+		})
+	}
+}
@@ -0,0 +1,96 @@
+// Copyright 2021 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+
+	gh "github.com/google/go-github/v50/github"
+)
+
+type graphQLRequest struct {
+	Query     string                 `json:"query"`
+	Variables map[string]interface{} `json:"variables,omitempty"`
+}
+
+type graphQLError struct {
+	Type    string `json:"type"`
+	Message string `json:"message"`
+}
+
+type graphQLResponse struct {
+	Data   json.RawMessage `json:"data"`
+	Errors []graphQLError  `json:"errors"`
+}
+
+// GraphQLError is returned when the GraphQL API reports errors. errors.Is
+// reports ErrNotFound or ErrForbidden according to their types.
+type GraphQLError struct {
+	Messages []string
+	// Types are the types of the errors, e.g. 'NOT_FOUND' or 'FORBIDDEN'.
+	Types []string
+	// SSO is the X-GitHub-SSO header of the response, set if the token
+	// isn't authorized for the SAML single sign-on of an organization.
+	SSO string
+}
+
+func (e *GraphQLError) Error() string {
+	return "graphql: " + strings.Join(e.Messages, "; ")
+}
+
+func (e *GraphQLError) Is(target error) bool {
+	for _, t := range e.Types {
+		switch {
+		case t == "NOT_FOUND" && target == ErrNotFound,
+			t == "FORBIDDEN" && target == ErrForbidden:
+			return true
+		}
+	}
+	return false
+}
+
+// GraphQL executes the given GraphQL query, or mutation, against the GitHub
+// GraphQL API and unmarshals the "data" field of the response into out.
+func GraphQL(ctx context.Context, ghClient *gh.Client, query string, vars map[string]interface{}, out interface{}) error {
+	req, err := ghClient.NewRequest("POST", "graphql", &graphQLRequest{
+		Query:     query,
+		Variables: vars,
+	})
+	if err != nil {
+		return err
+	}
+	resp := &graphQLResponse{}
+	httpResp, err := ghClient.Do(ctx, req, resp)
+	if err != nil {
+		return err
+	}
+	if len(resp.Errors) != 0 {
+		gqlErr := &GraphQLError{}
+		for _, e := range resp.Errors {
+			gqlErr.Messages = append(gqlErr.Messages, e.Message)
+			gqlErr.Types = append(gqlErr.Types, e.Type)
+		}
+		if httpResp != nil {
+			gqlErr.SSO = httpResp.Header.Get(ssoHeader)
+		}
+		return gqlErr
+	}
+	if out == nil {
+		return nil
+	}
+	return json.Unmarshal(resp.Data, out)
+}
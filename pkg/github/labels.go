@@ -24,10 +24,19 @@ import (
 const (
 	releaseNoteBlock = "```release-note"
 	upstreamPRsBlock = "```upstream-prs"
-	commentTag       = "<!--"
+	// upgradeNotesBlock holds the instructions to upgrade past a PR with
+	// an upgrade impact.
+	upgradeNotesBlock = "```upgrade-notes"
+	commentTag        = "<!--"
 )
 
 func textBlockBetween(body, str string) string {
+	return strings.TrimSpace(strings.Join(blockLines(body, str), " "))
+}
+
+// blockLines returns the lines of the block of body starting with the
+// given line and ending with a closing fence.
+func blockLines(body, str string) []string {
 	lines := strings.Split(body, "\n")
 	beginning, end := -1, -1
 	for idx, line := range lines {
@@ -41,12 +50,23 @@ func textBlockBetween(body, str string) string {
 		}
 	}
 	if beginning == end {
-		return ""
+		return nil
 	}
 	if end == -1 {
 		end = len(lines)
 	}
-	return strings.TrimSpace(strings.Join(lines[beginning+1:end], " "))
+	return lines[beginning+1 : end]
+}
+
+// upgradeNotesFromBody returns the upgrade notes block of the PR body,
+// keeping its lines, or an empty string if it isn't filled in.
+func upgradeNotesFromBody(body string) string {
+	lines := blockLines(strings.ReplaceAll(body, "\r\n", "\n"), upgradeNotesBlock)
+	notes := strings.TrimSpace(strings.Join(lines, "\n"))
+	if strings.Contains(notes, commentTag) {
+		return ""
+	}
+	return notes
 }
 
 func getUpstreamPRs(body string) []int {
@@ -84,13 +104,22 @@ func getUpstreamPRs(body string) []int {
 // getReleaseNote returns the release node if it is present in the given body
 // otherwise it will fallback to the title.
 func getReleaseNote(title, body string) string {
+	if note, ok := releaseNoteFromBody(body); ok {
+		return note
+	}
+	return strings.TrimSpace(title)
+}
+
+// releaseNoteFromBody returns the release note block of the PR body and
+// whether it was filled in.
+func releaseNoteFromBody(body string) (string, bool) {
 	if strings.Contains(body, releaseNoteBlock) {
 		block := textBlockBetween(body, releaseNoteBlock)
 		if len(block) != 0 && !strings.Contains(block, commentTag) {
-			return block
+			return block, true
 		}
 	}
-	return strings.TrimSpace(title)
+	return "", false
 }
 
 // getReleaseLabel returns the release label found in the slice of labels.
@@ -123,3 +152,9 @@ func parseGHLabels(ghLabels []*gh.Label) []string {
 	}
 	return lbls
 }
+
+// UpstreamPRs returns the numbers of the upstream PRs referenced in the
+// "upstream-prs" block of a backport PR body.
+func UpstreamPRs(body string) []int {
+	return getUpstreamPRs(body)
+}
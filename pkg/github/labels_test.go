@@ -177,3 +177,40 @@ func Test_getBackportPRs(t *testing.T) {
 		})
 	}
 }
+
+func Test_upgradeNotesFromBody(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want string
+	}{
+		{
+			name: "multi-line notes",
+			body: "Rename the foo option.\r\n" +
+				"\r\n" +
+				"```upgrade-notes\r\n" +
+				"The `foo` option was renamed to `bar`.\r\n" +
+				"\r\n" +
+				"* Set `bar` in your Helm values.\r\n" +
+				"```\r\n",
+			want: "The `foo` option was renamed to `bar`.\n\n* Set `bar` in your Helm values.",
+		},
+		{
+			name: "template left as is",
+			body: "```upgrade-notes\n<!-- Describe the steps needed to upgrade -->\n```\n",
+			want: "",
+		},
+		{
+			name: "no block",
+			body: "```release-note\nFix foo\n```\n",
+			want: "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := upgradeNotesFromBody(tt.body); got != tt.want {
+				t.Errorf("upgradeNotesFromBody() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
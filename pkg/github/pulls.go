@@ -0,0 +1,75 @@
+// Copyright 2021 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package github
+
+import (
+	"context"
+	"sort"
+
+	gh "github.com/google/go-github/v50/github"
+)
+
+// ListFiles returns all files changed by the given PR. GitHub lists at most
+// 3000 files.
+func ListFiles(ctx context.Context, ghClient *gh.Client, owner, repo string, prNumber int) ([]*gh.CommitFile, error) {
+	var files []*gh.CommitFile
+	opts := &gh.ListOptions{PerPage: 100}
+	for {
+		f, resp, err := ghClient.PullRequests.ListFiles(ctx, owner, repo, prNumber, opts)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, f...)
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+	return files, nil
+}
+
+// ListApprovers returns the sorted logins of the users whose last review of
+// the given PR, comments aside, is an approval. The author of the PR is left
+// out.
+func ListApprovers(ctx context.Context, ghClient *gh.Client, owner, repo string, prNumber int, author string) ([]string, error) {
+	states := map[string]string{}
+	opts := &gh.ListOptions{PerPage: 100}
+	for {
+		reviews, resp, err := ghClient.PullRequests.ListReviews(ctx, owner, repo, prNumber, opts)
+		if err != nil {
+			return nil, classify(err)
+		}
+		// The reviews are listed in chronological order.
+		for _, review := range reviews {
+			login := review.GetUser().GetLogin()
+			if len(login) == 0 || login == author || review.GetState() == "COMMENTED" {
+				continue
+			}
+			states[login] = review.GetState()
+		}
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+	approvers := []string{}
+	for login, state := range states {
+		if state == "APPROVED" {
+			approvers = append(approvers, login)
+		}
+	}
+	sort.Strings(approvers)
+	return approvers, nil
+}
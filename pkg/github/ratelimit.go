@@ -0,0 +1,188 @@
+// Copyright 2021 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package github
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// RateLimitFlag is the flag, accepted by all the commands, of the maximum
+// number of requests per second sent to GitHub.
+const RateLimitFlag = "rate-limit"
+
+// DefaultRateLimit is the number of requests per second sent to GitHub by
+// default, low enough for parallel runs not to trip its secondary rate
+// limits.
+const DefaultRateLimit = 10
+
+// sharedLimiter is the limiter of all the clients returned by NewClient, so
+// that the requests sent in parallel, e.g. generating the release notes of
+// several branches, are limited as a whole.
+var sharedLimiter = NewLimiter(DefaultRateLimit)
+
+// SetRateLimit sets the number of requests per second sent to GitHub by all
+// the clients returned by NewClient. The requests are not limited if rate
+// isn't positive.
+func SetRateLimit(rate float64) {
+	sharedLimiter.setRate(rate)
+}
+
+// Limiter is a token bucket limiting the rate of the requests sent to
+// GitHub, safe for concurrent use. It also holds all the requests back when
+// GitHub asks to, see Pause.
+type Limiter struct {
+	mu sync.Mutex
+	// rate is the number of tokens added per second, up to rate tokens.
+	// The requests are not limited if it isn't positive.
+	rate   float64
+	tokens float64
+	last   time.Time
+	// until is when the requests can be sent again after a pause.
+	until time.Time
+}
+
+// NewLimiter returns a limiter of the given number of requests per second,
+// allowing bursts of that many requests.
+func NewLimiter(rate float64) *Limiter {
+	return &Limiter{rate: rate, tokens: rate, last: time.Now()}
+}
+
+func (l *Limiter) setRate(rate float64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.rate, l.tokens, l.last = rate, rate, time.Now()
+}
+
+// reserve takes a token and returns how long to wait before using it.
+func (l *Limiter) reserve(now time.Time) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	var wait time.Duration
+	if now.Before(l.until) {
+		wait = l.until.Sub(now)
+	}
+	if l.rate <= 0 {
+		return wait
+	}
+	l.tokens += now.Sub(l.last).Seconds() * l.rate
+	if l.tokens > l.rate {
+		l.tokens = l.rate
+	}
+	l.last = now
+	l.tokens--
+	if l.tokens < 0 {
+		if d := time.Duration(-l.tokens / l.rate * float64(time.Second)); d > wait {
+			wait = d
+		}
+	}
+	return wait
+}
+
+// Wait blocks until a request can be sent or ctx is done.
+func (l *Limiter) Wait(ctx context.Context) error {
+	wait := l.reserve(time.Now())
+	if wait <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Pause holds all the requests back for d, e.g. as asked by GitHub when a
+// secondary rate limit is hit.
+func (l *Limiter) Pause(d time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if until := time.Now().Add(d); until.After(l.until) {
+		l.until = until
+	}
+}
+
+// rateLimitTransport waits for the limiter before sending each request.
+// When GitHub answers with a Retry-After header, all the requests are held
+// back for that long and the idempotent request is retried once. When the
+// primary rate limit is exhausted, all the requests are held back until it
+// resets and the response is returned as is, the reset being possibly an
+// hour away.
+type rateLimitTransport struct {
+	next    http.RoundTripper
+	limiter *Limiter
+}
+
+func (t *rateLimitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		if err := t.limiter.Wait(req.Context()); err != nil {
+			return nil, err
+		}
+		resp, err := t.next.RoundTrip(req)
+		if err != nil {
+			return resp, err
+		}
+		if reset, ok := rateLimitReset(resp); ok {
+			t.limiter.Pause(time.Until(reset))
+			return resp, nil
+		}
+		retryAfter, ok := retryAfter(resp)
+		if !ok {
+			return resp, nil
+		}
+		t.limiter.Pause(retryAfter)
+		if attempt > 0 || !idempotent(req.Method) || req.Body != nil {
+			return resp, nil
+		}
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+	}
+}
+
+// retryAfter returns the delay given by the Retry-After header of the
+// responses to the requests rejected by a secondary rate limit.
+func retryAfter(resp *http.Response) (time.Duration, bool) {
+	if resp.StatusCode != http.StatusForbidden && resp.StatusCode != http.StatusTooManyRequests {
+		return 0, false
+	}
+	seconds, err := strconv.Atoi(resp.Header.Get("Retry-After"))
+	if err != nil || seconds < 0 {
+		return 0, false
+	}
+	return time.Duration(seconds) * time.Second, true
+}
+
+// rateLimitReset returns when the primary rate limit resets, for the
+// responses to the requests rejected as it is exhausted.
+func rateLimitReset(resp *http.Response) (time.Time, bool) {
+	if resp.StatusCode != http.StatusForbidden && resp.StatusCode != http.StatusTooManyRequests {
+		return time.Time{}, false
+	}
+	if resp.Header.Get("X-RateLimit-Remaining") != "0" {
+		return time.Time{}, false
+	}
+	reset, err := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return time.Unix(reset, 0), true
+}
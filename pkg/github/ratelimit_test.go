@@ -0,0 +1,101 @@
+// Copyright 2021 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package github
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestLimiter(t *testing.T) {
+	start := time.Now()
+	l := &Limiter{rate: 2, tokens: 2, last: start}
+	// The burst is served right away, the next requests every 500ms.
+	for i, want := range []time.Duration{0, 0, 500 * time.Millisecond, time.Second} {
+		if got := l.reserve(start); got != want {
+			t.Errorf("request %d: got wait %s, want %s", i, got, want)
+		}
+	}
+
+	l = &Limiter{rate: 0}
+	if got := l.reserve(start); got != 0 {
+		t.Errorf("got wait %s without limit, want none", got)
+	}
+	l.until = start.Add(3 * time.Second)
+	if got := l.reserve(start); got != 3*time.Second {
+		t.Errorf("got wait %s while paused, want 3s", got)
+	}
+}
+
+func TestRateLimitTransport(t *testing.T) {
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	client := &http.Client{Transport: &rateLimitTransport{
+		next:    http.DefaultTransport,
+		limiter: NewLimiter(100),
+	}}
+	resp, err := client.Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || calls != 2 {
+		t.Errorf("got status %d after %d calls, want 200 after the request was retried once", resp.StatusCode, calls)
+	}
+}
+
+func TestRateLimitTransportPrimaryLimit(t *testing.T) {
+	reset := time.Now().Add(time.Hour).Truncate(time.Second)
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("X-RateLimit-Remaining", "0")
+		w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(reset.Unix(), 10))
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer srv.Close()
+
+	limiter := NewLimiter(0)
+	client := &http.Client{Transport: &rateLimitTransport{
+		next:    http.DefaultTransport,
+		limiter: limiter,
+	}}
+	resp, err := client.Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	// The request isn't retried, but the other ones are held back until
+	// the reset.
+	if resp.StatusCode != http.StatusForbidden || calls != 1 {
+		t.Errorf("got status %d after %d calls, want 403 after a single call", resp.StatusCode, calls)
+	}
+	if d := limiter.until.Sub(reset); d < -time.Second || d > time.Second {
+		t.Errorf("limiter paused until %s, want %s", limiter.until, reset)
+	}
+}
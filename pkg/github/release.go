@@ -16,24 +16,53 @@ package github
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"os"
 	"time"
 
 	gh "github.com/google/go-github/v50/github"
 
+	"github.com/cilium/release/pkg/cache"
 	"github.com/cilium/release/pkg/types"
 )
 
+// PatchReleaseOptions are the options of GeneratePatchRelease.
+type PatchReleaseOptions struct {
+	// Owner and Repo are the repository of the commits and backport PRs.
+	Owner, Repo string
+	// UpstreamOwner and UpstreamRepo are the repository of the upstream PRs
+	// referenced by the backport PRs.
+	UpstreamOwner, UpstreamRepo string
+	// Printer prints the progress of the resolution.
+	Printer func(msg string)
+	// Cache stores, and reuses, the PRs found.
+	Cache *cache.Cache
+	// Stream, if not nil, is called for each PR as soon as it is resolved,
+	// with backportPR set to 0 for PRs that are not upstream PRs of a
+	// backport.
+	Stream func(backportPR, prNumber int, pr types.PullRequest)
+	// Orphan, if not nil, is called for each commit without any merged PR
+	// and for each backport PR referencing an upstream PR that doesn't
+	// exist, the latter being skipped instead of failing. If it returns an
+	// error, it is returned along with the commits left, starting with the
+	// orphan one.
+	Orphan func(types.Orphan) error
+	// CommitPRs, if not nil, stores the numbers of the merged PRs of each
+	// commit.
+	CommitPRs map[string][]int
+}
+
 // GeneratePatchRelease will returns a map that maps the backport PR number to
 // the upstream PR number and a map that maps the backport PR number to the PR
 // if no upstream PR was found.
 // In case of an error, a list of non-processed commits will be returned.
+// The error is an ErrRateLimited, ErrNotFound or ErrForbidden one, as
+// reported by errors.Is, if the API failed for that reason.
 func GeneratePatchRelease(
 	ctx context.Context,
 	ghClient *gh.Client,
-	owner string,
-	repo string,
-	printer func(msg string),
+	opts PatchReleaseOptions,
 	backportPRs types.BackportPRs,
 	listOfPRs types.PullRequests,
 	commits []string,
@@ -45,74 +74,202 @@ func GeneratePatchRelease(
 ) {
 
 	for i, sha := range commits {
-		page := 0
+		prs, err := ListPRsWithCommit(ctx, ghClient, opts.Cache, opts.Owner, opts.Repo, sha)
+		if err != nil {
+			return backportPRs, listOfPRs, commits[i:], err
+		}
 		foundPR := false
-		for {
-			ctxWithTimeout, cancel := context.WithTimeout(ctx, 45*time.Second)
-			prs, resp, err := ghClient.PullRequests.ListPullRequestsWithCommit(ctxWithTimeout, owner, repo, sha, &gh.PullRequestListOptions{
-				State: "closed",
-				ListOptions: gh.ListOptions{
-					Page: page,
-				},
-			})
-			cancel()
-			if err != nil {
-				return backportPRs, listOfPRs, commits[i:], err
-			}
-
-			for _, pr := range prs {
-				printer(".")
-				_, ok := listOfPRs[pr.GetNumber()]
-				_, ok2 := backportPRs[pr.GetNumber()]
-				if ok || ok2 {
-					foundPR = true
-					continue
-				}
-				if pr.GetState() != "closed" {
-					continue
-				}
+		var numbers []int
+		for _, pr := range prs {
+			opts.Printer(".")
+			_, ok := listOfPRs[pr.GetNumber()]
+			_, ok2 := backportPRs[pr.GetNumber()]
+			if ok || ok2 {
 				foundPR = true
-				upstreamPRs := getUpstreamPRs(pr.GetBody())
-				if upstreamPRs == nil {
-					lbls := parseGHLabels(pr.Labels)
-					listOfPRs[pr.GetNumber()] = types.PullRequest{
-						ReleaseNote:      getReleaseNote(pr.GetTitle(), pr.GetBody()),
-						ReleaseLabel:     getReleaseLabel(lbls),
-						AuthorName:       pr.GetUser().GetLogin(),
-						BackportBranches: getBackportBranches(lbls),
-					}
+				numbers = append(numbers, pr.GetNumber())
+				continue
+			}
+			if pr.GetState() != "closed" {
+				continue
+			}
+			foundPR = true
+			err := AddPullRequest(ctx, ghClient, opts.Cache, opts.UpstreamOwner, opts.UpstreamRepo, opts.Stream, pr, backportPRs, listOfPRs)
+			var unresolved *UnresolvedUpstreamError
+			if opts.Orphan != nil && errors.As(err, &unresolved) {
+				err = opts.Orphan(types.Orphan{
+					Reason:     types.OrphanUnresolvedBackport,
+					SHA:        sha,
+					PR:         pr.GetNumber(),
+					UpstreamPR: unresolved.UpstreamPR,
+					Title:      pr.GetTitle(),
+					URL:        pr.GetHTMLURL(),
+				})
+				if err == nil {
+					opts.Printer(fmt.Sprintf("WARNING: %s!\n", unresolved))
 					continue
 				}
-				backportPRs[pr.GetNumber()] = map[int]types.PullRequest{}
-				for _, upstreamPRNumber := range upstreamPRs {
-					_, ok := backportPRs[pr.GetNumber()][upstreamPRNumber]
-					if ok {
-						continue
-					}
-					ctxWithTimeout, cancel := context.WithTimeout(ctx, 45*time.Second)
-					upstreamPR, _, err := ghClient.PullRequests.Get(ctxWithTimeout, owner, repo, upstreamPRNumber)
-					cancel()
-					if err != nil {
-						delete(backportPRs, pr.GetNumber())
-						return backportPRs, listOfPRs, commits[i:], err
-					}
-					lbls := parseGHLabels(upstreamPR.Labels)
-					backportPRs[pr.GetNumber()][upstreamPRNumber] = types.PullRequest{
-						ReleaseNote:  getReleaseNote(upstreamPR.GetTitle(), upstreamPR.GetBody()),
-						ReleaseLabel: getReleaseLabel(lbls),
-						AuthorName:   upstreamPR.GetUser().GetLogin(),
-					}
-				}
 			}
-
-			page = resp.NextPage
-			if page == 0 {
-				break
+			if err != nil {
+				return backportPRs, listOfPRs, commits[i:], err
 			}
+			numbers = append(numbers, pr.GetNumber())
+		}
+		if opts.CommitPRs != nil && len(numbers) != 0 {
+			opts.CommitPRs[sha] = numbers
 		}
 		if !foundPR {
-			printer(fmt.Sprintf("WARNING: PR not found for commit %s!\n", sha))
+			opts.Printer(fmt.Sprintf("WARNING: PR not found for commit %s!\n", sha))
+			if opts.Orphan != nil {
+				if err := opts.Orphan(types.Orphan{Reason: types.OrphanNoPR, SHA: sha}); err != nil {
+					return backportPRs, listOfPRs, commits[i:], err
+				}
+			}
 		}
 	}
 	return backportPRs, listOfPRs, nil, nil
 }
+
+// UnresolvedUpstreamError is returned when a backport PR references an
+// upstream PR that doesn't exist.
+type UnresolvedUpstreamError struct {
+	BackportPR int
+	UpstreamPR int
+}
+
+func (e *UnresolvedUpstreamError) Error() string {
+	return fmt.Sprintf("upstream PR %d of backport PR %d not found", e.UpstreamPR, e.BackportPR)
+}
+
+func (e *UnresolvedUpstreamError) Is(target error) bool {
+	return target == ErrNotFound
+}
+
+// AddPullRequest adds the given merged PR to listOfPRs or, if it is a
+// backport PR, its upstream PRs, fetched from upstreamOwner/upstreamRepo, to
+// backportPRs. If stream is not nil, it is called for each PR added. An
+// *UnresolvedUpstreamError is returned if an upstream PR doesn't exist.
+func AddPullRequest(
+	ctx context.Context,
+	ghClient *gh.Client,
+	prCache *cache.Cache,
+	upstreamOwner string,
+	upstreamRepo string,
+	stream func(backportPR, prNumber int, pr types.PullRequest),
+	pr *gh.PullRequest,
+	backportPRs types.BackportPRs,
+	listOfPRs types.PullRequests,
+) error {
+	upstreamPRs := getUpstreamPRs(pr.GetBody())
+	if upstreamPRs == nil {
+		listOfPR := newPullRequest(pr)
+		listOfPR.BackportBranches = getBackportBranches(listOfPR.Labels)
+		listOfPRs[pr.GetNumber()] = listOfPR
+		if stream != nil {
+			stream(0, pr.GetNumber(), listOfPR)
+		}
+		return nil
+	}
+	backportPRs[pr.GetNumber()] = map[int]types.PullRequest{}
+	for _, upstreamPRNumber := range upstreamPRs {
+		_, ok := backportPRs[pr.GetNumber()][upstreamPRNumber]
+		if ok {
+			continue
+		}
+		upstreamPR, err := getUpstreamPR(ctx, ghClient, prCache, upstreamOwner, upstreamRepo, upstreamPRNumber)
+		if err != nil {
+			delete(backportPRs, pr.GetNumber())
+			if errors.Is(err, ErrNotFound) {
+				return &UnresolvedUpstreamError{BackportPR: pr.GetNumber(), UpstreamPR: upstreamPRNumber}
+			}
+			return err
+		}
+		backportPRs[pr.GetNumber()][upstreamPRNumber] = upstreamPR
+		if stream != nil {
+			stream(pr.GetNumber(), upstreamPRNumber, upstreamPR)
+		}
+	}
+	return nil
+}
+
+// ListPRsWithCommit returns all PRs associated with the given commit, using
+// prCache.
+func ListPRsWithCommit(ctx context.Context, ghClient *gh.Client, prCache *cache.Cache, owner, repo, sha string) ([]*gh.PullRequest, error) {
+	if prs, ok := prCache.CommitPRs(owner, repo, sha); ok {
+		return prs, nil
+	}
+	var allPRs []*gh.PullRequest
+	page := 0
+	for {
+		ctxWithTimeout, cancel := context.WithTimeout(ctx, 45*time.Second)
+		prs, resp, err := ghClient.PullRequests.ListPullRequestsWithCommit(ctxWithTimeout, owner, repo, sha, &gh.PullRequestListOptions{
+			State: "closed",
+			ListOptions: gh.ListOptions{
+				Page: page,
+			},
+		})
+		cancel()
+		if err != nil {
+			return nil, classify(err)
+		}
+		allPRs = append(allPRs, prs...)
+		page = resp.NextPage
+		if page == 0 {
+			break
+		}
+	}
+	// Only closed PRs are cached as the others can still change.
+	closed := true
+	for _, pr := range allPRs {
+		if pr.GetState() != "closed" {
+			closed = false
+		}
+	}
+	if closed {
+		// The cache only saves API calls, the PRs found are still used.
+		if err := prCache.StoreCommitPRs(owner, repo, sha, allPRs); err != nil {
+			fmt.Fprintf(os.Stderr, "WARNING: unable to cache the PRs of commit %s: %s\n", sha, err)
+		}
+	}
+	return allPRs, nil
+}
+
+// getUpstreamPR returns the release note information of the given upstream
+// PR.
+func getUpstreamPR(ctx context.Context, ghClient *gh.Client, prCache *cache.Cache, owner, repo string, number int) (types.PullRequest, error) {
+	if pr, ok := prCache.PullRequest(owner, repo, number); ok {
+		return pr, nil
+	}
+	ctxWithTimeout, cancel := context.WithTimeout(ctx, 45*time.Second)
+	upstreamPR, _, err := ghClient.PullRequests.Get(ctxWithTimeout, owner, repo, number)
+	cancel()
+	if err != nil {
+		return types.PullRequest{}, classify(err)
+	}
+	pr := newPullRequest(upstreamPR)
+	if upstreamPR.GetState() == "closed" {
+		if err := prCache.StorePullRequest(owner, repo, number, pr); err != nil {
+			fmt.Fprintf(os.Stderr, "WARNING: unable to cache PR %s/%s#%d: %s\n", owner, repo, number, err)
+		}
+	}
+	return pr, nil
+}
+
+// newPullRequest returns the release note information of the given PR.
+func newPullRequest(pr *gh.PullRequest) types.PullRequest {
+	lbls := parseGHLabels(pr.Labels)
+	releaseLabel := getReleaseLabel(lbls)
+	_, hasReleaseNote := releaseNoteFromBody(pr.GetBody())
+	return types.PullRequest{
+		Title:              pr.GetTitle(),
+		ReleaseNote:        getReleaseNote(pr.GetTitle(), pr.GetBody()),
+		ReleaseLabel:       releaseLabel,
+		MissingReleaseNote: !hasReleaseNote && releaseLabel != "release-note/none",
+		AuthorName:         pr.GetUser().GetLogin(),
+		Labels:             lbls,
+		MergedAt:           pr.GetMergedAt().Time,
+		Milestone:          pr.GetMilestone().GetTitle(),
+		URL:                pr.GetHTMLURL(),
+		AuthorAssociation:  pr.GetAuthorAssociation(),
+		UpgradeNotes:       upgradeNotesFromBody(pr.GetBody()),
+	}
+}
@@ -0,0 +1,114 @@
+// Copyright 2021 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package github
+
+import (
+	"context"
+	"strings"
+
+	gh "github.com/google/go-github/v50/github"
+
+	"github.com/cilium/release/pkg/version"
+)
+
+// ListReleases returns all releases of the repository, including drafts and
+// pre-releases, ordered from the most recent to the oldest.
+func ListReleases(ctx context.Context, ghClient *gh.Client, owner, repo string) ([]*gh.RepositoryRelease, error) {
+	var allReleases []*gh.RepositoryRelease
+	opts := &gh.ListOptions{PerPage: 100}
+	for {
+		releases, resp, err := ghClient.Repositories.ListReleases(ctx, owner, repo, opts)
+		if err != nil {
+			return nil, err
+		}
+		allReleases = append(allReleases, releases...)
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+	return allReleases, nil
+}
+
+// ListPrereleases returns all published pre-releases for the given version,
+// e.g. for version '1.14.0' it returns 'v1.14.0-rc.1', 'v1.14.0-rc.2', etc.
+// Draft releases are ignored.
+func ListPrereleases(ctx context.Context, ghClient *gh.Client, owner, repo, version string) ([]*gh.RepositoryRelease, error) {
+	releases, err := ListReleases(ctx, ghClient, owner, repo)
+	if err != nil {
+		return nil, err
+	}
+	prefix := "v" + version + "-"
+	var prereleases []*gh.RepositoryRelease
+	for _, release := range releases {
+		if release.GetDraft() || !release.GetPrerelease() {
+			continue
+		}
+		if !strings.HasPrefix(release.GetTagName(), prefix) {
+			continue
+		}
+		prereleases = append(prereleases, release)
+	}
+	return prereleases, nil
+}
+
+// ListBranchReleases returns all published final releases of the given
+// branch, e.g. for branch '1.14' it returns 'v1.14.0', 'v1.14.1', etc. Draft
+// releases and pre-releases are ignored.
+func ListBranchReleases(ctx context.Context, ghClient *gh.Client, owner, repo, branch string) ([]*gh.RepositoryRelease, error) {
+	branchVer, err := version.Parse(branch)
+	if err != nil {
+		return nil, err
+	}
+	releases, err := ListReleases(ctx, ghClient, owner, repo)
+	if err != nil {
+		return nil, err
+	}
+	var branchReleases []*gh.RepositoryRelease
+	for _, release := range releases {
+		if release.GetDraft() || release.GetPrerelease() {
+			continue
+		}
+		ver, err := version.Parse(release.GetTagName())
+		if err != nil || ver.IsPrerelease() || !ver.SameMinor(branchVer) {
+			continue
+		}
+		branchReleases = append(branchReleases, release)
+	}
+	return branchReleases, nil
+}
+
+// LatestBranchRelease returns the most recent published final release of the
+// given branch, or nil if the branch has no published release.
+func LatestBranchRelease(ctx context.Context, ghClient *gh.Client, owner, repo, branch string) (*gh.RepositoryRelease, error) {
+	releases, err := ListBranchReleases(ctx, ghClient, owner, repo, branch)
+	if err != nil {
+		return nil, err
+	}
+	var (
+		latest    *gh.RepositoryRelease
+		latestVer version.Version
+	)
+	for _, release := range releases {
+		ver, err := version.Parse(release.GetTagName())
+		if err != nil {
+			continue
+		}
+		if latest == nil || ver.Compare(latestVer) > 0 {
+			latest, latestVer = release, ver
+		}
+	}
+	return latest, nil
+}
@@ -0,0 +1,93 @@
+// Copyright 2021 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package github
+
+import (
+	"errors"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+)
+
+// retryTransport retries the idempotent requests that failed with a
+// transient error, i.e. a 5xx status, a connection reset or a timeout, with
+// an exponential backoff and jitter. Non-idempotent requests, e.g. the ones
+// creating a comment, are never retried as they might have been performed.
+type retryTransport struct {
+	next       http.RoundTripper
+	maxRetries int
+	baseDelay  time.Duration
+}
+
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		r := req
+		if attempt > 0 && req.Body != nil {
+			if req.GetBody == nil {
+				return nil, errors.New("unable to retry request without GetBody")
+			}
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			r = req.Clone(req.Context())
+			r.Body = body
+		}
+		resp, err := t.next.RoundTrip(r)
+		if attempt == t.maxRetries || !idempotent(req.Method) || !transient(resp, err) {
+			return resp, err
+		}
+		if resp != nil {
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+		// Wait between 0.5 and 1.5 times baseDelay * 2^attempt.
+		delay := t.baseDelay << attempt
+		delay = delay/2 + time.Duration(rand.Int63n(int64(delay)+1))
+		select {
+		case <-time.After(delay):
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		}
+	}
+}
+
+func idempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// transient returns true if the request failed with an error that is
+// likely to go away if the request is retried.
+func transient(resp *http.Response, err error) bool {
+	if err != nil {
+		var netErr net.Error
+		return errors.Is(err, syscall.ECONNRESET) ||
+			errors.Is(err, io.ErrUnexpectedEOF) ||
+			errors.Is(err, io.EOF) ||
+			(errors.As(err, &netErr) && netErr.Timeout())
+	}
+	switch resp.StatusCode {
+	case http.StatusInternalServerError, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
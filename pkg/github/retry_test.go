@@ -0,0 +1,74 @@
+// Copyright 2021 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package github
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRetryTransport(t *testing.T) {
+	tests := []struct {
+		name      string
+		method    string
+		failures  int
+		wantCalls int
+		wantCode  int
+	}{
+		{name: "success", method: http.MethodGet, failures: 0, wantCalls: 1, wantCode: http.StatusOK},
+		{name: "transient failures", method: http.MethodGet, failures: 2, wantCalls: 3, wantCode: http.StatusOK},
+		{name: "too many failures", method: http.MethodGet, failures: 5, wantCalls: 4, wantCode: http.StatusBadGateway},
+		{name: "retried with body", method: http.MethodPut, failures: 1, wantCalls: 2, wantCode: http.StatusOK},
+		{name: "not idempotent", method: http.MethodPost, failures: 1, wantCalls: 1, wantCode: http.StatusBadGateway},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				calls++
+				if calls <= tt.failures {
+					w.WriteHeader(http.StatusBadGateway)
+					return
+				}
+				w.WriteHeader(http.StatusOK)
+			}))
+			defer srv.Close()
+
+			client := &http.Client{Transport: &retryTransport{
+				next:       http.DefaultTransport,
+				maxRetries: 3,
+				baseDelay:  time.Millisecond,
+			}}
+			req, err := http.NewRequest(tt.method, srv.URL, strings.NewReader("{}"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp, err := client.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+			if resp.StatusCode != tt.wantCode {
+				t.Errorf("got status %d, want %d", resp.StatusCode, tt.wantCode)
+			}
+			if calls != tt.wantCalls {
+				t.Errorf("got %d calls, want %d", calls, tt.wantCalls)
+			}
+		})
+	}
+}
@@ -0,0 +1,179 @@
+// Copyright 2021 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package github
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	gh "github.com/google/go-github/v50/github"
+)
+
+// maxSearchResults is the maximum number of results of a search.
+const maxSearchResults = 1000
+
+// SearchIssues returns all issues and PRs matching the given search query,
+// e.g. 'repo:cilium/cilium is:pr is:merged label:needs-backport/1.14'.
+// As the search API returns at most 1000 results, an error wrapping
+// ErrTooManyResults is returned if the query matches more.
+func SearchIssues(ctx context.Context, ghClient *gh.Client, query string) ([]*gh.Issue, error) {
+	var issues []*gh.Issue
+	opts := &gh.SearchOptions{ListOptions: gh.ListOptions{PerPage: 100}}
+	for {
+		result, resp, err := ghClient.Search.Issues(ctx, query, opts)
+		if err != nil {
+			return nil, err
+		}
+		if result.GetTotal() > maxSearchResults {
+			return nil, tooManyResults(result.GetTotal(), query)
+		}
+		issues = append(issues, result.Issues...)
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+	return issues, nil
+}
+
+// SearchIssuesMergedBetween returns all PRs matching the given search query
+// that were merged between since and until. The range is split by merge
+// date, as long as a part of it matches more results than the search API
+// returns, so that none of the PRs is missed.
+func SearchIssuesMergedBetween(ctx context.Context, ghClient *gh.Client, query string, since, until time.Time) ([]*gh.Issue, error) {
+	var issues []*gh.Issue
+	var search func(since, until time.Time) error
+	search = func(since, until time.Time) error {
+		found, err := SearchIssues(ctx, ghClient, fmt.Sprintf("%s merged:%s..%s",
+			query, since.UTC().Format(time.RFC3339), until.UTC().Format(time.RFC3339)))
+		if errors.Is(err, ErrTooManyResults) && until.Sub(since) > time.Second {
+			// The bounds of the range are inclusive and have a
+			// precision of a second.
+			mid := since.Add(until.Sub(since) / 2).Truncate(time.Second)
+			if err := search(since, mid); err != nil {
+				return err
+			}
+			return search(mid.Add(time.Second), until)
+		}
+		if err != nil {
+			return err
+		}
+		issues = append(issues, found...)
+		return nil
+	}
+	if err := search(since.Truncate(time.Second), until); err != nil {
+		return nil, err
+	}
+	return issues, nil
+}
+
+func tooManyResults(total int, query string) error {
+	return fmt.Errorf("%w: %d results match %q but the search API returns at most %d of them", ErrTooManyResults, total, query, maxSearchResults)
+}
+
+// CountIssues returns the number of issues and PRs matching the given search
+// query.
+func CountIssues(ctx context.Context, ghClient *gh.Client, query string) (int, error) {
+	result, _, err := ghClient.Search.Issues(ctx, query, &gh.SearchOptions{ListOptions: gh.ListOptions{PerPage: 1}})
+	if err != nil {
+		return 0, err
+	}
+	return result.GetTotal(), nil
+}
+
+// SearchMergedPRs returns the merged PRs matching the given search query,
+// e.g. 'repo:cilium/cilium is:pr is:merged base:v1.14', with their title,
+// description, labels, author and merge date. They are listed with the
+// GraphQL API, 100 PRs per call. As the search API returns at most 1000
+// results, an error wrapping ErrTooManyResults is returned if the query
+// matches more PRs.
+func SearchMergedPRs(ctx context.Context, ghClient *gh.Client, query string) ([]*gh.PullRequest, error) {
+	var prs []*gh.PullRequest
+	var after *string
+	for {
+		var data struct {
+			Search struct {
+				IssueCount int
+				PageInfo   struct {
+					HasNextPage bool
+					EndCursor   string
+				}
+				Nodes []struct {
+					Number            int
+					Title             string
+					Body              string
+					URL               string
+					MergedAt          *time.Time
+					AuthorAssociation string
+					Author            struct{ Login string }
+					Milestone         struct{ Title string }
+					Labels            struct {
+						Nodes []struct{ Name string }
+					}
+				}
+			}
+		}
+		err := GraphQL(ctx, ghClient, `
+query($query: String!, $after: String) {
+  search(query: $query, type: ISSUE, first: 100, after: $after) {
+    issueCount
+    pageInfo { hasNextPage endCursor }
+    nodes {
+      ... on PullRequest {
+        number title body url mergedAt authorAssociation
+        author { login }
+        milestone { title }
+        labels(first: 100) { nodes { name } }
+      }
+    }
+  }
+}`, map[string]interface{}{"query": query, "after": after}, &data)
+		if err != nil {
+			return nil, err
+		}
+		if data.Search.IssueCount > maxSearchResults {
+			return nil, tooManyResults(data.Search.IssueCount, query)
+		}
+		for _, n := range data.Search.Nodes {
+			// The nodes that aren't PRs have no number.
+			if n.Number == 0 || n.MergedAt == nil {
+				continue
+			}
+			pr := &gh.PullRequest{
+				Number:            gh.Int(n.Number),
+				Title:             gh.String(n.Title),
+				Body:              gh.String(n.Body),
+				HTMLURL:           gh.String(n.URL),
+				State:             gh.String("closed"),
+				MergedAt:          &gh.Timestamp{Time: *n.MergedAt},
+				AuthorAssociation: gh.String(n.AuthorAssociation),
+				User:              &gh.User{Login: gh.String(n.Author.Login)},
+			}
+			if len(n.Milestone.Title) != 0 {
+				pr.Milestone = &gh.Milestone{Title: gh.String(n.Milestone.Title)}
+			}
+			for _, lbl := range n.Labels.Nodes {
+				pr.Labels = append(pr.Labels, &gh.Label{Name: gh.String(lbl.Name)})
+			}
+			prs = append(prs, pr)
+		}
+		if !data.Search.PageInfo.HasNextPage {
+			return prs, nil
+		}
+		after = &data.Search.PageInfo.EndCursor
+	}
+}
@@ -0,0 +1,140 @@
+// Copyright 2021 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSearchMergedPRs(t *testing.T) {
+	pages := []string{
+		`{"data": {"search": {"issueCount": 3, "pageInfo": {"hasNextPage": true, "endCursor": "c1"}, "nodes": [
+			{"number": 123, "title": "Add foo", "body": "` + "```release-note\\nAdd foo\\n```" + `", "url": "https://github.com/cilium/cilium/pull/123",
+			 "mergedAt": "2023-07-12T09:30:00Z", "authorAssociation": "MEMBER", "author": {"login": "alice"},
+			 "milestone": {"title": "1.14.1"}, "labels": {"nodes": [{"name": "release-note/minor"}]}},
+			{}
+		]}}}`,
+		`{"data": {"search": {"issueCount": 3, "pageInfo": {"hasNextPage": false}, "nodes": [
+			{"number": 124, "title": "Fix bar", "url": "https://github.com/cilium/cilium/pull/124",
+			 "mergedAt": "2023-07-13T10:00:00Z", "author": {"login": "bob"}, "labels": {"nodes": []}}
+		]}}}`,
+	}
+	var afters []interface{}
+	ghClient := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		var req graphQLRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatal(err)
+		}
+		if q := req.Variables["query"]; q != "repo:cilium/cilium is:pr is:merged base:v1.14" {
+			t.Errorf("unexpected query %q", q)
+		}
+		afters = append(afters, req.Variables["after"])
+		fmt.Fprint(w, pages[len(afters)-1])
+	})
+
+	prs, err := SearchMergedPRs(context.Background(), ghClient, "repo:cilium/cilium is:pr is:merged base:v1.14")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []interface{}{nil, "c1"}; !reflect.DeepEqual(afters, want) {
+		t.Errorf("cursors = %v, want %v", afters, want)
+	}
+	if len(prs) != 2 {
+		t.Fatalf("got %d PRs, want 2", len(prs))
+	}
+	got := newPullRequest(prs[0])
+	if got.ReleaseNote != "Add foo" || got.AuthorName != "alice" || got.Milestone != "1.14.1" ||
+		got.ReleaseLabel != "release-note/minor" || got.MergedAt.IsZero() || got.IsCommunity() {
+		t.Errorf("unexpected PR %+v", got)
+	}
+	if prs[1].GetNumber() != 124 || prs[1].GetState() != "closed" {
+		t.Errorf("unexpected PR %+v", prs[1])
+	}
+}
+
+func TestSearchMergedPRsTooMany(t *testing.T) {
+	ghClient := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"data": {"search": {"issueCount": 1200, "pageInfo": {"hasNextPage": true}, "nodes": []}}}`)
+	})
+	_, err := SearchMergedPRs(context.Background(), ghClient, "repo:cilium/cilium is:pr is:merged")
+	if !errors.Is(err, ErrTooManyResults) || !strings.Contains(err.Error(), "at most 1000") {
+		t.Errorf("got error %v, want too many results", err)
+	}
+}
+
+func TestSearchIssuesTooMany(t *testing.T) {
+	ghClient := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"total_count": 1200, "items": []}`)
+	})
+	_, err := SearchIssues(context.Background(), ghClient, "repo:cilium/cilium is:pr is:merged")
+	if !errors.Is(err, ErrTooManyResults) {
+		t.Errorf("got error %v, want too many results", err)
+	}
+}
+
+func TestSearchIssuesMergedBetween(t *testing.T) {
+	since := time.Date(2023, 7, 1, 0, 0, 0, 0, time.UTC)
+	until := since.Add(4 * 24 * time.Hour)
+	merged := map[int]time.Time{}
+	for i := 0; i < 4; i++ {
+		merged[100+i] = since.Add(time.Duration(i)*24*time.Hour + time.Hour)
+	}
+	var queries []string
+	ghClient := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query().Get("q")
+		queries = append(queries, q)
+		bounds := strings.SplitN(strings.TrimPrefix(q, "repo:cilium/cilium is:pr is:merged merged:"), "..", 2)
+		from, err := time.Parse(time.RFC3339, bounds[0])
+		if err != nil {
+			t.Fatal(err)
+		}
+		to, err := time.Parse(time.RFC3339, bounds[1])
+		if err != nil {
+			t.Fatal(err)
+		}
+		// Pretend that each PR stands for 600 of them, so that only
+		// the ranges with at most one PR can be listed.
+		var items []string
+		for number, at := range merged {
+			if !at.Before(from) && !at.After(to) {
+				items = append(items, fmt.Sprintf(`{"number": %d}`, number))
+			}
+		}
+		fmt.Fprintf(w, `{"total_count": %d, "items": [%s]}`, 600*len(items), strings.Join(items, ","))
+	})
+
+	issues, err := SearchIssuesMergedBetween(context.Background(), ghClient, "repo:cilium/cilium is:pr is:merged", since, until)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []int
+	for _, issue := range issues {
+		got = append(got, issue.GetNumber())
+	}
+	if want := []int{100, 101, 102, 103}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got PRs %v, want %v", got, want)
+	}
+	if want := "repo:cilium/cilium is:pr is:merged merged:2023-07-01T00:00:00Z..2023-07-05T00:00:00Z"; queries[0] != want {
+		t.Errorf("first query %q, want %q", queries[0], want)
+	}
+}
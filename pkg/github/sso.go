@@ -0,0 +1,69 @@
+// Copyright 2021 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package github
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+)
+
+// ssoHeader is the header set by GitHub when the token isn't authorized for
+// the SAML single sign-on of an organization, e.g. 'required; url=<url>' on
+// failures or 'partial-results; organizations=<ids>' when the resources of
+// the organizations are left out of the results.
+const ssoHeader = "X-GitHub-SSO"
+
+// ssoHint returns how to authorize the token given the X-GitHub-SSO header,
+// or an empty string if the header isn't set.
+func ssoHint(sso string) string {
+	if len(sso) == 0 {
+		return ""
+	}
+	hint := "The organization enforces SAML single sign-on and the token isn't authorized for it."
+	if _, url, ok := strings.Cut(sso, "url="); ok {
+		return hint + " Authorize it at " + url
+	}
+	return hint + " Authorize it in the settings of the token."
+}
+
+// ssoTransport warns, once, when GitHub leaves the resources of
+// organizations the token isn't authorized for out of successful responses,
+// as the results, e.g. the PRs of a commit, are then silently incomplete.
+type ssoTransport struct {
+	next http.RoundTripper
+	out  io.Writer
+	once sync.Once
+}
+
+func (t *ssoTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		return resp, err
+	}
+	if sso := resp.Header.Get(ssoHeader); strings.HasPrefix(sso, "partial-results") {
+		t.once.Do(func() {
+			fmt.Fprintf(t.out, "WARNING: the results of %s may be incomplete. %s\n", req.URL.Path, ssoHint(sso))
+		})
+	}
+	return resp, err
+}
+
+func newSSOTransport(next http.RoundTripper) *ssoTransport {
+	return &ssoTransport{next: next, out: os.Stderr}
+}
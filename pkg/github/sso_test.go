@@ -0,0 +1,48 @@
+// Copyright 2021 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package github
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestSSOTransport(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/partial" {
+			w.Header().Set(ssoHeader, "partial-results; organizations=21955855")
+		}
+	}))
+	defer srv.Close()
+
+	var out bytes.Buffer
+	client := &http.Client{Transport: &ssoTransport{next: http.DefaultTransport, out: &out}}
+	for _, path := range []string{"/complete", "/partial", "/partial"} {
+		resp, err := client.Get(srv.URL + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+	}
+	if got := strings.Count(out.String(), "WARNING"); got != 1 {
+		t.Errorf("got %d warnings, want 1: %q", got, out.String())
+	}
+	if !strings.Contains(out.String(), "/partial may be incomplete") {
+		t.Errorf("got warning %q, want it to name the incomplete results", out.String())
+	}
+}
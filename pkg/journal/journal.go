@@ -0,0 +1,175 @@
+// Copyright 2021 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package journal records the mutating API calls made by the release tool
+// into an append-only local file, for the review of a botched release.
+package journal
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cilium/release/pkg/report"
+)
+
+// maxBody is the size above which the bodies recorded are truncated.
+const maxBody = 4096
+
+// Record is a mutating API call.
+type Record struct {
+	Time time.Time `json:"time"`
+	// Command is the command line of the run that made the call.
+	Command  string `json:"command"`
+	Method   string `json:"method"`
+	URL      string `json:"url"`
+	Status   int    `json:"status,omitempty"`
+	Error    string `json:"error,omitempty"`
+	Request  string `json:"request,omitempty"`
+	Response string `json:"response,omitempty"`
+}
+
+// Journal appends the mutating API calls made through its RoundTripper to
+// a file, one JSON record per line. A nil Journal records nothing.
+type Journal struct {
+	mu      sync.Mutex
+	file    string
+	command string
+}
+
+// Flag is the flag, accepted by all the commands, of the file the journal is
+// appended to.
+const Flag = "journal"
+
+// New returns a journal appending to file, recording command as the command
+// line of the calls. An empty file returns a nil journal.
+func New(file, command string) *Journal {
+	if len(file) == 0 {
+		return nil
+	}
+	return &Journal{file: file, command: command}
+}
+
+// mutating returns true if the request changes something, i.e. if it isn't
+// a read, a GraphQL query or a rendering of Markdown.
+func mutating(req *http.Request, body []byte) bool {
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return false
+	}
+	if strings.HasSuffix(req.URL.Path, "/markdown") {
+		return false
+	}
+	if strings.HasSuffix(req.URL.Path, "/graphql") {
+		var q struct {
+			Query string `json:"query"`
+		}
+		if err := json.Unmarshal(body, &q); err == nil {
+			return strings.HasPrefix(strings.TrimSpace(q.Query), "mutation")
+		}
+	}
+	return true
+}
+
+func truncate(b []byte) string {
+	if len(b) > maxBody {
+		return string(b[:maxBody]) + "..."
+	}
+	return string(b)
+}
+
+// RoundTripper returns a http.RoundTripper that records the mutating calls
+// made through next.
+func (j *Journal) RoundTripper(next http.RoundTripper) http.RoundTripper {
+	if j == nil {
+		return next
+	}
+	return roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		var body []byte
+		if req.Body != nil {
+			var err error
+			body, err = io.ReadAll(req.Body)
+			req.Body.Close()
+			if err != nil {
+				return nil, err
+			}
+			req.Body = io.NopCloser(bytes.NewReader(body))
+		}
+		if !mutating(req, body) {
+			return next.RoundTrip(req)
+		}
+
+		r := Record{
+			Time:    time.Now().UTC(),
+			Command: j.command,
+			Method:  req.Method,
+			URL:     req.URL.String(),
+			Request: truncate(body),
+		}
+		resp, err := next.RoundTrip(req)
+		if err != nil {
+			r.Error = err.Error()
+		} else {
+			r.Status = resp.StatusCode
+			respBody, readErr := io.ReadAll(resp.Body)
+			resp.Body.Close()
+			resp.Body = io.NopCloser(bytes.NewReader(respBody))
+			if readErr != nil {
+				return nil, readErr
+			}
+			r.Response = truncate(respBody)
+		}
+		if jErr := j.append(r); jErr != nil {
+			os.Stderr.WriteString("WARNING: unable to write the journal: " + jErr.Error() + "\n")
+		}
+		return resp, err
+	})
+}
+
+// append appends the record to the journal file.
+func (j *Journal) append(r Record) error {
+	b, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if err := os.MkdirAll(filepath.Dir(j.file), 0755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(j.file, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(append(b, '\n'))
+	if err2 := f.Close(); err == nil {
+		err = err2
+	}
+	if err == nil {
+		report.Written(j.file)
+	}
+	return err
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
@@ -0,0 +1,91 @@
+// Copyright 2021 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package journal
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRoundTripper(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprint(w, `{"ok": true}`)
+	}))
+	defer srv.Close()
+
+	file := filepath.Join(t.TempDir(), "journal", "journal.jsonl")
+	j := New(file, "release labels sync")
+	client := &http.Client{Transport: j.RoundTripper(http.DefaultTransport)}
+
+	requests := []struct {
+		method, path, body string
+	}{
+		{method: http.MethodGet, path: "/repos/cilium/cilium/labels"},
+		{method: http.MethodPost, path: "/repos/cilium/cilium/labels", body: `{"name": "kind/bug"}`},
+		{method: http.MethodPost, path: "/graphql", body: `{"query": "query { viewer { login } }"}`},
+		{method: http.MethodPost, path: "/markdown", body: `{"text": "**notes**"}`},
+		{method: http.MethodPost, path: "/graphql", body: `{"query": "mutation { addProjectV2ItemById }"}`},
+	}
+	for _, r := range requests {
+		req, err := http.NewRequest(r.method, srv.URL+r.path, strings.NewReader(r.body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// The response is still readable once recorded.
+		if b, _ := io.ReadAll(resp.Body); string(b) != `{"ok": true}` {
+			t.Errorf("got response %q", b)
+		}
+		resp.Body.Close()
+	}
+
+	f, err := os.Open(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	var records []Record
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var r Record
+		if err := json.Unmarshal(scanner.Bytes(), &r); err != nil {
+			t.Fatal(err)
+		}
+		records = append(records, r)
+	}
+	if len(records) != 2 {
+		t.Fatalf("got %d records, want 2: %+v", len(records), records)
+	}
+	if r := records[0]; r.Method != http.MethodPost || r.URL != srv.URL+"/repos/cilium/cilium/labels" ||
+		r.Status != http.StatusCreated || r.Request != `{"name": "kind/bug"}` || r.Response != `{"ok": true}` ||
+		r.Command != "release labels sync" || r.Time.IsZero() {
+		t.Errorf("unexpected record %+v", r)
+	}
+	if r := records[1]; r.URL != srv.URL+"/graphql" {
+		t.Errorf("unexpected record %+v", r)
+	}
+}
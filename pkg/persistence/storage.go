@@ -18,6 +18,7 @@ import (
 	"encoding/json"
 	"io/ioutil"
 
+	"github.com/cilium/release/pkg/report"
 	"github.com/cilium/release/pkg/types"
 )
 
@@ -25,32 +26,85 @@ type State struct {
 	BackportPRs  types.BackportPRs
 	PullRequests types.PullRequests
 	SHAs         []string
+	// NewContributors maps the login of the PR authors to whether the
+	// release contains their first merged PR.
+	NewContributors map[string]bool `json:",omitempty"`
+	// DownstreamPRs are the version bump PRs opened in the downstream
+	// repositories once the release was published.
+	DownstreamPRs []DownstreamPR `json:",omitempty"`
+	// Orphans are the commits and PRs that couldn't be categorized, see
+	// types.Config.OrphansReport.
+	Orphans []types.Orphan `json:",omitempty"`
+	// TrailerNotes maps the SHA of the commits to the release note given by
+	// their Release-note trailer.
+	TrailerNotes map[string]string `json:",omitempty"`
+	// CommitPRs maps the SHA of the commits resolved to the numbers of
+	// their merged PRs.
+	CommitPRs map[string][]int `json:",omitempty"`
+	// Base is the base of the release notes.
+	Base string `json:",omitempty"`
+	// HeadSHA is the commit the head of the release notes pointed to when
+	// the commits were compared, from which the commits merged since then
+	// are found when resuming.
+	HeadSHA string `json:",omitempty"`
+}
+
+// DownstreamStateFile is the default state file of the version bump PRs,
+// apart from the state of the release notes as it is written once the
+// release is published.
+const DownstreamStateFile = "downstream-state.json"
+
+// DownstreamPR is a version bump PR of a downstream repository.
+type DownstreamPR struct {
+	Repo string
+	// Version is the version, e.g. '1.14.3', the PR bumps to.
+	Version string `json:",omitempty"`
+	Number  int
+	URL     string
 }
 
 func StoreState(file string, backportPRs types.BackportPRs, prs types.PullRequests, shas []string) error {
-	s := State{
+	return Store(file, &State{
 		BackportPRs:  backportPRs,
 		PullRequests: prs,
 		SHAs:         shas,
+	})
+}
+
+func LoadState(file string) (types.BackportPRs, types.PullRequests, []string, error) {
+	s, err := Load(file)
+	if err != nil {
+		return nil, nil, nil, err
 	}
+
+	return s.BackportPRs, s.PullRequests, s.SHAs, nil
+}
+
+// Store writes the given state into file.
+func Store(file string, s *State) error {
 	data, err := json.Marshal(s)
 	if err != nil {
 		return err
 	}
 
-	return ioutil.WriteFile(file, data, 0664)
+	if err := ioutil.WriteFile(file, data, 0664); err != nil {
+		return err
+	}
+	report.Written(file)
+	return nil
 }
 
-func LoadState(file string) (types.BackportPRs, types.PullRequests, []string, error) {
+// Load reads the state stored in file.
+func Load(file string) (*State, error) {
 	data, err := ioutil.ReadFile(file)
 	if err != nil {
-		return nil, nil, nil, err
+		return nil, err
 	}
-	s := State{}
-	err = json.Unmarshal(data, &s)
+	s := &State{}
+	err = json.Unmarshal(data, s)
 	if err != nil {
-		return nil, nil, nil, err
+		return nil, err
 	}
 
-	return s.BackportPRs, s.PullRequests, s.SHAs, nil
+	return s, nil
 }
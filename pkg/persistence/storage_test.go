@@ -19,6 +19,7 @@ import (
 	"os"
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/cilium/release/pkg/types"
 )
@@ -60,6 +61,21 @@ func TestStoreState(t *testing.T) {
 						BackportBranches: []string{
 							"backport-done/1.5",
 						},
+						Labels: []string{
+							"release-note/minor",
+							"backport-done/1.5",
+						},
+						MergedAt:          time.Date(2021, 3, 4, 10, 20, 30, 0, time.UTC),
+						Milestone:         "1.6",
+						URL:               "https://github.com/cilium/cilium/pull/3",
+						AuthorAssociation: "MEMBER",
+					},
+					// Nobody approved the PR.
+					4: {
+						ReleaseNote:  "BazQux",
+						ReleaseLabel: "release-note/bug",
+						AuthorName:   "@example",
+						Reviewers:    []string{},
 					},
 				},
 				shas: []string{
@@ -0,0 +1,94 @@
+// Copyright 2021 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package persistence
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+)
+
+// Schema is the JSON schema of the state file.
+//
+//go:embed state.schema.json
+var Schema []byte
+
+var shaRe = regexp.MustCompile(`^[0-9a-f]{40}$`)
+
+// Validate checks the structure of the given state file content, rejecting
+// unknown fields, and its referential integrity. All the problems found are
+// returned, sorted.
+func Validate(data []byte) []error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	s := &State{}
+	if err := dec.Decode(s); err != nil {
+		return []error{fmt.Errorf("invalid state: %w", err)}
+	}
+
+	var problems []string
+	seen := map[string]bool{}
+	for _, sha := range s.SHAs {
+		if !shaRe.MatchString(sha) {
+			problems = append(problems, fmt.Sprintf("SHAs: %q is not a commit SHA", sha))
+		}
+		if seen[sha] {
+			problems = append(problems, fmt.Sprintf("SHAs: %s is duplicated", sha))
+		}
+		seen[sha] = true
+	}
+	for backportPR, upstreamPRs := range s.BackportPRs {
+		if len(upstreamPRs) == 0 {
+			problems = append(problems, fmt.Sprintf("BackportPRs: backport PR %d has no upstream PR", backportPR))
+		}
+		if _, ok := s.PullRequests[backportPR]; ok {
+			problems = append(problems, fmt.Sprintf("BackportPRs: backport PR %d is also in PullRequests", backportPR))
+		}
+		for number, pr := range upstreamPRs {
+			if len(pr.ReleaseNote) == 0 && len(pr.AuthorName) == 0 {
+				problems = append(problems, fmt.Sprintf("BackportPRs: upstream PR %d of backport PR %d is missing", number, backportPR))
+			}
+		}
+	}
+	for number, pr := range s.PullRequests {
+		if len(pr.ReleaseNote) == 0 && len(pr.AuthorName) == 0 {
+			problems = append(problems, fmt.Sprintf("PullRequests: PR %d is empty", number))
+		}
+	}
+	if len(s.HeadSHA) != 0 && !shaRe.MatchString(s.HeadSHA) {
+		problems = append(problems, fmt.Sprintf("HeadSHA: %q is not a commit SHA", s.HeadSHA))
+	}
+	for sha := range s.TrailerNotes {
+		if !shaRe.MatchString(sha) {
+			problems = append(problems, fmt.Sprintf("TrailerNotes: %q is not a commit SHA", sha))
+		}
+	}
+	for sha := range s.CommitPRs {
+		if !shaRe.MatchString(sha) {
+			problems = append(problems, fmt.Sprintf("CommitPRs: %q is not a commit SHA", sha))
+		}
+	}
+
+	sort.Strings(problems)
+	errs := make([]error, 0, len(problems))
+	for _, p := range problems {
+		errs = append(errs, errors.New(p))
+	}
+	return errs
+}
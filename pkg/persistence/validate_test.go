@@ -0,0 +1,122 @@
+// Copyright 2021 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package persistence
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/cilium/release/pkg/types"
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name  string
+		state string
+		want  []string
+	}{
+		{
+			name: "valid",
+			state: `{
+  "BackportPRs": {"200": {"150": {"ReleaseNote": "Fix bar", "AuthorName": "bob"}}},
+  "PullRequests": {"123": {"ReleaseNote": "Add foo", "AuthorName": "alice", "MergedAt": "2021-03-04T10:20:30Z"}},
+  "SHAs": ["9ba79ef2517ede0ece6c1d1a7798c57d33d24f77"]
+}`,
+		},
+		{
+			name:  "unknown field",
+			state: `{"PullRequests": {"123": {"ReleaseNotes": "Add foo"}}}`,
+			want:  []string{`invalid state: json: unknown field "ReleaseNotes"`},
+		},
+		{
+			name:  "wrong type",
+			state: `{"SHAs": "9ba79ef2517ede0ece6c1d1a7798c57d33d24f77"}`,
+			want:  []string{"invalid state: json: cannot unmarshal string into Go struct field State.SHAs of type []string"},
+		},
+		{
+			name: "integrity",
+			state: `{
+  "BackportPRs": {"200": {"150": {}}, "201": {}, "123": {"151": {"ReleaseNote": "Fix baz"}}},
+  "PullRequests": {"123": {"ReleaseNote": "Add foo"}},
+  "SHAs": ["9ba79ef2517ede0ece6c1d1a7798c57d33d24f77", "9ba79ef", "9ba79ef2517ede0ece6c1d1a7798c57d33d24f77"]
+}`,
+			want: []string{
+				"BackportPRs: backport PR 123 is also in PullRequests",
+				"BackportPRs: backport PR 201 has no upstream PR",
+				"BackportPRs: upstream PR 150 of backport PR 200 is missing",
+				`SHAs: "9ba79ef" is not a commit SHA`,
+				"SHAs: 9ba79ef2517ede0ece6c1d1a7798c57d33d24f77 is duplicated",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, err := range Validate([]byte(tt.state)) {
+				got = append(got, err.Error())
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Validate() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestSchema checks that the schema describes all the fields of the state.
+func TestSchema(t *testing.T) {
+	var schema struct {
+		Properties map[string]struct {
+			Items struct {
+				Properties map[string]interface{} `json:"properties"`
+			} `json:"items"`
+		} `json:"properties"`
+		Defs map[string]struct {
+			Properties map[string]interface{} `json:"properties"`
+		} `json:"$defs"`
+	}
+	if err := json.Unmarshal(Schema, &schema); err != nil {
+		t.Fatal(err)
+	}
+	check := func(name string, typ reflect.Type, properties func(string) bool) {
+		for i := 0; i < typ.NumField(); i++ {
+			field := typ.Field(i)
+			key, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+			if len(key) == 0 {
+				key = field.Name
+			}
+			if !properties(key) {
+				t.Errorf("%s: field %s is missing from the schema", name, key)
+			}
+		}
+	}
+	check("State", reflect.TypeOf(State{}), func(key string) bool {
+		_, ok := schema.Properties[key]
+		return ok
+	})
+	check("PullRequest", reflect.TypeOf(types.PullRequest{}), func(key string) bool {
+		_, ok := schema.Defs["PullRequest"].Properties[key]
+		return ok
+	})
+	check("DownstreamPR", reflect.TypeOf(DownstreamPR{}), func(key string) bool {
+		_, ok := schema.Properties["DownstreamPRs"].Items.Properties[key]
+		return ok
+	})
+	check("Orphan", reflect.TypeOf(types.Orphan{}), func(key string) bool {
+		_, ok := schema.Properties["Orphans"].Items.Properties[key]
+		return ok
+	})
+}
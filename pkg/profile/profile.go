@@ -0,0 +1,327 @@
+// Copyright 2021 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package profile contains the label schemes of the projects using the
+// release tool.
+package profile
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Section is a section of the release notes.
+type Section struct {
+	// Label is the label of the PRs listed in the section.
+	Label  string
+	Header string
+}
+
+// Profile is the label scheme of a project.
+type Profile struct {
+	// Sections are the sections of the release notes, in the order they
+	// are rendered.
+	Sections []Section
+	// DefaultLabel is the label of the section of the PRs that have none
+	// of the labels of Sections.
+	DefaultLabel string
+	// CILabel is the label of the section of the CI changes.
+	CILabel string
+	// CollapsibleLabels are the labels of the sections collapsed, in this
+	// order, when the release notes are too large.
+	CollapsibleLabels []string
+	// NeedsBackportPrefix, PendingBackportPrefix and DoneBackportPrefix
+	// are the prefixes of the labels, followed by the stable version, e.g.
+	// '1.14', tracking the backports of a PR.
+	NeedsBackportPrefix   string
+	PendingBackportPrefix string
+	DoneBackportPrefix    string
+	// StableBranchPrefix is the prefix of the stable branches, followed
+	// by the stable version, e.g. 'v' for 'v1.14'.
+	StableBranchPrefix string
+	// BackportLabels are the labels set in every backport PR, '%s' being
+	// replaced by the stable version, e.g. 'backport/%s'.
+	BackportLabels []string
+	// BugLabel is the label of the bug fixes, suggested for backport.
+	BugLabel string
+	// AreaPrefix is the prefix of the labels of the area of a PR, e.g.
+	// 'area/', by which the pending backports are grouped.
+	AreaPrefix string
+	// ConventionalCommits maps the type of a Conventional Commit title,
+	// e.g. 'feat' in 'feat(cli): add foo', to the label of the section of
+	// the PRs without any label of Sections. The '!' type is the one of
+	// breaking changes, e.g. 'feat!: remove foo'.
+	ConventionalCommits map[string]string
+	// KeepAChangelog maps a label, of a section or not, to the Keep a
+	// Changelog category, e.g. 'Added' or 'Fixed', of the PRs with it.
+	// The PRs with none of the labels are left out of that format.
+	KeepAChangelog map[string]string
+}
+
+// Default is the name of the profile used if none is given.
+const Default = "cilium"
+
+var ciliumConventionalCommits = map[string]string{
+	"!":        "release-note/major",
+	"feat":     "release-note/minor",
+	"fix":      "release-note/bug",
+	"ci":       "release-note/ci",
+	"build":    "release-note/misc",
+	"chore":    "release-note/misc",
+	"docs":     "release-note/misc",
+	"perf":     "release-note/misc",
+	"refactor": "release-note/misc",
+	"style":    "release-note/misc",
+	"test":     "release-note/misc",
+}
+
+// withConventionalCommits returns a copy of the Conventional Commits types of
+// cilium with the given types replaced.
+func withConventionalCommits(replaced map[string]string) map[string]string {
+	m := make(map[string]string, len(ciliumConventionalCommits)+len(replaced))
+	for typ, lbl := range ciliumConventionalCommits {
+		m[typ] = lbl
+	}
+	for typ, lbl := range replaced {
+		m[typ] = lbl
+	}
+	return m
+}
+
+var cilium = Profile{
+	Sections: []Section{
+		{"release-note/major", "**Major Changes:**"},
+		{"release-note/minor", "**Minor Changes:**"},
+		{"release-note/bug", "**Bugfixes:**"},
+		{"release-note/ci", "**CI Changes:**"},
+		{"release-note/misc", "**Misc Changes:**"},
+		{"release-note/none", "**Other Changes:**"},
+	},
+	DefaultLabel:          "release-note/none",
+	CILabel:               "release-note/ci",
+	CollapsibleLabels:     []string{"release-note/none", "release-note/misc"},
+	NeedsBackportPrefix:   "needs-backport/",
+	PendingBackportPrefix: "backport-pending/",
+	DoneBackportPrefix:    "backport-done/",
+	StableBranchPrefix:    "v",
+	BackportLabels:        []string{"kind/backports", "backport/%s"},
+	BugLabel:              "release-note/bug",
+	AreaPrefix:            "area/",
+	ConventionalCommits:   ciliumConventionalCommits,
+	KeepAChangelog: map[string]string{
+		"kind/security":      "Security",
+		"kind/deprecation":   "Deprecated",
+		"release-note/major": "Changed",
+		"release-note/minor": "Added",
+		"release-note/bug":   "Fixed",
+		"release-note/misc":  "Changed",
+	},
+}
+
+var profiles = map[string]Profile{
+	"cilium": cilium,
+	"tetragon": {
+		Sections: []Section{
+			{"release-note/major", "**Major Changes:**"},
+			{"release-note/minor", "**Minor Changes:**"},
+			{"release-note/bug", "**Bugfixes:**"},
+			{"release-note/docs", "**Documentation:**"},
+			{"release-note/dependency-update", "**Dependency Updates:**"},
+			{"release-note/ci", "**CI Changes:**"},
+			{"release-note/misc", "**Misc Changes:**"},
+			{"release-note/none", "**Other Changes:**"},
+		},
+		DefaultLabel:          "release-note/none",
+		CILabel:               "release-note/ci",
+		CollapsibleLabels:     []string{"release-note/none", "release-note/dependency-update", "release-note/misc"},
+		NeedsBackportPrefix:   cilium.NeedsBackportPrefix,
+		PendingBackportPrefix: cilium.PendingBackportPrefix,
+		DoneBackportPrefix:    cilium.DoneBackportPrefix,
+		StableBranchPrefix:    cilium.StableBranchPrefix,
+		BackportLabels:        cilium.BackportLabels,
+		BugLabel:              cilium.BugLabel,
+		AreaPrefix:            cilium.AreaPrefix,
+		ConventionalCommits:   withConventionalCommits(map[string]string{"docs": "release-note/docs"}),
+		KeepAChangelog: map[string]string{
+			"kind/security":                  "Security",
+			"kind/deprecation":               "Deprecated",
+			"release-note/major":             "Changed",
+			"release-note/minor":             "Added",
+			"release-note/bug":               "Fixed",
+			"release-note/docs":              "Changed",
+			"release-note/dependency-update": "Changed",
+			"release-note/misc":              "Changed",
+		},
+	},
+	"kubernetes": {
+		Sections: []Section{
+			{"kind/api-change", "**API Changes:**"},
+			{"kind/feature", "**Features:**"},
+			{"kind/deprecation", "**Deprecations:**"},
+			{"kind/bug", "**Bug Fixes:**"},
+			{"kind/documentation", "**Documentation:**"},
+			{"kind/flake", "**Test Fixes:**"},
+			{"kind/cleanup", "**Cleanups:**"},
+			{"kind/uncategorized", "**Uncategorized:**"},
+		},
+		DefaultLabel:          "kind/uncategorized",
+		CILabel:               "kind/flake",
+		CollapsibleLabels:     []string{"kind/uncategorized", "kind/cleanup"},
+		NeedsBackportPrefix:   "needs-cherry-pick/",
+		PendingBackportPrefix: "cherry-pick-pending/",
+		DoneBackportPrefix:    "cherry-picked/",
+		StableBranchPrefix:    "release-",
+		BackportLabels:        []string{"kind/cherry-pick", "cherry-pick/%s"},
+		BugLabel:              "kind/bug",
+		AreaPrefix:            "sig/",
+		ConventionalCommits: map[string]string{
+			"!":        "kind/api-change",
+			"feat":     "kind/feature",
+			"fix":      "kind/bug",
+			"docs":     "kind/documentation",
+			"test":     "kind/flake",
+			"ci":       "kind/flake",
+			"chore":    "kind/cleanup",
+			"refactor": "kind/cleanup",
+		},
+		KeepAChangelog: map[string]string{
+			"area/security":    "Security",
+			"kind/deprecation": "Deprecated",
+			"kind/api-change":  "Changed",
+			"kind/feature":     "Added",
+			"kind/bug":         "Fixed",
+			"kind/cleanup":     "Changed",
+		},
+	},
+}
+
+// Get returns the profile of the given name, the default one if empty.
+func Get(name string) (Profile, error) {
+	if len(name) == 0 {
+		name = Default
+	}
+	p, ok := profiles[name]
+	if !ok {
+		return Profile{}, fmt.Errorf("unknown profile %q, should be one of %s", name, strings.Join(Names(), ", "))
+	}
+	return p, nil
+}
+
+// Names returns the names of the built-in profiles.
+func Names() []string {
+	var names []string
+	for name := range profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Header returns the header of the section of the given label.
+func (p Profile) Header(label string) string {
+	for _, s := range p.Sections {
+		if s.Label == label {
+			return s.Header
+		}
+	}
+	return ""
+}
+
+// ReleaseLabel returns the label of the section the PR with the given
+// labels is listed in.
+func (p Profile) ReleaseLabel(lbls []string) string {
+	if lbl, ok := p.SectionLabel(lbls); ok {
+		return lbl
+	}
+	return p.DefaultLabel
+}
+
+// SectionLabel returns the label of the section of a PR with the given
+// labels. If they contain the labels of several sections, the section
+// rendered first, e.g. the major changes before the bugfixes, wins.
+func (p Profile) SectionLabel(lbls []string) (string, bool) {
+	if sectionLbls := p.SectionLabels(lbls); len(sectionLbls) != 0 {
+		return sectionLbls[0], true
+	}
+	return "", false
+}
+
+// SectionLabels returns the given labels that are the labels of a section,
+// in the order the sections are rendered.
+func (p Profile) SectionLabels(lbls []string) []string {
+	var sectionLbls []string
+	for _, s := range p.Sections {
+		for _, lbl := range lbls {
+			if lbl == s.Label {
+				sectionLbls = append(sectionLbls, lbl)
+				break
+			}
+		}
+	}
+	return sectionLbls
+}
+
+var conventionalRe = regexp.MustCompile(`^(\w+)(?:\([^)]*\))?(!)?: `)
+
+// ConventionalLabel returns the label of the section of the PR with the
+// given Conventional Commit title, e.g. 'fix(cli): foo'.
+func (p Profile) ConventionalLabel(title string) (string, bool) {
+	m := conventionalRe.FindStringSubmatch(title)
+	if m == nil {
+		return "", false
+	}
+	if len(m[2]) != 0 || strings.Contains(title, "BREAKING CHANGE") {
+		if lbl, ok := p.ConventionalCommits["!"]; ok {
+			return lbl, true
+		}
+	}
+	lbl, ok := p.ConventionalCommits[strings.ToLower(m[1])]
+	return lbl, ok
+}
+
+// BackportBranches returns the labels marking the PR with the given labels
+// as backported.
+func (p Profile) BackportBranches(lbls []string) []string {
+	var bb []string
+	for _, lbl := range lbls {
+		if strings.HasPrefix(lbl, p.DoneBackportPrefix) {
+			bb = append(bb, lbl)
+		}
+	}
+	return bb
+}
+
+// StableBranch returns the name of the stable branch of the given 'x.y'
+// version.
+func (p Profile) StableBranch(version string) string {
+	return p.StableBranchPrefix + version
+}
+
+// StableVersion returns the 'x.y' version of the given stable branch, the
+// reverse of StableBranch.
+func (p Profile) StableVersion(branch string) string {
+	return strings.TrimPrefix(branch, p.StableBranchPrefix)
+}
+
+// BackportPRLabels returns the labels of a backport PR into the given stable
+// branch.
+func (p Profile) BackportPRLabels(branch string) []string {
+	lbls := make([]string, 0, len(p.BackportLabels))
+	for _, lbl := range p.BackportLabels {
+		lbls = append(lbls, strings.ReplaceAll(lbl, "%s", p.StableVersion(branch)))
+	}
+	return lbls
+}
@@ -0,0 +1,115 @@
+// Copyright 2021 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package profile
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestProfiles(t *testing.T) {
+	for _, name := range Names() {
+		p, err := Get(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		lbls := append([]string{p.DefaultLabel, p.CILabel}, p.CollapsibleLabels...)
+		for _, lbl := range p.ConventionalCommits {
+			lbls = append(lbls, lbl)
+		}
+		for _, lbl := range lbls {
+			if len(p.Header(lbl)) == 0 {
+				t.Errorf("%s: label %s has no section", name, lbl)
+			}
+		}
+	}
+	if _, err := Get("unknown"); err == nil {
+		t.Error("expected an error for an unknown profile")
+	}
+}
+
+func TestReleaseLabel(t *testing.T) {
+	tests := []struct {
+		profile string
+		lbls    []string
+		want    string
+	}{
+		{"", []string{"area/foo", "release-note/bug"}, "release-note/bug"},
+		{"cilium", []string{"area/foo"}, "release-note/none"},
+		{"cilium", []string{"release-note/docs"}, "release-note/none"},
+		{"tetragon", []string{"release-note/docs"}, "release-note/docs"},
+		{"kubernetes", []string{"sig/network", "kind/feature"}, "kind/feature"},
+		{"kubernetes", []string{"sig/network"}, "kind/uncategorized"},
+		{"cilium", []string{"release-note/bug", "release-note/minor"}, "release-note/minor"},
+		{"cilium", []string{"release-note/none", "release-note/ci"}, "release-note/ci"},
+	}
+	for _, tt := range tests {
+		p, _ := Get(tt.profile)
+		if got := p.ReleaseLabel(tt.lbls); got != tt.want {
+			t.Errorf("%s: ReleaseLabel(%v) = %s, want %s", tt.profile, tt.lbls, got, tt.want)
+		}
+	}
+}
+
+func TestBackportBranches(t *testing.T) {
+	p, _ := Get("kubernetes")
+	got := p.BackportBranches([]string{"cherry-picked/1.28", "needs-cherry-pick/1.27", "backport-done/1.26"})
+	if want := []string{"cherry-picked/1.28"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("BackportBranches() = %v, want %v", got, want)
+	}
+}
+
+func TestBackportPRLabels(t *testing.T) {
+	tests := []struct {
+		profile string
+		branch  string
+		want    []string
+	}{
+		{"cilium", "v1.14", []string{"kind/backports", "backport/1.14"}},
+		{"kubernetes", "release-1.28", []string{"kind/cherry-pick", "cherry-pick/1.28"}},
+	}
+	for _, tt := range tests {
+		p, _ := Get(tt.profile)
+		if got := p.BackportPRLabels(tt.branch); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: BackportPRLabels(%s) = %v, want %v", tt.profile, tt.branch, got, tt.want)
+		}
+	}
+}
+
+func TestConventionalLabel(t *testing.T) {
+	tests := []struct {
+		profile string
+		title   string
+		want    string
+		wantOK  bool
+	}{
+		{"cilium", "feat: add foo", "release-note/minor", true},
+		{"cilium", "fix(cli): crash on start", "release-note/bug", true},
+		{"cilium", "Feat(cli): add bar", "release-note/minor", true},
+		{"cilium", "feat!: remove foo", "release-note/major", true},
+		{"cilium", "ci: fix flake", "release-note/ci", true},
+		{"cilium", "Add foo", "", false},
+		{"cilium", "wip: foo", "", false},
+		{"tetragon", "docs: fix typo", "release-note/docs", true},
+		{"kubernetes", "chore: bump deps", "kind/cleanup", true},
+	}
+	for _, tt := range tests {
+		p, _ := Get(tt.profile)
+		got, ok := p.ConventionalLabel(tt.title)
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("%s: ConventionalLabel(%q) = %s, %v, want %s, %v", tt.profile, tt.title, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
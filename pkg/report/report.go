@@ -0,0 +1,208 @@
+// Copyright 2021 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package report writes the machine-readable report of a run of the release
+// tool, so that the runs can be archived and compared over time.
+package report
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cilium/release/pkg/usage"
+)
+
+// Flag is the flag, accepted by all the commands, of the file the report of
+// the run is written into.
+const Flag = "run-report"
+
+// warningPrefix is the prefix of the warnings printed into stderr.
+const warningPrefix = "WARNING: "
+
+// Report summarizes a run.
+type Report struct {
+	// Command is the subcommand run, or 'release-notes'.
+	Command string `json:"command"`
+	// Args are the arguments of the command, i.e. its inputs.
+	Args     []string      `json:"args"`
+	Start    time.Time     `json:"start"`
+	Duration time.Duration `json:"duration"`
+	ExitCode int           `json:"exitCode"`
+	// Warnings are the warnings printed into stderr, without their
+	// 'WARNING: ' prefix.
+	Warnings []string `json:"warnings"`
+	// Usage are the API calls made and the duration of each phase.
+	Usage     usage.Report `json:"usage"`
+	Artifacts []Artifact   `json:"artifacts"`
+}
+
+// Artifact is a file written by the run.
+type Artifact struct {
+	Path   string `json:"path"`
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256"`
+}
+
+var (
+	writtenMu sync.Mutex
+	// written are the files registered by Written, by clean path.
+	written = map[string]string{}
+)
+
+// Written registers file as written by the run, so that it is part of the
+// artifacts of its report. Every command writing a file, including through
+// a default path, calls it once the file is written.
+func Written(file string) {
+	writtenMu.Lock()
+	defer writtenMu.Unlock()
+	written[filepath.Clean(file)] = file
+}
+
+// writtenFiles returns the files registered by Written, sorted, and forgets
+// them.
+func writtenFiles() []string {
+	writtenMu.Lock()
+	defer writtenMu.Unlock()
+	files := make([]string, 0, len(written))
+	for _, file := range written {
+		files = append(files, file)
+	}
+	written = map[string]string{}
+	sort.Strings(files)
+	return files
+}
+
+// Recorder records a run until its report is written.
+type Recorder struct {
+	report  Report
+	tracker *usage.Tracker
+
+	stderr *os.File
+	pipe   *os.File
+	done   chan struct{}
+
+	mu       sync.Mutex
+	warnings []string
+}
+
+// Start starts recording the run of the given command. stderr is captured,
+// while still being printed, to collect the warnings.
+func Start(command string, args []string, tracker *usage.Tracker) (*Recorder, error) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		return nil, err
+	}
+	rec := &Recorder{
+		report: Report{
+			Command: command,
+			Args:    append([]string{}, args...),
+			Start:   time.Now(),
+		},
+		tracker: tracker,
+		stderr:  os.Stderr,
+		pipe:    w,
+		done:    make(chan struct{}),
+	}
+	// Only the files written from now on are artifacts of the run.
+	writtenFiles()
+	os.Stderr = w
+	go func() {
+		defer close(rec.done)
+		rec.copy(rec.stderr, r)
+	}()
+	return rec, nil
+}
+
+// copy copies the lines of r into w, recording the warnings among them.
+func (rec *Recorder) copy(w io.Writer, r io.Reader) {
+	br := bufio.NewReader(r)
+	for {
+		line, err := br.ReadString('\n')
+		if len(line) != 0 {
+			io.WriteString(w, line)
+			rec.record(line)
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+// record records line if it is a warning, annotated for GitHub Actions or
+// not.
+func (rec *Recorder) record(line string) {
+	line = strings.TrimPrefix(strings.TrimSpace(line), "::warning::")
+	if !strings.HasPrefix(line, warningPrefix) {
+		return
+	}
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	rec.warnings = append(rec.warnings, strings.TrimPrefix(line, warningPrefix))
+}
+
+// Stop stops capturing stderr and returns the report of the run, which
+// ended with the given exit code.
+func (rec *Recorder) Stop(exitCode int) Report {
+	os.Stderr = rec.stderr
+	rec.pipe.Close()
+	<-rec.done
+
+	r := rec.report
+	r.Duration = time.Since(r.Start)
+	r.ExitCode = exitCode
+	r.Warnings = append([]string{}, rec.warnings...)
+	r.Usage = rec.tracker.Report()
+	r.Artifacts = artifacts(writtenFiles())
+	return r
+}
+
+// artifacts returns the given files, with their final size and checksum.
+func artifacts(files []string) []Artifact {
+	arts := []Artifact{}
+	for _, file := range files {
+		fi, err := os.Stat(file)
+		if err != nil || !fi.Mode().IsRegular() {
+			continue
+		}
+		b, err := os.ReadFile(file)
+		if err != nil {
+			continue
+		}
+		sum := sha256.Sum256(b)
+		arts = append(arts, Artifact{
+			Path:   file,
+			Size:   fi.Size(),
+			SHA256: hex.EncodeToString(sum[:]),
+		})
+	}
+	return arts
+}
+
+// WriteFile writes the report as JSON into the given file.
+func (r Report) WriteFile(file string) error {
+	b, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(file, append(b, '\n'), 0644)
+}
@@ -0,0 +1,72 @@
+// Copyright 2021 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package report
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/cilium/release/pkg/usage"
+)
+
+func TestRecorder(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "overrides.yaml")
+	output := filepath.Join(dir, "CHANGELOG.md")
+	if err := os.WriteFile(input, []byte("{}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	args := []string{"--overrides", input, "--output=" + output, "--base", "v1.14.2"}
+
+	// Files written before the run aren't part of its artifacts.
+	Written(input)
+	tracker := usage.New()
+	rec, err := Start("release-notes", args, tracker)
+	if err != nil {
+		t.Fatal(err)
+	}
+	end := tracker.Phase("rendering")
+	fmt.Fprintf(os.Stderr, "Found 2 commits!\n")
+	fmt.Fprintf(os.Stderr, "WARNING: PR #123 is not found by the search API\n")
+	fmt.Fprintf(os.Stderr, "::warning::WARNING: PR not found for commit 0123456!\n")
+	if err := os.WriteFile(output, []byte("Summary of Changes\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	Written(output)
+	end()
+	r := rec.Stop(0)
+
+	if r.Command != "release-notes" || !reflect.DeepEqual(r.Args, args) {
+		t.Errorf("got command %s %v, want release-notes %v", r.Command, r.Args, args)
+	}
+	wantWarnings := []string{"PR #123 is not found by the search API", "PR not found for commit 0123456!"}
+	if !reflect.DeepEqual(r.Warnings, wantWarnings) {
+		t.Errorf("got warnings %q, want %q", r.Warnings, wantWarnings)
+	}
+	if len(r.Usage.Phases) != 1 || r.Usage.Phases[0].Name != "rendering" {
+		t.Errorf("got phases %v, want rendering", r.Usage.Phases)
+	}
+	wantArtifacts := []Artifact{{
+		Path:   output,
+		Size:   19,
+		SHA256: "4b63a0e11dea94006cde54d4a2f3d843fddac8a0c0bf7e4526bf39bb74c23eae",
+	}}
+	if !reflect.DeepEqual(r.Artifacts, wantArtifacts) {
+		t.Errorf("got artifacts %v, want %v", r.Artifacts, wantArtifacts)
+	}
+}
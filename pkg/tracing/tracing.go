@@ -0,0 +1,118 @@
+// Copyright 2021 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package tracing records OpenTelemetry spans of the GitHub API calls and of
+// the phases of a run, exported through OTLP when configured.
+package tracing
+
+import (
+	"context"
+	"net/http"
+	"os"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/cilium/release/pkg/usage"
+)
+
+const tracerName = "github.com/cilium/release"
+
+// Enabled returns true if an OTLP endpoint is configured through the
+// standard OTEL_EXPORTER_OTLP_ENDPOINT or OTEL_EXPORTER_OTLP_TRACES_ENDPOINT
+// environment variables.
+func Enabled() bool {
+	return len(os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT")) != 0 ||
+		len(os.Getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT")) != 0
+}
+
+// Setup exports the spans through OTLP over HTTP if Enabled, and returns the
+// function flushing them. Otherwise, the spans are discarded.
+func Setup(ctx context.Context) (shutdown func(context.Context) error, err error) {
+	if !Enabled() {
+		return func(context.Context) error { return nil }, nil
+	}
+	exporter, err := otlptracehttp.New(ctx)
+	if err != nil {
+		return nil, err
+	}
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(
+		semconv.SchemaURL,
+		semconv.ServiceName("cilium-release"),
+	))
+	if err != nil {
+		return nil, err
+	}
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+	)
+	otel.SetTracerProvider(tp)
+	return tp.Shutdown, nil
+}
+
+// Start starts a span with the given name.
+func Start(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return otel.Tracer(tracerName).Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// Phase starts the given phase of a run, recorded both as a span and in
+// tracker, and returns the context of the phase and the function ending it.
+func Phase(ctx context.Context, tracker *usage.Tracker, name string) (context.Context, func()) {
+	ctx, span := Start(ctx, name)
+	endPhase := tracker.Phase(name)
+	return ctx, func() {
+		endPhase()
+		span.End()
+	}
+}
+
+// RoundTripper returns a http.RoundTripper recording a span for each call
+// made through next, named after its endpoint, e.g.
+// 'GET /repos/:owner/:repo/pulls/:number'.
+func RoundTripper(next http.RoundTripper) http.RoundTripper {
+	return roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		ctx, span := otel.Tracer(tracerName).Start(req.Context(), req.Method+" "+usage.Endpoint(req.URL.Path),
+			trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(
+				semconv.HTTPMethod(req.Method),
+				semconv.HTTPURL(req.URL.String()),
+			))
+		defer span.End()
+		resp, err := next.RoundTrip(req.WithContext(ctx))
+		switch {
+		case err != nil:
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		case resp.StatusCode >= 400:
+			span.SetAttributes(semconv.HTTPStatusCode(resp.StatusCode))
+			span.SetStatus(codes.Error, resp.Status)
+		default:
+			span.SetAttributes(semconv.HTTPStatusCode(resp.StatusCode))
+		}
+		return resp, err
+	})
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
@@ -0,0 +1,81 @@
+// Copyright 2021 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tracing
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"github.com/cilium/release/pkg/usage"
+)
+
+func TestRoundTripper(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/repos/cilium/cilium/pulls/2" {
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	tracker := usage.New()
+	ctx, endPhase := Phase(context.Background(), tracker, "PR resolution")
+	client := &http.Client{Transport: RoundTripper(http.DefaultTransport)}
+	for _, path := range []string{"/repos/cilium/cilium/pulls/1", "/repos/cilium/cilium/pulls/2"} {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+path, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+	}
+	endPhase()
+
+	spans := recorder.Ended()
+	if len(spans) != 3 {
+		t.Fatalf("got %d spans, want 3", len(spans))
+	}
+	phase := spans[2]
+	if phase.Name() != "PR resolution" {
+		t.Errorf("got phase span %q, want %q", phase.Name(), "PR resolution")
+	}
+	for i, want := range []codes.Code{codes.Unset, codes.Error} {
+		span := spans[i]
+		if span.Name() != "GET /repos/:owner/:repo/pulls/:number" {
+			t.Errorf("got span %q, want %q", span.Name(), "GET /repos/:owner/:repo/pulls/:number")
+		}
+		if span.Parent().SpanID() != phase.SpanContext().SpanID() {
+			t.Errorf("span %d is not a child of the phase span", i)
+		}
+		if span.Status().Code != want {
+			t.Errorf("got status %v for span %d, want %v", span.Status().Code, i, want)
+		}
+	}
+	if phases := tracker.Report().Phases; len(phases) != 1 || phases[0].Name != "PR resolution" {
+		t.Errorf("got phases %v, want the PR resolution phase", phases)
+	}
+}
@@ -0,0 +1,557 @@
+// Copyright 2021 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/cilium/release/pkg/git"
+	"github.com/cilium/release/pkg/profile"
+	"github.com/cilium/release/pkg/version"
+)
+
+// Config contains the options given to the release tool.
+type Config struct {
+	Base string
+	Head string
+	// LastStable are the stable versions (e.g. '1.13', or '<=1.13' for
+	// 1.13 and all the earlier series) whose backported PRs are assumed to
+	// be already released.
+	LastStable []string
+	StateFile  string
+	// ShasFile is the file of the commits of the release, one SHA per line
+	// from head to base as listed by 'git rev-list', read instead of
+	// comparing Base and Head if it exists, and written otherwise.
+	ShasFile string
+	// AllowTruncated lets the release notes be generated from a truncated
+	// comparison of Base and Head, without the commits GitHub didn't list.
+	AllowTruncated bool
+	// CacheDir is the directory of the PR metadata cache shared across
+	// runs. The cache is disabled if empty.
+	CacheDir string
+	RepoName string
+	// Remote, if set, is the git remote of the current clone RepoName is
+	// derived from when --repo isn't given.
+	Remote  string
+	CurrVer string
+	NextVer string
+	// SinceVersion, if set, is the version, e.g. '1.14.2', whose tag is the
+	// base of the release notes and whose branch is their head.
+	SinceVersion string
+
+	// UpstreamRepoName is the repository of the upstream PRs referenced
+	// by the backport PRs, if different from RepoName.
+	UpstreamRepoName string
+
+	// Owner and Repo are derived from RepoName, and UpstreamOwner and
+	// UpstreamRepo from UpstreamRepoName, by Sanitize.
+	Owner         string
+	Repo          string
+	UpstreamOwner string
+	UpstreamRepo  string
+
+	// SecurityFork, if set, is the private fork, e.g. of an embargoed
+	// security release, in which the commits are compared and the PRs
+	// resolved instead of RepoName. The release notes still target
+	// RepoName, referencing the PRs of the fork until they are disclosed,
+	// see DisclosuresFile. ForkOwner and ForkRepo are derived from it by
+	// Sanitize.
+	SecurityFork string
+	ForkOwner    string
+	ForkRepo     string
+	// DisclosuresFile, if set, maps the PRs of SecurityFork to the public
+	// PRs of RepoName they were disclosed as.
+	DisclosuresFile string
+
+	// ForceMovePending lets "pending" backports be moved from one project
+	// to another. By default this is set to false, since most commonly
+	// this is a mistake and the PR should have been previously marked as
+	// "backport-done".
+	ForceMovePending bool
+
+	// MovePending contains the numbers of the "pending" backports that are
+	// moved from one project to another. Other pending backports are left
+	// in the current project.
+	MovePending []int
+
+	// InteractiveMovePending asks, for each "pending" backport not present
+	// in MovePending, whether it should be moved to the next project.
+	InteractiveMovePending bool
+
+	// ProjectsV2 makes the backport projects be managed as GitHub
+	// ProjectsV2 instead of classic projects.
+	ProjectsV2 bool
+
+	// MergePrereleases is the final version (e.g. '1.14.0') for which the
+	// notes of all published pre-releases (e.g. 'v1.14.0-rc.1') should be
+	// merged into the generated notes.
+	MergePrereleases string
+
+	// SinceLatestRelease is the branch (e.g. '1.14') whose most recent
+	// published release is used as Base.
+	SinceLatestRelease string
+
+	// ExcludePublished is the branch (e.g. '1.14') whose published
+	// releases are fetched to exclude the PRs they mention from the
+	// generated notes.
+	ExcludePublished string
+
+	// DependencyChanges adds a Dependency Changes section listing the
+	// modules added, removed or updated in go.mod between Base and Head.
+	DependencyChanges bool
+
+	// KnownIssues is the branch (e.g. '1.14') whose open issues labeled
+	// 'known-issue/v1.14' are listed in the Known Issues section of the
+	// release notes.
+	KnownIssues string
+
+	// StreamFile, if set, is the file into which the changelog entries are
+	// written, in StreamFormat, as soon as their PRs are resolved.
+	StreamFile   string
+	StreamFormat string
+
+	// UsageReport, if set, is the file into which the API usage and timing
+	// report of the run is written as JSON.
+	UsageReport string
+
+	// OrphansReport, if set, is the file into which the commits and PRs
+	// that can't be categorized are written as JSON, grouped by reason.
+	// The backport PRs referencing an upstream PR that doesn't exist are
+	// then reported instead of failing the run.
+	OrphansReport string
+
+	// OverridesFile, if set, is the YAML file mapping PR numbers to the
+	// corrections applied to their entries once the PRs are fetched, see
+	// config.Overrides.
+	OverridesFile string
+
+	// AuthorsFile, if set, is the file mapping the GitHub login of the PR
+	// authors to how they are shown in the release notes.
+	AuthorsFile string
+
+	// CommunityMarker, if set, is shown before the release notes of the PRs
+	// authored by someone outside of the maintainers of the repository.
+	CommunityMarker string
+
+	// ThankNewContributors adds a line thanking the authors whose first
+	// merged PR is part of the release notes.
+	ThankNewContributors bool
+
+	// CreditReviewers looks up the users who approved each PR and credits
+	// them alongside its author in the release notes.
+	CreditReviewers bool
+
+	// AnnotateRisk annotates the entries with the size of their PR and a
+	// risk hint computed from the files it changes, critical ones being
+	// given by the backports of ConfigFile.
+	AnnotateRisk bool
+
+	// ComponentsSummary adds a summary of the components, e.g. the
+	// datapath or the operator, touched by the changes, computed from the
+	// files changed by their PR.
+	ComponentsSummary bool
+
+	// Format is the format of the release notes, e.g. 'markdown' or
+	// 'summary'.
+	Format string
+	// Top is the number of entries listed by the 'summary' format.
+	Top int
+	// PriorityLabels are the labels, by decreasing priority, of the
+	// entries listed first by the 'summary' format.
+	PriorityLabels []string
+	// RankByReactions ranks the entries listed by the 'summary' format, after
+	// PriorityLabels, by the number of 👍 and 🎉 reactions of their PRs.
+	RankByReactions bool
+
+	// MaxSize, if not 0, is the size in bytes above which the Misc and
+	// Other sections of the release notes are collapsed.
+	MaxSize int
+	// FullList is where the full list of changes can be found when
+	// sections are collapsed.
+	FullList string
+
+	// SkipCIChanges leaves the CI changes out of the release notes. They
+	// are written into CIChangesFile instead, if set.
+	SkipCIChanges bool
+	CIChangesFile string
+
+	// ExcludedFile and ExcludedReport, if set, are where the PRs excluded
+	// from the release notes as they were backported to the LastStable
+	// branches are written, as Markdown and JSON respectively, instead of
+	// being listed in stderr.
+	ExcludedFile   string
+	ExcludedReport string
+	// PreviouslyReleased appends the PRs excluded as they were backported
+	// to the LastStable branches to the release notes, in a section of
+	// their own.
+	PreviouslyReleased bool
+
+	// UpgradeNotesFile, if set, is where the upgrade notes of the PRs
+	// labeled upgrade-impact are consolidated.
+	UpgradeNotesFile string
+
+	// SkipNone leaves the Other Changes, i.e. the PRs labeled
+	// 'release-note/none', out of the release notes, except in their JSON
+	// format. They are still written into StreamFile.
+	SkipNone bool
+
+	// Output, if set, is the file into which the release notes are
+	// written instead of stdout.
+	Output string
+
+	// WaitForReset waits for the API rate limit to be reset, instead of
+	// failing, if the remaining rate limit isn't enough for the run.
+	WaitForReset bool
+
+	// Profile is the name of the label scheme of the repository, e.g.
+	// 'cilium' or 'kubernetes', defining the sections of the release notes
+	// and the backport labels.
+	Profile string
+
+	// ConventionalCommits categorizes the PRs without any release note
+	// label from the Conventional Commit prefix of their title, e.g.
+	// 'feat:' or 'fix:'.
+	ConventionalCommits bool
+
+	// Mode is how the release notes are built: from the PRs of the
+	// commits, 'prs' or empty, from the commit messages only, 'commits',
+	// which is much faster as no PR is resolved, or from the PRs merged
+	// into SearchBranch between the dates of Base and Head, 'search',
+	// which is much cheaper for branches with a linear history.
+	Mode string
+	// SearchBranch is the branch the PRs are merged into in the 'search'
+	// Mode, or in the cross-check with CrossCheckSearch. Defaults to Head.
+	SearchBranch string
+
+	// NoPRCommits is what happens to the commits without any PR: 'drop'
+	// leaves them out, 'render' lists them in the section of the default
+	// label with their subject and 'fail' fails the run. If empty, they
+	// are dropped in the 'prs' Mode and rendered in the 'commits' one.
+	NoPRCommits string
+
+	// GroupBy is how the entries are grouped into sections: by the type of
+	// change, 'type' or empty, or by the group owning them, 'owner', as
+	// mapped from their labels, e.g. 'sig/datapath', in ConfigFile.
+	GroupBy string
+
+	// StrictLabels fails the run if any PR has the labels of several
+	// sections, e.g. both release-note/bug and release-note/minor, instead
+	// of only warning about them.
+	StrictLabels bool
+
+	// CrossCheckGitHub, if set, compares the PRs found with the release
+	// notes generated by GitHub for the same range: 'diff' reports the
+	// differences and 'merge' also adds the PRs only found by GitHub.
+	CrossCheckGitHub string
+	// CrossCheckSearch compares the PRs found from the commits with the
+	// ones found by the search API, see Mode.
+	CrossCheckSearch bool
+
+	// ConfigFile, if set, is the configuration file whose schedule gives
+	// the end of life dates of the branches. Releases of branches past
+	// their end of life are refused unless Force is set.
+	ConfigFile string
+	Force      bool
+
+	// Branches, if set, are the branches (e.g. '1.14') whose release
+	// notes since their latest release are generated in parallel, each
+	// into its own output and state files.
+	Branches []string
+
+	// ChecksumsFile, if set, is the SHA256SUMS file into which the
+	// checksum of Output is added.
+	ChecksumsFile string
+	// Sign, if set, is how the detached signature of Output is created,
+	// e.g. 'gpg' or 'cosign'.
+	Sign string
+	// ProvenanceFile, if set, is where the SLSA provenance of Output, and
+	// of the other files written along with it, is written.
+	ProvenanceFile string
+	// ProvenanceArtifacts are the other files of the release, e.g. its
+	// binaries, that the provenance covers along with the release notes.
+	ProvenanceArtifacts []string
+
+	// GitHubActions writes the release notes into the summary of the
+	// GitHub Actions step, sets the outputs of the step and annotates the
+	// warnings.
+	GitHubActions bool
+
+	// PreviewPR, if set, is the release preparation PR on which the release
+	// notes are posted as a comment. Its head and target branch are used
+	// if Head and Base are not set.
+	PreviewPR int
+
+	// FoldReverts leaves the PRs reverted within the release notes, and
+	// their reverts, out of the sections and lists them, along with the
+	// PRs landing them again, in a Reverted Changes appendix.
+	FoldReverts bool
+
+	// MergeDuplicates merges the entries of a section with the same release
+	// note, e.g. of split backports or stacked PRs, into one listing all
+	// their PRs.
+	MergeDuplicates bool
+
+	// LateMerges, if set, is the stable branch, e.g. '1.14', into which
+	// the PRs merged after Head, and the PRs labeled as needing a backport
+	// to it, are reported as possibly missing from the release.
+	LateMerges string
+
+	// RequireBackports, if set, is the stable branch, e.g. '1.14', whose
+	// PRs labeled as needing a backport, or with a pending backport, must
+	// be part of the release notes for them to be generated.
+	RequireBackports string
+
+	// TrackingIssue, if set, is the URL of the tracking issue of the release
+	// on which the release notes are posted, and updated, as a comment.
+	TrackingIssue string
+
+	// NaturalSort sorts the entries of the release notes comparing the
+	// numbers they contain by their value, e.g. 'v2' before 'v10'.
+	NaturalSort bool
+
+	// NormalizeNotes copy-edits the release notes for a consistent style,
+	// e.g. capitalizing them and stripping their trailing periods.
+	NormalizeNotes bool
+
+	// FrontMatter precedes the release notes with a YAML front matter so
+	// that they can be published by a Hugo or Docusaurus docs site. Its
+	// fields default to the version detected from Head and to today.
+	FrontMatter        bool
+	FrontMatterTitle   string
+	FrontMatterDate    string
+	FrontMatterVersion string
+	FrontMatterAliases []string
+
+	// TemplateDir, if set, is the directory of the templates overriding
+	// the default ones the release notes are rendered with.
+	TemplateDir string
+
+	// Watch keeps running once the release notes are written and
+	// regenerates them whenever Head gains new commits, checked every
+	// WatchInterval.
+	Watch         bool
+	WatchInterval time.Duration
+
+	// Sinks are the destinations the release notes are published into,
+	// each given as '<kind>[:<target>]', e.g. 'stdout', 'file:NOTES.md',
+	// 'release:v1.14.3', 'gist[:<id>]' or 'issue:1234'. The release notes are
+	// only written into stdout, without Output, if none is given.
+	Sinks []string
+	// Confirm and Environment confirm the steps mutating the repository,
+	// i.e. syncing the projects and publishing into a release sink, see
+	// confirm.Open.
+	Confirm     string
+	Environment string
+}
+
+// Sanitize validates the configuration and fills in the derived fields.
+// With SinceVersion, Base defaults to its tag and Head to the branch of its
+// minor version.
+func (cfg *Config) Sanitize() error {
+	if len(cfg.SinceVersion) != 0 {
+		v, err := version.Parse(cfg.SinceVersion)
+		if err != nil {
+			return fmt.Errorf("--since-version should be of the format 'x.y.z': %w", err)
+		}
+		if len(cfg.Base) != 0 || len(cfg.SinceLatestRelease) != 0 || len(cfg.CurrVer) != 0 {
+			return fmt.Errorf("--since-version can't be used with --base, --since-latest-release or --current-version")
+		}
+		cfg.Base = "v" + v.String()
+		if len(cfg.Head) == 0 {
+			cfg.Head = "v" + v.MinorString()
+		}
+	}
+	if len(cfg.Branches) != 0 {
+		if len(cfg.Base) != 0 || len(cfg.Head) != 0 || len(cfg.SinceLatestRelease) != 0 || len(cfg.CurrVer) != 0 || cfg.PreviewPR != 0 {
+			return fmt.Errorf("--branches can't be used with --base, --head, --since-latest-release, --since-version, --current-version or --preview-pr")
+		}
+		for _, branch := range cfg.Branches {
+			if strings.HasPrefix(branch, "v") {
+				return fmt.Errorf("--branches should be of the format 'x.y'")
+			}
+		}
+	}
+	if len(cfg.Base) == 0 && len(cfg.CurrVer) == 0 && len(cfg.SinceLatestRelease) == 0 && cfg.PreviewPR == 0 && len(cfg.Branches) == 0 {
+		return fmt.Errorf("--base can't be empty")
+	}
+	if len(cfg.Base) != 0 && len(cfg.SinceLatestRelease) != 0 {
+		return fmt.Errorf("--base and --since-latest-release can't be used together")
+	}
+	if strings.HasPrefix(cfg.SinceLatestRelease, "v") {
+		return fmt.Errorf("--since-latest-release should be of the format 'x.y'")
+	}
+	if len(cfg.Head) == 0 && len(cfg.CurrVer) == 0 && cfg.PreviewPR == 0 && len(cfg.Branches) == 0 {
+		return fmt.Errorf("--head can't be empty")
+	}
+	if len(cfg.StateFile) == 0 {
+		return fmt.Errorf("--state-file can't be empty")
+	}
+	if len(cfg.TemplateDir) != 0 {
+		if fi, err := os.Stat(cfg.TemplateDir); err != nil || !fi.IsDir() {
+			return fmt.Errorf("--template-dir %s should be a directory", cfg.TemplateDir)
+		}
+	}
+	if cfg.Watch {
+		switch {
+		case len(cfg.Branches) != 0 || cfg.PreviewPR != 0:
+			return fmt.Errorf("--watch can't be used with --branches or --preview-pr")
+		case len(cfg.NextVer) != 0:
+			return fmt.Errorf("--watch can't be used with --next-dev-version")
+		case cfg.WatchInterval <= 0:
+			return fmt.Errorf("--watch-interval should be positive")
+		}
+	}
+	for _, s := range cfg.Sinks {
+		if _, err := ParseSink(s); err != nil {
+			return fmt.Errorf("--sink %s: %w", s, err)
+		}
+	}
+	if (len(cfg.Sinks) != 0 || len(cfg.TrackingIssue) != 0 || len(cfg.ShasFile) != 0) && len(cfg.Branches) != 0 {
+		return fmt.Errorf("--sink, --tracking-issue and --shas-file can't be used with --branches")
+	}
+	for _, lastStable := range cfg.LastStable {
+		if strings.Contains(lastStable, "v") {
+			return fmt.Errorf("--last-stable can't contain letters, should be of the format 'x.y' or '<=x.y'")
+		}
+		if _, err := version.ParseSeries(lastStable); err != nil {
+			return fmt.Errorf("--last-stable %s should be of the format 'x.y' or '<=x.y'", lastStable)
+		}
+	}
+	if strings.HasPrefix(cfg.MergePrereleases, "v") {
+		return fmt.Errorf("--merge-prereleases should be of the format 'x.y.z'")
+	}
+	switch cfg.Format {
+	case "", "markdown", "summary", "keepachangelog", "csv", "relnotes":
+	default:
+		return fmt.Errorf("--format should be 'markdown', 'summary', 'keepachangelog', 'csv' or 'relnotes'")
+	}
+	if (cfg.Format == "csv" || cfg.Format == "relnotes") && cfg.FrontMatter {
+		return fmt.Errorf("--front-matter can't be used with --format=%s", cfg.Format)
+	}
+	if (len(cfg.ChecksumsFile) != 0 || len(cfg.Sign) != 0 || len(cfg.ProvenanceFile) != 0) && len(cfg.Output) == 0 {
+		return fmt.Errorf("--checksums-file, --sign and --provenance require --output")
+	}
+	if len(cfg.ProvenanceArtifacts) != 0 && len(cfg.ProvenanceFile) == 0 {
+		return fmt.Errorf("--provenance-artifact requires --provenance")
+	}
+	switch cfg.Sign {
+	case "", "gpg", "cosign":
+	default:
+		return fmt.Errorf("--sign should be 'gpg' or 'cosign'")
+	}
+	switch cfg.Mode {
+	case "", "prs", "commits", "search":
+	default:
+		return fmt.Errorf("--mode should be 'prs', 'commits' or 'search'")
+	}
+	if cfg.CrossCheckSearch && len(cfg.Mode) != 0 && cfg.Mode != "prs" {
+		return fmt.Errorf("--cross-check-search requires --mode=prs")
+	}
+	switch cfg.NoPRCommits {
+	case "", "drop", "render", "fail":
+	default:
+		return fmt.Errorf("--no-pr-commits should be 'drop', 'render' or 'fail'")
+	}
+	switch cfg.GroupBy {
+	case "", "type":
+	case "owner":
+		if len(cfg.ConfigFile) == 0 {
+			return fmt.Errorf("--group-by=owner requires --config")
+		}
+	default:
+		return fmt.Errorf("--group-by should be 'type' or 'owner'")
+	}
+	switch cfg.CrossCheckGitHub {
+	case "", "diff", "merge":
+	default:
+		return fmt.Errorf("--cross-check-github should be 'diff' or 'merge'")
+	}
+	if _, err := profile.Get(cfg.Profile); err != nil {
+		return err
+	}
+	if strings.HasPrefix(cfg.ExcludePublished, "v") {
+		return fmt.Errorf("--exclude-published should be of the format 'x.y'")
+	}
+	if strings.HasPrefix(cfg.LateMerges, "v") {
+		return fmt.Errorf("--late-merges should be of the format 'x.y'")
+	}
+	if len(cfg.LateMerges) != 0 && len(cfg.Branches) != 0 {
+		return fmt.Errorf("--late-merges can't be used with --branches")
+	}
+	if strings.HasPrefix(cfg.RequireBackports, "v") {
+		return fmt.Errorf("--require-backports should be of the format 'x.y'")
+	}
+	if len(cfg.RequireBackports) != 0 && len(cfg.Branches) != 0 {
+		return fmt.Errorf("--require-backports can't be used with --branches")
+	}
+	if strings.HasPrefix(cfg.KnownIssues, "v") {
+		return fmt.Errorf("--known-issues should be of the format 'x.y'")
+	}
+	for _, name := range []*string{&cfg.RepoName, &cfg.UpstreamRepoName, &cfg.SecurityFork} {
+		n, err := NormalizeRepoName(*name)
+		if err != nil {
+			return err
+		}
+		*name = n
+	}
+	var err error
+	cfg.Owner, cfg.Repo, err = SplitRepoName(cfg.RepoName)
+	if err != nil {
+		return err
+	}
+	cfg.UpstreamOwner, cfg.UpstreamRepo = cfg.Owner, cfg.Repo
+	if len(cfg.UpstreamRepoName) != 0 {
+		cfg.UpstreamOwner, cfg.UpstreamRepo, err = SplitRepoName(cfg.UpstreamRepoName)
+		if err != nil {
+			return err
+		}
+	}
+	if len(cfg.SecurityFork) != 0 {
+		switch {
+		case len(cfg.UpstreamRepoName) != 0:
+			return fmt.Errorf("--security-fork and --upstream-repo can't be used together")
+		case len(cfg.CrossCheckGitHub) != 0:
+			return fmt.Errorf("--security-fork and --cross-check-github can't be used together")
+		case cfg.PreviewPR != 0:
+			return fmt.Errorf("--security-fork and --preview-pr can't be used together")
+		}
+		cfg.ForkOwner, cfg.ForkRepo, err = SplitRepoName(cfg.SecurityFork)
+	} else if len(cfg.DisclosuresFile) != 0 {
+		return fmt.Errorf("--disclosures requires --security-fork")
+	}
+	return err
+}
+
+// NormalizeRepoName returns the 'owner/repo' name of a repository given
+// either by name or by the URL of its GitHub repository, e.g.
+// 'https://github.com/cilium/cilium' or 'git@github.com:cilium/cilium.git'.
+func NormalizeRepoName(name string) (string, error) {
+	if !strings.Contains(name, "github.com") {
+		return name, nil
+	}
+	return git.ParseRemoteURL(name)
+}
+
+// SplitRepoName splits a repository name of the format 'owner/repo'.
+func SplitRepoName(name string) (owner, repo string, err error) {
+	ownerRepo := strings.Split(name, "/")
+	if len(ownerRepo) != 2 {
+		return "", "", fmt.Errorf("Invalid repo name: %s", name)
+	}
+	return ownerRepo[0], ownerRepo[1], nil
+}
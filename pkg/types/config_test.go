@@ -0,0 +1,127 @@
+// Copyright 2021 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+import "testing"
+
+func TestSanitizeSinceVersion(t *testing.T) {
+	tests := []struct {
+		name     string
+		cfg      Config
+		wantBase string
+		wantHead string
+		wantErr  bool
+	}{
+		{
+			name:     "branch of the version",
+			cfg:      Config{SinceVersion: "1.14.2"},
+			wantBase: "v1.14.2",
+			wantHead: "v1.14",
+		},
+		{
+			name:     "leading v and pre-release",
+			cfg:      Config{SinceVersion: "v1.15.0-rc.1"},
+			wantBase: "v1.15.0-rc.1",
+			wantHead: "v1.15",
+		},
+		{
+			name:     "head given",
+			cfg:      Config{SinceVersion: "1.14.2", Head: "main"},
+			wantBase: "v1.14.2",
+			wantHead: "main",
+		},
+		{
+			name:    "invalid version",
+			cfg:     Config{SinceVersion: "1.14.x"},
+			wantErr: true,
+		},
+		{
+			name:    "with base",
+			cfg:     Config{SinceVersion: "1.14.2", Base: "v1.14.1"},
+			wantErr: true,
+		},
+		{
+			name:    "with current version",
+			cfg:     Config{SinceVersion: "1.14.2", CurrVer: "1.14.3"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := tt.cfg
+			cfg.RepoName = "cilium/cilium"
+			cfg.StateFile = "release-state.json"
+			err := cfg.Sanitize()
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("got base %q and head %q, want an error", cfg.Base, cfg.Head)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if cfg.Base != tt.wantBase || cfg.Head != tt.wantHead {
+				t.Errorf("got base %q and head %q, want %q and %q", cfg.Base, cfg.Head, tt.wantBase, tt.wantHead)
+			}
+		})
+	}
+}
+
+func TestSanitizeCurrentVersion(t *testing.T) {
+	// --current-version alone syncs the projects, no release notes range is
+	// derived from it.
+	cfg := Config{RepoName: "cilium/cilium", StateFile: "release-state.json", CurrVer: "1.14.3"}
+	if err := cfg.Sanitize(); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Base != "" || cfg.Head != "" {
+		t.Errorf("got base %q and head %q, want none", cfg.Base, cfg.Head)
+	}
+}
+
+func TestSanitizeLastStable(t *testing.T) {
+	tests := []struct {
+		lastStable []string
+		wantErr    bool
+	}{
+		{lastStable: []string{"1.13"}},
+		{lastStable: []string{"1.13", "1.12"}},
+		{lastStable: []string{"1.13", "v1.12"}, wantErr: true},
+		{lastStable: []string{"1.13", "1.12.1"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		cfg := Config{RepoName: "cilium/cilium", StateFile: "release-state.json", Base: "v1.14.0", Head: "v1.14", LastStable: tt.lastStable}
+		if err := cfg.Sanitize(); (err != nil) != tt.wantErr {
+			t.Errorf("Sanitize() with --last-stable %v: error = %v, wantErr %v", tt.lastStable, err, tt.wantErr)
+		}
+	}
+}
+
+func TestSanitizeSinks(t *testing.T) {
+	for _, tt := range []struct {
+		sinks   []string
+		wantErr bool
+	}{
+		{sinks: []string{"stdout", "file:NOTES.md", "release:v1.14.3", "gist", "issue:123"}},
+		{sinks: []string{"release:"}, wantErr: true},
+		{sinks: []string{"slack"}, wantErr: true},
+	} {
+		cfg := Config{RepoName: "cilium/cilium", StateFile: "release-state.json", Base: "v1.14.2", Head: "v1.14", Sinks: tt.sinks}
+		if err := cfg.Sanitize(); (err != nil) != tt.wantErr {
+			t.Errorf("Sanitize() with --sink %v: error = %v, wantErr %v", tt.sinks, err, tt.wantErr)
+		}
+	}
+}
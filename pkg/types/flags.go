@@ -0,0 +1,86 @@
+// Copyright 2021 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	flag "github.com/spf13/pflag"
+)
+
+// EnvPrefix is the prefix of the environment variables setting the flags,
+// see EnvName.
+const EnvPrefix = "RELEASE_"
+
+// EnvName returns the environment variable setting the given flag, e.g.
+// 'RELEASE_STATE_FILE' for 'state-file'.
+func EnvName(name string) string {
+	return EnvPrefix + strings.ToUpper(strings.ReplaceAll(name, "-", "_"))
+}
+
+// CommandEnvName returns the environment variable setting the given flag of
+// a subcommand, e.g. 'RELEASE_DOWNSTREAM_BUMP_STATE_FILE' for 'state-file'
+// of 'downstream bump'. It's scoped to the subcommand as the same flag, e.g.
+// --state-file, has a different meaning in each of them.
+func CommandEnvName(command, name string) string {
+	return EnvName(strings.ReplaceAll(command, " ", "-") + "-" + name)
+}
+
+// SetFromEnv sets the flags of fs that were not given on the command line
+// from their environment variable, see EnvName, if set. The command line
+// thus takes precedence over the environment, which takes precedence over
+// the defaults. As the flags set are then marked as changed, the environment
+// also takes precedence over the profile of --config. The values of the
+// slice flags are comma-separated.
+func SetFromEnv(fs *flag.FlagSet, lookupEnv func(string) (string, bool)) error {
+	return setFromEnv(fs, lookupEnv, EnvName)
+}
+
+// SetCommandFromEnv is SetFromEnv for the flags fs of the given subcommand,
+// see CommandEnvName.
+func SetCommandFromEnv(fs *flag.FlagSet, command string, lookupEnv func(string) (string, bool)) error {
+	return setFromEnv(fs, lookupEnv, func(name string) string {
+		return CommandEnvName(command, name)
+	})
+}
+
+func setFromEnv(fs *flag.FlagSet, lookupEnv func(string) (string, bool), envName func(string) string) error {
+	var err error
+	fs.VisitAll(func(f *flag.Flag) {
+		if err != nil || f.Changed {
+			return
+		}
+		value, ok := lookupEnv(envName(f.Name))
+		if !ok {
+			return
+		}
+		if err2 := fs.Set(f.Name, value); err2 != nil {
+			err = fmt.Errorf("invalid value %q for %s: %w", value, envName(f.Name), err2)
+		}
+	})
+	return err
+}
+
+// ParseFlags parses the arguments of the given subcommand, e.g. 'backport
+// create', into its flags fs and sets the ones not given from the
+// environment, see SetCommandFromEnv.
+func ParseFlags(fs *flag.FlagSet, command string, args []string) error {
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	return SetCommandFromEnv(fs, command, os.LookupEnv)
+}
@@ -0,0 +1,89 @@
+// Copyright 2021 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+import (
+	"reflect"
+	"testing"
+
+	flag "github.com/spf13/pflag"
+)
+
+func TestSetFromEnv(t *testing.T) {
+	var (
+		stateFile  string
+		base       string
+		lastStable []string
+		dryRun     bool
+	)
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.StringVar(&stateFile, "state-file", "release-state.json", "")
+	fs.StringVar(&base, "base", "", "")
+	fs.StringSliceVar(&lastStable, "last-stable", []string{"1.12"}, "")
+	fs.BoolVar(&dryRun, "dry-run", false, "")
+	if err := fs.Parse([]string{"--base", "v1.14.0"}); err != nil {
+		t.Fatal(err)
+	}
+	env := map[string]string{
+		"RELEASE_BASE":        "v1.13.0",
+		"RELEASE_LAST_STABLE": "1.13,1.12",
+		"RELEASE_DRY_RUN":     "true",
+	}
+	lookupEnv := func(key string) (string, bool) {
+		v, ok := env[key]
+		return v, ok
+	}
+	if err := SetFromEnv(fs, lookupEnv); err != nil {
+		t.Fatal(err)
+	}
+	if base != "v1.14.0" {
+		t.Errorf("got base %q, want the one of the command line", base)
+	}
+	if stateFile != "release-state.json" {
+		t.Errorf("got state file %q, want the default one", stateFile)
+	}
+	if !reflect.DeepEqual(lastStable, []string{"1.13", "1.12"}) || !dryRun {
+		t.Errorf("got last stable %v and dry run %v, want the ones of the environment", lastStable, dryRun)
+	}
+
+	env["RELEASE_DRY_RUN"] = "maybe"
+	fs.Lookup("dry-run").Changed = false
+	if err := SetFromEnv(fs, lookupEnv); err == nil {
+		t.Errorf("got no error for an invalid RELEASE_DRY_RUN")
+	}
+}
+
+func TestSetCommandFromEnv(t *testing.T) {
+	var stateFile string
+	fs := flag.NewFlagSet("downstream bump", flag.ContinueOnError)
+	fs.StringVar(&stateFile, "state-file", "downstream-state.json", "")
+	if err := fs.Parse(nil); err != nil {
+		t.Fatal(err)
+	}
+	env := map[string]string{
+		"RELEASE_STATE_FILE":                 "release-state.json",
+		"RELEASE_DOWNSTREAM_BUMP_STATE_FILE": "/work/downstream-state.json",
+	}
+	lookupEnv := func(key string) (string, bool) {
+		v, ok := env[key]
+		return v, ok
+	}
+	if err := SetCommandFromEnv(fs, "downstream bump", lookupEnv); err != nil {
+		t.Fatal(err)
+	}
+	if stateFile != "/work/downstream-state.json" {
+		t.Errorf("got state file %q, want the one of RELEASE_DOWNSTREAM_BUMP_STATE_FILE", stateFile)
+	}
+}
@@ -0,0 +1,40 @@
+// Copyright 2021 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+const (
+	// OrphanNoPR is the reason of the commits for which no merged PR was
+	// found.
+	OrphanNoPR = "no-pr"
+	// OrphanNoLabel is the reason of the PRs without any release note
+	// label.
+	OrphanNoLabel = "no-release-note-label"
+	// OrphanUnresolvedBackport is the reason of the backport PRs
+	// referencing an upstream PR that doesn't exist.
+	OrphanUnresolvedBackport = "unresolved-backport"
+)
+
+// Orphan is a commit, or a PR, that can't be properly categorized in the
+// release notes.
+type Orphan struct {
+	Reason string `json:"reason"`
+	SHA    string `json:"sha,omitempty"`
+	PR     int    `json:"pr,omitempty"`
+	// UpstreamPR is the upstream PR referenced by the backport PR that
+	// couldn't be resolved.
+	UpstreamPR int    `json:"upstreamPR,omitempty"`
+	Title      string `json:"title,omitempty"`
+	URL        string `json:"url,omitempty"`
+}
@@ -14,13 +14,71 @@
 
 package types
 
+import "time"
+
 type PullRequest struct {
+	Title        string `json:",omitempty"`
 	ReleaseNote  string
 	ReleaseLabel string
 	AuthorName   string
 	// BackportBranches contains all the backport-done labels present in the
 	// PullRequest.
 	BackportBranches []string
+
+	// Labels contains all the labels present in the PullRequest.
+	Labels    []string
+	MergedAt  time.Time
+	Milestone string
+	URL       string
+	// AuthorAssociation is the association of the author with the
+	// repository, e.g. 'MEMBER' or 'CONTRIBUTOR'.
+	AuthorAssociation string
+	// MissingReleaseNote is set if the PR has a release note label but no
+	// release note, in which case ReleaseNote is its title.
+	MissingReleaseNote bool `json:",omitempty"`
+	// Commit is the SHA of the commit the release note was built from when
+	// it doesn't reference any PR, see Config.Mode.
+	Commit string `json:",omitempty"`
+	// Repo is the repository of the PR, e.g. 'cilium/cilium-security',
+	// when it isn't the released one and the PR wasn't disclosed yet, see
+	// Config.SecurityFork.
+	Repo string `json:",omitempty"`
+	// Reviewers are the logins of the users who approved the PR, see
+	// Config.CreditReviewers. It is nil if they were not looked up, and
+	// kept empty in the state if nobody approved the PR so that they are
+	// not looked up again.
+	Reviewers []string
+	// Risk is computed from the files changed by the PR, see
+	// Config.AnnotateRisk. It is nil if they were not looked up.
+	Risk *Risk `json:",omitempty"`
+	// Components maps the components, e.g. 'datapath', of the files
+	// changed by the PR to their number of files, see
+	// Config.ComponentsSummary. It is nil if they were not looked up.
+	Components map[string]int `json:",omitempty"`
+	// UpgradeNotes are the instructions to upgrade past the PR, given in
+	// the upgrade-notes block of its description.
+	UpgradeNotes string `json:",omitempty"`
+}
+
+// Risk sums up the changes of a PR to hint at how risky it is.
+type Risk struct {
+	Additions int
+	Deletions int
+	Files     int
+	// CriticalPaths are the critical paths, e.g. 'pkg/datapath/', touched
+	// by the PR.
+	CriticalPaths []string `json:",omitempty"`
+}
+
+// IsCommunity returns true if the PullRequest was authored by someone that
+// is not a member, or a collaborator, of the repository. It returns false if
+// the author association is unknown.
+func (pr PullRequest) IsCommunity() bool {
+	switch pr.AuthorAssociation {
+	case "", "OWNER", "MEMBER", "COLLABORATOR":
+		return false
+	}
+	return true
 }
 
 // BackportPRs maps a backport type PR to the upstream PRs
@@ -0,0 +1,68 @@
+// Copyright 2021 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Kinds of the sinks the release notes are published into, given as
+// '--sink=<kind>[:<target>]'.
+const (
+	SinkStdout = "stdout"
+	// SinkFile writes the release notes into the file of its target.
+	SinkFile = "file"
+	// SinkRelease sets the release notes as the body of the release,
+	// draft or not, of the tag of its target.
+	SinkRelease = "release"
+	// SinkGist uploads the release notes as a secret gist or, if its
+	// target is the ID or URL of an existing gist, as a new revision of
+	// that gist, so that its URL stays the same across regenerations.
+	SinkGist = "gist"
+	// SinkIssue posts the release notes as a comment of the issue, or PR,
+	// of the number of its target.
+	SinkIssue = "issue"
+)
+
+// Sink is a destination of the release notes.
+type Sink struct {
+	Kind   string
+	Target string
+}
+
+// ParseSink parses a sink given as '<kind>[:<target>]'.
+func ParseSink(s string) (Sink, error) {
+	kind, target, _ := strings.Cut(s, ":")
+	switch kind {
+	case SinkGist:
+	case SinkStdout:
+		if len(target) != 0 {
+			return Sink{}, fmt.Errorf("sink %q doesn't take a target", kind)
+		}
+	case SinkFile, SinkRelease:
+		if len(target) == 0 {
+			return Sink{}, fmt.Errorf("sink %q needs a target, e.g. '%s:<%s>'", kind, kind, map[string]string{SinkFile: "path", SinkRelease: "tag"}[kind])
+		}
+	case SinkIssue:
+		if _, err := strconv.Atoi(target); err != nil {
+			return Sink{}, fmt.Errorf("sink %q needs the number of the issue, e.g. '%s:<number>'", kind, kind)
+		}
+	default:
+		return Sink{}, fmt.Errorf("unknown sink %q, should be one of %s, %s, %s, %s or %s", kind, SinkStdout, SinkFile, SinkRelease, SinkGist, SinkIssue)
+	}
+	return Sink{Kind: kind, Target: target}, nil
+}
@@ -0,0 +1,29 @@
+// Copyright 2021 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+import "testing"
+
+func TestParseSink(t *testing.T) {
+	for _, s := range []string{"stdout:foo", "file", "release:", "issue:abc", "slack"} {
+		if _, err := ParseSink(s); err == nil {
+			t.Errorf("ParseSink(%q) should fail", s)
+		}
+	}
+	got, err := ParseSink("file:docs/NOTES.md")
+	if err != nil || got != (Sink{Kind: SinkFile, Target: "docs/NOTES.md"}) {
+		t.Errorf("ParseSink() = %v, %v", got, err)
+	}
+}
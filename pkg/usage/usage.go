@@ -0,0 +1,228 @@
+// Copyright 2021 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package usage keeps track of the GitHub API calls made, and of the time
+// spent, by a run of the release tool.
+package usage
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Tracker records the API calls made through its RoundTripper and the
+// duration of the phases of a run. A nil Tracker records nothing.
+type Tracker struct {
+	mu         sync.Mutex
+	calls      map[string]int
+	phases     []Phase
+	rateLimits map[string]RateLimit
+}
+
+// Phase is a named step of a run.
+type Phase struct {
+	Name     string        `json:"name"`
+	Duration time.Duration `json:"duration"`
+}
+
+// RateLimit is the last rate limit reported by GitHub for a resource
+// (e.g. 'core', 'graphql' or 'search').
+type RateLimit struct {
+	Limit     int       `json:"limit"`
+	Remaining int       `json:"remaining"`
+	Reset     time.Time `json:"reset"`
+}
+
+// Report is a snapshot of the usage recorded by a Tracker.
+type Report struct {
+	// Calls maps an endpoint (e.g. 'GET /repos/:owner/:repo/pulls/:number')
+	// to the number of calls made to it.
+	Calls        map[string]int       `json:"calls"`
+	RESTCalls    int                  `json:"restCalls"`
+	GraphQLCalls int                  `json:"graphqlCalls"`
+	Phases       []Phase              `json:"phases"`
+	RateLimits   map[string]RateLimit `json:"rateLimits"`
+}
+
+// New returns an empty Tracker.
+func New() *Tracker {
+	return &Tracker{
+		calls:      map[string]int{},
+		rateLimits: map[string]RateLimit{},
+	}
+}
+
+// RoundTripper returns a http.RoundTripper that records the calls made
+// through next.
+func (t *Tracker) RoundTripper(next http.RoundTripper) http.RoundTripper {
+	if t == nil {
+		return next
+	}
+	return roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		resp, err := next.RoundTrip(req)
+		t.record(req, resp)
+		return resp, err
+	})
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func (t *Tracker) record(req *http.Request, resp *http.Response) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.calls[req.Method+" "+Endpoint(req.URL.Path)]++
+
+	if resp == nil {
+		return
+	}
+	limit, err := strconv.Atoi(resp.Header.Get("X-RateLimit-Limit"))
+	if err != nil {
+		return
+	}
+	remaining, _ := strconv.Atoi(resp.Header.Get("X-RateLimit-Remaining"))
+	reset, _ := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64)
+	resource := resp.Header.Get("X-RateLimit-Resource")
+	if resource == "" {
+		resource = "core"
+	}
+	t.rateLimits[resource] = RateLimit{
+		Limit:     limit,
+		Remaining: remaining,
+		Reset:     time.Unix(reset, 0),
+	}
+}
+
+var (
+	numberRe = regexp.MustCompile(`^[0-9]+$`)
+	shaRe    = regexp.MustCompile(`^[0-9a-f]{40}$`)
+)
+
+// Endpoint returns the given API path with its variable parts replaced by
+// placeholders so that the calls to the same endpoint are grouped together.
+func Endpoint(path string) string {
+	path = strings.TrimPrefix(path, "/api/v3")
+	parts := strings.Split(path, "/")
+	for i, part := range parts {
+		switch {
+		case i > 0 && (parts[i-1] == "repos" || parts[i-1] == "orgs" || parts[i-1] == "users"):
+			parts[i] = ":owner"
+		case i > 1 && parts[i-2] == "repos":
+			parts[i] = ":repo"
+		case numberRe.MatchString(part):
+			parts[i] = ":number"
+		case shaRe.MatchString(part):
+			parts[i] = ":sha"
+		}
+	}
+	return strings.Join(parts, "/")
+}
+
+// Phase starts the given phase of a run and returns the function that ends
+// it.
+func (t *Tracker) Phase(name string) (end func()) {
+	if t == nil {
+		return func() {}
+	}
+	start := time.Now()
+	return func() {
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		t.phases = append(t.phases, Phase{Name: name, Duration: time.Since(start)})
+	}
+}
+
+// Report returns the usage recorded so far.
+func (t *Tracker) Report() Report {
+	r := Report{
+		Calls:      map[string]int{},
+		RateLimits: map[string]RateLimit{},
+	}
+	if t == nil {
+		return r
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for endpoint, n := range t.calls {
+		r.Calls[endpoint] = n
+		if strings.HasSuffix(endpoint, " /graphql") {
+			r.GraphQLCalls += n
+		} else {
+			r.RESTCalls += n
+		}
+	}
+	r.Phases = append(r.Phases, t.phases...)
+	for resource, rl := range t.rateLimits {
+		r.RateLimits[resource] = rl
+	}
+	return r
+}
+
+// Print writes the report in a human readable format.
+func (r Report) Print(w io.Writer) {
+	fmt.Fprintf(w, "API calls: %d REST, %d GraphQL\n", r.RESTCalls, r.GraphQLCalls)
+	endpoints := make([]string, 0, len(r.Calls))
+	for endpoint := range r.Calls {
+		endpoints = append(endpoints, endpoint)
+	}
+	sort.Slice(endpoints, func(i, j int) bool {
+		if r.Calls[endpoints[i]] != r.Calls[endpoints[j]] {
+			return r.Calls[endpoints[i]] > r.Calls[endpoints[j]]
+		}
+		return endpoints[i] < endpoints[j]
+	})
+	for _, endpoint := range endpoints {
+		fmt.Fprintf(w, "  %6d %s\n", r.Calls[endpoint], endpoint)
+	}
+	if len(r.Phases) != 0 {
+		fmt.Fprintf(w, "Time spent:\n")
+		for _, phase := range r.Phases {
+			fmt.Fprintf(w, "  %-20s %s\n", phase.Name, phase.Duration.Round(time.Millisecond))
+		}
+	}
+	if len(r.RateLimits) != 0 {
+		fmt.Fprintf(w, "Remaining rate limit:\n")
+		resources := make([]string, 0, len(r.RateLimits))
+		for resource := range r.RateLimits {
+			resources = append(resources, resource)
+		}
+		sort.Strings(resources)
+		for _, resource := range resources {
+			rl := r.RateLimits[resource]
+			fmt.Fprintf(w, "  %-20s %d/%d (resets at %s)\n", resource, rl.Remaining, rl.Limit, rl.Reset.Format(time.RFC3339))
+		}
+	}
+}
+
+// WriteFile writes the report as JSON into the given file.
+func (r Report) WriteFile(file string) error {
+	b, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(file, append(b, '\n'), 0644)
+}
@@ -0,0 +1,94 @@
+// Copyright 2021 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package usage
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestEndpoint(t *testing.T) {
+	tests := []struct {
+		path string
+		want string
+	}{
+		{
+			path: "/repos/cilium/cilium/pulls/123",
+			want: "/repos/:owner/:repo/pulls/:number",
+		},
+		{
+			path: "/repos/cilium/cilium/commits/0123456789abcdef0123456789abcdef01234567/pulls",
+			want: "/repos/:owner/:repo/commits/:sha/pulls",
+		},
+		{
+			path: "/api/v3/repos/cilium/cilium/compare/v1.13.0...v1.13.1",
+			want: "/repos/:owner/:repo/compare/v1.13.0...v1.13.1",
+		},
+		{
+			path: "/projects/columns/42/cards",
+			want: "/projects/columns/:number/cards",
+		},
+		{
+			path: "/graphql",
+			want: "/graphql",
+		},
+	}
+	for _, tt := range tests {
+		if got := Endpoint(tt.path); got != tt.want {
+			t.Errorf("Endpoint(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestTracker(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-RateLimit-Limit", "5000")
+		w.Header().Set("X-RateLimit-Remaining", "4990")
+		w.Header().Set("X-RateLimit-Reset", "1700000000")
+		if r.URL.Path == "/graphql" {
+			w.Header().Set("X-RateLimit-Resource", "graphql")
+		}
+	}))
+	defer srv.Close()
+
+	tracker := New()
+	client := &http.Client{Transport: tracker.RoundTripper(http.DefaultTransport)}
+	for _, path := range []string{"/repos/cilium/cilium/pulls/1", "/repos/cilium/cilium/pulls/2", "/graphql"} {
+		resp, err := client.Get(srv.URL + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+	}
+	tracker.Phase("test")()
+
+	r := tracker.Report()
+	if r.RESTCalls != 2 || r.GraphQLCalls != 1 {
+		t.Errorf("got %d REST and %d GraphQL calls, want 2 and 1", r.RESTCalls, r.GraphQLCalls)
+	}
+	if n := r.Calls["GET /repos/:owner/:repo/pulls/:number"]; n != 2 {
+		t.Errorf("got %d calls to pulls, want 2", n)
+	}
+	if rl := r.RateLimits["graphql"]; rl.Remaining != 4990 || rl.Limit != 5000 {
+		t.Errorf("unexpected graphql rate limit %+v", rl)
+	}
+	if _, ok := r.RateLimits["core"]; !ok {
+		t.Errorf("missing core rate limit")
+	}
+	if len(r.Phases) != 1 || r.Phases[0].Name != "test" {
+		t.Errorf("unexpected phases %+v", r.Phases)
+	}
+}
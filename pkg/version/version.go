@@ -0,0 +1,174 @@
+// Copyright 2021 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package version
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Version is a parsed release version such as 'v1.14.2' or '1.14.0-rc.1'.
+type Version struct {
+	Major int
+	Minor int
+	Patch int
+	// Pre is the pre-release suffix without the leading dash, e.g. 'rc.1'.
+	Pre string
+}
+
+// Parse parses a version with or without the leading 'v'. The patch number
+// is optional, so '1.14' and 'v1.14' are valid versions.
+func Parse(s string) (Version, error) {
+	var v Version
+	str := strings.TrimPrefix(strings.TrimSpace(s), "v")
+	if idx := strings.Index(str, "-"); idx != -1 {
+		v.Pre = str[idx+1:]
+		str = str[:idx]
+	}
+	parts := strings.Split(str, ".")
+	if len(parts) < 2 || len(parts) > 3 {
+		return Version{}, fmt.Errorf("invalid version %q", s)
+	}
+	nums := make([]int, 3)
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 {
+			return Version{}, fmt.Errorf("invalid version %q", s)
+		}
+		nums[i] = n
+	}
+	v.Major, v.Minor, v.Patch = nums[0], nums[1], nums[2]
+	return v, nil
+}
+
+// MinorString returns the 'x.y' representation of the version.
+func (v Version) MinorString() string {
+	return fmt.Sprintf("%d.%d", v.Major, v.Minor)
+}
+
+// String returns the 'x.y.z[-pre]' representation of the version.
+func (v Version) String() string {
+	s := fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+	if len(v.Pre) != 0 {
+		s += "-" + v.Pre
+	}
+	return s
+}
+
+// IsPrerelease returns true if the version has a pre-release suffix.
+func (v Version) IsPrerelease() bool {
+	return len(v.Pre) != 0
+}
+
+// Compare returns -1, 0 or 1 if v is respectively lower, equal or greater
+// than o. A pre-release is lower than its final version and pre-releases are
+// ordered as in semver, e.g. 'rc.2' < 'rc.10'.
+func (v Version) Compare(o Version) int {
+	for _, d := range []int{v.Major - o.Major, v.Minor - o.Minor, v.Patch - o.Patch} {
+		if d < 0 {
+			return -1
+		}
+		if d > 0 {
+			return 1
+		}
+	}
+	switch {
+	case v.Pre == o.Pre:
+		return 0
+	case len(v.Pre) == 0:
+		return 1
+	case len(o.Pre) == 0:
+		return -1
+	}
+	return comparePre(v.Pre, o.Pre)
+}
+
+// comparePre compares two pre-release suffixes identifier by identifier:
+// numeric identifiers numerically and lower than alphanumeric ones, the
+// others lexically, a shorter suffix being lower if all its identifiers are
+// equal.
+func comparePre(a, b string) int {
+	as, bs := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(as) && i < len(bs); i++ {
+		an, aErr := strconv.Atoi(as[i])
+		bn, bErr := strconv.Atoi(bs[i])
+		switch {
+		case aErr == nil && bErr == nil:
+			if an != bn {
+				if an < bn {
+					return -1
+				}
+				return 1
+			}
+		case aErr == nil:
+			return -1
+		case bErr == nil:
+			return 1
+		case as[i] != bs[i]:
+			if as[i] < bs[i] {
+				return -1
+			}
+			return 1
+		}
+	}
+	switch {
+	case len(as) < len(bs):
+		return -1
+	case len(as) > len(bs):
+		return 1
+	}
+	return 0
+}
+
+// SameMinor returns true if both versions belong to the same 'x.y' series.
+func (v Version) SameMinor(o Version) bool {
+	return v.Major == o.Major && v.Minor == o.Minor
+}
+
+// Series is a 'x.y' release series, or all the series up to and including
+// it if UpTo is set.
+type Series struct {
+	Version
+	UpTo bool
+}
+
+// ParseSeries parses a series of the format 'x.y', or '<=x.y' for all the
+// series up to and including 'x.y'.
+func ParseSeries(s string) (Series, error) {
+	str := strings.TrimSpace(s)
+	upTo := strings.HasPrefix(str, "<=")
+	v, err := Parse(strings.TrimPrefix(str, "<="))
+	if err != nil || v.MinorString() != strings.TrimPrefix(strings.TrimPrefix(str, "<="), "v") {
+		return Series{}, fmt.Errorf("invalid series %q", s)
+	}
+	return Series{Version: v, UpTo: upTo}, nil
+}
+
+// Contains returns true if the version belongs to the series.
+func (s Series) Contains(v Version) bool {
+	if s.UpTo {
+		return v.Major < s.Major || (v.Major == s.Major && v.Minor <= s.Minor)
+	}
+	return v.SameMinor(s.Version)
+}
+
+// String returns the 'x.y' or '<=x.y' representation of the series.
+func (s Series) String() string {
+	if s.UpTo {
+		return "<=" + s.MinorString()
+	}
+	return s.MinorString()
+}
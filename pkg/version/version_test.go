@@ -0,0 +1,123 @@
+// Copyright 2021 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package version
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		version string
+		want    Version
+		wantErr bool
+	}{
+		{
+			name:    "patch release",
+			version: "v1.14.2",
+			want:    Version{Major: 1, Minor: 14, Patch: 2},
+		},
+		{
+			name:    "pre-release without prefix",
+			version: "1.14.0-rc.1",
+			want:    Version{Major: 1, Minor: 14, Pre: "rc.1"},
+		},
+		{
+			name:    "minor only",
+			version: "1.13",
+			want:    Version{Major: 1, Minor: 13},
+		},
+		{
+			name:    "not a version",
+			version: "main",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse(tt.version)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Parse() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Parse() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompare(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{a: "1.14.0", b: "1.14.0", want: 0},
+		{a: "1.14.0-rc.1", b: "1.14.0", want: -1},
+		{a: "1.14.0-rc.2", b: "1.14.0-rc.1", want: 1},
+		{a: "1.14.0-rc.10", b: "1.14.0-rc.2", want: 1},
+		{a: "1.14.0-pre.3", b: "1.14.0-rc.1", want: -1},
+		{a: "1.14.0-rc", b: "1.14.0-rc.1", want: -1},
+		{a: "1.14.0-rc.1", b: "1.14.0-rc.a", want: -1},
+		{a: "1.9.10", b: "1.10.0", want: -1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.a+" "+tt.b, func(t *testing.T) {
+			a, _ := Parse(tt.a)
+			b, _ := Parse(tt.b)
+			if got := a.Compare(b); got != tt.want {
+				t.Errorf("Compare() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSeriesContains(t *testing.T) {
+	tests := []struct {
+		series, version string
+		want            bool
+	}{
+		{series: "1.1", version: "1.1", want: true},
+		{series: "1.1", version: "1.10", want: false},
+		{series: "1.1", version: "1.11.2", want: false},
+		{series: "<=1.13", version: "1.12", want: true},
+		{series: "<=1.13", version: "1.13", want: true},
+		{series: "<=1.13", version: "1.14", want: false},
+		{series: "<=1.13", version: "0.99", want: true},
+		{series: "<=1.13", version: "2.0", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.series+" "+tt.version, func(t *testing.T) {
+			s, err := ParseSeries(tt.series)
+			if err != nil {
+				t.Fatal(err)
+			}
+			v, _ := Parse(tt.version)
+			if got := s.Contains(v); got != tt.want {
+				t.Errorf("Contains() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseSeriesInvalid(t *testing.T) {
+	for _, s := range []string{"1", "1.13.2", "<1.13", "=<1.13", "1.x"} {
+		if _, err := ParseSeries(s); err == nil {
+			t.Errorf("ParseSeries(%q) succeeded, want error", s)
+		}
+	}
+}